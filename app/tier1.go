@@ -17,6 +17,9 @@ import (
 	"github.com/streamingfast/substreams/metrics"
 	"github.com/streamingfast/substreams/pipeline"
 	"github.com/streamingfast/substreams/service"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/gc"
+	"github.com/streamingfast/substreams/storage/store"
 	"github.com/streamingfast/substreams/wasm"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
@@ -43,10 +46,23 @@ type Tier1Config struct {
 	StateBundleSize      uint64
 	BlockType            string
 
-	MaxSubrequests       uint64
-	SubrequestsEndpoint  string
-	SubrequestsInsecure  bool
-	SubrequestsPlaintext bool
+	StoreDiskBackendDir    string // if set, stores keep their state on disk under this local directory instead of in memory
+	StoreMemtableSizeLimit uint64 // bytes of recent writes kept in memory by stores on disk, 0 for the default
+	StoreSnapshotFormat    string // format of written store snapshots, "proto" (default) or "sorted"
+	OutputCacheFormat      string // format of written module output files, "proto" (default) or "framed"
+
+	CacheGCInterval                  time.Duration // if set, the retention rules below are applied to the state store at this interval
+	CacheRetentionMaxAge             time.Duration // delete the module hashes not accessed for longer, 0 to disable
+	CacheRetentionMaxTotalSize       uint64        // delete the least recently accessed module hashes until the state store holds at most this many bytes, 0 to disable
+	CacheRetentionKeepEveryNthFullKV uint64        // only keep the full store snapshots ending every N bundles, and the latest one, 0 to disable
+
+	MaxSubrequests              uint64
+	Tier2Capacity               uint64             // if set, the tier2 worker slots shared by the jobs of all requests, leased by fair share between users
+	Tier2CapacityWeights        map[string]float64 // share of the tier2 worker slots of users, by user ID, relative to others (1 by default)
+	AdaptiveSegmentsJobDuration time.Duration      // if set, segments span as many bundles as their modules are expected to process in this duration
+	SubrequestsEndpoint         string
+	SubrequestsInsecure         bool
+	SubrequestsPlaintext        bool
 
 	WASMExtensions  []wasm.WASMExtensioner
 	PipelineOptions []pipeline.PipelineOptioner
@@ -158,6 +174,43 @@ func (a *Tier1App) Run() error {
 		opts = append(opts, service.WithModuleExecutionTracing())
 	}
 
+	if a.config.StoreDiskBackendDir != "" {
+		opts = append(opts, service.WithStoreDiskBackend(a.config.StoreDiskBackendDir, a.config.StoreMemtableSizeLimit))
+	}
+
+	if a.config.StoreSnapshotFormat != "" {
+		format, err := store.ParseSnapshotFormat(a.config.StoreSnapshotFormat)
+		if err != nil {
+			return fmt.Errorf("invalid store snapshot format: %w", err)
+		}
+		opts = append(opts, service.WithStoreSnapshotFormat(format))
+	}
+
+	if a.config.OutputCacheFormat != "" {
+		format, err := execout.ParseFileFormat(a.config.OutputCacheFormat)
+		if err != nil {
+			return fmt.Errorf("invalid output cache format: %w", err)
+		}
+		opts = append(opts, service.WithOutputCacheFormat(format))
+	}
+
+	if a.config.CacheGCInterval != 0 {
+		opts = append(opts, service.WithCacheGC(gc.Rules{
+			MaxAge:             a.config.CacheRetentionMaxAge,
+			MaxTotalSize:       a.config.CacheRetentionMaxTotalSize,
+			MinAge:             gc.DefaultAccessRecordInterval,
+			KeepEveryNthFullKV: a.config.CacheRetentionKeepEveryNthFullKV,
+		}, a.config.CacheGCInterval))
+	}
+
+	if a.config.Tier2Capacity != 0 {
+		opts = append(opts, service.WithTier2Capacity(int(a.config.Tier2Capacity), a.config.Tier2CapacityWeights))
+	}
+
+	if a.config.AdaptiveSegmentsJobDuration != 0 {
+		opts = append(opts, service.WithAdaptiveSegments(a.config.AdaptiveSegmentsJobDuration))
+	}
+
 	svc := service.NewTier1(
 		a.logger,
 		mergedBlocksStore,
@@ -218,5 +271,8 @@ func (a *Tier1App) IsReady(ctx context.Context) bool {
 // Validate inspects itself to determine if the current config is valid according to
 // substreams rules.
 func (config *Tier1Config) Validate() error {
+	if config.CacheGCInterval != 0 && config.CacheRetentionMaxAge == 0 && config.CacheRetentionMaxTotalSize == 0 && config.CacheRetentionKeepEveryNthFullKV <= 1 {
+		return fmt.Errorf("cache garbage collection enabled without any retention rule")
+	}
 	return nil
 }
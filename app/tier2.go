@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"time"
 
 	dauth "github.com/streamingfast/dauth"
 	"github.com/streamingfast/dmetrics"
@@ -12,6 +13,8 @@ import (
 	"github.com/streamingfast/substreams/metrics"
 	"github.com/streamingfast/substreams/pipeline"
 	"github.com/streamingfast/substreams/service"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/store"
 	"github.com/streamingfast/substreams/wasm"
 	"go.uber.org/atomic"
 	"go.uber.org/zap"
@@ -27,6 +30,13 @@ type Tier2Config struct {
 	StateBundleSize      uint64
 	BlockType            string
 
+	StoreDiskBackendDir    string // if set, stores keep their state on disk under this local directory instead of in memory
+	StoreMemtableSizeLimit uint64 // bytes of recent writes kept in memory by stores on disk, 0 for the default
+	StoreSnapshotFormat    string // format of written store snapshots, "proto" (default) or "sorted"
+	OutputCacheFormat      string // format of written module output files, "proto" (default) or "framed"
+
+	JobCheckpointInterval time.Duration // if set, jobs save a checkpoint this often, for a retry to resume from it
+
 	WASMExtensions  []wasm.WASMExtensioner
 	PipelineOptions []pipeline.PipelineOptioner
 
@@ -81,6 +91,30 @@ func (a *Tier2App) Run() error {
 		opts = append(opts, service.WithModuleExecutionTracing())
 	}
 
+	if a.config.StoreDiskBackendDir != "" {
+		opts = append(opts, service.WithStoreDiskBackend(a.config.StoreDiskBackendDir, a.config.StoreMemtableSizeLimit))
+	}
+
+	if a.config.StoreSnapshotFormat != "" {
+		format, err := store.ParseSnapshotFormat(a.config.StoreSnapshotFormat)
+		if err != nil {
+			return fmt.Errorf("invalid store snapshot format: %w", err)
+		}
+		opts = append(opts, service.WithStoreSnapshotFormat(format))
+	}
+
+	if a.config.OutputCacheFormat != "" {
+		format, err := execout.ParseFileFormat(a.config.OutputCacheFormat)
+		if err != nil {
+			return fmt.Errorf("invalid output cache format: %w", err)
+		}
+		opts = append(opts, service.WithOutputCacheFormat(format))
+	}
+
+	if a.config.JobCheckpointInterval != 0 {
+		opts = append(opts, service.WithJobCheckpoints(a.config.JobCheckpointInterval))
+	}
+
 	svc := service.NewTier2(
 		a.logger,
 		mergedBlocksStore,
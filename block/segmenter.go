@@ -4,10 +4,21 @@ package block
 // the caller can always keep track of just one number, and we can obtain the corresponding
 // Range for the segment. We can obtain info on the Segment too (if it's Partial, Complete, etc..)
 
+import (
+	"fmt"
+	"sort"
+)
+
 type Segmenter struct {
 	interval          uint64
 	initialBlock      uint64
 	exclusiveEndBlock uint64
+
+	// bounds, when set, are the start blocks of segments spanning one or
+	// more intervals, from bounds[0] to the last bound. Segments before the
+	// first bound and after the last one span a single interval, so a
+	// segment index keeps meaning the same blocks outside of the bounds.
+	bounds []uint64
 }
 
 func NewSegmenter(interval uint64, initialBlock uint64, exclusiveEndBlock uint64) *Segmenter {
@@ -19,6 +30,27 @@ func NewSegmenter(interval uint64, initialBlock uint64, exclusiveEndBlock uint64
 	return s
 }
 
+// WithBounds returns a Segmenter of variable-width segments starting at
+// `bounds`, which must be increasing multiples of the interval. Segments
+// always start and end on intervals, except for the first and the last,
+// cut by the initial and exclusive end blocks.
+func (s *Segmenter) WithBounds(bounds []uint64) *Segmenter {
+	for i, bound := range bounds {
+		if bound%s.interval != 0 || (i > 0 && bound <= bounds[i-1]) {
+			panic(fmt.Sprintf("invalid segment bounds %v for interval %d", bounds, s.interval))
+		}
+	}
+	out := NewSegmenter(s.interval, s.initialBlock, s.exclusiveEndBlock)
+	if len(bounds) != 0 {
+		out.bounds = bounds
+	}
+	return out
+}
+
+func (s *Segmenter) Interval() uint64 {
+	return s.interval
+}
+
 func (s *Segmenter) InitialBlock() uint64 {
 	return s.initialBlock
 }
@@ -28,11 +60,15 @@ func (s *Segmenter) ExclusiveEndBlock() uint64 {
 }
 
 func (s *Segmenter) WithInitialBlock(newInitialBlock uint64) *Segmenter {
-	return NewSegmenter(s.interval, newInitialBlock, s.exclusiveEndBlock)
+	out := NewSegmenter(s.interval, newInitialBlock, s.exclusiveEndBlock)
+	out.bounds = s.bounds
+	return out
 }
 
 func (s *Segmenter) WithExclusiveEndBlock(newExclusiveEndBlock uint64) *Segmenter {
-	return NewSegmenter(s.interval, s.initialBlock, newExclusiveEndBlock)
+	out := NewSegmenter(s.interval, s.initialBlock, newExclusiveEndBlock)
+	out.bounds = s.bounds
+	return out
 }
 
 // Count returns the number of valid segments for the internal range.
@@ -42,13 +78,42 @@ func (s *Segmenter) Count() int {
 }
 
 func (s *Segmenter) FirstIndex() int {
-	initSegment := s.initialBlock / s.interval
-	return int(initSegment)
+	return s.index(s.initialBlock)
 }
 
 func (s *Segmenter) LastIndex() int {
-	lastSegment := (s.exclusiveEndBlock - 1) / s.interval
-	return int(lastSegment)
+	return s.index(s.exclusiveEndBlock - 1)
+}
+
+// index returns the index of the segment containing `blockNum`.
+func (s *Segmenter) index(blockNum uint64) int {
+	if len(s.bounds) == 0 || blockNum < s.bounds[0] {
+		return int(blockNum / s.interval)
+	}
+	firstBoundIndex := int(s.bounds[0] / s.interval)
+	last := len(s.bounds) - 1
+	if blockNum >= s.bounds[last] {
+		return firstBoundIndex + last + int((blockNum-s.bounds[last])/s.interval)
+	}
+	pos := sort.Search(len(s.bounds), func(i int) bool { return s.bounds[i] > blockNum }) - 1
+	return firstBoundIndex + pos
+}
+
+// segmentStart returns the start block of the segment `idx`, ignoring the
+// initial block.
+func (s *Segmenter) segmentStart(idx int) uint64 {
+	if len(s.bounds) == 0 {
+		return uint64(idx) * s.interval
+	}
+	firstBoundIndex := int(s.bounds[0] / s.interval)
+	if idx < firstBoundIndex {
+		return uint64(idx) * s.interval
+	}
+	last := len(s.bounds) - 1
+	if pos := idx - firstBoundIndex; pos <= last {
+		return s.bounds[pos]
+	}
+	return s.bounds[last] + uint64(idx-firstBoundIndex-last)*s.interval
 }
 
 func (s *Segmenter) Range(idx int) *Range {
@@ -66,8 +131,7 @@ func (s *Segmenter) firstRange() *Range {
 	if s.exclusiveEndBlock != 0 && s.exclusiveEndBlock < s.initialBlock {
 		return nil
 	}
-	floorLowerBound := s.initialBlock - s.initialBlock%s.interval
-	upperBound := floorLowerBound + s.interval
+	upperBound := s.segmentStart(s.FirstIndex() + 1)
 	return NewRange(s.initialBlock, min(upperBound, s.exclusiveEndBlock))
 }
 
@@ -75,17 +139,33 @@ func (s *Segmenter) followingRange(idx int) *Range {
 	if idx > s.LastIndex() {
 		return nil
 	}
-	baseBlock := uint64(idx) * s.interval
-	upperBound := baseBlock + s.interval
+	baseBlock := s.segmentStart(idx)
+	upperBound := s.segmentStart(idx + 1)
 	return NewRange(baseBlock, min(upperBound, s.exclusiveEndBlock))
 }
 
+// IntervalRanges returns the range of the segment `idx` split on intervals,
+// for which the files are written.
+func (s *Segmenter) IntervalRanges(idx int) (out []*Range) {
+	rng := s.Range(idx)
+	if rng == nil {
+		return nil
+	}
+	start := rng.StartBlock
+	for start < rng.ExclusiveEndBlock {
+		end := min(start-start%s.interval+s.interval, rng.ExclusiveEndBlock)
+		out = append(out, NewRange(start, end))
+		start = end
+	}
+	return out
+}
+
 func (s *Segmenter) IndexForStartBlock(blockNum uint64) int {
-	return int(blockNum / s.interval)
+	return s.index(blockNum)
 }
 
 func (s *Segmenter) IndexForEndBlock(blockNum uint64) int {
-	return int((blockNum - 1) / s.interval) /* exclusive of the given blockNum */
+	return s.index(blockNum - 1) /* exclusive of the given blockNum */
 }
 
 func (s *Segmenter) EndsOnInterval(segmentIndex int) bool {
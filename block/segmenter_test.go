@@ -135,3 +135,40 @@ func TestSegmenter_Range(t *testing.T) {
 	assert.True(t, s.EndsOnInterval(1))
 
 }
+
+func TestSegmenter_WithBounds(t *testing.T) {
+	// segments: ..., 10-20, 20-50, 50-60, 60-90, 90-100, ...
+	s := NewSegmenter(10, 15, 95).WithBounds([]uint64{20, 50, 60, 90})
+	assert.Equal(t, 1, s.FirstIndex())
+	assert.Equal(t, 5, s.LastIndex())
+	assert.Equal(t, 5, s.Count())
+	assert.Equal(t, ParseRange("15-20"), s.Range(1))
+	assert.Equal(t, ParseRange("20-50"), s.Range(2))
+	assert.Equal(t, ParseRange("50-60"), s.Range(3))
+	assert.Equal(t, ParseRange("60-90"), s.Range(4))
+	assert.Equal(t, ParseRange("90-95"), s.Range(5))
+	assert.Nil(t, s.Range(6))
+	assert.True(t, s.EndsOnInterval(2))
+	assert.False(t, s.EndsOnInterval(5))
+
+	assert.Equal(t, 1, s.IndexForStartBlock(19))
+	assert.Equal(t, 2, s.IndexForStartBlock(20))
+	assert.Equal(t, 2, s.IndexForStartBlock(49))
+	assert.Equal(t, 3, s.IndexForStartBlock(50))
+	assert.Equal(t, 4, s.IndexForStartBlock(89))
+	assert.Equal(t, 5, s.IndexForStartBlock(90))
+	assert.Equal(t, 6, s.IndexForStartBlock(100))
+	assert.Equal(t, 2, s.IndexForEndBlock(50))
+	assert.Equal(t, 3, s.IndexForEndBlock(51))
+
+	assert.Equal(t, []*Range{ParseRange("20-30"), ParseRange("30-40"), ParseRange("40-50")}, s.IntervalRanges(2))
+	assert.Equal(t, []*Range{ParseRange("15-20")}, s.IntervalRanges(1))
+
+	mod := s.WithInitialBlock(35)
+	assert.Equal(t, 2, mod.FirstIndex())
+	assert.Equal(t, ParseRange("35-50"), mod.Range(2))
+	assert.Equal(t, []*Range{ParseRange("35-40"), ParseRange("40-50")}, mod.IntervalRanges(2))
+
+	assert.Panics(t, func() { NewSegmenter(10, 0, 100).WithBounds([]uint64{20, 25}) })
+	assert.Panics(t, func() { NewSegmenter(10, 0, 100).WithBounds([]uint64{20, 20}) })
+}
@@ -0,0 +1,46 @@
+// Package clienttest provides a fake of the `sf.substreams.rpc.v2.Stream`
+// client, for the tests of the consumers built on the client package.
+package clienttest
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+)
+
+// StreamClient streams the responses of `Streams`, one per call, each ending
+// with its error in `Errs`, io.EOF if nil. The start cursor of each call is
+// recorded in `Cursors`.
+type StreamClient struct {
+	Streams [][]*pbsubstreamsrpc.Response
+	Errs    []error
+	Cursors []string
+}
+
+func (c *StreamClient) Blocks(ctx context.Context, in *pbsubstreamsrpc.Request, opts ...grpc.CallOption) (pbsubstreamsrpc.Stream_BlocksClient, error) {
+	call := len(c.Cursors)
+	c.Cursors = append(c.Cursors, in.StartCursor)
+	err := c.Errs[call]
+	if err == nil {
+		err = io.EOF
+	}
+	return &blocksClient{responses: c.Streams[call], err: err}, nil
+}
+
+type blocksClient struct {
+	grpc.ClientStream
+	responses []*pbsubstreamsrpc.Response
+	err       error
+}
+
+func (s *blocksClient) Recv() (*pbsubstreamsrpc.Response, error) {
+	if len(s.responses) == 0 {
+		return nil, s.err
+	}
+	resp := s.responses[0]
+	s.responses = s.responses[1:]
+	return resp, nil
+}
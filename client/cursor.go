@@ -0,0 +1,168 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// CursorStore persists the cursor of a Stream, from which it resumes when
+// restarted.
+type CursorStore interface {
+	// Load returns the cursor last saved, empty if none.
+	Load(ctx context.Context) (string, error)
+	// Save persists `cursor`, the cursor of `block`.
+	Save(ctx context.Context, cursor string, block *pbsubstreams.BlockRef) error
+}
+
+// MemoryCursorStore keeps the cursor in memory, for streams that must not
+// resume from where a previous process stopped.
+type MemoryCursorStore struct {
+	mu     sync.Mutex
+	cursor string
+	block  *pbsubstreams.BlockRef
+}
+
+func NewMemoryCursorStore() *MemoryCursorStore {
+	return &MemoryCursorStore{}
+}
+
+func (s *MemoryCursorStore) Load(context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cursor, nil
+}
+
+func (s *MemoryCursorStore) Save(_ context.Context, cursor string, block *pbsubstreams.BlockRef) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cursor = cursor
+	s.block = block
+	return nil
+}
+
+// Block returns the block of the cursor last saved, nil if none.
+func (s *MemoryCursorStore) Block() *pbsubstreams.BlockRef {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.block
+}
+
+// FileCursorStore keeps the cursor in a JSON file, replaced atomically on
+// each save.
+type FileCursorStore struct {
+	path string
+}
+
+func NewFileCursorStore(path string) *FileCursorStore {
+	return &FileCursorStore{path: path}
+}
+
+type fileCursor struct {
+	Cursor   string `json:"cursor"`
+	BlockNum uint64 `json:"block_num"`
+	BlockID  string `json:"block_id"`
+}
+
+func (s *FileCursorStore) Load(context.Context) (string, error) {
+	cnt, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading cursor file: %w", err)
+	}
+
+	var cursor fileCursor
+	if err := json.Unmarshal(cnt, &cursor); err != nil {
+		return "", fmt.Errorf("decoding cursor file %q: %w", s.path, err)
+	}
+	return cursor.Cursor, nil
+}
+
+func (s *FileCursorStore) Save(_ context.Context, cursor string, block *pbsubstreams.BlockRef) error {
+	cnt, err := json.Marshal(&fileCursor{Cursor: cursor, BlockNum: block.GetNumber(), BlockID: block.GetId()})
+	if err != nil {
+		return err
+	}
+
+	// written next to the file then renamed, so that a crash never leaves a
+	// partially written cursor
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("creating cursor file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(cnt); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing cursor file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing cursor file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing cursor file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("replacing cursor file: %w", err)
+	}
+	return nil
+}
+
+// SQLCursorStore keeps the cursor in the row `id` of the `substreams_cursors`
+// table of a Postgres or SQLite database, the table used by `substreams sink
+// run`.
+type SQLCursorStore struct {
+	db *sql.DB
+	id string
+}
+
+// SQLCursorsTableDDL creates the `substreams_cursors` table, shared by the
+// SQLCursorStore and the loader of `substreams sink run`.
+const SQLCursorsTableDDL = `CREATE TABLE IF NOT EXISTS substreams_cursors (
+	id TEXT NOT NULL PRIMARY KEY,
+	cursor TEXT NOT NULL,
+	block_num BIGINT NOT NULL,
+	block_id TEXT NOT NULL
+)`
+
+// NewSQLCursorStore returns a SQLCursorStore of the cursor `id`, creating
+// the `substreams_cursors` table if it does not exist.
+func NewSQLCursorStore(ctx context.Context, db *sql.DB, id string) (*SQLCursorStore, error) {
+	if _, err := db.ExecContext(ctx, SQLCursorsTableDDL); err != nil {
+		return nil, fmt.Errorf("creating cursors table: %w", err)
+	}
+	return &SQLCursorStore{db: db, id: id}, nil
+}
+
+func (s *SQLCursorStore) Load(ctx context.Context) (string, error) {
+	var cursor string
+	err := s.db.QueryRowContext(ctx, `SELECT cursor FROM substreams_cursors WHERE id = $1`, s.id).Scan(&cursor)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading cursor: %w", err)
+	}
+	return cursor, nil
+}
+
+func (s *SQLCursorStore) Save(ctx context.Context, cursor string, block *pbsubstreams.BlockRef) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO substreams_cursors (id, cursor, block_num, block_id) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET cursor = excluded.cursor, block_num = excluded.block_num, block_id = excluded.block_id`,
+		s.id, cursor, block.GetNumber(), block.GetId(),
+	)
+	if err != nil {
+		return fmt.Errorf("saving cursor: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func testCursorStore(t *testing.T, store CursorStore) {
+	ctx := context.Background()
+
+	cursor, err := store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", cursor)
+
+	require.NoError(t, store.Save(ctx, "c1", &pbsubstreams.BlockRef{Number: 1, Id: "id1"}))
+	require.NoError(t, store.Save(ctx, "c2", &pbsubstreams.BlockRef{Number: 2, Id: "id2"}))
+	cursor, err = store.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "c2", cursor)
+}
+
+func TestFileCursorStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cursor.json")
+	testCursorStore(t, NewFileCursorStore(path))
+
+	cursor, err := NewFileCursorStore(path).Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "c2", cursor)
+	matches, err := filepath.Glob(path + ".tmp-*")
+	require.NoError(t, err)
+	assert.Empty(t, matches)
+}
+
+func TestSQLCursorStore(t *testing.T) {
+	ctx := context.Background()
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	db.SetMaxOpenConns(1)
+	defer db.Close()
+
+	store, err := NewSQLCursorStore(ctx, db, "abc")
+	require.NoError(t, err)
+	testCursorStore(t, store)
+
+	// cursors of other ids are kept apart
+	other, err := NewSQLCursorStore(ctx, db, "def")
+	require.NoError(t, err)
+	cursor, err := other.Load(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", cursor)
+
+	var blockNum uint64
+	require.NoError(t, db.QueryRow(`SELECT block_num FROM substreams_cursors WHERE id = 'abc'`).Scan(&blockNum))
+	assert.Equal(t, uint64(2), blockNum)
+}
@@ -0,0 +1,231 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+const (
+	// DefaultMaxRetries is the number of times in a row a Stream is
+	// reconnected after a failure before giving up.
+	DefaultMaxRetries = 15
+
+	maxBackoff = 30 * time.Second
+)
+
+// Stream consumes a stream of `Blocks` on behalf of callbacks, reconnecting
+// after transient failures. The cursor of a block is saved to its
+// CursorStore once the callbacks have handled the block successfully, and
+// the stream resumes from the cursor saved, both when reconnecting and when
+// restarted.
+type Stream struct {
+	client   pbsubstreamsrpc.StreamClient
+	callOpts []grpc.CallOption
+	request  *pbsubstreamsrpc.Request
+	cursors  CursorStore
+
+	onData     func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData) error
+	onUndo     func(ctx context.Context, undo *pbsubstreamsrpc.BlockUndoSignal) error
+	onProgress func(ctx context.Context, progress *pbsubstreamsrpc.ModulesProgress) error
+
+	maxRetries int
+	logger     *zap.Logger
+}
+
+type StreamOption func(*Stream)
+
+// WithOnData sets the callback handling the outputs of each block.
+func WithOnData(f func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData) error) StreamOption {
+	return func(s *Stream) {
+		s.onData = f
+	}
+}
+
+// WithOnUndo sets the callback reverting what was done for the blocks
+// above `undo.LastValidBlock`, which are no longer part of the chain. Those
+// blocks are sent again, from the new fork, after the undo signal.
+func WithOnUndo(f func(ctx context.Context, undo *pbsubstreamsrpc.BlockUndoSignal) error) StreamOption {
+	return func(s *Stream) {
+		s.onUndo = f
+	}
+}
+
+// WithOnProgress sets the callback receiving the progress of the
+// processing done by the server before the first block is sent.
+func WithOnProgress(f func(ctx context.Context, progress *pbsubstreamsrpc.ModulesProgress) error) StreamOption {
+	return func(s *Stream) {
+		s.onProgress = f
+	}
+}
+
+// WithMaxRetries sets the number of times in a row the stream is
+// reconnected after a failure before giving up, DefaultMaxRetries by
+// default.
+func WithMaxRetries(maxRetries int) StreamOption {
+	return func(s *Stream) {
+		s.maxRetries = maxRetries
+	}
+}
+
+func WithLogger(logger *zap.Logger) StreamOption {
+	return func(s *Stream) {
+		s.logger = logger
+	}
+}
+
+// NewStream returns a Stream of `request` through `client`, resuming from
+// the cursor of `cursors` if any, from the start cursor of `request`
+// otherwise.
+func NewStream(client pbsubstreamsrpc.StreamClient, callOpts []grpc.CallOption, request *pbsubstreamsrpc.Request, cursors CursorStore, opts ...StreamOption) *Stream {
+	s := &Stream{
+		client:     client,
+		callOpts:   callOpts,
+		request:    request,
+		cursors:    cursors,
+		maxRetries: DefaultMaxRetries,
+		logger:     zlog,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Run runs the stream until its stop block is reached, `ctx` is canceled, a
+// callback fails, the server sends a fatal error, or the stream fails
+// `maxRetries` times in a row. The cursor of a block is never saved if a
+// callback failed handling it.
+func (s *Stream) Run(ctx context.Context) error {
+	cursor, err := s.cursors.Load(ctx)
+	if err != nil {
+		return fmt.Errorf("loading cursor: %w", err)
+	}
+	if cursor != "" {
+		s.logger.Info("resuming from saved cursor", zap.String("cursor", cursor))
+	} else {
+		cursor = s.request.StartCursor
+	}
+
+	var retries int
+	for {
+		progressed, err := s.stream(ctx, &cursor)
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var fatalErr *fatalError
+		if errors.As(err, &fatalErr) || !retryable(err) {
+			return err
+		}
+		if progressed {
+			retries = 0
+		}
+		retries++
+		if retries > s.maxRetries {
+			return fmt.Errorf("stream failed %d times in a row: %w", retries, err)
+		}
+
+		backoff := min(time.Duration(1<<min(retries-1, 5))*time.Second, maxBackoff)
+		s.logger.Warn("stream failed, reconnecting", zap.Error(err), zap.Int("retries", retries), zap.Duration("backoff", backoff))
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// fatalError is an error after which the stream is not resumed.
+type fatalError struct{ err error }
+
+func (e *fatalError) Error() string { return e.err.Error() }
+func (e *fatalError) Unwrap() error { return e.err }
+
+// stream runs the stream from `cursor`, updated as blocks are handled, and
+// returns whether any block was handled.
+func (s *Stream) stream(ctx context.Context, cursor *string) (progressed bool, err error) {
+	request := proto.Clone(s.request).(*pbsubstreamsrpc.Request)
+	request.StartCursor = *cursor
+
+	stream, err := s.client.Blocks(ctx, request, s.callOpts...)
+	if err != nil {
+		return false, fmt.Errorf("call sf.substreams.rpc.v2.Stream/Blocks: %w", err)
+	}
+
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return progressed, nil
+		}
+		if err != nil {
+			return progressed, err
+		}
+
+		switch msg := resp.Message.(type) {
+		case *pbsubstreamsrpc.Response_BlockScopedData:
+			data := msg.BlockScopedData
+			if s.onData != nil {
+				if err := s.onData(ctx, data); err != nil {
+					return progressed, &fatalError{fmt.Errorf("handling block %d: %w", data.Clock.Number, err)}
+				}
+			}
+			if err := s.cursors.Save(ctx, data.Cursor, &pbsubstreams.BlockRef{Number: data.Clock.Number, Id: data.Clock.Id}); err != nil {
+				return progressed, &fatalError{fmt.Errorf("saving cursor of block %d: %w", data.Clock.Number, err)}
+			}
+			*cursor = data.Cursor
+			progressed = true
+		case *pbsubstreamsrpc.Response_BlockUndoSignal:
+			undo := msg.BlockUndoSignal
+			s.logger.Info("undoing blocks", zap.Uint64("last_valid_block", undo.LastValidBlock.Number), zap.String("last_valid_block_id", undo.LastValidBlock.Id))
+			if s.onUndo != nil {
+				if err := s.onUndo(ctx, undo); err != nil {
+					return progressed, &fatalError{fmt.Errorf("undoing blocks above %d: %w", undo.LastValidBlock.Number, err)}
+				}
+			}
+			if err := s.cursors.Save(ctx, undo.LastValidCursor, undo.LastValidBlock); err != nil {
+				return progressed, &fatalError{fmt.Errorf("saving cursor of block %d: %w", undo.LastValidBlock.Number, err)}
+			}
+			*cursor = undo.LastValidCursor
+			progressed = true
+		case *pbsubstreamsrpc.Response_Progress:
+			if s.onProgress != nil {
+				if err := s.onProgress(ctx, msg.Progress); err != nil {
+					return progressed, &fatalError{fmt.Errorf("handling progress: %w", err)}
+				}
+			}
+		case *pbsubstreamsrpc.Response_Session:
+			s.logger.Info("session started",
+				zap.String("trace_id", msg.Session.TraceId),
+				zap.Uint64("resolved_start_block", msg.Session.ResolvedStartBlock),
+				zap.Uint64("linear_handoff_block", msg.Session.LinearHandoffBlock),
+			)
+		case *pbsubstreamsrpc.Response_FatalError:
+			return progressed, &fatalError{fmt.Errorf("stream fatal error: %s", msg.FatalError.Reason)}
+		}
+	}
+}
+
+// retryable returns whether the stream can be resumed after `err`, a
+// connection or gRPC error.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.InvalidArgument, codes.Unauthenticated, codes.PermissionDenied, codes.FailedPrecondition, codes.Unimplemented:
+		return false
+	}
+	return true
+}
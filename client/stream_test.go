@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/streamingfast/substreams/client/clienttest"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func data(num uint64) *pbsubstreamsrpc.Response {
+	return &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockScopedData{BlockScopedData: &pbsubstreamsrpc.BlockScopedData{
+		Clock:  &pbsubstreams.Clock{Number: num, Id: fmt.Sprintf("id%d", num)},
+		Cursor: fmt.Sprintf("c%d", num),
+	}}}
+}
+
+func undo(num uint64) *pbsubstreamsrpc.Response {
+	return &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockUndoSignal{BlockUndoSignal: &pbsubstreamsrpc.BlockUndoSignal{
+		LastValidBlock:  &pbsubstreams.BlockRef{Number: num, Id: fmt.Sprintf("id%d", num)},
+		LastValidCursor: fmt.Sprintf("c%d", num),
+	}}}
+}
+
+func progress() *pbsubstreamsrpc.Response {
+	return &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_Progress{Progress: &pbsubstreamsrpc.ModulesProgress{}}}
+}
+
+func TestStream_Run(t *testing.T) {
+	client := &clienttest.StreamClient{
+		Streams: [][]*pbsubstreamsrpc.Response{
+			{progress(), data(1), data(2), data(3), undo(2)},
+			{data(3), data(4)},
+		},
+		Errs: []error{status.Error(codes.Unavailable, "connection reset"), nil},
+	}
+	cursors := NewMemoryCursorStore()
+	require.NoError(t, cursors.Save(context.Background(), "c0", nil))
+
+	var events []string
+	stream := NewStream(client, nil, &pbsubstreamsrpc.Request{StartCursor: "ignored"}, cursors,
+		WithOnData(func(_ context.Context, data *pbsubstreamsrpc.BlockScopedData) error {
+			events = append(events, "data:"+data.Clock.Id)
+			return nil
+		}),
+		WithOnUndo(func(_ context.Context, undo *pbsubstreamsrpc.BlockUndoSignal) error {
+			events = append(events, "undo:"+undo.LastValidBlock.Id)
+			return nil
+		}),
+		WithOnProgress(func(context.Context, *pbsubstreamsrpc.ModulesProgress) error {
+			events = append(events, "progress")
+			return nil
+		}),
+	)
+	require.NoError(t, stream.Run(context.Background()))
+
+	assert.Equal(t, []string{"c0", "c2"}, client.Cursors)
+	assert.Equal(t, []string{"progress", "data:id1", "data:id2", "data:id3", "undo:id2", "data:id3", "data:id4"}, events)
+
+	cursor, err := cursors.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "c4", cursor)
+	assert.Equal(t, &pbsubstreams.BlockRef{Number: 4, Id: "id4"}, cursors.Block())
+}
+
+func TestStream_Run_HandlerError(t *testing.T) {
+	client := &clienttest.StreamClient{
+		Streams: [][]*pbsubstreamsrpc.Response{{data(1), data(2), data(3)}},
+		Errs:    []error{nil},
+	}
+	cursors := NewMemoryCursorStore()
+
+	stream := NewStream(client, nil, &pbsubstreamsrpc.Request{StartCursor: "c0"}, cursors,
+		WithOnData(func(_ context.Context, data *pbsubstreamsrpc.BlockScopedData) error {
+			if data.Clock.Number == 2 {
+				return fmt.Errorf("boom")
+			}
+			return nil
+		}),
+	)
+	assert.EqualError(t, stream.Run(context.Background()), "handling block 2: boom")
+
+	// the stream is not retried, and the cursor of the failed block is not saved
+	assert.Equal(t, []string{"c0"}, client.Cursors)
+	cursor, err := cursors.Load(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "c1", cursor)
+}
+
+func TestStream_Run_NotRetryable(t *testing.T) {
+	client := &clienttest.StreamClient{
+		Streams: [][]*pbsubstreamsrpc.Response{
+			{data(1)},
+			{&pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_FatalError{FatalError: &pbsubstreamsrpc.Error{Reason: "boom"}}}},
+		},
+		Errs: []error{status.Error(codes.Unavailable, "connection reset"), nil},
+	}
+	stream := NewStream(client, nil, &pbsubstreamsrpc.Request{}, NewMemoryCursorStore())
+	assert.EqualError(t, stream.Run(context.Background()), "stream fatal error: boom")
+	assert.Equal(t, []string{"", "c1"}, client.Cursors)
+
+	client = &clienttest.StreamClient{
+		Streams: [][]*pbsubstreamsrpc.Response{nil},
+		Errs:    []error{status.Error(codes.Unauthenticated, "bad token")},
+	}
+	stream = NewStream(client, nil, &pbsubstreamsrpc.Request{}, NewMemoryCursorStore())
+	assert.Error(t, stream.Run(context.Background()))
+	assert.Len(t, client.Cursors, 1)
+}
+
+func TestStream_Run_MaxRetries(t *testing.T) {
+	client := &clienttest.StreamClient{
+		Streams: [][]*pbsubstreamsrpc.Response{nil, nil},
+		Errs:    []error{status.Error(codes.Unavailable, "down"), status.Error(codes.Unavailable, "down")},
+	}
+	stream := NewStream(client, nil, &pbsubstreamsrpc.Request{}, NewMemoryCursorStore(), WithMaxRetries(1))
+	assert.ErrorContains(t, stream.Run(context.Background()), "stream failed 2 times in a row")
+	assert.Len(t, client.Cursors, 2)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/cli"
+
+	"github.com/streamingfast/substreams/manifest"
+	"github.com/streamingfast/substreams/tui2"
+	"github.com/streamingfast/substreams/tui2/watcher"
+)
+
+func init() {
+	addGuiStreamFlags(devCmd)
+	devCmd.Flags().Duration("poll-interval", 500*time.Millisecond, "Interval at which the source files of the package are checked for changes")
+	rootCmd.AddCommand(devCmd)
+}
+
+var devCmd = &cobra.Command{
+	Use:   "dev [<manifest>] <module_name>",
+	Short: "Stream module outputs in the GUI, restarting the stream each time the package sources change",
+	Long: cli.Dedent(`
+		Stream module outputs from a local package in the GUI, like 'substreams gui', watching the manifest, its protobuf
+		files, the files of its binaries and its local imports. When they change, the package is rebuilt and the stream
+		restarted from the same start block, or the same cursor given by '--cursor'.
+
+		The outputs of the blocks viewed before the change are shown side by side with the new ones, the lines removed
+		on the left and the lines added on the right. Press 'D' in the Output tab to toggle the diff.
+	`),
+	Example: string(cli.ExamplePrefixed("substreams dev", `
+		map_pools_created
+		./substreams.yaml map_pools_created -s 12369621 -t +1000
+	`)),
+	RunE:         runDev,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+}
+
+func runDev(cmd *cobra.Command, args []string) error {
+	requestConfig, err := readGuiRequestConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	manifestPath := requestConfig.ManifestPath
+	files, err := manifest.LocalSourceFiles(manifestPath)
+	if err != nil {
+		return fmt.Errorf("listing package source files: %w", err)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("package %q has no local source files to watch", manifestPath)
+	}
+
+	w := watcher.New(func() ([]string, error) {
+		return manifest.LocalSourceFiles(manifestPath)
+	}, mustGetDuration(cmd, "poll-interval"))
+
+	fmt.Printf("Launching Substreams GUI, watching %d files...\n", len(files))
+
+	ui, err := tui2.New(requestConfig, tui2.WithWatcher(w))
+	if err != nil {
+		return err
+	}
+	prog := tea.NewProgram(ui, tea.WithAltScreen())
+	if _, err := prog.Run(); err != nil {
+		return fmt.Errorf("gui error: %w", err)
+	}
+
+	return nil
+}
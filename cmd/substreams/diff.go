@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/cli"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/streamingfast/substreams/client"
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/tools"
+	"github.com/streamingfast/substreams/tools/pkgdiff"
+)
+
+var diffCmd = &cobra.Command{
+	Use:   "diff <old_package> <new_package> [<module_name>]",
+	Short: "Report the differences between two packages, and optionally between the outputs of a module",
+	Long: cli.Dedent(`
+		Report the structural differences between two packages (manifests or .spkg files): the modules added and
+		removed, the changes of the kind, initial block, inputs, output or value type, update policy, entrypoint and
+		code of the modules present in both, and the fields added, removed, renamed or retyped in their protobuf
+		messages. Modules of which the hash changed are reported as invalidating their cache: their outputs and store
+		snapshots are computed again.
+
+		When a map module is given, it is also streamed from both packages, concurrently, over the same range of
+		final blocks, and the decoded outputs are compared block by block. The JSON paths that differ are reported
+		for each block, and the command fails if any block differs. The range starts at '--start-block', by default
+		the initial block of the module in the new package, and ends at '--stop-block', which is required.
+	`),
+	Example: string(cli.ExamplePrefixed("substreams diff", `
+		uniswap-v3-v0.2.7.spkg ./substreams.yaml
+		uniswap-v3-v0.2.7.spkg ./substreams.yaml map_pools_created -s 12369621 -t +1000
+	`)),
+	RunE:         runDiffE,
+	Args:         cobra.RangeArgs(2, 3),
+	SilenceUsage: true,
+}
+
+func init() {
+	diffCmd.Flags().StringP("substreams-endpoint", "e", "mainnet.eth.streamingfast.io:443", "Substreams gRPC endpoint")
+	diffCmd.Flags().String("substreams-api-token-envvar", "SUBSTREAMS_API_TOKEN", "name of variable containing Substreams Authentication token")
+	diffCmd.Flags().Bool("insecure", false, "Skip certificate validation on GRPC connection")
+	diffCmd.Flags().Bool("plaintext", false, "Establish GRPC connection in plaintext")
+	diffCmd.Flags().StringSliceP("header", "H", nil, "Additional headers to be sent in the substreams requests")
+	diffCmd.Flags().StringP("start-block", "s", "", "Start block of the outputs compared. If empty, will be replaced by the initialBlock of the module in the new package")
+	diffCmd.Flags().StringP("stop-block", "t", "0", "Stop block of the outputs compared, exclusively. A '+' prefix can indicate 'relative to start-block'")
+	diffCmd.Flags().Bool("production-mode", false, "Enable Production Mode, with high-speed parallel processing")
+	diffCmd.Flags().StringArrayP("params", "p", nil, "Set a params for parameterizable modules of both packages. Can be specified multiple times. Ex: -p module1=valA -p module2=valX&valY")
+
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiffE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	params := mustGetStringArray(cmd, "params")
+	oldPkg, err := readDiffPackage(args[0], params)
+	if err != nil {
+		return err
+	}
+	newPkg, err := readDiffPackage(args[1], params)
+	if err != nil {
+		return err
+	}
+
+	report, err := pkgdiff.Diff(oldPkg, newPkg)
+	if err != nil {
+		return err
+	}
+	report.Print(os.Stdout)
+
+	if len(args) != 3 {
+		return nil
+	}
+	outputModule := args[2]
+
+	newGraph, err := manifest.NewModuleGraph(newPkg.Modules.Modules)
+	if err != nil {
+		return fmt.Errorf("creating module graph: %w", err)
+	}
+	startBlock, readFromModule, err := readStartBlockFlag(cmd, "start-block")
+	if err != nil {
+		return fmt.Errorf("start block: %w", err)
+	}
+	if readFromModule {
+		initialBlock, err := newGraph.ModuleInitialBlock(outputModule)
+		if err != nil {
+			return fmt.Errorf("getting module start block: %w", err)
+		}
+		startBlock = int64(initialBlock)
+	}
+	stopBlock, err := readStopBlockFlag(cmd, startBlock, "stop-block", false)
+	if err != nil {
+		return fmt.Errorf("stop block: %w", err)
+	}
+	if stopBlock == 0 {
+		return fmt.Errorf("a --stop-block is required to compare the outputs of %q", outputModule)
+	}
+
+	substreamsClientConfig := client.NewSubstreamsClientConfig(
+		mustGetString(cmd, "substreams-endpoint"),
+		tools.ReadAPIToken(cmd, "substreams-api-token-envvar"),
+		mustGetBool(cmd, "insecure"),
+		mustGetBool(cmd, "plaintext"),
+	)
+	ssClient, connClose, callOpts, err := client.NewSubstreamsClient(substreamsClientConfig)
+	if err != nil {
+		return fmt.Errorf("substreams client setup: %w", err)
+	}
+	defer connClose()
+
+	if headers := parseHeaders(mustGetStringSlice(cmd, "header")); len(headers) != 0 {
+		headerArray := make([]string, 0, len(headers)*2)
+		for k, v := range headers {
+			headerArray = append(headerArray, k, v)
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, headerArray...)
+	}
+
+	outputs := func(pkg *pbsubstreams.Package) (pkgdiff.OutputsFunc, error) {
+		req := &pbsubstreamsrpc.Request{
+			StartBlockNum:   startBlock,
+			StopBlockNum:    stopBlock,
+			FinalBlocksOnly: true,
+			Modules:         pkg.Modules,
+			OutputModule:    outputModule,
+			ProductionMode:  mustGetBool(cmd, "production-mode"),
+		}
+		if err := req.Validate(); err != nil {
+			return nil, fmt.Errorf("validate request: %w", err)
+		}
+		msgDescs, err := manifest.BuildMessageDescriptors(pkg)
+		if err != nil {
+			return nil, fmt.Errorf("building message descriptors: %w", err)
+		}
+		return pkgdiff.StreamOutputs(ssClient, callOpts, req, msgDescs[outputModule]), nil
+	}
+	oldOutputs, err := outputs(oldPkg)
+	if err != nil {
+		return fmt.Errorf("old package: %w", err)
+	}
+	newOutputs, err := outputs(newPkg)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+
+	fmt.Printf("\nComparing the outputs of %s on blocks [%d, %d)\n", outputModule, startBlock, stopBlock)
+	var differing int
+	compared, err := pkgdiff.DiffOutputs(ctx, oldOutputs, newOutputs, func(diff *pkgdiff.BlockDiff) {
+		differing++
+		fmt.Printf("Block %d:\n  %s\n", diff.Block, strings.Join(diff.Changes, "\n  "))
+	})
+	if err != nil {
+		return err
+	}
+	if differing != 0 {
+		return fmt.Errorf("outputs differ at %d of %d blocks", differing, compared)
+	}
+	fmt.Printf("Outputs identical at all %d blocks\n", compared)
+	return nil
+}
+
+func readDiffPackage(input string, params []string) (*pbsubstreams.Package, error) {
+	manifestReader, err := manifest.NewReader(input)
+	if err != nil {
+		return nil, fmt.Errorf("manifest reader: %w", err)
+	}
+	pkg, err := manifestReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read manifest %q: %w", input, err)
+	}
+	if err := manifest.ApplyParams(params, pkg); err != nil {
+		return nil, err
+	}
+	return pkg, nil
+}
@@ -18,23 +18,28 @@ import (
 )
 
 func init() {
-	guiCmd.Flags().String("substreams-api-token-envvar", "SUBSTREAMS_API_TOKEN", "name of variable containing Substreams Authentication token")
-	guiCmd.Flags().StringP("substreams-endpoint", "e", "mainnet.eth.streamingfast.io:443", "Substreams gRPC endpoint")
-	guiCmd.Flags().Bool("insecure", false, "Skip certificate validation on GRPC connection")
-	guiCmd.Flags().Bool("plaintext", false, "Establish GRPC connection in plaintext")
-	guiCmd.Flags().StringSliceP("header", "H", nil, "Additional headers to be sent in the substreams request")
-	guiCmd.Flags().StringP("start-block", "s", "", "Start block to stream from. If empty, will be replaced by initialBlock of the first module you are streaming. If negative, will be resolved by the server relative to the chain head")
-	guiCmd.Flags().StringP("cursor", "c", "", "Cursor to stream from. Leave blank for no cursor")
-	guiCmd.Flags().StringP("stop-block", "t", "0", "Stop block to end stream at, inclusively.")
-	guiCmd.Flags().Bool("final-blocks-only", false, "Only process blocks that have pass finality, to prevent any reorg and undo signal by staying further away from the chain HEAD")
-	guiCmd.Flags().StringSlice("debug-modules-initial-snapshot", nil, "List of 'store' modules from which to print the initial data snapshot (Unavailable in Production Mode")
-	guiCmd.Flags().StringSlice("debug-modules-output", nil, "List of extra modules from which to print outputs, deltas and logs (Unavailable in Production Mode)")
-	guiCmd.Flags().Bool("production-mode", false, "Enable Production Mode, with high-speed parallel processing")
-	guiCmd.Flags().StringArrayP("params", "p", nil, "Set a params for parameterizable modules. Can be specified multiple times. Ex: -p module1=valA -p module2=valX&valY")
+	addGuiStreamFlags(guiCmd)
 	guiCmd.Flags().Bool("replay", false, "Replay saved session into GUI from replay.bin")
 	rootCmd.AddCommand(guiCmd)
 }
 
+// addGuiStreamFlags adds the flags of the stream of the GUI, shared by `gui` and `dev`.
+func addGuiStreamFlags(cmd *cobra.Command) {
+	cmd.Flags().String("substreams-api-token-envvar", "SUBSTREAMS_API_TOKEN", "name of variable containing Substreams Authentication token")
+	cmd.Flags().StringP("substreams-endpoint", "e", "mainnet.eth.streamingfast.io:443", "Substreams gRPC endpoint")
+	cmd.Flags().Bool("insecure", false, "Skip certificate validation on GRPC connection")
+	cmd.Flags().Bool("plaintext", false, "Establish GRPC connection in plaintext")
+	cmd.Flags().StringSliceP("header", "H", nil, "Additional headers to be sent in the substreams request")
+	cmd.Flags().StringP("start-block", "s", "", "Start block to stream from. If empty, will be replaced by initialBlock of the first module you are streaming. If negative, will be resolved by the server relative to the chain head")
+	cmd.Flags().StringP("cursor", "c", "", "Cursor to stream from. Leave blank for no cursor")
+	cmd.Flags().StringP("stop-block", "t", "0", "Stop block to end stream at, inclusively.")
+	cmd.Flags().Bool("final-blocks-only", false, "Only process blocks that have pass finality, to prevent any reorg and undo signal by staying further away from the chain HEAD")
+	cmd.Flags().StringSlice("debug-modules-initial-snapshot", nil, "List of 'store' modules from which to print the initial data snapshot (Unavailable in Production Mode")
+	cmd.Flags().StringSlice("debug-modules-output", nil, "List of extra modules from which to print outputs, deltas and logs (Unavailable in Production Mode)")
+	cmd.Flags().Bool("production-mode", false, "Enable Production Mode, with high-speed parallel processing")
+	cmd.Flags().StringArrayP("params", "p", nil, "Set a params for parameterizable modules. Can be specified multiple times. Ex: -p module1=valA -p module2=valX&valY")
+}
+
 // guiCmd represents the command to run substreams remotely
 var guiCmd = &cobra.Command{
 	Use:   "gui [<manifest>] <module_name>",
@@ -50,6 +55,29 @@ var guiCmd = &cobra.Command{
 }
 
 func runGui(cmd *cobra.Command, args []string) error {
+	requestConfig, err := readGuiRequestConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	requestConfig.Vcr = mustGetBool(cmd, "replay")
+
+	fmt.Println("Launching Substreams GUI...")
+
+	ui, err := tui2.New(requestConfig)
+	if err != nil {
+		return err
+	}
+	prog := tea.NewProgram(ui, tea.WithAltScreen())
+	if _, err := prog.Run(); err != nil {
+		return fmt.Errorf("gui error: %w", err)
+	}
+
+	return nil
+}
+
+// readGuiRequestConfig reads the request of the GUI from the arguments and
+// the flags added by addGuiStreamFlags.
+func readGuiRequestConfig(cmd *cobra.Command, args []string) (*request.RequestConfig, error) {
 	// TODO: DRY up this and `run` .. such duplication here.
 
 	manifestPath := ""
@@ -60,20 +88,20 @@ func runGui(cmd *cobra.Command, args []string) error {
 	} else {
 		// Check common error where manifest is provided by module name is missing
 		if manifest.IsLikelyManifestInput(args[0]) {
-			return fmt.Errorf("missing <module_name> argument, check 'substreams run --help' for more information")
+			return nil, fmt.Errorf("missing <module_name> argument, check 'substreams run --help' for more information")
 		}
 
 		// At this point, we assume the user invoked `substreams run <module_name>` so we `resolveManifestFile` using the empty string since no argument has been passed.
 		manifestPath, err = resolveManifestFile("")
 		if err != nil {
-			return fmt.Errorf("resolving manifest: %w", err)
+			return nil, fmt.Errorf("resolving manifest: %w", err)
 		}
 	}
 
 	productionMode := mustGetBool(cmd, "production-mode")
 	debugModulesOutput := mustGetStringSlice(cmd, "debug-modules-output")
 	if debugModulesOutput != nil && productionMode {
-		return fmt.Errorf("cannot set 'debug-modules-output' in 'production-mode'")
+		return nil, fmt.Errorf("cannot set 'debug-modules-output' in 'production-mode'")
 	}
 	debugModulesInitialSnapshot := mustGetStringSlice(cmd, "debug-modules-initial-snapshot")
 
@@ -88,15 +116,15 @@ func runGui(cmd *cobra.Command, args []string) error {
 
 	manifestReader, err := manifest.NewReader(manifestPath)
 	if err != nil {
-		return fmt.Errorf("manifest reader: %w", err)
+		return nil, fmt.Errorf("manifest reader: %w", err)
 	}
 	pkg, err := manifestReader.Read()
 	if err != nil {
-		return fmt.Errorf("read manifest %q: %w", manifestPath, err)
+		return nil, fmt.Errorf("read manifest %q: %w", manifestPath, err)
 	}
 	params := mustGetStringArray(cmd, "params")
 	if err := manifest.ApplyParams(params, pkg); err != nil {
-		return err
+		return nil, err
 	}
 
 	homeDir, err := os.UserHomeDir()
@@ -105,7 +133,7 @@ func runGui(cmd *cobra.Command, args []string) error {
 	} else {
 		err = os.MkdirAll(filepath.Join(homeDir, ".config", "substreams"), 0755)
 		if err != nil {
-			return fmt.Errorf("creating config directory: %w", err)
+			return nil, fmt.Errorf("creating config directory: %w", err)
 		}
 
 		homeDir = filepath.Join(homeDir, ".config", "substreams")
@@ -113,16 +141,14 @@ func runGui(cmd *cobra.Command, args []string) error {
 
 	cursor := mustGetString(cmd, "cursor")
 
-	fmt.Println("Launching Substreams GUI...")
-
 	startBlock, readFromModule, err := readStartBlockFlag(cmd, "start-block")
 	if err != nil {
-		return fmt.Errorf("start block: %w", err)
+		return nil, fmt.Errorf("start block: %w", err)
 	}
 
 	stopBlock, err := readStopBlockFlag(cmd, startBlock, "stop-block", cursor != "")
 	if err != nil {
-		return fmt.Errorf("stop block: %w", err)
+		return nil, fmt.Errorf("stop block: %w", err)
 	}
 
 	requestConfig := &request.RequestConfig{
@@ -134,7 +160,6 @@ func runGui(cmd *cobra.Command, args []string) error {
 		OutputModule:                outputModule,
 		SubstreamsClientConfig:      substreamsClientConfig,
 		HomeDir:                     homeDir,
-		Vcr:                         mustGetBool(cmd, "replay"),
 		Headers:                     parseHeaders(mustGetStringSlice(cmd, "header")),
 		Cursor:                      cursor,
 		StartBlock:                  startBlock,
@@ -143,16 +168,7 @@ func runGui(cmd *cobra.Command, args []string) error {
 		Params:                      params,
 	}
 
-	ui, err := tui2.New(requestConfig)
-	if err != nil {
-		return err
-	}
-	prog := tea.NewProgram(ui, tea.WithAltScreen())
-	if _, err := prog.Run(); err != nil {
-		return fmt.Errorf("gui error: %w", err)
-	}
-
-	return nil
+	return requestConfig, nil
 }
 
 // resolveManifestFile is solely nowadays by `substreams gui`. That is because manifest.Reader
@@ -82,6 +82,9 @@ func runInfo(cmd *cobra.Command, args []string) error {
 			fmt.Println("Kind: store")
 			fmt.Println("Value Type:", v.KindStore.ValueType)
 			fmt.Println("Update Policy:", v.KindStore.UpdatePolicy)
+		case *pbsubstreams.Module_KindBlockIndex_:
+			fmt.Println("Kind: block index")
+			fmt.Println("Output Type:", v.KindBlockIndex.OutputType)
 		default:
 			fmt.Println("Kind: Unknown")
 		}
@@ -14,6 +14,7 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc/metadata"
 	"io"
+	"os"
 )
 
 func init() {
@@ -33,6 +34,17 @@ func init() {
 	runCmd.Flags().StringArrayP("params", "p", nil, "Set a params for parameterizable modules. Can be specified multiple times. Ex: -p module1=valA -p module2=valX&valY")
 	runCmd.Flags().String("test-file", "", "runs a test file")
 	runCmd.Flags().Bool("test-verbose", false, "print out all the results")
+	runCmd.Flags().Bool("test-update-snapshots", false, "Write the snapshot files of the test file's snapshot tests from the outputs of the run, instead of comparing them")
+	runCmd.Flags().String("test-junit-report", "", "Write the results of the test file as a JUnit XML report to this path")
+	runCmd.Flags().Bool("local", false, "Run the Substreams in-process against local merged blocks files instead of a remote endpoint, requires a stop block")
+	runCmd.Flags().String("local-merged-blocks-store", "./merged-blocks", "[local] Store URL (or local path) to the merged blocks files to stream from")
+	runCmd.Flags().String("local-state-store", "./localdata", "[local] Store URL (or local path) where module outputs and store snapshots are cached")
+	runCmd.Flags().String("local-block-type", "", "[local] Protobuf type of the blocks contained in the merged blocks files, if empty, inferred from the modules' inputs")
+	runCmd.Flags().Uint64("local-parallel-jobs", 4, "[local] Number of parallel in-process jobs used to backprocess stores in production mode")
+	runCmd.Flags().Uint64("local-state-bundle-size", 1000, "[local] Interval in blocks at which store snapshots and output caches are written")
+	runCmd.Flags().String("local-store-disk-backend-dir", "", "[local] If set, stores keep their state on disk under this directory instead of in memory, for stores bigger than the available memory")
+	runCmd.Flags().String("local-store-snapshot-format", "proto", "[local] Format of written store snapshots, 'proto' or 'sorted' (block-compressed, sorted by key, readable without loading it whole)")
+	runCmd.Flags().String("local-output-cache-format", "proto", "[local] Format of written module output files, 'proto' or 'framed' (compressed per block, with a block index, streamed when read)")
 	rootCmd.AddCommand(runCmd)
 }
 
@@ -44,6 +56,9 @@ var runCmd = &cobra.Command{
 		Stream module outputs from a given package on a remote endpoint. The manifest is optional as it will try to find a file named
 		'substreams.yaml' in current working directory if nothing entered. You may enter a directory that contains a 'substreams.yaml'
 		'substreams.yaml' file in place of '<manifest_file>', or a link to a remote .spkg file, using urls gs://, http(s)://, ipfs://, etc.'.
+
+		With '--local', no endpoint is contacted: the modules are executed in-process against the merged blocks files found
+		in '--local-merged-blocks-store', caching outputs and store snapshots under '--local-state-store'.
 	`),
 	RunE:         runRun,
 	Args:         cobra.RangeArgs(1, 2),
@@ -91,7 +106,11 @@ func runRun(cmd *cobra.Command, args []string) error {
 	testFile := mustGetString(cmd, "test-file")
 	if testFile != "" {
 		zlog.Info("running test runner", zap.String(testFile, testFile))
-		testRunner, err = test.NewRunner(testFile, msgDescs, mustGetBool(cmd, "test-verbose"), zlog)
+		var opts []test.RunnerOption
+		if mustGetBool(cmd, "test-update-snapshots") {
+			opts = append(opts, test.WithUpdateSnapshots())
+		}
+		testRunner, err = test.NewRunner(testFile, msgDescs, mustGetBool(cmd, "test-verbose"), zlog, opts...)
 		if err != nil {
 			return fmt.Errorf("failed to setup test runner: %w", err)
 		}
@@ -123,19 +142,6 @@ func runRun(cmd *cobra.Command, args []string) error {
 		startBlock = int64(sb)
 	}
 
-	substreamsClientConfig := client.NewSubstreamsClientConfig(
-		mustGetString(cmd, "substreams-endpoint"),
-		tools.ReadAPIToken(cmd, "substreams-api-token-envvar"),
-		mustGetBool(cmd, "insecure"),
-		mustGetBool(cmd, "plaintext"),
-	)
-
-	ssClient, connClose, callOpts, err := client.NewSubstreamsClient(substreamsClientConfig)
-	if err != nil {
-		return fmt.Errorf("substreams client setup: %w", err)
-	}
-	defer connClose()
-
 	cursorStr := mustGetString(cmd, "cursor")
 
 	stopBlock, err := readStopBlockFlag(cmd, startBlock, "stop-block", cursorStr != "")
@@ -178,6 +184,23 @@ func runRun(cmd *cobra.Command, args []string) error {
 	})
 	defer cancel()
 
+	if mustGetBool(cmd, "local") {
+		return runLocal(cmd, streamCtx, req, ui, testRunner)
+	}
+
+	substreamsClientConfig := client.NewSubstreamsClientConfig(
+		mustGetString(cmd, "substreams-endpoint"),
+		tools.ReadAPIToken(cmd, "substreams-api-token-envvar"),
+		mustGetBool(cmd, "insecure"),
+		mustGetBool(cmd, "plaintext"),
+	)
+
+	ssClient, connClose, callOpts, err := client.NewSubstreamsClient(substreamsClientConfig)
+	if err != nil {
+		return fmt.Errorf("substreams client setup: %w", err)
+	}
+	defer connClose()
+
 	//parse additional-headers flag
 	additionalHeaders := mustGetStringSlice(cmd, "header")
 	if additionalHeaders != nil {
@@ -209,7 +232,7 @@ func runRun(cmd *cobra.Command, args []string) error {
 				ui.Cancel()
 				fmt.Println("all done")
 				if testRunner != nil {
-					testRunner.LogResults()
+					return finishTests(cmd, testRunner)
 				}
 
 				return nil
@@ -225,3 +248,31 @@ func runRun(cmd *cobra.Command, args []string) error {
 		}
 	}
 }
+
+// finishTests reports the results of the test file, failing if any test
+// failed.
+func finishTests(cmd *cobra.Command, testRunner *test.Runner) error {
+	if err := testRunner.Finish(); err != nil {
+		return fmt.Errorf("test runner: %w", err)
+	}
+	testRunner.LogResults()
+
+	if reportPath := mustGetString(cmd, "test-junit-report"); reportPath != "" {
+		file, err := os.Create(reportPath)
+		if err != nil {
+			return fmt.Errorf("creating junit report: %w", err)
+		}
+		defer file.Close()
+		if err := testRunner.WriteJUnitReport(file); err != nil {
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("writing junit report: %w", err)
+		}
+	}
+
+	if failed := testRunner.Failed(); failed != 0 {
+		return fmt.Errorf("%d tests failed", failed)
+	}
+	return nil
+}
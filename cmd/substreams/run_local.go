@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/dstore"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/service"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/store"
+	"github.com/streamingfast/substreams/tools/test"
+	"github.com/streamingfast/substreams/tui"
+)
+
+// runLocal executes `req` in-process against the merged blocks files configured
+// through the `--local-*` flags, feeding responses to the same `ui` as a remote
+// stream would.
+func runLocal(cmd *cobra.Command, ctx context.Context, req *pbsubstreamsrpc.Request, ui *tui.TUI, testRunner *test.Runner) error {
+	if req.StopBlockNum == 0 {
+		return fmt.Errorf("a stop block is required when running with '--local'")
+	}
+	if req.StartCursor != "" {
+		return fmt.Errorf("cannot use a cursor when running with '--local'")
+	}
+
+	blockType := mustGetString(cmd, "local-block-type")
+	if blockType == "" {
+		var err error
+		blockType, err = inferBlockType(req.Modules, req.OutputModule)
+		if err != nil {
+			return fmt.Errorf("inferring block type, use '--local-block-type' to set it explicitly: %w", err)
+		}
+	}
+
+	mergedBlocksStoreURL := mustGetString(cmd, "local-merged-blocks-store")
+	mergedBlocksStore, err := dstore.NewDBinStore(mergedBlocksStoreURL)
+	if err != nil {
+		return fmt.Errorf("failed setting up block store from url %q: %w", mergedBlocksStoreURL, err)
+	}
+
+	stateStoreURL := mustGetString(cmd, "local-state-store")
+	stateStore, err := dstore.NewStore(stateStoreURL, "zst", "zstd", true)
+	if err != nil {
+		return fmt.Errorf("failed setting up state store from url %q: %w", stateStoreURL, err)
+	}
+
+	initLocalBlockReading()
+
+	var opts []service.Option
+	if dir := mustGetString(cmd, "local-store-disk-backend-dir"); dir != "" {
+		opts = append(opts, service.WithStoreDiskBackend(dir, 0))
+	}
+	snapshotFormat, err := store.ParseSnapshotFormat(mustGetString(cmd, "local-store-snapshot-format"))
+	if err != nil {
+		return fmt.Errorf("invalid '--local-store-snapshot-format': %w", err)
+	}
+	opts = append(opts, service.WithStoreSnapshotFormat(snapshotFormat))
+	outputCacheFormat, err := execout.ParseFileFormat(mustGetString(cmd, "local-output-cache-format"))
+	if err != nil {
+		return fmt.Errorf("invalid '--local-output-cache-format': %w", err)
+	}
+	opts = append(opts, service.WithOutputCacheFormat(outputCacheFormat))
+
+	svc := service.NewLocal(
+		zlog,
+		mergedBlocksStore,
+		stateStore,
+		"",
+		blockType,
+		mustGetUint64(cmd, "local-parallel-jobs"),
+		mustGetUint64(cmd, "local-state-bundle-size"),
+		opts...,
+	)
+
+	ui.SetRequest(req)
+	ui.Connecting()
+	ui.Connected()
+
+	err = svc.LocalBlocks(ctx, req, func(respAny substreams.ResponseFromAnyTier) error {
+		if err := ui.IncomingMessage(ctx, respAny.(*pbsubstreamsrpc.Response), testRunner); err != nil {
+			fmt.Printf("RETURN HANDLER ERROR: %s\n", err)
+		}
+		return nil
+	})
+	if err != nil && err != io.EOF {
+		// Special handling if interrupted the context ourselves, no error
+		if ctx.Err() == context.Canceled {
+			ui.Cancel()
+			return nil
+		}
+		return err
+	}
+
+	ui.Cancel()
+	fmt.Println("all done")
+	if testRunner != nil {
+		return finishTests(cmd, testRunner)
+	}
+	return nil
+}
+
+// inferBlockType returns the block type consumed by `outputModule` and its ancestors,
+// failing if none or more than one (ignoring the clock) is found.
+func inferBlockType(modules *pbsubstreams.Modules, outputModule string) (string, error) {
+	graph, err := manifest.NewModuleGraph(modules.Modules)
+	if err != nil {
+		return "", fmt.Errorf("creating module graph: %w", err)
+	}
+
+	upstream, err := graph.ModulesDownTo(outputModule)
+	if err != nil {
+		return "", fmt.Errorf("computing modules down to %q: %w", outputModule, err)
+	}
+
+	var blockType string
+	for _, mod := range upstream {
+		for _, input := range mod.Inputs {
+			src := input.GetSource()
+			if src == nil || src.Type == "sf.substreams.v1.Clock" {
+				continue
+			}
+			if blockType != "" && blockType != src.Type {
+				return "", fmt.Errorf("modules consume more than one block type: %q and %q", blockType, src.Type)
+			}
+			blockType = src.Type
+		}
+	}
+
+	if blockType == "" {
+		return "", fmt.Errorf("no module consumes a block source")
+	}
+	return blockType, nil
+}
+
+// initLocalBlockReading configures `bstream` to read merged blocks files of any
+// chain, unless a chain specific import already did. Substreams only needs the
+// raw block payload, so the content type and version of the files are not checked.
+func initLocalBlockReading() {
+	if bstream.GetBlockReaderFactory != nil {
+		return
+	}
+
+	bstream.GetBlockPayloadSetter = bstream.MemoryBlockPayloadSetter
+	bstream.GetBlockReaderFactory = bstream.BlockReaderFactoryFunc(func(reader io.Reader) (bstream.BlockReader, error) {
+		return bstream.NewDBinBlockReader(reader, func(contentType string, version int32) error {
+			return nil
+		})
+	})
+}
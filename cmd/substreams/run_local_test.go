@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func Test_inferBlockType(t *testing.T) {
+	sourceInput := func(blockType string) *pbsubstreams.Module_Input {
+		return &pbsubstreams.Module_Input{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: blockType}}}
+	}
+	mapInput := func(moduleName string) *pbsubstreams.Module_Input {
+		return &pbsubstreams.Module_Input{Input: &pbsubstreams.Module_Input_Map_{Map: &pbsubstreams.Module_Input_Map{ModuleName: moduleName}}}
+	}
+	mapModule := func(name string, inputs ...*pbsubstreams.Module_Input) *pbsubstreams.Module {
+		return &pbsubstreams.Module{Name: name, Kind: &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{}}, Inputs: inputs}
+	}
+
+	tests := []struct {
+		name          string
+		modules       []*pbsubstreams.Module
+		outputModule  string
+		wantBlockType string
+		assertion     require.ErrorAssertionFunc
+	}{
+		{
+			"output module reads the block source",
+			[]*pbsubstreams.Module{
+				mapModule("map_a", sourceInput("sf.ethereum.type.v2.Block")),
+			},
+			"map_a",
+			"sf.ethereum.type.v2.Block",
+			require.NoError,
+		},
+		{
+			"ancestor reads the block source",
+			[]*pbsubstreams.Module{
+				mapModule("map_a", sourceInput("sf.ethereum.type.v2.Block")),
+				mapModule("map_b", mapInput("map_a"), sourceInput("sf.substreams.v1.Clock")),
+			},
+			"map_b",
+			"sf.ethereum.type.v2.Block",
+			require.NoError,
+		},
+		{
+			"modules not leading to the output module are ignored",
+			[]*pbsubstreams.Module{
+				mapModule("map_a", sourceInput("sf.ethereum.type.v2.Block")),
+				mapModule("map_other", sourceInput("sf.near.type.v1.Block")),
+			},
+			"map_a",
+			"sf.ethereum.type.v2.Block",
+			require.NoError,
+		},
+		{
+			"only the clock",
+			[]*pbsubstreams.Module{
+				mapModule("map_a", sourceInput("sf.substreams.v1.Clock")),
+			},
+			"map_a",
+			"",
+			errorEqual("no module consumes a block source"),
+		},
+		{
+			"several block types",
+			[]*pbsubstreams.Module{
+				mapModule("map_a", sourceInput("sf.ethereum.type.v2.Block")),
+				mapModule("map_b", mapInput("map_a"), sourceInput("sf.near.type.v1.Block")),
+			},
+			"map_b",
+			"",
+			require.Error,
+		},
+		{
+			"unknown output module",
+			[]*pbsubstreams.Module{
+				mapModule("map_a", sourceInput("sf.ethereum.type.v2.Block")),
+			},
+			"map_unknown",
+			"",
+			require.Error,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blockType, err := inferBlockType(&pbsubstreams.Modules{Modules: tt.modules}, tt.outputModule)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.wantBlockType, blockType)
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/cli"
+
+	"github.com/streamingfast/substreams/manifest"
+	"github.com/streamingfast/substreams/tools/moduletest"
+)
+
+var testCmd = &cobra.Command{
+	Use:   "test [<manifest>] <fixtures_file>",
+	Short: "Execute module entrypoints on fixtures and check their outputs, logs, store deltas and panics",
+	Long: cli.Dedent(`
+		Execute, in-process and without streaming any block, the entrypoints of the modules of the package on the
+		inputs given by each test of the fixtures file (YAML or JSON), and check the results against the test's
+		expectations.
+
+		Each test gives a module, the clock of the execution, its source and map inputs in the JSON mapping of their
+		protobuf type, the keys of the stores it reads (and of the store it writes, before the execution), the deltas
+		of the stores it reads in deltas mode, and params overriding the module's:
+
+		  tests:
+		    - name: maps the pool created
+		      module: map_pools_created
+		      block: 12369910
+		      inputs:
+		        sf.ethereum.type.v2.Block: {number: 12369910, ...}
+		      stores:
+		        store_tokens: {"token:0xabc": 18}
+		      expect:
+		        output: {pools: [{address: "0xdef", fee: 3000}]}
+		        logs: ["found 1 pool"]
+
+		The expectations are the 'output' of a map module (or its raw 'output_hex'), all its 'logs', the 'deltas'
+		written by a store module, and a 'panic' of which the message contains the expected string. The command fails
+		if any test fails.
+	`),
+	Example: string(cli.ExamplePrefixed("substreams test", `
+		tests/fixtures.yaml
+		uniswap-v3.spkg tests/fixtures.yaml
+	`)),
+	RunE:         runTestE,
+	Args:         cobra.RangeArgs(1, 2),
+	SilenceUsage: true,
+}
+
+func init() {
+	testCmd.Flags().StringArrayP("params", "p", nil, "Set a params for parameterizable modules. Can be specified multiple times. Ex: -p module1=valA -p module2=valX&valY")
+
+	rootCmd.AddCommand(testCmd)
+}
+
+func runTestE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	manifestPath := ""
+	if len(args) == 2 {
+		manifestPath = args[0]
+		args = args[1:]
+	}
+	fixturesPath := args[0]
+
+	manifestReader, err := manifest.NewReader(manifestPath)
+	if err != nil {
+		return fmt.Errorf("manifest reader: %w", err)
+	}
+	pkg, err := manifestReader.Read()
+	if err != nil {
+		return fmt.Errorf("read manifest %q: %w", manifestPath, err)
+	}
+	if err := manifest.ApplyParams(mustGetStringArray(cmd, "params"), pkg); err != nil {
+		return err
+	}
+
+	cases, err := moduletest.LoadFixtures(fixturesPath)
+	if err != nil {
+		return err
+	}
+
+	harness, err := moduletest.New(pkg, zlog)
+	if err != nil {
+		return err
+	}
+	defer harness.Close(ctx)
+
+	var failed int
+	for _, c := range cases {
+		res, err := harness.Run(ctx, c)
+		if err != nil {
+			return fmt.Errorf("test %q: %w", c.Name, err)
+		}
+		if res.Passed() {
+			fmt.Printf("PASS %s\n", c.Name)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL %s\n", c.Name)
+		for _, failure := range res.Failures {
+			fmt.Printf("  - %s\n", strings.ReplaceAll(failure, "\n", "\n    "))
+		}
+	}
+
+	if failed != 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, len(cases))
+	}
+	fmt.Printf("all %d tests passed\n", len(cases))
+	return nil
+}
@@ -4,4 +4,5 @@ import "github.com/streamingfast/substreams/tools"
 
 func init() {
 	rootCmd.AddCommand(tools.Cmd)
+	rootCmd.AddCommand(tools.StoreCmd)
 }
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/cli"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/service"
+	"github.com/streamingfast/substreams/tools"
+	"github.com/streamingfast/substreams/tools/verify"
+)
+
+func init() {
+	verifyCmd.Flags().String("state-store", "./localdata", "Store URL (or local path) of the cache holding the module outputs and store snapshots to verify")
+	verifyCmd.Flags().String("merged-blocks-store", "./merged-blocks", "Store URL (or local path) to the merged blocks files to re-execute the segments from")
+	verifyCmd.Flags().String("block-type", "", "Protobuf type of the blocks contained in the merged blocks files, if empty, inferred from the modules' inputs")
+	verifyCmd.Flags().Uint64("state-bundle-size", 1000, "Interval in blocks at which store snapshots and output caches were written, the size of the verified segments")
+	verifyCmd.Flags().Uint64("sample", 0, "If set, only verify this many segments of the range, picked at random")
+	verifyCmd.Flags().StringArrayP("params", "p", nil, "Set a params for parameterizable modules. Can be specified multiple times. Ex: -p module1=valA -p module2=valX&valY")
+
+	tools.Cmd.AddCommand(verifyCmd)
+}
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify [<manifest>] <module_name> <start_block>:<stop_block>",
+	Short: "Re-execute cached segments of a module and compare the outputs with the cached ones",
+	Long: cli.Dedent(`
+		Re-execute, in-process, the segments of '--state-bundle-size' blocks overlapping the given range, and compare the
+		outputs of the map module and of all its ancestors with the ones cached in '--state-store': every map output and
+		store delta is compared with the module's output cache, if any, and the stores' state at the end of each segment
+		with their full snapshot, if any. Each segment starts from the cached store snapshots at its start block.
+
+		The first divergent block, store key and module hash of each segment is reported, a divergence denoting either a
+		nondeterministic module or a poisoned cache. The command fails if any segment diverges.
+	`),
+	Example: string(cli.ExamplePrefixed("substreams tools verify", `
+		map_pools 12000000:12010000 --state-store ./localdata --merged-blocks-store ./merged-blocks
+		uniswap-v3.spkg map_pools 12000000:13000000 --sample 10 --state-store gs://[bucket-url-path]
+	`)),
+	RunE:         runVerifyE,
+	Args:         cobra.RangeArgs(2, 3),
+	SilenceUsage: true,
+}
+
+func runVerifyE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	manifestPath := ""
+	if len(args) == 3 {
+		manifestPath = args[0]
+		args = args[1:]
+	}
+	moduleName := args[0]
+	startBlock, stopBlock, err := parseBlockRange(args[1])
+	if err != nil {
+		return err
+	}
+
+	manifestReader, err := manifest.NewReader(manifestPath)
+	if err != nil {
+		return fmt.Errorf("manifest reader: %w", err)
+	}
+	pkg, err := manifestReader.Read()
+	if err != nil {
+		return fmt.Errorf("read manifest %q: %w", manifestPath, err)
+	}
+	if err := manifest.ApplyParams(mustGetStringArray(cmd, "params"), pkg); err != nil {
+		return err
+	}
+
+	blockType := mustGetString(cmd, "block-type")
+	if blockType == "" {
+		blockType, err = inferBlockType(pkg.Modules, moduleName)
+		if err != nil {
+			return fmt.Errorf("inferring block type, use '--block-type' to set it explicitly: %w", err)
+		}
+	}
+
+	mergedBlocksStoreURL := mustGetString(cmd, "merged-blocks-store")
+	mergedBlocksStore, err := dstore.NewDBinStore(mergedBlocksStoreURL)
+	if err != nil {
+		return fmt.Errorf("failed setting up block store from url %q: %w", mergedBlocksStoreURL, err)
+	}
+
+	stateStoreURL := mustGetString(cmd, "state-store")
+	stateStore, err := dstore.NewStore(stateStoreURL, "zst", "zstd", false)
+	if err != nil {
+		return fmt.Errorf("failed setting up state store from url %q: %w", stateStoreURL, err)
+	}
+
+	initLocalBlockReading()
+
+	bundleSize := mustGetUint64(cmd, "state-bundle-size")
+	run := func(ctx context.Context, scratchStore dstore.Store, req *pbsubstreamsrpc.Request, respFunc substreams.ResponseFunc) error {
+		svc := service.NewLocal(zlog, mergedBlocksStore, scratchStore, "", blockType, 1, bundleSize)
+		return svc.LocalBlocks(ctx, req, respFunc)
+	}
+
+	verifier, err := verify.New(pkg.Modules, moduleName, stateStore, run, zlog)
+	if err != nil {
+		return err
+	}
+
+	segments := verifier.Segments(startBlock, stopBlock, bundleSize)
+	if sample := int(mustGetUint64(cmd, "sample")); sample > 0 && sample < len(segments) {
+		picked := rand.Perm(len(segments))[:sample]
+		sort.Ints(picked)
+
+		sampled := make([]*block.Range, sample)
+		for i, idx := range picked {
+			sampled[i] = segments[idx]
+		}
+		segments = sampled
+	}
+	if len(segments) == 0 {
+		return fmt.Errorf("no segment of module %q in range %d:%d", moduleName, startBlock, stopBlock)
+	}
+
+	var divergent int
+	for _, segment := range segments {
+		result, err := verifySegment(ctx, verifier, segment)
+		if err != nil {
+			return fmt.Errorf("segment %s: %w", segment, err)
+		}
+
+		switch {
+		case result.Divergence != nil:
+			divergent++
+			fmt.Printf("segment %s: DIVERGENT, %s\n", segment, result.Divergence)
+		case len(result.Compared) == 0:
+			fmt.Printf("segment %s: nothing cached to compare with\n", segment)
+		default:
+			fmt.Printf("segment %s: OK (compared %s)\n", segment, strings.Join(result.Compared, ", "))
+		}
+		if len(result.Skipped) != 0 {
+			fmt.Printf("  not cached, skipped: %s\n", strings.Join(result.Skipped, ", "))
+		}
+	}
+
+	if divergent != 0 {
+		return fmt.Errorf("%d of %d verified segments diverge from the cache", divergent, len(segments))
+	}
+	fmt.Printf("all %d verified segments match the cache\n", len(segments))
+	return nil
+}
+
+// verifySegment verifies `segment` using a scratch state store in a
+// temporary directory, removed afterwards.
+func verifySegment(ctx context.Context, verifier *verify.Verifier, segment *block.Range) (*verify.Result, error) {
+	scratchDir, err := os.MkdirTemp("", "substreams-verify-")
+	if err != nil {
+		return nil, fmt.Errorf("creating scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratchDir)
+
+	scratchStore, err := dstore.NewStore("file://"+scratchDir, "zst", "zstd", true)
+	if err != nil {
+		return nil, fmt.Errorf("setting up scratch state store: %w", err)
+	}
+
+	zlog.Info("verifying segment", zap.Stringer("segment", segment), zap.String("scratch_dir", scratchDir))
+	return verifier.Segment(ctx, segment, scratchStore)
+}
+
+// parseBlockRange parses a `<start_block>:<stop_block>` range, the stop
+// block being exclusive.
+func parseBlockRange(in string) (startBlock, stopBlock uint64, err error) {
+	start, stop, found := strings.Cut(in, ":")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid range %q, expected <start_block>:<stop_block>", in)
+	}
+	if startBlock, err = strconv.ParseUint(start, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid start block %q: %w", start, err)
+	}
+	if stopBlock, err = strconv.ParseUint(stop, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid stop block %q: %w", stop, err)
+	}
+	if stopBlock <= startBlock {
+		return 0, 0, fmt.Errorf("invalid range %q, stop block must be above start block", in)
+	}
+	return startBlock, stopBlock, nil
+}
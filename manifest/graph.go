@@ -51,6 +51,12 @@ func NewModuleGraph(modules []*pbsubstreams.Module) (*ModuleGraph, error) {
 				moduleName = v.GetValue()
 			}
 
+			if filter := input.GetBlockFilter(); filter != nil {
+				if j, found := g.moduleIndex[filter.Module]; found {
+					g.AddCost(i, j, 1)
+				}
+			}
+
 			if moduleName == "" {
 				continue
 			}
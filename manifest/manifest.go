@@ -24,10 +24,14 @@ func init() {
 }
 
 const (
-	ModuleKindStore = "store"
-	ModuleKindMap   = "map"
+	ModuleKindStore      = "store"
+	ModuleKindMap        = "map"
+	ModuleKindBlockIndex = "blockIndex"
 )
 
+// BlockIndexOutputType is the only output type accepted for modules of kind 'blockIndex'.
+const BlockIndexOutputType = "proto:sf.substreams.index.v1.Keys"
+
 // Manifest is a YAML structure used to create a Package and its list
 // of Modules. The notion of a manifest does not live in protobuf definitions.
 type Manifest struct {
@@ -95,6 +99,13 @@ type Input struct {
 	Params string `yaml:"params"`
 
 	Mode string `yaml:"mode"`
+
+	BlockFilter *BlockFilter `yaml:"blockFilter"`
+}
+
+type BlockFilter struct {
+	Module string `yaml:"module"`
+	Query  string `yaml:"query"`
 }
 
 type Binary struct {
@@ -142,6 +153,17 @@ func (i *Input) IsParams() bool {
 }
 
 func (i *Input) parse() error {
+	if i.BlockFilter != nil {
+		if i.IsParams() {
+			return fmt.Errorf("input 'params': cannot have a 'blockFilter'")
+		}
+		if i.BlockFilter.Module == "" {
+			return fmt.Errorf("input 'blockFilter': missing 'module'")
+		}
+		if i.BlockFilter.Query == "" {
+			return fmt.Errorf("input 'blockFilter': missing 'query'")
+		}
+	}
 	if i.IsMap() {
 		//i.Name = fmt.Sprintf("map:%s", i.Map)
 		return nil
@@ -266,6 +288,7 @@ func (m *Module) setInputsToProto(pbModule *pbsubstreams.Module) error {
 						Type: input.Source,
 					},
 				},
+				BlockFilter: input.blockFilterToProto(),
 			}
 			pbModule.Inputs = append(pbModule.Inputs, pbInput)
 			continue
@@ -277,6 +300,7 @@ func (m *Module) setInputsToProto(pbModule *pbsubstreams.Module) error {
 						ModuleName: input.Map,
 					},
 				},
+				BlockFilter: input.blockFilterToProto(),
 			}
 			pbModule.Inputs = append(pbModule.Inputs, pbInput)
 			continue
@@ -302,6 +326,7 @@ func (m *Module) setInputsToProto(pbModule *pbsubstreams.Module) error {
 						Mode:       mode,
 					},
 				},
+				BlockFilter: input.blockFilterToProto(),
 			}
 			pbModule.Inputs = append(pbModule.Inputs, pbInput)
 			continue
@@ -328,6 +353,16 @@ func (m *Module) setInputsToProto(pbModule *pbsubstreams.Module) error {
 	return nil
 }
 
+func (i *Input) blockFilterToProto() *pbsubstreams.Module_Input_BlockFilter {
+	if i.BlockFilter == nil {
+		return nil
+	}
+	return &pbsubstreams.Module_Input_BlockFilter{
+		Module: i.BlockFilter.Module,
+		Query:  i.BlockFilter.Query,
+	}
+}
+
 const (
 	OutputValueTypeInt64      = "int64"
 	OutputValueTypeFloat64    = "float64"
@@ -356,6 +391,12 @@ func (m *Module) setKindToProto(pbModule *pbsubstreams.Module) {
 				OutputType: m.Output.Type,
 			},
 		}
+	case ModuleKindBlockIndex:
+		pbModule.Kind = &pbsubstreams.Module_KindBlockIndex_{
+			KindBlockIndex: &pbsubstreams.Module_KindBlockIndex{
+				OutputType: m.Output.Type,
+			},
+		}
 	case ModuleKindStore:
 		var updatePolicy pbsubstreams.Module_KindStore_UpdatePolicy
 		switch m.UpdatePolicy {
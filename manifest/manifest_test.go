@@ -61,6 +61,28 @@ inputs:
 				Inputs:       []*Input{{Source: "proto:sf.ethereum.type.v1.Block"}, {Store: "pairs"}},
 			},
 		},
+		{
+			name: "block index and filtered input",
+			rawYamlInput: `---
+name: transfers
+kind: map
+inputs:
+  - source: sf.ethereum.type.v2.Block
+    blockFilter:
+      module: index_transfers
+      query: "transfer && contract:0xa0b8"
+output:
+  type: proto:acme.Transfers`,
+			expectedOutput: Module{
+				Name: "transfers",
+				Kind: "map",
+				Inputs: []*Input{{
+					Source:      "sf.ethereum.type.v2.Block",
+					BlockFilter: &BlockFilter{Module: "index_transfers", Query: "transfer && contract:0xa0b8"},
+				}},
+				Output: StreamOutput{Type: "proto:acme.Transfers"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -51,6 +51,8 @@ func generateMermaidGraph(mods *pbsubstreams.Modules) string {
 			str.WriteString(fmt.Sprintf("  %s[map: %s];\n", s.Name, s.Name))
 		case *pbsubstreams.Module_KindStore_:
 			str.WriteString(fmt.Sprintf("  %s[store: %s];\n", s.Name, s.Name))
+		case *pbsubstreams.Module_KindBlockIndex_:
+			str.WriteString(fmt.Sprintf("  %s[block index: %s];\n", s.Name, s.Name))
 		}
 
 		for _, in := range s.Inputs {
@@ -73,6 +75,9 @@ func generateMermaidGraph(mods *pbsubstreams.Modules) string {
 				name := s.Name + ":params"
 				str.WriteString(fmt.Sprintf("  %s[params] --> %s;\n", name, s.Name))
 			}
+			if filter := in.BlockFilter; filter != nil {
+				str.WriteString(fmt.Sprintf("  %s -. filter .-> %s;\n", filter.Module, s.Name))
+			}
 		}
 	}
 
@@ -19,6 +19,7 @@ import (
 	"github.com/streamingfast/cli"
 	"github.com/streamingfast/dstore"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/index"
 	"go.uber.org/zap"
 	"golang.org/x/mod/semver"
 	"google.golang.org/protobuf/proto"
@@ -431,6 +432,10 @@ func (r *Reader) validatePackage(pkg *pbsubstreams.Package) error {
 					return fmt.Errorf("module %q incorrect outputTyupe %q valueType must be a proto Message", mod.Name, outputType)
 				}
 			}
+		case *pbsubstreams.Module_KindBlockIndex_:
+			if outputType := i.KindBlockIndex.OutputType; outputType != BlockIndexOutputType {
+				return fmt.Errorf("module %q: invalid outputType %q, block index modules must output %q", mod.Name, outputType, BlockIndexOutputType)
+			}
 		case *pbsubstreams.Module_KindStore_:
 			valueType := i.KindStore.ValueType
 			if !r.skipModuleOutputTypeValidation {
@@ -563,9 +568,39 @@ func ValidateModules(mods *pbsubstreams.Modules) error {
 					return fmt.Errorf("module %q: input index %d: unknown store mode value %d", mod.Name, idx, i.Store.Mode)
 				}
 			}
+
+			if filter := in.BlockFilter; filter != nil {
+				if err := validateBlockFilter(mods, filter, in); err != nil {
+					return fmt.Errorf("module %q: input %d: %w", mod.Name, idx, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func validateBlockFilter(mods *pbsubstreams.Modules, filter *pbsubstreams.Module_Input_BlockFilter, in *pbsubstreams.Module_Input) error {
+	if in.GetParams() != nil {
+		return fmt.Errorf("params input cannot have a block filter")
+	}
+
+	var found bool
+	for _, mod := range mods.Modules {
+		if mod.Name == filter.Module {
+			found = true
+			if _, ok := mod.Kind.(*pbsubstreams.Module_KindBlockIndex_); !ok {
+				return fmt.Errorf("block filter module %q not of 'blockIndex' kind", filter.Module)
+			}
 		}
 	}
+	if !found {
+		return fmt.Errorf("block filter module %q not found", filter.Module)
+	}
 
+	if _, err := index.ParseQuery(filter.Query); err != nil {
+		return fmt.Errorf("block filter: %w", err)
+	}
 	return nil
 }
 
@@ -611,6 +646,10 @@ func LoadManifestFile(inputPath string) (*Manifest, error) {
 			if err := validateStoreBuilder(s); err != nil {
 				return nil, fmt.Errorf("stream %q: %w", s.Name, err)
 			}
+		case ModuleKindBlockIndex:
+			if s.Output.Type != BlockIndexOutputType {
+				return nil, fmt.Errorf("stream %q: 'output.type' must be %q for kind 'blockIndex'", s.Name, BlockIndexOutputType)
+			}
 
 		default:
 			return nil, fmt.Errorf("stream %q: invalid kind %q", s.Name, s.Kind)
@@ -661,6 +700,9 @@ func prefixModules(mods []*pbsubstreams.Module, prefix string) {
 			default:
 				panic(fmt.Sprintf("module %q: input index %d: unsupported module input type %s", mod.Name, idx, inputIface.Input))
 			}
+			if filter := inputIface.BlockFilter; filter != nil {
+				filter.Module = prefix + PrefixSeparator + filter.Module
+			}
 		}
 	}
 }
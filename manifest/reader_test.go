@@ -315,6 +315,56 @@ func TestReader_Read(t *testing.T) {
 	}
 }
 
+func TestValidateModules_BlockFilter(t *testing.T) {
+	indexModule := func() *pbsubstreams.Module {
+		mod := newTestModuleModel("index_transfers", UNSET, "sf.ethereum.type.v2.Block", BlockIndexOutputType)
+		mod.Kind = &pbsubstreams.Module_KindBlockIndex_{KindBlockIndex: &pbsubstreams.Module_KindBlockIndex{OutputType: BlockIndexOutputType}}
+		return mod
+	}
+	filteredModule := func(filterModule, query string) *pbsubstreams.Module {
+		mod := newTestModuleModel("map_transfers", UNSET, "sf.ethereum.type.v2.Block", "proto:acme.Transfers")
+		mod.Inputs[0].BlockFilter = &pbsubstreams.Module_Input_BlockFilter{Module: filterModule, Query: query}
+		return mod
+	}
+
+	tests := []struct {
+		name      string
+		modules   []*pbsubstreams.Module
+		expectErr string
+	}{
+		{
+			name:    "valid",
+			modules: []*pbsubstreams.Module{indexModule(), filteredModule("index_transfers", "transfer && (contract:0xa0b8 || !contract:0xdac1)")},
+		},
+		{
+			name:      "unknown module",
+			modules:   []*pbsubstreams.Module{indexModule(), filteredModule("index_unknown", "transfer")},
+			expectErr: `module "map_transfers": input 0: block filter module "index_unknown" not found`,
+		},
+		{
+			name:      "not a block index",
+			modules:   []*pbsubstreams.Module{newTestModuleModel("map_other", UNSET, "sf.ethereum.type.v2.Block", "proto:acme.Other"), filteredModule("map_other", "transfer")},
+			expectErr: `module "map_transfers": input 0: block filter module "map_other" not of 'blockIndex' kind`,
+		},
+		{
+			name:      "invalid query",
+			modules:   []*pbsubstreams.Module{indexModule(), filteredModule("index_transfers", "transfer &&")},
+			expectErr: `module "map_transfers": input 0: block filter: parsing query "transfer &&": unexpected end of query`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateModules(&pbsubstreams.Modules{Modules: tt.modules})
+			if tt.expectErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.expectErr)
+		})
+	}
+}
+
 func newTestBinaryModel(content []byte) *pbsubstreams.Binary {
 	return &pbsubstreams.Binary{
 		Type:    "wasm/rust-v1",
@@ -66,6 +66,8 @@ func (m *ModuleHashes) HashModule(modules *pbsubstreams.Modules, module *pbsubst
 		buf.WriteString("map")
 	case *pbsubstreams.Module_KindStore_:
 		buf.WriteString("store")
+	case *pbsubstreams.Module_KindBlockIndex_:
+		buf.WriteString("block_index")
 	default:
 		return nil, fmt.Errorf("invalid module file %T", module.Kind)
 	}
@@ -87,6 +89,14 @@ func (m *ModuleHashes) HashModule(modules *pbsubstreams.Modules, module *pbsubst
 			return nil, err
 		}
 		buf.WriteString(value)
+
+		// only written when set, so that the hash of modules without block
+		// filters is unchanged
+		if filter := input.BlockFilter; filter != nil {
+			buf.WriteString("block_filter")
+			buf.WriteString(filter.Module)
+			buf.WriteString(filter.Query)
+		}
 	}
 
 	buf.WriteString("ancestors")
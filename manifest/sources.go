@@ -0,0 +1,133 @@
+package manifest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+// LocalSourceFiles returns the absolute paths of the local files the package
+// of `input` is built from: the manifest, the protobuf files it lists along
+// with the local files they import, the files of its binaries, and
+// recursively those of its local imports. A .spkg file is its own only
+// source file, and remote packages have none.
+func LocalSourceFiles(input string) ([]string, error) {
+	var out []string
+	if err := collectSourceFiles(input, map[string]bool{}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func collectSourceFiles(input string, seen map[string]bool, out *[]string) error {
+	if hasRemotePackagePrefix(input) {
+		return nil
+	}
+	resolved, err := resolveInput(input, "")
+	if err != nil {
+		return fmt.Errorf("resolving %q: %w", input, err)
+	}
+	absPath, err := filepath.Abs(resolved)
+	if err != nil {
+		return fmt.Errorf("getting absolute path: %w", err)
+	}
+	if seen[absPath] {
+		return nil
+	}
+	seen[absPath] = true
+	*out = append(*out, absPath)
+
+	if strings.HasSuffix(absPath, ".spkg") {
+		return nil
+	}
+
+	m, err := LoadManifestFile(absPath)
+	if err != nil {
+		return fmt.Errorf("loading manifest %q: %w", absPath, err)
+	}
+
+	for _, file := range m.protobufSourceFiles() {
+		if !seen[file] {
+			seen[file] = true
+			*out = append(*out, file)
+		}
+	}
+
+	binaryNames := make([]string, 0, len(m.Binaries))
+	for name := range m.Binaries {
+		binaryNames = append(binaryNames, name)
+	}
+	sort.Strings(binaryNames)
+	for _, name := range binaryNames {
+		file := m.Binaries[name].File
+		if file == "" {
+			continue
+		}
+		file = m.resolvePath(file)
+		if !seen[file] {
+			seen[file] = true
+			*out = append(*out, file)
+		}
+	}
+
+	for _, kv := range m.Imports {
+		if err := collectSourceFiles(m.resolvePath(kv[1]), seen, out); err != nil {
+			return fmt.Errorf("import %q: %w", kv[0], err)
+		}
+	}
+	return nil
+}
+
+// protobufSourceFiles returns the protobuf files of the manifest found in
+// its import paths, along with the ones they import. When the files cannot
+// be parsed, as while being edited, only the files listed are returned.
+func (m *Manifest) protobufSourceFiles() (out []string) {
+	var importPaths []string
+	for _, imp := range m.Protobuf.ImportPaths {
+		importPaths = append(importPaths, m.resolvePath(imp))
+	}
+	importPaths = append(importPaths, m.Workdir)
+
+	find := func(name string) string {
+		for _, importPath := range importPaths {
+			candidate := filepath.Join(importPath, name)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate
+			}
+		}
+		return ""
+	}
+
+	names := append([]string(nil), m.Protobuf.Files...)
+	parser := &protoparse.Parser{ImportPaths: importPaths}
+	if files, err := parser.ParseFiles(m.Protobuf.Files...); err == nil {
+		names = nil
+		seen := map[string]bool{}
+		var walk func(fd *desc.FileDescriptor)
+		walk = func(fd *desc.FileDescriptor) {
+			if seen[fd.GetName()] {
+				return
+			}
+			seen[fd.GetName()] = true
+			names = append(names, fd.GetName())
+			for _, dep := range fd.GetDependencies() {
+				walk(dep)
+			}
+		}
+		for _, fd := range files {
+			walk(fd)
+		}
+	}
+
+	for _, name := range names {
+		if file := find(name); file != "" {
+			out = append(out, file)
+		}
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalSourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	}
+
+	write("substreams.yaml", `
+specVersion: v0.1.0
+package:
+  name: test
+  version: v0.0.0
+imports:
+  dep: ./dep/substreams.yaml
+  remote: https://example.com/remote.spkg
+protobuf:
+  files:
+    - acme/pools.proto
+  importPaths:
+    - ./proto
+binaries:
+  default:
+    type: wasm/rust-v1
+    file: target/acme.wasm
+`)
+	write("proto/acme/pools.proto", `syntax = "proto3"; package acme; import "acme/token.proto"; message Pool { Token token = 1; }`)
+	write("proto/acme/token.proto", `syntax = "proto3"; package acme; message Token {}`)
+	write("dep/substreams.yaml", `
+specVersion: v0.1.0
+package:
+  name: dep
+  version: v0.0.0
+imports:
+  base: ../base.spkg
+`)
+	write("base.spkg", "")
+
+	files, err := LocalSourceFiles(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "substreams.yaml"),
+		filepath.Join(dir, "proto/acme/pools.proto"),
+		filepath.Join(dir, "proto/acme/token.proto"),
+		filepath.Join(dir, "target/acme.wasm"),
+		filepath.Join(dir, "dep/substreams.yaml"),
+		filepath.Join(dir, "base.spkg"),
+	}, files)
+
+	// unparsable protobuf files are still watched
+	write("proto/acme/pools.proto", `syntax = "proto3"; package acme; import "acme/token.proto"; message Pool {`)
+	files, err = LocalSourceFiles(filepath.Join(dir, "substreams.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "proto/acme/pools.proto"), files[1])
+	assert.NotContains(t, files, filepath.Join(dir, "proto/acme/token.proto"))
+}
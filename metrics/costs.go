@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"sync"
+	"time"
+)
+
+// maxCostModules bounds the number of modules of which ModuleCosts keeps
+// the costs, the least recently recorded ones being forgotten first.
+const maxCostModules = 1000
+
+// ModuleCosts records the processing time per block of modules, by module
+// hash and by interval of blocks, measured over the tier2 jobs that
+// executed them. It outlives the requests, for the next ones to size their
+// segments after it.
+type ModuleCosts struct {
+	mu       sync.Mutex
+	interval uint64
+	modules  map[string]*moduleCosts
+}
+
+type moduleCosts struct {
+	perBlock     map[uint64]time.Duration // by interval start block
+	lastRecorded time.Time
+}
+
+func NewModuleCosts(interval uint64) *ModuleCosts {
+	return &ModuleCosts{
+		interval: interval,
+		modules:  make(map[string]*moduleCosts),
+	}
+}
+
+// Record records that the module of hash `moduleHash` processed the
+// `blockCount` blocks starting at `startBlock` in `processingTime`. The
+// cost of the intervals covered replaces the one previously recorded.
+func (c *ModuleCosts) Record(moduleHash string, startBlock, blockCount uint64, processingTime time.Duration) {
+	if blockCount == 0 || moduleHash == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	mod := c.modules[moduleHash]
+	if mod == nil {
+		if len(c.modules) >= maxCostModules {
+			c.evictOldest()
+		}
+		mod = &moduleCosts{perBlock: make(map[uint64]time.Duration)}
+		c.modules[moduleHash] = mod
+	}
+	mod.lastRecorded = time.Now()
+
+	perBlock := processingTime / time.Duration(blockCount)
+	endBlock := startBlock + blockCount
+	for intervalStart := startBlock - startBlock%c.interval; intervalStart < endBlock; intervalStart += c.interval {
+		mod.perBlock[intervalStart] = perBlock
+	}
+}
+
+func (c *ModuleCosts) evictOldest() {
+	var oldestHash string
+	var oldest time.Time
+	for hash, mod := range c.modules {
+		if oldestHash == "" || mod.lastRecorded.Before(oldest) {
+			oldestHash = hash
+			oldest = mod.lastRecorded
+		}
+	}
+	delete(c.modules, oldestHash)
+}
+
+// Cost returns the processing time per block of the module of hash
+// `moduleHash` over the interval containing `blockNum`, if it was recorded.
+func (c *ModuleCosts) Cost(moduleHash string, blockNum uint64) (perBlock time.Duration, found bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	mod := c.modules[moduleHash]
+	if mod == nil {
+		return 0, false
+	}
+	perBlock, found = mod.perBlock[blockNum-blockNum%c.interval]
+	return
+}
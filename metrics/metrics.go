@@ -16,6 +16,9 @@ var SquashesLaunched = MetricSet.NewCounter("substreams_total_squashes_launched"
 var SquashersStarted = MetricSet.NewCounter("substreams_total_squash_processes_launched", "Counter for Total squash processes launched, used for rate")
 var SquashersEnded = MetricSet.NewCounter("substreams_total_squash_processes_closed", "Counter for Total squash processes closed, used for active processes")
 
+var Tier2JobsQueued = MetricSet.NewGaugeVec("substreams_tier2_jobs_queued", []string{"priority"}, "Number of tier2 jobs waiting for a worker slot, by priority")
+var Tier2JobsLeased = MetricSet.NewGauge("substreams_tier2_worker_slots_leased", "Number of tier2 worker slots leased to jobs")
+
 var AppReadiness = MetricSet.NewAppReadiness("firehose")
 
 var registerOnce sync.Once
@@ -35,6 +35,9 @@ type Stats struct {
 	// counter is used to get the next jobIdx
 	counter uint64
 
+	moduleCosts *ModuleCosts
+	moduleHash  func(moduleName string) string
+
 	logger *zap.Logger
 }
 
@@ -206,6 +209,18 @@ func (s *Stats) RecordNewSubrequest(stage uint32, startBlock, stopBlock uint64)
 	return id
 }
 
+// RecordJobQueued records whether the job waits for a worker slot. Once it
+// leaves the queue, its duration starts over.
+func (s *Stats) RecordJobQueued(jobIdx uint64, queued bool) {
+	s.Lock()
+	defer s.Unlock()
+	job := s.runningJobs[jobIdx]
+	if job.Queued && !queued {
+		job.start = time.Now()
+	}
+	job.Queued = queued
+}
+
 func (s *Stats) RecordModuleMerging(module string) {
 	s.Lock()
 	defer s.Unlock()
@@ -224,11 +239,27 @@ func (s *Stats) RecordModuleMergeComplete(module string) {
 	stat.mergingTime += time.Since(stat.mergeBegin)
 }
 
+// RecordModuleCosts makes the jobs record the processing time of their
+// modules in `costs` when they end, `moduleHash` resolving the hash of a
+// module from its name.
+func (s *Stats) RecordModuleCosts(costs *ModuleCosts, moduleHash func(moduleName string) string) {
+	s.Lock()
+	defer s.Unlock()
+	s.moduleCosts = costs
+	s.moduleHash = moduleHash
+}
+
 func (s *Stats) RecordEndSubrequest(jobIdx uint64) {
 	s.Lock()
 	defer s.Unlock()
 	job := s.runningJobs[jobIdx]
 
+	if s.moduleCosts != nil {
+		for name, jobStats := range job.modulesStats {
+			s.moduleCosts.Record(s.moduleHash(name), job.StartBlock, job.ProcessedBlocks, time.Duration(jobStats.ProcessingTimeMs)*time.Millisecond)
+		}
+	}
+
 	for i := 0; i <= int(job.Stage); i++ {
 		for _, mod := range s.stages[i].Modules {
 			if _, ok := s.modulesStats[mod]; !ok {
@@ -294,8 +325,8 @@ func (s *Stats) RecordModuleWasmStoreWrite(moduleName string, sizeBytes uint64,
 	mod.storeOperationTime += elapsed
 }
 
-// RecordModuleWasmStoreDeletePrefix can be called multiple times per module per block `elapsed` is the time spent in executing that operation.
-func (s *Stats) RecordModuleWasmStoreDeletePrefix(moduleName string, sizeBytes uint64, elapsed time.Duration) {
+// RecordModuleWasmStoreDelete can be called multiple times per module per block, for each DeletePrefix or DeleteRange operation. `elapsed` is the time spent in executing that operation.
+func (s *Stats) RecordModuleWasmStoreDelete(moduleName string, sizeBytes uint64, elapsed time.Duration) {
 	s.Lock()
 	defer s.Unlock()
 	mod := s.moduleStats(moduleName)
@@ -349,6 +380,7 @@ func (s *Stats) JobsStats() []*pbsubstreamsrpc.Job {
 			StopBlock:       v.StopBlock,
 			ProcessedBlocks: v.ProcessedBlocks,
 			DurationMs:      uint64(time.Since(v.start).Milliseconds()),
+			Queued:          v.Queued,
 		}
 		i++
 	}
@@ -3,6 +3,7 @@ package execout
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
 
@@ -24,28 +25,33 @@ import (
 type Walker struct {
 	ctx context.Context
 	*block.Range
-	fileWalker *execout.FileWalker
-	streamOut  *response.Stream
-	module     *pbsubstreams.Module
-	logger     *zap.Logger
-	working    bool
+	fileWalkers []*execout.FileWalker // one for each of the `modules`
+	streamOut   *response.Stream
+	modules     []*pbsubstreams.Module
+	logger      *zap.Logger
+	working     bool
+
+	// multipleOutputs sends the modules output in `BlockScopedData.Outputs`,
+	// for requests with `output_modules`.
+	multipleOutputs bool
 }
 
 func NewWalker(
 	ctx context.Context,
-	module *pbsubstreams.Module,
-	fileWalker *execout.FileWalker,
+	modules []*pbsubstreams.Module,
+	fileWalkers []*execout.FileWalker,
 	walkRange *block.Range,
 	stream *response.Stream,
 ) *Walker {
 	logger := reqctx.Logger(ctx)
 	return &Walker{
-		ctx:        ctx,
-		module:     module,
-		fileWalker: fileWalker,
-		Range:      walkRange,
-		streamOut:  stream,
-		logger:     logger,
+		ctx:             ctx,
+		modules:         modules,
+		fileWalkers:     fileWalkers,
+		Range:           walkRange,
+		streamOut:       stream,
+		logger:          logger,
+		multipleOutputs: len(reqctx.Details(ctx).OutputModules) != 0,
 	}
 }
 
@@ -62,21 +68,33 @@ func (r *Walker) IsWorking() bool {
 }
 
 func (r *Walker) CmdDownloadCurrentSegment(waitBefore time.Duration) loop.Cmd {
-	file := r.fileWalker.File()
+	files := make([]*execout.File, len(r.fileWalkers))
+	for i, fileWalker := range r.fileWalkers {
+		files[i] = fileWalker.File()
+	}
 
 	return func() loop.Msg {
 		time.Sleep(waitBefore)
 
-		err := file.Load(r.ctx)
-		if err == dstore.ErrNotFound {
+		readers := make([]*execout.ItemReader, 0, len(files))
+		defer func() {
+			for _, reader := range readers {
+				reader.Close()
+			}
+		}()
+		for _, file := range files {
+			reader, err := file.OpenItems(r.ctx, r.StartBlock)
+			if err == dstore.ErrNotFound {
 
-			return MsgFileNotPresent{NextWait: computeNewWait(waitBefore)}
-		}
-		if err != nil {
-			return loop.Quit(fmt.Errorf("loading %s cache %q: %w", file.ModuleName, file.Filename(), err))
+				return MsgFileNotPresent{NextWait: computeNewWait(waitBefore)}
+			}
+			if err != nil {
+				return loop.Quit(fmt.Errorf("loading %s cache %q: %w", file.ModuleName, file.Filename(), err))
+			}
+			readers = append(readers, reader)
 		}
 
-		if err := r.sendItems(file.SortedItems()); err != nil {
+		if err := r.sendItems(newItemsMerger(readers)); err != nil {
 			return loop.Quit(err)
 		}
 		return MsgFileDownloaded{}
@@ -94,16 +112,85 @@ func computeNewWait(previousWait time.Duration) time.Duration {
 	return newWait
 }
 
-func (r *Walker) sendItems(sortedItems []*pboutput.Item) error {
-	for _, item := range sortedItems {
-		if item == nil {
-			continue // why would that happen?!
+// blockItems holds the cached output of each module for a given block, nil
+// for the modules without output for that block.
+type blockItems []*pboutput.Item
+
+func (b blockItems) first() *pboutput.Item {
+	for _, item := range b {
+		if item != nil {
+			return item
+		}
+	}
+	return nil
+}
+
+// itemsMerger merges, by block number, the items read from the file of each
+// module, reading them as blocks are requested.
+type itemsMerger struct {
+	readers []*execout.ItemReader
+	heads   blockItems
+	done    []bool
+}
+
+func newItemsMerger(readers []*execout.ItemReader) *itemsMerger {
+	return &itemsMerger{
+		readers: readers,
+		heads:   make(blockItems, len(readers)),
+		done:    make([]bool, len(readers)),
+	}
+}
+
+// next returns the items of the next block, or io.EOF once all the items
+// were read.
+func (m *itemsMerger) next() (blockItems, error) {
+	var blockNum uint64
+	found := false
+	for i, reader := range m.readers {
+		if m.heads[i] == nil && !m.done[i] {
+			item, err := reader.Next()
+			if err == io.EOF {
+				m.done[i] = true
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			m.heads[i] = item
+		}
+		if head := m.heads[i]; head != nil && (!found || head.BlockNum < blockNum) {
+			blockNum = head.BlockNum
+			found = true
+		}
+	}
+	if !found {
+		return nil, io.EOF
+	}
+
+	items := make(blockItems, len(m.heads))
+	for i, head := range m.heads {
+		if head != nil && head.BlockNum == blockNum {
+			items[i] = head
+			m.heads[i] = nil
+		}
+	}
+	return items, nil
+}
+
+func (r *Walker) sendItems(merger *itemsMerger) error {
+	for {
+		items, err := merger.next()
+		if err == io.EOF {
+			return nil
 		}
-		if item.BlockNum < r.StartBlock {
+		if err != nil {
+			return fmt.Errorf("reading cached outputs: %w", err)
+		}
+		if items.first().BlockNum < r.StartBlock {
 			continue
 		}
 
-		blockScopedData, err := toBlockScopedData(r.module, item)
+		blockScopedData, err := toBlockScopedData(r.modules, items, r.multipleOutputs)
 		if err != nil {
 			return fmt.Errorf("converting to block scoped data: %w", err)
 		}
@@ -120,19 +207,20 @@ func (r *Walker) sendItems(sortedItems []*pboutput.Item) error {
 			return nil
 		}
 	}
-	return nil
 }
 
 func (r *Walker) NextSegment() {
-	r.fileWalker.Next()
+	for _, fileWalker := range r.fileWalkers {
+		fileWalker.Next()
+	}
 }
 
 func (r *Walker) IsCompleted() bool {
-	return r.fileWalker.IsDone()
+	return r.fileWalkers[0].IsDone()
 }
 
-func toBlockScopedData(module *pbsubstreams.Module, cacheItem *pboutput.Item) (*pbsubstreamsrpc.BlockScopedData, error) {
-	clock := toClock(cacheItem)
+func toBlockScopedData(modules []*pbsubstreams.Module, cacheItems blockItems, multipleOutputs bool) (*pbsubstreamsrpc.BlockScopedData, error) {
+	clock := toClock(cacheItems.first())
 	blockRef := bstream.NewBlockRef(clock.Id, clock.Number)
 	cursor := bstream.Cursor{
 		Step:      bstream.StepNewIrreversible,
@@ -146,11 +234,20 @@ func toBlockScopedData(module *pbsubstreams.Module, cacheItem *pboutput.Item) (*
 		FinalBlockHeight: blockRef.Num(),
 	}
 
-	m, err := toModuleOutput(module, cacheItem)
-	if err != nil {
-		return nil, fmt.Errorf("module output: %w", err)
+	for i, module := range modules {
+		if cacheItems[i] == nil {
+			continue
+		}
+		m, err := toModuleOutput(module, cacheItems[i])
+		if err != nil {
+			return nil, fmt.Errorf("module output: %w", err)
+		}
+		if !multipleOutputs {
+			out.Output = m
+			break
+		}
+		out.Outputs = append(out.Outputs, m)
 	}
-	out.Output = m
 
 	return out, nil
 }
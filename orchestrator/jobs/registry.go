@@ -0,0 +1,259 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/orchestrator/response"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+// Key identifies the work done by a tier2 job: two jobs with the same key
+// run the same modules and write the same files. The index of the stage is
+// left out, as it is local to the graph of each request.
+type Key struct {
+	CacheTag string
+	// Stages is the hashes of the modules of every stage up to the one
+	// run by the job, sorted and comma-separated within a stage, stages
+	// being separated by `|`.
+	Stages string
+	// Modules is the sorted, comma-separated hashes of the modules
+	// produced by the job.
+	Modules string
+	// OutputModules is the sorted, comma-separated hashes of the output
+	// modules of the request.
+	OutputModules     string
+	ProductionMode    bool
+	StartBlock        uint64
+	ExclusiveEndBlock uint64
+}
+
+func (k Key) String() string {
+	return fmt.Sprintf("%s/%s/%s/%s/production-%t/%d-%d", k.CacheTag, k.Stages, k.Modules, k.OutputModules, k.ProductionMode, k.StartBlock, k.ExclusiveEndBlock)
+}
+
+// Registry holds the jobs in flight on a tier1, so that the requests
+// needing the same work attach to the job already running it instead of
+// scheduling a duplicate.
+type Registry struct {
+	mu   sync.Mutex
+	jobs map[Key]*Job
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		jobs: make(map[Key]*Job),
+	}
+}
+
+// Acquire returns the job in flight for `key`, attaching the caller to it,
+// or a new job of which the caller is the owner and must run it in
+// Context(), then call Finish(). A new job is named after `traceID`: its
+// partial files are written with the owner's trace ID.
+//
+// Every caller, owner included, must then Wait() on the job, and Leave() it
+// once done with its partial files. The job is canceled when all of its
+// waiters went away before it finished.
+func (r *Registry) Acquire(ctx context.Context, key Key, traceID string) (job *Job, owner bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if job := r.jobs[key]; job != nil {
+		job.waiters++
+		job.consumers++
+		return job, false
+	}
+
+	// The job's lifetime is its own: it outlives the owner's request if
+	// others are waiting on it. It keeps the owner's values: request
+	// details, logger, stats and auth.
+	jobCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	job = &Job{
+		registry:  r,
+		key:       key,
+		traceID:   traceID,
+		ctx:       jobCtx,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+		waiters:   1,
+		consumers: 1,
+		released:  make(map[string]*releasedFile),
+		upstreams: make(map[*response.Stream]int),
+	}
+	r.jobs[key] = job
+	return job, true
+}
+
+// InFlight returns the number of jobs in flight.
+func (r *Registry) InFlight() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.jobs)
+}
+
+func (r *Registry) remove(job *Job) {
+	if r.jobs[job.key] == job {
+		delete(r.jobs, job.key)
+	}
+}
+
+type Job struct {
+	registry *Registry
+	key      Key
+	traceID  string
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	err    error
+
+	// waiters is the number of requests still waiting on the job, and
+	// consumers the number of requests that attached to it and did not
+	// leave it yet.
+	waiters   int
+	consumers int
+	// released are the partial files that some consumers are done with,
+	// by module and filename, until all of them are.
+	released map[string]*releasedFile
+	// upstreams are the response streams of the requests waiting on the
+	// job, counted by the waits on them.
+	upstreams map[*response.Stream]int
+}
+
+type releasedFile struct {
+	file  *store.FileInfo
+	count int
+}
+
+func (j *Job) Key() Key { return j.key }
+
+// TraceID is the trace ID of the owner's request, naming the partial files
+// written by the job.
+func (j *Job) TraceID() string { return j.traceID }
+
+// Context is the context in which the owner runs the job.
+func (j *Job) Context() context.Context { return j.ctx }
+
+// Upstream is the response stream to which the job reports its failures:
+// they are sent to the streams of all the requests still waiting on it,
+// instead of only the owner's.
+func (j *Job) Upstream() *response.Stream {
+	return response.New(func(resp substreams.ResponseFromAnyTier) error {
+		j.registry.mu.Lock()
+		upstreams := make([]*response.Stream, 0, len(j.upstreams))
+		for upstream := range j.upstreams {
+			upstreams = append(upstreams, upstream)
+		}
+		j.registry.mu.Unlock()
+
+		for _, upstream := range upstreams {
+			// A request gone away does not fail the others.
+			_ = upstream.Send(resp)
+		}
+		return nil
+	})
+}
+
+// Finish records the outcome of the job, and wakes up its waiters.
+func (j *Job) Finish(err error) {
+	r := j.registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-j.done:
+		return
+	default:
+	}
+	j.err = err
+	close(j.done)
+	r.remove(j)
+	j.cancel()
+}
+
+// Wait blocks until the job finished, returning its error, or until `ctx`
+// is done, returning the context's error. The job is canceled when the
+// last of its waiters leaves before it finished. The failures of the job
+// are sent to `upstream` while waiting, if not nil.
+func (j *Job) Wait(ctx context.Context, upstream *response.Stream) error {
+	if upstream != nil {
+		j.registry.mu.Lock()
+		j.upstreams[upstream]++
+		j.registry.mu.Unlock()
+
+		defer func() {
+			j.registry.mu.Lock()
+			defer j.registry.mu.Unlock()
+			if j.upstreams[upstream]--; j.upstreams[upstream] == 0 {
+				delete(j.upstreams, upstream)
+			}
+		}()
+	}
+
+	select {
+	case <-j.done:
+		return j.err
+	case <-ctx.Done():
+	}
+
+	r := j.registry
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	select {
+	case <-j.done:
+		return j.err
+	default:
+	}
+	j.waiters--
+	if j.waiters == 0 {
+		r.remove(j)
+		j.cancel()
+	}
+	return ctx.Err()
+}
+
+// Release records that a consumer is done with the partial file `file`,
+// and reports whether it was the last one, which can then delete the file.
+func (j *Job) Release(file *store.FileInfo) (last bool) {
+	j.registry.mu.Lock()
+	defer j.registry.mu.Unlock()
+
+	key := file.ModuleName + "/" + file.Filename
+	released := j.released[key]
+	if released == nil {
+		released = &releasedFile{file: file}
+		j.released[key] = released
+	}
+	released.count++
+	if released.count < j.consumers {
+		return false
+	}
+	delete(j.released, key)
+	return true
+}
+
+// Leave records that a consumer is done with the job, whether it merged
+// its partial files or went away, `released` being the ones it released.
+// It returns the partial files that all the remaining consumers released,
+// which the caller must then delete on their behalf.
+func (j *Job) Leave(released []*store.FileInfo) (deletable []*store.FileInfo) {
+	j.registry.mu.Lock()
+	defer j.registry.mu.Unlock()
+
+	j.consumers--
+	for _, file := range released {
+		if entry := j.released[file.ModuleName+"/"+file.Filename]; entry != nil {
+			entry.count--
+		}
+	}
+	for key, entry := range j.released {
+		if entry.count > 0 && entry.count >= j.consumers {
+			deletable = append(deletable, entry.file)
+			delete(j.released, key)
+		}
+	}
+	return deletable
+}
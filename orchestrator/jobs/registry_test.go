@@ -0,0 +1,164 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/orchestrator/response"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+var testKey = Key{CacheTag: "tag", Stages: "abc|def,ghi", Modules: "def,ghi", OutputModules: "jkl", StartBlock: 10, ExclusiveEndBlock: 20}
+
+func TestRegistry_Attach(t *testing.T) {
+	r := NewRegistry()
+
+	job, owner := r.Acquire(context.Background(), testKey, "trace-a")
+	assert.True(t, owner)
+	attached, owner := r.Acquire(context.Background(), testKey, "trace-b")
+	assert.False(t, owner)
+	assert.Same(t, job, attached)
+	assert.Equal(t, "trace-a", attached.TraceID())
+
+	other, owner := r.Acquire(context.Background(), Key{CacheTag: "tag", Stages: "abc|def,ghi", Modules: "def,ghi", OutputModules: "jkl", StartBlock: 20, ExclusiveEndBlock: 30}, "trace-b")
+	assert.True(t, owner)
+	assert.NotSame(t, job, other)
+	assert.Equal(t, 2, r.InFlight())
+
+	job.Finish(nil)
+	require.NoError(t, job.Wait(context.Background(), nil))
+	require.NoError(t, attached.Wait(context.Background(), nil))
+	assert.Equal(t, 1, r.InFlight())
+
+	// A finished job is not attached to, its outputs are found in the cache.
+	_, owner = r.Acquire(context.Background(), testKey, "trace-c")
+	assert.True(t, owner)
+}
+
+func TestRegistry_Failure(t *testing.T) {
+	r := NewRegistry()
+
+	job, _ := r.Acquire(context.Background(), testKey, "trace-a")
+	attached, _ := r.Acquire(context.Background(), testKey, "trace-b")
+
+	job.Finish(fmt.Errorf("work failed on remote host"))
+	assert.EqualError(t, attached.Wait(context.Background(), nil), "work failed on remote host")
+	assert.Equal(t, 0, r.InFlight())
+}
+
+func TestRegistry_Cancellation(t *testing.T) {
+	r := NewRegistry()
+
+	ownerCtx, cancelOwner := context.WithCancel(context.Background())
+	job, _ := r.Acquire(ownerCtx, testKey, "trace-a")
+	followerCtx, cancelFollower := context.WithCancel(context.Background())
+	r.Acquire(followerCtx, testKey, "trace-b")
+
+	// The owner going away leaves the job running for the other request.
+	cancelOwner()
+	assert.ErrorIs(t, job.Wait(ownerCtx, nil), context.Canceled)
+	assert.NoError(t, job.Context().Err())
+	assert.Equal(t, 1, r.InFlight())
+
+	// The last request going away cancels it.
+	cancelFollower()
+	assert.ErrorIs(t, job.Wait(followerCtx, nil), context.Canceled)
+	assert.ErrorIs(t, job.Context().Err(), context.Canceled)
+	assert.Equal(t, 0, r.InFlight())
+
+	_, owner := r.Acquire(context.Background(), testKey, "trace-c")
+	assert.True(t, owner)
+}
+
+func TestRegistry_Release(t *testing.T) {
+	r := NewRegistry()
+
+	job, _ := r.Acquire(context.Background(), testKey, "trace-a")
+	r.Acquire(context.Background(), testKey, "trace-b")
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	r.Acquire(canceledCtx, testKey, "trace-c")
+	cancel()
+	assert.ErrorIs(t, job.Wait(canceledCtx, nil), context.Canceled)
+	assert.Empty(t, job.Leave(nil))
+	job.Finish(nil)
+
+	first := store.NewPartialFileInfo("def", 10, 20, "trace-a")
+	second := store.NewPartialFileInfo("def", 20, 30, "trace-a")
+	// Every module of the job has a partial file of the same name.
+	otherModule := store.NewPartialFileInfo("ghi", 10, 20, "trace-a")
+
+	assert.False(t, job.Release(first))
+	assert.False(t, job.Release(second))
+	assert.False(t, job.Release(otherModule))
+	assert.True(t, job.Release(first))
+	assert.True(t, job.Release(second))
+	assert.True(t, job.Release(otherModule))
+}
+
+func TestRegistry_LeaveAfterFinish(t *testing.T) {
+	r := NewRegistry()
+
+	job, _ := r.Acquire(context.Background(), testKey, "trace-a")
+	r.Acquire(context.Background(), testKey, "trace-b")
+	r.Acquire(context.Background(), testKey, "trace-c")
+	job.Finish(nil)
+
+	first := store.NewPartialFileInfo("def", 10, 20, "trace-a")
+	second := store.NewPartialFileInfo("def", 20, 30, "trace-a")
+
+	// trace-a merges both files, trace-b only the first one, then trace-c
+	// goes away without merging any: the first file is deleted on its behalf.
+	assert.False(t, job.Release(first))
+	assert.False(t, job.Release(second))
+	assert.False(t, job.Release(first))
+	assert.Empty(t, job.Leave([]*store.FileInfo{first, second}))
+	assert.Equal(t, []*store.FileInfo{first}, job.Leave(nil))
+
+	// The last consumer deletes the second one.
+	assert.True(t, job.Release(second))
+	assert.Empty(t, job.Leave([]*store.FileInfo{first, second}))
+	assert.Empty(t, job.released)
+}
+
+func TestJob_Upstream(t *testing.T) {
+	r := NewRegistry()
+
+	var received []string
+	upstream := func(name string) *response.Stream {
+		return response.New(func(substreams.ResponseFromAnyTier) error {
+			received = append(received, name)
+			return fmt.Errorf("stream of %s closed", name)
+		})
+	}
+
+	job, _ := r.Acquire(context.Background(), testKey, "trace-a")
+	r.Acquire(context.Background(), testKey, "trace-b")
+
+	ownerCtx, cancelOwner := context.WithCancel(context.Background())
+	cancelOwner()
+	assert.ErrorIs(t, job.Wait(ownerCtx, upstream("owner")), context.Canceled)
+
+	followerDone := make(chan error)
+	go func() {
+		followerDone <- job.Wait(context.Background(), upstream("follower"))
+	}()
+	require.Eventually(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return len(job.upstreams) == 1
+	}, time.Second, time.Millisecond)
+
+	// The failure reaches the request still waiting, not the owner gone away.
+	require.NoError(t, job.Upstream().RPCFailedProgressResponse("boom", nil, false))
+	assert.Equal(t, []string{"follower"}, received)
+
+	job.Finish(fmt.Errorf("work failed on remote host: boom"))
+	assert.EqualError(t, <-followerDone, "work failed on remote host: boom")
+	assert.Empty(t, job.upstreams)
+}
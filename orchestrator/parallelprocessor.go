@@ -13,6 +13,7 @@ import (
 	"github.com/streamingfast/substreams/orchestrator/stage"
 	"github.com/streamingfast/substreams/orchestrator/work"
 	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/service/config"
 	"github.com/streamingfast/substreams/storage/execout"
 	"github.com/streamingfast/substreams/storage/store"
@@ -39,7 +40,13 @@ func BuildParallelProcessor(
 	stream := response.New(respFunc)
 	sched := scheduler.New(ctx, stream)
 
-	stages := stage.NewStages(ctx, outputGraph, reqPlan, storeConfigs, traceID)
+	cacheStore, err := runtimeConfig.BaseObjectStore.SubStore(reqctx.Details(ctx).CacheTag)
+	if err != nil {
+		return nil, fmt.Errorf("internal error setting store: %w", err)
+	}
+	sched.CacheStore = cacheStore
+
+	stages := stage.NewStages(ctx, outputGraph, reqPlan, storeConfigs, traceID, runtimeConfig.JobRegistry)
 	sched.Stages = stages
 
 	// we may be here only for mapper, without stores
@@ -85,19 +92,21 @@ func BuildParallelProcessor(
 	// for whatever reason,
 
 	if reqPlan.WriteExecOut != nil {
-		execOutSegmenter := reqPlan.WriteOutSegmenter()
-		// note: since we are *NOT* in a sub-request and are setting up output module is a map
-		requestedModule := outputGraph.OutputModule()
-		if requestedModule.GetKindStore() != nil {
-			panic("logic error: should not get a store as outputModule on tier 1")
+		execOutSegmenter := reqPlan.WriteOutFilesSegmenter()
+		// note: since we are *NOT* in a sub-request and are setting up output modules that are maps
+		requestedModules := outputGraph.OutputModules()
+		var walkers []*execout.FileWalker
+		for _, requestedModule := range requestedModules {
+			if requestedModule.GetKindStore() != nil {
+				panic("logic error: should not get a store as outputModule on tier 1")
+			}
+			walkers = append(walkers, execoutStorage.NewFileWalker(requestedModule.Name, execOutSegmenter))
 		}
 
-		walker := execoutStorage.NewFileWalker(requestedModule.Name, execOutSegmenter)
-
 		sched.ExecOutWalker = orchestratorExecout.NewWalker(
 			ctx,
-			requestedModule,
-			walker,
+			requestedModules,
+			walkers,
 			reqPlan.ReadExecOut,
 			stream,
 		)
@@ -137,6 +146,7 @@ func (b *ParallelProcessor) Stages() *stage.Stages {
 func (b *ParallelProcessor) Run(ctx context.Context) (storeMap store.Map, err error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	defer b.scheduler.Stages.LeaveJobs()
 
 	initCmd := b.scheduler.Init()
 	if err := b.scheduler.Run(ctx, initCmd); err != nil {
@@ -2,6 +2,7 @@ package plan
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/streamingfast/substreams/block"
 )
@@ -44,13 +45,20 @@ type RequestPlan struct {
 	// ref: /docs/assets/range_planning.png
 
 	segmentInterval uint64
+	// segmentBounds are the start blocks of variable-width segments, see
+	// block.Segmenter.WithBounds. When empty, segments span a single
+	// interval.
+	segmentBounds []uint64
 }
 
 func (p *RequestPlan) RequiresParallelProcessing() bool {
 	return p.WriteExecOut != nil || p.BuildStores != nil
 }
 
-func BuildTier1RequestPlan(productionMode bool, segmentInterval uint64, graphInitBlock, resolvedStartBlock, linearHandoffBlock, exclusiveEndBlock uint64, scheduleStores bool) (*RequestPlan, error) {
+// BuildTier1RequestPlan lays out the ranges of the request. They are computed
+// on intervals of `segmentInterval` blocks, then cut in segments starting at
+// `segmentBounds` if any, see AdaptiveSegmentBounds.
+func BuildTier1RequestPlan(productionMode bool, segmentInterval uint64, graphInitBlock, resolvedStartBlock, linearHandoffBlock, exclusiveEndBlock uint64, scheduleStores bool, segmentBounds []uint64) (*RequestPlan, error) {
 	if exclusiveEndBlock != 0 && linearHandoffBlock > exclusiveEndBlock {
 		panic(fmt.Sprintf("invalid linearHandoff %d when building plan, it should always be capped at exclusiveEndBlock %d", linearHandoffBlock, exclusiveEndBlock))
 	}
@@ -104,11 +112,40 @@ func BuildTier1RequestPlan(productionMode bool, segmentInterval uint64, graphIni
 		}
 		plan.WriteExecOut = nil
 	}
+
+	if len(segmentBounds) != 0 {
+		// The stages depend on the previous segment of each other: the
+		// stores must end and the mapper start on a segment bound.
+		if plan.BuildStores != nil {
+			segmentBounds = insertBound(segmentBounds, segmentInterval, plan.BuildStores.ExclusiveEndBlock)
+		}
+		if plan.WriteExecOut != nil {
+			segmentBounds = insertBound(segmentBounds, segmentInterval, plan.WriteExecOut.StartBlock)
+		}
+		plan.segmentBounds = segmentBounds
+	}
 	return plan, nil
 }
 
+// insertBound returns `bounds` with `blockNum` inserted if it falls on an
+// interval within them. Outside of them, segments span a single interval
+// so `blockNum` is already a bound.
+func insertBound(bounds []uint64, interval uint64, blockNum uint64) []uint64 {
+	if blockNum%interval != 0 || blockNum <= bounds[0] || blockNum >= bounds[len(bounds)-1] {
+		return bounds
+	}
+	pos := sort.Search(len(bounds), func(i int) bool { return bounds[i] >= blockNum })
+	if bounds[pos] == blockNum {
+		return bounds
+	}
+	out := make([]uint64, 0, len(bounds)+1)
+	out = append(out, bounds[:pos]...)
+	out = append(out, blockNum)
+	return append(out, bounds[pos:]...)
+}
+
 func (p *RequestPlan) StoresSegmenter() *block.Segmenter {
-	return block.NewSegmenter(p.segmentInterval, p.BuildStores.StartBlock, p.BuildStores.ExclusiveEndBlock)
+	return p.newSegmenter(p.BuildStores.StartBlock, p.BuildStores.ExclusiveEndBlock)
 }
 
 func (p *RequestPlan) BackprocessSegmenter() *block.Segmenter {
@@ -117,21 +154,34 @@ func (p *RequestPlan) BackprocessSegmenter() *block.Segmenter {
 	} else if p.WriteExecOut == nil {
 		return p.StoresSegmenter()
 	}
-	return block.NewSegmenter(
-		p.segmentInterval,
+	return p.newSegmenter(
 		min(p.BuildStores.StartBlock, p.WriteExecOut.StartBlock),
 		max(p.BuildStores.ExclusiveEndBlock, p.WriteExecOut.ExclusiveEndBlock),
 	)
 }
 
 func (p *RequestPlan) ModuleSegmenter(modInitBlock uint64) *block.Segmenter {
-	return block.NewSegmenter(p.segmentInterval, modInitBlock, p.BuildStores.ExclusiveEndBlock)
+	return p.newSegmenter(modInitBlock, p.BuildStores.ExclusiveEndBlock)
 }
 
 func (p *RequestPlan) WriteOutSegmenter() *block.Segmenter {
+	return p.newSegmenter(p.WriteExecOut.StartBlock, p.WriteExecOut.ExclusiveEndBlock)
+}
+
+// WriteOutFilesSegmenter segments the WriteExecOut range on the output
+// files, which always span a single interval.
+func (p *RequestPlan) WriteOutFilesSegmenter() *block.Segmenter {
 	return block.NewSegmenter(p.segmentInterval, p.WriteExecOut.StartBlock, p.WriteExecOut.ExclusiveEndBlock)
 }
 
+func (p *RequestPlan) newSegmenter(initialBlock, exclusiveEndBlock uint64) *block.Segmenter {
+	return block.NewSegmenter(p.segmentInterval, initialBlock, exclusiveEndBlock).WithBounds(p.segmentBounds)
+}
+
 func (p *RequestPlan) String() string {
-	return fmt.Sprintf("interval=%d, stores=%s, map_write=%s, map_read=%s, linear=%s", p.segmentInterval, p.BuildStores, p.WriteExecOut, p.ReadExecOut, p.LinearPipeline)
+	out := fmt.Sprintf("interval=%d, stores=%s, map_write=%s, map_read=%s, linear=%s", p.segmentInterval, p.BuildStores, p.WriteExecOut, p.ReadExecOut, p.LinearPipeline)
+	if len(p.segmentBounds) != 0 {
+		out += fmt.Sprintf(", segment_bounds=%d-%d (%d)", p.segmentBounds[0], p.segmentBounds[len(p.segmentBounds)-1], len(p.segmentBounds))
+	}
+	return out
 }
@@ -0,0 +1,48 @@
+package plan
+
+import (
+	"time"
+)
+
+// MaxSegmentIntervals is the widest a segment laid out by
+// AdaptiveSegmentBounds gets, in intervals.
+const MaxSegmentIntervals = 10
+
+// AdaptiveSegmentBounds lays out segments over [startBlock, exclusiveEndBlock),
+// spanning as many intervals as fit in `targetDuration` according to the
+// processing time of each interval given by `intervalCost`, so that the jobs
+// over ranges costly to process are narrow and the others wider. An interval
+// of unknown cost, as well as one costing more than `targetDuration`, is a
+// segment on its own.
+//
+// Segments always start and end on intervals: the full stores and the
+// output files are written on the same blocks as with fixed-width segments.
+// It returns nil when every segment would span a single interval.
+func AdaptiveSegmentBounds(interval, startBlock, exclusiveEndBlock uint64, targetDuration time.Duration, intervalCost func(intervalStart uint64) (cost time.Duration, known bool)) []uint64 {
+	var bounds []uint64
+	var segmentCost time.Duration
+	var segmentIntervals int
+	segmentKnown := false
+	widened := false
+
+	first := startBlock - startBlock%interval
+	for intervalStart := first; intervalStart < exclusiveEndBlock; intervalStart += interval {
+		cost, known := intervalCost(intervalStart)
+		if bounds == nil || !known || !segmentKnown || segmentIntervals == MaxSegmentIntervals || segmentCost+cost > targetDuration {
+			bounds = append(bounds, intervalStart)
+			segmentCost = 0
+			segmentIntervals = 0
+			segmentKnown = known
+		} else {
+			widened = true
+		}
+		segmentCost += cost
+		segmentIntervals++
+	}
+	if !widened {
+		return nil
+	}
+
+	lastEnd := exclusiveEndBlock + interval - 1
+	return append(bounds, lastEnd-lastEnd%interval)
+}
@@ -0,0 +1,74 @@
+package plan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/streamingfast/substreams/block"
+)
+
+func TestAdaptiveSegmentBounds(t *testing.T) {
+	costs := map[uint64]time.Duration{
+		0:  10 * time.Second, // heavy early intervals
+		10: 10 * time.Second,
+		20: 2 * time.Second,
+		30: 2 * time.Second,
+		40: 2 * time.Second,
+		50: 2 * time.Second,
+		60: 2 * time.Second,
+		// 70 unknown
+		80: 1 * time.Second,
+		90: 1 * time.Second,
+	}
+	intervalCost := func(intervalStart uint64) (time.Duration, bool) {
+		cost, found := costs[intervalStart]
+		return cost, found
+	}
+
+	bounds := AdaptiveSegmentBounds(10, 5, 95, 6*time.Second, intervalCost)
+	assert.Equal(t, []uint64{0, 10, 20, 50, 70, 80, 100}, bounds)
+
+	segmenter := block.NewSegmenter(10, 5, 95).WithBounds(bounds)
+	var ranges []string
+	for idx := segmenter.FirstIndex(); idx <= segmenter.LastIndex(); idx++ {
+		ranges = append(ranges, segmenter.Range(idx).String())
+	}
+	assert.Equal(t, []string{
+		block.ParseRange("5-10").String(),
+		block.ParseRange("10-20").String(),
+		block.ParseRange("20-50").String(),
+		block.ParseRange("50-70").String(),
+		block.ParseRange("70-80").String(),
+		block.ParseRange("80-95").String(),
+	}, ranges)
+}
+
+func TestAdaptiveSegmentBounds_MaxWidth(t *testing.T) {
+	cheap := func(uint64) (time.Duration, bool) { return time.Millisecond, true }
+	bounds := AdaptiveSegmentBounds(10, 0, 250, time.Hour, cheap)
+	assert.Equal(t, []uint64{0, 100, 200, 250}, bounds)
+}
+
+func TestAdaptiveSegmentBounds_Unknown(t *testing.T) {
+	unknown := func(uint64) (time.Duration, bool) { return 0, false }
+	assert.Nil(t, AdaptiveSegmentBounds(10, 0, 250, time.Hour, unknown))
+}
+
+func TestBuildTier1RequestPlan_SegmentBounds(t *testing.T) {
+	// stores end, and the mapper starts, in the middle of the 0-100 segment
+	res, err := BuildTier1RequestPlan(true, 10, 0, 45, 45, 45, true, []uint64{0, 100, 150})
+	require.NoError(t, err)
+	assert.Equal(t, "0-40", tostr(res.BuildStores))
+	assert.Equal(t, "40-45", tostr(res.WriteExecOut))
+
+	stores := res.StoresSegmenter()
+	assert.Equal(t, 0, stores.LastIndex())
+	assert.Equal(t, "0-40", tostr(stores.Range(0)))
+
+	mapper := res.WriteOutSegmenter()
+	assert.Equal(t, 1, mapper.FirstIndex())
+	assert.Equal(t, "40-45", tostr(mapper.Range(1)))
+}
@@ -15,6 +15,11 @@ func New(respFunc substreams.ResponseFunc) *Stream {
 	}
 }
 
+// Send sends `resp` as is, to relay the responses of another stream.
+func (s *Stream) Send(resp substreams.ResponseFromAnyTier) error {
+	return s.respFunc(resp)
+}
+
 func (s *Stream) BlockScopedData(in *pbsubstreamsrpc.BlockScopedData) error {
 	return s.respFunc(substreams.NewBlockScopedDataResponse(in))
 }
@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/streamingfast/dstore"
 	"go.uber.org/zap"
 
 	"github.com/streamingfast/substreams/orchestrator/execout"
+	"github.com/streamingfast/substreams/orchestrator/jobs"
 	"github.com/streamingfast/substreams/orchestrator/loop"
 	"github.com/streamingfast/substreams/orchestrator/response"
 	"github.com/streamingfast/substreams/orchestrator/stage"
@@ -25,9 +28,17 @@ type Scheduler struct {
 	Stages        *stage.Stages
 	WorkerPool    *work.WorkerPool
 	ExecOutWalker *execout.Walker
+	// CacheStore is the store of the request's cache tag, in which the
+	// files of the speculative duplicates of its jobs are adopted.
+	CacheStore dstore.Store
 
 	logger *zap.Logger
 
+	// running holds the jobs run by this request, and jobDurations the
+	// durations of the ones that succeeded, by stage.
+	running      map[stage.Unit]*runningJob
+	jobDurations map[int][]time.Duration
+
 	// Final state:
 	outputStreamCompleted bool
 	storesSyncCompleted   bool
@@ -36,9 +47,11 @@ type Scheduler struct {
 func New(ctx context.Context, stream *response.Stream) *Scheduler {
 	logger := reqctx.Logger(ctx)
 	s := &Scheduler{
-		ctx:    ctx,
-		stream: stream,
-		logger: logger,
+		ctx:          ctx,
+		stream:       stream,
+		logger:       logger,
+		running:      make(map[stage.Unit]*runningJob),
+		jobDurations: make(map[int][]time.Duration),
 	}
 	s.EventLoop = loop.NewEventLoop(s.Update)
 	return s
@@ -55,7 +68,7 @@ func (s *Scheduler) Init() loop.Cmd {
 		s.outputStreamCompleted = true
 	}
 
-	cmds = append(cmds, work.CmdScheduleNextJob())
+	cmds = append(cmds, work.CmdScheduleNextJob(), cmdCheckStragglers(s.ctx))
 
 	if s.Stages.AllStoresCompleted() {
 		cmds = append(cmds, func() loop.Msg { return stage.MsgAllStoresCompleted{} })
@@ -100,14 +113,39 @@ func (s *Scheduler) Update(msg loop.Msg) loop.Cmd {
 			return nil
 		}
 
+		// Waiting on a job shared with another request also takes a
+		// worker, which is not used, so that the request keeps within its
+		// parallelism.
 		worker := s.WorkerPool.Borrow()
 
+		job, owner := s.Stages.Job(workUnit)
+		if job != nil && !owner {
+			s.logger.Info("waiting on shared work", zap.Object("unit", workUnit))
+			return loop.Batch(
+				s.cmdWaitJob(job, workUnit, worker),
+				work.CmdScheduleNextJob(),
+			)
+		}
+
 		s.logger.Info("scheduling work", zap.Object("unit", workUnit))
-		modules := s.Stages.StageModules(workUnit.Stage)
-		return loop.Batch(
-			worker.Work(s.ctx, workUnit, workRange, modules, s.stream),
+		running := newRunningJob(s.ctx, workUnit, workRange, job)
+		s.running[workUnit] = running
+		cmds = append(cmds,
+			s.cmdAttempt(running, worker),
 			work.CmdScheduleNextJob(),
 		)
+		if job != nil {
+			cmds = append(cmds, s.cmdWaitJob(job, workUnit, nil))
+		}
+
+	case MsgAttemptFinished:
+		cmds = append(cmds, s.attemptFinished(msg))
+
+	case MsgCheckStragglers:
+		cmds = append(cmds,
+			s.speculate(),
+			cmdCheckStragglers(s.ctx),
+		)
 
 	case work.MsgJobFailed:
 		cmds = append(cmds, loop.Quit(msg.Error))
@@ -163,6 +201,17 @@ func (s *Scheduler) Update(msg loop.Msg) loop.Cmd {
 	return loop.Batch(cmds...)
 }
 
+// cmdWaitJob waits on a job shared with other requests, holding `worker`
+// if the job is run by another request.
+func (s *Scheduler) cmdWaitJob(job *jobs.Job, unit stage.Unit, worker work.Worker) loop.Cmd {
+	return func() loop.Msg {
+		if err := job.Wait(s.ctx, s.stream); err != nil {
+			return work.MsgJobFailed{Unit: unit, Error: err}
+		}
+		return work.MsgJobSucceeded{Unit: unit, Worker: worker}
+	}
+}
+
 func (s *Scheduler) cmdShutdownWhenComplete() loop.Cmd {
 	if s.outputStreamCompleted && s.storesSyncCompleted {
 		return func() loop.Msg {
@@ -1,12 +1,31 @@
 package scheduler
 
 import (
+	"context"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/streamingfast/dstore"
+	tracing "github.com/streamingfast/sf-tracing"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/manifest"
+	"github.com/streamingfast/substreams/metrics"
 	"github.com/streamingfast/substreams/orchestrator/execout"
+	"github.com/streamingfast/substreams/orchestrator/jobs"
 	"github.com/streamingfast/substreams/orchestrator/loop"
+	"github.com/streamingfast/substreams/orchestrator/plan"
+	"github.com/streamingfast/substreams/orchestrator/response"
+	"github.com/streamingfast/substreams/orchestrator/stage"
+	"github.com/streamingfast/substreams/orchestrator/work"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/reqctx"
 )
 
 func TestSched2_JobFinished(t *testing.T) {
@@ -35,3 +54,201 @@ func TestSched2_JobFinished(t *testing.T) {
 	//  * NextSegment()
 
 }
+
+func testContext() context.Context {
+	ctx := reqctx.WithRequest(context.Background(), &reqctx.RequestDetails{CacheTag: "tag", ProductionMode: true})
+	return reqctx.WithReqStats(ctx, metrics.NewReqStats(&metrics.Config{}, zap.NewNop()))
+}
+
+// newTestStages returns the stages of a request for the outputs of
+// `assert_test_store_add_i64` up to block 30, built in segments of 10
+// blocks by a store stage and a map stage.
+func newTestStages(t *testing.T, ctx context.Context, registry *jobs.Registry) *stage.Stages {
+	t.Helper()
+	pkg := manifest.TestReadManifest(t, "../../test/testdata/substreams-test-v0.1.0.spkg")
+	outputGraph, err := outputmodules.NewOutputModulesGraph([]string{"assert_test_store_add_i64"}, true, pkg.Modules)
+	require.NoError(t, err)
+	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 0, 0, 30, 30, true, nil)
+	require.NoError(t, err)
+	return stage.NewStages(ctx, outputGraph, reqPlan, nil, "trace", registry)
+}
+
+func newTestScheduler(t *testing.T, ctx context.Context, registry *jobs.Registry, workers ...work.Worker) *Scheduler {
+	t.Helper()
+	s := New(ctx, response.New(func(substreams.ResponseFromAnyTier) error { return nil }))
+	s.Stages = newTestStages(t, ctx, registry)
+	workerIdx := 0
+	s.WorkerPool = work.NewWorkerPool(ctx, len(workers), func(*zap.Logger) work.Worker {
+		workerIdx++
+		return workers[workerIdx-1]
+	})
+	return s
+}
+
+// batchCmds runs `cmd`, returning the commands of the batch it returns.
+func batchCmds(t *testing.T, cmd loop.Cmd) []loop.Cmd {
+	t.Helper()
+	require.NotNil(t, cmd)
+	batch, ok := cmd().(loop.BatchMsg)
+	require.True(t, ok)
+	return batch
+}
+
+func TestScheduler_SharedJobWaitsTakeWorkers(t *testing.T) {
+	ctx := testContext()
+	registry := jobs.NewRegistry()
+
+	// Another request owns all the jobs this one needs.
+	other := newTestStages(t, ctx, registry)
+	var owned []*jobs.Job
+	for {
+		unit, r := other.NextJob()
+		if r == nil {
+			break
+		}
+		job, owner := other.Job(unit)
+		require.True(t, owner)
+		owned = append(owned, job)
+	}
+	require.Len(t, owned, 3)
+
+	worker := work.NewWorkerFactoryFromFunc(func(context.Context, stage.Unit, *block.Range, uint32, []string, *response.Stream) loop.Cmd {
+		t.Fatal("a job shared with another request is not run")
+		return nil
+	})
+	s := newTestScheduler(t, ctx, registry, worker)
+
+	cmds := batchCmds(t, s.Update(work.MsgScheduleNextJob{}))
+	require.Len(t, cmds, 2)
+	assert.False(t, s.WorkerPool.WorkerAvailable(), "waiting on a shared job takes a worker")
+	assert.Nil(t, s.Update(work.MsgScheduleNextJob{}), "no more waits than workers")
+
+	owned[0].Finish(nil)
+	msg := cmds[0]()
+	require.Equal(t, work.MsgJobSucceeded{Unit: stage.Unit{Segment: 0, Stage: 1}, Worker: worker}, msg)
+	s.WorkerPool.Return(worker)
+	assert.True(t, s.WorkerPool.WorkerAvailable())
+}
+
+func TestScheduler_SpeculativeDuplicate(t *testing.T) {
+	const filename = "abc/outputs/0000000000-0000000010.output"
+
+	tests := []struct {
+		name          string
+		duplicateWins bool
+		expectContent string
+	}{
+		{"duplicate wins", true, "duplicate"},
+		{"straggler wins", false, "straggler"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := testContext()
+			cacheStore, err := dstore.NewStore("file://"+t.TempDir(), "", "", true)
+			require.NoError(t, err)
+
+			// The straggler writes to the cache directly, even after it was
+			// canceled, and the duplicate writes apart.
+			release := make(chan struct{})
+			duplicateWritten := make(chan struct{})
+			write := func(ctx context.Context, attempt uint32, content string) {
+				attemptStore, err := work.AttemptStore(cacheStore, tracing.GetTraceID(ctx).String(), attempt)
+				require.NoError(t, err)
+				require.NoError(t, attemptStore.WriteObject(context.Background(), filename, strings.NewReader(content)))
+			}
+			workFunc := func(ctx context.Context, unit stage.Unit, _ *block.Range, attempt uint32, _ []string, _ *response.Stream) loop.Cmd {
+				return func() loop.Msg {
+					if attempt == 0 {
+						select {
+						case <-ctx.Done():
+						case <-release:
+						}
+						write(ctx, attempt, "straggler")
+					} else {
+						write(ctx, attempt, "duplicate")
+						close(duplicateWritten)
+						if !tt.duplicateWins {
+							<-ctx.Done()
+						}
+					}
+					if err := ctx.Err(); err != nil {
+						return work.MsgJobFailed{Unit: unit, Error: err}
+					}
+					return work.MsgJobSucceeded{Unit: unit}
+				}
+			}
+			straggler := work.NewWorkerFactoryFromFunc(workFunc)
+			duplicate := work.NewWorkerFactoryFromFunc(workFunc)
+			s := newTestScheduler(t, ctx, nil, straggler, duplicate)
+			s.CacheStore = cacheStore
+
+			cmds := batchCmds(t, s.Update(work.MsgScheduleNextJob{}))
+			require.Len(t, cmds, 2)
+			require.Len(t, s.running, 1)
+			var running *runningJob
+			for _, r := range s.running {
+				running = r
+			}
+			unit := running.unit
+			s.jobDurations[unit.Stage] = []time.Duration{time.Second, time.Second, time.Second}
+			running.started = time.Now().Add(-time.Minute)
+
+			stragglerMsg := make(chan loop.Msg)
+			stragglerCmd := cmds[0]
+			go func() { stragglerMsg <- stragglerCmd() }()
+
+			cmds = batchCmds(t, s.Update(MsgCheckStragglers{}))
+			require.Len(t, cmds, 2)
+			speculated := batchCmds(t, cmds[0])
+			require.Len(t, speculated, 1, "the straggler is duplicated")
+			assert.False(t, s.WorkerPool.WorkerAvailable())
+
+			duplicateMsg := make(chan loop.Msg)
+			go func() { duplicateMsg <- speculated[0]() }()
+			if !tt.duplicateWins {
+				<-duplicateWritten
+				close(release)
+			}
+
+			var winner, loser MsgAttemptFinished
+			if tt.duplicateWins {
+				winner = (<-duplicateMsg).(MsgAttemptFinished)
+				loser = (<-stragglerMsg).(MsgAttemptFinished)
+				assert.Equal(t, MsgAttemptFinished{Unit: unit, Worker: duplicate, Won: true}, winner)
+			} else {
+				winner = (<-stragglerMsg).(MsgAttemptFinished)
+				loser = (<-duplicateMsg).(MsgAttemptFinished)
+				assert.Equal(t, MsgAttemptFinished{Unit: unit, Worker: straggler, Won: true}, winner)
+			}
+			assert.False(t, loser.Won)
+			assert.ErrorIs(t, loser.Error, context.Canceled)
+
+			// Only the winner's file is left, the loser wrote nothing after.
+			reader, err := cacheStore.OpenObject(context.Background(), filename)
+			require.NoError(t, err)
+			content, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			reader.Close()
+			assert.Equal(t, tt.expectContent, string(content))
+
+			var attemptFiles []string
+			require.NoError(t, cacheStore.Walk(context.Background(), "attempts/", func(filename string) error {
+				attemptFiles = append(attemptFiles, filename)
+				return nil
+			}))
+			assert.Empty(t, attemptFiles, "the files of the duplicate are adopted or deleted")
+
+			cmds = batchCmds(t, s.Update(loser))
+			require.Len(t, cmds, 1)
+			assert.Equal(t, work.MsgScheduleNextJob{}, cmds[0]())
+			cmds = batchCmds(t, s.Update(winner))
+			require.Len(t, cmds, 1)
+			cmds = batchCmds(t, cmds[0])
+			require.Len(t, cmds, 2)
+			assert.Equal(t, work.MsgJobSucceeded{Unit: unit}, cmds[0]())
+			assert.Empty(t, s.running)
+			assert.Len(t, s.jobDurations[unit.Stage], 4)
+			assert.True(t, s.WorkerPool.WorkerAvailable())
+		})
+	}
+}
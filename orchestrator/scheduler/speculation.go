@@ -0,0 +1,278 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	tracing "github.com/streamingfast/sf-tracing"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/orchestrator/jobs"
+	"github.com/streamingfast/substreams/orchestrator/loop"
+	"github.com/streamingfast/substreams/orchestrator/stage"
+	"github.com/streamingfast/substreams/orchestrator/work"
+)
+
+// A job running much longer than the others of its stage, a straggler,
+// holds back the merging of the stores and the linear handoff. A
+// speculative duplicate of it is then run on another worker: the first
+// attempt to succeed wins, and the other one is canceled. The duplicate
+// writes its files apart from the cache, see work.AttemptStore: they are
+// adopted if it wins, once the job it duplicates returned, and deleted
+// otherwise.
+const (
+	// stragglerFactor is how many times the median duration of the jobs
+	// completed in its stage a job runs before it is duplicated.
+	stragglerFactor = 3
+	// stragglerMinSamples is the number of jobs that must have completed
+	// in a stage before its jobs are considered for duplication.
+	stragglerMinSamples = 3
+	// stragglerMinDuration is the duration under which a job is never
+	// duplicated.
+	stragglerMinDuration = 30 * time.Second
+	// stragglerCheckInterval is the interval at which running jobs are
+	// checked for stragglers.
+	stragglerCheckInterval = 5 * time.Second
+)
+
+// MsgAttemptFinished is sent when an attempt at running a job finished.
+// Only the winning attempt decides of the outcome of the job.
+type MsgAttemptFinished struct {
+	Unit   stage.Unit
+	Worker work.Worker
+	Won    bool
+	Error  error
+}
+
+type MsgCheckStragglers struct{}
+
+func cmdCheckStragglers(ctx context.Context) loop.Cmd {
+	return func() loop.Msg {
+		select {
+		case <-time.After(stragglerCheckInterval):
+		case <-ctx.Done():
+		}
+		return MsgCheckStragglers{}
+	}
+}
+
+// runningJob tracks the attempts at running the job of a unit.
+type runningJob struct {
+	unit      stage.Unit
+	workRange *block.Range
+	started   time.Time
+	// job is the job shared with other requests, nil when jobs are not
+	// deduplicated.
+	job *jobs.Job
+	// traceID names the files of the speculative duplicates.
+	traceID string
+
+	// jobCtx outlives the attempts, for adopting the files of the winner.
+	// ctx is canceled once an attempt won, canceling the others.
+	jobCtx context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	speculated bool
+
+	mu          sync.Mutex
+	returned    *sync.Cond // signaled when an attempt returned
+	nextAttempt uint32
+	attempts    int // running
+	done        bool
+}
+
+func newRunningJob(ctx context.Context, unit stage.Unit, workRange *block.Range, job *jobs.Job) *runningJob {
+	if job != nil {
+		// Attempts at a job shared with other requests outlive this one.
+		ctx = job.Context()
+	}
+	attemptsCtx, cancel := context.WithCancel(ctx)
+	j := &runningJob{
+		unit:      unit,
+		workRange: workRange,
+		started:   time.Now(),
+		job:       job,
+		traceID:   tracing.GetTraceID(ctx).String(),
+		jobCtx:    ctx,
+		ctx:       attemptsCtx,
+		cancel:    cancel,
+	}
+	j.returned = sync.NewCond(&j.mu)
+	return j
+}
+
+// startAttempt returns the number of a new attempt at the job, false if an
+// attempt already won.
+func (j *runningJob) startAttempt() (attempt uint32, ok bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.done {
+		return 0, false
+	}
+	attempt = j.nextAttempt
+	j.nextAttempt++
+	j.attempts++
+	return attempt, true
+}
+
+// finishAttempt reports whether the attempt finishing with `err` wins: it
+// is the first attempt to succeed, or the last one to fail.
+func (j *runningJob) finishAttempt(err error) (won bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.attempts--
+	j.returned.Broadcast()
+	if j.done || (err != nil && j.attempts > 0) {
+		return false
+	}
+	j.done = true
+	return true
+}
+
+// waitAttempts cancels the attempts still running, and waits for them to
+// return.
+func (j *runningJob) waitAttempts() {
+	j.cancel()
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for j.attempts > 0 {
+		j.returned.Wait()
+	}
+}
+
+// cmdAttempt runs an attempt at the job on `worker`. The files of a
+// winning duplicate are adopted once all the other attempts returned, so
+// that none of them writes anything after, and the ones of a losing
+// duplicate deleted. The outcome of the winning attempt is recorded on the
+// shared job right away, for the other requests waiting on it even if this
+// one went away.
+func (s *Scheduler) cmdAttempt(running *runningJob, worker work.Worker) loop.Cmd {
+	attempt, ok := running.startAttempt()
+	if !ok {
+		return func() loop.Msg {
+			return MsgAttemptFinished{Unit: running.unit, Worker: worker}
+		}
+	}
+
+	upstream := s.stream
+	if running.job != nil {
+		// A job shared with other requests reports its failures to all of
+		// them, not only to this one.
+		upstream = running.job.Upstream()
+	}
+	modules := s.Stages.StageModules(running.unit.Stage)
+	workCmd := worker.Work(running.ctx, running.unit, running.workRange, attempt, modules, upstream)
+	return func() loop.Msg {
+		var err error
+		if failed, ok := workCmd().(work.MsgJobFailed); ok {
+			err = failed.Error
+		}
+		won := running.finishAttempt(err)
+		if won {
+			running.waitAttempts()
+			if err == nil {
+				if err = work.AdoptAttempt(running.jobCtx, s.CacheStore, running.traceID, attempt); err != nil {
+					err = fmt.Errorf("adopting files of speculative duplicate: %w", err)
+				}
+			}
+		}
+		if !won || err != nil {
+			if err := work.DeleteAttempt(running.jobCtx, s.CacheStore, running.traceID, attempt); err != nil {
+				s.logger.Warn("cannot delete files of speculative duplicate", zap.Object("unit", running.unit), zap.Uint32("attempt", attempt), zap.Error(err))
+			}
+		}
+		if won && running.job != nil {
+			running.job.Finish(err)
+		}
+		return MsgAttemptFinished{Unit: running.unit, Worker: worker, Won: won, Error: err}
+	}
+}
+
+// attemptFinished returns the worker of the attempt to the pool and, for
+// the winning attempt, cancels the others and records the outcome of the
+// job.
+func (s *Scheduler) attemptFinished(msg MsgAttemptFinished) loop.Cmd {
+	s.WorkerPool.Return(msg.Worker)
+	if !msg.Won {
+		return work.CmdScheduleNextJob()
+	}
+
+	running := s.running[msg.Unit]
+	delete(s.running, msg.Unit)
+	running.cancel()
+	if msg.Error == nil {
+		s.jobDurations[msg.Unit.Stage] = append(s.jobDurations[msg.Unit.Stage], time.Since(running.started))
+	}
+
+	if running.job != nil {
+		// The outcome is received by waiting on the job.
+		return work.CmdScheduleNextJob()
+	}
+	return loop.Batch(
+		func() loop.Msg {
+			if msg.Error != nil {
+				return work.MsgJobFailed{Unit: msg.Unit, Error: msg.Error}
+			}
+			return work.MsgJobSucceeded{Unit: msg.Unit}
+		},
+		work.CmdScheduleNextJob(),
+	)
+}
+
+// speculate runs a duplicate of the stragglers on the free workers. Their
+// files are written apart in the cache store, without which no job is
+// duplicated.
+func (s *Scheduler) speculate() loop.Cmd {
+	if s.CacheStore == nil {
+		return nil
+	}
+	var cmds []loop.Cmd
+	for _, running := range s.sortedRunning() {
+		if running.speculated || !s.WorkerPool.WorkerAvailable() {
+			continue
+		}
+		median, ok := medianDuration(s.jobDurations[running.unit.Stage])
+		if !ok {
+			continue
+		}
+		elapsed := time.Since(running.started)
+		if elapsed < stragglerMinDuration || elapsed < stragglerFactor*median {
+			continue
+		}
+
+		running.speculated = true
+		s.logger.Info("running speculative duplicate of slow job",
+			zap.Object("unit", running.unit),
+			zap.Duration("elapsed", elapsed),
+			zap.Duration("stage_median", median),
+		)
+		cmds = append(cmds, s.cmdAttempt(running, s.WorkerPool.Borrow()))
+	}
+	return loop.Batch(cmds...)
+}
+
+// sortedRunning returns the running jobs, the oldest first.
+func (s *Scheduler) sortedRunning() []*runningJob {
+	out := make([]*runningJob, 0, len(s.running))
+	for _, running := range s.running {
+		out = append(out, running)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].started.Before(out[j].started)
+	})
+	return out
+}
+
+func medianDuration(durations []time.Duration) (median time.Duration, ok bool) {
+	if len(durations) < stragglerMinSamples {
+		return 0, false
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted[len(sorted)/2], true
+}
@@ -0,0 +1,41 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/streamingfast/substreams/orchestrator/stage"
+)
+
+func TestRunningJob_FinishAttempt(t *testing.T) {
+	newJob := func(attempts int) *runningJob {
+		running := newRunningJob(context.Background(), stage.Unit{Segment: 1}, nil, nil)
+		running.attempts = attempts
+		return running
+	}
+
+	running := newJob(2)
+	assert.True(t, running.finishAttempt(nil), "first success wins")
+	assert.False(t, running.finishAttempt(context.Canceled), "canceled loser")
+
+	running = newJob(2)
+	assert.False(t, running.finishAttempt(fmt.Errorf("failed")), "failure while the other attempt runs")
+	assert.True(t, running.finishAttempt(nil))
+
+	running = newJob(2)
+	assert.False(t, running.finishAttempt(fmt.Errorf("failed")))
+	assert.True(t, running.finishAttempt(fmt.Errorf("failed again")), "last failure wins")
+}
+
+func TestMedianDuration(t *testing.T) {
+	_, ok := medianDuration([]time.Duration{time.Second, time.Second})
+	assert.False(t, ok, "not enough samples")
+
+	median, ok := medianDuration([]time.Duration{5 * time.Second, time.Second, 3 * time.Second, 2 * time.Second})
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, median)
+}
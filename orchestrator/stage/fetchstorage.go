@@ -4,10 +4,13 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/abourget/llerrgroup"
 	"github.com/streamingfast/bstream"
+	"go.uber.org/zap"
+
 	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/storage/catalog"
 	"github.com/streamingfast/substreams/storage/execout"
-
 	"github.com/streamingfast/substreams/storage/store"
 	"github.com/streamingfast/substreams/storage/store/state"
 )
@@ -24,23 +27,31 @@ func (s *Stages) FetchStoresState(
 
 	upToBlock := segmenter.ExclusiveEndBlock()
 
-	var mapperName string
-	var mapperFiles execout.FileInfos
-	if lastStage := s.stages[len(s.stages)-1]; lastStage.kind == KindMap {
-		if len(lastStage.moduleStates) != 1 {
-			panic("assertion: mapper stage should contain a single module")
+	var catalogs []*catalog.Catalog
+	for _, conf := range storeConfigMap {
+		catalogs = append(catalogs, conf.Catalog())
+	}
+	lastStage := s.stages[len(s.stages)-1]
+	if lastStage.kind == KindMap {
+		for _, modState := range lastStage.moduleStates {
+			catalogs = append(catalogs, execoutConfigs.ConfigMap[modState.name].Catalog())
 		}
-
-		mapperName = lastStage.moduleStates[0].name
-		conf := execoutConfigs.ConfigMap[mapperName]
-		// TODO: OPTIMIZATION: get the actual needed range for execOutputs to optimize lookup
-
-		if upToBlock != 0 {
-			files, err := conf.ListSnapshotFiles(ctx, bstream.NewInclusiveRange(0, upToBlock))
-			if err != nil {
-				return fmt.Errorf("fetching mapper storage state: %w", err)
+	}
+	s.ensureCatalogs(ctx, catalogs)
+
+	mapperFiles := map[string]execout.FileInfos{}
+	if lastStage.kind == KindMap {
+		for _, modState := range lastStage.moduleStates {
+			conf := execoutConfigs.ConfigMap[modState.name]
+			// TODO: OPTIMIZATION: get the actual needed range for execOutputs to optimize lookup
+
+			if upToBlock != 0 {
+				files, err := conf.ListSnapshotFiles(ctx, bstream.NewInclusiveRange(0, upToBlock))
+				if err != nil {
+					return fmt.Errorf("fetching mapper storage state: %w", err)
+				}
+				mapperFiles[modState.name] = files
 			}
-			mapperFiles = files
 		}
 	}
 
@@ -54,21 +65,29 @@ func (s *Stages) FetchStoresState(
 		moduleCount := len(stage.moduleStates)
 
 		if stage.kind == KindMap {
-			if mapperFiles == nil {
-				continue
-			}
 			if stageIdx != len(s.stages)-1 {
 				panic("assertion: mapper stage is not the last stage")
 			}
-			for _, outputFile := range mapperFiles {
-				segmentIdx := s.mapSegmenter.IndexForEndBlock(outputFile.BlockRange.ExclusiveEndBlock)
-				rng := s.mapSegmenter.Range(segmentIdx)
-				if rng == nil || rng.ExclusiveEndBlock != outputFile.BlockRange.ExclusiveEndBlock {
-					continue
+			for mapperName, files := range mapperFiles {
+				// A segment spanning several intervals is complete when
+				// the output files of all of its intervals are present.
+				fileEnds := make(map[uint64]bool, len(files))
+				for _, outputFile := range files {
+					fileEnds[outputFile.BlockRange.ExclusiveEndBlock] = true
 				}
-				unit := Unit{Stage: stageIdx, Segment: segmentIdx}
-				if allDone := markFound(completes, unit, mapperName, moduleCount); allDone {
-					s.markSegmentCompleted(unit)
+				for _, outputFile := range files {
+					segmentIdx := s.mapSegmenter.IndexForEndBlock(outputFile.BlockRange.ExclusiveEndBlock)
+					rng := s.mapSegmenter.Range(segmentIdx)
+					if rng == nil || rng.ExclusiveEndBlock != outputFile.BlockRange.ExclusiveEndBlock {
+						continue
+					}
+					if !allIntervalsPresent(s.mapSegmenter.IntervalRanges(segmentIdx), fileEnds) {
+						continue
+					}
+					unit := Unit{Stage: stageIdx, Segment: segmentIdx}
+					if allDone := markFound(completes, unit, mapperName, moduleCount); allDone {
+						s.markSegmentCompleted(unit)
+					}
 				}
 			}
 
@@ -128,6 +147,39 @@ func (s *Stages) FetchStoresState(
 	return nil
 }
 
+// ensureCatalogs builds the catalogs never built, or inconsistent, for the
+// files of their module to be found without listing them, by this request
+// and the next ones. Failing that, the files are listed.
+func (s *Stages) ensureCatalogs(ctx context.Context, catalogs []*catalog.Catalog) {
+	eg := llerrgroup.New(10)
+	for _, cat := range catalogs {
+		if cat == nil {
+			continue
+		}
+		if eg.Stop() {
+			break
+		}
+
+		cat := cat
+		eg.Go(func() error {
+			if err := cat.Ensure(ctx); err != nil {
+				s.logger.Warn("cannot build catalog, listing files", zap.Error(err))
+			}
+			return nil
+		})
+	}
+	eg.Wait()
+}
+
+func allIntervalsPresent(ranges []*block.Range, fileEnds map[uint64]bool) bool {
+	for _, rng := range ranges {
+		if !fileEnds[rng.ExclusiveEndBlock] {
+			return false
+		}
+	}
+	return true
+}
+
 type unitMap map[Unit]map[string]struct{}
 
 func markFound(unitMap unitMap, unit Unit, name string, moduleCount int) bool {
@@ -21,6 +21,12 @@ type ModuleState struct {
 
 	cachedStore      *store.FullKV
 	lastBlockInStore uint64
+
+	// When the store is merged from files, see store.Config.StreamingMerge,
+	// the full store file the pending partials are merged on top of, nil
+	// for the empty store, and the partials not yet merged.
+	baseFile        *store.FileInfo
+	pendingPartials []*store.FileInfo
 }
 
 func NewModuleState(logger *zap.Logger, name string, segmenter *block.Segmenter, storeConfig *store.Config) *ModuleState {
@@ -38,18 +44,59 @@ func (s *ModuleState) getStore(ctx context.Context, exclusiveEndBlock uint64) (*
 	}
 	loadStore := s.storeConfig.NewFullKV(s.logger)
 	moduleInitBlock := s.storeConfig.ModuleInitialBlock()
-	if moduleInitBlock != exclusiveEndBlock {
+	if s.lastBlockInStore == exclusiveEndBlock && len(s.pendingPartials) != 0 {
+		err := loadStore.LoadMergedFiles(ctx, s.baseFile, s.pendingPartials)
+		if err != nil {
+			return nil, fmt.Errorf("load store %q: %w", s.name, err)
+		}
+	} else if moduleInitBlock != exclusiveEndBlock {
 		fullKVFile := store.NewCompleteFileInfo(s.name, moduleInitBlock, exclusiveEndBlock)
 		err := loadStore.Load(ctx, fullKVFile)
 		if err != nil {
 			return nil, fmt.Errorf("load store %q: %w", s.name, err)
 		}
 	}
+	s.dropCachedStore()
 	s.cachedStore = loadStore
 	s.lastBlockInStore = exclusiveEndBlock
 	return loadStore, nil
 }
 
+// addPendingPartial queues `partialFile` to be merged from files. When the
+// store is not already at the partial's start block, the pending partials
+// are dropped and merging starts over from the full store file ending at
+// that block.
+func (s *ModuleState) addPendingPartial(partialFile *store.FileInfo) {
+	startBlock := partialFile.Range.StartBlock
+	if s.lastBlockInStore != startBlock {
+		s.baseFile = nil
+		if moduleInitBlock := s.storeConfig.ModuleInitialBlock(); moduleInitBlock != startBlock {
+			s.baseFile = store.NewCompleteFileInfo(s.name, moduleInitBlock, startBlock)
+		}
+		s.pendingPartials = nil
+	}
+	s.pendingPartials = append(s.pendingPartials, partialFile)
+	s.lastBlockInStore = partialFile.Range.ExclusiveEndBlock
+	s.dropCachedStore()
+}
+
+// mergedPendingPartials records that the pending partials were merged into
+// the full store file `fullFile`.
+func (s *ModuleState) mergedPendingPartials(fullFile *store.FileInfo) {
+	s.baseFile = fullFile
+	s.pendingPartials = nil
+}
+
+func (s *ModuleState) dropCachedStore() {
+	if s.cachedStore == nil {
+		return
+	}
+	if err := s.cachedStore.Close(); err != nil {
+		s.logger.Warn("closing replaced store", zap.String("store", s.name), zap.Error(err))
+	}
+	s.cachedStore = nil
+}
+
 func (s *ModuleState) derivePartialKV(initialBlock uint64) *store.PartialKV {
 	return s.storeConfig.NewPartialKV(initialBlock, s.logger)
 }
@@ -7,6 +7,7 @@ import (
 
 	"go.uber.org/zap"
 
+	"github.com/streamingfast/substreams/block"
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/storage/store"
 )
@@ -54,13 +55,32 @@ func (s *Stages) multiSquash(stage *Stage, mergeUnit Unit) error {
 // We keep the cache of the latest FullKV store, to speed up things
 // if they are linear
 func (s *Stages) singleSquash(stage *Stage, modState *ModuleState, mergeUnit Unit) error {
+	if modState.storeConfig.StreamingMerge() {
+		return s.streamingSquash(stage, modState, mergeUnit)
+	}
+
+	// A segment spanning several intervals has one partial per interval,
+	// and a full store is saved at the end of each of them.
+	for _, rng := range modState.segmenter.IntervalRanges(mergeUnit.Segment) {
+		if err := s.squashRange(stage, modState, mergeUnit, rng); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Stages) squashRange(stage *Stage, modState *ModuleState, mergeUnit Unit, rng *block.Range) error {
 	metrics := mergeMetrics{}
 	metrics.start = time.Now()
 
-	rng := modState.segmenter.Range(mergeUnit.Segment)
-	partialFile := store.NewPartialFileInfo(modState.name, rng.StartBlock, rng.ExclusiveEndBlock, s.traceID)
+	partialFile := store.NewPartialFileInfo(modState.name, rng.StartBlock, rng.ExclusiveEndBlock, s.partialTraceID(mergeUnit))
 	partialKV := modState.derivePartialKV(rng.StartBlock)
-	segmentEndsOnInterval := modState.segmenter.EndsOnInterval(mergeUnit.Segment)
+	defer func() {
+		if err := partialKV.Close(); err != nil {
+			s.logger.Warn("closing partial store", zap.Stringer("store", partialKV), zap.Error(err))
+		}
+	}()
+	segmentEndsOnInterval := rng.ExclusiveEndBlock%modState.segmenter.Interval() == 0
 
 	// Retrieve store to merge, from cache or load from storage. Allows skipping of segments
 	// for handling partials interspearsed with full KVs.
@@ -85,7 +105,7 @@ func (s *Stages) singleSquash(stage *Stage, modState *ModuleState, mergeUnit Uni
 	metrics.mergeEnd = time.Now()
 
 	// Delete partial store
-	if reqctx.Details(s.ctx).ProductionMode || segmentEndsOnInterval { /* FIXME: compute this elsewhere? */
+	if (reqctx.Details(s.ctx).ProductionMode || segmentEndsOnInterval) && s.releasePartial(mergeUnit, partialFile) { /* FIXME: compute this elsewhere? */
 		s.logger.Info("deleting store", zap.Stringer("store", partialKV))
 		stage.asyncWork.Go(func() error {
 			return partialKV.DeleteStore(s.ctx, partialFile)
@@ -110,3 +130,55 @@ func (s *Stages) singleSquash(stage *Stage, modState *ModuleState, mergeUnit Uni
 
 	return nil
 }
+
+// streamingSquash is the singleSquash of stores merged from files: partials
+// are only queued until the segment ends on an interval, where they are all
+// merged in a single pass with the previous full store file, without loading
+// any of them in memory.
+func (s *Stages) streamingSquash(stage *Stage, modState *ModuleState, mergeUnit Unit) error {
+	for _, rng := range modState.segmenter.IntervalRanges(mergeUnit.Segment) {
+		metrics := mergeMetrics{}
+		metrics.start = time.Now()
+
+		partialFile := store.NewPartialFileInfo(modState.name, rng.StartBlock, rng.ExclusiveEndBlock, s.partialTraceID(mergeUnit))
+		modState.addPendingPartial(partialFile)
+
+		// Partials are deleted once merged into a full store file, as they are
+		// read again when merging.
+		if rng.ExclusiveEndBlock%modState.segmenter.Interval() != 0 {
+			continue
+		}
+
+		partials := modState.pendingPartials
+		metrics.mergeStart = time.Now()
+		fullFile, writer, err := modState.storeConfig.SaveMergedFiles(s.ctx, modState.baseFile, partials, rng.ExclusiveEndBlock)
+		if err != nil {
+			return fmt.Errorf("merging: %w", err)
+		}
+		metrics.mergeEnd = time.Now()
+
+		// The full store file is written synchronously, as the next merge starts from it.
+		metrics.saveStart = time.Now()
+		if err := writer.Write(context.Background()); err != nil { // always write files here even if the request was cancelled.
+			return fmt.Errorf("save full store: %w", err)
+		}
+		metrics.saveEnd = time.Now()
+		modState.mergedPendingPartials(fullFile)
+
+		for _, partial := range partials {
+			partial := partial // capture in loop
+			partialUnit := Unit{Stage: stage.idx, Segment: modState.segmenter.IndexForStartBlock(partial.Range.StartBlock)}
+			if !s.releasePartial(partialUnit, partial) {
+				continue
+			}
+			s.logger.Info("deleting store", zap.String("store", modState.name), zap.String("file", partial.Filename))
+			stage.asyncWork.Go(func() error {
+				return modState.storeConfig.DeletePartialFile(s.ctx, partial)
+			})
+		}
+
+		s.logger.Info("squashing time metrics", metrics.logFields()...)
+	}
+
+	return nil
+}
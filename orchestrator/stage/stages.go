@@ -3,14 +3,18 @@ package stage
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
 
 	"go.uber.org/zap"
 
 	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/orchestrator/jobs"
 	"github.com/streamingfast/substreams/orchestrator/loop"
 	"github.com/streamingfast/substreams/orchestrator/plan"
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"github.com/streamingfast/substreams/pipeline/outputmodules"
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/storage/store"
@@ -48,7 +52,27 @@ type Stages struct {
 	// Any previous segment is assumed to have completed successfully, and any stores that we sync'd prior to this offset
 	// are assumed to have been either fully loaded, or merged up until this offset.
 	segmentOffset int
+
+	// jobRegistry, when not nil, deduplicates the jobs of the stages with
+	// the identical jobs of the other requests, identified by the hashes of
+	// the modules run up to each stage in jobStages[stageIdx], of the ones
+	// it produces in jobModules[stageIdx], and of the output modules.
+	jobRegistry      *jobs.Registry
+	jobStages        []string
+	jobModules       []string
+	jobOutputModules string
+	jobsLock         sync.Mutex
+	attachedJobs     map[Unit]*attachedJob
+	jobsLeft         bool
+}
+
+type attachedJob struct {
+	job   *jobs.Job
+	owner bool
+	// released are the partial files of the job merged by this request.
+	released []*store.FileInfo
 }
+
 type stageStates []UnitState
 
 func NewStages(
@@ -57,6 +81,7 @@ func NewStages(
 	reqPlan *plan.RequestPlan,
 	storeConfigs store.ConfigMap,
 	traceID string,
+	jobRegistry *jobs.Registry,
 ) (out *Stages) {
 
 	if !reqPlan.RequiresParallelProcessing() {
@@ -66,11 +91,23 @@ func NewStages(
 	logger := reqctx.Logger(ctx)
 
 	stagedModules := outputGraph.StagedUsedModules()
+	moduleHashes := func(modules []*pbsubstreams.Module) string {
+		var hashes []string
+		for _, mod := range modules {
+			hashes = append(hashes, outputGraph.ModuleHashes().Get(mod.Name))
+		}
+		sort.Strings(hashes)
+		return strings.Join(hashes, ",")
+	}
+	var stagesHashes []string
+
 	out = &Stages{
 		ctx:             ctx,
 		traceID:         traceID,
 		logger:          reqctx.Logger(ctx),
 		globalSegmenter: reqPlan.BackprocessSegmenter(),
+		jobRegistry:     jobRegistry,
+		attachedJobs:    make(map[Unit]*attachedJob),
 	}
 	if reqPlan.BuildStores != nil {
 		out.storeSegmenter = reqPlan.StoresSegmenter()
@@ -78,13 +115,21 @@ func NewStages(
 	if reqPlan.WriteExecOut != nil {
 		out.mapSegmenter = reqPlan.WriteOutSegmenter()
 	}
+	if jobRegistry != nil {
+		out.jobOutputModules = moduleHashes(outputGraph.OutputModules())
+	}
 	for idx, stageLayers := range stagedModules {
 		var allModules []string
+		var stageModules []*pbsubstreams.Module
 		for _, layer := range stageLayers {
 			for _, mod := range layer {
 				allModules = append(allModules, mod.Name)
+				stageModules = append(stageModules, mod)
 			}
 		}
+		if jobRegistry != nil {
+			stagesHashes = append(stagesHashes, moduleHashes(stageModules))
+		}
 		layer := stageLayers.LastLayer()
 		kind := layerKind(layer)
 
@@ -103,6 +148,12 @@ func NewStages(
 			segmenter = reqPlan.StoresSegmenter()
 		}
 
+		if kind == KindMap {
+			// The map stage produces the output of all the output modules,
+			// not only the ones of its last layer.
+			layer = stageOutputModules(outputGraph, stageLayers)
+		}
+
 		var moduleStates []*ModuleState
 		stageLowestInitBlock := layer[0].InitialBlock
 		for _, mod := range layer {
@@ -116,6 +167,11 @@ func NewStages(
 		stageSegmenter := segmenter.WithInitialBlock(stageLowestInitBlock)
 		stage := NewStage(idx, kind, stageSegmenter, moduleStates, allModules)
 		out.stages = append(out.stages, stage)
+
+		if jobRegistry != nil {
+			out.jobStages = append(out.jobStages, strings.Join(stagesHashes, "|"))
+			out.jobModules = append(out.jobModules, moduleHashes(layer))
+		}
 	}
 
 	out.initSegmentsOffset(reqPlan)
@@ -123,6 +179,17 @@ func NewStages(
 	return out
 }
 
+func stageOutputModules(outputGraph *outputmodules.Graph, stageLayers outputmodules.StageLayers) (out outputmodules.LayerModules) {
+	for _, layer := range stageLayers {
+		for _, mod := range layer {
+			if outputGraph.IsOutputModule(mod.Name) {
+				out = append(out, mod)
+			}
+		}
+	}
+	return out
+}
+
 func layerKind(layer outputmodules.LayerModules) Kind {
 	if layer.IsStoreLayer() {
 		return KindStore
@@ -376,12 +443,116 @@ func (s *Stages) NextJob() (Unit, *block.Range) {
 			}
 
 			s.markSegmentScheduled(unit)
+			s.acquireJob(unit, r)
 			return unit, r
 		}
 	}
 	return Unit{}, nil
 }
 
+// acquireJob attaches the unit to the identical job in flight for another
+// request, if any, or registers it as a new job.
+func (s *Stages) acquireJob(unit Unit, r *block.Range) {
+	if s.jobRegistry == nil {
+		return
+	}
+	reqDetails := reqctx.Details(s.ctx)
+	key := jobs.Key{
+		CacheTag:          reqDetails.CacheTag,
+		Stages:            s.jobStages[unit.Stage],
+		Modules:           s.jobModules[unit.Stage],
+		OutputModules:     s.jobOutputModules,
+		ProductionMode:    reqDetails.ProductionMode,
+		StartBlock:        r.StartBlock,
+		ExclusiveEndBlock: r.ExclusiveEndBlock,
+	}
+	job, owner := s.jobRegistry.Acquire(s.ctx, key, s.traceID)
+	if !owner {
+		s.logger.Info("attaching to job in flight", zap.Object("unit", unit), zap.Stringer("job", key))
+	}
+
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+	s.attachedJobs[unit] = &attachedJob{job: job, owner: owner}
+}
+
+// Job returns the job registered for the unit by NextJob, and whether this
+// request owns it and must run it. It returns a nil job when jobs are not
+// deduplicated.
+func (s *Stages) Job(unit Unit) (job *jobs.Job, owner bool) {
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+	attached := s.attachedJobs[unit]
+	if attached == nil {
+		return nil, false
+	}
+	return attached.job, attached.owner
+}
+
+// partialTraceID is the trace ID naming the partial files of the unit: the
+// one of the request owning the job that produced them.
+func (s *Stages) partialTraceID(unit Unit) string {
+	if job, _ := s.Job(unit); job != nil {
+		return job.TraceID()
+	}
+	return s.traceID
+}
+
+// releasePartial reports whether the partial file of the unit can be
+// deleted once merged: the partial files of a job shared with other
+// requests are deleted by the last of them.
+func (s *Stages) releasePartial(unit Unit, partial *store.FileInfo) bool {
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+	attached := s.attachedJobs[unit]
+	if attached == nil {
+		return true
+	}
+	if s.jobsLeft {
+		return false
+	}
+	attached.released = append(attached.released, partial)
+	return attached.job.Release(partial)
+}
+
+// LeaveJobs detaches the request from the jobs shared with other requests,
+// once it merged their partial files or went away. The partial files that
+// the other requests already merged, and that this one will not, are
+// deleted on their behalf.
+func (s *Stages) LeaveJobs() {
+	s.jobsLock.Lock()
+	defer s.jobsLock.Unlock()
+	if s.jobsLeft {
+		return
+	}
+	s.jobsLeft = true
+
+	ctx := context.WithoutCancel(s.ctx)
+	for _, attached := range s.attachedJobs {
+		for _, partial := range attached.job.Leave(attached.released) {
+			modState := s.moduleState(partial.ModuleName)
+			if modState == nil {
+				continue
+			}
+			s.logger.Info("deleting store", zap.String("store", partial.ModuleName), zap.String("file", partial.Filename))
+			if err := modState.storeConfig.DeletePartialFile(ctx, partial); err != nil {
+				s.logger.Warn("cannot delete partial store file", zap.String("file", partial.Filename), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *Stages) moduleState(name string) *ModuleState {
+	for _, stage := range s.stages {
+		for _, modState := range stage.moduleStates {
+			if modState.name == name {
+				return modState
+			}
+		}
+	}
+	return nil
+}
+
 func (s *Stages) allocSegments(segmentIdx int) {
 	segmentsNeeded := segmentIdx - s.segmentOffset
 	if len(s.segmentStates) > segmentsNeeded {
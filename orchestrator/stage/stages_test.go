@@ -7,15 +7,19 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/manifest"
+	"github.com/streamingfast/substreams/orchestrator/jobs"
 	"github.com/streamingfast/substreams/orchestrator/plan"
 	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/reqctx"
 )
 
 func TestNewStages(t *testing.T) {
 	//seg := block.NewSegmenter(10, 5, 75)
-	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 5, 5, 75, 75, true)
+	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 5, 5, 75, 75, true, nil)
 	assert.NoError(t, err)
 
 	stages := NewStages(
@@ -24,6 +28,7 @@ func TestNewStages(t *testing.T) {
 		reqPlan,
 		nil,
 		"trace",
+		nil,
 	)
 
 	assert.Equal(t, 8, stages.globalSegmenter.Count()) // from 5 to 75
@@ -41,7 +46,7 @@ func TestNewStages(t *testing.T) {
 
 func TestNewStagesNextJobs(t *testing.T) {
 	//seg := block.NewSegmenter(10, 5, 50)
-	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 5, 5, 50, 50, true)
+	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 5, 5, 50, 50, true, nil)
 	assert.NoError(t, err)
 	assert.Equal(t, "interval=10, stores=[5, 40), map_write=[5, 50), map_read=[5, 50), linear=[nil)", reqPlan.String())
 	stages := NewStages(
@@ -50,6 +55,7 @@ func TestNewStagesNextJobs(t *testing.T) {
 		reqPlan,
 		nil,
 		"trace",
+		nil,
 	)
 
 	stages.allocSegments(0)
@@ -257,3 +263,37 @@ func TestStages_allocSegments(t *testing.T) {
 		})
 	}
 }
+
+func TestStages_jobKey(t *testing.T) {
+	pkg := manifest.TestReadManifest(t, "../../test/testdata/substreams-test-v0.1.0.spkg")
+	reqPlan, err := plan.BuildTier1RequestPlan(true, 10, 0, 0, 30, 30, true, nil)
+	require.NoError(t, err)
+
+	stageZeroKey := func(outputModule string, productionMode bool) jobs.Key {
+		outputGraph, err := outputmodules.NewOutputModulesGraph([]string{outputModule}, productionMode, pkg.Modules)
+		require.NoError(t, err)
+
+		ctx := reqctx.WithRequest(context.Background(), &reqctx.RequestDetails{CacheTag: "tag", ProductionMode: productionMode})
+		stages := NewStages(ctx, outputGraph, reqPlan, nil, "trace", jobs.NewRegistry())
+		stages.allocSegments(0)
+		unit := Unit{Stage: 0, Segment: 0}
+		stages.acquireJob(unit, stages.storeSegmenter.Range(0))
+		job, owner := stages.Job(unit)
+		require.True(t, owner)
+		return job.Key()
+	}
+
+	key := stageZeroKey("assert_test_store_add_i64", true)
+	assert.Equal(t, key, stageZeroKey("assert_test_store_add_i64", true))
+	assert.Equal(t, uint64(0), key.StartBlock)
+	assert.Equal(t, uint64(10), key.ExclusiveEndBlock)
+
+	// The same store is built by the first stage of both requests, which
+	// are not shared as they run a different request.
+	otherOutput := stageZeroKey("assert_test_store_add_i64_deltas", true)
+	assert.Equal(t, key.Stages, otherOutput.Stages)
+	assert.Equal(t, key.Modules, otherOutput.Modules)
+	assert.NotEqual(t, key, otherOutput)
+
+	assert.NotEqual(t, key, stageZeroKey("assert_test_store_add_i64", false))
+}
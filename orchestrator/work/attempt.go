@@ -0,0 +1,93 @@
+package work
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/streamingfast/derr"
+	"github.com/streamingfast/dstore"
+)
+
+// The speculative duplicates of a job write their files apart from the
+// cache, each one under its own `attempts/<traceID>-<attempt>` directory,
+// so that an attempt never writes over the files of another. Once an
+// attempt won, tier1 adopts its files, moving them to the cache, and
+// deletes the ones of the attempts that lost. The first attempt at a job
+// writes to the cache directly.
+
+func attemptDir(traceID string, attempt uint32) string {
+	return fmt.Sprintf("attempts/%s-%d", traceID, attempt)
+}
+
+// AttemptStore returns the store to which the attempt `attempt` at a job of
+// the request of `traceID` writes its files: `cacheStore` itself for the
+// first attempt.
+func AttemptStore(cacheStore dstore.Store, traceID string, attempt uint32) (dstore.Store, error) {
+	if attempt == 0 {
+		return cacheStore, nil
+	}
+	return cacheStore.SubStore(attemptDir(traceID, attempt))
+}
+
+// AdoptAttempt moves the files written by the attempt `attempt` at a job of
+// the request of `traceID` to `cacheStore`, replacing the ones of the same
+// name.
+func AdoptAttempt(ctx context.Context, cacheStore dstore.Store, traceID string, attempt uint32) error {
+	if attempt == 0 {
+		return nil
+	}
+
+	prefix := attemptDir(traceID, attempt) + "/"
+	files, err := attemptFiles(ctx, cacheStore, prefix)
+	if err != nil {
+		return err
+	}
+	for _, filename := range files {
+		dest := strings.TrimPrefix(filename, prefix)
+		err := derr.RetryContext(ctx, 5, func(ctx context.Context) error {
+			return cacheStore.CopyObject(ctx, filename, dest)
+		})
+		if err != nil {
+			return fmt.Errorf("adopting %s: %w", dest, err)
+		}
+	}
+	return deleteFiles(ctx, cacheStore, files)
+}
+
+// DeleteAttempt deletes the files written by the attempt `attempt` at a job
+// of the request of `traceID`.
+func DeleteAttempt(ctx context.Context, cacheStore dstore.Store, traceID string, attempt uint32) error {
+	if attempt == 0 {
+		return nil
+	}
+
+	files, err := attemptFiles(ctx, cacheStore, attemptDir(traceID, attempt)+"/")
+	if err != nil {
+		return err
+	}
+	return deleteFiles(ctx, cacheStore, files)
+}
+
+func attemptFiles(ctx context.Context, cacheStore dstore.Store, prefix string) (files []string, err error) {
+	err = cacheStore.Walk(ctx, prefix, func(filename string) error {
+		files = append(files, filename)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing files of %s: %w", prefix, err)
+	}
+	return files, nil
+}
+
+func deleteFiles(ctx context.Context, cacheStore dstore.Store, files []string) error {
+	for _, filename := range files {
+		err := derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+			return cacheStore.DeleteObject(ctx, filename)
+		})
+		if err != nil {
+			return fmt.Errorf("deleting %s: %w", filename, err)
+		}
+	}
+	return nil
+}
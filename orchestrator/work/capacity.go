@@ -0,0 +1,168 @@
+package work
+
+import (
+	"context"
+	"sync"
+
+	"github.com/streamingfast/substreams/metrics"
+)
+
+// Priority orders the jobs waiting for a worker slot: jobs of a higher
+// priority are always leased a slot first.
+type Priority int
+
+const (
+	PriorityHistory Priority = iota
+	// PriorityLiveCatchup is given to the jobs that a request streaming live
+	// blocks waits on to catch up with the chain head.
+	PriorityLiveCatchup
+)
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityLiveCatchup:
+		return "live_catchup"
+	default:
+		return "history"
+	}
+}
+
+// CapacityScheduler leases the worker slots of the tier2 fleet to the jobs
+// of all the requests of a tier1. Among the jobs of the highest priority
+// waiting, a slot goes to the user with the fewest slots leased relative to
+// its weight, and a user's jobs are leased slots in the order they queued.
+type CapacityScheduler struct {
+	mu sync.Mutex
+
+	slots   int
+	weights map[string]float64
+	leased  map[string]int // slots leased, by user
+	total   int
+	queue   []*lease
+}
+
+type lease struct {
+	user     string
+	priority Priority
+	granted  chan struct{}
+}
+
+// NewCapacityScheduler returns a scheduler of `slots` worker slots. The
+// share of the slots of a user relative to others is its weight in
+// `weights`, 1 by default.
+func NewCapacityScheduler(slots int, weights map[string]float64) *CapacityScheduler {
+	return &CapacityScheduler{
+		slots:   slots,
+		weights: weights,
+		leased:  make(map[string]int),
+	}
+}
+
+// Lease blocks until a worker slot is leased to a job of `user`, or `ctx`
+// is done. The slot must be given back by calling `release`.
+func (c *CapacityScheduler) Lease(ctx context.Context, user string, priority Priority) (release func(), err error) {
+	l := &lease{
+		user:     user,
+		priority: priority,
+		granted:  make(chan struct{}),
+	}
+
+	c.mu.Lock()
+	c.queue = append(c.queue, l)
+	metrics.Tier2JobsQueued.Inc(priority.String())
+	c.dispatch()
+	c.mu.Unlock()
+
+	select {
+	case <-l.granted:
+		return c.releaseFunc(user), nil
+	case <-ctx.Done():
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-l.granted:
+		c.release(user)
+	default:
+		c.dequeue(l)
+	}
+	return nil, ctx.Err()
+}
+
+// Queued returns the number of jobs waiting for a worker slot.
+func (c *CapacityScheduler) Queued() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue)
+}
+
+// Leased returns the number of worker slots leased to the jobs of `user`.
+func (c *CapacityScheduler) Leased(user string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.leased[user]
+}
+
+func (c *CapacityScheduler) releaseFunc(user string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.release(user)
+		})
+	}
+}
+
+func (c *CapacityScheduler) release(user string) {
+	c.total--
+	c.leased[user]--
+	if c.leased[user] == 0 {
+		delete(c.leased, user)
+	}
+	metrics.Tier2JobsLeased.Dec()
+	c.dispatch()
+}
+
+// dispatch leases the free slots to the jobs waiting.
+func (c *CapacityScheduler) dispatch() {
+	for c.total < c.slots && len(c.queue) != 0 {
+		l := c.next()
+		c.dequeue(l)
+		c.total++
+		c.leased[l.user]++
+		metrics.Tier2JobsLeased.Inc()
+		close(l.granted)
+	}
+}
+
+// next returns the first job queued among those of the highest priority
+// and of the user with the smallest share of the slots.
+func (c *CapacityScheduler) next() (out *lease) {
+	var outShare float64
+	for _, l := range c.queue {
+		share := float64(c.leased[l.user]) / c.weight(l.user)
+		if out == nil || l.priority > out.priority || (l.priority == out.priority && share < outShare) {
+			out, outShare = l, share
+		}
+	}
+	return out
+}
+
+func (c *CapacityScheduler) weight(user string) float64 {
+	if weight, found := c.weights[user]; found && weight > 0 {
+		return weight
+	}
+	return 1
+}
+
+func (c *CapacityScheduler) dequeue(l *lease) {
+	for i, queued := range c.queue {
+		if queued == l {
+			c.queue = append(c.queue[:i], c.queue[i+1:]...)
+			metrics.Tier2JobsQueued.Dec(l.priority.String())
+			return
+		}
+	}
+}
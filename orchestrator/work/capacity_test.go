@@ -0,0 +1,110 @@
+package work
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	"github.com/streamingfast/substreams/reqctx"
+)
+
+// leaseAsync leases a slot in the background, sending the user on `granted`
+// once leased.
+func leaseAsync(t *testing.T, c *CapacityScheduler, user string, priority Priority, granted chan<- string) {
+	t.Helper()
+	queued := c.Queued()
+	go func() {
+		_, err := c.Lease(context.Background(), user, priority)
+		if err == nil {
+			granted <- user
+		}
+	}()
+	require.Eventually(t, func() bool { return c.Queued() == queued+1 }, time.Second, time.Millisecond)
+}
+
+func TestCapacityScheduler_FairShare(t *testing.T) {
+	c := NewCapacityScheduler(2, nil)
+
+	releaseA, err := c.Lease(context.Background(), "alice", PriorityHistory)
+	require.NoError(t, err)
+	releaseB, err := c.Lease(context.Background(), "alice", PriorityHistory)
+	require.NoError(t, err)
+	assert.Equal(t, 2, c.Leased("alice"))
+
+	granted := make(chan string, 10)
+	leaseAsync(t, c, "alice", PriorityHistory, granted)
+	leaseAsync(t, c, "bob", PriorityHistory, granted)
+
+	// Bob has no slot, while alice has two.
+	releaseA()
+	assert.Equal(t, "bob", <-granted)
+	releaseA() // releasing twice is a no-op
+	assert.Equal(t, 1, c.Queued())
+
+	releaseB()
+	assert.Equal(t, "alice", <-granted)
+	assert.Equal(t, 0, c.Queued())
+}
+
+func TestCapacityScheduler_Weights(t *testing.T) {
+	c := NewCapacityScheduler(1, map[string]float64{"carol": 3})
+
+	release, err := c.Lease(context.Background(), "alice", PriorityHistory)
+	require.NoError(t, err)
+
+	granted := make(chan string, 10)
+	// Both have one slot once the first one is leased, carol's weight
+	// making her share smaller.
+	leaseAsync(t, c, "carol", PriorityHistory, granted)
+	leaseAsync(t, c, "alice", PriorityHistory, granted)
+	release()
+	assert.Equal(t, "carol", <-granted)
+}
+
+func TestCapacityScheduler_Priority(t *testing.T) {
+	c := NewCapacityScheduler(1, nil)
+
+	release, err := c.Lease(context.Background(), "bob", PriorityHistory)
+	require.NoError(t, err)
+
+	granted := make(chan string, 10)
+	leaseAsync(t, c, "alice", PriorityHistory, granted)
+	leaseAsync(t, c, "bob", PriorityLiveCatchup, granted)
+	release()
+	assert.Equal(t, "bob", <-granted)
+}
+
+func TestCapacityScheduler_Cancel(t *testing.T) {
+	c := NewCapacityScheduler(1, nil)
+
+	release, err := c.Lease(context.Background(), "alice", PriorityHistory)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = c.Lease(ctx, "bob", PriorityHistory)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 0, c.Queued())
+
+	release()
+	assert.Equal(t, 0, c.Leased("alice"))
+	_, err = c.Lease(context.Background(), "bob", PriorityHistory)
+	assert.NoError(t, err)
+}
+
+func Test_jobPriority(t *testing.T) {
+	job := func(start, stop uint64) *pbssinternal.ProcessRangeRequest {
+		return &pbssinternal.ProcessRangeRequest{StartBlockNum: start, StopBlockNum: stop}
+	}
+	live := &reqctx.RequestDetails{LinearHandoffBlockNum: 10_000}
+	history := &reqctx.RequestDetails{LinearHandoffBlockNum: 10_000, StopBlockNum: 10_000}
+
+	assert.Equal(t, PriorityLiveCatchup, jobPriority(live, job(9_000, 10_000)))
+	assert.Equal(t, PriorityLiveCatchup, jobPriority(live, job(7_000, 8_000)))
+	assert.Equal(t, PriorityHistory, jobPriority(live, job(6_000, 7_000)))
+	assert.Equal(t, PriorityHistory, jobPriority(history, job(9_000, 10_000)))
+}
@@ -37,10 +37,12 @@ type Result struct {
 
 type Worker interface {
 	ID() string
-	Work(ctx context.Context, unit stage.Unit, workRange *block.Range, moduleNames []string, upstream *response.Stream) loop.Cmd // *Result
+	// Work runs the attempt `attempt` at the job of `unit`, 0 being the job
+	// itself and the others its speculative duplicates.
+	Work(ctx context.Context, unit stage.Unit, workRange *block.Range, attempt uint32, moduleNames []string, upstream *response.Stream) loop.Cmd // *Result
 }
 
-func NewWorkerFactoryFromFunc(f func(ctx context.Context, unit stage.Unit, workRange *block.Range, moduleNames []string, upstream *response.Stream) loop.Cmd) *SimpleWorkerFactory {
+func NewWorkerFactoryFromFunc(f func(ctx context.Context, unit stage.Unit, workRange *block.Range, attempt uint32, moduleNames []string, upstream *response.Stream) loop.Cmd) *SimpleWorkerFactory {
 	return &SimpleWorkerFactory{
 		f:  f,
 		id: atomic.AddUint64(&lastWorkerID, 1),
@@ -48,12 +50,12 @@ func NewWorkerFactoryFromFunc(f func(ctx context.Context, unit stage.Unit, workR
 }
 
 type SimpleWorkerFactory struct {
-	f  func(ctx context.Context, unit stage.Unit, workRange *block.Range, moduleNames []string, upstream *response.Stream) loop.Cmd
+	f  func(ctx context.Context, unit stage.Unit, workRange *block.Range, attempt uint32, moduleNames []string, upstream *response.Stream) loop.Cmd
 	id uint64
 }
 
-func (f SimpleWorkerFactory) Work(ctx context.Context, unit stage.Unit, workRange *block.Range, moduleNames []string, upstream *response.Stream) loop.Cmd {
-	return f.f(ctx, unit, workRange, moduleNames, upstream)
+func (f SimpleWorkerFactory) Work(ctx context.Context, unit stage.Unit, workRange *block.Range, attempt uint32, moduleNames []string, upstream *response.Stream) loop.Cmd {
+	return f.f(ctx, unit, workRange, attempt, moduleNames, upstream)
 }
 
 func (f SimpleWorkerFactory) ID() string {
@@ -65,14 +67,19 @@ type WorkerFactory = func(logger *zap.Logger) Worker
 
 type RemoteWorker struct {
 	clientFactory client.InternalClientFactory
+	capacity      *CapacityScheduler
 	tracer        ttrace.Tracer
 	logger        *zap.Logger
 	id            uint64
 }
 
-func NewRemoteWorker(clientFactory client.InternalClientFactory, logger *zap.Logger) *RemoteWorker {
+// NewRemoteWorker returns a worker running jobs on tier2 through
+// `clientFactory`. When `capacity` is not nil, each job first waits for a
+// worker slot leased by it.
+func NewRemoteWorker(clientFactory client.InternalClientFactory, capacity *CapacityScheduler, logger *zap.Logger) *RemoteWorker {
 	return &RemoteWorker{
 		clientFactory: clientFactory,
+		capacity:      capacity,
 		tracer:        otel.GetTracerProvider().Tracer("worker"),
 		logger:        logger,
 		id:            atomic.AddUint64(&lastWorkerID, 1),
@@ -83,18 +90,20 @@ func (w *RemoteWorker) ID() string {
 	return fmt.Sprintf("%d", w.id)
 }
 
-func NewRequest(req *reqctx.RequestDetails, stageIndex int, workRange *block.Range) *pbssinternal.ProcessRangeRequest {
+func NewRequest(req *reqctx.RequestDetails, stageIndex int, workRange *block.Range, attempt uint32) *pbssinternal.ProcessRangeRequest {
 	return &pbssinternal.ProcessRangeRequest{
 		StartBlockNum: workRange.StartBlock,
 		StopBlockNum:  workRange.ExclusiveEndBlock,
 		Modules:       req.Modules,
 		OutputModule:  req.OutputModule,
+		OutputModules: req.OutputModules,
 		Stage:         uint32(stageIndex),
+		Attempt:       attempt,
 	}
 }
 
-func (w *RemoteWorker) Work(ctx context.Context, unit stage.Unit, workRange *block.Range, moduleNames []string, upstream *response.Stream) loop.Cmd {
-	request := NewRequest(reqctx.Details(ctx), unit.Stage, workRange)
+func (w *RemoteWorker) Work(ctx context.Context, unit stage.Unit, workRange *block.Range, attempt uint32, moduleNames []string, upstream *response.Stream) loop.Cmd {
+	request := NewRequest(reqctx.Details(ctx), unit.Stage, workRange, attempt)
 	logger := reqctx.Logger(ctx)
 
 	return func() loop.Msg {
@@ -172,6 +181,26 @@ func (w *RemoteWorker) work(ctx context.Context, request *pbssinternal.ProcessRa
 	)
 	logger := w.logger
 
+	// A job resuming from a checkpoint only processes the blocks after it.
+	startBlock := request.StartBlockNum
+	if request.ResumeFromBlock != 0 {
+		startBlock = request.ResumeFromBlock
+	}
+
+	stats := reqctx.ReqStats(ctx)
+	jobIdx := stats.RecordNewSubrequest(request.Stage, startBlock, request.StopBlockNum)
+	defer stats.RecordEndSubrequest(jobIdx)
+
+	if w.capacity != nil {
+		stats.RecordJobQueued(jobIdx, true)
+		release, err := w.capacity.Lease(ctx, dauth.FromContext(ctx).UserID(), jobPriority(reqctx.Details(ctx), request))
+		stats.RecordJobQueued(jobIdx, false)
+		if err != nil {
+			return &Result{Error: err}
+		}
+		defer release()
+	}
+
 	grpcClient, closeFunc, grpcCallOpts, err := w.clientFactory()
 	if err != nil {
 		return &Result{Error: fmt.Errorf("unable to create grpc client: %w", err)}
@@ -181,12 +210,9 @@ func (w *RemoteWorker) work(ctx context.Context, request *pbssinternal.ProcessRa
 		zap.Int64("start_block_num", int64(request.StartBlockNum)),
 		zap.Uint64("stop_block_num", request.StopBlockNum),
 		zap.String("output_module", request.OutputModule),
+		zap.Uint64("resume_from_block", request.ResumeFromBlock),
 	)
 
-	stats := reqctx.ReqStats(ctx)
-	jobIdx := stats.RecordNewSubrequest(request.Stage, request.StartBlockNum, request.StopBlockNum)
-	defer stats.RecordEndSubrequest(jobIdx)
-
 	ctx = dauth.FromContext(ctx).ToOutgoingGRPCContext(ctx)
 	stream, err := grpcClient.ProcessRange(ctx, request, grpcCallOpts...)
 	if err != nil {
@@ -236,6 +262,10 @@ func (w *RemoteWorker) work(ctx context.Context, request *pbssinternal.ProcessRa
 			switch r := resp.Type.(type) {
 			case *pbssinternal.ProcessRangeResponse_Update:
 				stats.RecordJobUpdate(jobIdx, r.Update)
+				if r.Update.CheckpointBlock != 0 {
+					// retries of the job resume from its last checkpoint
+					request.ResumeFromBlock = r.Update.CheckpointBlock
+				}
 
 			case *pbssinternal.ProcessRangeResponse_Failed:
 				// FIXME(abourget): we do NOT emit those Failed objects anymore. There was a flow
@@ -275,6 +305,24 @@ func (w *RemoteWorker) work(ctx context.Context, request *pbssinternal.ProcessRa
 	}
 }
 
+// liveCatchupSegments is the number of segments before the linear handoff
+// block of a request streaming live blocks for which its jobs have the
+// PriorityLiveCatchup.
+const liveCatchupSegments = 2
+
+// jobPriority gives the PriorityLiveCatchup to the jobs ending close to the
+// linear handoff block of a request that then streams live blocks: it waits
+// on them to catch up with the chain head.
+func jobPriority(req *reqctx.RequestDetails, job *pbssinternal.ProcessRangeRequest) Priority {
+	if req.StopBlockNum != 0 && req.StopBlockNum <= req.LinearHandoffBlockNum {
+		return PriorityHistory
+	}
+	if job.StopBlockNum+liveCatchupSegments*(job.StopBlockNum-job.StartBlockNum) < req.LinearHandoffBlockNum {
+		return PriorityHistory
+	}
+	return PriorityLiveCatchup
+}
+
 func toRPCPartialFiles(completed *pbssinternal.Completed) (out store.FileInfos) {
 	// TODO(abourget): Add the MODULE Name in there, so we know to which modules each of those things
 	// are attached in the tier1.
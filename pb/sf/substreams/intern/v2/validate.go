@@ -6,11 +6,24 @@ import (
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 )
 
+// RequestedOutputModules returns the names of all the output modules of the
+// request, `output_module` being the first one.
+func (r *ProcessRangeRequest) RequestedOutputModules() []string {
+	if len(r.OutputModules) != 0 {
+		return r.OutputModules
+	}
+	return []string{r.OutputModule}
+}
+
 func (r *ProcessRangeRequest) Validate() error {
 	if r.StartBlockNum >= r.StopBlockNum {
 		return fmt.Errorf("stop block %d should be higher than start block %d", r.StopBlockNum, r.StartBlockNum)
 	}
 
+	if r.ResumeFromBlock != 0 && (r.ResumeFromBlock <= r.StartBlockNum || r.ResumeFromBlock >= r.StopBlockNum) {
+		return fmt.Errorf("resume from block %d should be within start block %d and stop block %d", r.ResumeFromBlock, r.StartBlockNum, r.StopBlockNum)
+	}
+
 	if r.Modules == nil {
 		return fmt.Errorf("no modules found in request")
 	}
@@ -19,18 +32,22 @@ func (r *ProcessRangeRequest) Validate() error {
 		return fmt.Errorf("no output module defined in request")
 	}
 
+	if len(r.OutputModules) != 0 && r.OutputModules[0] != r.OutputModule {
+		return fmt.Errorf("output module %q should be the first of output modules %v", r.OutputModule, r.OutputModules)
+	}
+
 	seenStores := map[string]bool{}
-	outputModuleFound := false
+	seenModules := map[string]bool{}
 	for _, mod := range r.Modules.Modules {
 		if _, ok := mod.Kind.(*pbsubstreams.Module_KindStore_); ok {
 			seenStores[mod.Name] = true
 		}
-		if mod.Name == r.OutputModule { // internal request can have store or module output
-			outputModuleFound = true
-		}
+		seenModules[mod.Name] = true // internal request can have store or module output
 	}
-	if !outputModuleFound {
-		return fmt.Errorf("output module %q not found in modules", r.OutputModule)
+	for _, outputModule := range r.RequestedOutputModules() {
+		if !seenModules[outputModule] {
+			return fmt.Errorf("output module %q not found in modules", outputModule)
+		}
 	}
 
 	return nil
@@ -53,7 +53,12 @@ func (s *StoreModuleOutput) ToAny() *AnyModuleOutput {
 }
 
 func (bd *BlockScopedData) AllModuleOutputs() (out []*AnyModuleOutput) {
-	out = append(out, bd.Output.ToAny())
+	if bd.Output != nil {
+		out = append(out, bd.Output.ToAny())
+	}
+	for _, mapOut := range bd.Outputs {
+		out = append(out, mapOut.ToAny())
+	}
 	for _, mapOut := range bd.DebugMapOutputs {
 		out = append(out, mapOut.ToAny())
 	}
@@ -63,6 +68,15 @@ func (bd *BlockScopedData) AllModuleOutputs() (out []*AnyModuleOutput) {
 	return
 }
 
+// RequestedOutputModules returns the names of the modules whose output is
+// requested, either from `output_modules` or from the single `output_module`.
+func (req *Request) RequestedOutputModules() []string {
+	if len(req.OutputModules) != 0 {
+		return req.OutputModules
+	}
+	return []string{req.OutputModule}
+}
+
 func (req *Request) Validate() error {
 	seenStores := map[string]bool{}
 
@@ -70,28 +84,40 @@ func (req *Request) Validate() error {
 		return fmt.Errorf("no modules found in request")
 	}
 
-	if req.OutputModule == "" {
+	if req.OutputModule == "" && len(req.OutputModules) == 0 {
 		return fmt.Errorf("no output module defined in request")
 	}
 
+	if req.OutputModule != "" && len(req.OutputModules) != 0 {
+		return fmt.Errorf("cannot set both 'output_module' and 'output_modules'")
+	}
+
 	if req.DebugInitialStoreSnapshotForModules != nil && req.ProductionMode {
 		return fmt.Errorf("cannot set 'debug-modules-initial-snapshot' in 'production-mode'")
 	}
 
-	outputModuleFound := false
+	outputModulesFound := map[string]bool{}
+	for _, outputModule := range req.RequestedOutputModules() {
+		if _, found := outputModulesFound[outputModule]; found {
+			return fmt.Errorf("output module %q requested more than once", outputModule)
+		}
+		outputModulesFound[outputModule] = false
+	}
 	for _, mod := range req.Modules.Modules {
 		if _, ok := mod.Kind.(*pbsubstreams.Module_KindStore_); ok {
 			seenStores[mod.Name] = true
 		}
-		if mod.Name == req.OutputModule {
+		if _, requested := outputModulesFound[mod.Name]; requested {
 			if _, ok := mod.Kind.(*pbsubstreams.Module_KindStore_); ok {
 				return fmt.Errorf("output module must be of kind 'map'")
 			}
-			outputModuleFound = true
+			outputModulesFound[mod.Name] = true
 		}
 	}
-	if !outputModuleFound {
-		return fmt.Errorf("output module %q not found in modules", req.OutputModule)
+	for _, outputModule := range req.RequestedOutputModules() {
+		if !outputModulesFound[outputModule] {
+			return fmt.Errorf("output module %q not found in modules", outputModule)
+		}
 	}
 
 	for _, storeSnapshot := range req.DebugInitialStoreSnapshotForModules {
@@ -19,6 +19,11 @@ func Test_ValidateRequest(t *testing.T) {
 		{"negative start block num", TestNewRequest(-1, withTestOutputModule("output_mod_1"), withTestMapModule("output_mod_1")), nil},
 		{"no modules found in request", &Request{StartBlockNum: 1}, fmt.Errorf("no modules found in request")},
 		{"store output module is accepted for sub-request", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestStoreModule("output_mod_1")), fmt.Errorf("output module must be of kind 'map'")},
+		{"several output modules", TestNewRequest(1, withTestOutputModules("output_mod_1", "output_mod_2"), withTestMapModule("output_mod_1"), withTestMapModule("output_mod_2")), nil},
+		{"one of several output modules not found", TestNewRequest(1, withTestOutputModules("output_mod_1", "output_mod_2"), withTestMapModule("output_mod_1")), fmt.Errorf("output module \"output_mod_2\" not found in modules")},
+		{"output module requested twice", TestNewRequest(1, withTestOutputModules("output_mod_1", "output_mod_1"), withTestMapModule("output_mod_1")), fmt.Errorf("output module \"output_mod_1\" requested more than once")},
+		{"store in several output modules", TestNewRequest(1, withTestOutputModules("output_mod_1", "output_mod_2"), withTestMapModule("output_mod_1"), withTestStoreModule("output_mod_2")), fmt.Errorf("output module must be of kind 'map'")},
+		{"both output module and output modules", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestOutputModules("output_mod_1"), withTestMapModule("output_mod_1")), fmt.Errorf("cannot set both 'output_module' and 'output_modules'")},
 		{"production mode should fail with debug flag", TestNewRequest(1, withTestOutputModule("output_mod_1"), withTestMapModule("output_mod_1"), withProductionMode(), withDebugSnapshotsModule("output_mod_1")), fmt.Errorf("cannot set 'debug-modules-initial-snapshot' in 'production-mode'")},
 	}
 
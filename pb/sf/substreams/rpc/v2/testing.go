@@ -36,6 +36,13 @@ func withTestOutputModule(module string) testNewRequestOption {
 	}
 }
 
+func withTestOutputModules(modules ...string) testNewRequestOption {
+	return func(req *Request) *Request {
+		req.OutputModules = append(req.OutputModules, modules...)
+		return req
+	}
+}
+
 func withTestStoreModule(name string) testNewRequestOption {
 	return func(req *Request) *Request {
 		req.Modules.Modules = append(req.Modules.Modules, TestNewStoreModule(name))
@@ -12,6 +12,7 @@ import (
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"github.com/streamingfast/substreams/service/config"
 	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/index"
 )
 
 // Engine manages the reversible segments and keeps track of
@@ -25,16 +26,18 @@ type Engine struct {
 	blockType         string
 	reversibleBuffers map[uint64]*execout.Buffer // block num to modules' outputs for that given block
 	execOutputWriter  *execout.Writer            // moduleName => irreversible File
+	indexWriter       *index.Writer              // block index modules => irreversible index files
 	runtimeConfig     config.RuntimeConfig       // TODO(abourget): Deprecated: remove this as it's not used
 	logger            *zap.Logger
 }
 
-func NewEngine(ctx context.Context, runtimeConfig config.RuntimeConfig, execOutWriter *execout.Writer, blockType string) (*Engine, error) {
+func NewEngine(ctx context.Context, runtimeConfig config.RuntimeConfig, execOutWriter *execout.Writer, indexWriter *index.Writer, blockType string) (*Engine, error) {
 	e := &Engine{
 		ctx:               ctx,
 		runtimeConfig:     runtimeConfig,
 		reversibleBuffers: map[uint64]*execout.Buffer{},
 		execOutputWriter:  execOutWriter,
+		indexWriter:       indexWriter,
 		logger:            reqctx.Logger(ctx),
 		blockType:         blockType,
 	}
@@ -65,7 +68,15 @@ func (e *Engine) HandleFinal(clock *pbsubstreams.Clock) error {
 	}
 
 	if e.execOutputWriter != nil {
-		e.execOutputWriter.Write(clock, execOutBuf)
+		if err := e.execOutputWriter.Write(e.ctx, clock, execOutBuf); err != nil {
+			return fmt.Errorf("writing exec output: %w", err)
+		}
+	}
+
+	if e.indexWriter != nil {
+		if err := e.indexWriter.Write(clock, execOutBuf); err != nil {
+			return fmt.Errorf("writing block index: %w", err)
+		}
 	}
 
 	delete(e.reversibleBuffers, clock.Number)
@@ -78,9 +89,58 @@ func (e *Engine) HandleStalled(clock *pbsubstreams.Clock) error {
 	return nil
 }
 
+// Checkpoint saves a checkpoint of the outputs and block indexes of the
+// blocks before `blockNum`, for the tier2 job of `traceID` to resume from.
+func (e *Engine) Checkpoint(ctx context.Context, blockNum uint64, traceID string) error {
+	if e.execOutputWriter != nil {
+		if err := e.execOutputWriter.Checkpoint(ctx, blockNum, traceID); err != nil {
+			return fmt.Errorf("exec output writer: %w", err)
+		}
+	}
+	if e.indexWriter != nil {
+		if err := e.indexWriter.Checkpoint(ctx, blockNum, traceID); err != nil {
+			return fmt.Errorf("block index writer: %w", err)
+		}
+	}
+	return nil
+}
+
+// Resume restores the outputs and block indexes from the checkpoint saved
+// at `blockNum` by the tier2 job of `traceID`. The block indexes are
+// restored first: processing the blocks again over them, should the
+// outputs fail to restore, yields the same indexes.
+func (e *Engine) Resume(ctx context.Context, blockNum uint64, traceID string) error {
+	if e.indexWriter != nil {
+		if err := e.indexWriter.Resume(ctx, blockNum, traceID); err != nil {
+			return fmt.Errorf("block index writer: %w", err)
+		}
+	}
+	if e.execOutputWriter != nil {
+		if err := e.execOutputWriter.Resume(ctx, blockNum, traceID); err != nil {
+			return fmt.Errorf("exec output writer: %w", err)
+		}
+	}
+	return nil
+}
+
+// DeleteCheckpoints deletes the last checkpoint saved, or resumed from.
+func (e *Engine) DeleteCheckpoints(ctx context.Context) {
+	if e.execOutputWriter != nil {
+		e.execOutputWriter.DeleteCheckpoints(ctx)
+	}
+	if e.indexWriter != nil {
+		e.indexWriter.DeleteCheckpoints(ctx)
+	}
+}
+
 func (e *Engine) EndOfStream(lastFinalClock *pbsubstreams.Clock) error {
 	if e.execOutputWriter != nil {
 		e.execOutputWriter.Close(context.Background())
 	}
+	if e.indexWriter != nil {
+		if err := e.indexWriter.Close(context.Background()); err != nil {
+			return fmt.Errorf("closing block index writer: %w", err)
+		}
+	}
 	return nil
 }
@@ -0,0 +1,192 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+// A checkpoint holds the state of a tier2 job before `blockNum`: the
+// stores, along with the outputs and block indexes being written, saved by
+// the cache engine, all of them apart from the cache in the `checkpoints`
+// directory of their module hash. A retry of the job resumes from it
+// instead of processing its range from the start.
+type checkpoint struct {
+	blockNum   uint64
+	savedAt    time.Time
+	storeFiles map[string]string // checkpoint names, by store name
+}
+
+// maybeCheckpoint saves a checkpoint after the final block of `clock` when
+// the checkpoint interval elapsed since the last one, and reports it to
+// tier1 right away. No checkpoint is saved on a store boundary, on which
+// the partial stores are written anyway.
+func (p *Pipeline) maybeCheckpoint(ctx context.Context, clock *pbsubstreams.Clock) error {
+	if p.checkpointInterval == 0 {
+		return nil
+	}
+
+	blockNum := clock.Number + 1
+	if isBlockOverStopBlock(blockNum, reqctx.Details(ctx).StopBlockNum) || blockNum%p.runtimeConfig.StateBundleSize == 0 {
+		return nil
+	}
+
+	lastSaved := p.startTime
+	if p.checkpoint != nil {
+		lastSaved = p.checkpoint.savedAt
+	}
+	if time.Since(lastSaved) < p.checkpointInterval {
+		return nil
+	}
+
+	if err := p.saveCheckpoint(ctx, blockNum); err != nil {
+		return fmt.Errorf("saving checkpoint at block %d: %w", blockNum, err)
+	}
+	return p.returnInternalModuleProgressOutputs(clock, true)
+}
+
+func (p *Pipeline) saveCheckpoint(ctx context.Context, blockNum uint64) (err error) {
+	ctx, span := reqctx.WithSpan(ctx, "substreams/tier2/pipeline/save_checkpoint")
+	defer span.EndWithErr(&err)
+
+	storeFiles := make(map[string]string)
+	for name, st := range p.stores.StoreMap.All() {
+		if st.InitialBlock() >= blockNum {
+			continue // no block processed yet
+		}
+		filename, err := st.SaveCheckpoint(ctx, blockNum, p.traceID)
+		if err != nil {
+			return fmt.Errorf("saving store %q: %w", name, err)
+		}
+		storeFiles[name] = filename
+	}
+
+	if err := p.execOutputCache.Checkpoint(ctx, blockNum, p.traceID); err != nil {
+		return err
+	}
+
+	p.deleteStoreCheckpoint(ctx)
+	p.checkpoint = &checkpoint{
+		blockNum:   blockNum,
+		savedAt:    time.Now(),
+		storeFiles: storeFiles,
+	}
+	reqctx.Logger(ctx).Info("checkpoint saved", zap.Uint64("block_num", blockNum), zap.Int("store_count", len(storeFiles)))
+	return nil
+}
+
+// resumeFromCheckpoint restores the stores, outputs and block indexes of
+// the job from the checkpoint saved at `blockNum` by a previous attempt of
+// it. Nothing is restored if any part of the checkpoint cannot be loaded.
+func (p *Pipeline) resumeFromCheckpoint(ctx context.Context, blockNum uint64) (err error) {
+	ctx, span := reqctx.WithSpan(ctx, "substreams/tier2/pipeline/resume_from_checkpoint")
+	defer span.EndWithErr(&err)
+
+	storeMap, storeFiles, err := p.loadCheckpointStores(ctx, blockNum)
+	if err != nil {
+		return err
+	}
+
+	if err := p.execOutputCache.Resume(ctx, blockNum, p.traceID); err != nil {
+		for _, st := range storeMap.All() {
+			st.Close()
+		}
+		return err
+	}
+
+	p.stores.SetStoreMap(storeMap)
+	p.stores.bounder.InitNextBoundary(blockNum)
+	p.processingModule.initialBlockNum = blockNum
+	p.checkpoint = &checkpoint{
+		blockNum:   blockNum,
+		savedAt:    time.Now(),
+		storeFiles: storeFiles,
+	}
+	return nil
+}
+
+// loadCheckpointStores loads the stores of the job as saved by
+// saveCheckpoint: the partial stores of the last stage hold the state from
+// the start of the job, or from the last boundary they were rolled on,
+// and the full stores of the previous stages the state from their module's
+// initial block.
+func (p *Pipeline) loadCheckpointStores(ctx context.Context, blockNum uint64) (store.Map, map[string]string, error) {
+	reqDetails := reqctx.Details(ctx)
+	logger := reqctx.Logger(ctx)
+
+	storeMap := store.NewMap()
+	storeFiles := make(map[string]string)
+	closeStores := func() {
+		for _, st := range storeMap.All() {
+			st.Close()
+		}
+	}
+
+	lastStage := len(p.executionStages) - 1
+	for stageIdx, stage := range p.executionStages {
+		layer := stage.LastLayer()
+		if !layer.IsStoreLayer() {
+			continue
+		}
+		for _, mod := range layer {
+			storeConfig := p.stores.configs[mod.Name]
+
+			if stageIdx == lastStage {
+				partialStart := max(reqDetails.ResolvedStartBlockNum, blockNum-blockNum%p.runtimeConfig.StateBundleSize)
+				partialStore := storeConfig.NewPartialKV(partialStart, logger)
+				storeMap.Set(partialStore)
+
+				filename, err := partialStore.LoadCheckpoint(ctx, blockNum, p.traceID)
+				if err != nil {
+					closeStores()
+					return nil, nil, err
+				}
+				storeFiles[mod.Name] = filename
+				continue
+			}
+
+			fullStore := storeConfig.NewFullKV(logger)
+			storeMap.Set(fullStore)
+			if fullStore.InitialBlock() >= blockNum {
+				continue
+			}
+
+			filename, err := fullStore.LoadCheckpoint(ctx, blockNum, p.traceID)
+			if err != nil {
+				closeStores()
+				return nil, nil, err
+			}
+			storeFiles[mod.Name] = filename
+		}
+	}
+	return storeMap, storeFiles, nil
+}
+
+// deleteCheckpoint deletes the last checkpoint, once the job completed.
+func (p *Pipeline) deleteCheckpoint(ctx context.Context) {
+	p.deleteStoreCheckpoint(ctx)
+	p.execOutputCache.DeleteCheckpoints(ctx)
+	p.checkpoint = nil
+}
+
+func (p *Pipeline) deleteStoreCheckpoint(ctx context.Context) {
+	if p.checkpoint == nil {
+		return
+	}
+	for name, filename := range p.checkpoint.storeFiles {
+		p.stores.configs[name].DeleteCheckpoint(ctx, filename)
+	}
+}
+
+func (p *Pipeline) checkpointBlock() uint64 {
+	if p.checkpoint == nil {
+		return 0
+	}
+	return p.checkpoint.blockNum
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/index"
 	"github.com/streamingfast/substreams/wasm"
 )
 
@@ -20,6 +21,7 @@ type BaseExecutor struct {
 	moduleName    string
 	wasmModule    wasm.Module
 	wasmArguments []wasm.Argument
+	blockFilters  []*index.BlockFilter
 	entrypoint    string
 	tracer        ttrace.Tracer
 
@@ -32,13 +34,14 @@ type BaseExecutor struct {
 	executionStack []string
 }
 
-func NewBaseExecutor(ctx context.Context, moduleName string, wasmModule wasm.Module, cacheEnabled bool, wasmArguments []wasm.Argument, entrypoint string, tracer ttrace.Tracer) *BaseExecutor {
+func NewBaseExecutor(ctx context.Context, moduleName string, wasmModule wasm.Module, cacheEnabled bool, wasmArguments []wasm.Argument, blockFilters []*index.BlockFilter, entrypoint string, tracer ttrace.Tracer) *BaseExecutor {
 	return &BaseExecutor{
 		ctx:                  ctx,
 		moduleName:           moduleName,
 		wasmModule:           wasmModule,
 		instanceCacheEnabled: cacheEnabled,
 		wasmArguments:        wasmArguments,
+		blockFilters:         blockFilters,
 		entrypoint:           entrypoint,
 		tracer:               tracer,
 	}
@@ -51,6 +54,16 @@ func (e *BaseExecutor) wasmCall(outputGetter execout.ExecutionOutputGetter) (cal
 	e.logsTruncated = false
 	e.executionStack = nil
 
+	// Like for empty inputs below, the VM is not called on blocks ruled out
+	// by the block filters of the module's inputs.
+	matches, err := e.matchesBlockFilters(outputGetter)
+	if err != nil {
+		return nil, err
+	}
+	if !matches {
+		return nil, nil
+	}
+
 	hasInput := false
 	for _, input := range e.wasmArguments {
 		switch v := input.(type) {
@@ -110,6 +123,24 @@ func (e *BaseExecutor) wasmCall(outputGetter execout.ExecutionOutputGetter) (cal
 	return
 }
 
+func (e *BaseExecutor) matchesBlockFilters(outputGetter execout.ExecutionOutputGetter) (bool, error) {
+	for _, filter := range e.blockFilters {
+		data, _, err := outputGetter.Get(filter.Module)
+		if err != nil && err != execout.NotFound {
+			return false, fmt.Errorf("block index %q: %w", filter.Module, err)
+		}
+
+		matches, err := filter.Matches(data)
+		if err != nil {
+			return false, err
+		}
+		if !matches {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (e *BaseExecutor) Close(ctx context.Context) error {
 	if e.cachedInstance != nil {
 		return e.cachedInstance.Close(ctx)
@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"context"
+	"time"
 
 	"github.com/streamingfast/substreams"
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
@@ -44,6 +45,24 @@ func WithFinalBlocksOnly() Option {
 	}
 }
 
+// WithCheckpoints makes a tier2 job save a checkpoint of its progress every
+// `interval`, for a retry of it to resume from. No checkpoint is saved when
+// `interval` is 0.
+func WithCheckpoints(interval time.Duration) Option {
+	return func(p *Pipeline) {
+		p.checkpointInterval = interval
+	}
+}
+
+// WithResumeFromBlock makes a tier2 job resume from the checkpoint saved at
+// `blockNum` by a previous attempt of it, processing its range from the
+// start when the checkpoint cannot be loaded.
+func WithResumeFromBlock(blockNum uint64) Option {
+	return func(p *Pipeline) {
+		p.resumeFromBlock = blockNum
+	}
+}
+
 func WithHighestStage(stage uint32) Option {
 	return func(p *Pipeline) {
 		s := int(stage)
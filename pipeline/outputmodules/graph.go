@@ -2,6 +2,7 @@ package outputmodules
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/streamingfast/substreams/manifest"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
@@ -15,59 +16,84 @@ type Graph struct {
 	stores            []*pbsubstreams.Module // subset of allModules: only the stores
 	lowestInitBlock   uint64
 
-	outputModule *pbsubstreams.Module
+	outputModules []*pbsubstreams.Module // in the order requested, the first one being the `OutputModule()`
 
 	schedulableModules      []*pbsubstreams.Module // stores and output mappers needed to execute to produce output for all `output_modules`.
 	schedulableAncestorsMap map[string][]string    // modules that are ancestors (therefore dependencies) of a given module
 }
 
-func (g *Graph) OutputModule() *pbsubstreams.Module   { return g.outputModule }
-func (g *Graph) Stores() []*pbsubstreams.Module       { return g.stores }
-func (g *Graph) UsedModules() []*pbsubstreams.Module  { return g.usedModules }
-func (g *Graph) StagedUsedModules() ExecutionStages   { return g.stagedUsedModules }
-func (g *Graph) IsOutputModule(name string) bool      { return g.outputModule.Name == name }
-func (g *Graph) ModuleHashes() *manifest.ModuleHashes { return g.moduleHashes }
-func (g *Graph) LowestInitBlock() uint64              { return g.lowestInitBlock }
+func (g *Graph) OutputModule() *pbsubstreams.Module    { return g.outputModules[0] }
+func (g *Graph) OutputModules() []*pbsubstreams.Module { return g.outputModules }
+func (g *Graph) Stores() []*pbsubstreams.Module        { return g.stores }
+func (g *Graph) UsedModules() []*pbsubstreams.Module   { return g.usedModules }
+func (g *Graph) StagedUsedModules() ExecutionStages    { return g.stagedUsedModules }
+func (g *Graph) ModuleHashes() *manifest.ModuleHashes  { return g.moduleHashes }
+func (g *Graph) LowestInitBlock() uint64               { return g.lowestInitBlock }
+
+func (g *Graph) IsOutputModule(name string) bool {
+	for _, mod := range g.outputModules {
+		if mod.Name == name {
+			return true
+		}
+	}
+	return false
+}
 
 func NewOutputModuleGraph(outputModule string, productionMode bool, modules *pbsubstreams.Modules) (out *Graph, err error) {
+	return NewOutputModulesGraph([]string{outputModule}, productionMode, modules)
+}
+
+// NewOutputModulesGraph computes the graph of the modules needed to produce the output of all the
+// `outputModules`, the first one being the graph's `OutputModule()`.
+func NewOutputModulesGraph(outputModules []string, productionMode bool, modules *pbsubstreams.Modules) (out *Graph, err error) {
 	out = &Graph{
 		requestModules: modules,
 	}
-	if err := out.computeGraph(outputModule, productionMode, modules); err != nil {
+	if err := out.computeGraph(outputModules, productionMode, modules); err != nil {
 		return nil, fmt.Errorf("module graph: %w", err)
 	}
 
 	return out, nil
 }
 
-func (g *Graph) computeGraph(outputModule string, productionMode bool, modules *pbsubstreams.Modules) error {
+func (g *Graph) computeGraph(outputModules []string, productionMode bool, modules *pbsubstreams.Modules) error {
 	graph, err := manifest.NewModuleGraph(modules.Modules)
 	if err != nil {
 		return fmt.Errorf("compute graph: %w", err)
 	}
-	outputModuleName := outputModule
 
-	processModules, err := graph.ModulesDownTo(outputModuleName)
+	processModules, err := modulesDownTo(graph, outputModules)
 	if err != nil {
 		return fmt.Errorf("building execution moduleGraph: %w", err)
 	}
 	g.usedModules = processModules
-	g.stagedUsedModules = computeStages(processModules)
 	g.lowestInitBlock = computeLowestInitBlock(processModules)
 
 	if err := g.hashModules(graph); err != nil {
 		return fmt.Errorf("cannot hash module: %w", err)
 	}
 
-	g.outputModule = computeOutputModule(g.usedModules, outputModuleName)
+	for _, outputModuleName := range outputModules {
+		g.outputModules = append(g.outputModules, computeOutputModule(g.usedModules, outputModuleName))
+	}
 
-	storeModules, err := graph.StoresDownTo(g.outputModule.Name)
+	storeModules := computeStores(g.usedModules)
+	g.stores = storeModules
+
+	lastStageModules, err := computeLastStageModules(graph, g.usedModules, storeModules, g.outputModules)
 	if err != nil {
-		return fmt.Errorf("stores down: %w", err)
+		return fmt.Errorf("computing last stage: %w", err)
 	}
-	g.stores = storeModules
+	if productionMode {
+		for _, mod := range g.outputModules {
+			if mod.GetKindStore() == nil && !lastStageModules[mod.Name] {
+				return fmt.Errorf("output module %q cannot be an input of a store required by another output module in production mode", mod.Name)
+			}
+		}
+	}
+	g.stagedUsedModules = computeStages(processModules, lastStageModules)
 
-	g.schedulableModules = computeSchedulableModules(storeModules, g.outputModule, productionMode)
+	g.schedulableModules = computeSchedulableModules(storeModules, g.outputModules, productionMode)
 
 	ancestorsMap, err := computeSchedulableAncestors(graph, g.schedulableModules)
 	if err != nil {
@@ -78,6 +104,98 @@ func (g *Graph) computeGraph(outputModule string, productionMode bool, modules *
 	return nil
 }
 
+// modulesDownTo returns, in the order of the module graph, the modules needed to
+// produce the output of all the `outputModules`.
+func modulesDownTo(graph *manifest.ModuleGraph, outputModules []string) ([]*pbsubstreams.Module, error) {
+	if len(outputModules) == 1 {
+		return graph.ModulesDownTo(outputModules[0])
+	}
+
+	sortedModules, ok := graph.TopologicalSort()
+	if !ok {
+		return nil, fmt.Errorf("could not get topological sort of module graph")
+	}
+	topologicalIndex := map[string]int{}
+	for i, mod := range sortedModules {
+		topologicalIndex[mod.Name] = i
+	}
+
+	var out []*pbsubstreams.Module
+	alreadyAdded := map[string]bool{}
+	for _, outputModule := range outputModules {
+		mods, err := graph.ModulesDownTo(outputModule)
+		if err != nil {
+			return nil, err
+		}
+		for _, mod := range mods {
+			if alreadyAdded[mod.Name] {
+				continue
+			}
+			out = append(out, mod)
+			alreadyAdded[mod.Name] = true
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return topologicalIndex[out[i].Name] > topologicalIndex[out[j].Name]
+	})
+	return out, nil
+}
+
+// computeStores returns the stores among the `usedModules`, in the same order.
+func computeStores(usedModules []*pbsubstreams.Module) (out []*pbsubstreams.Module) {
+	for _, mod := range usedModules {
+		if mod.GetKindStore() != nil {
+			out = append(out, mod)
+		}
+	}
+	return out
+}
+
+// computeLastStageModules returns the output modules, along with the modules depending
+// on them, that no store depends on. Those are only executed to produce the requested
+// outputs, so they are all kept for the last stage: this way, the jobs of that stage
+// write the output of all the output modules at once.
+func computeLastStageModules(graph *manifest.ModuleGraph, usedModules []*pbsubstreams.Module, stores []*pbsubstreams.Module, outputModules []*pbsubstreams.Module) (map[string]bool, error) {
+	storeAncestors := map[string]bool{}
+	for _, store := range stores {
+		ancestors, err := graph.AncestorsOf(store.Name)
+		if err != nil {
+			return nil, fmt.Errorf("getting ancestors of store %s: %w", store.Name, err)
+		}
+		for _, ancestor := range ancestors {
+			storeAncestors[ancestor.Name] = true
+		}
+	}
+
+	out := map[string]bool{}
+	for _, mod := range usedModules {
+		if mod.GetKindStore() != nil || storeAncestors[mod.Name] {
+			continue
+		}
+		ancestors, err := graph.AncestorsOf(mod.Name)
+		if err != nil {
+			return nil, fmt.Errorf("getting ancestors of module %s: %w", mod.Name, err)
+		}
+		for _, outputModule := range outputModules {
+			if outputModule.Name == mod.Name || containsModule(ancestors, outputModule.Name) {
+				out[mod.Name] = true
+				break
+			}
+		}
+	}
+	return out, nil
+}
+
+func containsModule(modules []*pbsubstreams.Module, name string) bool {
+	for _, mod := range modules {
+		if mod.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 func computeLowestInitBlock(modules []*pbsubstreams.Module) (out uint64) {
 	lowest := modules[0].InitialBlock
 	for _, mod := range modules {
@@ -116,20 +234,48 @@ func (l LayerModules) IsStoreLayer() bool {
 	return l[0].GetKindStore() != nil
 }
 
-func computeStages(mods []*pbsubstreams.Module) (stages ExecutionStages) {
+func computeStages(mods []*pbsubstreams.Module, lastStageModules map[string]bool) (stages ExecutionStages) {
 	seen := map[string]bool{}
 
-	var layers StageLayers
+	var firstMods, lastMods []*pbsubstreams.Module
+	for _, mod := range mods {
+		if lastStageModules[mod.Name] {
+			lastMods = append(lastMods, mod)
+		} else {
+			firstMods = append(firstMods, mod)
+		}
+	}
+
+	layers := computeLayers(nil, firstMods, seen)
+	layers = computeLayers(layers, lastMods, seen)
+
+	lastLayerIndex := len(layers) - 1
+	var newStage StageLayers
+	for idx, layer := range layers {
+		isLastStage := idx == lastLayerIndex
+		isStoreLayer := layer.IsStoreLayer()
 
-	for i := 0; ; i++ {
-		if len(seen) == len(mods) {
-			break
+		newStage = append(newStage, layer)
+		if isStoreLayer || isLastStage {
+			stages = append(stages, newStage)
+			newStage = nil
 		}
+	}
+
+	return stages
+}
+
+// computeLayers appends to `layers` the layers of execution of `mods`, alternating
+// layers of stores and layers of maps, each module coming after the modules it
+// depends on, which are either in `mods` or already `seen`.
+func computeLayers(layers StageLayers, mods []*pbsubstreams.Module, seen map[string]bool) StageLayers {
+	remaining := len(mods)
+	for i := 0; remaining != 0; i++ {
 		var layer LayerModules
 	modLoop:
 		for _, mod := range mods {
 			switch mod.Kind.(type) {
-			case *pbsubstreams.Module_KindMap_:
+			case *pbsubstreams.Module_KindMap_, *pbsubstreams.Module_KindBlockIndex_:
 				if i%2 == 0 {
 					continue
 				}
@@ -144,6 +290,10 @@ func computeStages(mods []*pbsubstreams.Module) (stages ExecutionStages) {
 			}
 
 			for _, dep := range mod.Inputs {
+				if filter := dep.BlockFilter; filter != nil && !seen[filter.Module] {
+					continue modLoop
+				}
+
 				var depModName string
 				switch input := dep.Input.(type) {
 				case *pbsubstreams.Module_Input_Params_:
@@ -169,23 +319,10 @@ func computeStages(mods []*pbsubstreams.Module) (stages ExecutionStages) {
 			for _, mod := range layer {
 				seen[mod.Name] = true
 			}
+			remaining -= len(layer)
 		}
 	}
-
-	lastLayerIndex := len(layers) - 1
-	var newStage StageLayers
-	for idx, layer := range layers {
-		isLastStage := idx == lastLayerIndex
-		isStoreLayer := layer.IsStoreLayer()
-
-		newStage = append(newStage, layer)
-		if isStoreLayer || isLastStage {
-			stages = append(stages, newStage)
-			newStage = nil
-		}
-	}
-
-	return stages
+	return layers
 }
 
 func computeOutputModule(mods []*pbsubstreams.Module, outputModule string) *pbsubstreams.Module {
@@ -198,16 +335,19 @@ func computeOutputModule(mods []*pbsubstreams.Module, outputModule string) *pbsu
 
 }
 
-func computeSchedulableModules(stores []*pbsubstreams.Module, outputModule *pbsubstreams.Module, productionMode bool) []*pbsubstreams.Module {
+func computeSchedulableModules(stores []*pbsubstreams.Module, outputModules []*pbsubstreams.Module, productionMode bool) []*pbsubstreams.Module {
 	if !productionMode { // dev never schedules maps, all stores are in there
 		return stores
 	}
 
-	if outputModule.GetKindStore() != nil {
-		return stores
+	out := stores
+	for _, outputModule := range outputModules {
+		if outputModule.GetKindStore() != nil {
+			continue
+		}
+		out = append(out, outputModule)
 	}
-
-	return append(stores, outputModule)
+	return out
 }
 
 func computeSchedulableAncestors(graph *manifest.ModuleGraph, schedulableModules []*pbsubstreams.Module) (out map[string][]string, err error) {
@@ -247,9 +387,29 @@ func (g *Graph) hashModules(graph *manifest.ModuleGraph) error {
 	return nil
 }
 
+// ValidateRequestStartBlock checks that the request starts at or after the
+// initial block of each output module.
 func (g *Graph) ValidateRequestStartBlock(requestStartBlockNum uint64) error {
-	if requestStartBlockNum < g.outputModule.InitialBlock {
-		return fmt.Errorf("start block %d smaller than request outputs for module %q with start block %d", requestStartBlockNum, g.outputModule.Name, g.outputModule.InitialBlock)
+	for _, outputModule := range g.outputModules {
+		if requestStartBlockNum < outputModule.InitialBlock {
+			return fmt.Errorf("start block %d smaller than request outputs for module %q with start block %d", requestStartBlockNum, outputModule.Name, outputModule.InitialBlock)
+		}
+	}
+	return nil
+}
+
+// ValidateSubrequestStartBlock checks that a tier2 request starts at or after
+// the initial block of at least one of the output modules, the modules
+// executed for the others starting earlier.
+func (g *Graph) ValidateSubrequestStartBlock(requestStartBlockNum uint64) error {
+	lowest := g.outputModules[0]
+	for _, outputModule := range g.outputModules {
+		if outputModule.InitialBlock < lowest.InitialBlock {
+			lowest = outputModule
+		}
+	}
+	if requestStartBlockNum < lowest.InitialBlock {
+		return fmt.Errorf("start block %d smaller than request outputs for module %q with start block %d", requestStartBlockNum, lowest.Name, lowest.InitialBlock)
 	}
 	return nil
 }
@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/streamingfast/substreams/manifest"
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 )
@@ -42,11 +44,71 @@ func TestGraph_computeStages(t *testing.T) {
 			input:  "Ma Mb:Ma Sc:Mb Md:Sc Se:Md,Sg Mf:Ma Sg:Mf Mh:Se,Ma",
 			expect: "[[Ma] [Mb Mf] [Sc Sg]] [[Md] [Se]] [[Mh]]",
 		},
+		{
+			name:   "block index graph",
+			input:  "Ia:R Mb:R,Fa Sc:Mb Md:Sc,Fa",
+			expect: "[[Ia] [Mb] [Sc]] [[Md]]",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			out := computeStages(computeStagesInput(test.input), nil)
+			assert.Equal(t, test.expect, computeStagesOutput(out))
+		})
+	}
+}
+
+func TestGraph_computeStagesWithOutputModules(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		outputModules []string
+		expect        string
+	}{
+		{
+			name:          "single output module",
+			input:         "Ma Sb:Ma Mc:Sb Md:Ma Me:Mc,Md",
+			outputModules: []string{"e"},
+			expect:        "[[Ma] [Sb]] [[Md Mc] [Me]]",
+		},
+		{
+			name:          "output module not depending on stores",
+			input:         "Ma Sb:Ma Mc:Sb Md:R",
+			outputModules: []string{"c", "d"},
+			expect:        "[[Ma] [Sb]] [[Md Mc]]",
+		},
+		{
+			name:          "output module depending on another one",
+			input:         "Ma Sb:Ma Mc:Sb Md:Mc",
+			outputModules: []string{"d", "c"},
+			expect:        "[[Ma] [Sb]] [[Mc] [Md]]",
+		},
+		{
+			name:          "output module input of a store",
+			input:         "Ma Sb:Ma Mc:Sb",
+			outputModules: []string{"a", "c"},
+			expect:        "[[Ma] [Sb]] [[Mc]]",
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			out := computeStages(computeStagesInput(test.input))
+			graph, err := manifest.NewModuleGraph(computeStagesInput(test.input))
+			require.NoError(t, err)
+
+			usedModules, err := modulesDownTo(graph, test.outputModules)
+			require.NoError(t, err)
+
+			var outputModules []*pbsubstreams.Module
+			for _, name := range test.outputModules {
+				outputModules = append(outputModules, computeOutputModule(usedModules, name))
+			}
+
+			lastStageModules, err := computeLastStageModules(graph, usedModules, computeStores(usedModules), outputModules)
+			require.NoError(t, err)
+
+			out := computeStages(usedModules, lastStageModules)
 			assert.Equal(t, test.expect, computeStagesOutput(out))
 		})
 	}
@@ -67,6 +129,9 @@ func computeStagesInput(in string) (out []*pbsubstreams.Module) {
 		case 'M':
 			newMod.Kind = &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{}}
 			newMod.Name = modName[1:]
+		case 'I':
+			newMod.Kind = &pbsubstreams.Module_KindBlockIndex_{KindBlockIndex: &pbsubstreams.Module_KindBlockIndex{}}
+			newMod.Name = modName[1:]
 		default:
 			panic("invalid prefix in word: " + modName)
 		}
@@ -82,6 +147,8 @@ func computeStagesInput(in string) (out []*pbsubstreams.Module) {
 					newMod.Inputs = append(newMod.Inputs, &pbsubstreams.Module_Input{Input: &pbsubstreams.Module_Input_Params_{}})
 				case 'R':
 					newMod.Inputs = append(newMod.Inputs, &pbsubstreams.Module_Input{Input: &pbsubstreams.Module_Input_Source_{}})
+				case 'F':
+					newMod.Inputs[len(newMod.Inputs)-1].BlockFilter = &pbsubstreams.Module_Input_BlockFilter{Module: inputName}
 				default:
 					panic("invalid input prefix: " + input)
 				}
@@ -103,6 +170,9 @@ func computeStagesOutput(in ExecutionStages) string {
 				if l3.GetKindMap() != nil {
 					modKind = "M"
 				}
+				if l3.GetKindBlockIndex() != nil {
+					modKind = "I"
+				}
 				level3 = append(level3, modKind+l3.Name)
 			}
 			level2 = append(level2, fmt.Sprintf("%v", level3))
@@ -151,7 +221,7 @@ func TestGraph_computeSchedulableModules(t *testing.T) {
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			out := computeSchedulableModules(test.stores, test.outputModule, test.productionMode)
+			out := computeSchedulableModules(test.stores, []*pbsubstreams.Module{test.outputModule}, test.productionMode)
 
 			assert.Equal(t, test.expect, out)
 		})
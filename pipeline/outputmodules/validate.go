@@ -18,7 +18,7 @@ func ValidateTier1Request(request *pbsubstreamsrpc.Request, blockType string) er
 		return fmt.Errorf("validate tier1 request: %s", err)
 	}
 
-	err := validateRequest(request.Modules.Binaries, request.Modules, request.OutputModule, blockType)
+	err := validateRequest(request.Modules.Binaries, request.Modules, request.RequestedOutputModules(), blockType)
 	if err != nil {
 		return err
 	}
@@ -31,7 +31,7 @@ func ValidateTier2Request(request *pbssinternal.ProcessRangeRequest, blockType s
 		return fmt.Errorf("validate tier2 request: %s", err)
 	}
 
-	err := validateRequest(request.Modules.Binaries, request.Modules, request.OutputModule, blockType)
+	err := validateRequest(request.Modules.Binaries, request.Modules, request.RequestedOutputModules(), blockType)
 	if err != nil {
 		return err
 	}
@@ -39,7 +39,7 @@ func ValidateTier2Request(request *pbssinternal.ProcessRangeRequest, blockType s
 	return nil
 }
 
-func validateRequest(binaries []*pbsubstreams.Binary, modules *pbsubstreams.Modules, outputModule string, blockType string) error {
+func validateRequest(binaries []*pbsubstreams.Binary, modules *pbsubstreams.Modules, outputModules []string, blockType string) error {
 	if err := validateBinaryTypes(binaries); err != nil {
 		return err
 	}
@@ -48,8 +48,10 @@ func validateRequest(binaries []*pbsubstreams.Binary, modules *pbsubstreams.Modu
 		return fmt.Errorf("modules validation failed: %w", err)
 	}
 
-	if err := validateModuleGraph(modules.Modules, outputModule, blockType); err != nil {
-		return err
+	for _, outputModule := range outputModules {
+		if err := validateModuleGraph(modules.Modules, outputModule, blockType); err != nil {
+			return err
+		}
 	}
 	return nil
 }
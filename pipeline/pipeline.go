@@ -24,6 +24,7 @@ import (
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/service/config"
 	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/index"
 	"github.com/streamingfast/substreams/storage/store"
 	"github.com/streamingfast/substreams/wasm"
 )
@@ -48,7 +49,7 @@ type Pipeline struct {
 	moduleExecutors [][]exec.ModuleExecutor // Staged module executors
 	executionStages outputmodules.ExecutionStages
 
-	mapModuleOutput         *pbsubstreamsrpc.MapModuleOutput
+	mapModuleOutputs        map[string]*pbsubstreamsrpc.MapModuleOutput // by output module name
 	extraMapModuleOutputs   []*pbsubstreamsrpc.MapModuleOutput
 	extraStoreModuleOutputs []*pbsubstreamsrpc.StoreModuleOutput
 
@@ -75,6 +76,14 @@ type Pipeline struct {
 	// (for chains with potential block skips)
 	lastFinalClock *pbsubstreams.Clock
 
+	// checkpointInterval is how often a tier2 job saves a checkpoint to be
+	// resumed from, none being saved when 0. resumeFromBlock is the block of
+	// the checkpoint the job resumes from, 0 when processing its range from
+	// the start.
+	checkpointInterval time.Duration
+	resumeFromBlock    uint64
+	checkpoint         *checkpoint
+
 	traceID string
 }
 
@@ -103,6 +112,8 @@ func New(
 		forkHandler:     NewForkHandler(),
 		traceID:         traceID,
 		startTime:       time.Now(),
+
+		mapModuleOutputs: map[string]*pbsubstreamsrpc.MapModuleOutput{},
 	}
 	for _, opt := range opts {
 		opt(pipe)
@@ -140,14 +151,23 @@ func (p *Pipeline) InitTier2Stores(ctx context.Context) (err error) {
 		return err
 	}
 
-	storeMap, err := p.setupSubrequestStores(ctx)
-	if err != nil {
-		return fmt.Errorf("subrequest stores setup failed: %w", err)
+	logger := reqctx.Logger(ctx)
+	if p.resumeFromBlock != 0 {
+		if err := p.resumeFromCheckpoint(ctx, p.resumeFromBlock); err != nil {
+			logger.Warn("cannot resume from checkpoint, processing the range from its start", zap.Uint64("checkpoint_block", p.resumeFromBlock), zap.Error(err))
+			p.resumeFromBlock = 0
+		}
 	}
 
-	p.stores.SetStoreMap(storeMap)
+	if p.resumeFromBlock == 0 {
+		storeMap, err := p.setupSubrequestStores(ctx)
+		if err != nil {
+			return fmt.Errorf("subrequest stores setup failed: %w", err)
+		}
+
+		p.stores.SetStoreMap(storeMap)
+	}
 
-	logger := reqctx.Logger(ctx)
 	logger.Info("stores loaded", zap.Object("stores", p.stores.StoreMap), zap.Int("stage", reqctx.Details(ctx).Tier2Stage))
 
 	return nil
@@ -171,6 +191,12 @@ func (p *Pipeline) InitTier1StoresAndBackprocess(ctx context.Context, reqPlan *p
 	return nil
 }
 
+// ResumeFromBlock returns the block from which the tier2 job resumes, once
+// its stores initialized, or 0 if it processes its range from the start.
+func (p *Pipeline) ResumeFromBlock() uint64 {
+	return p.resumeFromBlock
+}
+
 func (p *Pipeline) GetStoreMap() store.Map {
 	return p.stores.StoreMap
 }
@@ -413,6 +439,7 @@ func (p *Pipeline) toInternalUpdate(clock *pbsubstreams.Clock) *pbssinternal.Upd
 		TotalBytesRead:    meter.BytesRead(),
 		TotalBytesWritten: meter.BytesWritten(),
 		ModulesStats:      reqctx.ReqStats(p.ctx).LocalModulesStats(),
+		CheckpointBlock:   p.checkpointBlock(),
 	}
 }
 
@@ -476,11 +503,16 @@ func (p *Pipeline) buildModuleExecutors(ctx context.Context) ([][]exec.ModuleExe
 					return nil, fmt.Errorf("module %q: get wasm inputs: %w", module.Name, err)
 				}
 
+				blockFilters, err := index.ModuleBlockFilters(module)
+				if err != nil {
+					return nil, err
+				}
+
 				entrypoint := module.BinaryEntrypoint
 				mod := loadedModules[module.BinaryIndex]
 
 				switch kind := module.Kind.(type) {
-				case *pbsubstreams.Module_KindMap_:
+				case *pbsubstreams.Module_KindMap_, *pbsubstreams.Module_KindBlockIndex_:
 					outType := strings.TrimPrefix(module.Output.Type, "proto:")
 					baseExecutor := exec.NewBaseExecutor(
 						ctx,
@@ -488,6 +520,7 @@ func (p *Pipeline) buildModuleExecutors(ctx context.Context) ([][]exec.ModuleExe
 						mod,
 						p.wasmRuntime.InstanceCacheEnabled(),
 						inputs,
+						blockFilters,
 						entrypoint,
 						tracer,
 					)
@@ -510,6 +543,7 @@ func (p *Pipeline) buildModuleExecutors(ctx context.Context) ([][]exec.ModuleExe
 						mod,
 						p.wasmRuntime.InstanceCacheEnabled(),
 						inputs,
+						blockFilters,
 						entrypoint,
 						tracer,
 					)
@@ -544,10 +578,26 @@ func (p *Pipeline) cleanUpModuleExecutors(ctx context.Context) error {
 	return nil
 }
 
+// requestedOutputs returns the output of the requested output module, or when the
+// request has several output modules, the output of each of them in the order
+// requested, skipping the ones without output for the block.
+func (p *Pipeline) requestedOutputs(reqDetails *reqctx.RequestDetails) (output *pbsubstreamsrpc.MapModuleOutput, outputs []*pbsubstreamsrpc.MapModuleOutput) {
+	if len(reqDetails.OutputModules) == 0 {
+		return p.mapModuleOutputs[reqDetails.OutputModule], nil
+	}
+	for _, outputModule := range reqDetails.OutputModules {
+		if out := p.mapModuleOutputs[outputModule]; out != nil {
+			outputs = append(outputs, out)
+		}
+	}
+	return nil, outputs
+}
+
 func returnModuleDataOutputs(
 	clock *pbsubstreams.Clock,
 	cursor *bstream.Cursor,
 	mapModuleOutput *pbsubstreamsrpc.MapModuleOutput,
+	mapModuleOutputs []*pbsubstreamsrpc.MapModuleOutput,
 	extraMapModuleOutputs []*pbsubstreamsrpc.MapModuleOutput,
 	extraStoreModuleOutputs []*pbsubstreamsrpc.StoreModuleOutput,
 	respFunc substreams.ResponseFunc,
@@ -555,6 +605,7 @@ func returnModuleDataOutputs(
 	out := &pbsubstreamsrpc.BlockScopedData{
 		Clock:             clock,
 		Output:            mapModuleOutput,
+		Outputs:           mapModuleOutputs,
 		DebugMapOutputs:   extraMapModuleOutputs,
 		DebugStoreOutputs: extraStoreModuleOutputs,
 		Cursor:            cursor.ToOpaque(),
@@ -72,6 +72,25 @@ func TestPipeline_runExecutor(t *testing.T) {
 	}
 }
 
+func TestPipeline_requestedOutputs(t *testing.T) {
+	outA := &pbsubstreamsrpc.MapModuleOutput{Name: "map_a"}
+	outC := &pbsubstreamsrpc.MapModuleOutput{Name: "map_c"}
+	pipe := &Pipeline{
+		mapModuleOutputs: map[string]*pbsubstreamsrpc.MapModuleOutput{
+			"map_a": outA,
+			"map_c": outC,
+		},
+	}
+
+	output, outputs := pipe.requestedOutputs(&reqctx.RequestDetails{OutputModule: "map_c"})
+	assert.Equal(t, outC, output)
+	assert.Nil(t, outputs)
+
+	output, outputs = pipe.requestedOutputs(&reqctx.RequestDetails{OutputModule: "map_c", OutputModules: []string{"map_c", "map_b", "map_a"}})
+	assert.Nil(t, output)
+	assert.Equal(t, []*pbsubstreamsrpc.MapModuleOutput{outC, outA}, outputs)
+}
+
 func mapTestExecutor(t *testing.T, ctx context.Context, name string) *exec.MapperModuleExecutor {
 	pkg := manifest.TestReadManifest(t, "../test/testdata/substreams-test-v0.1.0.spkg")
 
@@ -98,6 +117,7 @@ func mapTestExecutor(t *testing.T, ctx context.Context, name string) *exec.Mappe
 				wasm.NewParamsInput("my test params"),
 				wasm.NewSourceInput("sf.substreams.v1.test.Block"),
 			},
+			nil,
 			name,
 			otel.GetTracerProvider().Tracer("test"),
 		),
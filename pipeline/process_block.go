@@ -124,6 +124,11 @@ func (p *Pipeline) processBlock(
 		if err != nil {
 			return fmt.Errorf("handling step irreversible: %w", err)
 		}
+		if !eof && reqctx.Details(ctx).IsTier2Request {
+			if err := p.maybeCheckpoint(ctx, clock); err != nil {
+				return fmt.Errorf("step new irr: %w", err)
+			}
+		}
 
 	case bstream.StepIrreversible:
 		err = p.handleStepFinal(clock)
@@ -250,7 +255,8 @@ func (p *Pipeline) handleStepNew(ctx context.Context, block *bstream.Block, cloc
 			}
 		}
 		p.pendingUndoMessage = nil
-		if err = returnModuleDataOutputs(clock, cursor, p.mapModuleOutput, p.extraMapModuleOutputs, p.extraStoreModuleOutputs, p.respFunc); err != nil {
+		mapModuleOutput, mapModuleOutputs := p.requestedOutputs(reqctx.Details(ctx))
+		if err = returnModuleDataOutputs(clock, cursor, mapModuleOutput, mapModuleOutputs, p.extraMapModuleOutputs, p.extraStoreModuleOutputs, p.respFunc); err != nil {
 			return fmt.Errorf("failed to return module data output: %w", err)
 		}
 	}
@@ -264,7 +270,7 @@ func (p *Pipeline) executeModules(ctx context.Context, execOutput execout.Execut
 	ctx, span := reqctx.WithModuleExecutionSpan(ctx, "modules_executions")
 	defer span.EndWithErr(&err)
 
-	p.mapModuleOutput = nil
+	clear(p.mapModuleOutputs)
 	p.extraMapModuleOutputs = nil
 	p.extraStoreModuleOutputs = nil
 	moduleExecutors, err := p.buildModuleExecutors(ctx)
@@ -358,7 +364,7 @@ func (p *Pipeline) applyExecutionResult(ctx context.Context, executor exec.Modul
 
 func (p *Pipeline) saveModuleOutput(output *pbssinternal.ModuleOutput, moduleName string, isProduction bool) {
 	if p.isOutputModule(moduleName) {
-		p.mapModuleOutput = toRPCMapModuleOutputs(output)
+		p.mapModuleOutputs[moduleName] = toRPCMapModuleOutputs(output)
 		return
 	}
 	if isProduction {
@@ -28,7 +28,8 @@ func BuildRequestDetails(
 	getHeadBlock getBlockFunc) (req *reqctx.RequestDetails, undoSignal *pbsubstreamsrpc.BlockUndoSignal, err error) {
 	req = &reqctx.RequestDetails{
 		Modules:                             request.Modules,
-		OutputModule:                        request.OutputModule,
+		OutputModule:                        request.RequestedOutputModules()[0],
+		OutputModules:                       request.OutputModules,
 		DebugInitialStoreSnapshotForModules: request.DebugInitialStoreSnapshotForModules,
 		ProductionMode:                      request.ProductionMode,
 		StopBlockNum:                        request.StopBlockNum,
@@ -55,6 +56,7 @@ func BuildRequestDetailsFromSubrequest(request *pbssinternal.ProcessRangeRequest
 	req = &reqctx.RequestDetails{
 		Modules:               request.Modules,
 		OutputModule:          request.OutputModule,
+		OutputModules:         request.OutputModules,
 		ProductionMode:        true,
 		IsTier2Request:        true,
 		Tier2Stage:            int(request.Stage),
@@ -56,6 +56,16 @@ func (s *Stores) resetStores() {
 	}
 }
 
+// closeStores releases the resources held by the stores, which cannot be
+// used anymore afterwards.
+func (s *Stores) closeStores() {
+	for name, st := range s.StoreMap.All() {
+		if err := st.Close(); err != nil {
+			s.logger.Warn("closing store", zap.String("store", name), zap.Error(err))
+		}
+	}
+}
+
 // flushStores is called only for Tier2 request, as to not save reversible stores.
 func (s *Stores) flushStores(ctx context.Context, executionStages outputmodules.ExecutionStages, blockNum uint64) (err error) {
 	if s.StoreMap == nil {
@@ -108,7 +118,8 @@ func (s *Stores) saveStoreSnapshot(ctx context.Context, saveStore store.Store, b
 		return fmt.Errorf("failed to write store: %w", err)
 	}
 
-	if reqctx.Details(ctx).ShouldReturnWrittenPartials(saveStore.Name()) {
+	// A tier2 job spanning several intervals writes one partial per interval.
+	if reqctx.Details(ctx).IsTier2Request {
 		//s.partialsWritten = append(s.partialsWritten, file.Range)
 		s.logger.Debug("adding partials written",
 			zap.Stringer("range", file.Range),
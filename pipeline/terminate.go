@@ -23,6 +23,8 @@ func (p *Pipeline) OnStreamTerminated(ctx context.Context, err error) error {
 	logger := reqctx.Logger(ctx)
 	reqDetails := reqctx.Details(ctx)
 
+	defer p.stores.closeStores()
+
 	if err := p.cleanUpModuleExecutors(ctx); err != nil {
 		return err
 	}
@@ -56,6 +58,10 @@ func (p *Pipeline) OnStreamTerminated(ctx context.Context, err error) error {
 		return fmt.Errorf("step new irr: stores end of stream: %w", err)
 	}
 
+	if reqDetails.IsTier2Request {
+		p.deleteCheckpoint(ctx)
+	}
+
 	return nil
 }
 
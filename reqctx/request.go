@@ -13,6 +13,8 @@ type RequestDetails struct {
 
 	DebugInitialStoreSnapshotForModules []string
 	OutputModule                        string
+	// Set when the request has several output modules, OutputModule being the first one
+	OutputModules []string
 	// What the user requested, derived from either the Request.StartBlockNum or Request.Cursor
 	ResolvedStartBlockNum uint64
 	ResolvedCursor        string
@@ -33,11 +35,15 @@ func (d *RequestDetails) UniqueIDString() string {
 }
 
 func (d *RequestDetails) IsOutputModule(modName string) bool {
-	return modName == d.OutputModule
-}
-
-func (d *RequestDetails) ShouldReturnWrittenPartials(modName string) bool {
-	return d.IsTier2Request && d.IsOutputModule(modName)
+	if modName == d.OutputModule {
+		return true
+	}
+	for _, outputModule := range d.OutputModules {
+		if modName == outputModule {
+			return true
+		}
+	}
+	return false
 }
 
 func (d *RequestDetails) ShouldStreamCachedOutputs() bool {
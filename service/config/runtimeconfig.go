@@ -3,7 +3,10 @@ package config
 import (
 	"github.com/streamingfast/dstore"
 
+	"github.com/streamingfast/substreams/orchestrator/jobs"
 	"github.com/streamingfast/substreams/orchestrator/work"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/store"
 )
 
 // RuntimeConfig is a global configuration for the service.
@@ -19,8 +22,19 @@ type RuntimeConfig struct {
 	BaseObjectStore dstore.Store
 	DefaultCacheTag string // appended to BaseObjectStore unless overriden by auth layer
 	WorkerFactory   work.WorkerFactory
+	// if not nil, tier1 requests attach to the identical jobs in flight for
+	// other requests instead of scheduling duplicates
+	JobRegistry *jobs.Registry
 
 	ModuleExecutionTracing bool
+
+	// if not empty, stores keep their state on disk under this directory
+	// instead of in memory, to support stores bigger than the available memory
+	StoreDiskBackendDir    string
+	StoreMemtableSizeLimit uint64 // bytes of recent writes kept in memory by stores on disk before spilling them
+
+	StoreSnapshotFormat store.SnapshotFormat // format used when writing store snapshots, files of any format are always readable
+	OutputCacheFormat   execout.FileFormat   // format used when writing module output files, files of any format are always readable
 }
 
 func NewRuntimeConfig(
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/bstream/stream"
+	"github.com/streamingfast/dmetering"
+	"github.com/streamingfast/dstore"
+	"github.com/streamingfast/logging"
+	tracing "github.com/streamingfast/sf-tracing"
+	"github.com/streamingfast/shutter"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/metrics"
+	"github.com/streamingfast/substreams/orchestrator/loop"
+	"github.com/streamingfast/substreams/orchestrator/response"
+	"github.com/streamingfast/substreams/orchestrator/stage"
+	"github.com/streamingfast/substreams/orchestrator/work"
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/service/config"
+	"github.com/streamingfast/substreams/storage/gc"
+)
+
+// NewLocal creates a Tier1Service that runs entirely in-process, without any
+// network dependency. Blocks are read from the merged blocks files found in
+// `mergedBlocksStore` only (there is no live source, so every block is final)
+// and parallel jobs are executed by an embedded Tier2Service instead of being
+// dispatched to remote tier2 instances.
+//
+// Use `LocalBlocks` to run a request against the returned service.
+func NewLocal(
+	logger *zap.Logger,
+	mergedBlocksStore dstore.Store,
+
+	stateStore dstore.Store,
+	defaultCacheTag string,
+
+	blockType string,
+
+	parallelSubRequests uint64,
+	stateBundleSize uint64,
+	opts ...Option,
+) *Tier1Service {
+	tier2 := NewTier2(logger, mergedBlocksStore, stateStore, defaultCacheTag, stateBundleSize, blockType, opts...)
+
+	runtimeConfig := config.NewRuntimeConfig(
+		stateBundleSize,
+		parallelSubRequests,
+		10,
+		0,
+		stateStore,
+		defaultCacheTag,
+		func(logger *zap.Logger) work.Worker {
+			return NewLocalWorker(tier2, logger)
+		},
+	)
+
+	s := &Tier1Service{
+		Shutter:        shutter.New(),
+		runtimeConfig:  runtimeConfig,
+		blockType:      blockType,
+		tracer:         tracing.GetTracer(),
+		failedRequests: make(map[string]*recordedFailure),
+		logger:         logger,
+		accessTracker:  gc.NewAccessTracker(stateStore, gc.DefaultAccessRecordInterval),
+		resolveCursor: func(ctx context.Context, cursor *bstream.Cursor) (bstream.BlockRef, bstream.BlockRef, error) {
+			return nil, nil, fmt.Errorf("cannot resolve non-final cursor %q without a live source", cursor)
+		},
+		getRecentFinalBlock: func() (uint64, error) {
+			return 0, fmt.Errorf("no live feed")
+		},
+		getHeadBlock: func() (uint64, error) {
+			return 0, fmt.Errorf("no live feed")
+		},
+	}
+
+	sf := &StreamFactory{
+		mergedBlocksStore: mergedBlocksStore,
+	}
+	s.streamFactoryFunc = sf.New
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// LocalBlocks is the in-process counterpart of `Blocks`, responses are sent
+// to `respFunc` instead of a gRPC server stream. The request must have a stop
+// block since there is no live source to resolve the chain's head from.
+func (s *Tier1Service) LocalBlocks(ctx context.Context, request *pbsubstreamsrpc.Request, respFunc substreams.ResponseFunc) error {
+	if request.StopBlockNum == 0 {
+		return stream.NewErrInvalidArg("a stop block is required when running locally")
+	}
+
+	logger := s.logger.Named("local")
+
+	ctx = logging.WithLogger(ctx, logger)
+	ctx = reqctx.WithTracer(ctx, s.tracer)
+	ctx = dmetering.WithBytesMeter(ctx)
+
+	if err := outputmodules.ValidateTier1Request(request, s.blockType); err != nil {
+		return stream.NewErrInvalidArg(fmt.Errorf("validate request: %w", err).Error())
+	}
+
+	outputGraph, err := outputmodules.NewOutputModulesGraph(request.RequestedOutputModules(), request.ProductionMode, request.Modules)
+	if err != nil {
+		return stream.NewErrInvalidArg(err.Error())
+	}
+
+	return s.blocks(ctx, request, outputGraph, respFunc)
+}
+
+var lastLocalWorkerID uint64
+
+// LocalWorker is a `work.Worker` executing jobs on an in-process Tier2Service,
+// it is the local equivalent of the `work.RemoteWorker`.
+type LocalWorker struct {
+	tier2  *Tier2Service
+	logger *zap.Logger
+	id     uint64
+}
+
+func NewLocalWorker(tier2 *Tier2Service, logger *zap.Logger) *LocalWorker {
+	return &LocalWorker{
+		tier2:  tier2,
+		logger: logger,
+		id:     atomic.AddUint64(&lastLocalWorkerID, 1),
+	}
+}
+
+func (w *LocalWorker) ID() string {
+	return fmt.Sprintf("local-%d", w.id)
+}
+
+func (w *LocalWorker) Work(ctx context.Context, unit stage.Unit, workRange *block.Range, attempt uint32, moduleNames []string, upstream *response.Stream) loop.Cmd {
+	request := work.NewRequest(reqctx.Details(ctx), unit.Stage, workRange, attempt)
+	traceID := tracing.GetTraceID(ctx).String()
+	logger := reqctx.Logger(ctx)
+
+	return func() loop.Msg {
+		startTime := time.Now()
+
+		stats := reqctx.ReqStats(ctx)
+		jobIdx := stats.RecordNewSubrequest(request.Stage, request.StartBlockNum, request.StopBlockNum)
+		defer stats.RecordEndSubrequest(jobIdx)
+
+		if err := w.work(ctx, request, traceID, stats, jobIdx, upstream); err != nil {
+			logger.Info("local job failed", zap.Object("unit", unit), zap.Error(err))
+			return work.MsgJobFailed{Unit: unit, Error: err}
+		}
+
+		if err := ctx.Err(); err != nil {
+			logger.Info("local job not completed", zap.Object("unit", unit), zap.Error(err))
+			return work.MsgJobFailed{Unit: unit, Error: err}
+		}
+
+		logger.Info("local job completed",
+			zap.Object("unit", unit),
+			zap.Strings("module_name", moduleNames),
+			zap.Duration("duration", time.Since(startTime)),
+		)
+		return work.MsgJobSucceeded{Unit: unit, Worker: w}
+	}
+}
+
+func (w *LocalWorker) work(ctx context.Context, request *pbssinternal.ProcessRangeRequest, traceID string, stats *metrics.Stats, jobIdx uint64, upstream *response.Stream) error {
+	logger := w.logger.With(
+		zap.Uint64("start_block_num", request.StartBlockNum),
+		zap.Uint64("stop_block_num", request.StopBlockNum),
+		zap.Uint32("stage", request.Stage),
+	)
+	ctx = logging.WithLogger(ctx, logger)
+
+	var failure *pbssinternal.Failed
+	respFunc := func(respAny substreams.ResponseFromAnyTier) error {
+		resp := respAny.(*pbssinternal.ProcessRangeResponse)
+		switch r := resp.Type.(type) {
+		case *pbssinternal.ProcessRangeResponse_Update:
+			stats.RecordJobUpdate(jobIdx, r.Update)
+		case *pbssinternal.ProcessRangeResponse_Failed:
+			failure = r.Failed
+		}
+		return nil
+	}
+
+	if err := w.tier2.processRange(ctx, request, respFunc, traceID); err != nil {
+		return fmt.Errorf("local tier2 processing: %w", err)
+	}
+
+	if failure != nil {
+		upstream.RPCFailedProgressResponse(failure.Reason, failure.Logs, failure.LogsTruncated)
+		return fmt.Errorf("work failed on local worker: %s", failure.Reason)
+	}
+	return nil
+}
@@ -1,7 +1,14 @@
 package service
 
 import (
+	"time"
+
+	"github.com/streamingfast/substreams/metrics"
+	"github.com/streamingfast/substreams/orchestrator/work"
 	"github.com/streamingfast/substreams/pipeline"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/gc"
+	"github.com/streamingfast/substreams/storage/store"
 	"github.com/streamingfast/substreams/wasm"
 )
 
@@ -55,3 +62,104 @@ func WithModuleExecutionTracing() Option {
 		}
 	}
 }
+
+// WithStoreDiskBackend makes stores keep their state on disk under `dir`,
+// holding at most `memtableSizeLimit` bytes of recent writes in memory,
+// instead of keeping it all in memory. A `memtableSizeLimit` of 0 uses
+// store.DefaultMemtableSizeLimit.
+func WithStoreDiskBackend(dir string, memtableSizeLimit uint64) Option {
+	if memtableSizeLimit == 0 {
+		memtableSizeLimit = store.DefaultMemtableSizeLimit
+	}
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.runtimeConfig.StoreDiskBackendDir = dir
+			s.runtimeConfig.StoreMemtableSizeLimit = memtableSizeLimit
+		case *Tier2Service:
+			s.runtimeConfig.StoreDiskBackendDir = dir
+			s.runtimeConfig.StoreMemtableSizeLimit = memtableSizeLimit
+		}
+	}
+}
+
+// WithOutputCacheFormat sets the format used to write the module output
+// files. Files already written in another format remain readable.
+func WithOutputCacheFormat(format execout.FileFormat) Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.runtimeConfig.OutputCacheFormat = format
+		case *Tier2Service:
+			s.runtimeConfig.OutputCacheFormat = format
+		}
+	}
+}
+
+// WithStoreSnapshotFormat sets the format used to write store snapshots.
+// Snapshots already written in another format remain readable.
+func WithStoreSnapshotFormat(format store.SnapshotFormat) Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.runtimeConfig.StoreSnapshotFormat = format
+		case *Tier2Service:
+			s.runtimeConfig.StoreSnapshotFormat = format
+		}
+	}
+}
+
+// WithCacheGC makes tier1 apply the cache retention `rules` to its state
+// store every `interval`. The rules' StateBundleSize defaults to the
+// service's one.
+func WithCacheGC(rules gc.Rules, interval time.Duration) Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.cacheGCRules = rules
+			s.cacheGCInterval = interval
+		}
+	}
+}
+
+// WithTier2Capacity makes tier1 lease at most `slots` tier2 worker slots to
+// the jobs of all of its requests at once, sharing them between users in
+// proportion to their weight in `userWeights`, 1 by default. Each request
+// still runs at most its number of parallel jobs.
+func WithTier2Capacity(slots int, userWeights map[string]float64) Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.tier2Capacity = work.NewCapacityScheduler(slots, userWeights)
+		}
+	}
+}
+
+// WithAdaptiveSegments makes tier1 size the segments of its requests after
+// the processing time of their modules recorded by the previous requests,
+// for their jobs to last about `targetJobDuration`: costly ranges get
+// segments of a single bundle, cheap ones up to plan.MaxSegmentIntervals
+// bundles. Segments always end on bundles, so the files written are the
+// same as with fixed-width segments.
+func WithAdaptiveSegments(targetJobDuration time.Duration) Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier1Service:
+			s.moduleCosts = metrics.NewModuleCosts(s.runtimeConfig.StateBundleSize)
+			s.segmentTargetDuration = targetJobDuration
+		}
+	}
+}
+
+// WithJobCheckpoints makes tier2 jobs save a checkpoint of their progress
+// every `interval`: the stores, outputs and block indexes of the blocks
+// processed so far. A job retried by tier1 after its stream died resumes
+// from its last checkpoint instead of processing its range from the start.
+func WithJobCheckpoints(interval time.Duration) Option {
+	return func(a anyTierService) {
+		switch s := a.(type) {
+		case *Tier2Service:
+			s.checkpointInterval = interval
+		}
+	}
+}
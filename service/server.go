@@ -56,8 +56,12 @@ func ListenTier1(
 		return ssconnect.NewStreamHandler(svc, opts...)
 	}
 
+	storeQueryHandlerGetter := func(opts ...connect_go.HandlerOption) (string, http.Handler) {
+		return ssconnect.NewStoreQueryHandler(svc, opts...)
+	}
+
 	options = append(options, dgrpcserver.WithPermissiveCORS())
-	srv := connectweb.New([]connectweb.HandlerGetter{streamHandlerGetter}, options...)
+	srv := connectweb.New([]connectweb.HandlerGetter{streamHandlerGetter, storeQueryHandlerGetter}, options...)
 	addr = strings.ReplaceAll(addr, "*", "")
 	srv.Launch(addr)
 	<-srv.Terminated()
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/streamingfast/dauth"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+const (
+	// StoreQueryMaxScanLimit is the maximum number of entries returned by a
+	// single ScanPrefix call.
+	StoreQueryMaxScanLimit = 1000
+	// StoreQueryMaxKeys is the maximum number of keys looked up by a single
+	// GetMany call.
+	StoreQueryMaxKeys = 1000
+	// StoreQueryMaxResponseBytes bounds the size of the keys and values
+	// returned by a single call. ScanPrefix returns a shorter page instead.
+	StoreQueryMaxResponseBytes = 16 * 1024 * 1024
+	// StoreQueryTimeout bounds the time spent on a single call, including
+	// waiting for the snapshot to be downloaded.
+	StoreQueryTimeout = 30 * time.Second
+)
+
+var errResponseFull = errors.New("response full")
+
+// Get returns the value of a key in the latest full snapshot of a store.
+func (s *Tier1Service) Get(ctx context.Context, req *connect.Request[pbsubstreamsrpc.StoreGetRequest]) (*connect.Response[pbsubstreamsrpc.StoreGetResponse], error) {
+	ctx, cancel := context.WithTimeout(ctx, StoreQueryTimeout)
+	defer cancel()
+
+	snapshot, err := s.latestStoreSnapshot(ctx, req.Msg.Module)
+	if err != nil {
+		return nil, toGRPCError(ctx, err)
+	}
+	defer snapshot.release()
+
+	value, found, err := snapshot.local.Get(req.Msg.Key)
+	if err != nil {
+		return nil, toGRPCError(ctx, err)
+	}
+	if len(value) > StoreQueryMaxResponseBytes {
+		return nil, status.Errorf(codes.ResourceExhausted, "value of key %q larger than %d bytes", req.Msg.Key, StoreQueryMaxResponseBytes)
+	}
+
+	return connect.NewResponse(&pbsubstreamsrpc.StoreGetResponse{
+		Snapshot: snapshot.info,
+		Found:    found,
+		Value:    value,
+	}), nil
+}
+
+// GetMany returns the values of the keys found in the latest full snapshot
+// of a store.
+func (s *Tier1Service) GetMany(ctx context.Context, req *connect.Request[pbsubstreamsrpc.StoreGetManyRequest]) (*connect.Response[pbsubstreamsrpc.StoreGetManyResponse], error) {
+	if len(req.Msg.Keys) > StoreQueryMaxKeys {
+		return nil, status.Errorf(codes.InvalidArgument, "too many keys: %d, at most %d", len(req.Msg.Keys), StoreQueryMaxKeys)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, StoreQueryTimeout)
+	defer cancel()
+
+	snapshot, err := s.latestStoreSnapshot(ctx, req.Msg.Module)
+	if err != nil {
+		return nil, toGRPCError(ctx, err)
+	}
+	defer snapshot.release()
+
+	keys := make([]string, len(req.Msg.Keys))
+	copy(keys, req.Msg.Keys)
+	sort.Strings(keys)
+
+	var entries []*pbsubstreamsrpc.StoreEntry
+	var size int
+	for i, key := range keys {
+		if i > 0 && key == keys[i-1] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, toGRPCError(ctx, err)
+		}
+
+		value, found, err := snapshot.local.Get(key)
+		if err != nil {
+			return nil, toGRPCError(ctx, err)
+		}
+		if !found {
+			continue
+		}
+		if size += len(key) + len(value); size > StoreQueryMaxResponseBytes {
+			return nil, status.Errorf(codes.ResourceExhausted, "values larger than %d bytes, query fewer keys", StoreQueryMaxResponseBytes)
+		}
+		entries = append(entries, &pbsubstreamsrpc.StoreEntry{Key: key, Value: value})
+	}
+
+	return connect.NewResponse(&pbsubstreamsrpc.StoreGetManyResponse{
+		Snapshot: snapshot.info,
+		Entries:  entries,
+	}), nil
+}
+
+// ScanPrefix returns, in key order, a page of the keys starting with a
+// prefix in the latest full snapshot of a store, seeking to the first one
+// through the index of the snapshot.
+func (s *Tier1Service) ScanPrefix(ctx context.Context, req *connect.Request[pbsubstreamsrpc.StoreScanPrefixRequest]) (*connect.Response[pbsubstreamsrpc.StoreScanPrefixResponse], error) {
+	limit := req.Msg.Limit
+	if limit == 0 || limit > StoreQueryMaxScanLimit {
+		limit = StoreQueryMaxScanLimit
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, StoreQueryTimeout)
+	defer cancel()
+
+	snapshot, err := s.latestStoreSnapshot(ctx, req.Msg.Module)
+	if err != nil {
+		return nil, toGRPCError(ctx, err)
+	}
+	defer snapshot.release()
+
+	var entries []*pbsubstreamsrpc.StoreEntry
+	var size int
+	more := false
+	err = snapshot.local.IterPrefix(req.Msg.Prefix, req.Msg.StartKey, func(key string, value []byte) error {
+		size += len(key) + len(value)
+		if uint64(len(entries)) == limit || (len(entries) != 0 && size > StoreQueryMaxResponseBytes) {
+			more = true
+			return errResponseFull
+		}
+		if size > StoreQueryMaxResponseBytes {
+			return status.Errorf(codes.ResourceExhausted, "value of key %q larger than %d bytes", key, StoreQueryMaxResponseBytes)
+		}
+		entries = append(entries, &pbsubstreamsrpc.StoreEntry{Key: key, Value: value})
+		return ctx.Err()
+	})
+	if err != nil && !errors.Is(err, errResponseFull) {
+		return nil, toGRPCError(ctx, err)
+	}
+
+	return connect.NewResponse(&pbsubstreamsrpc.StoreScanPrefixResponse{
+		Snapshot: snapshot.info,
+		Entries:  entries,
+		More:     more,
+	}), nil
+}
+
+// latestStoreSnapshot returns the latest full snapshot of the store module
+// queried, under the cache tag of the caller. It must be released once
+// read.
+func (s *Tier1Service) latestStoreSnapshot(ctx context.Context, query *pbsubstreamsrpc.StoreQueryModule) (*storeSnapshot, error) {
+	if query == nil || query.Modules == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing modules in request")
+	}
+
+	graph, err := manifest.NewModuleGraph(query.Modules.Modules)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid modules: %s", err)
+	}
+	module, err := graph.Module(query.ModuleName)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "module %q not found", query.ModuleName)
+	}
+	if module.GetKindStore() == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "module %q is not a store", query.ModuleName)
+	}
+
+	hash, err := manifest.NewModuleHashes().HashModule(query.Modules, module, graph)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "hashing module %q: %s", query.ModuleName, err)
+	}
+	moduleHash := hex.EncodeToString(hash)
+
+	cacheTag := s.runtimeConfig.DefaultCacheTag
+	if auth := dauth.FromContext(ctx); auth != nil {
+		if tag := auth.Get("X-Sf-Substreams-Cache-Tag"); tag != "" {
+			if !IsValidCacheTag(tag) {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid value for X-Sf-Substreams-Cache-Tag %s, should only contain letters, numbers, hyphens and undescores", tag)
+			}
+			cacheTag = tag
+		}
+	}
+
+	resolve := func(ctx context.Context) (*store.Config, *store.FileInfo, error) {
+		cacheStore, err := s.runtimeConfig.BaseObjectStore.SubStore(cacheTag)
+		if err != nil {
+			return nil, nil, fmt.Errorf("internal error setting store: %w", err)
+		}
+
+		config, err := store.NewConfig(module.Name, module.InitialBlock, moduleHash, module.GetKindStore().UpdatePolicy, module.GetKindStore().ValueType, cacheStore, "")
+		if err != nil {
+			return nil, nil, fmt.Errorf("configuring store %q: %w", module.Name, err)
+		}
+
+		files, err := config.ListSnapshotFiles(ctx, math.MaxUint64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("listing snapshots of store %q: %w", module.Name, err)
+		}
+		var latest *store.FileInfo
+		for _, file := range files {
+			if file.Partial {
+				continue
+			}
+			if latest == nil || file.Range.ExclusiveEndBlock > latest.Range.ExclusiveEndBlock {
+				latest = file
+			}
+		}
+		if latest == nil {
+			return nil, nil, status.Errorf(codes.NotFound, "no full snapshot of store %q (hash %s) found", module.Name, moduleHash)
+		}
+		return config, latest, nil
+	}
+
+	snapshot, err := s.storeSnapshots.get(ctx, path.Join(cacheTag, moduleHash), resolve, s.logger)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Debug("querying store snapshot", zap.String("module", module.Name), zap.String("module_hash", moduleHash), zap.String("file", snapshot.file.Filename))
+	return snapshot, nil
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+const (
+	// storeSnapshotResolveInterval is how long the latest snapshot found for
+	// a store is queried before its snapshots are listed again.
+	storeSnapshotResolveInterval = time.Minute
+	// storeSnapshotOpenTimeout bounds the download of a snapshot, which goes
+	// on when the call that started it times out, for the next ones.
+	storeSnapshotOpenTimeout = 10 * time.Minute
+	// storeSnapshotCacheSize is the number of snapshots kept locally, the
+	// least recently queried ones being removed first.
+	storeSnapshotCacheSize = 32
+)
+
+// storeSnapshots keeps a local copy of the latest snapshot of the stores
+// queried, so that each call only reads the blocks of the snapshot holding
+// the keys it looks for. The zero value is ready to use.
+type storeSnapshots struct {
+	lock    sync.Mutex
+	entries map[string]*storeSnapshot // by cache tag and module hash
+}
+
+// storeSnapshot is the latest snapshot of a store when it was resolved.
+type storeSnapshot struct {
+	file       *store.FileInfo
+	info       *pbsubstreamsrpc.StoreSnapshotInfo
+	resolvedAt time.Time // guarded by storeSnapshots.lock
+	usedAt     time.Time // guarded by storeSnapshots.lock
+
+	opened chan struct{} // closed once `local` or `err` is set
+	local  *store.LocalSnapshot
+	err    error
+
+	// lock is held for reading while the snapshot is queried, and for
+	// writing to remove its local copy.
+	lock   sync.RWMutex
+	closed bool
+}
+
+type resolveStoreSnapshot func(ctx context.Context) (*store.Config, *store.FileInfo, error)
+
+// get returns the latest snapshot of the store `key`, listing its snapshots
+// with `resolve` when not done in the last storeSnapshotResolveInterval. The
+// snapshot returned must be released once read.
+func (c *storeSnapshots) get(ctx context.Context, key string, resolve resolveStoreSnapshot, logger *zap.Logger) (*storeSnapshot, error) {
+	for {
+		snapshot, err := c.lookup(ctx, key, resolve, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case <-snapshot.opened:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if snapshot.err != nil {
+			return nil, snapshot.err
+		}
+		if snapshot.acquire() {
+			return snapshot, nil
+		}
+		// removed meanwhile, superseded by a newer snapshot or evicted
+	}
+}
+
+func (c *storeSnapshots) lookup(ctx context.Context, key string, resolve resolveStoreSnapshot, logger *zap.Logger) (*storeSnapshot, error) {
+	now := time.Now()
+	c.lock.Lock()
+	if snapshot := c.entries[key]; snapshot != nil && now.Sub(snapshot.resolvedAt) < storeSnapshotResolveInterval {
+		snapshot.usedAt = now
+		c.lock.Unlock()
+		return snapshot, nil
+	}
+	c.lock.Unlock()
+
+	config, file, err := resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	current := c.entries[key]
+	if current != nil && current.file.Filename == file.Filename {
+		current.resolvedAt = now
+		current.usedAt = now
+		return current, nil
+	}
+
+	snapshot := &storeSnapshot{
+		file: file,
+		info: &pbsubstreamsrpc.StoreSnapshotInfo{
+			ModuleHash:        config.ModuleHash(),
+			ExclusiveEndBlock: file.Range.ExclusiveEndBlock,
+		},
+		resolvedAt: now,
+		usedAt:     now,
+		opened:     make(chan struct{}),
+	}
+	go c.open(key, snapshot, config, logger)
+
+	if current != nil {
+		go current.remove()
+	}
+	if c.entries == nil {
+		c.entries = make(map[string]*storeSnapshot)
+	}
+	c.entries[key] = snapshot
+	c.evict()
+	return snapshot, nil
+}
+
+// open downloads the local copy of `snapshot`, forgetting it on failure so
+// that the next call tries again.
+func (c *storeSnapshots) open(key string, snapshot *storeSnapshot, config *store.Config, logger *zap.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), storeSnapshotOpenTimeout)
+	defer cancel()
+
+	snapshot.local, snapshot.err = config.OpenLocalSnapshot(ctx, snapshot.file)
+	close(snapshot.opened)
+	if snapshot.err == nil {
+		return
+	}
+
+	logger.Warn("cannot download store snapshot", zap.String("key", key), zap.String("file", snapshot.file.Filename), zap.Error(snapshot.err))
+	c.lock.Lock()
+	if c.entries[key] == snapshot {
+		delete(c.entries, key)
+	}
+	c.lock.Unlock()
+}
+
+// evict removes the least recently queried snapshots beyond
+// storeSnapshotCacheSize. The lock must be held.
+func (c *storeSnapshots) evict() {
+	for len(c.entries) > storeSnapshotCacheSize {
+		var oldestKey string
+		var oldest *storeSnapshot
+		for key, snapshot := range c.entries {
+			if oldest == nil || snapshot.usedAt.Before(oldest.usedAt) {
+				oldestKey, oldest = key, snapshot
+			}
+		}
+		delete(c.entries, oldestKey)
+		go oldest.remove()
+	}
+}
+
+// close removes the local copy of all the snapshots.
+func (c *storeSnapshots) close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for key, snapshot := range c.entries {
+		delete(c.entries, key)
+		go snapshot.remove()
+	}
+}
+
+func (s *storeSnapshot) acquire() bool {
+	s.lock.RLock()
+	if s.closed {
+		s.lock.RUnlock()
+		return false
+	}
+	return true
+}
+
+func (s *storeSnapshot) release() {
+	s.lock.RUnlock()
+}
+
+// remove deletes the local copy of the snapshot, once opened and not
+// queried anymore.
+func (s *storeSnapshot) remove() {
+	<-s.opened
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	if s.local != nil {
+		s.local.Close()
+	}
+}
@@ -11,6 +11,7 @@ import (
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 	"github.com/streamingfast/substreams/pipeline/outputmodules"
 	"github.com/streamingfast/substreams/service/config"
+	"github.com/streamingfast/substreams/storage/gc"
 	"github.com/streamingfast/substreams/storage/store"
 )
 
@@ -34,13 +35,14 @@ func TestNewService(runtimeConfig config.RuntimeConfig, linearHandoffBlockNum ui
 			}
 			return 0, fmt.Errorf("no live feed")
 		},
-		tracer: nil,
-		logger: zlog,
+		tracer:        nil,
+		logger:        zlog,
+		accessTracker: gc.NewAccessTracker(runtimeConfig.BaseObjectStore, gc.DefaultAccessRecordInterval),
 	}
 }
 
 func (s *Tier1Service) TestBlocks(ctx context.Context, isSubRequest bool, request *pbsubstreamsrpc.Request, respFunc substreams.ResponseFunc) error {
-	outputGraph, err := outputmodules.NewOutputModuleGraph(request.OutputModule, request.ProductionMode, request.Modules)
+	outputGraph, err := outputmodules.NewOutputModulesGraph(request.RequestedOutputModules(), request.ProductionMode, request.Modules)
 	if err != nil {
 		return stream.NewErrInvalidArg(err.Error())
 	}
@@ -48,14 +50,18 @@ func (s *Tier1Service) TestBlocks(ctx context.Context, isSubRequest bool, reques
 	return s.blocks(ctx, request, outputGraph, respFunc)
 }
 
-func TestNewServiceTier2(runtimeConfig config.RuntimeConfig, streamFactoryFunc StreamFactoryFunc) *Tier2Service {
-	return &Tier2Service{
+func TestNewServiceTier2(runtimeConfig config.RuntimeConfig, streamFactoryFunc StreamFactoryFunc, opts ...Option) *Tier2Service {
+	s := &Tier2Service{
 		blockType:         "sf.substreams.v1.test.Block",
 		streamFactoryFunc: streamFactoryFunc,
 		runtimeConfig:     runtimeConfig,
 		tracer:            nil,
 		logger:            zlog,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *Tier2Service) TestProcessRange(ctx context.Context, request *pbssinternal.ProcessRangeRequest, respFunc substreams.ResponseFunc, traceID *string) error {
@@ -6,11 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"github.com/streamingfast/bstream"
+	"path"
 	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/streamingfast/bstream/hub"
 	"github.com/streamingfast/bstream/stream"
@@ -27,6 +29,7 @@ import (
 	"github.com/streamingfast/substreams"
 	"github.com/streamingfast/substreams/client"
 	"github.com/streamingfast/substreams/metrics"
+	"github.com/streamingfast/substreams/orchestrator/jobs"
 	"github.com/streamingfast/substreams/orchestrator/plan"
 	"github.com/streamingfast/substreams/orchestrator/work"
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
@@ -39,6 +42,7 @@ import (
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/service/config"
 	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/gc"
 	"github.com/streamingfast/substreams/storage/store"
 	"github.com/streamingfast/substreams/wasm"
 	"go.opentelemetry.io/otel/attribute"
@@ -52,6 +56,7 @@ import (
 type Tier1Service struct {
 	*shutter.Shutter
 	ssconnect.UnimplementedStreamHandler
+	ssconnect.UnimplementedStoreQueryHandler
 
 	blockType          string
 	wasmExtensions     []wasm.WASMExtensioner
@@ -63,6 +68,19 @@ type Tier1Service struct {
 	tracer             ttrace.Tracer
 	logger             *zap.Logger
 
+	// storeSnapshots are the local copies of the store snapshots queried
+	// through the StoreQuery service.
+	storeSnapshots storeSnapshots
+
+	accessTracker   *gc.AccessTracker
+	cacheGCRules    gc.Rules
+	cacheGCInterval time.Duration
+
+	tier2Capacity *work.CapacityScheduler
+
+	moduleCosts           *metrics.ModuleCosts
+	segmentTargetDuration time.Duration
+
 	getRecentFinalBlock func() (uint64, error)
 	resolveCursor       pipeline.CursorResolver
 	getHeadBlock        func() (uint64, error)
@@ -89,6 +107,7 @@ func NewTier1(
 	logger.Info("creating grpc client factory", zap.Reflect("config", substreamsClientConfig))
 	clientFactory := client.NewInternalClientFactory(substreamsClientConfig)
 
+	var s *Tier1Service
 	runtimeConfig := config.NewRuntimeConfig(
 		stateBundleSize,
 		parallelSubRequests,
@@ -97,10 +116,11 @@ func NewTier1(
 		stateStore,
 		defaultCacheTag,
 		func(logger *zap.Logger) work.Worker {
-			return work.NewRemoteWorker(clientFactory, logger)
+			return work.NewRemoteWorker(clientFactory, s.tier2Capacity, logger)
 		},
 	)
-	s := &Tier1Service{
+	runtimeConfig.JobRegistry = jobs.NewRegistry()
+	s = &Tier1Service{
 		Shutter:        shutter.New(),
 		runtimeConfig:  runtimeConfig,
 		blockType:      blockType,
@@ -108,6 +128,7 @@ func NewTier1(
 		failedRequests: make(map[string]*recordedFailure),
 		resolveCursor:  pipeline.NewCursorResolver(hub, mergedBlocksStore, forkedBlocksStore),
 		logger:         logger,
+		accessTracker:  gc.NewAccessTracker(stateStore, gc.DefaultAccessRecordInterval),
 	}
 
 	sf := &StreamFactory{
@@ -125,6 +146,11 @@ func NewTier1(
 		opt(s)
 	}
 
+	if s.cacheGCInterval != 0 {
+		go s.runCacheGC()
+	}
+	s.OnTerminated(func(error) { s.storeSnapshots.close() })
+
 	return s
 }
 
@@ -178,7 +204,7 @@ func (s *Tier1Service) Blocks(
 		zap.Uint64("stop_block", request.StopBlockNum),
 		zap.String("cursor", request.StartCursor),
 		zap.Strings("modules", moduleNames),
-		zap.String("output_module", request.OutputModule),
+		zap.Strings("output_modules", request.RequestedOutputModules()),
 	}
 	fields = append(fields, zap.Bool("production_mode", request.ProductionMode))
 	if auth := dauth.FromContext(ctx); auth != nil {
@@ -200,13 +226,18 @@ func (s *Tier1Service) Blocks(
 		return status.Error(codes.InvalidArgument, fmt.Errorf("validate request: %w", err).Error())
 	}
 
-	outputGraph, err := outputmodules.NewOutputModuleGraph(request.OutputModule, request.ProductionMode, request.Modules)
+	outputGraph, err := outputmodules.NewOutputModulesGraph(request.RequestedOutputModules(), request.ProductionMode, request.Modules)
 	if err != nil {
 		return bsstream.NewErrInvalidArg(err.Error())
 	}
 
+	var outputModuleHashes []string
+	for _, outputModule := range request.RequestedOutputModules() {
+		outputModuleHashes = append(outputModuleHashes, outputGraph.ModuleHashes().Get(outputModule))
+	}
+
 	requestID := fmt.Sprintf("%s:%d:%d:%s:%t:%t:%s",
-		outputGraph.ModuleHashes().Get(request.OutputModule),
+		strings.Join(outputModuleHashes, ","),
 		request.StartBlockNum,
 		request.StopBlockNum,
 		request.StartCursor,
@@ -265,7 +296,7 @@ func (s *Tier1Service) writePackage(ctx context.Context, request *pbsubstreamsrp
 		return fmt.Errorf("marshalling package: %w", err)
 	}
 
-	modulePath := filepath.Join(reqctx.Details(ctx).CacheTag, outputGraph.ModuleHashes().Get(request.OutputModule))
+	modulePath := filepath.Join(reqctx.Details(ctx).CacheTag, outputGraph.ModuleHashes().Get(outputGraph.OutputModule().Name))
 	moduleStore, err := s.runtimeConfig.BaseObjectStore.SubStore(modulePath)
 	if err != nil {
 		return fmt.Errorf("getting substore: %w", err)
@@ -282,6 +313,51 @@ func (s *Tier1Service) writePackage(ctx context.Context, request *pbsubstreamsrp
 	return nil
 }
 
+// recordAccess records the access time of the cache of every module used by
+// the request, for the cache retention rules to spare them.
+func (s *Tier1Service) recordAccess(ctx context.Context, outputGraph *outputmodules.Graph) error {
+	cacheTag := reqctx.Details(ctx).CacheTag
+	for _, module := range outputGraph.UsedModules() {
+		moduleDir := path.Join(cacheTag, outputGraph.ModuleHashes().Get(module.Name))
+		if err := s.accessTracker.Touch(ctx, moduleDir); err != nil {
+			return fmt.Errorf("module %q: %w", module.Name, err)
+		}
+	}
+	return nil
+}
+
+// runCacheGC applies the cache retention rules to the state store every
+// `cacheGCInterval`, until the service terminates.
+func (s *Tier1Service) runCacheGC() {
+	rules := s.cacheGCRules
+	if rules.StateBundleSize == 0 {
+		rules.StateBundleSize = s.runtimeConfig.StateBundleSize
+	}
+	collector := gc.NewCollector(s.runtimeConfig.BaseObjectStore, rules, s.logger)
+
+	ticker := time.NewTicker(s.cacheGCInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.Terminating():
+			return
+		case <-ticker.C:
+		}
+
+		report, err := collector.Run(context.Background(), false)
+		if err != nil {
+			s.logger.Warn("cache garbage collection failed", zap.Error(err))
+			continue
+		}
+		s.logger.Info("cache garbage collection completed",
+			zap.Int("modules", report.Modules),
+			zap.Int("deleted_modules", len(report.DeletedModules)),
+			zap.Int("deleted_full_kvs", len(report.DeletedFullKVs)),
+			zap.Int("deleted_files", report.DeletedFiles),
+		)
+	}
+}
+
 var IsValidCacheTag = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`).MatchString
 
 func (s *Tier1Service) blocks(ctx context.Context, request *pbsubstreamsrpc.Request, outputGraph *outputmodules.Graph, respFunc substreams.ResponseFunc) error {
@@ -320,6 +396,9 @@ func (s *Tier1Service) blocks(ctx context.Context, request *pbsubstreamsrpc.Requ
 	var requestStats *metrics.Stats
 	ctx, requestStats = setupRequestStats(ctx, requestDetails, outputGraph, false)
 	defer requestStats.LogAndClose()
+	if s.moduleCosts != nil {
+		requestStats.RecordModuleCosts(s.moduleCosts, outputGraph.ModuleHashes().Get)
+	}
 
 	traceId := tracing.GetTraceID(ctx).String()
 	respFunc(&pbsubstreamsrpc.Response{
@@ -341,6 +420,9 @@ func (s *Tier1Service) blocks(ctx context.Context, request *pbsubstreamsrpc.Requ
 	if err := s.writePackage(ctx, request, outputGraph); err != nil {
 		logger.Warn("cannot write package", zap.Error(err))
 	}
+	if err := s.recordAccess(ctx, outputGraph); err != nil {
+		logger.Warn("cannot record cache access", zap.Error(err))
+	}
 
 	if err := outputGraph.ValidateRequestStartBlock(requestDetails.ResolvedStartBlockNum); err != nil {
 		return stream.NewErrInvalidArg(err.Error())
@@ -357,15 +439,20 @@ func (s *Tier1Service) blocks(ctx context.Context, request *pbsubstreamsrpc.Requ
 	if err != nil {
 		return fmt.Errorf("new config map: %w", err)
 	}
+	execOutputConfigs.SetFileFormat(s.runtimeConfig.OutputCacheFormat)
 
 	storeConfigs, err := store.NewConfigMap(cacheStore, outputGraph.Stores(), outputGraph.ModuleHashes(), tracing.GetTraceID(ctx).String())
 	if err != nil {
 		return fmt.Errorf("configuring stores: %w", err)
 	}
+	if s.runtimeConfig.StoreDiskBackendDir != "" {
+		storeConfigs.UseDiskBackend(s.runtimeConfig.StoreDiskBackendDir, s.runtimeConfig.StoreMemtableSizeLimit)
+	}
+	storeConfigs.SetSnapshotFormat(s.runtimeConfig.StoreSnapshotFormat)
 
 	stores := pipeline.NewStores(ctx, storeConfigs, s.runtimeConfig.StateBundleSize, requestDetails.LinearHandoffBlockNum, request.StopBlockNum, false)
 
-	execOutputCacheEngine, err := cache.NewEngine(ctx, s.runtimeConfig, nil, s.blockType)
+	execOutputCacheEngine, err := cache.NewEngine(ctx, s.runtimeConfig, nil, nil, s.blockType)
 	if err != nil {
 		return fmt.Errorf("error building caching engine: %w", err)
 	}
@@ -411,6 +498,7 @@ func (s *Tier1Service) blocks(ctx context.Context, request *pbsubstreamsrpc.Requ
 		requestDetails.LinearHandoffBlockNum,
 		requestDetails.StopBlockNum,
 		scheduleStores,
+		s.segmentBounds(outputGraph, requestDetails),
 	)
 	if err != nil {
 		return fmt.Errorf("error building request plan: %w", err)
@@ -423,7 +511,7 @@ func (s *Tier1Service) blocks(ctx context.Context, request *pbsubstreamsrpc.Requ
 		zap.Uint64("request_stop_block", request.StopBlockNum),
 		zap.String("request_start_cursor", request.StartCursor),
 		zap.String("resolved_cursor", requestDetails.ResolvedCursor),
-		zap.String("output_module", request.OutputModule),
+		zap.Strings("output_modules", request.RequestedOutputModules()),
 	)
 
 	if err := pipe.InitTier1StoresAndBackprocess(ctx, reqPlan); err != nil {
@@ -504,6 +592,39 @@ func tier1ResponseHandler(ctx context.Context, mut *sync.Mutex, logger *zap.Logg
 	}
 }
 
+// segmentBounds lays out the segments of the request after the processing
+// time recorded for its modules, or returns nil for fixed-width segments.
+// The cost of an interval is the one of its modules recorded over it, the
+// modules never executed by a job there, like the output module in
+// development mode, counting for nothing.
+func (s *Tier1Service) segmentBounds(outputGraph *outputmodules.Graph, requestDetails *reqctx.RequestDetails) []uint64 {
+	if s.moduleCosts == nil {
+		return nil
+	}
+
+	interval := s.runtimeConfig.StateBundleSize
+	modules := outputGraph.UsedModules()
+	hashes := outputGraph.ModuleHashes()
+	return plan.AdaptiveSegmentBounds(
+		interval,
+		outputGraph.LowestInitBlock(),
+		requestDetails.LinearHandoffBlockNum,
+		s.segmentTargetDuration,
+		func(intervalStart uint64) (cost time.Duration, known bool) {
+			for _, mod := range modules {
+				if mod.InitialBlock >= intervalStart+interval {
+					continue
+				}
+				if perBlock, found := s.moduleCosts.Cost(hashes.Get(mod.Name), intervalStart); found {
+					cost += perBlock * time.Duration(interval)
+					known = true
+				}
+			}
+			return cost, known
+		},
+	)
+}
+
 func setupRequestStats(ctx context.Context, requestDetails *reqctx.RequestDetails, graph *outputmodules.Graph, tier2 bool) (context.Context, *metrics.Stats) {
 	logger := reqctx.Logger(ctx)
 	auth := dauth.FromContext(ctx)
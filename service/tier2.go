@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	"github.com/streamingfast/bstream/stream"
 	"github.com/streamingfast/dauth"
@@ -13,14 +15,18 @@ import (
 	tracing "github.com/streamingfast/sf-tracing"
 
 	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/block"
 	"github.com/streamingfast/substreams/metrics"
+	"github.com/streamingfast/substreams/orchestrator/work"
 	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"github.com/streamingfast/substreams/pipeline"
 	"github.com/streamingfast/substreams/pipeline/cache"
 	"github.com/streamingfast/substreams/pipeline/outputmodules"
 	"github.com/streamingfast/substreams/reqctx"
 	"github.com/streamingfast/substreams/service/config"
 	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/index"
 	"github.com/streamingfast/substreams/storage/store"
 	"github.com/streamingfast/substreams/wasm"
 	"go.opentelemetry.io/otel/attribute"
@@ -39,6 +45,10 @@ type Tier2Service struct {
 	runtimeConfig     config.RuntimeConfig
 	tracer            ttrace.Tracer
 	logger            *zap.Logger
+
+	// checkpointInterval is how often jobs save a checkpoint of their
+	// progress, for a retry of them to resume from, none being saved when 0.
+	checkpointInterval time.Duration
 }
 
 func NewTier2(
@@ -168,7 +178,7 @@ func (s *Tier2Service) processRange(ctx context.Context, request *pbssinternal.P
 
 	// FIXME: here, we validate that we have only modules on the same
 	// stage, otherwise we fall back.
-	outputGraph, err := outputmodules.NewOutputModuleGraph(request.OutputModule, true, request.Modules)
+	outputGraph, err := outputmodules.NewOutputModulesGraph(request.RequestedOutputModules(), true, request.Modules)
 	if err != nil {
 		return stream.NewErrInvalidArg(err.Error())
 	}
@@ -194,7 +204,7 @@ func (s *Tier2Service) processRange(ctx context.Context, request *pbssinternal.P
 	ctx, requestStats = setupRequestStats(ctx, requestDetails, outputGraph, true)
 	defer requestStats.LogAndClose()
 
-	if err := outputGraph.ValidateRequestStartBlock(requestDetails.ResolvedStartBlockNum); err != nil {
+	if err := outputGraph.ValidateSubrequestStartBlock(requestDetails.ResolvedStartBlockNum); err != nil {
 		return stream.NewErrInvalidArg(err.Error())
 	}
 
@@ -204,31 +214,54 @@ func (s *Tier2Service) processRange(ctx context.Context, request *pbssinternal.P
 	if err != nil {
 		return fmt.Errorf("internal error setting store: %w", err)
 	}
+	// A speculative duplicate writes its files apart, tier1 adopts them if it wins.
+	cacheStore, err = work.AttemptStore(cacheStore, traceID, request.Attempt)
+	if err != nil {
+		return fmt.Errorf("internal error setting attempt store: %w", err)
+	}
 
 	execOutputConfigs, err := execout.NewConfigs(cacheStore, outputGraph.UsedModules(), outputGraph.ModuleHashes(), s.runtimeConfig.StateBundleSize, logger)
 	if err != nil {
 		return fmt.Errorf("new config map: %w", err)
 	}
+	execOutputConfigs.SetFileFormat(s.runtimeConfig.OutputCacheFormat)
+
+	indexConfigs, err := index.NewConfigs(cacheStore, outputGraph.UsedModules(), outputGraph.ModuleHashes().Get, s.runtimeConfig.StateBundleSize, logger)
+	if err != nil {
+		return fmt.Errorf("new index config map: %w", err)
+	}
 
 	storeConfigs, err := store.NewConfigMap(cacheStore, outputGraph.Stores(), outputGraph.ModuleHashes(), traceID)
 	if err != nil {
 		return fmt.Errorf("configuring stores: %w", err)
 	}
+	if s.runtimeConfig.StoreDiskBackendDir != "" {
+		storeConfigs.UseDiskBackend(s.runtimeConfig.StoreDiskBackendDir, s.runtimeConfig.StoreMemtableSizeLimit)
+	}
+	storeConfigs.SetSnapshotFormat(s.runtimeConfig.StoreSnapshotFormat)
 	stores := pipeline.NewStores(ctx, storeConfigs, s.runtimeConfig.StateBundleSize, requestDetails.ResolvedStartBlockNum, request.StopBlockNum, true)
 
-	outputModule := outputGraph.OutputModule()
+	outputModules := outputGraph.OutputModules()
 
 	var execOutWriter *execout.Writer
 	if !outputGraph.StagedUsedModules()[request.Stage].LastLayer().IsStoreLayer() {
 		execOutWriter = execout.NewWriter(
 			requestDetails.ResolvedStartBlockNum,
 			requestDetails.StopBlockNum,
-			outputModule.Name,
+			request.RequestedOutputModules(),
 			execOutputConfigs,
 		)
 	}
 
-	execOutputCacheEngine, err := cache.NewEngine(ctx, s.runtimeConfig, execOutWriter, s.blockType)
+	jobRange := block.NewRange(requestDetails.ResolvedStartBlockNum, request.StopBlockNum)
+	executedStages := outputGraph.StagedUsedModules()[0 : request.Stage+1]
+
+	var indexWriter *index.Writer
+	if indexModules := blockIndexModules(executedStages); len(indexModules) != 0 {
+		indexWriter = index.NewWriter(jobRange, indexModules, indexConfigs)
+	}
+
+	execOutputCacheEngine, err := cache.NewEngine(ctx, s.runtimeConfig, execOutWriter, indexWriter, s.blockType)
 	if err != nil {
 		return fmt.Errorf("error building caching engine: %w", err)
 	}
@@ -236,6 +269,10 @@ func (s *Tier2Service) processRange(ctx context.Context, request *pbssinternal.P
 	opts := s.buildPipelineOptions(ctx, request)
 	opts = append(opts, pipeline.WithFinalBlocksOnly())
 	opts = append(opts, pipeline.WithHighestStage(request.Stage))
+	opts = append(opts, pipeline.WithCheckpoints(s.checkpointInterval))
+	if request.ResumeFromBlock != 0 {
+		opts = append(opts, pipeline.WithResumeFromBlock(request.ResumeFromBlock))
+	}
 
 	pipe := pipeline.New(
 		ctx,
@@ -254,18 +291,37 @@ func (s *Tier2Service) processRange(ctx context.Context, request *pbssinternal.P
 	logger.Info("initializing tier2 pipeline",
 		zap.Uint64("request_start_block", requestDetails.ResolvedStartBlockNum),
 		zap.Uint64("request_stop_block", request.StopBlockNum),
-		zap.String("output_module", request.OutputModule),
+		zap.Strings("output_modules", request.RequestedOutputModules()),
 		zap.Uint32("stage", request.Stage),
+		zap.Uint64("resume_from_block", request.ResumeFromBlock),
+		zap.Uint32("attempt", request.Attempt),
 	)
 	if err := pipe.InitTier2Stores(ctx); err != nil {
 		return fmt.Errorf("error building pipeline: %w", err)
 	}
 
+	skip, err := canSkipRange(ctx, indexConfigs, executedStages.LastStage(), outputModules, jobRange)
+	if err != nil {
+		return fmt.Errorf("checking block indexes: %w", err)
+	}
+	if skip {
+		// Terminating right away writes the same empty outputs and
+		// partial stores as processing the range would.
+		logger.Info("block indexes rule out every block of the range, skipping it", zap.Stringer("range", jobRange))
+		return pipe.OnStreamTerminated(ctx, io.EOF)
+	}
+
+	streamStartBlock := requestDetails.ResolvedStartBlockNum
+	if resumeFromBlock := pipe.ResumeFromBlock(); resumeFromBlock != 0 {
+		logger.Info("resuming from checkpoint", zap.Uint64("resume_from_block", resumeFromBlock))
+		streamStartBlock = resumeFromBlock
+	}
+
 	var streamErr error
 	blockStream, err := s.streamFactoryFunc(
 		ctx,
 		pipe,
-		int64(requestDetails.ResolvedStartBlockNum),
+		int64(streamStartBlock),
 		request.StopBlockNum,
 		"",
 		true,
@@ -283,6 +339,47 @@ func (s *Tier2Service) processRange(ctx context.Context, request *pbssinternal.P
 	return pipe.OnStreamTerminated(ctx, streamErr)
 }
 
+// blockIndexModules returns the names of the block index modules executed
+// by `stages`, for which index files are written.
+func blockIndexModules(stages outputmodules.ExecutionStages) (out []string) {
+	for _, stage := range stages {
+		for _, layer := range stage {
+			for _, mod := range layer {
+				if mod.GetKindBlockIndex() != nil {
+					out = append(out, mod.Name)
+				}
+			}
+		}
+	}
+	return
+}
+
+// canSkipRange returns whether the block filters of the modules producing
+// the outputs of `lastStage` rule out every block of `jobRange`, in which
+// case there is no need to execute it at all.
+//
+// This is only known once the index files of the range exist. On a cold
+// cache, the block index modules run in the same job as the modules they
+// filter: every block of the range is read, and the filtered modules are
+// only executed on the blocks matching their filters.
+func canSkipRange(ctx context.Context, indexConfigs *index.Configs, lastStage outputmodules.StageLayers, outputModules []*pbsubstreams.Module, jobRange *block.Range) (bool, error) {
+	producingModules := outputModules
+	if lastLayer := lastStage.LastLayer(); lastLayer.IsStoreLayer() {
+		producingModules = lastLayer
+	}
+
+	for _, mod := range producingModules {
+		bitmap, err := indexConfigs.ExecutionBitmap(ctx, mod, jobRange)
+		if err != nil {
+			return false, fmt.Errorf("module %q: %w", mod.Name, err)
+		}
+		if bitmap == nil || !bitmap.IsEmpty() {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (s *Tier2Service) buildPipelineOptions(ctx context.Context, request *pbssinternal.ProcessRangeRequest) (opts []pipeline.Option) {
 	requestDetails := reqctx.Details(ctx)
 	for _, pipeOpts := range s.pipelineOptions {
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/storage/index"
+)
+
+func Test_canSkipRange(t *testing.T) {
+	ctx := context.Background()
+
+	indexModule := &pbsubstreams.Module{
+		Name: "index_transfers",
+		Kind: &pbsubstreams.Module_KindBlockIndex_{KindBlockIndex: &pbsubstreams.Module_KindBlockIndex{}},
+		Inputs: []*pbsubstreams.Module_Input{{
+			Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "sf.test.Block"}},
+		}},
+	}
+	mapModule := &pbsubstreams.Module{
+		Name: "map_transfers",
+		Kind: &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{}},
+		Inputs: []*pbsubstreams.Module_Input{{
+			Input:       &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "sf.test.Block"}},
+			BlockFilter: &pbsubstreams.Module_Input_BlockFilter{Module: "index_transfers", Query: "approval"},
+		}},
+	}
+	lastStage := outputmodules.StageLayers{{indexModule}, {mapModule}}
+	jobRange := block.NewRange(0, 100)
+
+	configs, err := index.NewConfigs(dstore.NewMockStore(nil), []*pbsubstreams.Module{indexModule, mapModule}, func(string) string { return "abc" }, 100, zap.NewNop())
+	require.NoError(t, err)
+
+	// On a cold cache, the index module runs in the same job as the module
+	// it filters, so the range is processed.
+	skip, err := canSkipRange(ctx, configs, lastStage, []*pbsubstreams.Module{mapModule}, jobRange)
+	require.NoError(t, err)
+	assert.False(t, skip)
+
+	idx := index.NewIndex(jobRange)
+	idx.Add(42, []string{"transfer"})
+	require.NoError(t, configs.ConfigMap["index_transfers"].Save(ctx, idx))
+
+	skip, err = canSkipRange(ctx, configs, lastStage, []*pbsubstreams.Module{mapModule}, jobRange)
+	require.NoError(t, err)
+	assert.True(t, skip, "no block of the range has an approval key")
+
+	skip, err = canSkipRange(ctx, configs, lastStage, []*pbsubstreams.Module{mapModule, indexModule}, jobRange)
+	require.NoError(t, err)
+	assert.False(t, skip, "the unfiltered index module is requested")
+}
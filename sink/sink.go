@@ -0,0 +1,68 @@
+// Package sink runs a Substreams stream on behalf of a sink, passing the
+// outputs of the blocks and the undo signals to a Handler, which persists
+// them along with the cursor of the stream. The stream is resumed from the
+// last cursor handled when it fails, and from the cursor persisted by the
+// Handler when the sink is restarted.
+package sink
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/streamingfast/substreams/client"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// Handler persists what a sink receives. Each call must persist the
+// cursor it is given atomically with the changes it makes, so that the
+// stream can be resumed exactly where it stopped.
+type Handler interface {
+	// Cursor returns the cursor last persisted, empty if none.
+	Cursor(ctx context.Context) (string, error)
+	// HandleBlockScopedData persists the outputs of a block, and its cursor.
+	HandleBlockScopedData(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData) error
+	// HandleBlockUndoSignal reverts the changes of the blocks above
+	// `undo.LastValidBlock`, and persists `undo.LastValidCursor`.
+	HandleBlockUndoSignal(ctx context.Context, undo *pbsubstreamsrpc.BlockUndoSignal) error
+}
+
+// Sinker runs a stream, passing what it receives to a Handler.
+type Sinker struct {
+	stream *client.Stream
+}
+
+// New returns a Sinker running `request` through `streamClient`. The start
+// cursor of `request` is replaced by the one persisted by `handler`, if any.
+func New(streamClient pbsubstreamsrpc.StreamClient, callOpts []grpc.CallOption, request *pbsubstreamsrpc.Request, handler Handler, logger *zap.Logger) *Sinker {
+	return &Sinker{
+		stream: client.NewStream(streamClient, callOpts, request, handlerCursors{handler},
+			client.WithOnData(handler.HandleBlockScopedData),
+			client.WithOnUndo(handler.HandleBlockUndoSignal),
+			client.WithLogger(logger),
+		),
+	}
+}
+
+// Run runs the stream until its stop block is reached, `ctx` is canceled,
+// the handler fails, or the stream fails client.DefaultMaxRetries times in
+// a row.
+func (s *Sinker) Run(ctx context.Context) error {
+	return s.stream.Run(ctx)
+}
+
+// handlerCursors is the CursorStore of a Handler, which persists the
+// cursors itself.
+type handlerCursors struct {
+	handler Handler
+}
+
+func (c handlerCursors) Load(ctx context.Context) (string, error) {
+	return c.handler.Cursor(ctx)
+}
+
+func (c handlerCursors) Save(context.Context, string, *pbsubstreams.BlockRef) error {
+	return nil
+}
@@ -0,0 +1,67 @@
+package sink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/streamingfast/substreams/client/clienttest"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+type testHandler struct {
+	cursor string
+	events []string
+}
+
+func (h *testHandler) Cursor(context.Context) (string, error) { return h.cursor, nil }
+
+func (h *testHandler) HandleBlockScopedData(_ context.Context, data *pbsubstreamsrpc.BlockScopedData) error {
+	h.cursor = data.Cursor
+	h.events = append(h.events, "data:"+data.Clock.Id)
+	return nil
+}
+
+func (h *testHandler) HandleBlockUndoSignal(_ context.Context, undo *pbsubstreamsrpc.BlockUndoSignal) error {
+	h.cursor = undo.LastValidCursor
+	h.events = append(h.events, "undo:"+undo.LastValidBlock.Id)
+	return nil
+}
+
+func data(id string) *pbsubstreamsrpc.Response {
+	return &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockScopedData{BlockScopedData: &pbsubstreamsrpc.BlockScopedData{
+		Clock:  &pbsubstreams.Clock{Id: id},
+		Cursor: "c" + id,
+	}}}
+}
+
+func undo(id string) *pbsubstreamsrpc.Response {
+	return &pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockUndoSignal{BlockUndoSignal: &pbsubstreamsrpc.BlockUndoSignal{
+		LastValidBlock:  &pbsubstreams.BlockRef{Id: id},
+		LastValidCursor: "c" + id,
+	}}}
+}
+
+func TestSinker_Run(t *testing.T) {
+	client := &clienttest.StreamClient{
+		Streams: [][]*pbsubstreamsrpc.Response{
+			{data("1"), data("2"), data("3b"), undo("2")},
+			{data("3"), data("4")},
+		},
+		Errs: []error{status.Error(codes.Unavailable, "connection reset"), nil},
+	}
+	handler := &testHandler{cursor: "c0"}
+
+	sinker := New(client, nil, &pbsubstreamsrpc.Request{StartCursor: "ignored"}, handler, zap.NewNop())
+	require.NoError(t, sinker.Run(context.Background()))
+
+	assert.Equal(t, []string{"c0", "c2"}, client.Cursors)
+	assert.Equal(t, []string{"data:1", "data:2", "data:3b", "undo:2", "data:3", "data:4"}, handler.events)
+	assert.Equal(t, "c4", handler.cursor)
+}
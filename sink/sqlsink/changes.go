@@ -0,0 +1,217 @@
+package sqlsink
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+)
+
+// Operation is the operation of a TableChange.
+type Operation int
+
+const (
+	OperationCreate Operation = iota + 1
+	OperationUpdate
+	OperationDelete
+	OperationUpsert
+)
+
+func (o Operation) String() string {
+	switch o {
+	case OperationCreate:
+		return "create"
+	case OperationUpdate:
+		return "update"
+	case OperationDelete:
+		return "delete"
+	case OperationUpsert:
+		return "upsert"
+	}
+	return fmt.Sprintf("Operation(%d)", int(o))
+}
+
+// TableChange is a change to a row of a table, decoded from a
+// `sf.substreams.sink.database.v1.TableChange` message.
+type TableChange struct {
+	Table string
+	// PrimaryKey holds the value of the single primary key column, named
+	// after the table's primary key, if PrimaryKeyColumns is nil.
+	PrimaryKey string
+	// PrimaryKeyColumns holds the value of each primary key column, for
+	// tables with a composite primary key.
+	PrimaryKeyColumns map[string]string
+	Ordinal           uint64
+	Operation         Operation
+	Fields            []Field
+}
+
+// Field is the new value of a column of a row.
+type Field struct {
+	Name     string
+	NewValue string
+}
+
+// DecodeChanges decodes the `DatabaseChanges` message `payload`, whose
+// type is `msgDesc`, returning its table changes sorted by ordinal. Any
+// message with the fields of `sf.substreams.sink.database.v1.DatabaseChanges`
+// can be decoded.
+func DecodeChanges(msgDesc *desc.MessageDescriptor, payload []byte) ([]*TableChange, error) {
+	msg := dynamic.NewMessage(msgDesc)
+	if err := msg.Unmarshal(payload); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", msgDesc.GetFullyQualifiedName(), err)
+	}
+
+	tableChanges, err := repeatedMessages(msg, "table_changes")
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]*TableChange, 0, len(tableChanges))
+	for i, tableChange := range tableChanges {
+		change, err := decodeTableChange(tableChange)
+		if err != nil {
+			return nil, fmt.Errorf("table change %d: %w", i, err)
+		}
+		out = append(out, change)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Ordinal < out[j].Ordinal })
+	return out, nil
+}
+
+// ValidateChangesType returns an error if messages of type `msgDesc` do not
+// have the fields of `sf.substreams.sink.database.v1.DatabaseChanges`.
+func ValidateChangesType(msgDesc *desc.MessageDescriptor) error {
+	field := msgDesc.FindFieldByName("table_changes")
+	if field == nil || !field.IsRepeated() || field.GetMessageType() == nil {
+		return fmt.Errorf("message %s has no repeated message field 'table_changes', expected a sf.substreams.sink.database.v1.DatabaseChanges", msgDesc.GetFullyQualifiedName())
+	}
+	tableChange := field.GetMessageType()
+	for _, name := range []string{"table", "operation", "fields"} {
+		if tableChange.FindFieldByName(name) == nil {
+			return fmt.Errorf("message %s has no field %q, expected a sf.substreams.sink.database.v1.TableChange", tableChange.GetFullyQualifiedName(), name)
+		}
+	}
+	return nil
+}
+
+func decodeTableChange(msg *dynamic.Message) (*TableChange, error) {
+	change := &TableChange{}
+	var err error
+	if change.Table, err = stringField(msg, "table"); err != nil {
+		return nil, err
+	}
+	if change.Table == "" {
+		return nil, fmt.Errorf("no table")
+	}
+	if field := msg.GetMessageDescriptor().FindFieldByName("ordinal"); field != nil {
+		change.Ordinal, _ = msg.GetField(field).(uint64)
+	}
+
+	if change.Operation, err = operation(msg); err != nil {
+		return nil, fmt.Errorf("table %q: %w", change.Table, err)
+	}
+
+	if field := msg.GetMessageDescriptor().FindFieldByName("composite_pk"); field != nil && msg.HasField(field) {
+		keys, err := mapField(msg.GetField(field).(*dynamic.Message), "keys")
+		if err != nil {
+			return nil, err
+		}
+		change.PrimaryKeyColumns = keys
+	} else if change.PrimaryKey, err = stringField(msg, "pk"); err != nil {
+		return nil, err
+	}
+
+	fields, err := repeatedMessages(msg, "fields")
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		name, err := stringField(field, "name")
+		if err != nil {
+			return nil, err
+		}
+		value, err := stringField(field, "new_value")
+		if err != nil {
+			return nil, err
+		}
+		change.Fields = append(change.Fields, Field{Name: name, NewValue: value})
+	}
+	return change, nil
+}
+
+func operation(msg *dynamic.Message) (Operation, error) {
+	field := msg.GetMessageDescriptor().FindFieldByName("operation")
+	if field == nil || field.GetEnumType() == nil {
+		return 0, fmt.Errorf("no enum field 'operation'")
+	}
+
+	number := msg.GetField(field).(int32)
+	value := field.GetEnumType().FindValueByNumber(number)
+	if value == nil {
+		return 0, fmt.Errorf("unknown operation %d", number)
+	}
+	// values are either prefixed by their enum name (OPERATION_CREATE), or
+	// not (CREATE)
+	name := value.GetName()
+	if i := strings.LastIndex(name, "_"); i != -1 {
+		name = name[i+1:]
+	}
+	switch name {
+	case "CREATE":
+		return OperationCreate, nil
+	case "UPDATE":
+		return OperationUpdate, nil
+	case "DELETE":
+		return OperationDelete, nil
+	case "UPSERT":
+		return OperationUpsert, nil
+	}
+	return 0, fmt.Errorf("unsupported operation %s", value.GetName())
+}
+
+func stringField(msg *dynamic.Message, name string) (string, error) {
+	field := msg.GetMessageDescriptor().FindFieldByName(name)
+	if field == nil {
+		return "", fmt.Errorf("message %s has no field %q", msg.GetMessageDescriptor().GetFullyQualifiedName(), name)
+	}
+	value, ok := msg.GetField(field).(string)
+	if !ok {
+		return "", fmt.Errorf("field %q of message %s is not a string", name, msg.GetMessageDescriptor().GetFullyQualifiedName())
+	}
+	return value, nil
+}
+
+func repeatedMessages(msg *dynamic.Message, name string) ([]*dynamic.Message, error) {
+	field := msg.GetMessageDescriptor().FindFieldByName(name)
+	if field == nil || !field.IsRepeated() || field.GetMessageType() == nil {
+		return nil, fmt.Errorf("message %s has no repeated message field %q", msg.GetMessageDescriptor().GetFullyQualifiedName(), name)
+	}
+
+	values := msg.GetField(field).([]interface{})
+	out := make([]*dynamic.Message, len(values))
+	for i, value := range values {
+		out[i] = value.(*dynamic.Message)
+	}
+	return out, nil
+}
+
+func mapField(msg *dynamic.Message, name string) (map[string]string, error) {
+	field := msg.GetMessageDescriptor().FindFieldByName(name)
+	if field == nil || !field.IsMap() {
+		return nil, fmt.Errorf("message %s has no map field %q", msg.GetMessageDescriptor().GetFullyQualifiedName(), name)
+	}
+
+	out := map[string]string{}
+	for k, v := range msg.GetField(field).(map[interface{}]interface{}) {
+		key, ok := k.(string)
+		value, ok2 := v.(string)
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("map field %q of message %s does not map strings to strings", name, msg.GetMessageDescriptor().GetFullyQualifiedName())
+		}
+		out[key] = value
+	}
+	return out, nil
+}
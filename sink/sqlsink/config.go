@@ -0,0 +1,58 @@
+package sqlsink
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// Schema returns the `schema` field of the sink config of `pkg`, the SQL
+// statements creating the tables of the sink, as in the `sf.substreams.sink.sql.v1.Service`
+// config:
+//
+//	sink:
+//	  module: db_out
+//	  type: sf.substreams.sink.sql.v1.Service
+//	  config:
+//	    schema: "@schema.sql"
+func Schema(pkg *pbsubstreams.Package) (string, error) {
+	if pkg.SinkConfig == nil {
+		return "", fmt.Errorf("package has no sink config")
+	}
+	typeName := pkg.SinkConfig.TypeUrl
+	if i := strings.LastIndex(typeName, "/"); i != -1 {
+		typeName = typeName[i+1:]
+	}
+
+	files, err := desc.CreateFileDescriptors(pkg.ProtoFiles)
+	if err != nil {
+		return "", fmt.Errorf("creating file descriptors: %w", err)
+	}
+	var msgDesc *desc.MessageDescriptor
+	for _, file := range files {
+		if msgDesc = file.FindMessage(typeName); msgDesc != nil {
+			break
+		}
+	}
+	if msgDesc == nil {
+		return "", fmt.Errorf("sink config type %q not found in the package's protobuf definitions", typeName)
+	}
+
+	config := dynamic.NewMessage(msgDesc)
+	if err := config.Unmarshal(pkg.SinkConfig.Value); err != nil {
+		return "", fmt.Errorf("decoding sink config: %w", err)
+	}
+	field := msgDesc.FindFieldByName("schema")
+	if field == nil {
+		return "", fmt.Errorf("sink config type %q has no 'schema' field", typeName)
+	}
+	schema, ok := config.GetField(field).(string)
+	if !ok {
+		return "", fmt.Errorf("field 'schema' of sink config type %q is not a string", typeName)
+	}
+	return schema, nil
+}
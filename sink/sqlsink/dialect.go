@@ -0,0 +1,145 @@
+package sqlsink
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// querier is implemented by *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// dialect holds what differs between the databases supported.
+type dialect interface {
+	// placeholder returns the placeholder of the n-th argument of a
+	// statement, starting at 1.
+	placeholder(n int) string
+	// historyTableDDL returns the statement creating the history table.
+	historyTableDDL() string
+	tableExists(ctx context.Context, q querier, table string) (bool, error)
+	// primaryKey returns the columns of the primary key of `table`, in order.
+	primaryKey(ctx context.Context, q querier, table string) ([]string, error)
+}
+
+// openDB opens the database of `dsn`, either `postgres://...` or
+// `sqlite://<path>`, `sqlite://:memory:` opening a database in memory.
+func openDB(dsn string) (*sql.DB, dialect, error) {
+	scheme, path, found := strings.Cut(dsn, "://")
+	if !found {
+		return nil, nil, fmt.Errorf("invalid dsn %q, expected postgres://... or sqlite://<path>", dsn)
+	}
+
+	switch scheme {
+	case "postgres", "postgresql":
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return nil, nil, err
+		}
+		return db, postgresDialect{}, nil
+	case "sqlite":
+		db, err := sql.Open("sqlite3", path)
+		if err != nil {
+			return nil, nil, err
+		}
+		// a single connection, writes being serialized anyway, and each
+		// connection to `:memory:` opening a different database
+		db.SetMaxOpenConns(1)
+		return db, sqliteDialect{}, nil
+	}
+	return nil, nil, fmt.Errorf("unsupported database %q, expected postgres or sqlite", scheme)
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+
+func (postgresDialect) historyTableDDL() string {
+	return `CREATE TABLE IF NOT EXISTS substreams_history (
+		id BIGSERIAL PRIMARY KEY,
+		cursor_id TEXT NOT NULL,
+		block_num BIGINT NOT NULL,
+		table_name TEXT NOT NULL,
+		pk TEXT NOT NULL,
+		prev_row TEXT
+	)`
+}
+
+func (postgresDialect) tableExists(ctx context.Context, q querier, table string) (bool, error) {
+	var exists bool
+	if err := q.QueryRowContext(ctx, `SELECT to_regclass($1) IS NOT NULL`, table).Scan(&exists); err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+func (postgresDialect) primaryKey(ctx context.Context, q querier, table string) ([]string, error) {
+	return queryStrings(ctx, q, `
+		SELECT a.attname
+		FROM pg_index i
+		JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+		WHERE i.indrelid = $1::regclass AND i.indisprimary
+		ORDER BY array_position(i.indkey::int2[], a.attnum)`, table)
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) placeholder(int) string { return "?" }
+
+func (sqliteDialect) historyTableDDL() string {
+	return `CREATE TABLE IF NOT EXISTS substreams_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		cursor_id TEXT NOT NULL,
+		block_num INTEGER NOT NULL,
+		table_name TEXT NOT NULL,
+		pk TEXT NOT NULL,
+		prev_row TEXT
+	)`
+}
+
+func (sqliteDialect) tableExists(ctx context.Context, q querier, table string) (bool, error) {
+	var count int
+	if err := q.QueryRowContext(ctx, `SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count); err != nil {
+		return false, err
+	}
+	return count != 0, nil
+}
+
+func (sqliteDialect) primaryKey(ctx context.Context, q querier, table string) ([]string, error) {
+	return queryStrings(ctx, q, `SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk`, table)
+}
+
+func queryStrings(ctx context.Context, q querier, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []string
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		out = append(out, value)
+	}
+	return out, rows.Err()
+}
+
+// quoteIdentifier quotes `name`, each part of a schema qualified name
+// (`schema.table`) being quoted separately.
+func quoteIdentifier(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = `"` + strings.ReplaceAll(part, `"`, `""`) + `"`
+	}
+	return strings.Join(parts, ".")
+}
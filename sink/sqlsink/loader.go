@@ -0,0 +1,444 @@
+// Package sqlsink writes the outputs of a `DatabaseChanges` module to a SQL
+// database, Postgres or SQLite, one transaction per block, along with the
+// cursor of the stream. The previous state of the rows changed by reversible
+// blocks is kept in a history table, from which the changes of the blocks
+// undone by a reorg are reverted.
+package sqlsink
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/client"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/sink"
+)
+
+var _ sink.Handler = (*Loader)(nil)
+
+// Loader applies the table changes of the blocks it handles to a database.
+type Loader struct {
+	db      *sql.DB
+	dialect dialect
+
+	// cursorID identifies the cursor of the sink in the cursors table, the
+	// hash of its output module.
+	cursorID string
+	msgDesc  *desc.MessageDescriptor
+
+	primaryKeys map[string][]string
+	logger      *zap.Logger
+}
+
+// New opens the database of `dsn`, `postgres://...` or `sqlite://<path>`,
+// and returns a Loader of the outputs of type `msgDesc`, a
+// `DatabaseChanges`. On the first run, the tables of the sink are created by
+// executing `schema`, then the cursors and history tables are created.
+func New(ctx context.Context, dsn string, cursorID string, msgDesc *desc.MessageDescriptor, schema string, logger *zap.Logger) (*Loader, error) {
+	if err := ValidateChangesType(msgDesc); err != nil {
+		return nil, err
+	}
+
+	db, dialect, err := openDB(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	l := &Loader{
+		db:          db,
+		dialect:     dialect,
+		cursorID:    cursorID,
+		msgDesc:     msgDesc,
+		primaryKeys: map[string][]string{},
+		logger:      logger,
+	}
+	if err := l.setup(ctx, schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("setting up database: %w", err)
+	}
+	return l, nil
+}
+
+func (l *Loader) setup(ctx context.Context, schema string) error {
+	exists, err := l.dialect.tableExists(ctx, l.db, "substreams_cursors")
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(schema) != "" {
+		l.logger.Info("creating tables from schema")
+		if _, err := tx.ExecContext(ctx, schema); err != nil {
+			return fmt.Errorf("executing schema: %w", err)
+		}
+	}
+	for _, ddl := range []string{client.SQLCursorsTableDDL, l.dialect.historyTableDDL()} {
+		if _, err := tx.ExecContext(ctx, ddl); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close closes the database.
+func (l *Loader) Close() error {
+	return l.db.Close()
+}
+
+func (l *Loader) Cursor(ctx context.Context) (string, error) {
+	var cursor string
+	err := l.db.QueryRowContext(ctx, l.bind(`SELECT cursor FROM substreams_cursors WHERE id = ?`), l.cursorID).Scan(&cursor)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return cursor, err
+}
+
+func (l *Loader) HandleBlockScopedData(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData) error {
+	var changes []*TableChange
+	if output := data.Output.GetMapOutput(); output != nil {
+		var err error
+		if changes, err = DecodeChanges(l.msgDesc, output.Value); err != nil {
+			return err
+		}
+	}
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// the previous state of the rows is kept until the block is final
+	reversible := data.Clock.Number > data.FinalBlockHeight
+	for _, change := range changes {
+		if err := l.apply(ctx, tx, change, data.Clock.Number, reversible); err != nil {
+			return fmt.Errorf("%s of table %q: %w", change.Operation, change.Table, err)
+		}
+	}
+
+	if err := l.saveCursor(ctx, tx, data.Cursor, data.Clock.Number, data.Clock.Id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, l.bind(`DELETE FROM substreams_history WHERE cursor_id = ? AND block_num <= ?`), l.cursorID, data.FinalBlockHeight); err != nil {
+		return fmt.Errorf("pruning history: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (l *Loader) HandleBlockUndoSignal(ctx context.Context, undo *pbsubstreamsrpc.BlockUndoSignal) error {
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	entries, err := l.history(ctx, tx, undo.LastValidBlock.Number)
+	if err != nil {
+		return fmt.Errorf("reading history: %w", err)
+	}
+	for _, entry := range entries {
+		if err := l.revert(ctx, tx, entry); err != nil {
+			return fmt.Errorf("reverting row of table %q changed at block %d: %w", entry.table, entry.blockNum, err)
+		}
+	}
+	l.logger.Debug("reverted rows", zap.Int("count", len(entries)), zap.Uint64("last_valid_block", undo.LastValidBlock.Number))
+
+	if _, err := tx.ExecContext(ctx, l.bind(`DELETE FROM substreams_history WHERE cursor_id = ? AND block_num > ?`), l.cursorID, undo.LastValidBlock.Number); err != nil {
+		return fmt.Errorf("deleting history: %w", err)
+	}
+	if err := l.saveCursor(ctx, tx, undo.LastValidCursor, undo.LastValidBlock.Number, undo.LastValidBlock.Id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (l *Loader) saveCursor(ctx context.Context, tx *sql.Tx, cursor string, blockNum uint64, blockID string) error {
+	_, err := tx.ExecContext(ctx, l.bind(`INSERT INTO substreams_cursors (id, cursor, block_num, block_id) VALUES (?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET cursor = excluded.cursor, block_num = excluded.block_num, block_id = excluded.block_id`),
+		l.cursorID, cursor, blockNum, blockID,
+	)
+	if err != nil {
+		return fmt.Errorf("saving cursor: %w", err)
+	}
+	return nil
+}
+
+// row is the value of the columns of a row, nil values being NULL.
+type row map[string]*string
+
+func (l *Loader) apply(ctx context.Context, tx *sql.Tx, change *TableChange, blockNum uint64, reversible bool) error {
+	pk, err := l.primaryKey(ctx, tx, change)
+	if err != nil {
+		return err
+	}
+
+	if reversible {
+		prev, err := l.selectRow(ctx, tx, change.Table, pk)
+		if err != nil {
+			return fmt.Errorf("reading previous row: %w", err)
+		}
+		if err := l.saveHistory(ctx, tx, blockNum, change.Table, pk, prev); err != nil {
+			return err
+		}
+	}
+
+	values := row{}
+	for column, value := range pk {
+		values[column] = value
+	}
+	for _, field := range change.Fields {
+		value := field.NewValue
+		values[field.Name] = &value
+	}
+
+	switch change.Operation {
+	case OperationCreate:
+		return l.insert(ctx, tx, change.Table, values, nil)
+	case OperationUpsert:
+		return l.insert(ctx, tx, change.Table, values, pk)
+	case OperationUpdate:
+		return l.update(ctx, tx, change.Table, pk, change.Fields)
+	case OperationDelete:
+		return l.delete(ctx, tx, change.Table, pk)
+	}
+	return fmt.Errorf("unsupported operation %s", change.Operation)
+}
+
+// primaryKey returns the value of each column of the primary key of the row
+// changed by `change`.
+func (l *Loader) primaryKey(ctx context.Context, q querier, change *TableChange) (row, error) {
+	if change.PrimaryKeyColumns != nil {
+		pk := row{}
+		for column, value := range change.PrimaryKeyColumns {
+			value := value
+			pk[column] = &value
+		}
+		return pk, nil
+	}
+
+	columns, found := l.primaryKeys[change.Table]
+	if !found {
+		var err error
+		if columns, err = l.dialect.primaryKey(ctx, q, change.Table); err != nil {
+			return nil, fmt.Errorf("reading primary key: %w", err)
+		}
+		l.primaryKeys[change.Table] = columns
+	}
+	if len(columns) != 1 {
+		return nil, fmt.Errorf("table has %d primary key columns, a single one is required when the change has no composite primary key", len(columns))
+	}
+	return row{columns[0]: &change.PrimaryKey}, nil
+}
+
+func (l *Loader) insert(ctx context.Context, tx *sql.Tx, table string, values row, conflictKey row) error {
+	columns := sortedColumns(values)
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+		placeholders[i] = l.dialect.placeholder(i + 1)
+		args[i] = values[column]
+	}
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdentifier(table), strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+
+	if conflictKey != nil {
+		var keys, updates []string
+		for _, column := range sortedColumns(conflictKey) {
+			keys = append(keys, quoteIdentifier(column))
+		}
+		for _, column := range columns {
+			if _, isKey := conflictKey[column]; !isKey {
+				updates = append(updates, fmt.Sprintf("%s = excluded.%s", quoteIdentifier(column), quoteIdentifier(column)))
+			}
+		}
+		if len(updates) == 0 {
+			query += fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(keys, ", "))
+		} else {
+			query += fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(keys, ", "), strings.Join(updates, ", "))
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, query, args...)
+	return err
+}
+
+func (l *Loader) update(ctx context.Context, tx *sql.Tx, table string, pk row, fields []Field) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	var sets []string
+	var args []any
+	for _, field := range fields {
+		args = append(args, field.NewValue)
+		sets = append(sets, fmt.Sprintf("%s = %s", quoteIdentifier(field.Name), l.dialect.placeholder(len(args))))
+	}
+	where, args := l.where(pk, args)
+
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("UPDATE %s SET %s WHERE %s", quoteIdentifier(table), strings.Join(sets, ", "), where), args...)
+	return err
+}
+
+func (l *Loader) delete(ctx context.Context, tx *sql.Tx, table string, pk row) error {
+	where, args := l.where(pk, nil)
+	_, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE %s", quoteIdentifier(table), where), args...)
+	return err
+}
+
+func (l *Loader) selectRow(ctx context.Context, tx *sql.Tx, table string, pk row) (row, error) {
+	where, args := l.where(pk, nil)
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT * FROM %s WHERE %s", quoteIdentifier(table), where), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	out := row{}
+	for i, column := range columns {
+		if values[i].Valid {
+			out[column] = &values[i].String
+		} else {
+			out[column] = nil
+		}
+	}
+	return out, nil
+}
+
+// where returns the condition matching the row of primary key `pk`, and
+// `args` followed by its arguments.
+func (l *Loader) where(pk row, args []any) (string, []any) {
+	var conditions []string
+	for _, column := range sortedColumns(pk) {
+		args = append(args, pk[column])
+		conditions = append(conditions, fmt.Sprintf("%s = %s", quoteIdentifier(column), l.dialect.placeholder(len(args))))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// historyEntry is the previous state of a row changed by a reversible
+// block, `prev` being nil if the row did not exist.
+type historyEntry struct {
+	blockNum uint64
+	table    string
+	pk       row
+	prev     row
+}
+
+func (l *Loader) saveHistory(ctx context.Context, tx *sql.Tx, blockNum uint64, table string, pk, prev row) error {
+	pkJSON, err := json.Marshal(pk)
+	if err != nil {
+		return err
+	}
+	var prevJSON *string
+	if prev != nil {
+		cnt, err := json.Marshal(prev)
+		if err != nil {
+			return err
+		}
+		prevJSON = new(string)
+		*prevJSON = string(cnt)
+	}
+
+	_, err = tx.ExecContext(ctx, l.bind(`INSERT INTO substreams_history (cursor_id, block_num, table_name, pk, prev_row) VALUES (?, ?, ?, ?, ?)`),
+		l.cursorID, blockNum, table, string(pkJSON), prevJSON,
+	)
+	if err != nil {
+		return fmt.Errorf("saving history: %w", err)
+	}
+	return nil
+}
+
+// history returns the entries of the blocks above `lastValidBlock`, the
+// most recent first.
+func (l *Loader) history(ctx context.Context, tx *sql.Tx, lastValidBlock uint64) ([]*historyEntry, error) {
+	rows, err := tx.QueryContext(ctx, l.bind(`SELECT block_num, table_name, pk, prev_row FROM substreams_history WHERE cursor_id = ? AND block_num > ? ORDER BY id DESC`), l.cursorID, lastValidBlock)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []*historyEntry
+	for rows.Next() {
+		entry := &historyEntry{}
+		var pkJSON string
+		var prevJSON sql.NullString
+		if err := rows.Scan(&entry.blockNum, &entry.table, &pkJSON, &prevJSON); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(pkJSON), &entry.pk); err != nil {
+			return nil, fmt.Errorf("decoding primary key: %w", err)
+		}
+		if prevJSON.Valid {
+			if err := json.Unmarshal([]byte(prevJSON.String), &entry.prev); err != nil {
+				return nil, fmt.Errorf("decoding previous row: %w", err)
+			}
+		}
+		out = append(out, entry)
+	}
+	return out, rows.Err()
+}
+
+func (l *Loader) revert(ctx context.Context, tx *sql.Tx, entry *historyEntry) error {
+	if err := l.delete(ctx, tx, entry.table, entry.pk); err != nil {
+		return err
+	}
+	if entry.prev == nil {
+		return nil
+	}
+	return l.insert(ctx, tx, entry.table, entry.prev, nil)
+}
+
+// bind replaces the `?` placeholders of `query` by those of the dialect.
+func (l *Loader) bind(query string) string {
+	var n int
+	var b strings.Builder
+	for _, c := range query {
+		if c == '?' {
+			n++
+			b.WriteString(l.dialect.placeholder(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func sortedColumns(values row) []string {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+	return columns
+}
@@ -0,0 +1,245 @@
+package sqlsink
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+const testProto = `
+syntax = "proto3";
+package sf.substreams.sink.database.v1;
+
+message DatabaseChanges {
+  repeated TableChange table_changes = 1;
+}
+
+message TableChange {
+  string table = 1;
+  oneof primary_key {
+    string pk = 2;
+    CompositePrimaryKey composite_pk = 6;
+  }
+  uint64 ordinal = 3;
+  enum Operation {
+    OPERATION_UNSPECIFIED = 0;
+    OPERATION_CREATE = 1;
+    OPERATION_UPDATE = 2;
+    OPERATION_DELETE = 3;
+    OPERATION_UPSERT = 4;
+  }
+  Operation operation = 4;
+  repeated Field fields = 5;
+}
+
+message CompositePrimaryKey {
+  map<string, string> keys = 1;
+}
+
+message Field {
+  string name = 1;
+  string new_value = 2;
+  string old_value = 3;
+}
+`
+
+const testSchema = `
+CREATE TABLE transfers (id TEXT PRIMARY KEY, amount INTEGER, sender TEXT);
+CREATE TABLE balances (owner TEXT, token TEXT, amount INTEGER, PRIMARY KEY (owner, token));
+`
+
+func testDescriptor(t *testing.T) *desc.MessageDescriptor {
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{"database.proto": testProto})}
+	files, err := parser.ParseFiles("database.proto")
+	require.NoError(t, err)
+	return files[0].FindMessage("sf.substreams.sink.database.v1.DatabaseChanges")
+}
+
+type testChange struct {
+	table     string
+	pk        string
+	composite map[string]string
+	ordinal   uint64
+	operation int32
+	fields    map[string]string
+}
+
+func testBlock(t *testing.T, msgDesc *desc.MessageDescriptor, number, finalBlockHeight uint64, changes ...testChange) *pbsubstreamsrpc.BlockScopedData {
+	file := msgDesc.GetFile()
+	msg := dynamic.NewMessage(msgDesc)
+	for _, change := range changes {
+		tableChange := dynamic.NewMessage(file.FindMessage("sf.substreams.sink.database.v1.TableChange"))
+		tableChange.SetFieldByName("table", change.table)
+		if change.composite != nil {
+			pk := dynamic.NewMessage(file.FindMessage("sf.substreams.sink.database.v1.CompositePrimaryKey"))
+			pk.SetFieldByName("keys", change.composite)
+			tableChange.SetFieldByName("composite_pk", pk)
+		} else {
+			tableChange.SetFieldByName("pk", change.pk)
+		}
+		tableChange.SetFieldByName("ordinal", change.ordinal)
+		tableChange.SetFieldByName("operation", change.operation)
+		for name, value := range change.fields {
+			field := dynamic.NewMessage(file.FindMessage("sf.substreams.sink.database.v1.Field"))
+			field.SetFieldByName("name", name)
+			field.SetFieldByName("new_value", value)
+			tableChange.AddRepeatedFieldByName("fields", field)
+		}
+		msg.AddRepeatedFieldByName("table_changes", tableChange)
+	}
+	payload, err := msg.Marshal()
+	require.NoError(t, err)
+
+	return &pbsubstreamsrpc.BlockScopedData{
+		Output: &pbsubstreamsrpc.MapModuleOutput{
+			Name:      "db_out",
+			MapOutput: &anypb.Any{TypeUrl: "type.googleapis.com/" + msgDesc.GetFullyQualifiedName(), Value: payload},
+		},
+		Clock:            &pbsubstreams.Clock{Number: number, Id: blockID(number)},
+		Cursor:           blockID(number),
+		FinalBlockHeight: finalBlockHeight,
+	}
+}
+
+func blockID(number uint64) string {
+	return "block-" + string(rune('a'+number))
+}
+
+func testLoader(t *testing.T) *Loader {
+	loader, err := New(context.Background(), "sqlite://:memory:", "abc", testDescriptor(t), testSchema, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { loader.Close() })
+	return loader
+}
+
+func queryRows(t *testing.T, l *Loader, query string) [][]string {
+	rows, err := l.db.Query(query)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	require.NoError(t, err)
+	var out [][]string
+	for rows.Next() {
+		values := make([]string, len(columns))
+		dest := make([]any, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		require.NoError(t, rows.Scan(dest...))
+		out = append(out, values)
+	}
+	require.NoError(t, rows.Err())
+	return out
+}
+
+const (
+	create = 1
+	update = 2
+	del    = 3
+	upsert = 4
+)
+
+func TestLoader_HandleBlockScopedData(t *testing.T) {
+	ctx := context.Background()
+	msgDesc := testDescriptor(t)
+	loader := testLoader(t)
+
+	cursor, err := loader.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "", cursor)
+
+	require.NoError(t, loader.HandleBlockScopedData(ctx, testBlock(t, msgDesc, 1, 1,
+		testChange{table: "transfers", pk: "t1", ordinal: 1, operation: create, fields: map[string]string{"amount": "10", "sender": "alice"}},
+		testChange{table: "transfers", pk: "t2", ordinal: 2, operation: create, fields: map[string]string{"amount": "20", "sender": "bob"}},
+		testChange{table: "balances", composite: map[string]string{"owner": "alice", "token": "eth"}, ordinal: 3, operation: upsert, fields: map[string]string{"amount": "5"}},
+	)))
+	require.NoError(t, loader.HandleBlockScopedData(ctx, testBlock(t, msgDesc, 2, 2,
+		// applied in ordinal order, the update after the creation
+		testChange{table: "transfers", pk: "t3", ordinal: 2, operation: update, fields: map[string]string{"amount": "31"}},
+		testChange{table: "transfers", pk: "t3", ordinal: 1, operation: create, fields: map[string]string{"amount": "30", "sender": "carol"}},
+		testChange{table: "transfers", pk: "t1", ordinal: 3, operation: del},
+		testChange{table: "balances", composite: map[string]string{"owner": "alice", "token": "eth"}, ordinal: 4, operation: upsert, fields: map[string]string{"amount": "7"}},
+	)))
+
+	assert.Equal(t, [][]string{{"t2", "20", "bob"}, {"t3", "31", "carol"}}, queryRows(t, loader, "SELECT id, amount, sender FROM transfers ORDER BY id"))
+	assert.Equal(t, [][]string{{"alice", "eth", "7"}}, queryRows(t, loader, "SELECT owner, token, amount FROM balances"))
+	assert.Empty(t, queryRows(t, loader, "SELECT id FROM substreams_history"))
+
+	cursor, err = loader.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, blockID(2), cursor)
+}
+
+func TestLoader_HandleBlockUndoSignal(t *testing.T) {
+	ctx := context.Background()
+	msgDesc := testDescriptor(t)
+	loader := testLoader(t)
+
+	require.NoError(t, loader.HandleBlockScopedData(ctx, testBlock(t, msgDesc, 1, 1,
+		testChange{table: "transfers", pk: "t1", operation: create, fields: map[string]string{"amount": "10", "sender": "alice"}},
+		testChange{table: "transfers", pk: "t2", operation: create, fields: map[string]string{"amount": "20"}},
+	)))
+	// reversible blocks
+	require.NoError(t, loader.HandleBlockScopedData(ctx, testBlock(t, msgDesc, 2, 1,
+		testChange{table: "transfers", pk: "t1", ordinal: 1, operation: update, fields: map[string]string{"amount": "11"}},
+		testChange{table: "transfers", pk: "t3", ordinal: 2, operation: create, fields: map[string]string{"amount": "30"}},
+	)))
+	require.NoError(t, loader.HandleBlockScopedData(ctx, testBlock(t, msgDesc, 3, 1,
+		testChange{table: "transfers", pk: "t1", ordinal: 1, operation: update, fields: map[string]string{"amount": "12"}},
+		testChange{table: "transfers", pk: "t2", ordinal: 2, operation: del},
+	)))
+	assert.Equal(t, [][]string{{"t1", "12"}, {"t3", "30"}}, queryRows(t, loader, "SELECT id, amount FROM transfers ORDER BY id"))
+	assert.Len(t, queryRows(t, loader, "SELECT id FROM substreams_history"), 4)
+
+	require.NoError(t, loader.HandleBlockUndoSignal(ctx, &pbsubstreamsrpc.BlockUndoSignal{
+		LastValidBlock:  &pbsubstreams.BlockRef{Number: 1, Id: blockID(1)},
+		LastValidCursor: blockID(1),
+	}))
+
+	assert.Equal(t, [][]string{{"t1", "10", "alice"}, {"t2", "20", ""}}, queryRows(t, loader, "SELECT id, amount, coalesce(sender, '') FROM transfers ORDER BY id"))
+	assert.Empty(t, queryRows(t, loader, "SELECT id FROM substreams_history"))
+	cursor, err := loader.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, blockID(1), cursor)
+}
+
+func TestLoader_Setup(t *testing.T) {
+	ctx := context.Background()
+	path := "sqlite://" + t.TempDir() + "/data.db"
+
+	loader, err := New(ctx, path, "abc", testDescriptor(t), testSchema, zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, loader.HandleBlockScopedData(ctx, testBlock(t, testDescriptor(t), 1, 1,
+		testChange{table: "transfers", pk: "t1", operation: create, fields: map[string]string{"amount": "10"}},
+	)))
+	require.NoError(t, loader.Close())
+
+	// the schema is not executed again, its tables existing
+	loader, err = New(ctx, path, "abc", testDescriptor(t), testSchema, zap.NewNop())
+	require.NoError(t, err)
+	defer loader.Close()
+	cursor, err := loader.Cursor(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, blockID(1), cursor)
+}
+
+func TestLoader_SinglePrimaryKeyRequired(t *testing.T) {
+	ctx := context.Background()
+	loader := testLoader(t)
+
+	err := loader.HandleBlockScopedData(ctx, testBlock(t, testDescriptor(t), 1, 1,
+		testChange{table: "balances", pk: "alice", operation: create},
+	))
+	assert.ErrorContains(t, err, "table has 2 primary key columns")
+}
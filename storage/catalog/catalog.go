@@ -0,0 +1,413 @@
+// Package catalog maintains, for each module hash of the cache, the list of
+// the files written under its `outputs` and `states` directories, sparing
+// readers the listing of these directories, slow on object stores holding
+// many files.
+//
+// The catalog of a module hash lives in its `catalog` directory: a base
+// object, holding the files known when it was last written, and a log of
+// small objects, one per change recorded since. Writers only ever add log
+// objects, so that concurrent writers never overwrite each other's changes.
+// Readers apply the log on top of the base, and fold it into the base once
+// it grows large.
+//
+// A catalog is only a hint. A file written whose entry is lost, if two
+// readers fold the same log concurrently, or not recorded, if the object
+// store failed, is only unknown to readers, whose work is then done again.
+// A removal lost or not recorded is worse: the file deleted still looks
+// present, and readers fail on it until the catalog is rebuilt. Files must
+// therefore be deleted before their removal is recorded, never along with
+// the catalog, which a concurrent Ensure would rebuild from a listing still
+// holding them.
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abourget/llerrgroup"
+	"github.com/streamingfast/derr"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+)
+
+// DirName is the name of the directory holding the catalog of a module
+// hash, next to its `outputs` and `states` directories.
+const DirName = "catalog"
+
+const (
+	baseFilename = "catalog.json"
+	logDir       = "log"
+	version      = 1
+
+	// compactThreshold is the number of log objects from which readers fold
+	// the log into the base.
+	compactThreshold = 64
+)
+
+var (
+	ErrNotFound     = errors.New("catalog not found")
+	ErrInconsistent = errors.New("catalog inconsistent")
+)
+
+// Dir is a directory of a module hash whose files are cataloged.
+type Dir string
+
+const (
+	DirOutputs Dir = "outputs"
+	DirStates  Dir = "states"
+)
+
+var dirs = []Dir{DirOutputs, DirStates}
+
+// Entry is a change recorded to a catalog.
+type Entry struct {
+	Dir      Dir    `json:"dir"`
+	Filename string `json:"filename"`
+	Removed  bool   `json:"removed,omitempty"`
+}
+
+type logObject struct {
+	Entries []Entry `json:"entries"`
+}
+
+type baseObject struct {
+	Version int              `json:"version"`
+	Files   map[Dir][]string `json:"files"`
+	Written time.Time        `json:"written"`
+}
+
+// Files are the files known to a catalog, by directory, sorted by name.
+type Files map[Dir][]string
+
+func (f Files) Outputs() []string { return f[DirOutputs] }
+func (f Files) States() []string  { return f[DirStates] }
+
+// Catalog is the catalog of a module hash.
+type Catalog struct {
+	moduleStore dstore.Store
+	logger      *zap.Logger
+
+	now func() time.Time
+}
+
+// New returns the catalog of the module hash whose directory is
+// `moduleHash` in `baseStore`, the store of a cache tag.
+func New(baseStore dstore.Store, moduleHash string, logger *zap.Logger) (*Catalog, error) {
+	moduleStore, err := baseStore.SubStore(moduleHash)
+	if err != nil {
+		return nil, fmt.Errorf("creating sub store: %w", err)
+	}
+	return &Catalog{
+		moduleStore: moduleStore,
+		logger:      logger.With(zap.String("module_hash", moduleHash)),
+		now:         time.Now,
+	}, nil
+}
+
+// Record adds `entries` to the log of the catalog, once the files they
+// refer to are written or removed.
+func (c *Catalog) Record(ctx context.Context, entries ...Entry) error {
+	cnt, err := json.Marshal(&logObject{Entries: entries})
+	if err != nil {
+		return fmt.Errorf("marshalling catalog log: %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("generating catalog log name: %w", err)
+	}
+	filename := path.Join(DirName, logDir, fmt.Sprintf("%020d-%s.json", c.now().UnixNano(), hex.EncodeToString(suffix)))
+
+	return derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		return c.moduleStore.WriteObject(ctx, filename, bytes.NewReader(cnt))
+	})
+}
+
+// Read returns the files known to the catalog. It fails with ErrNotFound if
+// the catalog was never built, and ErrInconsistent if it cannot be read.
+func (c *Catalog) Read(ctx context.Context) (Files, error) {
+	base, err := c.readBase(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	logs, err := c.listLogs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make(map[Dir]map[string]bool, len(dirs))
+	for _, dir := range dirs {
+		sets[dir] = make(map[string]bool, len(base.Files[dir]))
+		for _, filename := range base.Files[dir] {
+			sets[dir][filename] = true
+		}
+	}
+	for _, logFile := range logs {
+		entries, err := c.readLog(ctx, logFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			set, found := sets[entry.Dir]
+			if !found {
+				return nil, fmt.Errorf("%w: unknown directory %q in %s", ErrInconsistent, entry.Dir, logFile)
+			}
+			if entry.Removed {
+				delete(set, entry.Filename)
+			} else {
+				set[entry.Filename] = true
+			}
+		}
+	}
+
+	files := make(Files, len(dirs))
+	for dir, set := range sets {
+		files[dir] = sortedKeys(set)
+	}
+
+	if len(logs) >= compactThreshold {
+		if err := c.compact(ctx, files, logs); err != nil {
+			c.logger.Warn("cannot compact catalog", zap.Error(err))
+		}
+	}
+	return files, nil
+}
+
+// Rebuild lists the files of the module hash and writes them as the base of
+// the catalog, dropping its log.
+func (c *Catalog) Rebuild(ctx context.Context) (Files, error) {
+	// Changes logged before listing are reflected by the listing, the ones
+	// logged while listing are kept to be applied on top of the new base.
+	logs, err := c.listLogs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	files, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.compact(ctx, files, logs); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// Ensure rebuilds the catalog if it was never built or is inconsistent.
+func (c *Catalog) Ensure(ctx context.Context) error {
+	_, err := c.Read(ctx)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrInconsistent) {
+		return err
+	}
+
+	c.logger.Info("rebuilding catalog", zap.NamedError("reason", err))
+	if _, err := c.Rebuild(ctx); err != nil {
+		return fmt.Errorf("rebuilding catalog: %w", err)
+	}
+	return nil
+}
+
+// List lists the files of the module hash, without using the catalog.
+func (c *Catalog) List(ctx context.Context) (files Files, err error) {
+	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		// We must reset accumulated files between each retry
+		files = make(Files, len(dirs))
+
+		for _, dir := range dirs {
+			prefix := string(dir) + "/"
+			err := c.moduleStore.Walk(ctx, prefix, func(filename string) error {
+				files[dir] = append(files[dir], strings.TrimPrefix(filename, prefix))
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("walking %s: %w", dir, err)
+			}
+			sort.Strings(files[dir])
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Diff are the differences between the files known to a catalog and the
+// files present.
+type Diff struct {
+	// Unknown files are present but unknown to the catalog.
+	Unknown []string
+	// Stale files are known to the catalog but not present.
+	Stale []string
+}
+
+func (d *Diff) Empty() bool {
+	return len(d.Unknown) == 0 && len(d.Stale) == 0
+}
+
+// Check compares the files known to the catalog with the files present.
+// Files written or removed while checking may be reported.
+func (c *Catalog) Check(ctx context.Context) (*Diff, error) {
+	known, err := c.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	present, err := c.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &Diff{}
+	for _, dir := range dirs {
+		knownSet := toSet(known[dir])
+		presentSet := toSet(present[dir])
+		for _, filename := range present[dir] {
+			if !knownSet[filename] {
+				diff.Unknown = append(diff.Unknown, path.Join(string(dir), filename))
+			}
+		}
+		for _, filename := range known[dir] {
+			if !presentSet[filename] {
+				diff.Stale = append(diff.Stale, path.Join(string(dir), filename))
+			}
+		}
+	}
+	return diff, nil
+}
+
+// compact writes `files` as the base of the catalog, then deletes the log
+// objects `logs` they include.
+func (c *Catalog) compact(ctx context.Context, files Files, logs []string) error {
+	cnt, err := json.Marshal(&baseObject{Version: version, Files: files, Written: c.now().UTC()})
+	if err != nil {
+		return fmt.Errorf("marshalling catalog: %w", err)
+	}
+	filename := path.Join(DirName, baseFilename)
+	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		return c.moduleStore.WriteObject(ctx, filename, bytes.NewReader(cnt))
+	})
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", filename, err)
+	}
+
+	eg := llerrgroup.New(10)
+	for _, logFile := range logs {
+		if eg.Stop() {
+			break
+		}
+		logFile := logFile
+		eg.Go(func() error {
+			err := c.moduleStore.DeleteObject(ctx, logFile)
+			if err != nil && err != dstore.ErrNotFound {
+				return fmt.Errorf("deleting %s: %w", logFile, err)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
+
+func (c *Catalog) readBase(ctx context.Context) (*baseObject, error) {
+	filename := path.Join(DirName, baseFilename)
+	cnt, err := c.readObject(ctx, filename)
+	if err == dstore.ErrNotFound {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	base := &baseObject{}
+	if err := json.Unmarshal(cnt, base); err != nil {
+		return nil, fmt.Errorf("%w: unmarshalling %s: %s", ErrInconsistent, filename, err)
+	}
+	if base.Version != version {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInconsistent, base.Version)
+	}
+	return base, nil
+}
+
+func (c *Catalog) readLog(ctx context.Context, filename string) ([]Entry, error) {
+	cnt, err := c.readObject(ctx, filename)
+	if err == dstore.ErrNotFound {
+		// folded into the base by a concurrent reader, which we read before
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log := &logObject{}
+	if err := json.Unmarshal(cnt, log); err != nil {
+		return nil, fmt.Errorf("%w: unmarshalling %s: %s", ErrInconsistent, filename, err)
+	}
+	return log.Entries, nil
+}
+
+// listLogs returns the log objects of the catalog, oldest first.
+func (c *Catalog) listLogs(ctx context.Context) (logs []string, err error) {
+	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		// We must reset accumulated files between each retry
+		logs = nil
+
+		return c.moduleStore.Walk(ctx, path.Join(DirName, logDir)+"/", func(filename string) error {
+			logs = append(logs, filename)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing catalog log: %w", err)
+	}
+	sort.Strings(logs)
+	return logs, nil
+}
+
+func (c *Catalog) readObject(ctx context.Context, filename string) (cnt []byte, err error) {
+	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		reader, err := c.moduleStore.OpenObject(ctx, filename)
+		if err == dstore.ErrNotFound {
+			return derr.NewFatalError(err)
+		}
+		if err != nil {
+			return fmt.Errorf("opening %s: %w", filename, err)
+		}
+		defer reader.Close()
+
+		cnt, err = io.ReadAll(reader)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", filename, err)
+		}
+		return nil
+	})
+	if errors.Is(err, dstore.ErrNotFound) {
+		return nil, dstore.ErrNotFound
+	}
+	return cnt, err
+}
+
+func toSet(filenames []string) map[string]bool {
+	out := make(map[string]bool, len(filenames))
+	for _, filename := range filenames {
+		out[filename] = true
+	}
+	return out
+}
+
+func sortedKeys(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for filename := range set {
+		out = append(out, filename)
+	}
+	sort.Strings(out)
+	return out
+}
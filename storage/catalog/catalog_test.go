@@ -0,0 +1,124 @@
+package catalog
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const testHash = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+func newTestCatalog(t *testing.T) (*Catalog, dstore.Store) {
+	store, err := dstore.NewStore("file://"+t.TempDir(), "zst", "zstd", true)
+	require.NoError(t, err)
+
+	cat, err := New(store, testHash, zap.NewNop())
+	require.NoError(t, err)
+	return cat, store
+}
+
+func writeFiles(t *testing.T, store dstore.Store, filenames ...string) {
+	for _, filename := range filenames {
+		require.NoError(t, store.WriteObject(context.Background(), testHash+"/"+filename, bytes.NewReader([]byte("content"))))
+	}
+}
+
+func TestCatalog_ReadRecord(t *testing.T) {
+	ctx := context.Background()
+	cat, store := newTestCatalog(t)
+
+	_, err := cat.Read(ctx)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	writeFiles(t, store, "outputs/0000000000-0000001000.output", "states/0000001000-0000000000.kv")
+	require.NoError(t, cat.Ensure(ctx))
+
+	files, err := cat.Read(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0000000000-0000001000.output"}, files.Outputs())
+	assert.Equal(t, []string{"0000001000-0000000000.kv"}, files.States())
+
+	require.NoError(t, cat.Record(ctx, Entry{Dir: DirStates, Filename: "0000002000-0000001000.partial"}))
+	require.NoError(t, cat.Record(ctx, Entry{Dir: DirStates, Filename: "0000002000-0000000000.kv"}))
+	require.NoError(t, cat.Record(ctx, Entry{Dir: DirStates, Filename: "0000002000-0000001000.partial", Removed: true}))
+	require.NoError(t, cat.Record(ctx, Entry{Dir: DirOutputs, Filename: "0000001000-0000002000.output"}))
+
+	files, err = cat.Read(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0000000000-0000001000.output", "0000001000-0000002000.output"}, files.Outputs())
+	assert.Equal(t, []string{"0000001000-0000000000.kv", "0000002000-0000000000.kv"}, files.States())
+}
+
+func TestCatalog_Compact(t *testing.T) {
+	ctx := context.Background()
+	cat, _ := newTestCatalog(t)
+	require.NoError(t, cat.Ensure(ctx))
+
+	var expected []string
+	for i := 0; i < compactThreshold; i++ {
+		filename := fmt.Sprintf("%010d-%010d.output", i*10, (i+1)*10)
+		expected = append(expected, filename)
+		require.NoError(t, cat.Record(ctx, Entry{Dir: DirOutputs, Filename: filename}))
+	}
+
+	files, err := cat.Read(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, files.Outputs())
+
+	logs, err := cat.listLogs(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, logs)
+
+	files, err = cat.Read(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, expected, files.Outputs())
+}
+
+func TestCatalog_EnsureInconsistent(t *testing.T) {
+	ctx := context.Background()
+	cat, store := newTestCatalog(t)
+
+	writeFiles(t, store, "states/0000001000-0000000000.kv")
+	require.NoError(t, store.WriteObject(ctx, testHash+"/catalog/catalog.json", strings.NewReader("{not json")))
+
+	_, err := cat.Read(ctx)
+	require.ErrorIs(t, err, ErrInconsistent)
+
+	require.NoError(t, cat.Ensure(ctx))
+	files, err := cat.Read(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0000001000-0000000000.kv"}, files.States())
+}
+
+func TestCatalog_Check(t *testing.T) {
+	ctx := context.Background()
+	cat, store := newTestCatalog(t)
+
+	writeFiles(t, store, "states/0000001000-0000000000.kv")
+	require.NoError(t, cat.Ensure(ctx))
+
+	diff, err := cat.Check(ctx)
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+
+	writeFiles(t, store, "states/0000002000-0000000000.kv")
+	require.NoError(t, cat.Record(ctx, Entry{Dir: DirOutputs, Filename: "0000000000-0000001000.output"}))
+
+	diff, err = cat.Check(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"states/0000002000-0000000000.kv"}, diff.Unknown)
+	assert.Equal(t, []string{"outputs/0000000000-0000001000.output"}, diff.Stale)
+
+	_, err = cat.Rebuild(ctx)
+	require.NoError(t, err)
+	diff, err = cat.Check(ctx)
+	require.NoError(t, err)
+	assert.True(t, diff.Empty())
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/streamingfast/substreams/block"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/catalog"
 	pboutput "github.com/streamingfast/substreams/storage/execout/pb"
 )
 
@@ -19,6 +20,17 @@ type Config struct {
 	moduleHash string
 	objStore   dstore.Store
 
+	// checkpointStore holds the checkpoints of the output files being
+	// written by tier2 jobs, out of the way of the output files.
+	checkpointStore dstore.Store
+
+	// catalog records the output files written, it is nil for modules not
+	// identified by a module hash.
+	catalog *catalog.Catalog
+
+	// format is the format of the output files written.
+	format FileFormat
+
 	modKind            pbsubstreams.ModuleKind
 	moduleInitialBlock uint64
 
@@ -31,13 +43,29 @@ func NewConfig(name string, moduleInitialBlock uint64, modKind pbsubstreams.Modu
 		return nil, fmt.Errorf("creating sub store: %w", err)
 	}
 
+	checkpointStore, err := baseStore.SubStore(fmt.Sprintf("%s/checkpoints", moduleHash))
+	if err != nil {
+		return nil, fmt.Errorf("creating checkpoint sub store: %w", err)
+	}
+
+	logger = logger.With(zap.String("module", name))
+
+	var cat *catalog.Catalog
+	if moduleHash != "" {
+		if cat, err = catalog.New(baseStore, moduleHash, logger); err != nil {
+			return nil, fmt.Errorf("creating catalog: %w", err)
+		}
+	}
+
 	return &Config{
 		name:               name,
 		objStore:           subStore,
+		checkpointStore:    checkpointStore,
+		catalog:            cat,
 		modKind:            modKind,
 		moduleInitialBlock: moduleInitialBlock,
 		moduleHash:         moduleHash,
-		logger:             logger.With(zap.String("module", name)),
+		logger:             logger,
 	}, nil
 }
 
@@ -46,16 +74,69 @@ func (c *Config) NewFile(targetRange *block.Range) *File {
 		kv:         make(map[string]*pboutput.Item),
 		ModuleName: c.name,
 		store:      c.objStore,
+		catalog:    c.catalog,
+		format:     c.format,
 		Range:      targetRange,
 		logger:     c.logger,
+
+		checkpointStore: c.checkpointStore,
 	}
 }
 
+// DeleteCheckpoint deletes the checkpoint `filename`, once superseded. The
+// checkpoints not deleted are left to the garbage collection.
+func (c *Config) DeleteCheckpoint(ctx context.Context, filename string) {
+	if err := c.checkpointStore.DeleteObject(ctx, filename); err != nil && err != dstore.ErrNotFound {
+		c.logger.Warn("cannot delete exec output checkpoint", zap.String("filename", filename), zap.Error(err))
+	}
+}
+
+// SetFileFormat sets the format of the output files written. Files in any
+// format can be read, whatever the format set.
+func (c *Config) SetFileFormat(format FileFormat) {
+	c.format = format
+}
+
 func (c *Config) Name() string                        { return c.name }
+func (c *Config) ModuleHash() string                  { return c.moduleHash }
 func (c *Config) ModuleKind() pbsubstreams.ModuleKind { return c.modKind }
 func (c *Config) ModuleInitialBlock() uint64          { return c.moduleInitialBlock }
 
+// Catalog returns the catalog of the module's hash, nil if the module is not
+// identified by a module hash.
+func (c *Config) Catalog() *catalog.Catalog { return c.catalog }
+
+// catalogFiles returns the output files known to the module's catalog, in
+// block order, or false if the catalog is unusable.
+func (c *Config) catalogFiles(ctx context.Context) (FileInfos, bool) {
+	if c.catalog == nil {
+		return nil, false
+	}
+	cataloged, err := c.catalog.Read(ctx)
+	if err != nil {
+		c.logger.Debug("catalog unusable, listing output files", zap.Error(err))
+		return nil, false
+	}
+
+	var files FileInfos
+	for _, filename := range cataloged.Outputs() {
+		if fileInfo, err := parseFileName(filename); err == nil {
+			files = append(files, fileInfo)
+		}
+	}
+	return files, true
+}
+
 func (c *Config) ListSnapshotFiles(ctx context.Context, inRange *bstream.Range) (files FileInfos, err error) {
+	if cataloged, ok := c.catalogFiles(ctx); ok {
+		for _, fileInfo := range cataloged {
+			if fileInfo.BlockRange.StartBlock >= inRange.StartBlock() && !inRange.ReachedEndBlock(fileInfo.BlockRange.ExclusiveEndBlock-1) {
+				files = append(files, fileInfo)
+			}
+		}
+		return files, nil
+	}
+
 	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
 		// We must reset accumulated files between each retry
 		files = nil
@@ -80,3 +161,40 @@ func (c *Config) ListSnapshotFiles(ctx context.Context, inRange *bstream.Range)
 
 	return files, nil
 }
+
+// ListFilesUpTo lists, in block order, the files starting at or after
+// `startBlock`, up to the one holding the outputs of block `lastBlock`.
+func (c *Config) ListFilesUpTo(ctx context.Context, startBlock, lastBlock uint64) (files FileInfos, err error) {
+	if cataloged, ok := c.catalogFiles(ctx); ok {
+		for _, fileInfo := range cataloged {
+			if fileInfo.BlockRange.StartBlock >= startBlock && fileInfo.BlockRange.StartBlock <= lastBlock {
+				files = append(files, fileInfo)
+			}
+		}
+		return files, nil
+	}
+
+	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		// We must reset accumulated files between each retry
+		files = nil
+
+		return c.objStore.WalkFrom(ctx, "", computeDBinFilename(startBlock, 0), func(filename string) (err error) {
+			fileInfo, err := parseFileName(filename)
+			if err != nil {
+				c.logger.Warn("seen exec output file that we don't know how to parse", zap.String("filename", filename), zap.Error(err))
+				return nil
+			}
+			if fileInfo.BlockRange.StartBlock > lastBlock {
+				return dstore.StopIteration
+			}
+
+			files = append(files, fileInfo)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking files: %s", err)
+	}
+
+	return files, nil
+}
@@ -50,3 +50,11 @@ func (c *Configs) NewFile(moduleName string, targetRange *block.Range) *File {
 func (c *Configs) NewFileWalker(moduleName string, segmenter *block.Segmenter) *FileWalker {
 	return c.ConfigMap[moduleName].NewFileWalker(segmenter)
 }
+
+// SetFileFormat sets the format of the output files written by all the
+// modules, see Config.SetFileFormat.
+func (c *Configs) SetFileFormat(format FileFormat) {
+	for _, conf := range c.ConfigMap {
+		conf.SetFileFormat(format)
+	}
+}
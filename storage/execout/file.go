@@ -3,8 +3,8 @@ package execout
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"math"
 	"sort"
 	"strconv"
@@ -21,6 +21,7 @@ import (
 
 	"github.com/streamingfast/substreams/block"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/catalog"
 )
 
 // A File in `execout` stores, for a given module (with a given hash), the outputs of module execution
@@ -32,7 +33,11 @@ type File struct {
 	ModuleName string
 	kv         map[string]*pboutput.Item
 	store      dstore.Store
+	catalog    *catalog.Catalog
+	format     FileFormat
 	logger     *zap.Logger
+
+	checkpointStore dstore.Store
 }
 
 func (c *File) Filename() string {
@@ -100,51 +105,130 @@ func (c *File) Load(ctx context.Context) error {
 	filename := computeDBinFilename(c.Range.StartBlock, c.Range.ExclusiveEndBlock)
 	c.logger.Debug("loading execout file", zap.String("file_name", filename), zap.Object("block_range", c.Range))
 
-	return derr.RetryContext(ctx, 5, func(ctx context.Context) error {
-		objectReader, err := c.store.OpenObject(ctx, filename)
-		if err == dstore.ErrNotFound {
-			return derr.NewFatalError(err)
-		}
+	return c.load(ctx, c.store, filename)
+}
 
+func (c *File) load(ctx context.Context, store dstore.Store, filename string) error {
+	return derr.RetryContext(ctx, 5, func(ctx context.Context) error {
+		reader, err := c.openItems(ctx, store, filename, 0)
 		if err != nil {
-			return fmt.Errorf("loading block reader %s: %w", filename, err)
+			return err
 		}
-		defer objectReader.Close()
+		defer reader.Close()
 
-		bytes, err := io.ReadAll(objectReader)
+		items, err := reader.readAll()
 		if err != nil {
-			return fmt.Errorf("reading store file %s: %w", filename, err)
+			return fmt.Errorf("reading file %s: %w", filename, err)
 		}
 
-		outputData := &pboutput.Map{}
-		if err = outputData.UnmarshalFast(bytes); err != nil {
-			return fmt.Errorf("unmarshalling file %s: %w", filename, err)
+		c.kv = make(map[string]*pboutput.Item, len(items))
+		for _, item := range items {
+			c.kv[item.BlockId] = item
 		}
 
-		c.kv = outputData.Kv
-
 		c.logger.Debug("outputs data loaded", zap.Int("output_count", len(c.kv)), zap.Stringer("block_range", c.Range))
 		return nil
 	})
 }
 
-func (c *File) Save(ctx context.Context) error {
+// OpenItems opens the file to read its items one at a time, in block order,
+// starting at the first item at or after `startBlock`. Files in the framed
+// format are streamed, the items before `startBlock` being skipped without
+// decoding them, while files in the proto format are read whole. It fails
+// with dstore.ErrNotFound if the file does not exist.
+func (c *File) OpenItems(ctx context.Context, startBlock uint64) (reader *ItemReader, err error) {
+	filename := c.Filename()
+	c.logger.Debug("opening execout file", zap.String("file_name", filename), zap.Uint64("start_block", startBlock))
+
+	err = derr.RetryContext(ctx, 5, func(ctx context.Context) (err error) {
+		reader, err = c.openItems(ctx, c.store, filename, startBlock)
+		return err
+	})
+	if errors.Is(err, dstore.ErrNotFound) {
+		return nil, dstore.ErrNotFound
+	}
+	return reader, err
+}
+
+func (c *File) openItems(ctx context.Context, store dstore.Store, filename string, startBlock uint64) (*ItemReader, error) {
+	objectReader, err := store.OpenObject(ctx, filename)
+	if err == dstore.ErrNotFound {
+		return nil, derr.NewFatalError(err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading block reader %s: %w", filename, err)
+	}
 
+	reader, err := NewItemReader(objectReader, startBlock)
+	if err != nil {
+		objectReader.Close()
+		return nil, fmt.Errorf("reading file %s: %w", filename, err)
+	}
+	return reader, nil
+}
+
+func (c *File) Save(ctx context.Context) error {
 	filename := c.Filename()
-	outputData := &pboutput.Map{Kv: c.kv}
-	cnt, err := outputData.MarshalFast()
+	c.logger.Info("writing execution output file", zap.String("filename", filename), zap.Stringer("format", c.format))
+	if err := c.write(ctx, c.store, filename); err != nil {
+		return err
+	}
+
+	if c.catalog != nil {
+		if err := c.catalog.Record(ctx, catalog.Entry{Dir: catalog.DirOutputs, Filename: filename}); err != nil {
+			c.logger.Warn("cannot record execution output file to catalog", zap.String("filename", filename), zap.Error(err))
+		}
+	}
+	return nil
+}
+
+// SaveCheckpoint saves the outputs of the file, of the blocks before
+// `endBlock`, as a checkpoint of the tier2 job of `traceID` to resume from.
+// It returns the name of the checkpoint.
+func (c *File) SaveCheckpoint(ctx context.Context, endBlock uint64, traceID string) (string, error) {
+	filename := checkpointFilename(c.StartBlock, endBlock, traceID)
+	c.logger.Debug("writing execution output checkpoint", zap.String("filename", filename))
+	if err := c.write(ctx, c.checkpointStore, filename); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// LoadCheckpoint replaces the outputs of the file with the ones of the
+// checkpoint saved at `endBlock` by the tier2 job of `traceID`. It returns
+// the name of the checkpoint.
+func (c *File) LoadCheckpoint(ctx context.Context, endBlock uint64, traceID string) (string, error) {
+	filename := checkpointFilename(c.StartBlock, endBlock, traceID)
+	if err := c.load(ctx, c.checkpointStore, filename); err != nil {
+		return "", fmt.Errorf("loading checkpoint %s: %w", filename, err)
+	}
+	return filename, nil
+}
+
+func (c *File) write(ctx context.Context, store dstore.Store, filename string) error {
+	cnt, err := c.marshal()
 	if err != nil {
-		return fmt.Errorf("unmarshalling file %s: %w", filename, err)
+		return fmt.Errorf("marshalling file %s: %w", filename, err)
 	}
 
-	c.logger.Info("writing execution output file", zap.String("filename", filename))
 	return derr.RetryContext(ctx, 5, func(ctx context.Context) error {
-		reader := bytes.NewReader(cnt)
-		err := c.store.WriteObject(ctx, filename, reader)
-		return err
+		return store.WriteObject(ctx, filename, bytes.NewReader(cnt))
 	})
 }
 
+func (c *File) marshal() ([]byte, error) {
+	if c.format == FileFormatFramed {
+		items := make([]*pboutput.Item, 0, len(c.kv))
+		for _, item := range c.kv {
+			items = append(items, item)
+		}
+		return marshalFramed(items)
+	}
+
+	outputData := &pboutput.Map{Kv: c.kv}
+	return outputData.MarshalFast()
+}
+
 func (c *File) String() string {
 	return c.store.ObjectURL("")
 }
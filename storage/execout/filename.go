@@ -44,3 +44,10 @@ func fileNameToRange(filename string) (*block.Range, error) {
 		ExclusiveEndBlock: end,
 	}, nil
 }
+
+// checkpointFilename is the name of the checkpoint of the output file
+// starting at `startBlock`, holding its outputs before `endBlock`, written by
+// the tier2 job of `traceID`.
+func checkpointFilename(startBlock, endBlock uint64, traceID string) string {
+	return fmt.Sprintf("%010d-%010d.%s.output", startBlock, endBlock, traceID)
+}
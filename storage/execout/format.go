@@ -0,0 +1,307 @@
+package execout
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+
+	"github.com/klauspost/compress/zstd"
+
+	pboutput "github.com/streamingfast/substreams/storage/execout/pb"
+)
+
+// FileFormat is the format of the output files written by a module.
+type FileFormat int
+
+const (
+	// FileFormatProto writes the `Map` protobuf message, holding the items
+	// of the file by block ID, which must be read whole.
+	FileFormatProto FileFormat = iota
+	// FileFormatFramed writes the items in block order, one compressed frame
+	// per block, with an index, see ItemReader.
+	FileFormatFramed
+)
+
+func (f FileFormat) String() string {
+	switch f {
+	case FileFormatProto:
+		return "proto"
+	case FileFormatFramed:
+		return "framed"
+	}
+	return fmt.Sprintf("FileFormat(%d)", int(f))
+}
+
+// ParseFileFormat returns the FileFormat named `name`, as returned by
+// FileFormat.String.
+func ParseFileFormat(name string) (FileFormat, error) {
+	switch name {
+	case "proto":
+		return FileFormatProto, nil
+	case "framed":
+		return FileFormatFramed, nil
+	}
+	return 0, fmt.Errorf("unknown output cache format %q, expected %q or %q", name, FileFormatProto, FileFormatFramed)
+}
+
+// The framed format stores the items of an output file in block order, one
+// frame per block, so that a file can be read one item at a time and the
+// items before a given block skipped without decoding them:
+//
+//	header  magic "SFOC" | version (1 byte)
+//	index   index length | index payload | crc32 of the index payload (u32)
+//	frames  for each item: crc32 of the frame payload (u32) | frame payload
+//
+// The index payload holds the item count then, for each item, the
+// difference between its block number and the previous item's, and the
+// length of its frame payload, the zstd-compressed `Item` message. The index
+// comes first, all the items of a segment being known when its file is
+// written, so that the frames to skip are known when reading the file
+// sequentially. Integers are little-endian, lengths and counts uvarints.
+
+const (
+	FramedMagic   = "SFOC"
+	FramedVersion = 1
+
+	framedHeaderSize = len(FramedMagic) + 1
+
+	// maxFramedIndexLength bounds the index read, a corrupted length
+	// otherwise allocating an arbitrary amount of memory.
+	maxFramedIndexLength = 64 * 1024 * 1024
+)
+
+var (
+	ErrFramedChecksum = errors.New("output file checksum mismatch")
+
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// IsFramed returns whether `header`, the first bytes of an output file, is
+// the header of a file in the framed format. Files in the proto format start
+// with the tag of the `Map.kv` field, or are empty.
+func IsFramed(header []byte) bool {
+	return len(header) >= len(FramedMagic) && string(header[:len(FramedMagic)]) == FramedMagic
+}
+
+type framedEntry struct {
+	blockNum uint64
+	length   uint64
+}
+
+// marshalFramed returns the file holding `items` in the framed format.
+func marshalFramed(items []*pboutput.Item) ([]byte, error) {
+	sorted := make([]*pboutput.Item, len(items))
+	copy(sorted, items)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].BlockNum < sorted[j].BlockNum })
+
+	frames := make([][]byte, len(sorted))
+	index := binary.AppendUvarint(nil, uint64(len(sorted)))
+	var previous uint64
+	for i, item := range sorted {
+		cnt, err := item.MarshalVT()
+		if err != nil {
+			return nil, fmt.Errorf("marshalling item of block %d: %w", item.BlockNum, err)
+		}
+		frames[i] = zstdEncoder.EncodeAll(cnt, nil)
+
+		index = binary.AppendUvarint(index, item.BlockNum-previous)
+		index = binary.AppendUvarint(index, uint64(len(frames[i])))
+		previous = item.BlockNum
+	}
+
+	out := append([]byte(FramedMagic), FramedVersion)
+	out = binary.AppendUvarint(out, uint64(len(index)))
+	out = append(out, index...)
+	out = binary.LittleEndian.AppendUint32(out, crc32.Checksum(index, crcTable))
+	for _, frame := range frames {
+		out = binary.LittleEndian.AppendUint32(out, crc32.Checksum(frame, crcTable))
+		out = append(out, frame...)
+	}
+	return out, nil
+}
+
+// ItemReader reads the items of an output file one at a time, in block
+// order. Files in the framed format are read as items are requested, files
+// in the proto format are read whole when opened.
+type ItemReader struct {
+	closer io.Closer
+
+	r      *bufio.Reader
+	framed bool
+
+	// framed format
+	entries []framedEntry
+
+	// proto format
+	items []*pboutput.Item
+}
+
+// NewItemReader reads the output file read from `rc`, in any format,
+// starting at the first item at or after `startBlock`. Closing the
+// ItemReader closes `rc`.
+func NewItemReader(rc io.ReadCloser, startBlock uint64) (*ItemReader, error) {
+	ir := &ItemReader{closer: rc, r: bufio.NewReader(rc)}
+
+	if header, _ := ir.r.Peek(framedHeaderSize); !IsFramed(header) {
+		if err := ir.loadProto(startBlock); err != nil {
+			return nil, err
+		}
+		return ir, nil
+	}
+
+	ir.framed = true
+	if err := ir.readIndex(); err != nil {
+		return nil, err
+	}
+
+	var skip uint64
+	for len(ir.entries) != 0 && ir.entries[0].blockNum < startBlock {
+		skip += 4 + ir.entries[0].length
+		ir.entries = ir.entries[1:]
+	}
+	if _, err := ir.r.Discard(int(skip)); err != nil {
+		return nil, fmt.Errorf("skipping to block %d: %w", startBlock, noEOF(err))
+	}
+	return ir, nil
+}
+
+func (r *ItemReader) loadProto(startBlock uint64) error {
+	cnt, err := io.ReadAll(r.r)
+	if err != nil {
+		return err
+	}
+
+	outputData := &pboutput.Map{}
+	if err := outputData.UnmarshalFast(cnt); err != nil {
+		return fmt.Errorf("unmarshalling: %w", err)
+	}
+	for _, item := range outputData.Kv {
+		if item != nil && item.BlockNum >= startBlock {
+			r.items = append(r.items, item)
+		}
+	}
+	sort.Slice(r.items, func(i, j int) bool { return r.items[i].BlockNum < r.items[j].BlockNum })
+	return nil
+}
+
+func (r *ItemReader) readIndex() error {
+	header := make([]byte, framedHeaderSize)
+	if _, err := io.ReadFull(r.r, header); err != nil {
+		return fmt.Errorf("reading header: %w", noEOF(err))
+	}
+	if header[len(FramedMagic)] != FramedVersion {
+		return fmt.Errorf("unsupported output file version %d", header[len(FramedMagic)])
+	}
+
+	length, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return fmt.Errorf("reading index length: %w", noEOF(err))
+	}
+	if length > maxFramedIndexLength {
+		return fmt.Errorf("index length %d too large", length)
+	}
+	index := make([]byte, length+4)
+	if _, err := io.ReadFull(r.r, index); err != nil {
+		return fmt.Errorf("reading index: %w", noEOF(err))
+	}
+	if crc32.Checksum(index[:length], crcTable) != binary.LittleEndian.Uint32(index[length:]) {
+		return ErrFramedChecksum
+	}
+	index = index[:length]
+
+	count, n := binary.Uvarint(index)
+	if n <= 0 || count > uint64(len(index)) {
+		return fmt.Errorf("invalid index item count")
+	}
+	index = index[n:]
+
+	r.entries = make([]framedEntry, 0, count)
+	var blockNum uint64
+	for i := uint64(0); i < count; i++ {
+		delta, n := binary.Uvarint(index)
+		if n <= 0 {
+			return fmt.Errorf("invalid index entry %d", i)
+		}
+		index = index[n:]
+		frameLength, n := binary.Uvarint(index)
+		if n <= 0 {
+			return fmt.Errorf("invalid index entry %d", i)
+		}
+		index = index[n:]
+
+		blockNum += delta
+		r.entries = append(r.entries, framedEntry{blockNum: blockNum, length: frameLength})
+	}
+	return nil
+}
+
+// Next returns the next item of the file, or io.EOF once all were read.
+func (r *ItemReader) Next() (*pboutput.Item, error) {
+	if !r.framed {
+		if len(r.items) == 0 {
+			return nil, io.EOF
+		}
+		item := r.items[0]
+		r.items = r.items[1:]
+		return item, nil
+	}
+
+	if len(r.entries) == 0 {
+		return nil, io.EOF
+	}
+	entry := r.entries[0]
+	r.entries = r.entries[1:]
+
+	frame := make([]byte, 4+entry.length)
+	if _, err := io.ReadFull(r.r, frame); err != nil {
+		return nil, fmt.Errorf("reading frame of block %d: %w", entry.blockNum, noEOF(err))
+	}
+	if crc32.Checksum(frame[4:], crcTable) != binary.LittleEndian.Uint32(frame) {
+		return nil, fmt.Errorf("frame of block %d: %w", entry.blockNum, ErrFramedChecksum)
+	}
+	cnt, err := zstdDecoder.DecodeAll(frame[4:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing frame of block %d: %w", entry.blockNum, err)
+	}
+
+	item := &pboutput.Item{}
+	if err := item.UnmarshalVT(cnt); err != nil {
+		return nil, fmt.Errorf("unmarshalling item of block %d: %w", entry.blockNum, err)
+	}
+	if item.BlockNum != entry.blockNum {
+		return nil, fmt.Errorf("frame holds block %d, expected %d", item.BlockNum, entry.blockNum)
+	}
+	return item, nil
+}
+
+// readAll returns the items remaining to be read.
+func (r *ItemReader) readAll() (out []*pboutput.Item, err error) {
+	for {
+		item, err := r.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+}
+
+func (r *ItemReader) Close() error {
+	return r.closer.Close()
+}
+
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,127 @@
+package execout
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	pboutput "github.com/streamingfast/substreams/storage/execout/pb"
+)
+
+func testItems(blockNums ...uint64) (out []*pboutput.Item) {
+	for _, blockNum := range blockNums {
+		out = append(out, &pboutput.Item{
+			BlockNum: blockNum,
+			BlockId:  fmt.Sprintf("%da", blockNum),
+			Payload:  bytes.Repeat([]byte{byte(blockNum)}, 100),
+		})
+	}
+	return out
+}
+
+func readItems(t *testing.T, cnt []byte, startBlock uint64) (blockNums []uint64) {
+	reader, err := NewItemReader(io.NopCloser(bytes.NewReader(cnt)), startBlock)
+	require.NoError(t, err)
+	defer reader.Close()
+
+	items, err := reader.readAll()
+	require.NoError(t, err)
+	for _, item := range items {
+		assert.Equal(t, fmt.Sprintf("%da", item.BlockNum), item.BlockId)
+		assert.Equal(t, bytes.Repeat([]byte{byte(item.BlockNum)}, 100), item.Payload)
+		blockNums = append(blockNums, item.BlockNum)
+	}
+	return blockNums
+}
+
+func TestItemReader(t *testing.T) {
+	items := testItems(15, 12, 10, 17)
+
+	framed, err := marshalFramed(items)
+	require.NoError(t, err)
+	assert.True(t, IsFramed(framed))
+
+	kv := map[string]*pboutput.Item{}
+	for _, item := range items {
+		kv[item.BlockId] = item
+	}
+	proto, err := (&pboutput.Map{Kv: kv}).MarshalFast()
+	require.NoError(t, err)
+	assert.False(t, IsFramed(proto))
+
+	for name, cnt := range map[string][]byte{"framed": framed, "proto": proto} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, []uint64{10, 12, 15, 17}, readItems(t, cnt, 0))
+			assert.Equal(t, []uint64{12, 15, 17}, readItems(t, cnt, 11))
+			assert.Equal(t, []uint64{17}, readItems(t, cnt, 17))
+			assert.Empty(t, readItems(t, cnt, 18))
+		})
+	}
+}
+
+func TestItemReader_Empty(t *testing.T) {
+	framed, err := marshalFramed(nil)
+	require.NoError(t, err)
+	assert.Empty(t, readItems(t, framed, 0))
+	assert.Empty(t, readItems(t, nil, 0))
+}
+
+func TestItemReader_Checksum(t *testing.T) {
+	framed, err := marshalFramed(testItems(10, 11))
+	require.NoError(t, err)
+	framed[len(framed)-1] ^= 0xff
+
+	reader, err := NewItemReader(io.NopCloser(bytes.NewReader(framed)), 0)
+	require.NoError(t, err)
+	_, err = reader.Next()
+	require.NoError(t, err)
+	_, err = reader.Next()
+	require.ErrorIs(t, err, ErrFramedChecksum)
+}
+
+func TestFile_SaveLoad(t *testing.T) {
+	ctx := context.Background()
+
+	for _, format := range []FileFormat{FileFormatProto, FileFormatFramed} {
+		t.Run(format.String(), func(t *testing.T) {
+			store, err := dstore.NewStore("file://"+t.TempDir(), "zst", "zstd", true)
+			require.NoError(t, err)
+			config, err := NewConfig("A", 0, pbsubstreams.ModuleKindMap, "", store, zap.NewNop())
+			require.NoError(t, err)
+			config.SetFileFormat(format)
+
+			file := config.NewFile(block.NewRange(10, 20))
+			for _, blockNum := range []uint64{10, 12, 15} {
+				file.SetItem(&pbsubstreams.Clock{Number: blockNum, Id: fmt.Sprintf("%da", blockNum)}, bytes.Repeat([]byte{byte(blockNum)}, 100))
+			}
+			require.NoError(t, file.Save(ctx))
+
+			loaded := config.NewFile(block.NewRange(10, 20))
+			require.NoError(t, loaded.Load(ctx))
+			payload, found := loaded.GetAtBlock(12)
+			assert.True(t, found)
+			assert.Equal(t, bytes.Repeat([]byte{12}, 100), payload)
+			assert.Len(t, loaded.SortedItems(), 3)
+
+			reader, err := loaded.OpenItems(ctx, 11)
+			require.NoError(t, err)
+			items, err := reader.readAll()
+			require.NoError(t, err)
+			require.NoError(t, reader.Close())
+			require.Len(t, items, 2)
+			assert.Equal(t, uint64(12), items[0].BlockNum)
+
+			_, err = config.NewFile(block.NewRange(20, 30)).OpenItems(ctx, 0)
+			assert.Equal(t, dstore.ErrNotFound, err)
+		})
+	}
+}
@@ -9,37 +9,139 @@ import (
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 )
 
-// The Writer writes a single file with executionOutputs that will be read by the LinearExecOutReader.
+// The Writer writes, for each output module, the files with executionOutputs that will be read by the LinearExecOutReader,
+// one per interval of the range.
 // `initialBlockBoundary` is expected to be on a boundary, or to be the module's initial block.
 type Writer struct {
 	wg *sync.WaitGroup
 
-	currentFile  *File
-	outputModule string
+	walkers       []*FileWalker
+	currentFiles  []*File
+	outputModules []string
+
+	// checkpoints are the names of the last checkpoints of the current
+	// files, by output module, deleted once superseded.
+	checkpoints []string
 }
 
-func NewWriter(initialBlockBoundary, exclusiveEndBlock uint64, outputModule string, configs *Configs) *Writer {
+func NewWriter(initialBlockBoundary, exclusiveEndBlock uint64, outputModules []string, configs *Configs) *Writer {
 	w := &Writer{
-		wg:           &sync.WaitGroup{},
-		outputModule: outputModule,
+		wg:            &sync.WaitGroup{},
+		outputModules: outputModules,
 	}
 
 	segmenter := block.NewSegmenter(configs.execOutputSaveInterval, initialBlockBoundary, exclusiveEndBlock)
-	walker := configs.NewFileWalker(outputModule, segmenter)
-	w.currentFile = walker.File()
+	for _, outputModule := range outputModules {
+		walker := configs.NewFileWalker(outputModule, segmenter)
+		w.walkers = append(w.walkers, walker)
+		w.currentFiles = append(w.currentFiles, walker.File())
+	}
 
 	return w
 }
 
-func (w *Writer) Write(clock *pbsubstreams.Clock, buffer *Buffer) {
-	if val, found := buffer.values[w.outputModule]; found {
-		w.currentFile.SetItem(clock, val)
+func (w *Writer) Write(ctx context.Context, clock *pbsubstreams.Clock, buffer *Buffer) error {
+	for i, outputModule := range w.outputModules {
+		if err := w.rotate(ctx, i, clock.Number); err != nil {
+			return err
+		}
+		if val, found := buffer.values[outputModule]; found {
+			w.currentFiles[i].SetItem(clock, val)
+		}
+	}
+	return nil
+}
+
+// rotate saves the files of the module `i` ending before `blockNum`, up to
+// the one containing it.
+func (w *Writer) rotate(ctx context.Context, i int, blockNum uint64) error {
+	for w.currentFiles[i] != nil && blockNum >= w.currentFiles[i].ExclusiveEndBlock {
+		if err := w.currentFiles[i].Save(ctx); err != nil {
+			return fmt.Errorf("flushing exec output writer: %w", err)
+		}
+		w.walkers[i].Next()
+		w.currentFiles[i] = w.walkers[i].File()
+	}
+	return nil
+}
+
+// Checkpoint saves the files ending at or before `blockNum`, and a
+// checkpoint of the current ones holding their outputs before `blockNum`,
+// for the tier2 job of `traceID` to resume from it. The previous checkpoint
+// is deleted.
+func (w *Writer) Checkpoint(ctx context.Context, blockNum uint64, traceID string) error {
+	checkpoints := make([]string, len(w.outputModules))
+	for i := range w.outputModules {
+		if err := w.rotate(ctx, i, blockNum); err != nil {
+			return err
+		}
+		if w.currentFiles[i] == nil {
+			continue
+		}
+		filename, err := w.currentFiles[i].SaveCheckpoint(ctx, blockNum, traceID)
+		if err != nil {
+			return fmt.Errorf("saving exec output checkpoint: %w", err)
+		}
+		checkpoints[i] = filename
+	}
+
+	w.DeleteCheckpoints(ctx)
+	w.checkpoints = checkpoints
+	return nil
+}
+
+// Resume moves to the files containing `blockNum`, the ones before having
+// been saved, and loads their outputs from the checkpoint saved at
+// `blockNum` by the tier2 job of `traceID`. The writer is left untouched if
+// any of the checkpoints cannot be loaded.
+func (w *Writer) Resume(ctx context.Context, blockNum uint64, traceID string) error {
+	segments := make([]int, len(w.walkers))
+	files := make([]*File, len(w.walkers))
+	checkpoints := make([]string, len(w.walkers))
+	for i, walker := range w.walkers {
+		segments[i] = walker.segmenter.IndexForStartBlock(blockNum)
+		rng := walker.segmenter.Range(segments[i])
+		if rng == nil {
+			return fmt.Errorf("block %d is out of the range written for module %q", blockNum, w.outputModules[i])
+		}
+		files[i] = walker.config.NewFile(rng)
+
+		filename, err := files[i].LoadCheckpoint(ctx, blockNum, traceID)
+		if err != nil {
+			return fmt.Errorf("resuming exec output of module %q: %w", w.outputModules[i], err)
+		}
+		checkpoints[i] = filename
+	}
+
+	for i, walker := range w.walkers {
+		walker.segment = segments[i]
+		w.currentFiles[i] = files[i]
+	}
+	w.checkpoints = checkpoints
+	return nil
+}
+
+// DeleteCheckpoints deletes the last checkpoint saved, or resumed from.
+func (w *Writer) DeleteCheckpoints(ctx context.Context) {
+	for i, filename := range w.checkpoints {
+		if filename != "" {
+			w.walkers[i].config.DeleteCheckpoint(ctx, filename)
+		}
 	}
+	w.checkpoints = nil
 }
 
+// Close saves the current files, and the ones of the remaining intervals
+// of the range, on which there was no block.
 func (w *Writer) Close(ctx context.Context) error {
-	if err := w.currentFile.Save(ctx); err != nil {
-		return fmt.Errorf("flushing exec output writer: %w", err)
+	for i := range w.currentFiles {
+		for w.currentFiles[i] != nil {
+			if err := w.currentFiles[i].Save(ctx); err != nil {
+				return fmt.Errorf("flushing exec output writer: %w", err)
+			}
+			w.walkers[i].Next()
+			w.currentFiles[i] = w.walkers[i].File()
+		}
 	}
 	return nil
 }
@@ -1,10 +1,16 @@
 package execout
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
+	"github.com/streamingfast/dstore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/streamingfast/substreams/block"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 )
 
 var testConfigs = &Configs{
@@ -23,7 +29,95 @@ var testConfigs = &Configs{
 }
 
 func TestNewExecOutputWriterIsSubRequest(t *testing.T) {
-	res := NewWriter(11, 15, "A", testConfigs)
+	res := NewWriter(11, 15, []string{"A"}, testConfigs)
+	require.NotNil(t, res)
+	require.Len(t, res.currentFiles, 1)
+	assert.Equal(t, 15, int(res.currentFiles[0].ExclusiveEndBlock))
+}
+
+func TestNewExecOutputWriterSeveralOutputModules(t *testing.T) {
+	res := NewWriter(20, 30, []string{"A", "C"}, testConfigs)
 	require.NotNil(t, res)
-	assert.Equal(t, 15, int(res.currentFile.ExclusiveEndBlock))
+	require.Len(t, res.currentFiles, 2)
+	for _, file := range res.currentFiles {
+		assert.Equal(t, 20, int(file.StartBlock))
+		assert.Equal(t, 30, int(file.ExclusiveEndBlock))
+	}
+}
+
+func TestExecOutputWriterRotatesOnIntervals(t *testing.T) {
+	objStore := dstore.NewMockStore(nil)
+	conf, err := NewConfig("A", 5, pbsubstreams.ModuleKindMap, "", objStore, zlog)
+	require.NoError(t, err)
+	configs := &Configs{
+		execOutputSaveInterval: 10,
+		ConfigMap:              map[string]*Config{"A": conf},
+	}
+
+	w := NewWriter(10, 40, []string{"A"}, configs)
+	ctx := context.Background()
+	for _, blockNum := range []uint64{12, 15, 35} {
+		buffer := &Buffer{values: map[string][]byte{"A": {0x01}}}
+		require.NoError(t, w.Write(ctx, &pbsubstreams.Clock{Number: blockNum, Id: fmt.Sprintf("%d", blockNum)}, buffer))
+	}
+	require.NoError(t, w.Close(ctx))
+
+	var files []string
+	require.NoError(t, conf.objStore.Walk(ctx, "", func(filename string) error {
+		files = append(files, filename)
+		return nil
+	}))
+	assert.Equal(t, []string{
+		computeDBinFilename(10, 20),
+		computeDBinFilename(20, 30),
+		computeDBinFilename(30, 40),
+	}, files)
+}
+
+func TestExecOutputWriterCheckpointResume(t *testing.T) {
+	objStore := dstore.NewMockStore(nil)
+	conf, err := NewConfig("A", 5, pbsubstreams.ModuleKindMap, "", objStore, zlog)
+	require.NoError(t, err)
+	configs := &Configs{
+		execOutputSaveInterval: 10,
+		ConfigMap:              map[string]*Config{"A": conf},
+	}
+	ctx := context.Background()
+	write := func(w *Writer, blockNum uint64) {
+		buffer := &Buffer{values: map[string][]byte{"A": {byte(blockNum)}}}
+		require.NoError(t, w.Write(ctx, &pbsubstreams.Clock{Number: blockNum, Id: fmt.Sprintf("%d", blockNum)}, buffer))
+	}
+
+	w := NewWriter(10, 40, []string{"A"}, configs)
+	write(w, 12)
+	require.NoError(t, w.Checkpoint(ctx, 15, "trace"))
+	write(w, 15)
+	write(w, 22)
+	require.NoError(t, w.Checkpoint(ctx, 23, "trace"))
+
+	exists, err := conf.checkpointStore.FileExists(ctx, checkpointFilename(10, 15, "trace"))
+	require.NoError(t, err)
+	assert.False(t, exists, "previous checkpoint deleted")
+	exists, err = conf.objStore.FileExists(ctx, computeDBinFilename(10, 20))
+	require.NoError(t, err)
+	assert.True(t, exists, "file of the interval before the checkpoint saved")
+
+	// the retry resumes from the last checkpoint, in the file 20-30
+	resumed := NewWriter(10, 40, []string{"A"}, configs)
+	require.NoError(t, resumed.Resume(ctx, 23, "trace"))
+	write(resumed, 25)
+	require.NoError(t, resumed.Close(ctx))
+	resumed.DeleteCheckpoints(ctx)
+
+	file := conf.NewFile(block.NewRange(20, 30))
+	require.NoError(t, file.Load(ctx))
+	var blocks []uint64
+	for _, item := range file.SortedItems() {
+		blocks = append(blocks, item.BlockNum)
+	}
+	assert.Equal(t, []uint64{22, 25}, blocks)
+
+	exists, err = conf.checkpointStore.FileExists(ctx, checkpointFilename(20, 23, "trace"))
+	require.NoError(t, err)
+	assert.False(t, exists, "checkpoint deleted once completed")
 }
@@ -0,0 +1,110 @@
+package gc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/streamingfast/dstore"
+)
+
+// LastAccessFilename is the name of the file, in the directory of a module
+// hash, recording the last time the module was requested.
+const LastAccessFilename = "last_access.json"
+
+// DefaultAccessRecordInterval is the default minimum interval between two
+// writes of the last access time of a module hash by an AccessTracker.
+const DefaultAccessRecordInterval = time.Hour
+
+type lastAccess struct {
+	LastAccess time.Time `json:"last_access"`
+}
+
+// AccessTracker records the last access time of module hashes, in their
+// directory of the cache. The time is written at most once per interval
+// for each module hash, the last access time recorded can thus be behind
+// by that interval.
+type AccessTracker struct {
+	store    dstore.Store
+	interval time.Duration
+
+	mu      sync.Mutex
+	written map[string]time.Time
+
+	now func() time.Time
+}
+
+func NewAccessTracker(store dstore.Store, interval time.Duration) *AccessTracker {
+	return &AccessTracker{
+		store:    store,
+		interval: interval,
+		written:  make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Touch records that the module hash whose directory is `moduleDir`,
+// relative to the tracker's store (`<cacheTag>/<moduleHash>`), is accessed
+// now.
+func (t *AccessTracker) Touch(ctx context.Context, moduleDir string) error {
+	now := t.now()
+
+	t.mu.Lock()
+	if last, found := t.written[moduleDir]; found && now.Sub(last) < t.interval {
+		t.mu.Unlock()
+		return nil
+	}
+	t.written[moduleDir] = now
+	t.mu.Unlock()
+
+	if err := WriteLastAccess(ctx, t.store, moduleDir, now); err != nil {
+		t.mu.Lock()
+		delete(t.written, moduleDir)
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// WriteLastAccess records `at` as the last access time of the module hash
+// whose directory is `moduleDir`.
+func WriteLastAccess(ctx context.Context, store dstore.Store, moduleDir string, at time.Time) error {
+	cnt, err := json.Marshal(&lastAccess{LastAccess: at.UTC()})
+	if err != nil {
+		return fmt.Errorf("marshalling last access: %w", err)
+	}
+	filename := path.Join(moduleDir, LastAccessFilename)
+	if err := store.WriteObject(ctx, filename, bytes.NewReader(cnt)); err != nil {
+		return fmt.Errorf("writing %s: %w", filename, err)
+	}
+	return nil
+}
+
+// ReadLastAccess returns the last access time recorded for the module
+// hash whose directory is `moduleDir`, if any.
+func ReadLastAccess(ctx context.Context, store dstore.Store, moduleDir string) (at time.Time, found bool, err error) {
+	filename := path.Join(moduleDir, LastAccessFilename)
+	reader, err := store.OpenObject(ctx, filename)
+	if err == dstore.ErrNotFound {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("opening %s: %w", filename, err)
+	}
+	defer reader.Close()
+
+	cnt, err := io.ReadAll(reader)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	access := &lastAccess{}
+	if err := json.Unmarshal(cnt, access); err != nil {
+		return time.Time{}, false, fmt.Errorf("unmarshalling %s: %w", filename, err)
+	}
+	return access.LastAccess, true, nil
+}
@@ -0,0 +1,391 @@
+// Package gc removes stale data from the cache of module outputs and store
+// snapshots, following retention rules based on the last time each module
+// hash was requested, as recorded by an AccessTracker.
+package gc
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/abourget/llerrgroup"
+	"github.com/streamingfast/derr"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/storage/catalog"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+// Rules are the retention rules applied by a Collector, each one is
+// disabled when left to 0.
+type Rules struct {
+	// MaxAge deletes the module hashes not accessed for longer.
+	MaxAge time.Duration
+	// MaxTotalSize deletes the least recently accessed module hashes until
+	// the cache holds at most this many bytes.
+	MaxTotalSize uint64
+	// MinAge protects the module hashes accessed more recently from being
+	// deleted by MaxTotalSize.
+	MinAge time.Duration
+	// KeepEveryNthFullKV deletes the full store snapshots of the module
+	// hashes kept, except the ones ending every N bundles of StateBundleSize
+	// blocks and the latest one. Requests starting between two snapshots
+	// kept re-process the stores from the previous one.
+	KeepEveryNthFullKV uint64
+	StateBundleSize    uint64
+	// IncludeUntracked applies MaxAge and MaxTotalSize to the module hashes
+	// without a recorded access time, as if they were never accessed. They
+	// are kept otherwise.
+	IncludeUntracked bool
+}
+
+func (r Rules) Validate() error {
+	if r.KeepEveryNthFullKV > 1 && r.StateBundleSize == 0 {
+		return fmt.Errorf("state bundle size is required to keep every Nth full store snapshot")
+	}
+	return nil
+}
+
+// Module is the directory of a module hash in the cache.
+type Module struct {
+	// Dir is the directory, relative to the collected store, usually
+	// `<cacheTag>/<moduleHash>`.
+	Dir string
+	// LastAccess is zero when no access time is recorded.
+	LastAccess time.Time
+	Files      []string
+	// Size is only computed when the MaxTotalSize rule is set.
+	Size uint64
+	// Reason is why the module hash is deleted.
+	Reason string
+
+	fileSizes map[string]uint64
+}
+
+func (m *Module) Tracked() bool {
+	return !m.LastAccess.IsZero()
+}
+
+// Report lists what a Collector run deleted, or would delete on a dry run.
+type Report struct {
+	DryRun bool
+	// Modules is the number of module hashes found in the cache.
+	Modules        int
+	DeletedModules []*Module
+	// DeletedFullKVs are the paths of the full store snapshots deleted by
+	// the KeepEveryNthFullKV rule.
+	DeletedFullKVs []string
+	DeletedFiles   int
+	// DeletedBytes is only computed when the MaxTotalSize rule is set.
+	DeletedBytes uint64
+}
+
+// Collector applies retention rules to a cache store, holding either the
+// module hashes of a single cache tag or the cache tags themselves.
+type Collector struct {
+	store       dstore.Store
+	rules       Rules
+	concurrency int
+	logger      *zap.Logger
+
+	now func() time.Time
+}
+
+func NewCollector(store dstore.Store, rules Rules, logger *zap.Logger) *Collector {
+	return &Collector{
+		store:       store,
+		rules:       rules,
+		concurrency: 16,
+		logger:      logger.Named("gc"),
+		now:         time.Now,
+	}
+}
+
+// Run applies the rules, only reporting what would be deleted if `dryRun`
+// is set.
+func (c *Collector) Run(ctx context.Context, dryRun bool) (*Report, error) {
+	if err := c.rules.Validate(); err != nil {
+		return nil, err
+	}
+
+	modules, err := c.scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{DryRun: dryRun, Modules: len(modules)}
+
+	// least recently accessed first, untracked ones before all others
+	sort.SliceStable(modules, func(i, j int) bool {
+		return modules[i].LastAccess.Before(modules[j].LastAccess)
+	})
+
+	var totalSize uint64
+	for _, mod := range modules {
+		totalSize += mod.Size
+	}
+
+	now := c.now()
+	var kept []*Module
+	for _, mod := range modules {
+		age := now.Sub(mod.LastAccess)
+		switch {
+		case !mod.Tracked() && !c.rules.IncludeUntracked:
+		case c.rules.MaxAge != 0 && age > c.rules.MaxAge:
+			mod.Reason = fmt.Sprintf("not accessed for more than %s", c.rules.MaxAge)
+		case c.rules.MaxTotalSize != 0 && totalSize > c.rules.MaxTotalSize && age >= c.rules.MinAge:
+			mod.Reason = fmt.Sprintf("cache size %d bytes above %d bytes", totalSize, c.rules.MaxTotalSize)
+		}
+
+		if mod.Reason == "" {
+			kept = append(kept, mod)
+			continue
+		}
+		totalSize -= mod.Size
+		report.DeletedModules = append(report.DeletedModules, mod)
+		report.DeletedFiles += len(mod.Files)
+		report.DeletedBytes += mod.Size
+	}
+
+	extraFullKVs := map[*Module][]string{}
+	if c.rules.KeepEveryNthFullKV > 1 {
+		for _, mod := range kept {
+			extra := c.extraFullKVs(mod)
+			if len(extra) == 0 {
+				continue
+			}
+			extraFullKVs[mod] = extra
+			for _, filename := range extra {
+				report.DeletedFullKVs = append(report.DeletedFullKVs, filename)
+				report.DeletedFiles++
+				report.DeletedBytes += mod.fileSizes[filename]
+			}
+		}
+	}
+
+	if dryRun {
+		return report, nil
+	}
+
+	for _, mod := range report.DeletedModules {
+		c.logger.Info("deleting module hash", zap.String("dir", mod.Dir), zap.Time("last_access", mod.LastAccess), zap.String("reason", mod.Reason))
+		if err := c.deleteModule(ctx, mod); err != nil {
+			return nil, fmt.Errorf("deleting %s: %w", mod.Dir, err)
+		}
+	}
+	for _, mod := range kept {
+		extra := extraFullKVs[mod]
+		if len(extra) == 0 {
+			continue
+		}
+		if err := c.deleteFiles(ctx, extra); err != nil {
+			return nil, fmt.Errorf("deleting full store snapshots of %s: %w", mod.Dir, err)
+		}
+		if err := c.recordRemovals(ctx, mod, extra); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+// scan lists the module hash directories of the store along with their
+// files and last access time.
+func (c *Collector) scan(ctx context.Context) ([]*Module, error) {
+	byDir := map[string]*Module{}
+	var modules []*Module
+	err := c.store.Walk(ctx, "", func(filename string) error {
+		dir, ok := ModuleDir(filename)
+		if !ok {
+			return nil
+		}
+		mod := byDir[dir]
+		if mod == nil {
+			mod = &Module{Dir: dir, fileSizes: map[string]uint64{}}
+			byDir[dir] = mod
+			modules = append(modules, mod)
+		}
+		mod.Files = append(mod.Files, filename)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walking cache: %w", err)
+	}
+
+	for _, mod := range modules {
+		lastAccess, _, err := ReadLastAccess(ctx, c.store, mod.Dir)
+		if err != nil {
+			return nil, err
+		}
+		mod.LastAccess = lastAccess
+
+		if c.rules.MaxTotalSize == 0 {
+			continue
+		}
+		for _, filename := range mod.Files {
+			attrs, err := c.store.ObjectAttributes(ctx, filename)
+			if err != nil {
+				return nil, fmt.Errorf("getting size of %s: %w", filename, err)
+			}
+			mod.fileSizes[filename] = uint64(attrs.Size)
+			mod.Size += uint64(attrs.Size)
+		}
+	}
+	return modules, nil
+}
+
+// extraFullKVs returns the full store snapshots of `mod` that the
+// KeepEveryNthFullKV rule deletes.
+func (c *Collector) extraFullKVs(mod *Module) (out []string) {
+	interval := c.rules.KeepEveryNthFullKV * c.rules.StateBundleSize
+
+	var latest uint64
+	fullKVs := map[string]*store.FileInfo{}
+	for _, filename := range mod.Files {
+		dir, name := path.Split(filename)
+		if path.Base(dir) != "states" {
+			continue
+		}
+		file, ok := store.ParseFileName(name)
+		if !ok || file.Partial {
+			continue
+		}
+		fullKVs[filename] = file
+		latest = max(latest, file.Range.ExclusiveEndBlock)
+	}
+
+	for filename, file := range fullKVs {
+		if end := file.Range.ExclusiveEndBlock; end%interval != 0 && end != latest {
+			out = append(out, filename)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// deleteModule deletes all the files of `mod`. The removal of the cataloged
+// files is recorded before the catalog is deleted, so that the catalog
+// never lists files already deleted, even if rebuilt meanwhile. The last
+// access time goes last, so that an interrupted deletion is resumed by the
+// next run.
+func (c *Collector) deleteModule(ctx context.Context, mod *Module) error {
+	lastAccessFile := path.Join(mod.Dir, LastAccessFilename)
+	catalogPrefix := path.Join(mod.Dir, catalog.DirName) + "/"
+
+	var files []string
+	for _, filename := range mod.Files {
+		if filename != lastAccessFile && !strings.HasPrefix(filename, catalogPrefix) {
+			files = append(files, filename)
+		}
+	}
+	if err := c.deleteFiles(ctx, files); err != nil {
+		return err
+	}
+	if err := c.recordRemovals(ctx, mod, files); err != nil {
+		return err
+	}
+
+	// listed again, to include the log objects recorded since the scan
+	var catalogFiles []string
+	err := c.store.Walk(ctx, catalogPrefix, func(filename string) error {
+		catalogFiles = append(catalogFiles, filename)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("listing catalog: %w", err)
+	}
+	if err := c.deleteFiles(ctx, catalogFiles); err != nil {
+		return err
+	}
+
+	if mod.Tracked() {
+		return c.deleteFiles(ctx, []string{lastAccessFile})
+	}
+	return nil
+}
+
+// recordRemovals records to the catalog of `mod` the removal of the files
+// among `files` that it lists, once deleted. A catalog rebuilt while they
+// were being deleted may list them otherwise.
+func (c *Collector) recordRemovals(ctx context.Context, mod *Module, files []string) error {
+	var entries []catalog.Entry
+	for _, filename := range files {
+		dir, name, found := strings.Cut(strings.TrimPrefix(filename, mod.Dir+"/"), "/")
+		if !found || (dir != string(catalog.DirOutputs) && dir != string(catalog.DirStates)) {
+			continue
+		}
+		entries = append(entries, catalog.Entry{Dir: catalog.Dir(dir), Filename: name, Removed: true})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	cat, err := catalog.New(c.store, mod.Dir, c.logger)
+	if err != nil {
+		return err
+	}
+	if err := cat.Record(ctx, entries...); err != nil {
+		return fmt.Errorf("recording removals to the catalog of %s: %w", mod.Dir, err)
+	}
+	return nil
+}
+
+func (c *Collector) deleteFiles(ctx context.Context, files []string) error {
+	eg := llerrgroup.New(c.concurrency)
+	for _, filename := range files {
+		if eg.Stop() {
+			break
+		}
+		filename := filename
+		eg.Go(func() error {
+			return derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+				err := c.store.DeleteObject(ctx, filename)
+				if err == dstore.ErrNotFound {
+					return nil
+				}
+				return err
+			})
+		})
+	}
+	return eg.Wait()
+}
+
+var moduleDirEntries = map[string]bool{
+	"outputs":       true,
+	"states":        true,
+	"index":         true,
+	"checkpoints":   true,
+	catalog.DirName: true,
+}
+
+var moduleDirFiles = map[string]bool{
+	LastAccessFilename:        true,
+	"substreams.partial.spkg": true,
+}
+
+// ModuleDir returns the directory of the module hash holding `filename`,
+// if it is a file of the cache.
+func ModuleDir(filename string) (string, bool) {
+	parts := strings.Split(filename, "/")
+	for i := 1; i < len(parts); i++ {
+		if !isModuleHash(parts[i-1]) {
+			continue
+		}
+		last := i == len(parts)-1
+		if (!last && moduleDirEntries[parts[i]]) || (last && moduleDirFiles[parts[i]]) {
+			return path.Join(parts[:i]...), true
+		}
+	}
+	return "", false
+}
+
+func isModuleHash(name string) bool {
+	if len(name) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
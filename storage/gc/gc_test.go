@@ -0,0 +1,294 @@
+package gc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/storage/catalog"
+)
+
+var testNow = time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// lastAccessSize is the size of a last access file written by testCache.
+const lastAccessSize = uint64(len(`{"last_access":"2023-06-01T00:00:00Z"}`))
+
+const (
+	hashA = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	hashB = "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+	hashC = "cccccccccccccccccccccccccccccccccccccccc"
+)
+
+// testCache writes, under cache tag `v1`, module hash A accessed 1 hour ago
+// with 100 bytes of states and a 10 bytes catalog, B accessed 10 days ago
+// with 100 bytes of outputs, and C, untracked, with 50 bytes of states, 336
+// bytes in total.
+func testCache(t *testing.T) dstore.Store {
+	ctx := context.Background()
+	store, err := dstore.NewStore("file://"+t.TempDir(), "", "", true)
+	require.NoError(t, err)
+
+	write := func(filename string, size int) {
+		require.NoError(t, store.WriteObject(ctx, filename, bytes.NewReader(make([]byte, size))))
+	}
+	for _, endBlock := range []string{"0000001000", "0000002000", "0000003000", "0000004000", "0000005000"} {
+		write("v1/"+hashA+"/states/"+endBlock+"-0000000000.kv", 20)
+	}
+	write("v1/"+hashA+"/catalog/catalog.json", 10)
+	write("v1/"+hashB+"/outputs/0000000000-0000001000.output", 100)
+	write("v1/"+hashC+"/states/0000001000-0000000000.kv", 50)
+	write("v1/unrelated/file", 1000)
+
+	require.NoError(t, WriteLastAccess(ctx, store, "v1/"+hashA, testNow.Add(-time.Hour)))
+	require.NoError(t, WriteLastAccess(ctx, store, "v1/"+hashB, testNow.Add(-10*24*time.Hour)))
+	return store
+}
+
+func listFiles(t *testing.T, store dstore.Store) (out []string) {
+	require.NoError(t, store.Walk(context.Background(), "", func(filename string) error {
+		out = append(out, filename)
+		return nil
+	}))
+	sort.Strings(out)
+	return out
+}
+
+func deletedDirs(report *Report) (out []string) {
+	for _, mod := range report.DeletedModules {
+		out = append(out, path.Base(mod.Dir))
+	}
+	return out
+}
+
+func TestCollector_Run(t *testing.T) {
+	tests := []struct {
+		name            string
+		rules           Rules
+		expectedModules []string
+		expectedFullKVs []string
+		expectedBytes   uint64
+
+		expectedCatalogDeleted bool
+	}{
+		{
+			name:            "max age",
+			rules:           Rules{MaxAge: 24 * time.Hour},
+			expectedModules: []string{hashB},
+		},
+		{
+			name:            "max age including untracked",
+			rules:           Rules{MaxAge: 24 * time.Hour, IncludeUntracked: true},
+			expectedModules: []string{hashC, hashB},
+		},
+		{
+			name:            "max total size",
+			rules:           Rules{MaxTotalSize: 200},
+			expectedModules: []string{hashB},
+			expectedBytes:   100 + lastAccessSize,
+		},
+		{
+			name:            "max total size protected by min age",
+			rules:           Rules{MaxTotalSize: 10, MinAge: 2 * time.Hour},
+			expectedModules: []string{hashB},
+			expectedBytes:   100 + lastAccessSize,
+		},
+		{
+			name:            "max total size not protected by min age",
+			rules:           Rules{MaxTotalSize: 10, MinAge: 30 * time.Minute},
+			expectedModules: []string{hashB, hashA},
+			expectedBytes:   100 + lastAccessSize + 5*20 + 10 + lastAccessSize,
+
+			expectedCatalogDeleted: true,
+		},
+		{
+			name:  "keep every nth full kv",
+			rules: Rules{KeepEveryNthFullKV: 2, StateBundleSize: 1000},
+			expectedFullKVs: []string{
+				"v1/" + hashA + "/states/0000001000-0000000000.kv",
+				"v1/" + hashA + "/states/0000003000-0000000000.kv",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			store := testCache(t)
+			before := listFiles(t, store)
+
+			collector := NewCollector(store, test.rules, zap.NewNop())
+			collector.now = func() time.Time { return testNow }
+
+			report, err := collector.Run(context.Background(), true)
+			require.NoError(t, err)
+			assert.Equal(t, 3, report.Modules)
+			assert.Equal(t, test.expectedModules, deletedDirs(report))
+			assert.Equal(t, test.expectedFullKVs, report.DeletedFullKVs)
+			assert.Equal(t, test.expectedBytes, report.DeletedBytes)
+			assert.Equal(t, before, listFiles(t, store), "dry run deleted files")
+
+			report, err = collector.Run(context.Background(), false)
+			require.NoError(t, err)
+			var after, removalLogs []string
+			for _, filename := range listFiles(t, store) {
+				if strings.Contains(filename, "/catalog/log/") {
+					removalLogs = append(removalLogs, filename)
+					continue
+				}
+				after = append(after, filename)
+			}
+			assert.Len(t, after, len(before)-report.DeletedFiles)
+			assert.Equal(t, len(test.expectedFullKVs) != 0, len(removalLogs) != 0, "removal of the full KVs recorded to the catalog")
+			for _, filename := range after {
+				for _, hash := range test.expectedModules {
+					assert.False(t, strings.Contains(filename, hash), "file %s of deleted module hash remaining", filename)
+				}
+				assert.NotContains(t, test.expectedFullKVs, filename)
+			}
+			assert.Equal(t, test.expectedCatalogDeleted, !slices.Contains(after, "v1/"+hashA+"/catalog/catalog.json"))
+		})
+	}
+}
+
+// ensuringStore runs `ensure` before deleting each file, as tier1 may
+// ensure the catalog of a module hash at any time while it is collected.
+type ensuringStore struct {
+	dstore.Store
+
+	lock   sync.Mutex
+	ensure func(ctx context.Context) error
+}
+
+func (s *ensuringStore) DeleteObject(ctx context.Context, filename string) error {
+	s.lock.Lock()
+	err := s.ensure(ctx)
+	s.lock.Unlock()
+	if err != nil {
+		return err
+	}
+	return s.Store.DeleteObject(ctx, filename)
+}
+
+func TestCollector_RunWithConcurrentEnsure(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules Rules
+	}{
+		{
+			name:  "module hash deleted",
+			rules: Rules{MaxAge: time.Minute},
+		},
+		{
+			name:  "full kvs deleted",
+			rules: Rules{KeepEveryNthFullKV: 2, StateBundleSize: 1000},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			localStore, err := dstore.NewStore("file://"+t.TempDir(), "", "", true)
+			require.NoError(t, err)
+
+			for _, filename := range []string{
+				"states/0000001000-0000000000.kv",
+				"states/0000002000-0000000000.kv",
+				"states/0000003000-0000000000.kv",
+				"outputs/0000000000-0000001000.output",
+			} {
+				require.NoError(t, localStore.WriteObject(ctx, "v1/"+hashA+"/"+filename, bytes.NewReader([]byte("content"))))
+			}
+			require.NoError(t, WriteLastAccess(ctx, localStore, "v1/"+hashA, testNow.Add(-time.Hour)))
+
+			cat, err := catalog.New(localStore, "v1/"+hashA, zap.NewNop())
+			require.NoError(t, err)
+			_, err = cat.Rebuild(ctx)
+			require.NoError(t, err)
+
+			store := &ensuringStore{Store: localStore, ensure: cat.Ensure}
+			collector := NewCollector(store, test.rules, zap.NewNop())
+			collector.now = func() time.Time { return testNow }
+
+			_, err = collector.Run(ctx, false)
+			require.NoError(t, err)
+
+			diff, err := cat.Check(ctx)
+			if errors.Is(err, catalog.ErrNotFound) {
+				return
+			}
+			require.NoError(t, err)
+			assert.Empty(t, diff.Stale, "catalog listing deleted files")
+			assert.Empty(t, diff.Unknown)
+		})
+	}
+}
+
+func TestRules_Validate(t *testing.T) {
+	assert.NoError(t, Rules{MaxAge: time.Hour}.Validate())
+	assert.Error(t, Rules{KeepEveryNthFullKV: 10}.Validate())
+}
+
+func TestAccessTracker_Touch(t *testing.T) {
+	ctx := context.Background()
+	store, err := dstore.NewStore("file://"+t.TempDir(), "", "", true)
+	require.NoError(t, err)
+
+	now := testNow
+	tracker := NewAccessTracker(store, time.Hour)
+	tracker.now = func() time.Time { return now }
+
+	_, found, err := ReadLastAccess(ctx, store, "v1/"+hashA)
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, tracker.Touch(ctx, "v1/"+hashA))
+	now = testNow.Add(30 * time.Minute)
+	require.NoError(t, tracker.Touch(ctx, "v1/"+hashA))
+
+	at, found, err := ReadLastAccess(ctx, store, "v1/"+hashA)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.True(t, testNow.Equal(at), "access within the interval recorded")
+
+	now = testNow.Add(2 * time.Hour)
+	require.NoError(t, tracker.Touch(ctx, "v1/"+hashA))
+	at, _, err = ReadLastAccess(ctx, store, "v1/"+hashA)
+	require.NoError(t, err)
+	assert.True(t, now.Equal(at))
+}
+
+func TestModuleDir(t *testing.T) {
+	tests := []struct {
+		filename    string
+		expectedDir string
+	}{
+		{"v1/" + hashA + "/states/0000001000-0000000000.kv", "v1/" + hashA},
+		{hashA + "/outputs/0000000000-0000001000.output", hashA},
+		{"v1/" + hashA + "/index/0000000000-0000001000.index", "v1/" + hashA},
+		{"v1/" + hashA + "/checkpoints/0000000000-0000000450.abc.output", "v1/" + hashA},
+		{"v1/" + hashA + "/" + LastAccessFilename, "v1/" + hashA},
+		{"v1/" + hashA + "/substreams.partial.spkg", "v1/" + hashA},
+		{"v1/" + hashA + "/other", ""},
+		{"v1/" + hashA, ""},
+		{"v1/notahash/states/0000001000-0000000000.kv", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.filename, func(t *testing.T) {
+			dir, ok := ModuleDir(test.filename)
+			assert.Equal(t, test.expectedDir != "", ok)
+			assert.Equal(t, test.expectedDir, dir)
+		})
+	}
+}
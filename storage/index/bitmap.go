@@ -0,0 +1,103 @@
+package index
+
+import (
+	"math/bits"
+
+	"github.com/streamingfast/substreams/block"
+)
+
+// Bitmap is a dense set of block numbers within a block range, one bit
+// per block of the range.
+type Bitmap struct {
+	blockRange *block.Range
+	words      []uint64
+}
+
+func NewBitmap(blockRange *block.Range) *Bitmap {
+	return &Bitmap{
+		blockRange: blockRange,
+		words:      make([]uint64, wordCount(blockRange)),
+	}
+}
+
+func wordCount(blockRange *block.Range) int {
+	return int((blockRange.Len() + 63) / 64)
+}
+
+func (b *Bitmap) Range() *block.Range { return b.blockRange }
+
+func (b *Bitmap) Add(blockNum uint64) {
+	if !b.blockRange.Contains(blockNum) {
+		return
+	}
+	offset := blockNum - b.blockRange.StartBlock
+	b.words[offset/64] |= 1 << (offset % 64)
+}
+
+func (b *Bitmap) Has(blockNum uint64) bool {
+	if !b.blockRange.Contains(blockNum) {
+		return false
+	}
+	offset := blockNum - b.blockRange.StartBlock
+	return b.words[offset/64]&(1<<(offset%64)) != 0
+}
+
+// And returns a new Bitmap holding the blocks present in both `b` and `other`,
+// which must cover the same range.
+func (b *Bitmap) And(other *Bitmap) *Bitmap {
+	out := NewBitmap(b.blockRange)
+	for i := range out.words {
+		out.words[i] = b.words[i] & other.words[i]
+	}
+	return out
+}
+
+// Or returns a new Bitmap holding the blocks present in either `b` or `other`,
+// which must cover the same range.
+func (b *Bitmap) Or(other *Bitmap) *Bitmap {
+	out := NewBitmap(b.blockRange)
+	for i := range out.words {
+		out.words[i] = b.words[i] | other.words[i]
+	}
+	return out
+}
+
+// Not returns a new Bitmap holding the blocks of the range absent from `b`.
+func (b *Bitmap) Not() *Bitmap {
+	out := NewBitmap(b.blockRange)
+	for i := range out.words {
+		out.words[i] = ^b.words[i]
+	}
+	if rem := b.blockRange.Len() % 64; rem != 0 {
+		out.words[len(out.words)-1] &= (1 << rem) - 1
+	}
+	return out
+}
+
+func (b *Bitmap) Count() (out int) {
+	for _, word := range b.words {
+		out += bits.OnesCount64(word)
+	}
+	return
+}
+
+func (b *Bitmap) IsEmpty() bool {
+	for _, word := range b.words {
+		if word != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// AnyIn returns whether at least one block of `blockRange` is in the Bitmap.
+func (b *Bitmap) AnyIn(blockRange *block.Range) bool {
+	start := max(blockRange.StartBlock, b.blockRange.StartBlock)
+	end := min(blockRange.ExclusiveEndBlock, b.blockRange.ExclusiveEndBlock)
+	for blockNum := start; blockNum < end; blockNum++ {
+		if b.Has(blockNum) {
+			return true
+		}
+	}
+	return false
+}
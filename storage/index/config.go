@@ -0,0 +1,182 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/streamingfast/derr"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+)
+
+// Config locates the index files of a block index module, stored under
+// `<moduleHash>/index` next to the module's `<moduleHash>/outputs` cache.
+type Config struct {
+	name               string
+	moduleHash         string
+	moduleInitialBlock uint64
+	saveInterval       uint64
+	objStore           dstore.Store
+
+	// checkpointStore holds the checkpoints of the indexes being built by
+	// tier2 jobs, out of the way of the index files.
+	checkpointStore dstore.Store
+
+	logger *zap.Logger
+}
+
+func NewConfig(name string, moduleInitialBlock uint64, moduleHash string, saveInterval uint64, baseStore dstore.Store, logger *zap.Logger) (*Config, error) {
+	subStore, err := baseStore.SubStore(fmt.Sprintf("%s/index", moduleHash))
+	if err != nil {
+		return nil, fmt.Errorf("creating sub store: %w", err)
+	}
+
+	checkpointStore, err := baseStore.SubStore(fmt.Sprintf("%s/checkpoints", moduleHash))
+	if err != nil {
+		return nil, fmt.Errorf("creating checkpoint sub store: %w", err)
+	}
+
+	return &Config{
+		name:               name,
+		moduleHash:         moduleHash,
+		moduleInitialBlock: moduleInitialBlock,
+		saveInterval:       saveInterval,
+		objStore:           subStore,
+		checkpointStore:    checkpointStore,
+		logger:             logger.With(zap.String("module", name)),
+	}, nil
+}
+
+func (c *Config) Name() string               { return c.name }
+func (c *Config) ModuleHash() string         { return c.moduleHash }
+func (c *Config) ModuleInitialBlock() uint64 { return c.moduleInitialBlock }
+
+func (c *Config) Save(ctx context.Context, idx *Index) error {
+	filename := computeIndexFilename(idx.StartBlock, idx.ExclusiveEndBlock)
+	content := idx.Marshal()
+
+	c.logger.Info("writing block index file", zap.String("filename", filename), zap.Int("key_count", idx.KeyCount()))
+	return derr.RetryContext(ctx, 5, func(ctx context.Context) error {
+		return c.objStore.WriteObject(ctx, filename, bytes.NewReader(content))
+	})
+}
+
+// Load returns the index covering all of `blockRange`, or `nil` if there is
+// none. An index starting at the module's initial block also covers the
+// blocks before it, on which the module emits no keys.
+func (c *Config) Load(ctx context.Context, blockRange *block.Range) (*Index, error) {
+	filename, found, err := c.findFile(ctx, blockRange)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+
+	return c.read(ctx, c.objStore, filename)
+}
+
+// SaveCheckpoint saves `idx`, holding the keys of the blocks before
+// `endBlock`, as a checkpoint of the tier2 job of `traceID` to resume from.
+// It returns the name of the checkpoint.
+func (c *Config) SaveCheckpoint(ctx context.Context, idx *Index, endBlock uint64, traceID string) (string, error) {
+	filename := checkpointFilename(idx.StartBlock, endBlock, traceID)
+	content := idx.Marshal()
+
+	c.logger.Debug("writing block index checkpoint", zap.String("filename", filename))
+	err := derr.RetryContext(ctx, 5, func(ctx context.Context) error {
+		return c.checkpointStore.WriteObject(ctx, filename, bytes.NewReader(content))
+	})
+	if err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// LoadCheckpoint returns the index starting at `startBlock` saved as a
+// checkpoint at `endBlock` by the tier2 job of `traceID`, along with the
+// name of the checkpoint.
+func (c *Config) LoadCheckpoint(ctx context.Context, startBlock, endBlock uint64, traceID string) (*Index, string, error) {
+	filename := checkpointFilename(startBlock, endBlock, traceID)
+	idx, err := c.read(ctx, c.checkpointStore, filename)
+	if err != nil {
+		return nil, "", fmt.Errorf("loading checkpoint %s: %w", filename, err)
+	}
+	return idx, filename, nil
+}
+
+// DeleteCheckpoint deletes the checkpoint `filename`, once superseded. The
+// checkpoints not deleted are left to the garbage collection.
+func (c *Config) DeleteCheckpoint(ctx context.Context, filename string) {
+	if err := c.checkpointStore.DeleteObject(ctx, filename); err != nil && err != dstore.ErrNotFound {
+		c.logger.Warn("cannot delete block index checkpoint", zap.String("filename", filename), zap.Error(err))
+	}
+}
+
+func (c *Config) read(ctx context.Context, store dstore.Store, filename string) (*Index, error) {
+	var idx *Index
+	err := derr.RetryContext(ctx, 5, func(ctx context.Context) error {
+		objectReader, err := store.OpenObject(ctx, filename)
+		if err == dstore.ErrNotFound {
+			return derr.NewFatalError(err)
+		}
+		if err != nil {
+			return fmt.Errorf("opening index file %s: %w", filename, err)
+		}
+		defer objectReader.Close()
+
+		content, err := io.ReadAll(objectReader)
+		if err != nil {
+			return fmt.Errorf("reading index file %s: %w", filename, err)
+		}
+
+		idx, err = Unmarshal(content)
+		if err != nil {
+			return derr.NewFatalError(fmt.Errorf("unmarshalling index file %s: %w", filename, err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return idx, nil
+}
+
+func (c *Config) findFile(ctx context.Context, blockRange *block.Range) (filename string, found bool, err error) {
+	// index files never span more than one segment, so the one we are
+	// looking for starts in the segment of `blockRange.StartBlock`
+	segmentStartBlock := blockRange.StartBlock - blockRange.StartBlock%c.saveInterval
+	lastStartBlock := max(blockRange.StartBlock, c.moduleInitialBlock)
+
+	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
+		filename, found = "", false
+
+		return c.objStore.WalkFrom(ctx, "", computeIndexFilename(segmentStartBlock, 0), func(candidate string) error {
+			fileRange, err := parseIndexFilename(candidate)
+			if err != nil {
+				c.logger.Warn("seen index file that we don't know how to parse", zap.String("filename", candidate), zap.Error(err))
+				return nil
+			}
+			if fileRange.StartBlock > lastStartBlock {
+				return dstore.StopIteration
+			}
+			if fileRange.ExclusiveEndBlock < blockRange.ExclusiveEndBlock {
+				return nil
+			}
+			if fileRange.StartBlock <= blockRange.StartBlock || fileRange.StartBlock == c.moduleInitialBlock {
+				filename, found = candidate, true
+				return dstore.StopIteration
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("walking index files: %w", err)
+	}
+	return
+}
@@ -0,0 +1,111 @@
+package index
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+type Configs struct {
+	ConfigMap map[string]*Config
+	logger    *zap.Logger
+}
+
+// NewConfigs creates the Config of each block index module found in
+// `allRequestedModules`, `moduleHash` resolving the hash of a module
+// from its name.
+func NewConfigs(baseObjectStore dstore.Store, allRequestedModules []*pbsubstreams.Module, moduleHash func(moduleName string) string, saveInterval uint64, logger *zap.Logger) (*Configs, error) {
+	out := make(map[string]*Config)
+	for _, mod := range allRequestedModules {
+		if mod.ModuleKind() != pbsubstreams.ModuleKindBlockIndex {
+			continue
+		}
+		conf, err := NewConfig(
+			mod.Name,
+			mod.InitialBlock,
+			moduleHash(mod.Name),
+			saveInterval,
+			baseObjectStore,
+			logger,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("new index config for %q: %w", mod.Name, err)
+		}
+		out[mod.Name] = conf
+	}
+
+	return &Configs{
+		ConfigMap: out,
+		logger:    logger,
+	}, nil
+}
+
+// ModuleNames returns the names of the block index modules.
+func (c *Configs) ModuleNames() (out []string) {
+	for name := range c.ConfigMap {
+		out = append(out, name)
+	}
+	return
+}
+
+// ExecutionBitmap returns the blocks of `blockRange` on which `module` needs
+// to be executed according to the block filters of its inputs. It returns
+// `nil` when this cannot be known in advance, because the module has no
+// block filter or because an index file it depends on was not produced yet.
+func (c *Configs) ExecutionBitmap(ctx context.Context, module *pbsubstreams.Module, blockRange *block.Range) (*Bitmap, error) {
+	filters, err := ModuleBlockFilters(module)
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) == 0 {
+		return nil, nil
+	}
+
+	var out *Bitmap
+	for _, filter := range filters {
+		conf, found := c.ConfigMap[filter.Module]
+		if !found {
+			return nil, fmt.Errorf("module %q: block index %q not found", module.Name, filter.Module)
+		}
+
+		idx, err := conf.Load(ctx, blockRange)
+		if err != nil {
+			return nil, fmt.Errorf("loading block index %q for range %s: %w", filter.Module, blockRange, err)
+		}
+		if idx == nil {
+			return nil, nil
+		}
+
+		filterBitmap := evaluateOnRange(filter.Query, idx, blockRange)
+		if out == nil {
+			out = filterBitmap
+		} else {
+			out = out.And(filterBitmap)
+		}
+	}
+	return out, nil
+}
+
+// evaluateOnRange evaluates `query` over `blockRange`, blocks outside of
+// the index's range being those on which the index module emitted no keys.
+func evaluateOnRange(query *Query, idx *Index, blockRange *block.Range) *Bitmap {
+	matched := query.Bitmap(idx)
+	matchesNoKeys := query.Matches(nil)
+
+	out := NewBitmap(blockRange)
+	for blockNum := blockRange.StartBlock; blockNum < blockRange.ExclusiveEndBlock; blockNum++ {
+		if idx.Contains(blockNum) {
+			if matched.Has(blockNum) {
+				out.Add(blockNum)
+			}
+		} else if matchesNoKeys {
+			out.Add(blockNum)
+		}
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package index
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/streamingfast/substreams/block"
+)
+
+var indexFilenameRegex = regexp.MustCompile(`(\d+)-(\d+)\.index$`)
+
+func computeIndexFilename(startBlock, exclusiveEndBlock uint64) string {
+	return fmt.Sprintf("%010d-%010d.index", startBlock, exclusiveEndBlock)
+}
+
+// checkpointFilename is the name of the checkpoint of the index starting at
+// `startBlock`, holding the keys of the blocks before `endBlock`, written by
+// the tier2 job of `traceID`.
+func checkpointFilename(startBlock, endBlock uint64, traceID string) string {
+	return fmt.Sprintf("%010d-%010d.%s.index", startBlock, endBlock, traceID)
+}
+
+func parseIndexFilename(filename string) (*block.Range, error) {
+	res := indexFilenameRegex.FindStringSubmatch(filename)
+	if res == nil {
+		return nil, fmt.Errorf("invalid index filename %q", filename)
+	}
+
+	start, err := strconv.ParseUint(res[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing start block of %q: %w", filename, err)
+	}
+	end, err := strconv.ParseUint(res[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parsing end block of %q: %w", filename, err)
+	}
+
+	return block.NewRange(start, end), nil
+}
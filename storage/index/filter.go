@@ -0,0 +1,52 @@
+package index
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	pbindex "github.com/streamingfast/substreams/pb/sf/substreams/index/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// BlockFilter is the parsed form of a module input's `block_filter`,
+// `Module` being the name of the block index module the `Query` is
+// evaluated against.
+type BlockFilter struct {
+	Module string
+	Query  *Query
+}
+
+func NewBlockFilter(filter *pbsubstreams.Module_Input_BlockFilter) (*BlockFilter, error) {
+	query, err := ParseQuery(filter.Query)
+	if err != nil {
+		return nil, err
+	}
+	return &BlockFilter{Module: filter.Module, Query: query}, nil
+}
+
+// ModuleBlockFilters returns the block filters found on the inputs of
+// `module`. A module is executed on a block only if all of them match.
+func ModuleBlockFilters(module *pbsubstreams.Module) (out []*BlockFilter, err error) {
+	for idx, input := range module.Inputs {
+		if input.BlockFilter == nil {
+			continue
+		}
+		filter, err := NewBlockFilter(input.BlockFilter)
+		if err != nil {
+			return nil, fmt.Errorf("module %q: input %d: block filter: %w", module.Name, idx, err)
+		}
+		out = append(out, filter)
+	}
+	return out, nil
+}
+
+// Matches evaluates the filter against `data`, the serialized
+// `sf.substreams.index.v1.Keys` output of the block index module.
+func (f *BlockFilter) Matches(data []byte) (bool, error) {
+	keys := &pbindex.Keys{}
+	if err := proto.Unmarshal(data, keys); err != nil {
+		return false, fmt.Errorf("unmarshalling keys of block index %q: %w", f.Module, err)
+	}
+	return f.Query.Matches(keys.Keys), nil
+}
@@ -0,0 +1,145 @@
+package index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/streamingfast/substreams/block"
+)
+
+var indexMagic = []byte("ssidx")
+
+const indexVersion = 1
+
+// Index holds, for a block range, the Bitmap of the blocks on which each
+// key was emitted by a block index module.
+type Index struct {
+	*block.Range
+
+	keys map[string]*Bitmap
+}
+
+func NewIndex(blockRange *block.Range) *Index {
+	return &Index{
+		Range: blockRange,
+		keys:  make(map[string]*Bitmap),
+	}
+}
+
+func (i *Index) Add(blockNum uint64, keys []string) {
+	for _, key := range keys {
+		bitmap, found := i.keys[key]
+		if !found {
+			bitmap = NewBitmap(i.Range)
+			i.keys[key] = bitmap
+		}
+		bitmap.Add(blockNum)
+	}
+}
+
+// Get returns the blocks on which `key` was emitted, an empty Bitmap
+// if it never was.
+func (i *Index) Get(key string) *Bitmap {
+	if bitmap, found := i.keys[key]; found {
+		return bitmap
+	}
+	return NewBitmap(i.Range)
+}
+
+func (i *Index) KeyCount() int {
+	return len(i.keys)
+}
+
+func (i *Index) sortedKeys() (out []string) {
+	for key := range i.keys {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return
+}
+
+// Marshal encodes the index as: magic, version, start block, exclusive
+// end block and key count, followed by each key and its bitmap words,
+// keys sorted so that the output is deterministic.
+func (i *Index) Marshal() []byte {
+	buf := bytes.NewBuffer(nil)
+	buf.Write(indexMagic)
+	buf.WriteByte(indexVersion)
+	buf.Write(binary.AppendUvarint(nil, i.StartBlock))
+	buf.Write(binary.AppendUvarint(nil, i.ExclusiveEndBlock))
+	buf.Write(binary.AppendUvarint(nil, uint64(len(i.keys))))
+
+	word := make([]byte, 8)
+	for _, key := range i.sortedKeys() {
+		buf.Write(binary.AppendUvarint(nil, uint64(len(key))))
+		buf.WriteString(key)
+		for _, w := range i.keys[key].words {
+			binary.LittleEndian.PutUint64(word, w)
+			buf.Write(word)
+		}
+	}
+	return buf.Bytes()
+}
+
+func Unmarshal(data []byte) (*Index, error) {
+	reader := bytes.NewReader(data)
+
+	magic := make([]byte, len(indexMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || !bytes.Equal(magic, indexMagic) {
+		return nil, fmt.Errorf("invalid index file: bad magic")
+	}
+	version, err := reader.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("reading version: %w", err)
+	}
+	if version != indexVersion {
+		return nil, fmt.Errorf("unsupported index version %d", version)
+	}
+
+	start, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading start block: %w", err)
+	}
+	end, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading end block: %w", err)
+	}
+	if end < start {
+		return nil, fmt.Errorf("invalid range [%d, %d)", start, end)
+	}
+	keyCount, err := binary.ReadUvarint(reader)
+	if err != nil {
+		return nil, fmt.Errorf("reading key count: %w", err)
+	}
+
+	idx := NewIndex(block.NewRange(start, end))
+	wordsPerKey := wordCount(idx.Range)
+	word := make([]byte, 8)
+	for k := uint64(0); k < keyCount; k++ {
+		keyLen, err := binary.ReadUvarint(reader)
+		if err != nil {
+			return nil, fmt.Errorf("reading key %d length: %w", k, err)
+		}
+		if keyLen > uint64(reader.Len()) {
+			return nil, fmt.Errorf("reading key %d: length %d overflows data", k, keyLen)
+		}
+		key := make([]byte, keyLen)
+		if _, err := io.ReadFull(reader, key); err != nil {
+			return nil, fmt.Errorf("reading key %d: %w", k, err)
+		}
+
+		bitmap := NewBitmap(idx.Range)
+		for w := 0; w < wordsPerKey; w++ {
+			if _, err := io.ReadFull(reader, word); err != nil {
+				return nil, fmt.Errorf("reading bitmap of key %q: %w", key, err)
+			}
+			bitmap.words[w] = binary.LittleEndian.Uint64(word)
+		}
+		idx.keys[string(key)] = bitmap
+	}
+
+	return idx, nil
+}
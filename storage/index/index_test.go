@@ -0,0 +1,145 @@
+package index
+
+import (
+	"context"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamingfast/substreams/block"
+	pbindex "github.com/streamingfast/substreams/pb/sf/substreams/index/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func TestIndex_MarshalRoundTrip(t *testing.T) {
+	idx := NewIndex(block.NewRange(1000, 1130))
+	idx.Add(1000, []string{"transfer", "contract:0xa0b8"})
+	idx.Add(1064, []string{"transfer"})
+	idx.Add(1129, []string{"approval"})
+
+	decoded, err := Unmarshal(idx.Marshal())
+	require.NoError(t, err)
+
+	assert.Equal(t, idx.Range, decoded.Range)
+	assert.Equal(t, 3, decoded.KeyCount())
+	assert.Equal(t, idx.Marshal(), decoded.Marshal())
+	assert.True(t, decoded.Get("transfer").Has(1064))
+	assert.True(t, decoded.Get("approval").Has(1129))
+	assert.False(t, decoded.Get("approval").Has(1000))
+
+	_, err = Unmarshal([]byte("garbage"))
+	require.Error(t, err)
+}
+
+func TestConfigs_ExecutionBitmap(t *testing.T) {
+	ctx := context.Background()
+	objStore := dstore.NewMockStore(nil)
+
+	indexModule := &pbsubstreams.Module{
+		Name:         "index_transfers",
+		InitialBlock: 10,
+		Kind:         &pbsubstreams.Module_KindBlockIndex_{KindBlockIndex: &pbsubstreams.Module_KindBlockIndex{}},
+	}
+	filteredModule := func(query string) *pbsubstreams.Module {
+		return &pbsubstreams.Module{
+			Name: "map_transfers",
+			Kind: &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{}},
+			Inputs: []*pbsubstreams.Module_Input{{
+				Input:       &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "sf.test.Block"}},
+				BlockFilter: &pbsubstreams.Module_Input_BlockFilter{Module: "index_transfers", Query: query},
+			}},
+		}
+	}
+
+	configs, err := NewConfigs(objStore, []*pbsubstreams.Module{indexModule, filteredModule("transfer")}, func(string) string { return "abc" }, 100, zap.NewNop())
+	require.NoError(t, err)
+	require.Equal(t, []string{"index_transfers"}, configs.ModuleNames())
+
+	bitmap, err := configs.ExecutionBitmap(ctx, filteredModule("transfer"), block.NewRange(10, 100))
+	require.NoError(t, err)
+	assert.Nil(t, bitmap, "no index file written yet")
+
+	writer := NewWriter(block.NewRange(10, 100), configs.ModuleNames(), configs)
+	require.NoError(t, writer.Close(ctx))
+	_, found, err := configs.ConfigMap["index_transfers"].findFile(ctx, block.NewRange(10, 100))
+	require.NoError(t, err)
+	assert.False(t, found, "nothing written when no block was seen")
+
+	idx := NewIndex(block.NewRange(10, 100))
+	idx.Add(42, []string{"transfer"})
+	require.NoError(t, configs.ConfigMap["index_transfers"].Save(ctx, idx))
+
+	bitmap, err = configs.ExecutionBitmap(ctx, filteredModule("transfer"), block.NewRange(10, 100))
+	require.NoError(t, err)
+	require.NotNil(t, bitmap)
+	assert.Equal(t, 1, bitmap.Count())
+	assert.True(t, bitmap.Has(42))
+
+	bitmap, err = configs.ExecutionBitmap(ctx, filteredModule("approval"), block.NewRange(10, 100))
+	require.NoError(t, err)
+	require.NotNil(t, bitmap)
+	assert.True(t, bitmap.IsEmpty())
+
+	// blocks before the index module's initial block have no keys
+	bitmap, err = configs.ExecutionBitmap(ctx, filteredModule("!transfer"), block.NewRange(0, 100))
+	require.NoError(t, err)
+	require.NotNil(t, bitmap)
+	assert.Equal(t, 99, bitmap.Count())
+	assert.True(t, bitmap.Has(0))
+	assert.False(t, bitmap.Has(42))
+}
+
+type testOutputs map[string][]byte
+
+func (o testOutputs) Get(name string) ([]byte, bool, error) {
+	return o[name], false, nil
+}
+
+func TestWriter_CheckpointResume(t *testing.T) {
+	ctx := context.Background()
+	objStore := dstore.NewMockStore(nil)
+	indexModule := &pbsubstreams.Module{
+		Name: "index_transfers",
+		Kind: &pbsubstreams.Module_KindBlockIndex_{KindBlockIndex: &pbsubstreams.Module_KindBlockIndex{}},
+	}
+	configs, err := NewConfigs(objStore, []*pbsubstreams.Module{indexModule}, func(string) string { return "abc" }, 100, zap.NewNop())
+	require.NoError(t, err)
+	conf := configs.ConfigMap["index_transfers"]
+
+	transfer, err := proto.Marshal(&pbindex.Keys{Keys: []string{"transfer"}})
+	require.NoError(t, err)
+
+	jobRange := block.NewRange(0, 100)
+	writer := NewWriter(jobRange, configs.ModuleNames(), configs)
+	require.NoError(t, writer.Write(&pbsubstreams.Clock{Number: 12}, testOutputs{"index_transfers": transfer}))
+	require.NoError(t, writer.Checkpoint(ctx, 20, "trace"))
+	require.NoError(t, writer.Write(&pbsubstreams.Clock{Number: 25}, testOutputs{"index_transfers": transfer}))
+	require.NoError(t, writer.Checkpoint(ctx, 30, "trace"))
+
+	exists, err := conf.checkpointStore.FileExists(ctx, checkpointFilename(0, 20, "trace"))
+	require.NoError(t, err)
+	assert.False(t, exists, "previous checkpoint deleted")
+
+	// the retry resumes from the last checkpoint, block 12 and 25 are kept
+	resumed := NewWriter(jobRange, configs.ModuleNames(), configs)
+	require.NoError(t, resumed.Resume(ctx, 30, "trace"))
+	require.NoError(t, resumed.Write(&pbsubstreams.Clock{Number: 31}, testOutputs{"index_transfers": transfer}))
+	require.NoError(t, resumed.Close(ctx))
+	resumed.DeleteCheckpoints(ctx)
+
+	idx, err := conf.Load(ctx, jobRange)
+	require.NoError(t, err)
+	require.NotNil(t, idx)
+	assert.Equal(t, 3, idx.Get("transfer").Count())
+	assert.True(t, idx.Get("transfer").Has(12))
+	assert.True(t, idx.Get("transfer").Has(25))
+	assert.True(t, idx.Get("transfer").Has(31))
+
+	exists, err = conf.checkpointStore.FileExists(ctx, checkpointFilename(0, 30, "trace"))
+	require.NoError(t, err)
+	assert.False(t, exists, "checkpoint deleted once completed")
+}
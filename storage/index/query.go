@@ -0,0 +1,186 @@
+package index
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Query is a boolean expression over the keys emitted by a block index
+// module, as found in the `block_filter` of a module's input. Keys are
+// combined with `&&`, `||`, `!` and parentheses, `&&` binding tighter
+// than `||`, ex: `transfer && (contract:0xa0b8 || contract:0xdac1)`.
+type Query struct {
+	raw  string
+	root queryNode
+}
+
+func ParseQuery(in string) (*Query, error) {
+	p := &queryParser{tokens: tokenize(in)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, fmt.Errorf("parsing query %q: %w", in, err)
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("parsing query %q: unexpected %q", in, p.peek())
+	}
+
+	return &Query{raw: in, root: root}, nil
+}
+
+func (q *Query) String() string {
+	return q.raw
+}
+
+// Matches evaluates the query against the keys emitted for a single block.
+func (q *Query) Matches(keys []string) bool {
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[key] = true
+	}
+	return q.root.matches(set)
+}
+
+// Bitmap evaluates the query against every block covered by `idx`.
+func (q *Query) Bitmap(idx *Index) *Bitmap {
+	return q.root.bitmap(idx)
+}
+
+type queryNode interface {
+	matches(keys map[string]bool) bool
+	bitmap(idx *Index) *Bitmap
+}
+
+type keyNode string
+
+func (n keyNode) matches(keys map[string]bool) bool { return keys[string(n)] }
+func (n keyNode) bitmap(idx *Index) *Bitmap         { return idx.Get(string(n)) }
+
+type notNode struct{ operand queryNode }
+
+func (n notNode) matches(keys map[string]bool) bool { return !n.operand.matches(keys) }
+func (n notNode) bitmap(idx *Index) *Bitmap         { return n.operand.bitmap(idx).Not() }
+
+type andNode struct{ left, right queryNode }
+
+func (n andNode) matches(keys map[string]bool) bool {
+	return n.left.matches(keys) && n.right.matches(keys)
+}
+func (n andNode) bitmap(idx *Index) *Bitmap { return n.left.bitmap(idx).And(n.right.bitmap(idx)) }
+
+type orNode struct{ left, right queryNode }
+
+func (n orNode) matches(keys map[string]bool) bool {
+	return n.left.matches(keys) || n.right.matches(keys)
+}
+func (n orNode) bitmap(idx *Index) *Bitmap { return n.left.bitmap(idx).Or(n.right.bitmap(idx)) }
+
+func tokenize(in string) (out []string) {
+	var current strings.Builder
+	flush := func() {
+		if current.Len() != 0 {
+			out = append(out, current.String())
+			current.Reset()
+		}
+	}
+
+	runes := []rune(in)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case r == '(' || r == ')' || r == '!':
+			flush()
+			out = append(out, string(r))
+		case (r == '&' || r == '|') && i+1 < len(runes) && runes[i+1] == r:
+			flush()
+			out = append(out, string([]rune{r, r}))
+			i++
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return
+}
+
+type queryParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *queryParser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *queryParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseUnary() (queryNode, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+
+	token := p.tokens[p.pos]
+	p.pos++
+	switch token {
+	case "!":
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand}, nil
+	case "(":
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return inner, nil
+	case ")", "&&", "||":
+		return nil, fmt.Errorf("unexpected %q", token)
+	}
+	return keyNode(token), nil
+}
@@ -0,0 +1,105 @@
+package index
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/streamingfast/substreams/block"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		query     string
+		expectErr string
+	}{
+		{query: "transfer"},
+		{query: "transfer && contract:0xa0b8"},
+		{query: "transfer&&(contract:0xa0b8||contract:0xdac1)"},
+		{query: "!approval && !(a || b)"},
+		{query: "", expectErr: "empty query"},
+		{query: "transfer &&", expectErr: `parsing query "transfer &&": unexpected end of query`},
+		{query: "(transfer", expectErr: `parsing query "(transfer": missing closing parenthesis`},
+		{query: "transfer approval", expectErr: `parsing query "transfer approval": unexpected "approval"`},
+		{query: "a & b", expectErr: `parsing query "a & b": unexpected "&"`},
+		{query: "|| a", expectErr: `parsing query "|| a": unexpected "||"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := ParseQuery(tt.query)
+			if tt.expectErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tt.expectErr)
+		})
+	}
+}
+
+func TestQuery_Matches(t *testing.T) {
+	tests := []struct {
+		query  string
+		keys   []string
+		expect bool
+	}{
+		{"transfer", []string{"transfer"}, true},
+		{"transfer", []string{"approval"}, false},
+		{"transfer", nil, false},
+		{"!transfer", nil, true},
+		{"a || b && c", []string{"a"}, true},
+		{"a || b && c", []string{"b"}, false},
+		{"(a || b) && c", []string{"a"}, false},
+		{"(a || b) && c", []string{"b", "c"}, true},
+		{"a && !b", []string{"a", "b"}, false},
+		{"!!a", []string{"a"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := ParseQuery(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expect, query.Matches(tt.keys))
+		})
+	}
+}
+
+func TestQuery_Bitmap(t *testing.T) {
+	idx := NewIndex(block.NewRange(100, 200))
+	idx.Add(100, []string{"a"})
+	idx.Add(110, []string{"a", "b"})
+	idx.Add(150, []string{"b"})
+	idx.Add(199, []string{"c"})
+
+	tests := []struct {
+		query  string
+		expect []uint64
+	}{
+		{"a", []uint64{100, 110}},
+		{"a && b", []uint64{110}},
+		{"a || c", []uint64{100, 110, 199}},
+		{"b && !a", []uint64{150}},
+		{"unknown", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := ParseQuery(tt.query)
+			require.NoError(t, err)
+
+			bitmap := query.Bitmap(idx)
+			var blocks []uint64
+			for blockNum := uint64(100); blockNum < 200; blockNum++ {
+				if bitmap.Has(blockNum) {
+					blocks = append(blocks, blockNum)
+				}
+			}
+			assert.Equal(t, tt.expect, blocks)
+		})
+	}
+
+	notA, err := ParseQuery("!a")
+	require.NoError(t, err)
+	assert.Equal(t, 98, notA.Bitmap(idx).Count())
+}
@@ -0,0 +1,119 @@
+package index
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamingfast/substreams/block"
+	pbindex "github.com/streamingfast/substreams/pb/sf/substreams/index/v1"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+type outputGetter interface {
+	Get(name string) (value []byte, cached bool, err error)
+}
+
+// The Writer accumulates the keys emitted by block index modules over the
+// range of a tier2 job, and writes one index file per module on Close.
+type Writer struct {
+	configs *Configs
+	indexes map[string]*Index
+	written bool
+
+	// checkpoints are the names of the last checkpoints of the indexes, by
+	// module, deleted once superseded.
+	checkpoints map[string]string
+}
+
+func NewWriter(blockRange *block.Range, moduleNames []string, configs *Configs) *Writer {
+	indexes := make(map[string]*Index, len(moduleNames))
+	for _, name := range moduleNames {
+		indexes[name] = NewIndex(blockRange)
+	}
+	return &Writer{
+		configs: configs,
+		indexes: indexes,
+	}
+}
+
+// Write indexes the keys found in `outputs` for the block of `clock`. A
+// module without output for the block (not executed, or before its initial
+// block) is indexed as having emitted no keys.
+func (w *Writer) Write(clock *pbsubstreams.Clock, outputs outputGetter) error {
+	w.written = true
+	for name, idx := range w.indexes {
+		data, _, err := outputs.Get(name)
+		if err != nil || len(data) == 0 {
+			continue
+		}
+
+		keys := &pbindex.Keys{}
+		if err := proto.Unmarshal(data, keys); err != nil {
+			return fmt.Errorf("unmarshalling keys of block index %q at block %d: %w", name, clock.Number, err)
+		}
+		idx.Add(clock.Number, keys.Keys)
+	}
+	return nil
+}
+
+// Checkpoint saves the indexes, holding the keys of the blocks before
+// `blockNum`, as a checkpoint for the tier2 job of `traceID` to resume from
+// it. The previous checkpoint is deleted.
+func (w *Writer) Checkpoint(ctx context.Context, blockNum uint64, traceID string) error {
+	checkpoints := make(map[string]string, len(w.indexes))
+	for name, idx := range w.indexes {
+		filename, err := w.configs.ConfigMap[name].SaveCheckpoint(ctx, idx, blockNum, traceID)
+		if err != nil {
+			return fmt.Errorf("saving block index %q checkpoint: %w", name, err)
+		}
+		checkpoints[name] = filename
+	}
+
+	w.DeleteCheckpoints(ctx)
+	w.checkpoints = checkpoints
+	return nil
+}
+
+// Resume loads the indexes from the checkpoint saved at `blockNum` by the
+// tier2 job of `traceID`. The writer is left untouched if any of the
+// checkpoints cannot be loaded.
+func (w *Writer) Resume(ctx context.Context, blockNum uint64, traceID string) error {
+	indexes := make(map[string]*Index, len(w.indexes))
+	checkpoints := make(map[string]string, len(w.indexes))
+	for name, idx := range w.indexes {
+		loaded, filename, err := w.configs.ConfigMap[name].LoadCheckpoint(ctx, idx.StartBlock, blockNum, traceID)
+		if err != nil {
+			return fmt.Errorf("resuming block index %q: %w", name, err)
+		}
+		indexes[name] = loaded
+		checkpoints[name] = filename
+	}
+
+	w.indexes = indexes
+	w.checkpoints = checkpoints
+	w.written = true
+	return nil
+}
+
+// DeleteCheckpoints deletes the last checkpoint saved, or resumed from.
+func (w *Writer) DeleteCheckpoints(ctx context.Context) {
+	for name, filename := range w.checkpoints {
+		w.configs.ConfigMap[name].DeleteCheckpoint(ctx, filename)
+	}
+	w.checkpoints = nil
+}
+
+// Close saves the index files, unless no block was written at all.
+func (w *Writer) Close(ctx context.Context) error {
+	if !w.written {
+		return nil
+	}
+	for name, idx := range w.indexes {
+		if err := w.configs.ConfigMap[name].Save(ctx, idx); err != nil {
+			return fmt.Errorf("saving block index %q: %w", name, err)
+		}
+	}
+	return nil
+}
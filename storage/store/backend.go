@@ -0,0 +1,141 @@
+package store
+
+import (
+	"sort"
+	"strings"
+)
+
+// StoreBackend holds the key/value state of a store, all deltas being
+// already applied to it. Implementations are not safe for concurrent use,
+// and `f` must not modify the backend while it is being iterated.
+type StoreBackend interface {
+	Get(key string) ([]byte, bool)
+	Set(key string, value []byte)
+	Delete(key string)
+	Len() uint64
+
+	// Iter calls `f` for each key of the backend, in no particular order.
+	Iter(f func(key string, value []byte) error) error
+	// IterPrefix calls `f` for each key starting with `prefix`, in no
+	// particular order.
+	IterPrefix(prefix string, f func(key string, value []byte) error) error
+	// IterRange calls `f` for each key between `low` (inclusive) and `high`
+	// (exclusive), in key order. An empty `high` means no upper bound.
+	IterRange(low, high string, f func(key string, value []byte) error) error
+
+	// Reset removes all keys.
+	Reset()
+	// Close releases the resources held by the backend, which cannot be
+	// used anymore afterwards.
+	Close() error
+}
+
+// BackendFactory creates the StoreBackend of each store instance.
+type BackendFactory func() StoreBackend
+
+// MapBackend is the default StoreBackend, keeping the whole state in memory.
+type MapBackend struct {
+	kv map[string][]byte
+	// sortedKeys are the keys in order, for IterRange, kept until a key is
+	// added or removed so that paging through the state sorts it only once.
+	sortedKeys []string
+}
+
+func NewMapBackend() StoreBackend {
+	return newMapBackend(nil)
+}
+
+func newMapBackend(kv map[string][]byte) *MapBackend {
+	if kv == nil {
+		kv = make(map[string][]byte)
+	}
+	return &MapBackend{kv: kv}
+}
+
+func (m *MapBackend) Get(key string) ([]byte, bool) {
+	val, found := m.kv[key]
+	return val, found
+}
+
+func (m *MapBackend) Set(key string, value []byte) {
+	if _, found := m.kv[key]; !found {
+		m.sortedKeys = nil
+	}
+	m.kv[key] = value
+}
+
+func (m *MapBackend) Delete(key string) {
+	if _, found := m.kv[key]; found {
+		m.sortedKeys = nil
+	}
+	delete(m.kv, key)
+}
+
+func (m *MapBackend) Len() uint64 { return uint64(len(m.kv)) }
+
+func (m *MapBackend) Iter(f func(key string, value []byte) error) error {
+	for k, v := range m.kv {
+		if err := f(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MapBackend) IterPrefix(prefix string, f func(key string, value []byte) error) error {
+	for k, v := range m.kv {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if err := f(k, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MapBackend) IterRange(low, high string, f func(key string, value []byte) error) error {
+	if m.sortedKeys == nil {
+		m.sortedKeys = make([]string, 0, len(m.kv))
+		for k := range m.kv {
+			m.sortedKeys = append(m.sortedKeys, k)
+		}
+		sort.Strings(m.sortedKeys)
+	}
+
+	for _, k := range m.sortedKeys[sort.SearchStrings(m.sortedKeys, low):] {
+		if high != "" && k >= high {
+			break
+		}
+		if err := f(k, m.kv[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *MapBackend) Reset() {
+	for k := range m.kv {
+		delete(m.kv, k)
+	}
+	m.sortedKeys = nil
+}
+
+func (m *MapBackend) Close() error { return nil }
+
+func inRange(key, low, high string) bool {
+	return key >= low && (high == "" || key < high)
+}
+
+// prefixUpperBound returns the smallest key greater than all the keys
+// starting with `prefix`, or "" when there is none.
+func prefixUpperBound(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+	return ""
+}
@@ -0,0 +1,606 @@
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultMemtableSizeLimit is the amount of recent writes kept in memory
+// by a DiskBackend when no limit is configured.
+const DefaultMemtableSizeLimit = 64 * 1024 * 1024
+
+const (
+	// maxSortedRuns is the number of run files after which they are all
+	// compacted into a single one.
+	maxSortedRuns = 8
+	// runBlockSize is the amount of data between two keys of the sparse
+	// index of a run file, which is what a `Get` reads from disk.
+	runBlockSize = 4096
+	// diskEntryOverhead approximates the memory used by a memtable entry on
+	// top of its key and value.
+	diskEntryOverhead = 64
+)
+
+// DiskBackend is a StoreBackend keeping the most recent writes in an
+// in-memory memtable, spilled to a sorted run file once it grows past
+// `memtableSizeLimit` bytes, much like a minimal LSM tree. Run files live in
+// their own directory under `baseDir`, created on the first spill, and are
+// compacted together when they accumulate.
+//
+// Failing to read or write run files panics, like the other store failures,
+// which the pipeline turns into an error for the request.
+type DiskBackend struct {
+	baseDir           string
+	dir               string
+	memtableSizeLimit uint64
+
+	memtable     map[string]diskEntry
+	memtableSize uint64
+	runs         []*sortedRun // oldest first
+	nextRunID    uint64
+	length       uint64
+}
+
+type diskEntry struct {
+	value   []byte
+	deleted bool
+}
+
+func NewDiskBackendFactory(baseDir string, memtableSizeLimit uint64) BackendFactory {
+	return func() StoreBackend {
+		return NewDiskBackend(baseDir, memtableSizeLimit)
+	}
+}
+
+func NewDiskBackend(baseDir string, memtableSizeLimit uint64) *DiskBackend {
+	return &DiskBackend{
+		baseDir:           baseDir,
+		memtableSizeLimit: memtableSizeLimit,
+		memtable:          make(map[string]diskEntry),
+	}
+}
+
+func (d *DiskBackend) Get(key string) ([]byte, bool) {
+	if entry, found := d.memtable[key]; found {
+		return entry.value, !entry.deleted
+	}
+
+	for i := len(d.runs) - 1; i >= 0; i-- {
+		entry, found, err := d.runs[i].get(key)
+		if err != nil {
+			panic(fmt.Errorf("reading store run file %s: %w", d.runs[i].path, err))
+		}
+		if found {
+			return entry.value, !entry.deleted
+		}
+	}
+	return nil, false
+}
+
+func (d *DiskBackend) Set(key string, value []byte) {
+	if _, found := d.Get(key); !found {
+		d.length++
+	}
+	d.put(key, diskEntry{value: value})
+}
+
+func (d *DiskBackend) Delete(key string) {
+	if _, found := d.Get(key); !found {
+		return
+	}
+	d.length--
+
+	if len(d.runs) == 0 {
+		d.memtableSize -= entrySize(key, d.memtable[key])
+		delete(d.memtable, key)
+		return
+	}
+	d.put(key, diskEntry{deleted: true})
+}
+
+func (d *DiskBackend) Len() uint64 {
+	return d.length
+}
+
+func (d *DiskBackend) Iter(f func(key string, value []byte) error) error {
+	return d.IterPrefix("", f)
+}
+
+// IterPrefix calls `f` for each key starting with `prefix`, in key order.
+func (d *DiskBackend) IterPrefix(prefix string, f func(key string, value []byte) error) error {
+	return d.IterRange(prefix, prefixUpperBound(prefix), f)
+}
+
+func (d *DiskBackend) IterRange(low, high string, f func(key string, value []byte) error) error {
+	sources := []entryIterator{newMemtableIterator(d.memtable, low, high)}
+	for i := len(d.runs) - 1; i >= 0; i-- {
+		it, err := d.runs[i].iterator(low, high)
+		if err != nil {
+			return fmt.Errorf("iterating store run file %s: %w", d.runs[i].path, err)
+		}
+		sources = append(sources, it)
+	}
+
+	return mergeEntries(sources, func(key string, entry diskEntry) error {
+		if entry.deleted {
+			return nil
+		}
+		return f(key, entry.value)
+	})
+}
+
+func (d *DiskBackend) Reset() {
+	if err := d.removeRuns(d.runs); err != nil {
+		panic(err)
+	}
+	d.runs = nil
+	d.memtable = make(map[string]diskEntry)
+	d.memtableSize = 0
+	d.length = 0
+}
+
+func (d *DiskBackend) Close() error {
+	if err := d.removeRuns(d.runs); err != nil {
+		return err
+	}
+	d.runs = nil
+	d.memtable = nil
+
+	if d.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(d.dir)
+}
+
+func (d *DiskBackend) put(key string, entry diskEntry) {
+	if prev, found := d.memtable[key]; found {
+		d.memtableSize -= entrySize(key, prev)
+	}
+	d.memtable[key] = entry
+	d.memtableSize += entrySize(key, entry)
+
+	if d.memtableSize >= d.memtableSizeLimit {
+		if err := d.flush(); err != nil {
+			panic(fmt.Errorf("spilling store to disk: %w", err))
+		}
+	}
+}
+
+// flush writes the memtable to a new run file, compacting the run files if
+// there are too many of them.
+func (d *DiskBackend) flush() error {
+	if len(d.memtable) == 0 {
+		return nil
+	}
+
+	// tombstones are only needed to shadow keys of older runs
+	keepDeleted := len(d.runs) != 0
+	run, err := d.writeRun(uint64(len(d.memtable)), []entryIterator{newMemtableIterator(d.memtable, "", "")}, keepDeleted)
+	if err != nil {
+		return err
+	}
+
+	d.runs = append(d.runs, run)
+	d.memtable = make(map[string]diskEntry)
+	d.memtableSize = 0
+
+	if len(d.runs) > maxSortedRuns {
+		return d.compact()
+	}
+	return nil
+}
+
+// compact merges all the run files into a single one, dropping deleted keys.
+func (d *DiskBackend) compact() error {
+	var keyCount uint64
+	var sources []entryIterator
+	for i := len(d.runs) - 1; i >= 0; i-- {
+		it, err := d.runs[i].iterator("", "")
+		if err != nil {
+			return fmt.Errorf("iterating store run file %s: %w", d.runs[i].path, err)
+		}
+		sources = append(sources, it)
+		keyCount += d.runs[i].keyCount
+	}
+
+	run, err := d.writeRun(keyCount, sources, false)
+	if err != nil {
+		return err
+	}
+
+	if err := d.removeRuns(d.runs); err != nil {
+		return err
+	}
+	d.runs = []*sortedRun{run}
+	return nil
+}
+
+func (d *DiskBackend) writeRun(expectedKeyCount uint64, sources []entryIterator, keepDeleted bool) (*sortedRun, error) {
+	if d.dir == "" {
+		if err := os.MkdirAll(d.baseDir, 0755); err != nil {
+			return nil, fmt.Errorf("creating store backend directory: %w", err)
+		}
+		dir, err := os.MkdirTemp(d.baseDir, "store-")
+		if err != nil {
+			return nil, fmt.Errorf("creating store backend directory: %w", err)
+		}
+		d.dir = dir
+	}
+
+	path := filepath.Join(d.dir, fmt.Sprintf("%06d.run", d.nextRunID))
+	d.nextRunID++
+
+	w, err := newRunWriter(path, expectedKeyCount)
+	if err != nil {
+		return nil, err
+	}
+
+	err = mergeEntries(sources, func(key string, entry diskEntry) error {
+		if entry.deleted && !keepDeleted {
+			return nil
+		}
+		return w.write(key, entry)
+	})
+	if err != nil {
+		w.abort()
+		return nil, fmt.Errorf("writing store run file %s: %w", path, err)
+	}
+
+	return w.close()
+}
+
+func (d *DiskBackend) removeRuns(runs []*sortedRun) error {
+	var errs []error
+	for _, run := range runs {
+		errs = append(errs, run.file.Close(), os.Remove(run.path))
+	}
+	return errors.Join(errs...)
+}
+
+func entrySize(key string, entry diskEntry) uint64 {
+	return uint64(len(key)+len(entry.value)) + diskEntryOverhead
+}
+
+// sortedRun is an immutable file of entries sorted by key. Each record is
+// made of the key length (uvarint), the key, a deleted flag byte, the value
+// length (uvarint) and the value.
+type sortedRun struct {
+	path     string
+	file     *os.File
+	size     int64
+	keyCount uint64
+
+	blocks []runBlock
+	bloom  bloomFilter
+}
+
+type runBlock struct {
+	firstKey string
+	offset   int64
+}
+
+func (r *sortedRun) get(key string) (diskEntry, bool, error) {
+	if !r.bloom.mayContain(key) {
+		return diskEntry{}, false, nil
+	}
+
+	idx := sort.Search(len(r.blocks), func(i int) bool { return r.blocks[i].firstKey > key }) - 1
+	if idx < 0 {
+		return diskEntry{}, false, nil
+	}
+
+	end := r.size
+	if idx+1 < len(r.blocks) {
+		end = r.blocks[idx+1].offset
+	}
+	data := make([]byte, end-r.blocks[idx].offset)
+	if _, err := r.file.ReadAt(data, r.blocks[idx].offset); err != nil {
+		return diskEntry{}, false, err
+	}
+
+	for len(data) > 0 {
+		recordKey, entry, n, err := decodeRecord(data)
+		if err != nil {
+			return diskEntry{}, false, err
+		}
+		data = data[n:]
+
+		if recordKey == key {
+			return entry, true, nil
+		}
+		if recordKey > key {
+			break
+		}
+	}
+	return diskEntry{}, false, nil
+}
+
+func (r *sortedRun) iterator(low, high string) (*runIterator, error) {
+	idx := sort.Search(len(r.blocks), func(i int) bool { return r.blocks[i].firstKey >= low }) - 1
+	if idx < 0 {
+		idx = 0
+	}
+
+	var offset int64
+	if len(r.blocks) != 0 {
+		offset = r.blocks[idx].offset
+	}
+
+	return &runIterator{
+		reader: bufio.NewReader(io.NewSectionReader(r.file, offset, r.size-offset)),
+		low:    low,
+		high:   high,
+	}, nil
+}
+
+type runWriter struct {
+	path   string
+	file   *os.File
+	writer *bufio.Writer
+	run    *sortedRun
+
+	offset     int64
+	blockStart int64
+	buf        []byte
+}
+
+func newRunWriter(path string, expectedKeyCount uint64) (*runWriter, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("creating store run file: %w", err)
+	}
+
+	return &runWriter{
+		path:       path,
+		file:       file,
+		writer:     bufio.NewWriter(file),
+		blockStart: -1,
+		run: &sortedRun{
+			path:  path,
+			file:  file,
+			bloom: newBloomFilter(expectedKeyCount),
+		},
+	}, nil
+}
+
+func (w *runWriter) write(key string, entry diskEntry) error {
+	if w.blockStart < 0 || w.offset-w.blockStart >= runBlockSize {
+		w.run.blocks = append(w.run.blocks, runBlock{firstKey: key, offset: w.offset})
+		w.blockStart = w.offset
+	}
+
+	w.buf = binary.AppendUvarint(w.buf[:0], uint64(len(key)))
+	w.buf = append(w.buf, key...)
+	if entry.deleted {
+		w.buf = append(w.buf, 1)
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+	w.buf = binary.AppendUvarint(w.buf, uint64(len(entry.value)))
+	w.buf = append(w.buf, entry.value...)
+
+	if _, err := w.writer.Write(w.buf); err != nil {
+		return err
+	}
+
+	w.offset += int64(len(w.buf))
+	w.run.keyCount++
+	w.run.bloom.add(key)
+	return nil
+}
+
+func (w *runWriter) close() (*sortedRun, error) {
+	if err := w.writer.Flush(); err != nil {
+		w.abort()
+		return nil, fmt.Errorf("flushing store run file %s: %w", w.path, err)
+	}
+	w.run.size = w.offset
+	return w.run, nil
+}
+
+func (w *runWriter) abort() {
+	w.file.Close()
+	os.Remove(w.path)
+}
+
+func decodeRecord(data []byte) (key string, entry diskEntry, n int, err error) {
+	keyLen, l := binary.Uvarint(data)
+	if l <= 0 || uint64(len(data)-l) < keyLen+1 {
+		return "", entry, 0, io.ErrUnexpectedEOF
+	}
+	n = l
+	key = string(data[n : n+int(keyLen)])
+	n += int(keyLen)
+	entry.deleted = data[n] == 1
+	n++
+
+	valueLen, l := binary.Uvarint(data[n:])
+	if l <= 0 || uint64(len(data)-n-l) < valueLen {
+		return "", entry, 0, io.ErrUnexpectedEOF
+	}
+	n += l
+	if !entry.deleted {
+		entry.value = make([]byte, valueLen)
+		copy(entry.value, data[n:n+int(valueLen)])
+	}
+	n += int(valueLen)
+	return key, entry, n, nil
+}
+
+// entryIterator yields entries in increasing key order, `ok` being false
+// once exhausted.
+type entryIterator interface {
+	next() (key string, entry diskEntry, ok bool, err error)
+}
+
+type memtableIterator struct {
+	memtable map[string]diskEntry
+	keys     []string
+}
+
+func newMemtableIterator(memtable map[string]diskEntry, low, high string) *memtableIterator {
+	var keys []string
+	for k := range memtable {
+		if inRange(k, low, high) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return &memtableIterator{memtable: memtable, keys: keys}
+}
+
+func (it *memtableIterator) next() (string, diskEntry, bool, error) {
+	if len(it.keys) == 0 {
+		return "", diskEntry{}, false, nil
+	}
+	key := it.keys[0]
+	it.keys = it.keys[1:]
+	return key, it.memtable[key], true, nil
+}
+
+type runIterator struct {
+	reader *bufio.Reader
+	low    string
+	high   string
+	buf    []byte
+}
+
+func (it *runIterator) next() (string, diskEntry, bool, error) {
+	for {
+		keyLen, err := binary.ReadUvarint(it.reader)
+		if err == io.EOF {
+			return "", diskEntry{}, false, nil
+		}
+		if err != nil {
+			return "", diskEntry{}, false, err
+		}
+
+		if uint64(cap(it.buf)) < keyLen+1 {
+			it.buf = make([]byte, keyLen+1)
+		}
+		header := it.buf[:keyLen+1]
+		if _, err := io.ReadFull(it.reader, header); err != nil {
+			return "", diskEntry{}, false, err
+		}
+		key := string(header[:keyLen])
+		entry := diskEntry{deleted: header[keyLen] == 1}
+
+		valueLen, err := binary.ReadUvarint(it.reader)
+		if err != nil {
+			return "", diskEntry{}, false, err
+		}
+		value := make([]byte, valueLen)
+		if _, err := io.ReadFull(it.reader, value); err != nil {
+			return "", diskEntry{}, false, err
+		}
+		if !entry.deleted {
+			entry.value = value
+		}
+
+		if it.high != "" && key >= it.high {
+			return "", diskEntry{}, false, nil
+		}
+		if key >= it.low {
+			return key, entry, true, nil
+		}
+	}
+}
+
+// mergeEntries calls `f` once per key found in `sources`, in key order,
+// with the entry of the first source holding it. Sources are thus expected
+// from the newest to the oldest.
+func mergeEntries(sources []entryIterator, f func(key string, entry diskEntry) error) error {
+	type head struct {
+		key   string
+		entry diskEntry
+		ok    bool
+	}
+
+	heads := make([]head, len(sources))
+	advance := func(i int) (err error) {
+		heads[i].key, heads[i].entry, heads[i].ok, err = sources[i].next()
+		return err
+	}
+	for i := range sources {
+		if err := advance(i); err != nil {
+			return err
+		}
+	}
+
+	for {
+		smallest := -1
+		for i, h := range heads {
+			if h.ok && (smallest < 0 || h.key < heads[smallest].key) {
+				smallest = i
+			}
+		}
+		if smallest < 0 {
+			return nil
+		}
+
+		key := heads[smallest].key
+		if err := f(key, heads[smallest].entry); err != nil {
+			return err
+		}
+
+		for i := range heads {
+			if heads[i].ok && heads[i].key == key {
+				if err := advance(i); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// bloomFilter avoids reading a run file for keys it does not contain, which
+// is the common case when creating new keys.
+type bloomFilter []uint64
+
+const (
+	bloomBitsPerKey  = 10
+	bloomHashesCount = 7
+)
+
+func newBloomFilter(keyCount uint64) bloomFilter {
+	bits := max(keyCount*bloomBitsPerKey, 64)
+	return make(bloomFilter, (bits+63)/64)
+}
+
+func (b bloomFilter) add(key string) {
+	h1, h2 := bloomHashes(key)
+	bits := uint64(len(b)) * 64
+	for i := uint64(0); i < bloomHashesCount; i++ {
+		bit := (h1 + i*h2) % bits
+		b[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+func (b bloomFilter) mayContain(key string) bool {
+	h1, h2 := bloomHashes(key)
+	bits := uint64(len(b)) * 64
+	for i := uint64(0); i < bloomHashesCount; i++ {
+		bit := (h1 + i*h2) % bits
+		if b[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes derives the two hashes of the double hashing scheme from the
+// FNV-1a hash of `key`.
+func bloomHashes(key string) (uint64, uint64) {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= 1099511628211
+	}
+	return h, (h>>33 | h<<31) | 1
+}
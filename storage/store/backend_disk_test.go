@@ -0,0 +1,142 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/catalog"
+)
+
+func TestDiskBackend_MatchesMapBackend(t *testing.T) {
+	dir := t.TempDir()
+	disk := NewDiskBackend(dir, 512) // spills every few keys, to exercise runs and compactions
+	expected := newMapBackend(nil)
+
+	rnd := rand.New(rand.NewSource(42))
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key:%02d:%03d", rnd.Intn(10), rnd.Intn(200))
+		switch rnd.Intn(3) {
+		case 0:
+			disk.Delete(key)
+			expected.Delete(key)
+		default:
+			value := []byte(fmt.Sprintf("value-%d", i))
+			disk.Set(key, value)
+			expected.Set(key, value)
+		}
+	}
+
+	require.Greater(t, len(disk.runs), 0, "memtable should have been spilled")
+	assert.LessOrEqual(t, len(disk.runs), maxSortedRuns)
+	assert.Equal(t, expected.Len(), disk.Len())
+
+	for key, value := range expected.kv {
+		actual, found := disk.Get(key)
+		require.True(t, found, key)
+		assert.Equal(t, value, actual, key)
+	}
+	_, found := disk.Get("key:99:999")
+	assert.False(t, found)
+
+	assert.Equal(t, sortedKeys(expected, ""), iterKeys(t, disk, ""))
+	assert.Equal(t, sortedKeys(expected, "key:03:"), iterKeys(t, disk, "key:03:"))
+	assert.Empty(t, iterKeys(t, disk, "unknown"))
+	assert.Equal(t, rangeKeys(t, expected, "key:02:1", "key:05"), rangeKeys(t, disk, "key:02:1", "key:05"))
+	assert.Equal(t, rangeKeys(t, expected, "key:08", ""), rangeKeys(t, disk, "key:08", ""))
+
+	disk.Reset()
+	assert.Equal(t, uint64(0), disk.Len())
+	assert.Empty(t, iterKeys(t, disk, ""))
+
+	require.NoError(t, disk.Close())
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "closing should remove the run files")
+}
+
+func TestFullKV_DiskBackend_SaveLoad(t *testing.T) {
+	var writtenBytes []byte
+	objStore := dstore.NewMockStore(func(base string, f io.Reader) (err error) {
+		if strings.HasPrefix(base, catalog.DirName+"/") {
+			return nil
+		}
+		writtenBytes, err = io.ReadAll(f)
+		return err
+	})
+	objStore.OpenObjectFunc = func(ctx context.Context, name string) (out io.ReadCloser, err error) {
+		return io.NopCloser(bytes.NewBuffer(writtenBytes)), nil
+	}
+
+	config, err := NewConfig("test", 0, "test.module.hash", pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string", objStore, "")
+	require.NoError(t, err)
+	config.UseDiskBackend(t.TempDir(), 256)
+
+	kvs := config.NewFullKV(zap.NewNop())
+	for i := 0; i < 100; i++ {
+		kvs.Set(uint64(i), fmt.Sprintf("key:%03d", i), fmt.Sprintf("value-%d", i))
+	}
+	kvs.DeletePrefix(100, "key:01")
+	require.Equal(t, uint64(90), kvs.Length())
+
+	file, writer, err := kvs.Save(123)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(context.Background()))
+	require.NoError(t, kvs.Close())
+
+	// files spilled to disk are readable by the default in-memory stores
+	inMemory := &FullKV{baseStore: &baseStore{Config: config, kv: newMapBackend(nil), logger: zap.NewNop(), marshaller: kvs.marshaller}}
+	require.NoError(t, inMemory.Load(context.Background(), file))
+	assert.Equal(t, uint64(90), inMemory.Length())
+
+	kvl := config.NewFullKV(zap.NewNop())
+	require.NoError(t, kvl.Load(context.Background(), file))
+	assert.Equal(t, uint64(90), kvl.Length())
+	assert.Equal(t, inMemory.SizeBytes(), kvl.SizeBytes())
+
+	value, found := kvl.GetLast("key:042")
+	require.True(t, found)
+	assert.Equal(t, "value-42", string(value))
+	assert.False(t, kvl.HasLast("key:012"))
+	require.NoError(t, kvl.Close())
+}
+
+func iterKeys(t *testing.T, backend StoreBackend, prefix string) (out []string) {
+	t.Helper()
+	require.NoError(t, backend.IterPrefix(prefix, func(key string, _ []byte) error {
+		out = append(out, key)
+		return nil
+	}))
+	return out
+}
+
+func rangeKeys(t *testing.T, backend StoreBackend, low, high string) (out []string) {
+	t.Helper()
+	require.NoError(t, backend.IterRange(low, high, func(key string, _ []byte) error {
+		out = append(out, key)
+		return nil
+	}))
+	require.NotEmpty(t, out)
+	return out
+}
+
+func sortedKeys(m *MapBackend, prefix string) (out []string) {
+	_ = m.IterPrefix(prefix, func(key string, _ []byte) error {
+		out = append(out, key)
+		return nil
+	})
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapBackend_IterRangeAfterChanges(t *testing.T) {
+	kv := newMapBackend(nil)
+	kv.Set("b", []byte("1"))
+	kv.Set("d", []byte("2"))
+	assert.Equal(t, []string{"b", "d"}, rangeKeys(t, kv, "", ""))
+
+	kv.Set("c", []byte("3"))
+	kv.Set("d", []byte("4"))
+	kv.Delete("b")
+	assert.Equal(t, []string{"c", "d"}, rangeKeys(t, kv, "", ""))
+	assert.Equal(t, []string{"c"}, rangeKeys(t, kv, "bb", "d"))
+
+	value, found := kv.Get("d")
+	require.True(t, found)
+	assert.Equal(t, "4", string(value))
+
+	kv.Reset()
+	kv.Set("a", nil)
+	assert.Equal(t, []string{"a"}, rangeKeys(t, kv, "", ""))
+}
+
+var errPageFull = errors.New("page full")
+
+// BenchmarkMapBackend_PagedScan pages through the whole state, the way
+// successive store scans read it, each page starting after the last key of
+// the previous one.
+func BenchmarkMapBackend_PagedScan(b *testing.B) {
+	const keys, pageSize = 100_000, 100
+
+	kv := NewMapBackend()
+	for i := 0; i < keys; i++ {
+		kv.Set(fmt.Sprintf("key:%06d", i), []byte("value"))
+	}
+
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		low, read := "", 0
+		for {
+			page := 0
+			err := kv.IterRange(low, "", func(key string, _ []byte) error {
+				if page == pageSize {
+					return errPageFull
+				}
+				page++
+				low = key + "\x00"
+				return nil
+			})
+			read += page
+			if err == nil {
+				break
+			}
+			if !errors.Is(err, errPageFull) {
+				b.Fatal(err)
+			}
+		}
+		if read != keys {
+			b.Fatalf("read %d keys, expected %d", read, keys)
+		}
+	}
+}
@@ -1,8 +1,12 @@
 package store
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
 
+	"github.com/streamingfast/dstore"
 	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 	"github.com/streamingfast/substreams/storage/store/marshaller"
@@ -13,7 +17,7 @@ import (
 type baseStore struct {
 	*Config
 
-	kv             map[string][]byte          // kv is the state, and assumes all deltas were already applied to it.
+	kv             StoreBackend               // kv is the state, and assumes all deltas were already applied to it.
 	deltas         []*pbssinternal.StoreDelta // deltas are always deltas for the given block.
 	lastOrdinal    uint64
 	marshaller     marshaller.Marshaller
@@ -34,7 +38,7 @@ func (b *baseStore) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 	enc.AddString("name", b.name)
 	enc.AddString("hash", b.moduleHash)
 	enc.AddUint64("module_initial_block", b.moduleInitialBlock)
-	enc.AddInt("key_count", len(b.kv))
+	enc.AddUint64("key_count", b.kv.Len())
 	enc.AddUint64("total_size_bytes", b.totalSizeBytes)
 
 	return nil
@@ -42,12 +46,17 @@ func (b *baseStore) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 
 func (b *baseStore) Reset() {
 	if tracer.Enabled() {
-		b.logger.Debug("flushing store", zap.Int("delta_count", len(b.deltas)), zap.Int("entry_count", len(b.kv)), zap.Uint64("total_size_bytes", b.totalSizeBytes))
+		b.logger.Debug("flushing store", zap.Int("delta_count", len(b.deltas)), zap.Uint64("entry_count", b.kv.Len()), zap.Uint64("total_size_bytes", b.totalSizeBytes))
 	}
 	b.deltas = nil
 	b.lastOrdinal = 0
 }
 
+// Close releases the resources held by the store's backend.
+func (b *baseStore) Close() error {
+	return b.kv.Close()
+}
+
 func (b *baseStore) bumpOrdinal(ord uint64) {
 	if b.lastOrdinal > ord {
 		panic("cannot Set or Del a value on a state.Builder with an ordinal lower than the previous")
@@ -62,3 +71,119 @@ func (b *baseStore) ValueType() string {
 func (b *baseStore) UpdatePolicy() pbsubstreams.Module_KindStore_UpdatePolicy {
 	return b.updatePolicy
 }
+
+// loadKV replaces the state with the content of `filename` in `store`,
+// returning the delete prefixes and ranges it holds. Stores not kept in
+// memory read the file entry by entry, so it never needs to fit in memory.
+func (b *baseStore) loadKV(ctx context.Context, store dstore.Store, filename string) (deletePrefixes []string, deleteRanges []marshaller.KeyRange, err error) {
+	if _, ok := b.kv.(*MapBackend); ok {
+		data, err := loadStore(ctx, store, filename)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		storeData, size, err := marshaller.ForContent(data, b.marshaller).Unmarshal(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unmarshal store: %w", err)
+		}
+
+		b.kv = newMapBackend(storeData.Kv)
+		b.totalSizeBytes = size
+		return storeData.DeletePrefixes, storeData.DeleteRanges, nil
+	}
+
+	err = loadStoreStream(ctx, store, filename, func(r io.Reader) (err error) {
+		b.kv.Reset()
+		deletePrefixes, deleteRanges, b.totalSizeBytes, err = marshaller.StreamUnmarshal(r, func(key string, value []byte) error {
+			b.kv.Set(key, value)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("unmarshal store: %w", err)
+		}
+		return nil
+	})
+	return deletePrefixes, deleteRanges, err
+}
+
+// newFileWriter returns the fileWriter saving the current state, along with
+// `deletePrefixes` and `deleteRanges`, to `filename`. Stores not kept in
+// memory spill their snapshot to a local file instead of marshalling it in
+// memory.
+func (b *baseStore) newFileWriter(filename string, deletePrefixes []string, deleteRanges []marshaller.KeyRange) (*fileWriter, error) {
+	if kv, ok := b.kv.(*MapBackend); ok {
+		content, err := b.marshaller.Marshal(&marshaller.StoreData{
+			Kv:             kv.kv,
+			DeletePrefixes: deletePrefixes,
+			DeleteRanges:   deleteRanges,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &fileWriter{
+			store:    b.objStore,
+			catalog:  b.catalog,
+			filename: filename,
+			content:  content,
+		}, nil
+	}
+
+	file, err := b.createSpillFile("snapshot-")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err := b.streamSnapshot(file, deletePrefixes, deleteRanges); err != nil {
+		os.Remove(file.Name())
+		return nil, fmt.Errorf("spilling to %s: %w", file.Name(), err)
+	}
+
+	return &fileWriter{
+		store:       b.objStore,
+		catalog:     b.catalog,
+		filename:    filename,
+		contentPath: file.Name(),
+	}, nil
+}
+
+func (b *baseStore) streamSnapshot(w io.Writer, deletePrefixes []string, deleteRanges []marshaller.KeyRange) error {
+	iter := b.kv.Iter
+	if b.snapshotFormat == SnapshotFormatSorted {
+		iter = func(f func(key string, value []byte) error) error {
+			return b.kv.IterRange("", "", f)
+		}
+	}
+	return b.writeSnapshot(w, iter, deletePrefixes, deleteRanges)
+}
+
+// writeSnapshot writes the entries yielded by `iter` as a store file in the
+// configured format, `iter` having to yield them in key order for the
+// sorted format.
+func (c *Config) writeSnapshot(w io.Writer, iter func(f func(key string, value []byte) error) error, deletePrefixes []string, deleteRanges []marshaller.KeyRange) error {
+	if c.snapshotFormat != SnapshotFormatSorted {
+		return marshaller.StreamMarshal(w, iter, deletePrefixes, deleteRanges)
+	}
+
+	sw := marshaller.NewSnapshotWriter(w)
+	if err := iter(sw.Add); err != nil {
+		return err
+	}
+	return sw.Close(deletePrefixes, deleteRanges)
+}
+
+// createSpillFile creates a temporary local file, under the spill directory
+// when stores are kept on disk.
+func (c *Config) createSpillFile(pattern string) (*os.File, error) {
+	if c.spillDir != "" {
+		if err := os.MkdirAll(c.spillDir, 0755); err != nil {
+			return nil, fmt.Errorf("creating spill directory: %w", err)
+		}
+	}
+	file, err := os.CreateTemp(c.spillDir, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("creating spill file: %w", err)
+	}
+	return file, nil
+}
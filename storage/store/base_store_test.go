@@ -74,6 +74,43 @@ func TestStore(t *testing.T) {
 	assert.False(t, found)
 }
 
+func TestStore_ScanAndDeleteRange(t *testing.T) {
+	s := newTestBaseStore(t, pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string", nil)
+
+	s.Set(0, "a:1", "v1")
+	s.Set(0, "a:2", "v2")
+	s.Set(0, "a:3", "v3")
+	s.Set(0, "b:1", "v4")
+	s.Set(0, "c", "v5")
+
+	assert.Equal(t, []KV{{"a:1", []byte("v1")}, {"a:2", []byte("v2")}, {"a:3", []byte("v3")}}, s.ScanPrefix("a:", 0))
+	assert.Equal(t, []KV{{"a:1", []byte("v1")}, {"a:2", []byte("v2")}}, s.ScanPrefix("a:", 2))
+	assert.Equal(t, []KV{{"a:3", []byte("v3")}, {"b:1", []byte("v4")}}, s.ScanRange("a:2\x00", "c", 0))
+	assert.Equal(t, []KV{{"c", []byte("v5")}}, s.ScanRange("b:2", "", 0))
+	assert.Empty(t, s.ScanPrefix("d", 0))
+
+	s.DeleteRange(1, "a:2", "b:1")
+	s.Set(2, "a:2", "v6")
+
+	assert.Equal(t, []KV{{"a:1", []byte("v1")}, {"a:2", []byte("v6")}}, s.ScanPrefix("a:", 0))
+
+	deltas := s.GetDeltas()
+	require.Len(t, deltas, 8)
+	assert.Equal(t, "a:2", deltas[5].Key)
+	assert.Equal(t, "a:3", deltas[6].Key)
+	assert.Equal(t, uint64(1), deltas[6].Ordinal)
+	assert.Equal(t, []byte("v3"), deltas[6].OldValue)
+
+	val, found := s.GetAt(1, "a:2")
+	assert.False(t, found)
+	assert.Nil(t, val)
+	val, found = s.GetAt(0, "a:3")
+	assert.True(t, found)
+	assert.Equal(t, "v3", string(val))
+
+	assert.Panics(t, func() { s.DeleteRange(0, "a", "b") }, "ordinal going backwards")
+}
+
 func TestFileName(t *testing.T) {
 	prefix := fullStateFilePrefix(10000)
 	require.Equal(t, "0000010000", prefix)
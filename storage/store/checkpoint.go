@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/storage/store/marshaller"
+)
+
+// A checkpoint holds the state of a store in the middle of the range of a
+// tier2 job, for a retry of the job to resume from. Checkpoints are written
+// to the `checkpoints` directory of the store's module hash, apart from its
+// snapshots, and are never cataloged.
+
+// SaveCheckpoint saves the state of the store, from its module's initial
+// block up to `endBlock`, as a checkpoint of the tier2 job of `traceID`. It
+// returns the name of the checkpoint.
+func (s *FullKV) SaveCheckpoint(ctx context.Context, endBlock uint64, traceID string) (string, error) {
+	return s.saveCheckpoint(ctx, checkpointFilename(s.moduleInitialBlock, endBlock, traceID), nil, nil)
+}
+
+// LoadCheckpoint replaces the state of the store with the one saved at
+// `endBlock` by the tier2 job of `traceID`. It returns the name of the
+// checkpoint.
+func (s *FullKV) LoadCheckpoint(ctx context.Context, endBlock uint64, traceID string) (string, error) {
+	filename := checkpointFilename(s.moduleInitialBlock, endBlock, traceID)
+	if _, _, err := s.loadKV(ctx, s.checkpointStore, filename); err != nil {
+		return "", fmt.Errorf("load full store %s checkpoint %s: %w", s.name, filename, err)
+	}
+	s.loadedFrom = filename
+	return filename, nil
+}
+
+// SaveCheckpoint saves the state of the store, from its initial block up to
+// `endBlock`, as a checkpoint of the tier2 job of `traceID`. It returns the
+// name of the checkpoint.
+func (p *PartialKV) SaveCheckpoint(ctx context.Context, endBlock uint64, traceID string) (string, error) {
+	return p.saveCheckpoint(ctx, checkpointFilename(p.initialBlock, endBlock, traceID), p.DeletedPrefixes, p.DeletedRanges)
+}
+
+// LoadCheckpoint replaces the state of the store with the one saved at
+// `endBlock` by the tier2 job of `traceID`. It returns the name of the
+// checkpoint.
+func (p *PartialKV) LoadCheckpoint(ctx context.Context, endBlock uint64, traceID string) (string, error) {
+	filename := checkpointFilename(p.initialBlock, endBlock, traceID)
+	deletePrefixes, deleteRanges, err := p.loadKV(ctx, p.checkpointStore, filename)
+	if err != nil {
+		return "", fmt.Errorf("load partial store %s checkpoint %s: %w", p.name, filename, err)
+	}
+	p.DeletedPrefixes = deletePrefixes
+	p.DeletedRanges = deleteRanges
+	for _, prefix := range deletePrefixes {
+		p.seen[prefix] = true
+	}
+	for _, keyRange := range deleteRanges {
+		p.seenRanges[keyRange] = true
+	}
+	p.loadedFrom = filename
+	return filename, nil
+}
+
+func (b *baseStore) saveCheckpoint(ctx context.Context, filename string, deletePrefixes []string, deleteRanges []marshaller.KeyRange) (string, error) {
+	b.logger.Debug("writing store checkpoint", zap.String("filename", filename))
+
+	fw, err := b.newFileWriter(filename, deletePrefixes, deleteRanges)
+	if err != nil {
+		return "", fmt.Errorf("marshal store %s checkpoint: %w", b.name, err)
+	}
+	fw.store = b.checkpointStore
+	fw.catalog = nil
+	if err := fw.Write(ctx); err != nil {
+		return "", fmt.Errorf("writing store %s checkpoint: %w", b.name, err)
+	}
+	return filename, nil
+}
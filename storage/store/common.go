@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 
 	"github.com/streamingfast/dmetering"
 
@@ -26,22 +27,28 @@ func saveStore(ctx context.Context, store dstore.Store, filename string, content
 	})
 }
 
-func loadStore(ctx context.Context, store dstore.Store, filename string) (out []byte, err error) {
+func saveStoreFile(ctx context.Context, store dstore.Store, filename string, path string) (err error) {
 	if cloned, ok := store.(dstore.Clonable); ok {
 		store, err = cloned.Clone(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("cloning store: %w", err)
+			return fmt.Errorf("cloning store: %w", err)
 		}
 		store.SetMeter(dmetering.GetBytesMeter(ctx))
 	}
 
-	err = derr.RetryContext(ctx, 5, func(ctx context.Context) error {
-		r, err := store.OpenObject(ctx, filename)
+	return derr.RetryContext(ctx, 5, func(ctx context.Context) error {
+		f, err := os.Open(path)
 		if err != nil {
-			return fmt.Errorf("opening file: %w", err)
+			return derr.NewFatalError(fmt.Errorf("opening spilled file: %w", err))
 		}
+		defer f.Close()
 
-		defer r.Close()
+		return store.WriteObject(ctx, filename, f)
+	})
+}
+
+func loadStore(ctx context.Context, store dstore.Store, filename string) (out []byte, err error) {
+	err = loadStoreStream(ctx, store, filename, func(r io.Reader) error {
 		data, err := io.ReadAll(r)
 		if err != nil {
 			return fmt.Errorf("reading data: %w", err)
@@ -52,3 +59,25 @@ func loadStore(ctx context.Context, store dstore.Store, filename string) (out []
 	})
 	return out, err
 }
+
+// loadStoreStream calls `f` with the content of `filename`, `f` being
+// called again from the start if reading fails and is retried.
+func loadStoreStream(ctx context.Context, store dstore.Store, filename string, f func(r io.Reader) error) (err error) {
+	if cloned, ok := store.(dstore.Clonable); ok {
+		store, err = cloned.Clone(ctx)
+		if err != nil {
+			return fmt.Errorf("cloning store: %w", err)
+		}
+		store.SetMeter(dmetering.GetBytesMeter(ctx))
+	}
+
+	return derr.RetryContext(ctx, 5, func(ctx context.Context) error {
+		r, err := store.OpenObject(ctx, filename)
+		if err != nil {
+			return fmt.Errorf("opening file: %w", err)
+		}
+
+		defer r.Close()
+		return f(r)
+	})
+}
@@ -3,6 +3,7 @@ package store
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/streamingfast/derr"
 	"github.com/streamingfast/dstore"
@@ -10,6 +11,7 @@ import (
 	"go.uber.org/zap"
 
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/catalog"
 	"github.com/streamingfast/substreams/storage/store/marshaller"
 )
 
@@ -17,6 +19,13 @@ type Config struct {
 	name       string
 	moduleHash string
 	objStore   dstore.Store
+	// checkpointStore holds the checkpoints of the stores being built by
+	// tier2 jobs, apart from their snapshots.
+	checkpointStore dstore.Store
+
+	// catalog records the store files written, it is nil for stores not
+	// identified by a module hash.
+	catalog *catalog.Catalog
 
 	moduleInitialBlock uint64
 	updatePolicy       pbsubstreams.Module_KindStore_UpdatePolicy
@@ -26,6 +35,14 @@ type Config struct {
 	totalSizeLimit uint64
 	itemSizeLimit  uint64
 
+	// newBackend creates the backend holding the state of each store, the
+	// state is kept in memory when nil. spillDir is where snapshots are
+	// staged on disk when saving a store not kept in memory.
+	newBackend BackendFactory
+	spillDir   string
+
+	snapshotFormat SnapshotFormat
+
 	// traceID uniquely identifies the connection ID so that store can be
 	// written to unique filename preventing some races when multiple Substreams
 	// request works on the same range.
@@ -45,12 +62,25 @@ func NewConfig(
 	if err != nil {
 		return nil, fmt.Errorf("creating sub store: %w", err)
 	}
+	checkpointStore, err := store.SubStore(fmt.Sprintf("%s/checkpoints", moduleHash))
+	if err != nil {
+		return nil, fmt.Errorf("creating checkpoint sub store: %w", err)
+	}
+
+	var cat *catalog.Catalog
+	if moduleHash != "" {
+		if cat, err = catalog.New(store, moduleHash, zlog); err != nil {
+			return nil, fmt.Errorf("creating catalog: %w", err)
+		}
+	}
 
 	return &Config{
 		name:               name,
 		updatePolicy:       updatePolicy,
 		valueType:          valueType,
 		objStore:           subStore,
+		checkpointStore:    checkpointStore,
+		catalog:            cat,
 		moduleInitialBlock: moduleInitialBlock,
 		moduleHash:         moduleHash,
 		appendLimit:        8_388_608,     // 8MiB = 8 * 1024 * 1024,
@@ -60,12 +90,34 @@ func NewConfig(
 	}, nil
 }
 
+// UseDiskBackend makes the stores keep their state in a DiskBackend under
+// `dir` instead of in memory. The total size limit is lifted, stores not
+// being bound to the available memory anymore.
+func (c *Config) UseDiskBackend(dir string, memtableSizeLimit uint64) {
+	c.newBackend = NewDiskBackendFactory(dir, memtableSizeLimit)
+	c.spillDir = dir
+	c.totalSizeLimit = 0
+}
+
+// SetSnapshotFormat sets the format of the store files written by the
+// stores. Files in any format can be loaded, whatever the format set.
+func (c *Config) SetSnapshotFormat(format SnapshotFormat) {
+	c.snapshotFormat = format
+}
+
+func (c *Config) newKV() StoreBackend {
+	if c.newBackend == nil {
+		return NewMapBackend()
+	}
+	return c.newBackend()
+}
+
 func (c *Config) newBaseStore(logger *zap.Logger) *baseStore {
 	return &baseStore{
 		Config:     c,
-		kv:         make(map[string][]byte),
+		kv:         c.newKV(),
 		logger:     logger.Named("store").With(zap.String("store_name", c.name), zap.String("module_hash", c.moduleHash)),
-		marshaller: marshaller.Default(),
+		marshaller: c.snapshotFormat.marshaller(),
 	}
 }
 
@@ -89,6 +141,12 @@ func (c *Config) ModuleInitialBlock() uint64 {
 	return c.moduleInitialBlock
 }
 
+// Catalog returns the catalog of the store's module hash, nil if the store
+// is not identified by a module hash.
+func (c *Config) Catalog() *catalog.Catalog {
+	return c.catalog
+}
+
 func (c *Config) NewFullKV(logger *zap.Logger) *FullKV {
 	return &FullKV{c.newBaseStore(logger), "N/A"}
 }
@@ -98,6 +156,7 @@ func (c *Config) NewPartialKV(initialBlock uint64, logger *zap.Logger) *PartialK
 		baseStore:    c.newBaseStore(logger),
 		initialBlock: initialBlock,
 		seen:         make(map[string]bool),
+		seenRanges:   make(map[marshaller.KeyRange]bool),
 	}
 }
 
@@ -118,12 +177,27 @@ func (c *Config) FileSize(ctx context.Context, fileInfo *FileInfo) (int64, error
 	return size, nil
 }
 
+// ListSnapshotFiles lists the store files starting below `below`, as known
+// to the store's catalog, or by listing them if the catalog is unusable.
 func (c *Config) ListSnapshotFiles(ctx context.Context, below uint64) (files []*FileInfo, err error) {
 	if below == 0 {
 		return nil, nil
 	}
 
 	logger := logging.Logger(ctx, zlog)
+	if c.catalog != nil {
+		cataloged, err := c.catalog.Read(ctx)
+		if err == nil {
+			for _, filename := range cataloged.States() {
+				if fileInfo, ok := parseFileName(c.Name(), filename); ok && fileInfo.Range.StartBlock < below {
+					files = append(files, fileInfo)
+				}
+			}
+			return files, nil
+		}
+		logger.Debug("catalog unusable, listing snapshot files", zap.String("store_name", c.name), zap.Error(err))
+	}
+
 	err = derr.RetryContext(ctx, 3, func(ctx context.Context) error {
 		// We need to clear each time we start because a previous retry could have accumulated a partial state
 		files = nil
@@ -149,3 +223,28 @@ func (c *Config) ListSnapshotFiles(ctx context.Context, below uint64) (files []*
 
 	return files, nil
 }
+
+// DeleteCheckpoint deletes the checkpoint `filename`, once superseded. The
+// checkpoints not deleted are left to the garbage collection.
+func (c *Config) DeleteCheckpoint(ctx context.Context, filename string) {
+	if err := c.checkpointStore.DeleteObject(ctx, filename); err != nil && err != dstore.ErrNotFound {
+		zlog.Warn("cannot delete store checkpoint", zap.String("store", c.name), zap.String("filename", filename), zap.Error(err))
+	}
+}
+
+// FileExists returns whether `file` is present in the object store.
+func (c *Config) FileExists(ctx context.Context, file *FileInfo) (bool, error) {
+	return c.objStore.FileExists(ctx, file.Filename)
+}
+
+// CopyFile copies `file`, as is, to the object store of `dest`, the config
+// of the same store module on another object store.
+func (c *Config) CopyFile(ctx context.Context, file *FileInfo, dest *Config) error {
+	err := loadStoreStream(ctx, c.objStore, file.Filename, func(r io.Reader) error {
+		return dest.objStore.WriteObject(ctx, file.Filename, r)
+	})
+	if err != nil {
+		return fmt.Errorf("copying store file %s: %w", file.Filename, err)
+	}
+	return nil
+}
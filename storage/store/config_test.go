@@ -4,11 +4,15 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/streamingfast/dstore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
 )
 
 func TestConfig_ListSnapshotFiles(t *testing.T) {
@@ -51,3 +55,36 @@ func TestConfig_ListSnapshotFiles(t *testing.T) {
 
 	assert.Equal(t, expectedFiles, actualFiles)
 }
+
+func TestConfig_ListSnapshotFiles_Catalog(t *testing.T) {
+	ctx := context.Background()
+	objStore, err := dstore.NewStore("file://"+t.TempDir(), "zst", "zstd", true)
+	require.NoError(t, err)
+
+	c, err := NewConfig("test", 0, "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string", objStore, "")
+	require.NoError(t, err)
+
+	listFilenames := func() (out []string) {
+		files, err := c.ListSnapshotFiles(ctx, 10000)
+		require.NoError(t, err)
+		for _, file := range files {
+			out = append(out, file.Filename)
+		}
+		return out
+	}
+	save := func(endBlock uint64) {
+		_, writer, err := c.NewFullKV(zap.NewNop()).Save(endBlock)
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(ctx))
+	}
+
+	// without a catalog, files are listed
+	save(1000)
+	assert.Equal(t, []string{"0000001000-0000000000.kv"}, listFilenames())
+
+	require.NoError(t, c.Catalog().Ensure(ctx))
+	save(2000)
+	require.NoError(t, c.objStore.WriteObject(ctx, "0000003000-0000000000.kv", strings.NewReader("not recorded")))
+
+	assert.Equal(t, []string{"0000001000-0000000000.kv", "0000002000-0000000000.kv"}, listFilenames())
+}
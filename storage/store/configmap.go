@@ -29,3 +29,19 @@ func NewConfigMap(baseObjectStore dstore.Store, storeModules []*pbsubstreams.Mod
 	}
 	return out, nil
 }
+
+// UseDiskBackend makes the stores of all the configs keep their state on
+// disk, see Config.UseDiskBackend.
+func (m ConfigMap) UseDiskBackend(dir string, memtableSizeLimit uint64) {
+	for _, c := range m {
+		c.UseDiskBackend(dir, memtableSizeLimit)
+	}
+}
+
+// SetSnapshotFormat sets the format of the store files written by the
+// stores of all the configs.
+func (m ConfigMap) SetSnapshotFormat(format SnapshotFormat) {
+	for _, c := range m {
+		c.SetSnapshotFormat(format)
+	}
+}
@@ -20,7 +20,7 @@ func (b *baseStore) ApplyDelta(delta *pbssinternal.StoreDelta) {
 	keySize := uint64(len(delta.Key))
 	switch delta.Operation {
 	case pbssinternal.StoreDelta_UPDATE:
-		b.kv[delta.Key] = delta.NewValue
+		b.kv.Set(delta.Key, delta.NewValue)
 		switch {
 		case newSize > oldSize:
 			b.totalSizeBytes += (newSize - oldSize)
@@ -29,18 +29,18 @@ func (b *baseStore) ApplyDelta(delta *pbssinternal.StoreDelta) {
 		}
 
 	case pbssinternal.StoreDelta_CREATE:
-		b.kv[delta.Key] = delta.NewValue
+		b.kv.Set(delta.Key, delta.NewValue)
 		b.totalSizeBytes += newSize
 		b.totalSizeBytes += keySize
 
 	case pbssinternal.StoreDelta_DELETE:
-		delete(b.kv, delta.Key)
+		b.kv.Delete(delta.Key)
 		b.totalSizeBytes -= oldSize
 		b.totalSizeBytes -= keySize
 		return
 	}
 
-	if b.totalSizeBytes > b.totalSizeLimit {
+	if b.totalSizeLimit > 0 && b.totalSizeBytes > b.totalSizeLimit {
 		panic(fmt.Sprintf("store %q became too big at %d, maximum size: %d", b.Name(), b.totalSizeBytes, b.totalSizeLimit))
 	}
 }
@@ -54,7 +54,7 @@ func (b *baseStore) ApplyDeltasReverse(deltas []*pbssinternal.StoreDelta) {
 		keySize := uint64(len(delta.Key))
 		switch delta.Operation {
 		case pbssinternal.StoreDelta_UPDATE:
-			b.kv[delta.Key] = delta.OldValue
+			b.kv.Set(delta.Key, delta.OldValue)
 			switch {
 			case newSize > oldSize:
 				b.totalSizeBytes -= (newSize - oldSize)
@@ -63,12 +63,12 @@ func (b *baseStore) ApplyDeltasReverse(deltas []*pbssinternal.StoreDelta) {
 			}
 
 		case pbssinternal.StoreDelta_CREATE:
-			delete(b.kv, delta.Key)
+			b.kv.Delete(delta.Key)
 			b.totalSizeBytes -= newSize
 			b.totalSizeBytes -= keySize
 
 		case pbssinternal.StoreDelta_DELETE:
-			b.kv[delta.Key] = delta.OldValue
+			b.kv.Set(delta.Key, delta.OldValue)
 			b.totalSizeBytes += oldSize
 			b.totalSizeBytes += keySize
 			return
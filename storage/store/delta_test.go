@@ -84,12 +84,12 @@ func TestApplyDelta(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			s := &baseStore{
 				Config: baseStoreConfig,
-				kv:     make(map[string][]byte),
+				kv:     newMapBackend(nil),
 			}
 			for _, delta := range test.deltas {
 				s.ApplyDelta(delta)
 			}
-			assert.Equal(t, test.expectedKV, s.kv)
+			assert.Equal(t, test.expectedKV, s.kv.(*MapBackend).kv)
 		})
 	}
 }
@@ -97,7 +97,7 @@ func TestApplyDelta(t *testing.T) {
 func Test_baseStore_SetDeltas(t *testing.T) {
 	s := baseStore{
 		Config:         baseStoreConfig,
-		kv:             map[string][]byte{"A": []byte("a")},
+		kv:             newMapBackend(map[string][]byte{"A": []byte("a")}),
 		totalSizeBytes: 2,
 	}
 	s.SetDeltas([]*pbssinternal.StoreDelta{
@@ -123,9 +123,9 @@ func Test_baseStore_SetDeltas(t *testing.T) {
 			NewValue:  []byte("d"),
 		},
 	})
-	assert.Len(t, s.kv, 2)
-	assert.Equal(t, "b", string(s.kv["B"]))
-	assert.Equal(t, "d", string(s.kv["C"]))
+	assert.Equal(t, uint64(2), s.kv.Len())
+	assert.Equal(t, "b", string(s.kv.(*MapBackend).kv["B"]))
+	assert.Equal(t, "d", string(s.kv.(*MapBackend).kv["C"]))
 	assert.Equal(t, uint64(4), s.totalSizeBytes)
 	assert.Len(t, s.deltas, 4)
 }
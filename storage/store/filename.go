@@ -65,6 +65,12 @@ func NewPartialFileInfo(moduleName string, start uint64, exclusiveEndBlock uint6
 	}
 }
 
+// ParseFileName parses the name of a full or partial store file, as found
+// under the `states` directory of a store module.
+func ParseFileName(filename string) (*FileInfo, bool) {
+	return parseFileName("", filename)
+}
+
 func parseFileName(moduleName, filename string) (*FileInfo, bool) {
 	res := stateFileRegex.FindAllStringSubmatch(filename, 1)
 	if len(res) != 1 {
@@ -93,6 +99,13 @@ func FullStateFileName(r *block.Range) string {
 	return fmt.Sprintf("%010d-%010d.kv", r.ExclusiveEndBlock, r.StartBlock)
 }
 
+// checkpointFilename is the name of the checkpoint of a store holding its
+// state from `startBlock` up to `endBlock`, written by the tier2 job of
+// `traceID`.
+func checkpointFilename(startBlock, endBlock uint64, traceID string) string {
+	return fmt.Sprintf("%010d-%010d.%s.kv", startBlock, endBlock, traceID)
+}
+
 func mustAtoi(s string) int {
 	i, err := strconv.Atoi(s)
 	if err != nil {
@@ -24,7 +24,7 @@ func (s *FullKV) Marshaller() marshaller.Marshaller {
 func (s *FullKV) DerivePartialStore(initialBlock uint64) *PartialKV {
 	b := &baseStore{
 		Config:     s.Config,
-		kv:         make(map[string][]byte),
+		kv:         s.newKV(),
 		logger:     s.logger,
 		marshaller: marshaller.Default(),
 	}
@@ -32,6 +32,7 @@ func (s *FullKV) DerivePartialStore(initialBlock uint64) *PartialKV {
 		baseStore:    b,
 		initialBlock: initialBlock,
 		seen:         make(map[string]bool),
+		seenRanges:   make(map[marshaller.KeyRange]bool),
 	}
 }
 
@@ -39,23 +40,32 @@ func (s *FullKV) Load(ctx context.Context, file *FileInfo) error {
 	s.loadedFrom = file.Filename
 	s.logger.Debug("loading full store state from file", zap.String("fileName", file.Filename))
 
-	data, err := loadStore(ctx, s.objStore, file.Filename)
-	if err != nil {
+	if _, _, err := s.loadKV(ctx, s.objStore, file.Filename); err != nil {
 		return fmt.Errorf("load full store %s at %s: %w", s.name, file.Filename, err)
 	}
 
-	storeData, size, err := s.marshaller.Unmarshal(data)
+	s.logger.Debug("full store loaded", zap.String("fileName", file.Filename), zap.Uint64("key_count", s.kv.Len()), zap.Uint64("data_size", s.totalSizeBytes))
+	return nil
+}
+
+// LoadMergedFiles replaces the state with the result of merging `partials`
+// on top of `base`, see Config.MergeFiles.
+func (s *FullKV) LoadMergedFiles(ctx context.Context, base *FileInfo, partials []*FileInfo) error {
+	s.kv.Reset()
+	s.totalSizeBytes = 0
+
+	err := s.MergeFiles(ctx, base, partials, func(key string, value []byte) error {
+		s.setNewKV(key, value)
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("unmarshal store: %w", err)
+		return fmt.Errorf("load merged full store %s: %w", s.name, err)
 	}
 
-	s.kv = storeData.Kv
-	s.totalSizeBytes = size
-	if s.kv == nil {
-		s.kv = make(map[string][]byte)
+	if len(partials) > 0 {
+		s.loadedFrom = partials[len(partials)-1].Filename
 	}
-
-	s.logger.Debug("full store loaded", zap.String("fileName", file.Filename), zap.Int("key_count", len(s.kv)), zap.Uint64("data_size", size))
+	s.logger.Debug("merged full store loaded", zap.String("fileName", s.loadedFrom), zap.Uint64("key_count", s.kv.Len()), zap.Uint64("data_size", s.totalSizeBytes))
 	return nil
 }
 
@@ -65,15 +75,6 @@ func (s *FullKV) Load(ctx context.Context, file *FileInfo) error {
 func (s *FullKV) Save(endBoundaryBlock uint64) (*FileInfo, *fileWriter, error) {
 	s.logger.Debug("writing full store state", zap.Object("store", s))
 
-	stateData := &marshaller.StoreData{
-		Kv: s.kv,
-	}
-
-	content, err := s.marshaller.Marshal(stateData)
-	if err != nil {
-		return nil, nil, fmt.Errorf("marshal kv state: %w", err)
-	}
-
 	file := NewCompleteFileInfo(s.name, s.moduleInitialBlock, endBoundaryBlock)
 
 	s.logger.Info("saving store",
@@ -81,10 +82,9 @@ func (s *FullKV) Save(endBoundaryBlock uint64) (*FileInfo, *fileWriter, error) {
 		zap.Object("block_range", file.Range),
 	)
 
-	fw := &fileWriter{
-		store:    s.objStore,
-		filename: file.Filename,
-		content:  content,
+	fw, err := s.newFileWriter(file.Filename, nil, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal kv state: %w", err)
 	}
 
 	return file, fw, nil
@@ -92,12 +92,12 @@ func (s *FullKV) Save(endBoundaryBlock uint64) (*FileInfo, *fileWriter, error) {
 
 func (s *FullKV) Reset() {
 	if tracer.Enabled() {
-		s.logger.Debug("flushing store", zap.Int("delta_count", len(s.deltas)), zap.Int("entry_count", len(s.kv)))
+		s.logger.Debug("flushing store", zap.Int("delta_count", len(s.deltas)), zap.Uint64("entry_count", s.kv.Len()))
 	}
 	s.deltas = nil
 	s.lastOrdinal = 0
 }
 
 func (s *FullKV) String() string {
-	return fmt.Sprintf("fullKV name %s moduleInitialBlock %d keyCount %d loadedFrom %s deltasCount %d", s.Name(), s.moduleInitialBlock, len(s.kv), s.loadedFrom, len(s.deltas))
+	return fmt.Sprintf("fullKV name %s moduleInitialBlock %d keyCount %d loadedFrom %s deltasCount %d", s.Name(), s.moduleInitialBlock, s.kv.Len(), s.loadedFrom, len(s.deltas))
 }
@@ -31,7 +31,7 @@ func newTestBaseStore(
 	require.NoError(t, err)
 	return &baseStore{
 		Config:     config,
-		kv:         make(map[string][]byte),
+		kv:         newMapBackend(nil),
 		logger:     zap.NewNop(),
 		marshaller: &marshaller.Binary{},
 	}
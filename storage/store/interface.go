@@ -17,11 +17,13 @@ type Store interface {
 
 	Loadable
 	Savable
+	Checkpointable
 	Iterable
 	DeltaAccessor
 	Resettable
 	Mergeable
 	Named
+	Closable
 	// todoo: add fmt.Stringer ??
 
 	// intrinsics
@@ -60,6 +62,13 @@ type Savable interface {
 	Save(endBoundaryBlock uint64) (*FileInfo, *fileWriter, error)
 }
 
+// Checkpointable stores save their state in the middle of the range of a
+// tier2 job, for a retry of the job to resume from.
+type Checkpointable interface {
+	SaveCheckpoint(ctx context.Context, endBlock uint64, traceID string) (string, error)
+	LoadCheckpoint(ctx context.Context, endBlock uint64, traceID string) (string, error)
+}
+
 type Resettable interface {
 	Reset()
 }
@@ -68,6 +77,10 @@ type Named interface {
 	Name() string
 }
 
+type Closable interface {
+	Close() error
+}
+
 type Iterable interface {
 	Length() uint64
 	Iter(func(key string, value []byte) error) error
@@ -92,6 +105,9 @@ type Reader interface {
 	HasFirst(key string) bool
 	HasLast(key string) bool
 	HasAt(ord uint64, key string) bool
+
+	ScanPrefix(prefix string, limit uint64) []KV
+	ScanRange(lowKey, highKey string, limit uint64) []KV
 }
 
 type Mergeable interface {
@@ -115,8 +131,8 @@ type Appender interface {
 
 type Deleter interface {
 	DeletePrefix(ord uint64, prefix string)
-	//// Deletes a range of keys, lexicographically between `lowKey` and `highKey`
-	//DeleteRange(lowKey, highKey string)
+	// Deletes a range of keys, lexicographically between `lowKey` (inclusive) and `highKey` (exclusive)
+	DeleteRange(ord uint64, lowKey, highKey string)
 	//// Deletes a range of keys, first considering the _value_ of such keys as a _pointerSeparator_-separated list of keys to _also_ delete.
 	//DeleteRangePointers(lowKey, highKey, pointerSeparator string)
 }
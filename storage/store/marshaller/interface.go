@@ -1,8 +1,20 @@
 package marshaller
 
+import (
+	pbstore "github.com/streamingfast/substreams/storage/store/marshaller/pb"
+)
+
 type StoreData struct {
 	Kv             map[string][]byte
 	DeletePrefixes []string
+	DeleteRanges   []KeyRange
+}
+
+// KeyRange holds the keys lexicographically between Low (inclusive) and
+// High (exclusive), an empty High meaning no upper bound.
+type KeyRange struct {
+	Low  string
+	High string
 }
 
 type Marshaller interface {
@@ -13,3 +25,38 @@ type Marshaller interface {
 func Default() Marshaller {
 	return &VTproto{}
 }
+
+// ForContent returns the Marshaller able to read `content`: the Snapshot
+// marshaller for content in the snapshot format, `m` otherwise, or the
+// default one if `m` is the Snapshot marshaller.
+func ForContent(content []byte, m Marshaller) Marshaller {
+	if IsSnapshot(content) {
+		return &Snapshot{}
+	}
+	if _, ok := m.(*Snapshot); ok {
+		return Default()
+	}
+	return m
+}
+
+func toProtoKeyRanges(ranges []KeyRange) []*pbstore.KeyRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	out := make([]*pbstore.KeyRange, len(ranges))
+	for i, r := range ranges {
+		out[i] = &pbstore.KeyRange{Low: r.Low, High: r.High}
+	}
+	return out
+}
+
+func fromProtoKeyRanges(ranges []*pbstore.KeyRange) []KeyRange {
+	if len(ranges) == 0 {
+		return nil
+	}
+	out := make([]KeyRange, len(ranges))
+	for i, r := range ranges {
+		out[i] = KeyRange{Low: r.GetLow(), High: r.GetHigh()}
+	}
+	return out
+}
@@ -17,6 +17,7 @@ func (p *Proto) Unmarshal(in []byte) (*StoreData, uint64, error) {
 	return &StoreData{
 		Kv:             stateData.GetKv(),
 		DeletePrefixes: stateData.GetDeletePrefixes(),
+		DeleteRanges:   fromProtoKeyRanges(stateData.GetDeleteRanges()),
 	}, 0, nil
 }
 
@@ -24,6 +25,7 @@ func (p *Proto) Marshal(data *StoreData) ([]byte, error) {
 	stateData := &pbsubstreams.StoreData{
 		Kv:             data.Kv,
 		DeletePrefixes: data.DeletePrefixes,
+		DeleteRanges:   toProtoKeyRanges(data.DeleteRanges),
 	}
 	return proto.Marshal(stateData)
 }
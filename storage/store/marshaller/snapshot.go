@@ -0,0 +1,706 @@
+package marshaller
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// The snapshot format stores the entries sorted by key, in compressed
+// blocks, so that a store file can be read one block at a time and a key
+// can be looked up without decoding the whole file:
+//
+//	header    magic "SFSS" | version (1 byte)
+//	blocks    data frame...
+//	metadata  meta frame: delete prefixes and ranges, key count, data size
+//	index     index frame: first key, last key and offset of each block
+//	footer    meta offset (u64) | index offset (u64) | crc32 of the offsets (u32) | magic "SFSS"
+//
+// Every frame starts with its kind and, before its payload, the crc32 of the
+// payload. Data frames also hold the first and last keys of their block, so
+// that blocks can be skipped when reading the file sequentially. Integers are
+// little-endian, lengths and counts uvarints.
+
+const (
+	SnapshotMagic   = "SFSS"
+	SnapshotVersion = 1
+
+	// SnapshotBlockSize is the size of the entries held in each block, before
+	// compression.
+	SnapshotBlockSize = 64 * 1024
+
+	snapshotHeaderSize = len(SnapshotMagic) + 1
+	snapshotFooterSize = 8 + 8 + 4 + len(SnapshotMagic)
+
+	frameData  byte = 1
+	frameMeta  byte = 2
+	frameIndex byte = 3
+)
+
+var (
+	ErrSnapshotChecksum = errors.New("snapshot checksum mismatch")
+
+	crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest))
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+// IsSnapshot returns whether `header`, the first bytes of a store file, is
+// the header of a file in the snapshot format. Files written by the `VTproto`
+// and `Proto` marshallers never start with the snapshot magic.
+func IsSnapshot(header []byte) bool {
+	return len(header) >= len(SnapshotMagic) && string(header[:len(SnapshotMagic)]) == SnapshotMagic
+}
+
+// Snapshot is the Marshaller of the snapshot format.
+type Snapshot struct{}
+
+func (s *Snapshot) Marshal(data *StoreData) ([]byte, error) {
+	keys := make([]string, 0, len(data.Kv))
+	for k := range data.Kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := &bytes.Buffer{}
+	w := NewSnapshotWriter(buf)
+	for _, k := range keys {
+		if err := w.Add(k, data.Kv[k]); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Close(data.DeletePrefixes, data.DeleteRanges); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Snapshot) Unmarshal(in []byte) (*StoreData, uint64, error) {
+	out := &StoreData{Kv: make(map[string][]byte)}
+	var size uint64
+	var err error
+	out.DeletePrefixes, out.DeleteRanges, size, err = ReadSnapshot(bytes.NewReader(in), func(key string, value []byte) error {
+		out.Kv[key] = value
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	return out, size, nil
+}
+
+type snapshotBlock struct {
+	firstKey string
+	lastKey  string
+	offset   uint64
+	count    uint64
+}
+
+type snapshotMeta struct {
+	deletePrefixes []string
+	deleteRanges   []KeyRange
+	keyCount       uint64
+	dataSize       uint64
+}
+
+// SnapshotWriter writes a store file in the snapshot format. Entries must be
+// added in increasing key order.
+type SnapshotWriter struct {
+	w      *bufio.Writer
+	offset uint64
+
+	block      []byte
+	blockCount uint64
+	firstKey   string
+	lastKey    string
+	hasKey     bool
+
+	index []snapshotBlock
+	meta  snapshotMeta
+	err   error
+}
+
+func NewSnapshotWriter(w io.Writer) *SnapshotWriter {
+	sw := &SnapshotWriter{w: bufio.NewWriter(w)}
+	sw.write(append([]byte(SnapshotMagic), SnapshotVersion))
+	return sw
+}
+
+func (w *SnapshotWriter) Add(key string, value []byte) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.hasKey && key <= w.lastKey {
+		return fmt.Errorf("snapshot keys must be added in increasing order, got %q after %q", key, w.lastKey)
+	}
+
+	if w.blockCount == 0 {
+		w.firstKey = key
+	}
+	w.block = binary.AppendUvarint(w.block, uint64(len(key)))
+	w.block = append(w.block, key...)
+	w.block = binary.AppendUvarint(w.block, uint64(len(value)))
+	w.block = append(w.block, value...)
+	w.blockCount++
+	w.lastKey = key
+	w.hasKey = true
+
+	w.meta.keyCount++
+	w.meta.dataSize += uint64(len(key) + len(value))
+
+	if len(w.block) >= SnapshotBlockSize {
+		w.flushBlock()
+	}
+	return w.err
+}
+
+// Close writes the end of the file, including `deletePrefixes` and
+// `deleteRanges`. It does not close the underlying writer.
+func (w *SnapshotWriter) Close(deletePrefixes []string, deleteRanges []KeyRange) error {
+	w.flushBlock()
+
+	w.meta.deletePrefixes = deletePrefixes
+	w.meta.deleteRanges = deleteRanges
+	metaOffset := w.offset
+	w.writeFrame(frameMeta, nil, encodeSnapshotMeta(&w.meta))
+
+	indexOffset := w.offset
+	w.writeFrame(frameIndex, nil, encodeSnapshotIndex(w.index))
+
+	footer := make([]byte, 0, snapshotFooterSize)
+	footer = binary.LittleEndian.AppendUint64(footer, metaOffset)
+	footer = binary.LittleEndian.AppendUint64(footer, indexOffset)
+	footer = binary.LittleEndian.AppendUint32(footer, crc32.Checksum(footer, crcTable))
+	footer = append(footer, SnapshotMagic...)
+	w.write(footer)
+
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+func (w *SnapshotWriter) flushBlock() {
+	if w.blockCount == 0 {
+		return
+	}
+
+	w.index = append(w.index, snapshotBlock{
+		firstKey: w.firstKey,
+		lastKey:  w.lastKey,
+		offset:   w.offset,
+		count:    w.blockCount,
+	})
+
+	var header []byte
+	header = appendString(header, w.firstKey)
+	header = appendString(header, w.lastKey)
+	header = binary.AppendUvarint(header, w.blockCount)
+	w.writeFrame(frameData, header, zstdEncoder.EncodeAll(w.block, nil))
+
+	w.block = w.block[:0]
+	w.blockCount = 0
+}
+
+func (w *SnapshotWriter) writeFrame(kind byte, header, payload []byte) {
+	frame := append([]byte{kind}, header...)
+	frame = binary.AppendUvarint(frame, uint64(len(payload)))
+	frame = binary.LittleEndian.AppendUint32(frame, crc32.Checksum(payload, crcTable))
+	w.write(frame)
+	w.write(payload)
+}
+
+func (w *SnapshotWriter) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(data)
+	w.offset += uint64(n)
+	w.err = err
+}
+
+// ReadSnapshot reads a file in the snapshot format from `r`, calling `f`
+// for each entry, in key order. It returns the delete prefixes and ranges,
+// and the total size of the keys and values read.
+func ReadSnapshot(r io.Reader, f func(key string, value []byte) error) (deletePrefixes []string, deleteRanges []KeyRange, dataSize uint64, err error) {
+	sr, err := newSnapshotStream(r)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	for {
+		frame, err := sr.next()
+		if err != nil {
+			return nil, nil, 0, err
+		}
+
+		switch frame.kind {
+		case frameData:
+			block, err := frame.decompress()
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			if err := iterBlock(block, func(key string, value []byte) error {
+				dataSize += uint64(len(key) + len(value))
+				return f(key, value)
+			}); err != nil {
+				return nil, nil, 0, err
+			}
+		case frameMeta:
+			meta, err := decodeSnapshotMeta(frame.payload)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			if meta.dataSize != dataSize {
+				return nil, nil, 0, fmt.Errorf("snapshot holds %d bytes of data, expected %d", dataSize, meta.dataSize)
+			}
+			return meta.deletePrefixes, meta.deleteRanges, dataSize, nil
+		default:
+			return nil, nil, 0, fmt.Errorf("unexpected snapshot frame kind %d", frame.kind)
+		}
+	}
+}
+
+// SnapshotGet looks up `key` in the snapshot file read from `r`, only
+// decompressing the block that can hold it and stopping as soon as it is
+// read.
+func SnapshotGet(r io.Reader, key string) (value []byte, found bool, err error) {
+	sr, err := newSnapshotStream(r)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for {
+		frame, err := sr.next()
+		if err != nil {
+			return nil, false, err
+		}
+		if frame.kind != frameData || key < frame.firstKey {
+			return nil, false, nil
+		}
+		if key > frame.lastKey {
+			continue
+		}
+
+		block, err := frame.decompress()
+		if err != nil {
+			return nil, false, err
+		}
+		return getInBlock(block, key)
+	}
+}
+
+type snapshotFrame struct {
+	kind     byte
+	firstKey string
+	lastKey  string
+	payload  []byte
+}
+
+func (f *snapshotFrame) decompress() ([]byte, error) {
+	block, err := zstdDecoder.DecodeAll(f.payload, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decompressing snapshot block starting at %q: %w", f.firstKey, err)
+	}
+	return block, nil
+}
+
+type snapshotStream struct {
+	r *bufio.Reader
+}
+
+func newSnapshotStream(r io.Reader) (*snapshotStream, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, snapshotHeaderSize)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("reading snapshot header: %w", noEOF(err))
+	}
+	if !IsSnapshot(header) {
+		return nil, fmt.Errorf("not a snapshot file")
+	}
+	if header[len(SnapshotMagic)] != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", header[len(SnapshotMagic)])
+	}
+	return &snapshotStream{r: br}, nil
+}
+
+func (s *snapshotStream) next() (*snapshotFrame, error) {
+	kind, err := s.r.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot frame: %w", noEOF(err))
+	}
+
+	frame := &snapshotFrame{kind: kind}
+	if kind == frameData {
+		if frame.firstKey, err = readString(s.r); err != nil {
+			return nil, fmt.Errorf("reading snapshot block first key: %w", noEOF(err))
+		}
+		if frame.lastKey, err = readString(s.r); err != nil {
+			return nil, fmt.Errorf("reading snapshot block last key: %w", noEOF(err))
+		}
+		if _, err = binary.ReadUvarint(s.r); err != nil {
+			return nil, fmt.Errorf("reading snapshot block entry count: %w", noEOF(err))
+		}
+	}
+
+	if frame.payload, err = readFramePayload(s.r); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+func readFramePayload(r *bufio.Reader) ([]byte, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot frame length: %w", noEOF(err))
+	}
+	var crc [4]byte
+	if _, err := io.ReadFull(r, crc[:]); err != nil {
+		return nil, fmt.Errorf("reading snapshot frame checksum: %w", noEOF(err))
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("reading snapshot frame: %w", noEOF(err))
+	}
+	if crc32.Checksum(payload, crcTable) != binary.LittleEndian.Uint32(crc[:]) {
+		return nil, ErrSnapshotChecksum
+	}
+	return payload, nil
+}
+
+// SnapshotReader gives random access to a file in the snapshot format.
+type SnapshotReader struct {
+	r          io.ReaderAt
+	index      []snapshotBlock
+	meta       *snapshotMeta
+	metaOffset int64
+}
+
+// OpenSnapshot reads the metadata and index of the snapshot file of `size`
+// bytes held by `r`.
+func OpenSnapshot(r io.ReaderAt, size int64) (*SnapshotReader, error) {
+	if size < int64(snapshotHeaderSize+snapshotFooterSize) {
+		return nil, fmt.Errorf("snapshot file too small: %d bytes", size)
+	}
+
+	header := make([]byte, snapshotHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("reading snapshot header: %w", err)
+	}
+	if !IsSnapshot(header) || header[len(SnapshotMagic)] != SnapshotVersion {
+		return nil, fmt.Errorf("not a snapshot file of version %d", SnapshotVersion)
+	}
+
+	footer := make([]byte, snapshotFooterSize)
+	if _, err := r.ReadAt(footer, size-int64(snapshotFooterSize)); err != nil {
+		return nil, fmt.Errorf("reading snapshot footer: %w", err)
+	}
+	if !IsSnapshot(footer[20:]) {
+		return nil, fmt.Errorf("invalid snapshot footer")
+	}
+	if crc32.Checksum(footer[:16], crcTable) != binary.LittleEndian.Uint32(footer[16:20]) {
+		return nil, ErrSnapshotChecksum
+	}
+	metaOffset := int64(binary.LittleEndian.Uint64(footer[0:8]))
+	indexOffset := int64(binary.LittleEndian.Uint64(footer[8:16]))
+	endOffset := size - int64(snapshotFooterSize)
+	if metaOffset < int64(snapshotHeaderSize) || indexOffset < metaOffset || indexOffset > endOffset {
+		return nil, fmt.Errorf("invalid snapshot footer offsets")
+	}
+
+	metaPayload, err := readFrameAt(r, frameMeta, metaOffset, indexOffset)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := decodeSnapshotMeta(metaPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	indexPayload, err := readFrameAt(r, frameIndex, indexOffset, endOffset)
+	if err != nil {
+		return nil, err
+	}
+	index, err := decodeSnapshotIndex(indexPayload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SnapshotReader{r: r, index: index, meta: meta, metaOffset: metaOffset}, nil
+}
+
+func readFrameAt(r io.ReaderAt, kind byte, offset, end int64) ([]byte, error) {
+	br := bufio.NewReader(io.NewSectionReader(r, offset, end-offset))
+	actual, err := br.ReadByte()
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot frame: %w", noEOF(err))
+	}
+	if actual != kind {
+		return nil, fmt.Errorf("expected snapshot frame kind %d, got %d", kind, actual)
+	}
+	return readFramePayload(br)
+}
+
+func (s *SnapshotReader) KeyCount() uint64         { return s.meta.keyCount }
+func (s *SnapshotReader) DataSize() uint64         { return s.meta.dataSize }
+func (s *SnapshotReader) DeletePrefixes() []string { return s.meta.deletePrefixes }
+func (s *SnapshotReader) DeleteRanges() []KeyRange { return s.meta.deleteRanges }
+
+func (s *SnapshotReader) Get(key string) (value []byte, found bool, err error) {
+	idx := sort.Search(len(s.index), func(i int) bool { return s.index[i].lastKey >= key })
+	if idx == len(s.index) || key < s.index[idx].firstKey {
+		return nil, false, nil
+	}
+
+	block, err := s.readBlock(idx)
+	if err != nil {
+		return nil, false, err
+	}
+	return getInBlock(block, key)
+}
+
+// IterRange calls `f` for each key between `low` (inclusive) and `high`
+// (exclusive), in key order. An empty `high` means no upper bound.
+func (s *SnapshotReader) IterRange(low, high string, f func(key string, value []byte) error) error {
+	idx := sort.Search(len(s.index), func(i int) bool { return s.index[i].lastKey >= low })
+	for ; idx < len(s.index); idx++ {
+		if high != "" && s.index[idx].firstKey >= high {
+			return nil
+		}
+
+		block, err := s.readBlock(idx)
+		if err != nil {
+			return err
+		}
+		err = iterBlock(block, func(key string, value []byte) error {
+			if key < low || (high != "" && key >= high) {
+				return nil
+			}
+			return f(key, value)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SnapshotReader) readBlock(idx int) ([]byte, error) {
+	// the metadata frame follows the last block
+	end := s.metaOffset
+	if idx+1 < len(s.index) {
+		end = int64(s.index[idx+1].offset)
+	}
+
+	start := int64(s.index[idx].offset)
+	br := bufio.NewReader(io.NewSectionReader(s.r, start, end-start))
+	frame, err := (&snapshotStream{r: br}).next()
+	if err != nil {
+		return nil, err
+	}
+	if frame.kind != frameData {
+		return nil, fmt.Errorf("expected snapshot data frame at offset %d", s.index[idx].offset)
+	}
+	return frame.decompress()
+}
+
+// SnapshotIterator walks the entries of a snapshot in key order, holding a
+// single decompressed block in memory at a time.
+type SnapshotIterator struct {
+	s         *SnapshotReader
+	nextBlock int
+	block     []byte
+}
+
+// Iterator returns a SnapshotIterator positioned before the first entry.
+func (s *SnapshotReader) Iterator() *SnapshotIterator {
+	return &SnapshotIterator{s: s}
+}
+
+// Next returns the next entry, `ok` being false once all the entries were
+// read.
+func (it *SnapshotIterator) Next() (key string, value []byte, ok bool, err error) {
+	for len(it.block) == 0 {
+		if it.nextBlock == len(it.s.index) {
+			return "", nil, false, nil
+		}
+		if it.block, err = it.s.readBlock(it.nextBlock); err != nil {
+			return "", nil, false, err
+		}
+		it.nextBlock++
+	}
+
+	key, value, n, err := decodeBlockEntry(it.block)
+	if err != nil {
+		return "", nil, false, err
+	}
+	it.block = it.block[n:]
+	return key, value, true, nil
+}
+
+func iterBlock(block []byte, f func(key string, value []byte) error) error {
+	for len(block) > 0 {
+		key, value, n, err := decodeBlockEntry(block)
+		if err != nil {
+			return err
+		}
+		block = block[n:]
+
+		if err := f(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getInBlock(block []byte, key string) ([]byte, bool, error) {
+	for len(block) > 0 {
+		entryKey, value, n, err := decodeBlockEntry(block)
+		if err != nil {
+			return nil, false, err
+		}
+		block = block[n:]
+
+		if entryKey == key {
+			return value, true, nil
+		}
+		if entryKey > key {
+			break
+		}
+	}
+	return nil, false, nil
+}
+
+func decodeBlockEntry(in []byte) (key string, value []byte, n int, err error) {
+	keyLen, l := binary.Uvarint(in)
+	if l <= 0 || uint64(len(in)-l) < keyLen {
+		return "", nil, 0, fmt.Errorf("invalid snapshot block entry key")
+	}
+	n = l + int(keyLen)
+	key = string(in[l:n])
+
+	valueLen, l := binary.Uvarint(in[n:])
+	if l <= 0 || uint64(len(in)-n-l) < valueLen {
+		return "", nil, 0, fmt.Errorf("invalid snapshot block entry value for key %q", key)
+	}
+	n += l
+	value = in[n : n+int(valueLen)]
+	return key, value, n + int(valueLen), nil
+}
+
+func encodeSnapshotMeta(meta *snapshotMeta) (out []byte) {
+	out = binary.AppendUvarint(out, uint64(len(meta.deletePrefixes)))
+	for _, prefix := range meta.deletePrefixes {
+		out = appendString(out, prefix)
+	}
+	out = binary.AppendUvarint(out, uint64(len(meta.deleteRanges)))
+	for _, keyRange := range meta.deleteRanges {
+		out = appendString(out, keyRange.Low)
+		out = appendString(out, keyRange.High)
+	}
+	out = binary.AppendUvarint(out, meta.keyCount)
+	out = binary.AppendUvarint(out, meta.dataSize)
+	return out
+}
+
+func decodeSnapshotMeta(in []byte) (*snapshotMeta, error) {
+	r := bufio.NewReader(bytes.NewReader(in))
+	meta := &snapshotMeta{}
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot delete prefixes: %w", noEOF(err))
+	}
+	for i := uint64(0); i < count; i++ {
+		prefix, err := readString(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading snapshot delete prefixes: %w", noEOF(err))
+		}
+		meta.deletePrefixes = append(meta.deletePrefixes, prefix)
+	}
+
+	if count, err = binary.ReadUvarint(r); err != nil {
+		return nil, fmt.Errorf("reading snapshot delete ranges: %w", noEOF(err))
+	}
+	for i := uint64(0); i < count; i++ {
+		low, err := readString(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading snapshot delete ranges: %w", noEOF(err))
+		}
+		high, err := readString(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading snapshot delete ranges: %w", noEOF(err))
+		}
+		meta.deleteRanges = append(meta.deleteRanges, KeyRange{Low: low, High: high})
+	}
+
+	if meta.keyCount, err = binary.ReadUvarint(r); err != nil {
+		return nil, fmt.Errorf("reading snapshot key count: %w", noEOF(err))
+	}
+	if meta.dataSize, err = binary.ReadUvarint(r); err != nil {
+		return nil, fmt.Errorf("reading snapshot data size: %w", noEOF(err))
+	}
+	return meta, nil
+}
+
+func encodeSnapshotIndex(index []snapshotBlock) (out []byte) {
+	out = binary.AppendUvarint(out, uint64(len(index)))
+	for _, block := range index {
+		out = appendString(out, block.firstKey)
+		out = appendString(out, block.lastKey)
+		out = binary.AppendUvarint(out, block.offset)
+		out = binary.AppendUvarint(out, block.count)
+	}
+	return out
+}
+
+func decodeSnapshotIndex(in []byte) ([]snapshotBlock, error) {
+	r := bufio.NewReader(bytes.NewReader(in))
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot index: %w", noEOF(err))
+	}
+	index := make([]snapshotBlock, count)
+	for i := range index {
+		if index[i].firstKey, err = readString(r); err != nil {
+			return nil, fmt.Errorf("reading snapshot index: %w", noEOF(err))
+		}
+		if index[i].lastKey, err = readString(r); err != nil {
+			return nil, fmt.Errorf("reading snapshot index: %w", noEOF(err))
+		}
+		if index[i].offset, err = binary.ReadUvarint(r); err != nil {
+			return nil, fmt.Errorf("reading snapshot index: %w", noEOF(err))
+		}
+		if index[i].count, err = binary.ReadUvarint(r); err != nil {
+			return nil, fmt.Errorf("reading snapshot index: %w", noEOF(err))
+		}
+	}
+	return index, nil
+}
+
+func appendString(out []byte, s string) []byte {
+	out = binary.AppendUvarint(out, uint64(len(s)))
+	return append(out, s...)
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,115 @@
+package marshaller
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	data := &StoreData{
+		Kv:             map[string][]byte{},
+		DeletePrefixes: []string{"prefix1"},
+		DeleteRanges:   []KeyRange{{Low: "a", High: "b"}},
+	}
+	var dataSize uint64
+	for i := 0; i < 20000; i++ {
+		key := fmt.Sprintf("key:%05d", i*2)
+		data.Kv[key] = []byte(fmt.Sprintf("value-%d", i))
+		dataSize += uint64(len(key) + len(data.Kv[key]))
+	}
+
+	content, err := (&Snapshot{}).Marshal(data)
+	require.NoError(t, err)
+	require.True(t, IsSnapshot(content))
+	assert.IsType(t, &Snapshot{}, ForContent(content, Default()))
+
+	decoded, size, err := (&Snapshot{}).Unmarshal(content)
+	require.NoError(t, err)
+	assert.Equal(t, dataSize, size)
+	assert.Equal(t, data, decoded)
+
+	var keys []string
+	deletePrefixes, deleteRanges, streamedSize, err := StreamUnmarshal(bytes.NewReader(content), func(key string, value []byte) error {
+		keys = append(keys, key)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, dataSize, streamedSize)
+	assert.Equal(t, data.DeletePrefixes, deletePrefixes)
+	assert.Equal(t, data.DeleteRanges, deleteRanges)
+	require.Len(t, keys, 20000)
+	assert.Equal(t, "key:00000", keys[0])
+	assert.Equal(t, "key:39998", keys[len(keys)-1])
+
+	value, found, err := SnapshotGet(bytes.NewReader(content), "key:12344")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "value-6172", string(value))
+	_, found, err = SnapshotGet(bytes.NewReader(content), "key:12345")
+	require.NoError(t, err)
+	assert.False(t, found)
+	_, found, err = SnapshotGet(bytes.NewReader(content), "zzz")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	reader, err := OpenSnapshot(bytes.NewReader(content), int64(len(content)))
+	require.NoError(t, err)
+	require.Greater(t, len(reader.index), 1)
+	assert.Equal(t, uint64(20000), reader.KeyCount())
+	assert.Equal(t, dataSize, reader.DataSize())
+	assert.Equal(t, data.DeletePrefixes, reader.DeletePrefixes())
+	assert.Equal(t, data.DeleteRanges, reader.DeleteRanges())
+
+	value, found, err = reader.Get("key:39998")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, "value-19999", string(value))
+	_, found, err = reader.Get("key:00001")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	keys = nil
+	require.NoError(t, reader.IterRange("key:10001", "key:10010", func(key string, _ []byte) error {
+		keys = append(keys, key)
+		return nil
+	}))
+	assert.Equal(t, []string{"key:10002", "key:10004", "key:10006", "key:10008"}, keys)
+
+	it := reader.Iterator()
+	for i := 0; ; i++ {
+		key, value, ok, err := it.Next()
+		require.NoError(t, err)
+		if !ok {
+			assert.Equal(t, 20000, i)
+			break
+		}
+		require.Equal(t, fmt.Sprintf("key:%05d", i*2), key)
+		require.Equal(t, fmt.Sprintf("value-%d", i), string(value))
+	}
+}
+
+func TestSnapshot_Corrupted(t *testing.T) {
+	content, err := (&Snapshot{}).Marshal(&StoreData{Kv: map[string][]byte{"key": []byte("value")}})
+	require.NoError(t, err)
+
+	corrupted := bytes.Clone(content)
+	corrupted[len(corrupted)-len(SnapshotMagic)-5] ^= 0xff
+	_, err = OpenSnapshot(bytes.NewReader(corrupted), int64(len(corrupted)))
+	assert.ErrorIs(t, err, ErrSnapshotChecksum)
+
+	corrupted = bytes.Clone(content)
+	corrupted[snapshotHeaderSize+12] ^= 0xff
+	_, _, err = (&Snapshot{}).Unmarshal(corrupted)
+	assert.Error(t, err)
+
+	_, _, err = (&Snapshot{}).Unmarshal(content[:len(content)/2])
+	assert.Error(t, err)
+
+	w := NewSnapshotWriter(&bytes.Buffer{})
+	require.NoError(t, w.Add("b", nil))
+	assert.Error(t, w.Add("a", nil))
+}
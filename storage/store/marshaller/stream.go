@@ -0,0 +1,170 @@
+package marshaller
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The streaming functions below read and write the same wire format as the
+// `VTproto` and `Proto` marshallers, one entry at a time, so that stores
+// bigger than the available memory can be saved and loaded.
+
+const (
+	storeDataKvField             protowire.Number = 1
+	storeDataDeletePrefixesField protowire.Number = 2
+	storeDataDeleteRangesField   protowire.Number = 3
+)
+
+// StreamMarshal writes to `w` the entries produced by `iter` followed by
+// `deletePrefixes` and `deleteRanges`.
+func StreamMarshal(w io.Writer, iter func(f func(key string, value []byte) error) error, deletePrefixes []string, deleteRanges []KeyRange) error {
+	bw := bufio.NewWriter(w)
+
+	var buf []byte
+	err := iter(func(key string, value []byte) error {
+		entryLen := protowire.SizeTag(1) + protowire.SizeBytes(len(key)) + protowire.SizeTag(2) + protowire.SizeBytes(len(value))
+
+		buf = protowire.AppendTag(buf[:0], storeDataKvField, protowire.BytesType)
+		buf = protowire.AppendVarint(buf, uint64(entryLen))
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendString(buf, key)
+		buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, value)
+
+		_, err := bw.Write(buf)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("writing kv entries: %w", err)
+	}
+
+	for _, prefix := range deletePrefixes {
+		buf = protowire.AppendTag(buf[:0], storeDataDeletePrefixesField, protowire.BytesType)
+		buf = protowire.AppendString(buf, prefix)
+		if _, err := bw.Write(buf); err != nil {
+			return fmt.Errorf("writing delete prefixes: %w", err)
+		}
+	}
+
+	for _, keyRange := range deleteRanges {
+		rangeLen := protowire.SizeTag(1) + protowire.SizeBytes(len(keyRange.Low)) + protowire.SizeTag(2) + protowire.SizeBytes(len(keyRange.High))
+
+		buf = protowire.AppendTag(buf[:0], storeDataDeleteRangesField, protowire.BytesType)
+		buf = protowire.AppendVarint(buf, uint64(rangeLen))
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		buf = protowire.AppendString(buf, keyRange.Low)
+		buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+		buf = protowire.AppendString(buf, keyRange.High)
+		if _, err := bw.Write(buf); err != nil {
+			return fmt.Errorf("writing delete ranges: %w", err)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// StreamUnmarshal reads the store data from `r`, calling `f` for each
+// entry. It returns the delete prefixes and ranges, and the total size of the
+// keys and values read. Files in the snapshot format are detected and read
+// with ReadSnapshot.
+func StreamUnmarshal(r io.Reader, f func(key string, value []byte) error) (deletePrefixes []string, deleteRanges []KeyRange, dataSize uint64, err error) {
+	br := bufio.NewReader(r)
+	if header, _ := br.Peek(len(SnapshotMagic)); IsSnapshot(header) {
+		return ReadSnapshot(br, f)
+	}
+
+	var buf []byte
+	for {
+		tag, err := binary.ReadUvarint(br)
+		if err == io.EOF {
+			return deletePrefixes, deleteRanges, dataSize, nil
+		}
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("reading tag: %w", err)
+		}
+
+		fieldNum, wireType := protowire.DecodeTag(tag)
+		if wireType != protowire.BytesType {
+			return nil, nil, 0, fmt.Errorf("proto: wrong wireType = %d for field %d", wireType, fieldNum)
+		}
+
+		length, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("reading length of field %d: %w", fieldNum, noEOF(err))
+		}
+		if uint64(cap(buf)) < length {
+			buf = make([]byte, length)
+		}
+		buf = buf[:length]
+		if _, err := io.ReadFull(br, buf); err != nil {
+			return nil, nil, 0, fmt.Errorf("reading field %d: %w", fieldNum, noEOF(err))
+		}
+
+		switch fieldNum {
+		case storeDataKvField:
+			key, value, err := decodeEntry(buf)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			dataSize += uint64(len(key) + len(value))
+			if err := f(key, value); err != nil {
+				return nil, nil, 0, err
+			}
+		case storeDataDeletePrefixesField:
+			deletePrefixes = append(deletePrefixes, string(buf))
+		case storeDataDeleteRangesField:
+			low, high, err := decodeEntry(buf)
+			if err != nil {
+				return nil, nil, 0, err
+			}
+			deleteRanges = append(deleteRanges, KeyRange{Low: low, High: string(high)})
+		}
+	}
+}
+
+// decodeEntry decodes the two bytes fields of a kv map entry or a key range.
+func decodeEntry(in []byte) (key string, value []byte, err error) {
+	for len(in) > 0 {
+		num, typ, n := protowire.ConsumeTag(in)
+		if n < 0 {
+			return "", nil, fmt.Errorf("proto: entry: %w", protowire.ParseError(n))
+		}
+		in = in[n:]
+
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, in)
+			if n < 0 {
+				return "", nil, fmt.Errorf("proto: entry: %w", protowire.ParseError(n))
+			}
+			in = in[n:]
+			continue
+		}
+
+		data, n := protowire.ConsumeBytes(in)
+		if n < 0 {
+			return "", nil, fmt.Errorf("proto: entry: %w", protowire.ParseError(n))
+		}
+		in = in[n:]
+
+		switch num {
+		case 1:
+			key = string(data)
+		case 2:
+			value = make([]byte, len(data))
+			copy(value, data)
+		}
+	}
+	return key, value, nil
+}
+
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,57 @@
+package marshaller
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream_CompatibleWithVTproto(t *testing.T) {
+	data := &StoreData{
+		Kv: map[string][]byte{
+			"key1":  []byte("value1"),
+			"key2":  []byte("value2"),
+			"empty": nil,
+		},
+		DeletePrefixes: []string{"prefix1", "prefix2"},
+		DeleteRanges:   []KeyRange{{Low: "a", High: "b"}, {Low: "c"}},
+	}
+
+	buf := &bytes.Buffer{}
+	iter := func(f func(key string, value []byte) error) error {
+		for k, v := range data.Kv {
+			if err := f(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	require.NoError(t, StreamMarshal(buf, iter, data.DeletePrefixes, data.DeleteRanges))
+
+	decoded, size, err := (&VTproto{}).Unmarshal(buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, data.DeletePrefixes, decoded.DeletePrefixes)
+	assert.Equal(t, data.DeleteRanges, decoded.DeleteRanges)
+	assert.Len(t, decoded.Kv, 3)
+	assert.Equal(t, []byte("value1"), decoded.Kv["key1"])
+
+	content, err := (&VTproto{}).Marshal(data)
+	require.NoError(t, err)
+
+	streamed := map[string][]byte{}
+	deletePrefixes, deleteRanges, streamedSize, err := StreamUnmarshal(bytes.NewReader(content), func(key string, value []byte) error {
+		streamed[key] = value
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, data.DeletePrefixes, deletePrefixes)
+	assert.Equal(t, data.DeleteRanges, deleteRanges)
+	assert.Equal(t, size, streamedSize)
+	assert.Len(t, streamed, 3)
+	assert.Equal(t, []byte("value2"), streamed["key2"])
+
+	_, _, _, err = StreamUnmarshal(bytes.NewReader(content[:len(content)-3]), func(string, []byte) error { return nil })
+	require.Error(t, err)
+}
@@ -18,6 +18,7 @@ func (p *VTproto) Unmarshal(in []byte) (*StoreData, uint64, error) {
 	return &StoreData{
 		Kv:             stateData.GetKv(),
 		DeletePrefixes: stateData.GetDeletePrefixes(),
+		DeleteRanges:   fromProtoKeyRanges(stateData.GetDeleteRanges()),
 	}, dataSize, nil
 }
 
@@ -25,6 +26,7 @@ func (p *VTproto) Marshal(data *StoreData) ([]byte, error) {
 	stateData := &pbstore.StoreData{
 		Kv:             data.Kv,
 		DeletePrefixes: data.DeletePrefixes,
+		DeleteRanges:   toProtoKeyRanges(data.DeleteRanges),
 	}
 
 	return stateData.MarshalVT()
@@ -236,6 +238,41 @@ func unmarshalVT(m *pbstore.StoreData, dAtA []byte) (dataSize uint64, err error)
 			//m.DeletePrefixes = append(m.DeletePrefixes, string(dAtA[iNdEx:postIndex]))
 			m.DeletePrefixes = append(m.DeletePrefixes, unsafeGetString(dAtA[iNdEx:postIndex]))
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return 0, fmt.Errorf("proto: wrong wireType = %d for field DeleteRanges", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, pbstore.ErrIntOverflow
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return 0, pbstore.ErrInvalidLength
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return 0, pbstore.ErrInvalidLength
+			}
+			if postIndex > l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			keyRange := &pbstore.KeyRange{}
+			if err := keyRange.UnmarshalVT(dAtA[iNdEx:postIndex]); err != nil {
+				return 0, err
+			}
+			m.DeleteRanges = append(m.DeleteRanges, keyRange)
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skip(dAtA[iNdEx:])
@@ -1,8 +1,10 @@
 package store
 
 import (
+	"context"
 	"fmt"
 	"math/big"
+	"os"
 	"strconv"
 	"strings"
 	"time"
@@ -12,280 +14,378 @@ import (
 	"github.com/shopspring/decimal"
 	"github.com/streamingfast/substreams/manifest"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/store/marshaller"
 )
 
 func (b *baseStore) setKV(k string, v []byte) {
-	if prev, ok := b.kv[k]; ok {
+	if prev, ok := b.kv.Get(k); ok {
 		b.totalSizeBytes -= uint64(len(prev))
 	} else {
 		b.totalSizeBytes += uint64(len(k))
 	}
 	b.totalSizeBytes += uint64(len(v))
-	b.kv[k] = v
+	b.kv.Set(k, v)
 }
 
 func (b *baseStore) setNewKV(k string, v []byte) {
 	b.totalSizeBytes += uint64(len(k) + len(v))
-	b.kv[k] = v
+	b.kv.Set(k, v)
 }
 
 // Merge nextStore _into_ `s`, where nextStore is for the next contiguous segment's store output.
 func (b *baseStore) Merge(kvPartialStore *PartialKV) error {
-	b.logger.Debug("merging store", zap.Int("current_key_count", len(b.kv)), zap.Uint64("mod_init_block", b.moduleInitialBlock), zap.Int("partial_key_count", len(kvPartialStore.kv)), zap.Uint64("partial_start_block", kvPartialStore.initialBlock))
+	b.logger.Debug("merging store", zap.Uint64("current_key_count", b.kv.Len()), zap.Uint64("mod_init_block", b.moduleInitialBlock), zap.Uint64("partial_key_count", kvPartialStore.kv.Len()), zap.Uint64("partial_start_block", kvPartialStore.initialBlock))
 
-	if kvPartialStore.updatePolicy != b.updatePolicy {
-		return fmt.Errorf("incompatible update policies: policy %q cannot merge policy %q", b.updatePolicy, kvPartialStore.updatePolicy)
+	if err := b.checkMergeable(kvPartialStore.Config); err != nil {
+		return err
 	}
 
-	if kvPartialStore.valueType != b.valueType {
-		return fmt.Errorf("incompatible value types: cannot merge %q and %q", b.valueType, kvPartialStore.valueType)
+	merge, err := newValueMerger(b.updatePolicy, b.valueType, b.appendLimit)
+	if err != nil {
+		return err
 	}
 
 	partialKvTime := time.Now()
 	for _, prefix := range kvPartialStore.DeletedPrefixes {
 		b.DeletePrefix(kvPartialStore.lastOrdinal, prefix)
 	}
-	if len(kvPartialStore.DeletedPrefixes) > 0 {
-		b.logger.Info("merging: applied delete prefixes", zap.Duration("duration", time.Since(partialKvTime)))
+	for _, keyRange := range kvPartialStore.DeletedRanges {
+		b.DeleteRange(kvPartialStore.lastOrdinal, keyRange.Low, keyRange.High)
+	}
+	if len(kvPartialStore.DeletedPrefixes) > 0 || len(kvPartialStore.DeletedRanges) > 0 {
+		b.logger.Info("merging: applied delete prefixes and ranges", zap.Duration("duration", time.Since(partialKvTime)))
 	}
 
-	intoValueTypeLower := strings.ToLower(b.valueType)
+	err = kvPartialStore.kv.Iter(func(k string, v []byte) error {
+		prev, found := b.kv.Get(k)
+		if found && b.updatePolicy == pbsubstreams.Module_KindStore_UPDATE_POLICY_SET_IF_NOT_EXISTS {
+			return nil
+		}
 
-	switch b.updatePolicy {
-	case pbsubstreams.Module_KindStore_UPDATE_POLICY_SET:
-		for k, v := range kvPartialStore.kv {
-			b.setKV(k, v)
+		next, err := merge(prev, found, v)
+		if err != nil {
+			return err
 		}
-	case pbsubstreams.Module_KindStore_UPDATE_POLICY_SET_IF_NOT_EXISTS:
-		for k, v := range kvPartialStore.kv {
-			if _, found := b.kv[k]; !found {
-				b.setNewKV(k, v)
-			}
+		b.setKV(k, next)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	b.Reset() // Merge should never keep deltas or ordinals
+	return nil
+}
+
+func (c *Config) checkMergeable(partial *Config) error {
+	if partial.updatePolicy != c.updatePolicy {
+		return fmt.Errorf("incompatible update policies: policy %q cannot merge policy %q", c.updatePolicy, partial.updatePolicy)
+	}
+
+	if partial.valueType != c.valueType {
+		return fmt.Errorf("incompatible value types: cannot merge %q and %q", c.valueType, partial.valueType)
+	}
+	return nil
+}
+
+// StreamingMerge tells if partial files are to be merged with MergeFiles
+// rather than loaded and merged in memory: files of stores written in the
+// sorted format need no sorting to be merged, and stores kept on disk are
+// expected not to fit in memory.
+func (c *Config) StreamingMerge() bool {
+	return c.snapshotFormat == SnapshotFormatSorted || c.newBackend != nil
+}
+
+// MergeFiles merges the partial store files `partials`, given in block
+// order, on top of the full store file `base`, nil for the empty store,
+// calling `f` with the resulting entries in key order. Each partial's
+// deletes apply to the keys merged from the files before it, as with
+// Merge. Files are not loaded: they are downloaded locally and walked in
+// lockstep, so memory use does not depend on the size of the stores.
+func (c *Config) MergeFiles(ctx context.Context, base *FileInfo, partials []*FileInfo, f func(key string, value []byte) error) error {
+	merge, err := newValueMerger(c.updatePolicy, c.valueType, c.appendLimit)
+	if err != nil {
+		return err
+	}
+
+	files := partials
+	if base != nil {
+		files = append([]*FileInfo{base}, partials...)
+	}
+
+	var sources []*mergeSource
+	defer func() {
+		for _, source := range sources {
+			source.close()
 		}
-	case pbsubstreams.Module_KindStore_UPDATE_POLICY_APPEND:
-		for k, v := range kvPartialStore.kv {
-			if prevVal, found := b.kv[k]; found {
-				newLen := len(prevVal) + len(v)
-				if b.appendLimit > 0 && uint64(newLen) >= b.appendLimit {
-					return fmt.Errorf("append would exceed limit of %d bytes", b.appendLimit)
-				}
+	}()
+	for i, file := range files {
+		sf, err := c.openSortedFile(ctx, file)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, &mergeSource{
+			sortedFile: sf,
+			it:         sf.reader.Iterator(),
+			base:       base != nil && i == 0,
+		})
+	}
 
-				nextVal := make([]byte, len(prevVal)+len(v))
-				copy(nextVal[0:], prevVal)
-				copy(nextVal[len(prevVal):], v)
-				b.setKV(k, nextVal)
-			} else {
-				b.setNewKV(k, v)
-			}
+	return mergeSources(sources, merge, f)
+}
+
+// SaveMergedFiles writes the result of MergeFiles as the full store file
+// ending at `endBoundaryBlock`, staged in a local file until written.
+func (c *Config) SaveMergedFiles(ctx context.Context, base *FileInfo, partials []*FileInfo, endBoundaryBlock uint64) (*FileInfo, *fileWriter, error) {
+	file := NewCompleteFileInfo(c.name, c.moduleInitialBlock, endBoundaryBlock)
+
+	spill, err := c.createSpillFile("snapshot-")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer spill.Close()
+
+	err = c.writeSnapshot(spill, func(f func(key string, value []byte) error) error {
+		return c.MergeFiles(ctx, base, partials, f)
+	}, nil, nil)
+	if err != nil {
+		os.Remove(spill.Name())
+		return nil, nil, fmt.Errorf("merging store %s into %s: %w", c.name, file.Filename, err)
+	}
+
+	return file, &fileWriter{
+		store:       c.objStore,
+		catalog:     c.catalog,
+		filename:    file.Filename,
+		contentPath: spill.Name(),
+	}, nil
+}
+
+type mergeSource struct {
+	*sortedFile
+	it   *marshaller.SnapshotIterator
+	base bool // entries of the base are taken as is, not merged
+
+	key   string
+	value []byte
+	ok    bool
+}
+
+func (s *mergeSource) advance() (err error) {
+	s.key, s.value, s.ok, err = s.it.Next()
+	return err
+}
+
+func (s *mergeSource) deletes(key string) bool {
+	for _, prefix := range s.reader.DeletePrefixes() {
+		if strings.HasPrefix(key, prefix) {
+			return true
 		}
-	case pbsubstreams.Module_KindStore_UPDATE_POLICY_ADD:
-		// check valueType to do the right thing
-		switch intoValueTypeLower {
-		case manifest.OutputValueTypeInt64:
-			sum := func(a, b int64) int64 {
-				return a + b
-			}
-			for k, v := range kvPartialStore.kv {
-				v0b, fv0 := b.kv[k]
-				v0 := foundOrZeroInt64(v0b, fv0)
-				v1 := foundOrZeroInt64(v, true)
-				b.setKV(k, []byte(fmt.Sprintf("%d", sum(v0, v1))))
-			}
-		case manifest.OutputValueTypeFloat64:
-			sum := func(a, b float64) float64 {
-				return a + b
-			}
-			for k, v := range kvPartialStore.kv {
-				v0b, fv0 := b.kv[k]
-				v0 := foundOrZeroFloat(v0b, fv0)
-				v1 := foundOrZeroFloat(v, true)
-				b.setKV(k, floatToBytes(sum(v0, v1)))
-			}
-		case manifest.OutputValueTypeBigInt:
-			sum := func(a, b *big.Int) *big.Int {
-				return new(big.Int).Add(a, b)
-			}
-			for k, v := range kvPartialStore.kv {
-				v0b, fv0 := b.kv[k]
-				v0 := foundOrZeroBigInt(v0b, fv0)
-				v1 := foundOrZeroBigInt(v, true)
-				b.setKV(k, []byte(fmt.Sprintf("%d", sum(v0, v1))))
-			}
-		case manifest.OutputValueTypeBigFloat:
-			fallthrough
-		case manifest.OutputValueTypeBigDecimal:
-			for k, v := range kvPartialStore.kv {
-				v0b, fv0 := b.kv[k]
-				v0 := foundOrZeroBigDecimal(v0b, fv0)
-				v1 := foundOrZeroBigDecimal(v, true)
-				b.setKV(k, []byte(v0.Add(v1).String()))
-			}
-		default:
-			return fmt.Errorf("update policy %q not supported for value type %q", b.updatePolicy, b.valueType)
+	}
+	for _, keyRange := range s.reader.DeleteRanges() {
+		if inRange(key, keyRange.Low, keyRange.High) {
+			return true
 		}
-	case pbsubstreams.Module_KindStore_UPDATE_POLICY_MAX:
-		switch intoValueTypeLower {
-		case manifest.OutputValueTypeInt64:
-			max := func(a, b int64) int64 {
-				if a >= b {
-					return a
-				}
-				return b
-			}
-			for k, v := range kvPartialStore.kv {
-				v1 := foundOrZeroInt64(v, true)
-				v, found := b.kv[k]
-				if !found {
-					b.setNewKV(k, []byte(fmt.Sprintf("%d", v1)))
-					continue
-				}
-				v0 := foundOrZeroInt64(v, true)
+	}
+	return false
+}
 
-				b.setKV(k, []byte(fmt.Sprintf("%d", max(v0, v1))))
-			}
-		case manifest.OutputValueTypeFloat64:
-			max := func(a, b float64) float64 {
-				if a < b {
-					return b
-				}
-				return a
+// mergeSources calls `f` once per key found in `sources`, in key order,
+// with its value once the entries of all the sources are merged, from the
+// first source to the last.
+func mergeSources(sources []*mergeSource, merge valueMerger, f func(key string, value []byte) error) error {
+	for _, source := range sources {
+		if err := source.advance(); err != nil {
+			return err
+		}
+	}
+
+	for {
+		var key string
+		var pending bool
+		for _, source := range sources {
+			if source.ok && (!pending || source.key < key) {
+				key, pending = source.key, true
 			}
-			for k, v := range kvPartialStore.kv {
-				v1 := foundOrZeroFloat(v, true)
-				v, found := b.kv[k]
-				if !found {
-					b.setNewKV(k, floatToBytes(v1))
-					continue
-				}
-				v0 := foundOrZeroFloat(v, true)
+		}
+		if !pending {
+			return nil
+		}
 
-				b.setKV(k, floatToBytes(max(v0, v1)))
+		var value []byte
+		var found bool
+		for _, source := range sources {
+			if source.deletes(key) {
+				value, found = nil, false
 			}
-		case manifest.OutputValueTypeBigInt:
-			max := func(a, b *big.Int) *big.Int {
-				if a.Cmp(b) <= 0 {
-					return b
-				}
-				return a
+			if !source.ok || source.key != key {
+				continue
 			}
-			for k, v := range kvPartialStore.kv {
-				v1 := foundOrZeroBigInt(v, true)
-				v, found := b.kv[k]
-				if !found {
-					b.setNewKV(k, []byte(v1.String()))
-					continue
-				}
-				v0 := foundOrZeroBigInt(v, true)
 
-				b.setKV(k, []byte(fmt.Sprintf("%d", max(v0, v1))))
-			}
-		case manifest.OutputValueTypeBigFloat:
-			fallthrough
-		case manifest.OutputValueTypeBigDecimal:
-			max := func(a, b decimal.Decimal) decimal.Decimal {
-				if a.Cmp(b) <= 0 {
-					return b
+			if source.base {
+				value = source.value
+			} else {
+				next, err := merge(value, found, source.value)
+				if err != nil {
+					return fmt.Errorf("merging key %q: %w", key, err)
 				}
-				return a
+				value = next
 			}
-			for k, v := range kvPartialStore.kv {
-				v1 := foundOrZeroBigDecimal(v, true)
-				v, found := b.kv[k]
-				if !found {
-					b.setNewKV(k, []byte(v1.String()))
-					continue
-				}
-				v0 := foundOrZeroBigDecimal(v, true)
+			found = true
 
-				b.setNewKV(k, []byte(max(v0, v1).String()))
+			if err := source.advance(); err != nil {
+				return err
 			}
-		default:
-			return fmt.Errorf("update policy %q not supported for value type %q", kvPartialStore.updatePolicy, kvPartialStore.valueType)
 		}
-	case pbsubstreams.Module_KindStore_UPDATE_POLICY_MIN:
-		switch intoValueTypeLower {
-		case manifest.OutputValueTypeInt64:
-			min := func(a, b int64) int64 {
-				if a <= b {
-					return a
-				}
-				return b
+
+		if found {
+			if err := f(key, value); err != nil {
+				return err
 			}
-			for k, v := range kvPartialStore.kv {
-				v1 := foundOrZeroInt64(v, true)
-				v, found := b.kv[k]
-				if !found {
-					b.setNewKV(k, []byte(fmt.Sprintf("%d", v1)))
-					continue
-				}
-				v0 := foundOrZeroInt64(v, true)
+		}
+	}
+}
+
+// valueMerger returns the value of a key once `value`, read from a partial
+// store, is merged into its previous value `prev`, `found` being false when
+// the key had no previous value.
+type valueMerger func(prev []byte, found bool, value []byte) ([]byte, error)
+
+func newValueMerger(updatePolicy pbsubstreams.Module_KindStore_UpdatePolicy, valueType string, appendLimit uint64) (valueMerger, error) {
+	unsupported := fmt.Errorf("update policy %q not supported for value type %q", updatePolicy, valueType)
 
-				b.setKV(k, []byte(fmt.Sprintf("%d", min(v0, v1))))
+	switch updatePolicy {
+	case pbsubstreams.Module_KindStore_UPDATE_POLICY_SET:
+		return func(_ []byte, _ bool, v []byte) ([]byte, error) {
+			return v, nil
+		}, nil
+	case pbsubstreams.Module_KindStore_UPDATE_POLICY_SET_IF_NOT_EXISTS:
+		return func(prev []byte, found bool, v []byte) ([]byte, error) {
+			if found {
+				return prev, nil
 			}
-		case manifest.OutputValueTypeFloat64:
-			min := func(a, b float64) float64 {
-				if a < b {
-					return a
-				}
-				return b
+			return v, nil
+		}, nil
+	case pbsubstreams.Module_KindStore_UPDATE_POLICY_APPEND:
+		return func(prev []byte, found bool, v []byte) ([]byte, error) {
+			if !found {
+				return v, nil
 			}
-			for k, v := range kvPartialStore.kv {
-				v1 := foundOrZeroFloat(v, true)
-				v, found := b.kv[k]
-				if !found {
-					b.setNewKV(k, floatToBytes(v1))
-					continue
-				}
-				v0 := foundOrZeroFloat(v, true)
 
-				b.setKV(k, floatToBytes(min(v0, v1)))
+			newLen := len(prev) + len(v)
+			if appendLimit > 0 && uint64(newLen) >= appendLimit {
+				return nil, fmt.Errorf("append would exceed limit of %d bytes", appendLimit)
 			}
+
+			nextVal := make([]byte, newLen)
+			copy(nextVal[0:], prev)
+			copy(nextVal[len(prev):], v)
+			return nextVal, nil
+		}, nil
+	case pbsubstreams.Module_KindStore_UPDATE_POLICY_ADD:
+		// check valueType to do the right thing
+		switch strings.ToLower(valueType) {
+		case manifest.OutputValueTypeInt64:
+			return func(prev []byte, found bool, v []byte) ([]byte, error) {
+				v0 := foundOrZeroInt64(prev, found)
+				v1 := foundOrZeroInt64(v, true)
+				return []byte(fmt.Sprintf("%d", v0+v1)), nil
+			}, nil
+		case manifest.OutputValueTypeFloat64:
+			return func(prev []byte, found bool, v []byte) ([]byte, error) {
+				v0 := foundOrZeroFloat(prev, found)
+				v1 := foundOrZeroFloat(v, true)
+				return floatToBytes(v0 + v1), nil
+			}, nil
 		case manifest.OutputValueTypeBigInt:
-			min := func(a, b *big.Int) *big.Int {
-				if a.Cmp(b) <= 0 {
-					return a
+			return func(prev []byte, found bool, v []byte) ([]byte, error) {
+				v0 := foundOrZeroBigInt(prev, found)
+				v1 := foundOrZeroBigInt(v, true)
+				return []byte(fmt.Sprintf("%d", new(big.Int).Add(v0, v1))), nil
+			}, nil
+		case manifest.OutputValueTypeBigFloat, manifest.OutputValueTypeBigDecimal:
+			return func(prev []byte, found bool, v []byte) ([]byte, error) {
+				v0 := foundOrZeroBigDecimal(prev, found)
+				v1 := foundOrZeroBigDecimal(v, true)
+				return []byte(v0.Add(v1).String()), nil
+			}, nil
+		}
+		return nil, unsupported
+	case pbsubstreams.Module_KindStore_UPDATE_POLICY_MAX:
+		return newMinMaxMerger(valueType, 1, unsupported)
+	case pbsubstreams.Module_KindStore_UPDATE_POLICY_MIN:
+		return newMinMaxMerger(valueType, -1, unsupported)
+	}
+	return nil, fmt.Errorf("update policy %q not supported", updatePolicy) // should have been validated already
+}
+
+// newMinMaxMerger keeps the greatest value when `keep` is 1, and the
+// smallest one when it is -1. Keys without a previous value take the
+// partial's value, normalized.
+func newMinMaxMerger(valueType string, keep int, unsupported error) (valueMerger, error) {
+	switch strings.ToLower(valueType) {
+	case manifest.OutputValueTypeInt64:
+		return func(prev []byte, found bool, v []byte) ([]byte, error) {
+			v1 := foundOrZeroInt64(v, true)
+			if found {
+				if v0 := foundOrZeroInt64(prev, true); compareInt64(v0, v1) == keep {
+					v1 = v0
 				}
-				return b
 			}
-			for k, v := range kvPartialStore.kv {
-				v1 := foundOrZeroBigInt(v, true)
-				v, found := b.kv[k]
-				if !found {
-					b.setNewKV(k, []byte(v1.String()))
-					continue
+			return []byte(fmt.Sprintf("%d", v1)), nil
+		}, nil
+	case manifest.OutputValueTypeFloat64:
+		return func(prev []byte, found bool, v []byte) ([]byte, error) {
+			v1 := foundOrZeroFloat(v, true)
+			if found {
+				if v0 := foundOrZeroFloat(prev, true); compareFloat64(v0, v1) == keep {
+					v1 = v0
 				}
-				v0 := foundOrZeroBigInt(v, true)
-
-				b.setKV(k, []byte(fmt.Sprintf("%d", min(v0, v1))))
 			}
-		case manifest.OutputValueTypeBigFloat:
-			fallthrough
-		case manifest.OutputValueTypeBigDecimal:
-			min := func(a, b decimal.Decimal) decimal.Decimal {
-				if a.Cmp(b) <= 0 {
-					return a
+			return floatToBytes(v1), nil
+		}, nil
+	case manifest.OutputValueTypeBigInt:
+		return func(prev []byte, found bool, v []byte) ([]byte, error) {
+			v1 := foundOrZeroBigInt(v, true)
+			if found {
+				if v0 := foundOrZeroBigInt(prev, true); v0.Cmp(v1) == keep {
+					v1 = v0
 				}
-				return b
 			}
-			for k, v := range kvPartialStore.kv {
-				v1 := foundOrZeroBigDecimal(v, true)
-				v, found := b.kv[k]
-				if !found {
-					b.setNewKV(k, []byte(v1.String()))
-					continue
+			return []byte(v1.String()), nil
+		}, nil
+	case manifest.OutputValueTypeBigFloat, manifest.OutputValueTypeBigDecimal:
+		return func(prev []byte, found bool, v []byte) ([]byte, error) {
+			v1 := foundOrZeroBigDecimal(v, true)
+			if found {
+				if v0 := foundOrZeroBigDecimal(prev, true); v0.Cmp(v1) == keep {
+					v1 = v0
 				}
-				v0 := foundOrZeroBigDecimal(v, true)
-				b.setNewKV(k, []byte(min(v0, v1).String()))
 			}
-		default:
-			return fmt.Errorf("update policy %q not supported for value type %q", b.updatePolicy, b.valueType)
-		}
-	default:
-		return fmt.Errorf("update policy %q not supported", b.updatePolicy) // should have been validated already
+			return []byte(v1.String()), nil
+		}, nil
 	}
+	return nil, unsupported
+}
 
-	b.Reset() // Merge should never keep deltas or ordinals
-	return nil
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat64(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
 }
 
 func foundOrZeroInt64(in []byte, found bool) int64 {
@@ -1,8 +1,15 @@
 package store
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
 	"testing"
 
+	"github.com/streamingfast/dstore"
+
 	"go.uber.org/zap"
 
 	"github.com/stretchr/testify/require"
@@ -12,6 +19,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/store/marshaller"
 )
 
 func TestStore_Merge(t *testing.T) {
@@ -351,7 +359,7 @@ func TestStore_Merge(t *testing.T) {
 				require.NoError(t, err)
 			}
 
-			for k, v := range test.prev.kv {
+			for k, v := range test.prev.kv.(*MapBackend).kv {
 				if test.latest.valueType == manifest.OutputValueTypeBigDecimal {
 					actual, _ := foundOrZeroBigFloat(v, true).Float64()
 					expected, _ := foundOrZeroBigFloat(test.expectedKV[k], true).Float64()
@@ -366,10 +374,10 @@ func TestStore_Merge(t *testing.T) {
 			for k, v := range test.expectedKV {
 				if test.latest.valueType == manifest.OutputValueTypeBigDecimal {
 					actual, _ := foundOrZeroBigFloat(v, true).Float64()
-					expected, _ := foundOrZeroBigFloat(test.prev.kv[k], true).Float64()
+					expected, _ := foundOrZeroBigFloat(test.prev.kv.(*MapBackend).kv[k], true).Float64()
 					assert.InDelta(t, actual, expected, 0.01)
 				} else {
-					expected := string(test.prev.kv[k])
+					expected := string(test.prev.kv.(*MapBackend).kv[k])
 					actual := string(v)
 					assert.Equal(t, expected, actual)
 				}
@@ -380,9 +388,115 @@ func TestStore_Merge(t *testing.T) {
 	}
 }
 
+func TestStore_Merge_DeletedRanges(t *testing.T) {
+	prev := newStore(map[string][]byte{
+		"a":   []byte("1"),
+		"b:1": []byte("2"),
+		"b:2": []byte("3"),
+		"c":   []byte("4"),
+	}, pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, manifest.OutputValueTypeString)
+	latest := newPartialStore(map[string][]byte{
+		"b:3": []byte("5"),
+	}, pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, manifest.OutputValueTypeString, nil)
+	latest.DeletedRanges = []marshaller.KeyRange{{Low: "b", High: "c"}}
+
+	require.NoError(t, prev.Merge(latest))
+	assert.Equal(t, map[string][]byte{
+		"a":   []byte("1"),
+		"b:3": []byte("5"),
+		"c":   []byte("4"),
+	}, prev.kv.(*MapBackend).kv)
+}
+
+func TestConfig_MergeFiles(t *testing.T) {
+	files := map[string][]byte{}
+	objStore := dstore.NewMockStore(func(base string, f io.Reader) (err error) {
+		files[base], err = io.ReadAll(f)
+		return err
+	})
+	objStore.OpenObjectFunc = func(ctx context.Context, name string) (out io.ReadCloser, err error) {
+		return io.NopCloser(bytes.NewReader(files[name])), nil
+	}
+
+	for _, format := range []SnapshotFormat{SnapshotFormatProto, SnapshotFormatSorted} {
+		for _, updatePolicy := range []pbsubstreams.Module_KindStore_UpdatePolicy{
+			pbsubstreams.Module_KindStore_UPDATE_POLICY_SET,
+			pbsubstreams.Module_KindStore_UPDATE_POLICY_SET_IF_NOT_EXISTS,
+			pbsubstreams.Module_KindStore_UPDATE_POLICY_ADD,
+			pbsubstreams.Module_KindStore_UPDATE_POLICY_MIN,
+			pbsubstreams.Module_KindStore_UPDATE_POLICY_MAX,
+			pbsubstreams.Module_KindStore_UPDATE_POLICY_APPEND,
+		} {
+			t.Run(fmt.Sprintf("%s, %s", format, updatePolicy), func(t *testing.T) {
+				config, err := NewConfig("test", 0, "test.module.hash", updatePolicy, manifest.OutputValueTypeInt64, objStore, "")
+				require.NoError(t, err)
+				config.SetSnapshotFormat(format)
+				require.True(t, config.StreamingMerge() == (format == SnapshotFormatSorted))
+
+				full := config.NewFullKV(zap.NewNop())
+				for i, key := range []string{"a:1", "a:2", "b:1", "b:2", "c:1"} {
+					full.Set(0, key, fmt.Sprintf("%d", i+10))
+				}
+				base, writer, err := full.Save(100)
+				require.NoError(t, err)
+				require.NoError(t, writer.Write(context.Background()))
+
+				first := config.NewPartialKV(100, zap.NewNop())
+				first.Set(0, "a:1", "30")
+				first.Set(0, "d:1", "5")
+				first.DeletePrefix(1, "b:")
+				first.Set(2, "b:2", "3")
+				second := config.NewPartialKV(200, zap.NewNop())
+				second.Set(0, "a:1", "1")
+				second.Set(0, "b:1", "2")
+				second.DeleteRange(1, "c", "d")
+				var partials []*FileInfo
+				for i, partial := range []*PartialKV{first, second} {
+					file, writer, err := partial.Save(uint64(i+2) * 100)
+					require.NoError(t, err)
+					require.NoError(t, writer.Write(context.Background()))
+					partials = append(partials, file)
+				}
+
+				expected := map[string]string{}
+				require.NoError(t, full.Merge(first))
+				require.NoError(t, full.Merge(second))
+				require.NoError(t, full.Iter(func(key string, value []byte) error {
+					expected[key] = string(value)
+					return nil
+				}))
+
+				var keys []string
+				merged := map[string]string{}
+				require.NoError(t, config.MergeFiles(context.Background(), base, partials, func(key string, value []byte) error {
+					keys = append(keys, key)
+					merged[key] = string(value)
+					return nil
+				}))
+				assert.Equal(t, expected, merged)
+				assert.True(t, sort.StringsAreSorted(keys))
+
+				file, writer, err := config.SaveMergedFiles(context.Background(), base, partials, 300)
+				require.NoError(t, err)
+				require.NoError(t, writer.Write(context.Background()))
+				loaded := config.NewFullKV(zap.NewNop())
+				require.NoError(t, loaded.Load(context.Background(), file))
+				assert.Equal(t, uint64(len(expected)), loaded.Length())
+				value, found := loaded.GetLast("a:1")
+				require.True(t, found)
+				assert.Equal(t, expected["a:1"], string(value))
+
+				require.NoError(t, loaded.LoadMergedFiles(context.Background(), base, partials[:1]))
+				_, found = loaded.GetLast("b:1")
+				assert.False(t, found)
+			})
+		}
+	}
+}
+
 func newPartialStore(kv map[string][]byte, updatePolicy pbsubstreams.Module_KindStore_UpdatePolicy, valueType string, deletedPrefixes []string) *PartialKV {
 	b := &baseStore{
-		kv: kv,
+		kv: newMapBackend(kv),
 		Config: &Config{
 			updatePolicy: updatePolicy,
 			valueType:    valueType,
@@ -394,7 +508,7 @@ func newPartialStore(kv map[string][]byte, updatePolicy pbsubstreams.Module_Kind
 
 func newStore(kv map[string][]byte, updatePolicy pbsubstreams.Module_KindStore_UpdatePolicy, valueType string) *FullKV {
 	b := &baseStore{
-		kv: kv,
+		kv: newMapBackend(kv),
 		Config: &Config{
 			updatePolicy: updatePolicy,
 			valueType:    valueType,
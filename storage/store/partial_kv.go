@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/streamingfast/substreams/storage/store/marshaller"
 	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/storage/catalog"
+	"github.com/streamingfast/substreams/storage/store/marshaller"
 )
 
 var _ Store = (*PartialKV)(nil)
@@ -15,14 +17,16 @@ type PartialKV struct {
 
 	initialBlock    uint64 // block at which we initialized this store
 	DeletedPrefixes []string
+	DeletedRanges   []marshaller.KeyRange
 
 	loadedFrom string
 	seen       map[string]bool
+	seenRanges map[marshaller.KeyRange]bool
 }
 
 func (p *PartialKV) Roll(lastBlock uint64) {
 	p.initialBlock = lastBlock
-	p.baseStore.kv = map[string][]byte{}
+	p.baseStore.kv.Reset()
 }
 
 func (p *PartialKV) InitialBlock() uint64 { return p.initialBlock }
@@ -31,47 +35,26 @@ func (p *PartialKV) Load(ctx context.Context, file *FileInfo) error {
 	p.loadedFrom = file.Filename
 	p.logger.Debug("loading partial store state from file", zap.String("filename", file.Filename))
 
-	data, err := loadStore(ctx, p.objStore, file.Filename)
+	deletePrefixes, deleteRanges, err := p.loadKV(ctx, p.objStore, file.Filename)
 	if err != nil {
 		return fmt.Errorf("load partial store %s at %s: %w", p.name, file.Filename, err)
 	}
+	p.DeletedPrefixes = deletePrefixes
+	p.DeletedRanges = deleteRanges
 
-	storeData, size, err := p.marshaller.Unmarshal(data)
-	if err != nil {
-		return fmt.Errorf("unmarshal store: %w", err)
-	}
-
-	p.kv = storeData.Kv
-	if p.kv == nil {
-		p.kv = map[string][]byte{}
-	}
-	p.totalSizeBytes = size
-	p.DeletedPrefixes = storeData.DeletePrefixes
-
-	p.logger.Debug("partial store loaded", zap.String("filename", file.Filename), zap.Int("key_count", len(p.kv)), zap.Uint64("data_size", size))
+	p.logger.Debug("partial store loaded", zap.String("filename", file.Filename), zap.Uint64("key_count", p.kv.Len()), zap.Uint64("data_size", p.totalSizeBytes))
 	return nil
 }
 
 func (p *PartialKV) Save(endBoundaryBlock uint64) (*FileInfo, *fileWriter, error) {
 	p.logger.Debug("writing partial store state", zap.Object("store", p))
 
-	stateData := &marshaller.StoreData{
-		Kv:             p.kv,
-		DeletePrefixes: p.DeletedPrefixes,
-	}
-
-	content, err := p.marshaller.Marshal(stateData)
-	if err != nil {
-		return nil, nil, fmt.Errorf("marshal partial data: %w", err)
-	}
-
 	file := NewPartialFileInfo(p.name, p.initialBlock, endBoundaryBlock, p.traceID)
 	p.logger.Info("partial store save written", zap.String("file_name", file.Filename), zap.Stringer("block_range", file.Range))
 
-	fw := &fileWriter{
-		store:    p.objStore,
-		filename: file.Filename,
-		content:  content,
+	fw, err := p.newFileWriter(file.Filename, p.DeletedPrefixes, p.DeletedRanges)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal partial data: %w", err)
 	}
 
 	return file, fw, nil
@@ -86,15 +69,37 @@ func (p *PartialKV) DeletePrefix(ord uint64, prefix string) {
 	}
 }
 
+func (p *PartialKV) DeleteRange(ord uint64, lowKey, highKey string) {
+	p.baseStore.DeleteRange(ord, lowKey, highKey)
+
+	keyRange := marshaller.KeyRange{Low: lowKey, High: highKey}
+	if !p.seenRanges[keyRange] {
+		p.DeletedRanges = append(p.DeletedRanges, keyRange)
+		p.seenRanges[keyRange] = true
+	}
+}
+
 func (p *PartialKV) DeleteStore(ctx context.Context, file *FileInfo) (err error) {
+	return p.DeletePartialFile(ctx, file)
+}
+
+// DeletePartialFile deletes the partial store file `file`, once merged.
+func (c *Config) DeletePartialFile(ctx context.Context, file *FileInfo) (err error) {
 	zlog.Debug("deleting partial store file", zap.String("file_name", file.Filename))
 
-	if err = p.objStore.DeleteObject(ctx, file.Filename); err != nil {
+	if err = c.objStore.DeleteObject(ctx, file.Filename); err != nil {
 		zlog.Warn("deleting file", zap.String("file_name", file.Filename), zap.Error(err))
+		return err
 	}
-	return err
+
+	if c.catalog != nil {
+		if err := c.catalog.Record(ctx, catalog.Entry{Dir: catalog.DirStates, Filename: file.Filename, Removed: true}); err != nil {
+			zlog.Warn("cannot record partial store file removal to catalog", zap.String("file_name", file.Filename), zap.Error(err))
+		}
+	}
+	return nil
 }
 
 func (p *PartialKV) String() string {
-	return fmt.Sprintf("partialKV name %s moduleInitialBlock %d  keyCount %d deltasCount %d loadFrom %s", p.Name(), p.moduleInitialBlock, len(p.kv), len(p.deltas), p.loadedFrom)
+	return fmt.Sprintf("partialKV name %s moduleInitialBlock %d  keyCount %d deltasCount %d loadFrom %s", p.Name(), p.moduleInitialBlock, p.kv.Len(), len(p.deltas), p.loadedFrom)
 }
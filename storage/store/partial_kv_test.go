@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"context"
 	"io"
+	"strings"
 	"testing"
 
 	"github.com/streamingfast/dstore"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/catalog"
 	"github.com/streamingfast/substreams/storage/store/marshaller"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
@@ -24,7 +27,7 @@ func TestPartialKV_Save_Load_Empty_MapNotNil(t *testing.T) {
 
 	kvs := &PartialKV{
 		baseStore: &baseStore{
-			kv: map[string][]byte{},
+			kv: newMapBackend(nil),
 
 			logger:     zap.NewNop(),
 			marshaller: marshaller.Default(),
@@ -44,7 +47,7 @@ func TestPartialKV_Save_Load_Empty_MapNotNil(t *testing.T) {
 
 	kvl := &PartialKV{
 		baseStore: &baseStore{
-			kv: map[string][]byte{},
+			kv: newMapBackend(nil),
 
 			logger:     zap.NewNop(),
 			marshaller: marshaller.Default(),
@@ -60,3 +63,40 @@ func TestPartialKV_Save_Load_Empty_MapNotNil(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNilf(t, kvl.kv, "kvl.kv is nil")
 }
+
+func TestPartialKV_Save_Load_DeletedRanges(t *testing.T) {
+	for _, onDisk := range []bool{false, true} {
+		var writtenBytes []byte
+		store := dstore.NewMockStore(func(base string, f io.Reader) (err error) {
+			if strings.HasPrefix(base, catalog.DirName+"/") {
+				return nil
+			}
+			writtenBytes, err = io.ReadAll(f)
+			return err
+		})
+		store.OpenObjectFunc = func(ctx context.Context, name string) (out io.ReadCloser, err error) {
+			return io.NopCloser(bytes.NewBuffer(writtenBytes)), nil
+		}
+
+		config, err := NewConfig("test", 0, "test.module.hash", pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string", store, "")
+		require.NoError(t, err)
+		if onDisk {
+			config.UseDiskBackend(t.TempDir(), 0)
+		}
+
+		kvs := config.NewPartialKV(0, zap.NewNop())
+		kvs.Set(0, "a", "1")
+		kvs.DeleteRange(1, "b", "c")
+		kvs.DeleteRange(2, "b", "c")
+		kvs.DeleteRange(3, "d", "")
+
+		file, writer, err := kvs.Save(123)
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(context.Background()))
+
+		kvl := config.NewPartialKV(0, zap.NewNop())
+		require.NoError(t, kvl.Load(context.Background(), file))
+		require.Equal(t, []marshaller.KeyRange{{Low: "b", High: "c"}, {Low: "d"}}, kvl.DeletedRanges)
+		require.Equal(t, uint64(1), kvl.Length())
+	}
+}
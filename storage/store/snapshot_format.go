@@ -0,0 +1,229 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/streamingfast/substreams/storage/store/marshaller"
+)
+
+// SnapshotFormat is the format of the store files written by a store.
+type SnapshotFormat int
+
+const (
+	// SnapshotFormatProto writes the `StoreData` protobuf message, whose
+	// entries are not sorted.
+	SnapshotFormatProto SnapshotFormat = iota
+	// SnapshotFormatSorted writes the entries sorted by key in compressed
+	// blocks, with an index, see marshaller.Snapshot.
+	SnapshotFormatSorted
+)
+
+func (f SnapshotFormat) marshaller() marshaller.Marshaller {
+	if f == SnapshotFormatSorted {
+		return &marshaller.Snapshot{}
+	}
+	return marshaller.Default()
+}
+
+func (f SnapshotFormat) String() string {
+	switch f {
+	case SnapshotFormatProto:
+		return "proto"
+	case SnapshotFormatSorted:
+		return "sorted"
+	}
+	return fmt.Sprintf("SnapshotFormat(%d)", int(f))
+}
+
+// ParseSnapshotFormat returns the SnapshotFormat named `name`, as returned
+// by SnapshotFormat.String.
+func ParseSnapshotFormat(name string) (SnapshotFormat, error) {
+	switch name {
+	case "proto":
+		return SnapshotFormatProto, nil
+	case "sorted":
+		return SnapshotFormatSorted, nil
+	}
+	return 0, fmt.Errorf("unknown store snapshot format %q, expected %q or %q", name, SnapshotFormatProto, SnapshotFormatSorted)
+}
+
+var errKeyFound = errors.New("key found")
+
+// IterFile calls `f` for each entry of the store file, whatever its format,
+// without loading the whole file in memory. Entries come in key order for
+// files in the sorted format.
+func (c *Config) IterFile(ctx context.Context, file *FileInfo, f func(key string, value []byte) error) error {
+	return loadStoreStream(ctx, c.objStore, file.Filename, func(r io.Reader) error {
+		if _, _, _, err := marshaller.StreamUnmarshal(r, f); err != nil {
+			return fmt.Errorf("reading store file %s: %w", file.Filename, err)
+		}
+		return nil
+	})
+}
+
+// ReadKey returns the value of `key` in the store file. Files in the sorted
+// format are only read up to the block holding the key.
+func (c *Config) ReadKey(ctx context.Context, file *FileInfo, key string) (value []byte, found bool, err error) {
+	err = loadStoreStream(ctx, c.objStore, file.Filename, func(r io.Reader) (err error) {
+		br := bufio.NewReader(r)
+		if header, _ := br.Peek(len(marshaller.SnapshotMagic)); marshaller.IsSnapshot(header) {
+			value, found, err = marshaller.SnapshotGet(br, key)
+			return err
+		}
+
+		value, found = nil, false
+		_, _, _, err = marshaller.StreamUnmarshal(br, func(k string, v []byte) error {
+			if k == key {
+				value, found = v, true
+				return errKeyFound
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errKeyFound) {
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("reading key %q in store file %s: %w", key, file.Filename, err)
+	}
+	return value, found, nil
+}
+
+// sortedFile is a local copy of a store file in the sorted format.
+type sortedFile struct {
+	file   *os.File
+	reader *marshaller.SnapshotReader
+}
+
+func (f *sortedFile) close() {
+	removeLocalFile(f.file)
+}
+
+// LocalSnapshot is a local copy of a store file, in the sorted format
+// whatever the format of the original, whose entries are looked up through
+// the index of the format. It is safe for concurrent use.
+type LocalSnapshot struct {
+	*sortedFile
+}
+
+// OpenLocalSnapshot downloads `file` to a local file, see LocalSnapshot.
+// It must be closed once not used anymore, to remove the local file.
+func (c *Config) OpenLocalSnapshot(ctx context.Context, file *FileInfo) (*LocalSnapshot, error) {
+	sf, err := c.openSortedFile(ctx, file)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalSnapshot{sortedFile: sf}, nil
+}
+
+// Get returns the value of `key`, only reading the block that can hold it.
+func (s *LocalSnapshot) Get(key string) (value []byte, found bool, err error) {
+	return s.reader.Get(key)
+}
+
+// IterPrefix calls `f` for each key starting with `prefix`, from `startKey`
+// on, in key order, only reading the blocks that can hold them. Returning
+// an error from `f` stops the iteration.
+func (s *LocalSnapshot) IterPrefix(prefix, startKey string, f func(key string, value []byte) error) error {
+	return s.reader.IterRange(max(prefix, startKey), prefixUpperBound(prefix), f)
+}
+
+func (s *LocalSnapshot) Close() {
+	s.close()
+}
+
+// openSortedFile downloads `file` to a local file, so that it can be read
+// without being loaded in memory. Files not in the sorted format are sorted
+// through a temporary store backend.
+func (c *Config) openSortedFile(ctx context.Context, file *FileInfo) (*sortedFile, error) {
+	local, err := c.createSpillFile("merge-")
+	if err != nil {
+		return nil, err
+	}
+
+	err = loadStoreStream(ctx, c.objStore, file.Filename, func(r io.Reader) error {
+		// start over when retried
+		if err := local.Truncate(0); err != nil {
+			return err
+		}
+		if _, err := local.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		_, err := io.Copy(local, r)
+		return err
+	})
+	if err != nil {
+		removeLocalFile(local)
+		return nil, fmt.Errorf("downloading store file %s: %w", file.Filename, err)
+	}
+
+	header := make([]byte, len(marshaller.SnapshotMagic))
+	if _, err := local.ReadAt(header, 0); err != nil && err != io.EOF {
+		removeLocalFile(local)
+		return nil, fmt.Errorf("reading store file %s: %w", file.Filename, err)
+	}
+	if !marshaller.IsSnapshot(header) {
+		sorted, err := c.sortFile(local)
+		removeLocalFile(local)
+		if err != nil {
+			return nil, fmt.Errorf("sorting store file %s: %w", file.Filename, err)
+		}
+		local = sorted
+	}
+
+	info, err := local.Stat()
+	if err != nil {
+		removeLocalFile(local)
+		return nil, fmt.Errorf("reading store file %s: %w", file.Filename, err)
+	}
+	reader, err := marshaller.OpenSnapshot(local, info.Size())
+	if err != nil {
+		removeLocalFile(local)
+		return nil, fmt.Errorf("opening store file %s: %w", file.Filename, err)
+	}
+	return &sortedFile{file: local, reader: reader}, nil
+}
+
+// sortFile writes the entries of the store file `unsorted` to a new local
+// file in the sorted format.
+func (c *Config) sortFile(unsorted *os.File) (*os.File, error) {
+	if _, err := unsorted.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	kv := c.newKV()
+	defer kv.Close()
+	deletePrefixes, deleteRanges, _, err := marshaller.StreamUnmarshal(bufio.NewReader(unsorted), func(key string, value []byte) error {
+		kv.Set(key, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sorted, err := c.createSpillFile("merge-")
+	if err != nil {
+		return nil, err
+	}
+	sw := marshaller.NewSnapshotWriter(sorted)
+	if err := kv.IterRange("", "", sw.Add); err != nil {
+		removeLocalFile(sorted)
+		return nil, err
+	}
+	if err := sw.Close(deletePrefixes, deleteRanges); err != nil {
+		removeLocalFile(sorted)
+		return nil, err
+	}
+	return sorted, nil
+}
+
+func removeLocalFile(file *os.File) {
+	file.Close()
+	os.Remove(file.Name())
+}
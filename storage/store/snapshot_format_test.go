@@ -0,0 +1,110 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/store/marshaller"
+)
+
+func TestSnapshotFormat_SaveLoad(t *testing.T) {
+	files := map[string][]byte{}
+	objStore := dstore.NewMockStore(func(base string, f io.Reader) (err error) {
+		files[base], err = io.ReadAll(f)
+		return err
+	})
+	objStore.OpenObjectFunc = func(ctx context.Context, name string) (out io.ReadCloser, err error) {
+		return io.NopCloser(bytes.NewReader(files[name])), nil
+	}
+
+	newConfig := func(format SnapshotFormat, onDisk bool) *Config {
+		config, err := NewConfig("test", 0, "test.module.hash", pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string", objStore, "")
+		require.NoError(t, err)
+		config.SetSnapshotFormat(format)
+		if onDisk {
+			config.UseDiskBackend(t.TempDir(), 512)
+		}
+		return config
+	}
+
+	for _, format := range []SnapshotFormat{SnapshotFormatProto, SnapshotFormatSorted} {
+		for _, onDisk := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s, on disk: %v", format, onDisk), func(t *testing.T) {
+				config := newConfig(format, onDisk)
+				kvs := config.NewFullKV(zap.NewNop())
+				for i := 0; i < 100; i++ {
+					kvs.Set(uint64(i), fmt.Sprintf("key:%03d", 99-i), fmt.Sprintf("value-%d", i))
+				}
+				file, writer, err := kvs.Save(1000)
+				require.NoError(t, err)
+				require.NoError(t, writer.Write(context.Background()))
+				require.NoError(t, kvs.Close())
+				assert.Equal(t, format == SnapshotFormatSorted, marshaller.IsSnapshot(files[file.Filename]))
+
+				// files are read whatever the format set on the reading store
+				for _, readFormat := range []SnapshotFormat{SnapshotFormatProto, SnapshotFormatSorted} {
+					kvl := newConfig(readFormat, !onDisk).NewFullKV(zap.NewNop())
+					require.NoError(t, kvl.Load(context.Background(), file))
+					assert.Equal(t, uint64(100), kvl.Length())
+					value, found := kvl.GetLast("key:042")
+					require.True(t, found)
+					assert.Equal(t, "value-57", string(value))
+					require.NoError(t, kvl.Close())
+				}
+
+				value, found, err := config.ReadKey(context.Background(), file, "key:007")
+				require.NoError(t, err)
+				require.True(t, found)
+				assert.Equal(t, "value-92", string(value))
+				_, found, err = config.ReadKey(context.Background(), file, "key:100")
+				require.NoError(t, err)
+				assert.False(t, found)
+
+				var keys []string
+				require.NoError(t, config.IterFile(context.Background(), file, func(key string, _ []byte) error {
+					keys = append(keys, key)
+					return nil
+				}))
+				assert.Len(t, keys, 100)
+				if format == SnapshotFormatSorted {
+					assert.Equal(t, "key:000", keys[0])
+					assert.Equal(t, "key:099", keys[99])
+				}
+
+				local, err := config.OpenLocalSnapshot(context.Background(), file)
+				require.NoError(t, err)
+				defer local.Close()
+				value, found, err = local.Get("key:007")
+				require.NoError(t, err)
+				require.True(t, found)
+				assert.Equal(t, "value-92", string(value))
+
+				keys = nil
+				require.NoError(t, local.IterPrefix("key:04", "key:045", func(key string, _ []byte) error {
+					keys = append(keys, key)
+					return nil
+				}))
+				assert.Equal(t, []string{"key:045", "key:046", "key:047", "key:048", "key:049"}, keys)
+			})
+		}
+	}
+}
+
+func TestParseSnapshotFormat(t *testing.T) {
+	for _, format := range []SnapshotFormat{SnapshotFormatProto, SnapshotFormatSorted} {
+		parsed, err := ParseSnapshotFormat(format.String())
+		require.NoError(t, err)
+		assert.Equal(t, format, parsed)
+	}
+	_, err := ParseSnapshotFormat("unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package state
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamingfast/substreams/block"
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+// ReadKeyAtBlock returns the value `key` had once block `blockNum` was
+// processed. It is read from the nearest full store file ending at or below
+// `blockNum`, then the store deltas cached in `outputs`, the output cache of
+// the store's module, are replayed up to `blockNum` included.
+func ReadKeyAtBlock(ctx context.Context, storeConfig *store.Config, outputs *execout.Config, key string, blockNum uint64) (value []byte, found bool, err error) {
+	if blockNum < storeConfig.ModuleInitialBlock() {
+		return nil, false, nil
+	}
+
+	snapshots, err := listSnapshots(ctx, storeConfig, blockNum+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	nextBlock := storeConfig.ModuleInitialBlock()
+	for i := len(snapshots.FullKVFiles) - 1; i >= 0; i-- {
+		file := snapshots.FullKVFiles[i]
+		if file.Range.ExclusiveEndBlock > blockNum+1 {
+			continue
+		}
+		if value, found, err = storeConfig.ReadKey(ctx, file, key); err != nil {
+			return nil, false, err
+		}
+		nextBlock = file.Range.ExclusiveEndBlock
+		break
+	}
+
+	if nextBlock > blockNum {
+		return value, found, nil
+	}
+
+	outputFiles, err := outputs.ListFilesUpTo(ctx, nextBlock, blockNum)
+	if err != nil {
+		return nil, false, fmt.Errorf("listing output files: %w", err)
+	}
+	for _, outputFile := range outputFiles {
+		if outputFile.BlockRange.StartBlock > nextBlock {
+			break
+		}
+
+		file := outputs.NewFile(block.NewRange(outputFile.BlockRange.StartBlock, outputFile.BlockRange.ExclusiveEndBlock))
+		if err := file.Load(ctx); err != nil {
+			return nil, false, fmt.Errorf("loading output file %s: %w", outputFile.Filename, err)
+		}
+
+		for _, item := range file.SortedItems() {
+			if item.BlockNum < nextBlock || item.BlockNum > blockNum {
+				continue
+			}
+
+			deltas := &pbssinternal.StoreDeltas{}
+			if err := proto.Unmarshal(item.Payload, deltas); err != nil {
+				return nil, false, fmt.Errorf("unmarshalling output deltas at block %d: %w", item.BlockNum, err)
+			}
+			for _, delta := range deltas.StoreDeltas {
+				if delta.Key != key {
+					continue
+				}
+				if delta.Operation == pbssinternal.StoreDelta_DELETE {
+					value, found = nil, false
+					continue
+				}
+				value, found = delta.NewValue, true
+			}
+		}
+		nextBlock = outputFile.BlockRange.ExclusiveEndBlock
+		if nextBlock > blockNum {
+			return value, found, nil
+		}
+	}
+
+	return nil, false, fmt.Errorf("no cached outputs of module %q found from block %d", storeConfig.Name(), nextBlock)
+}
@@ -0,0 +1,73 @@
+package state
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamingfast/substreams/block"
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+func TestReadKeyAtBlock(t *testing.T) {
+	ctx := context.Background()
+	objStore, err := dstore.NewStore("file://"+t.TempDir(), "zst", "zstd", true)
+	require.NoError(t, err)
+
+	storeConfig, err := store.NewConfig("test", 5, "test.module.hash", pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string", objStore, "")
+	require.NoError(t, err)
+	outputs, err := execout.NewConfig("test", 5, pbsubstreams.ModuleKindStore, "test.module.hash", objStore, zap.NewNop())
+	require.NoError(t, err)
+
+	full := storeConfig.NewFullKV(zap.NewNop())
+	full.Set(0, "a", "1")
+	_, writer, err := full.Save(10)
+	require.NoError(t, err)
+	require.NoError(t, writer.Write(ctx))
+
+	file := outputs.NewFile(block.NewRange(10, 20))
+	for blockNum, delta := range map[uint64]*pbssinternal.StoreDelta{
+		12: {Operation: pbssinternal.StoreDelta_UPDATE, Key: "a", OldValue: []byte("1"), NewValue: []byte("2")},
+		13: {Operation: pbssinternal.StoreDelta_CREATE, Key: "b", NewValue: []byte("3")},
+		15: {Operation: pbssinternal.StoreDelta_DELETE, Key: "a", OldValue: []byte("2")},
+		18: {Operation: pbssinternal.StoreDelta_CREATE, Key: "a", NewValue: []byte("5")},
+	} {
+		data, err := proto.Marshal(&pbssinternal.StoreDeltas{StoreDeltas: []*pbssinternal.StoreDelta{delta}})
+		require.NoError(t, err)
+		file.SetItem(&pbsubstreams.Clock{Number: blockNum, Id: fmt.Sprintf("%d", blockNum)}, data)
+	}
+	require.NoError(t, file.Save(ctx))
+
+	for _, test := range []struct {
+		blockNum      uint64
+		expectedValue string
+		expectedFound bool
+	}{
+		{blockNum: 4},
+		{blockNum: 9, expectedValue: "1", expectedFound: true},
+		{blockNum: 11, expectedValue: "1", expectedFound: true},
+		{blockNum: 12, expectedValue: "2", expectedFound: true},
+		{blockNum: 14, expectedValue: "2", expectedFound: true},
+		{blockNum: 15},
+		{blockNum: 19, expectedValue: "5", expectedFound: true},
+	} {
+		t.Run(fmt.Sprintf("block %d", test.blockNum), func(t *testing.T) {
+			value, found, err := ReadKeyAtBlock(ctx, storeConfig, outputs, "a", test.blockNum)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedFound, found)
+			assert.Equal(t, test.expectedValue, string(value))
+		})
+	}
+
+	_, _, err = ReadKeyAtBlock(ctx, storeConfig, outputs, "a", 20)
+	assert.Error(t, err)
+}
@@ -1,8 +1,8 @@
 package store
 
 import (
+	"fmt"
 	"sort"
-	"strings"
 
 	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
 )
@@ -27,20 +27,47 @@ import (
 func (b *baseStore) DeletePrefix(ord uint64, prefix string) {
 	b.bumpOrdinal(ord)
 
-	var deltas []*pbssinternal.StoreDelta
-	for key, val := range b.kv {
-		if !strings.HasPrefix(key, prefix) {
-			continue
-		}
-		delta := &pbssinternal.StoreDelta{
+	deltas, err := b.deleteDeltas(ord, func(f func(key string, value []byte) error) error {
+		return b.kv.IterPrefix(prefix, f)
+	})
+	if err != nil {
+		panic(fmt.Errorf("iterating keys with prefix %q of store %q: %w", prefix, b.name, err))
+	}
+	b.applyDeleteDeltas(deltas)
+}
+
+// DeleteRange deletes the keys between `lowKey` (inclusive) and `highKey`
+// (exclusive), an empty `highKey` meaning no upper bound.
+func (b *baseStore) DeleteRange(ord uint64, lowKey, highKey string) {
+	b.bumpOrdinal(ord)
+
+	deltas, err := b.deleteDeltas(ord, func(f func(key string, value []byte) error) error {
+		return b.kv.IterRange(lowKey, highKey, f)
+	})
+	if err != nil {
+		panic(fmt.Errorf("iterating keys in range [%q, %q) of store %q: %w", lowKey, highKey, b.name, err))
+	}
+	b.applyDeleteDeltas(deltas)
+}
+
+func (b *baseStore) deleteDeltas(ord uint64, iter func(f func(key string, value []byte) error) error) (deltas []*pbssinternal.StoreDelta, err error) {
+	err = iter(func(key string, val []byte) error {
+		deltas = append(deltas, &pbssinternal.StoreDelta{
 			Operation: pbssinternal.StoreDelta_DELETE,
 			Ordinal:   ord,
 			Key:       key,
 			OldValue:  val,
 			NewValue:  nil,
-		}
+		})
+		return nil
+	})
+	return deltas, err
+}
+
+func (b *baseStore) applyDeleteDeltas(deltas []*pbssinternal.StoreDelta) {
+	// deltas are applied once iteration is done, backends cannot be modified while iterated
+	for _, delta := range deltas {
 		b.ApplyDelta(delta)
-		deltas = append(deltas, delta)
 	}
 	sort.Slice(deltas, func(i, j int) bool {
 		return deltas[i].Key < deltas[j].Key
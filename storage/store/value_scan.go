@@ -0,0 +1,42 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// KV is a key and its value, as returned by the store scans.
+type KV struct {
+	Key   string
+	Value []byte
+}
+
+var errScanLimitReached = errors.New("scan limit reached")
+
+// ScanPrefix returns, in key order, the keys starting with `prefix` with
+// their latest value. At most `limit` keys are returned, 0 meaning no limit.
+func (b *baseStore) ScanPrefix(prefix string, limit uint64) []KV {
+	return b.scan(prefix, prefixUpperBound(prefix), limit)
+}
+
+// ScanRange returns, in key order, the keys between `lowKey` (inclusive)
+// and `highKey` (exclusive) with their latest value, an empty `highKey`
+// meaning no upper bound. At most `limit` keys are returned, 0 meaning no
+// limit. The next page starts at the last key returned followed by "\x00".
+func (b *baseStore) ScanRange(lowKey, highKey string, limit uint64) []KV {
+	return b.scan(lowKey, highKey, limit)
+}
+
+func (b *baseStore) scan(lowKey, highKey string, limit uint64) (out []KV) {
+	err := b.kv.IterRange(lowKey, highKey, func(key string, value []byte) error {
+		out = append(out, KV{Key: key, Value: value})
+		if limit != 0 && uint64(len(out)) >= limit {
+			return errScanLimitReached
+		}
+		return nil
+	})
+	if err != nil && err != errScanLimitReached {
+		panic(fmt.Errorf("scanning keys in range [%q, %q) of store %q: %w", lowKey, highKey, b.name, err))
+	}
+	return out
+}
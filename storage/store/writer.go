@@ -2,15 +2,40 @@ package store
 
 import (
 	"context"
+	"os"
+
 	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/storage/catalog"
 )
 
 type fileWriter struct {
 	store    dstore.Store
+	catalog  *catalog.Catalog
 	filename string
 	content  []byte
+
+	// contentPath is set instead of `content` for stores not kept in
+	// memory, whose snapshot is spilled to this local file until written.
+	contentPath string
 }
 
-func (f *fileWriter) Write(ctx context.Context) error {
-	return saveStore(ctx, f.store, f.filename, f.content)
+func (f *fileWriter) Write(ctx context.Context) (err error) {
+	if f.contentPath != "" {
+		defer os.Remove(f.contentPath)
+		err = saveStoreFile(ctx, f.store, f.filename, f.contentPath)
+	} else {
+		err = saveStore(ctx, f.store, f.filename, f.content)
+	}
+	if err != nil {
+		return err
+	}
+
+	if f.catalog != nil {
+		if err := f.catalog.Record(ctx, catalog.Entry{Dir: catalog.DirStates, Filename: f.filename}); err != nil {
+			zlog.Warn("cannot record store file to catalog", zap.String("filename", f.filename), zap.Error(err))
+		}
+	}
+	return nil
 }
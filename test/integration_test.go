@@ -3,6 +3,8 @@ package integration
 import (
 	"context"
 	"fmt"
+	"io"
+	"math"
 	"math/big"
 	"os"
 	"path/filepath"
@@ -10,14 +12,28 @@ import (
 	"testing"
 	"time"
 
+	"github.com/bufbuild/connect-go"
 	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/dstore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 
 	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/manifest"
 	"github.com/streamingfast/substreams/orchestrator/stage"
 	"github.com/streamingfast/substreams/orchestrator/work"
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/service"
+	"github.com/streamingfast/substreams/service/config"
+	"github.com/streamingfast/substreams/storage/catalog"
+	"github.com/streamingfast/substreams/storage/execout"
+	pboutput "github.com/streamingfast/substreams/storage/execout/pb"
+	"github.com/streamingfast/substreams/storage/gc"
 
 	//_ "github.com/streamingfast/substreams/wasm/wasmtime"
 	_ "github.com/streamingfast/substreams/wasm/wazero"
@@ -390,6 +406,42 @@ func TestOneStoreOneMap(t *testing.T) {
 	}
 }
 
+func TestMultipleOutputModules(t *testing.T) {
+	tests := []struct {
+		name        string
+		linearBlock uint64
+		production  bool
+	}{
+		{name: "dev_mode", linearBlock: 25},
+		{name: "prod_mode_back_forward_to_lib", linearBlock: 27, production: true},
+		{name: "prod_mode_back_forward_to_stop", linearBlock: 29, production: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			run := newTestRun(t, 25, test.linearBlock, 29, "")
+			run.ModuleNames = []string{"assert_test_store_add_i64", "test_map"}
+			run.Params = map[string]string{"test_map": "my test params"}
+			run.ProductionMode = test.production
+			run.ParallelSubrequests = 5
+			require.NoError(t, run.Run(t, test.name))
+
+			var blocks []uint64
+			for _, response := range run.Responses {
+				data := response.GetBlockScopedData()
+				if data == nil {
+					continue
+				}
+				blocks = append(blocks, data.Clock.Number)
+				assert.Nil(t, data.Output)
+				require.Len(t, data.Outputs, 2)
+				assert.Equal(t, "assert_test_store_add_i64", data.Outputs[0].Name)
+				assert.Equal(t, "test_map", data.Outputs[1].Name)
+			}
+			assert.Equal(t, []uint64{25, 26, 27, 28}, blocks)
+		})
+	}
+}
+
 func TestStoreDeletePrefix(t *testing.T) {
 	run := newTestRun(t, 30, 41, 41, "assert_test_store_delete_prefix")
 	run.BlockProcessedCallback = func(ctx *execContext) {
@@ -403,6 +455,45 @@ func TestStoreDeletePrefix(t *testing.T) {
 	require.NoError(t, run.Run(t, "test_store_delete_prefix"))
 }
 
+func TestStoreQuery(t *testing.T) {
+	run := newTestRun(t, 25, 25, 29, "assert_test_store_add_i64")
+	run.ProductionMode = true
+	require.NoError(t, run.Run(t, "store_query"))
+
+	baseStore, err := dstore.NewStore(filepath.Join(run.TempDir, "test.store"), "", "none", true)
+	require.NoError(t, err)
+	svc := service.TestNewService(config.NewRuntimeConfig(10, 1, 10, 0, baseStore, "tag", nil), 0, nil)
+	module := &pbsubstreamsrpc.StoreQueryModule{Modules: run.Package.Modules, ModuleName: "setup_test_store_add_i64"}
+	ctx := context.Background()
+
+	get, err := svc.Get(ctx, connect.NewRequest(&pbsubstreamsrpc.StoreGetRequest{Module: module, Key: "a.key"}))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(20), get.Msg.Snapshot.ExclusiveEndBlock)
+	assert.True(t, get.Msg.Found)
+	assert.Equal(t, "0", string(get.Msg.Value)) // adds i64::MAX, i64::MIN and 1 on each block
+
+	getMany, err := svc.GetMany(ctx, connect.NewRequest(&pbsubstreamsrpc.StoreGetManyRequest{Module: module, Keys: []string{"b.key", "a.key"}}))
+	require.NoError(t, err)
+	require.Len(t, getMany.Msg.Entries, 1)
+	assert.Equal(t, "a.key", getMany.Msg.Entries[0].Key)
+
+	_, err = svc.GetMany(ctx, connect.NewRequest(&pbsubstreamsrpc.StoreGetManyRequest{Module: module, Keys: make([]string, service.StoreQueryMaxKeys+1)}))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	scan, err := svc.ScanPrefix(ctx, connect.NewRequest(&pbsubstreamsrpc.StoreScanPrefixRequest{Module: module, Prefix: "a."}))
+	require.NoError(t, err)
+	require.Len(t, scan.Msg.Entries, 1)
+	assert.False(t, scan.Msg.More)
+
+	scan, err = svc.ScanPrefix(ctx, connect.NewRequest(&pbsubstreamsrpc.StoreScanPrefixRequest{Module: module, Prefix: "a.", StartKey: "a.key\x00"}))
+	require.NoError(t, err)
+	assert.Empty(t, scan.Msg.Entries)
+	assert.False(t, scan.Msg.More)
+
+	_, err = svc.Get(ctx, connect.NewRequest(&pbsubstreamsrpc.StoreGetRequest{Module: &pbsubstreamsrpc.StoreQueryModule{Modules: run.Package.Modules, ModuleName: "assert_test_store_add_i64"}, Key: "a.key"}))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
 func TestAllAssertions(t *testing.T) {
 	// Relies on `assert_all_test` having modInit == 1, so
 	run := newTestRun(t, 1, 31, 31, "assert_all_test")
@@ -479,6 +570,168 @@ func Test_SingleMapModule_FileWalker(t *testing.T) {
 	require.NoError(t, run.Run(t, "test_map"))
 }
 
+func TestTier2ResumeFromCheckpoint(t *testing.T) {
+	tests := []struct {
+		name         string
+		module       string
+		wantedFiles  []string
+		interruptAt  uint64
+		resumedBlock uint64
+	}{
+		{
+			name:   "mapper outputs",
+			module: "test_map",
+			wantedFiles: []string{
+				"0000000010-0000000020.output",
+				"0000000020-0000000030.output",
+				"0000000030-0000000040.output",
+			},
+			interruptAt:  24,
+			resumedBlock: 25,
+		},
+		{
+			name:   "store partials",
+			module: "setup_test_store_add_i64",
+			wantedFiles: []string{
+				"0000000020-0000000010.00000000000000000000000000000000.partial",
+				"0000000030-0000000020.00000000000000000000000000000000.partial",
+				"0000000040-0000000030.00000000000000000000000000000000.partial",
+			},
+			interruptAt:  24,
+			resumedBlock: 25,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkg := manifest.TestReadManifest(t, "./testdata/substreams-test-v0.1.0.spkg")
+			for _, mod := range pkg.Modules.Modules {
+				if mod.Name == "test_map" {
+					mod.Inputs[0].GetParams().Value = "my test params"
+				}
+			}
+			ctx := reqctx.WithLogger(context.Background(), zlog)
+
+			newRequest := func(resumeFromBlock uint64) *pbssinternal.ProcessRangeRequest {
+				return &pbssinternal.ProcessRangeRequest{
+					StartBlockNum:   10,
+					StopBlockNum:    40,
+					Modules:         pkg.Modules,
+					OutputModule:    test.module,
+					ResumeFromBlock: resumeFromBlock,
+				}
+			}
+			newBlockGenerator := func(interruptAt uint64) BlockGeneratorFactory {
+				return func(startBlock uint64, inclusiveStopBlock uint64) TestBlockGenerator {
+					return fixedTimeBlockGenerator{LinearBlockGenerator{
+						startBlock:         startBlock,
+						inclusiveStopBlock: min(inclusiveStopBlock, interruptAt),
+					}}
+				}
+			}
+			checkpoints := service.WithJobCheckpoints(time.Nanosecond)
+
+			uninterruptedDir := t.TempDir()
+			require.NoError(t, processInternalRequest(t, ctx, newRequest(0), nil, newBlockGenerator(math.MaxUint64), newResponseCollector(), nil, uninterruptedDir, nil, checkpoints))
+
+			// The stream of the first attempt dies after `interruptAt`,
+			// leaving its last checkpoint behind.
+			resumedDir := t.TempDir()
+			interrupted := newResponseCollector()
+			require.NoError(t, processInternalRequest(t, ctx, newRequest(0), nil, newBlockGenerator(test.interruptAt), interrupted, nil, resumedDir, nil, checkpoints))
+
+			var checkpointBlock uint64
+			for _, resp := range interrupted.internalResponses {
+				if update := resp.GetUpdate(); update != nil {
+					checkpointBlock = max(checkpointBlock, update.CheckpointBlock)
+				}
+			}
+			require.Equal(t, test.resumedBlock, checkpointBlock)
+			assert.NotEmpty(t, checkpointFiles(t, resumedDir))
+
+			var resumedFrom uint64
+			resumed := func(ctx *execContext) {
+				if resumedFrom == 0 {
+					resumedFrom = ctx.block.Number
+				}
+			}
+			require.NoError(t, processInternalRequest(t, ctx, newRequest(checkpointBlock), nil, newBlockGenerator(math.MaxUint64), newResponseCollector(), resumed, resumedDir, nil, checkpoints))
+			assert.Equal(t, test.resumedBlock, resumedFrom)
+			assert.Empty(t, checkpointFiles(t, resumedDir), "checkpoints are deleted once the job completed")
+
+			uninterruptedFiles := readCacheFiles(t, uninterruptedDir)
+			resumedFiles := readCacheFiles(t, resumedDir)
+
+			var filenames []string
+			for filename := range resumedFiles {
+				filenames = append(filenames, filepath.Base(filename))
+			}
+			assert.ElementsMatch(t, test.wantedFiles, filenames)
+			assert.Equal(t, uninterruptedFiles, resumedFiles)
+		})
+	}
+}
+
+// fixedTimeBlockGenerator generates the blocks of LinearBlockGenerator,
+// timestamped after their number, for the outputs of two runs to be equal.
+type fixedTimeBlockGenerator struct {
+	LinearBlockGenerator
+}
+
+func (g fixedTimeBlockGenerator) Generate() []*GeneratedBlock {
+	blocks := g.LinearBlockGenerator.Generate()
+	for _, blk := range blocks {
+		blk.block.Timestamp = time.Unix(int64(blk.block.Number), 0)
+	}
+	return blocks
+}
+
+// checkpointFiles returns the checkpoints written under the cache of
+// `tempDir`, in the `checkpoints` directory of their module hash.
+func checkpointFiles(t *testing.T, tempDir string) (out []string) {
+	for _, filename := range listFiles(t, tempDir) {
+		if strings.Contains(filename, string(os.PathSeparator)+"checkpoints"+string(os.PathSeparator)) {
+			out = append(out, filename)
+		}
+	}
+	return
+}
+
+// readCacheFiles returns the content of the outputs and store files written
+// under the cache of `tempDir`, by path. The outputs, a map by block ID, are
+// marshalled again in block order.
+func readCacheFiles(t *testing.T, tempDir string) map[string][]byte {
+	files := make(map[string][]byte)
+	for _, filename := range listFiles(t, tempDir) {
+		switch filepath.Ext(filename) {
+		case ".output":
+			f, err := os.Open(filepath.Join(tempDir, filename))
+			require.NoError(t, err)
+			reader, err := execout.NewItemReader(f, 0)
+			require.NoError(t, err)
+
+			outputs := &pboutput.Array{}
+			for {
+				item, err := reader.Next()
+				if err == io.EOF {
+					break
+				}
+				require.NoError(t, err)
+				outputs.Items = append(outputs.Items, item)
+			}
+			require.NoError(t, reader.Close())
+
+			files[filename], err = proto.Marshal(outputs)
+			require.NoError(t, err)
+		case ".partial", ".kv":
+			content, err := os.ReadFile(filepath.Join(tempDir, filename))
+			require.NoError(t, err)
+			files[filename] = content
+		}
+	}
+	return files
+}
+
 func cancelledContext(delay time.Duration) context.Context {
 	ctx, cancel := context.WithCancel(context.Background())
 	go func() {
@@ -507,17 +760,25 @@ func assertFiles(t *testing.T, tempDir string, wantedFiles ...string) {
 	producedFiles := listFiles(t, tempDir)
 
 	actualFiles := make([]string, 0, len(producedFiles))
-	var seenPartialSpkg bool
+	var seenPartialSpkg, seenLastAccess bool
 	for _, f := range producedFiles {
 		parts := strings.Split(f, string(os.PathSeparator))
 		if parts[len(parts)-1] == "substreams.partial.spkg" {
 			seenPartialSpkg = true
 			continue
 		}
+		if parts[len(parts)-1] == gc.LastAccessFilename {
+			seenLastAccess = true
+			continue
+		}
+		if parts[4] == catalog.DirName {
+			continue
+		}
 		actualFiles = append(actualFiles, filepath.Join(parts[4:]...))
 	}
 
 	assert.True(t, seenPartialSpkg, "substreams.partial.spkg should be produced")
+	assert.True(t, seenLastAccess, "last_access.json should be produced")
 	assert.ElementsMatch(t, wantedFiles, actualFiles)
 }
 
@@ -530,7 +791,7 @@ func partialPreWork(t *testing.T, start, end uint64, stageIdx int, run *testRun,
 	segmenter := block.NewSegmenter(10, 0, 0)
 	unit := stage.Unit{Segment: segmenter.IndexForStartBlock(start), Stage: stageIdx}
 	ctx := reqctx.WithRequest(run.Context, &reqctx.RequestDetails{Modules: run.Package.Modules, OutputModule: run.ModuleName, CacheTag: "tag"})
-	cmd := worker.Work(ctx, unit, block.NewRange(start, end), []string{run.ModuleName}, nil)
+	cmd := worker.Work(ctx, unit, block.NewRange(start, end), 0, []string{run.ModuleName}, nil)
 	result := cmd()
 	msg, ok := result.(work.MsgJobSucceeded)
 	require.True(t, ok)
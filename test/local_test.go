@@ -0,0 +1,113 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/streamingfast/bstream"
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamingfast/substreams"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreamstest "github.com/streamingfast/substreams/pb/sf/substreams/v1/test"
+	"github.com/streamingfast/substreams/service"
+)
+
+func TestLocalBlocks(t *testing.T) {
+	if bstream.GetBlockReaderFactory == nil {
+		bstream.GetBlockPayloadSetter = bstream.MemoryBlockPayloadSetter
+		bstream.GetBlockReaderFactory = bstream.BlockReaderFactoryFunc(func(reader io.Reader) (bstream.BlockReader, error) {
+			return bstream.NewDBinBlockReader(reader, func(contentType string, version int32) error {
+				return nil
+			})
+		})
+	}
+
+	tempDir := t.TempDir()
+	mergedBlocksStore, err := dstore.NewDBinStore(filepath.Join(tempDir, "merged"))
+	require.NoError(t, err)
+	writeTestMergedBlocks(t, mergedBlocksStore, 0, 100)
+
+	stateStore, err := dstore.NewStore(filepath.Join(tempDir, "states"), "zst", "zstd", true)
+	require.NoError(t, err)
+
+	run := newTestRun(t, 1, 29, 29, "assert_test_store_add_i64")
+	svc := service.NewLocal(zlog, mergedBlocksStore, stateStore, "", "sf.substreams.v1.test.Block", 5, 10)
+
+	t.Run("stop block required", func(t *testing.T) {
+		err := svc.LocalBlocks(context.Background(), &pbsubstreamsrpc.Request{
+			StartBlockNum: 1,
+			Modules:       run.Package.Modules,
+			OutputModule:  "assert_test_store_add_i64",
+		}, func(substreams.ResponseFromAnyTier) error { return nil })
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "a stop block is required")
+	})
+
+	t.Run("backprocesses stores with local jobs", func(t *testing.T) {
+		responseCollector := newResponseCollector()
+		err := svc.LocalBlocks(context.Background(), &pbsubstreamsrpc.Request{
+			StartBlockNum:  1,
+			StopBlockNum:   29,
+			Modules:        run.Package.Modules,
+			OutputModule:   "assert_test_store_add_i64",
+			ProductionMode: true,
+		}, responseCollector.Collect)
+		require.NoError(t, err)
+
+		run.Responses = responseCollector.responses
+		mapOutput := run.MapOutput("assert_test_store_add_i64")
+		assert.Contains(t, mapOutput, `assert_test_store_add_i64: 0801`)
+		assert.Equal(t, 28, strings.Count(mapOutput, "\n"))
+
+		var stateFiles []string
+		require.NoError(t, stateStore.Walk(context.Background(), "", func(filename string) error {
+			stateFiles = append(stateFiles, filename)
+			return nil
+		}))
+		assert.NotEmpty(t, stateFiles, "local jobs should have written the stores to the state store")
+	})
+}
+
+// writeTestMergedBlocks writes the merged blocks file of the 100 blocks bundle
+// starting at `bundleStart`, holding the test blocks up to `exclusiveEnd`.
+func writeTestMergedBlocks(t *testing.T, mergedBlocksStore dstore.Store, bundleStart, exclusiveEnd uint64) {
+	t.Helper()
+
+	buf := bytes.NewBuffer(nil)
+	writer, err := bstream.NewDBinBlockWriter(buf, "tst", 1)
+	require.NoError(t, err)
+
+	for i := bundleStart; i < exclusiveEnd; i++ {
+		libNum := uint64(0)
+		previousID := ""
+		if i > 0 {
+			libNum = i - 1
+			previousID = fmt.Sprintf("block-%d", i-1)
+		}
+		payload, err := proto.Marshal(&pbsubstreamstest.Block{Id: fmt.Sprintf("block-%d", i), Number: i})
+		require.NoError(t, err)
+
+		blk := &bstream.Block{
+			Id:         fmt.Sprintf("block-%d", i),
+			Number:     i,
+			PreviousId: previousID,
+			Timestamp:  time.Unix(int64(i), 0),
+			LibNum:     libNum,
+		}
+		blk, err = bstream.MemoryBlockPayloadSetter(blk, payload)
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(blk))
+	}
+
+	require.NoError(t, mergedBlocksStore.WriteObject(context.Background(), fmt.Sprintf("%010d", bundleStart), buf))
+}
@@ -42,6 +42,7 @@ type testRun struct {
 	StartBlock             int64
 	ExclusiveEndBlock      uint64
 	ModuleName             string
+	ModuleNames            []string // requested as `output_modules` in place of ModuleName when set
 	ParallelSubrequests    uint64
 	NewBlockGenerator      BlockGeneratorFactory
 	BlockProcessedCallback blockProcessedCallBack
@@ -94,6 +95,10 @@ func (f *testRun) Run(t *testing.T, testName string) error {
 		OutputModule:   f.ModuleName,
 		ProductionMode: f.ProductionMode,
 	}
+	if len(f.ModuleNames) != 0 {
+		request.OutputModule = ""
+		request.OutputModules = f.ModuleNames
+	}
 
 	if f.Params != nil {
 		for k, v := range f.Params {
@@ -238,6 +243,7 @@ func processInternalRequest(
 	blockProcessedCallBack blockProcessedCallBack,
 	testTempDir string,
 	traceID *string,
+	opts ...service.Option,
 ) error {
 	t.Helper()
 
@@ -261,7 +267,7 @@ func processInternalRequest(
 		"tag",
 		workerFactory,
 	)
-	svc := service.TestNewServiceTier2(runtimeConfig, tr.StreamFactory)
+	svc := service.TestNewServiceTier2(runtimeConfig, tr.StreamFactory, opts...)
 
 	return svc.TestProcessRange(ctx, request, responseCollector.Collect, traceID)
 }
@@ -32,10 +32,10 @@ func (w *TestWorker) ID() string {
 	return fmt.Sprintf("%d", w.id)
 }
 
-func (w *TestWorker) Work(ctx context.Context, unit stage.Unit, workRange *block.Range, moduleNames []string, upstream *response.Stream) loop.Cmd {
+func (w *TestWorker) Work(ctx context.Context, unit stage.Unit, workRange *block.Range, attempt uint32, moduleNames []string, upstream *response.Stream) loop.Cmd {
 	w.t.Helper()
 
-	request := work.NewRequest(reqctx.Details(ctx), unit.Stage, workRange)
+	request := work.NewRequest(reqctx.Details(ctx), unit.Stage, workRange, attempt)
 
 	logger := reqctx.Logger(ctx)
 	logger = logger.With(zap.Uint64("workerId", w.id))
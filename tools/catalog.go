@@ -0,0 +1,174 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/cli"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/storage/catalog"
+	"github.com/streamingfast/substreams/storage/gc"
+)
+
+var catalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Check and repair the catalogs listing the files cached for each module hash",
+}
+
+var catalogCheckCmd = &cobra.Command{
+	Use:   "check <store_url> [<module_hash>...]",
+	Short: "Compare the catalogs of the module hashes with the files present",
+	Long: cli.Dedent(`
+		Compare the files listed by the catalog of each module hash of '<store_url>' with the files present in its
+		'outputs' and 'states' directories. Without any '<module_hash>', every module hash found in the store is
+		checked, which lists the whole store. Files written or deleted while checking may be reported.
+	`),
+	Example: ExamplePrefixed("substreams tools catalog check", `
+		gs://bucket/substreams-states/v1
+		./localdata 3f3a4b35a8c2b5b1c9e0d0c3c1f0b0c5d4e3f2a1
+	`),
+	Args:         cobra.MinimumNArgs(1),
+	RunE:         catalogCheckE,
+	SilenceUsage: true,
+}
+
+var catalogRepairCmd = &cobra.Command{
+	Use:   "repair <store_url> [<module_hash>...]",
+	Short: "Rebuild the catalogs missing or inconsistent with the files present",
+	Long: cli.Dedent(`
+		Rebuild, from a listing of their files, the catalogs of the module hashes of '<store_url>' which are missing,
+		unreadable or inconsistent with the files present. Without any '<module_hash>', every module hash found in
+		the store is repaired, which lists the whole store.
+	`),
+	Example: ExamplePrefixed("substreams tools catalog repair", `
+		gs://bucket/substreams-states/v1
+		./localdata 3f3a4b35a8c2b5b1c9e0d0c3c1f0b0c5d4e3f2a1 --force
+	`),
+	Args:         cobra.MinimumNArgs(1),
+	RunE:         catalogRepairE,
+	SilenceUsage: true,
+}
+
+func init() {
+	catalogRepairCmd.Flags().Bool("force", false, "Rebuild the catalogs even when consistent")
+
+	catalogCmd.AddCommand(catalogCheckCmd)
+	catalogCmd.AddCommand(catalogRepairCmd)
+	Cmd.AddCommand(catalogCmd)
+}
+
+func catalogCheckE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	store, moduleDirs, err := catalogModuleDirs(cmd, args)
+	if err != nil {
+		return err
+	}
+
+	var inconsistent int
+	for _, moduleDir := range moduleDirs {
+		cat, err := catalog.New(store, moduleDir, zlog)
+		if err != nil {
+			return err
+		}
+
+		diff, err := cat.Check(ctx)
+		switch {
+		case errors.Is(err, catalog.ErrNotFound):
+			inconsistent++
+			fmt.Printf("%s: no catalog\n", moduleDir)
+		case errors.Is(err, catalog.ErrInconsistent):
+			inconsistent++
+			fmt.Printf("%s: %s\n", moduleDir, err)
+		case err != nil:
+			return fmt.Errorf("checking catalog of %s: %w", moduleDir, err)
+		case diff.Empty():
+			fmt.Printf("%s: OK\n", moduleDir)
+		default:
+			inconsistent++
+			fmt.Printf("%s: %d files unknown to the catalog, %d files listed but not present\n", moduleDir, len(diff.Unknown), len(diff.Stale))
+			for _, filename := range diff.Unknown {
+				fmt.Printf("  unknown: %s\n", filename)
+			}
+			for _, filename := range diff.Stale {
+				fmt.Printf("  stale: %s\n", filename)
+			}
+		}
+	}
+
+	if inconsistent != 0 {
+		return fmt.Errorf("%d of %d catalogs missing or inconsistent, use 'substreams tools catalog repair' to rebuild them", inconsistent, len(moduleDirs))
+	}
+	return nil
+}
+
+func catalogRepairE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	store, moduleDirs, err := catalogModuleDirs(cmd, args)
+	if err != nil {
+		return err
+	}
+	force := mustGetBool(cmd, "force")
+
+	var rebuilt int
+	for _, moduleDir := range moduleDirs {
+		cat, err := catalog.New(store, moduleDir, zlog)
+		if err != nil {
+			return err
+		}
+
+		if !force {
+			diff, err := cat.Check(ctx)
+			if err != nil && !errors.Is(err, catalog.ErrNotFound) && !errors.Is(err, catalog.ErrInconsistent) {
+				return fmt.Errorf("checking catalog of %s: %w", moduleDir, err)
+			}
+			if err == nil && diff.Empty() {
+				continue
+			}
+		}
+
+		files, err := cat.Rebuild(ctx)
+		if err != nil {
+			return fmt.Errorf("rebuilding catalog of %s: %w", moduleDir, err)
+		}
+		rebuilt++
+		fmt.Printf("%s: rebuilt, %d output files, %d store files\n", moduleDir, len(files.Outputs()), len(files.States()))
+	}
+
+	fmt.Printf("Rebuilt %d of %d catalogs\n", rebuilt, len(moduleDirs))
+	return nil
+}
+
+// catalogModuleDirs returns the store given as first argument, and the
+// module hashes given as next arguments or, if none, all the module hashes
+// found in the store.
+func catalogModuleDirs(cmd *cobra.Command, args []string) (dstore.Store, []string, error) {
+	store, err := dstore.NewStore(args[0], "zst", "zstd", true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating store: %w", err)
+	}
+	if len(args) > 1 {
+		return store, args[1:], nil
+	}
+
+	zlog.Info("listing the store to find module hashes", zap.String("store", args[0]))
+	seen := map[string]bool{}
+	var moduleDirs []string
+	err = store.Walk(cmd.Context(), "", func(filename string) error {
+		if dir, ok := gc.ModuleDir(filename); ok && !seen[dir] {
+			seen[dir] = true
+			moduleDirs = append(moduleDirs, dir)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("walking store: %w", err)
+	}
+	sort.Strings(moduleDirs)
+	return store, moduleDirs, nil
+}
@@ -21,6 +21,7 @@ var checkCmd = &cobra.Command{
 }
 
 func init() {
+	checkCmd.Flags().Bool("verify-content", false, "Also read every kv file through, validating its checksums when it is in the sorted snapshot format")
 	Cmd.AddCommand(checkCmd)
 }
 
@@ -36,6 +37,14 @@ func checkE(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("listing snapshots: %w", err)
 	}
 
+	if mustGetBool(cmd, "verify-content") {
+		for _, file := range files {
+			if err := stateStore.IterFile(ctx, file, func(string, []byte) error { return nil }); err != nil {
+				return fmt.Errorf("**corrupted file** %s: %w", file.Filename, err)
+			}
+		}
+	}
+
 	var prevRange *block.Range
 	for _, file := range files {
 		if !file.Partial {
@@ -148,7 +148,7 @@ func runDecodeStatesModuleRunE(cmd *cobra.Command, args []string) error {
 	startBlock := execout.ComputeStartBlock(blockNumber, saveInterval)
 
 	switch matchingModule.Kind.(type) {
-	case *pbsubstreams.Module_KindMap_:
+	case *pbsubstreams.Module_KindMap_, *pbsubstreams.Module_KindBlockIndex_:
 		return fmt.Errorf("no states are available for a mapper")
 	case *pbsubstreams.Module_KindStore_:
 		return searchStateModule(ctx, startBlock, moduleHash, key, matchingModule, objStore, protoFiles)
@@ -230,7 +230,7 @@ func runDecodeOutputsModuleRunE(cmd *cobra.Command, args []string) error {
 	startBlock := execout.ComputeStartBlock(blockNumber, saveInterval)
 
 	switch matchingModule.Kind.(type) {
-	case *pbsubstreams.Module_KindMap_:
+	case *pbsubstreams.Module_KindMap_, *pbsubstreams.Module_KindBlockIndex_:
 		return searchOutputsModule(ctx, blockNumber, startBlock, saveInterval, moduleHash, matchingModule, s, protoFiles)
 	case *pbsubstreams.Module_KindStore_:
 		return searchOutputsModule(ctx, blockNumber, startBlock, saveInterval, moduleHash, matchingModule, s, protoFiles)
@@ -298,14 +298,12 @@ func searchStateModule(
 	if err != nil {
 		return fmt.Errorf("initializing store config module %q: %w", module.Name, err)
 	}
-	moduleStore := config.NewFullKV(zlog)
 
 	file := store.NewCompleteFileInfo(module.Name, module.InitialBlock, startBlock)
-	if err = moduleStore.Load(ctx, file); err != nil {
-		return fmt.Errorf("unable to load file: %w", err)
+	bytes, found, err := config.ReadKey(ctx, file, key)
+	if err != nil {
+		return fmt.Errorf("unable to read file: %w", err)
 	}
-
-	bytes, found := moduleStore.GetLast(key)
 	if !found {
 		return fmt.Errorf("no data found for %q", key)
 	}
@@ -317,7 +315,7 @@ func printObject(module *pbsubstreams.Module, protoFiles []*descriptorpb.FileDes
 	valuePrinted := false
 
 	switch module.Kind.(type) {
-	case *pbsubstreams.Module_KindMap_:
+	case *pbsubstreams.Module_KindMap_, *pbsubstreams.Module_KindBlockIndex_:
 		protoDefinition = module.Output.GetType()
 	case *pbsubstreams.Module_KindStore_:
 		protoDefinition = module.Kind.(*pbsubstreams.Module_KindStore_).KindStore.ValueType
@@ -334,7 +332,7 @@ func printObject(module *pbsubstreams.Module, protoFiles []*descriptorpb.FileDes
 		msgDesc = file.FindMessage(strings.TrimPrefix(protoDefinition, "proto:"))
 		if msgDesc != nil {
 			switch module.Kind.(type) {
-			case *pbsubstreams.Module_KindMap_, *pbsubstreams.Module_KindStore_:
+			case *pbsubstreams.Module_KindMap_, *pbsubstreams.Module_KindStore_, *pbsubstreams.Module_KindBlockIndex_:
 				dynMsg := dynamic.NewMessageFactoryWithDefaults().NewDynamicMessage(msgDesc)
 				val, err := unmarshalData(data, dynMsg)
 				if err != nil {
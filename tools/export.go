@@ -0,0 +1,145 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/cli"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/manifest"
+	"github.com/streamingfast/substreams/tools/export"
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export <manifest> <module_name> <state_store_url>",
+	Short: "Export the cached outputs of a map module to a Parquet or JSONL file, without running a stream",
+	Long: cli.Dedent(`
+		Read the outputs of a map module from its output cache files in '<state_store_url>', the cache of a cache tag
+		('<state-store>/<cache-tag>'), and write them to a file, one row per block. The cache files are found from the
+		module hash, so the manifest and '--params' must be the ones the outputs were produced with.
+
+		The fields of the output message are flattened into typed columns, named after their path in the message
+		('pool.token0.address'), after the '_block_number', '_block_id' and '_block_timestamp' columns. Repeated, map
+		and recursive message fields are held as JSON. Only the blocks with an output are exported.
+
+		The segments of '--state-bundle-size' blocks whose output file is missing are reported, and the command
+		fails once the available segments are exported.
+	`),
+	Example: ExamplePrefixed("substreams tools export", `
+		uniswap-v3.spkg map_pools_created ./localdata/v1 --range 12369621-12400000
+		substreams.yaml map_transfers gs://bucket/substreams-states/v1 --range 17000000-17100000 --format jsonl -o transfers.jsonl
+	`),
+	Args:         cobra.ExactArgs(3),
+	RunE:         exportE,
+	SilenceUsage: true,
+}
+
+func init() {
+	exportCmd.Flags().String("range", "", "Range of blocks to export, as '<start_block>-<stop_block>', the stop block being exclusive")
+	exportCmd.Flags().String("format", "parquet", "Format of the exported file, 'parquet' or 'jsonl'")
+	exportCmd.Flags().StringP("output", "o", "", "Path of the exported file, '<module_name>-<start_block>-<stop_block>.<format>' if empty")
+	exportCmd.Flags().Uint64("state-bundle-size", 1000, "Interval in blocks at which the output caches were written")
+	exportCmd.Flags().StringArrayP("params", "p", nil, "Set a params for parameterizable modules. Can be specified multiple times. Ex: -p module1=valA -p module2=valX&valY")
+
+	Cmd.AddCommand(exportCmd)
+}
+
+func exportE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+	manifestPath, moduleName, storeURL := args[0], args[1], args[2]
+
+	startBlock, stopBlock, err := parseExportRange(mustGetString(cmd, "range"))
+	if err != nil {
+		return err
+	}
+	format, err := export.ParseFormat(mustGetString(cmd, "format"))
+	if err != nil {
+		return err
+	}
+
+	manifestReader, err := manifest.NewReader(manifestPath)
+	if err != nil {
+		return fmt.Errorf("manifest reader: %w", err)
+	}
+	pkg, err := manifestReader.Read()
+	if err != nil {
+		return fmt.Errorf("read manifest %q: %w", manifestPath, err)
+	}
+	if err := manifest.ApplyParams(mustGetStringArray(cmd, "params"), pkg); err != nil {
+		return err
+	}
+
+	cacheStore, err := dstore.NewStore(storeURL, "zst", "zstd", false)
+	if err != nil {
+		return fmt.Errorf("initializing dstore for %q: %w", storeURL, err)
+	}
+
+	exporter, err := export.New(pkg, moduleName, cacheStore, zlog)
+	if err != nil {
+		return err
+	}
+	zlog.Info("exporting module outputs",
+		zap.String("module_name", moduleName),
+		zap.String("module_hash", exporter.ModuleHash()),
+		zap.Int("columns", len(exporter.Columns())),
+		zap.Uint64("start_block", startBlock),
+		zap.Uint64("stop_block", stopBlock),
+	)
+
+	outputPath := mustGetString(cmd, "output")
+	if outputPath == "" {
+		outputPath = fmt.Sprintf("%s-%d-%d.%s", moduleName, startBlock, stopBlock, format)
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("creating %q: %w", outputPath, err)
+	}
+	defer file.Close()
+
+	writer, err := export.NewWriter(format, file, exporter.Columns())
+	if err != nil {
+		return err
+	}
+	report, err := exporter.Export(ctx, startBlock, stopBlock, mustGetUint64(cmd, "state-bundle-size"), writer)
+	if err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("writing %q: %w", outputPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("writing %q: %w", outputPath, err)
+	}
+
+	for _, segment := range report.Missing {
+		fmt.Printf("Missing output file for segment %s\n", segment)
+	}
+	fmt.Printf("Exported %d rows from %d of %d segments of module %q (hash %s) to %s\n", report.Rows, report.Segments-len(report.Missing), report.Segments, moduleName, exporter.ModuleHash(), outputPath)
+
+	if len(report.Missing) != 0 {
+		return fmt.Errorf("%d segments missing from the cache, their blocks were not exported", len(report.Missing))
+	}
+	return nil
+}
+
+func parseExportRange(in string) (startBlock, stopBlock uint64, err error) {
+	start, stop, found := strings.Cut(in, "-")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid '--range' %q, expected <start_block>-<stop_block>", in)
+	}
+	if startBlock, err = strconv.ParseUint(start, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid start block %q: %w", start, err)
+	}
+	if stopBlock, err = strconv.ParseUint(stop, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid stop block %q: %w", stop, err)
+	}
+	if stopBlock <= startBlock {
+		return 0, 0, fmt.Errorf("invalid '--range' %q, stop block must be above start block", in)
+	}
+	return startBlock, stopBlock, nil
+}
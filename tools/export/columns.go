@@ -0,0 +1,205 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Kind is the type of the values of a column.
+type Kind int
+
+const (
+	KindBool Kind = iota
+	KindInt32
+	KindInt64
+	KindUint32
+	KindUint64
+	KindFloat
+	KindDouble
+	KindString
+	KindBytes
+	// KindJSON columns hold the JSON encoding of repeated, map and recursive
+	// message fields, which cannot be flattened.
+	KindJSON
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindBool:
+		return "bool"
+	case KindInt32:
+		return "int32"
+	case KindInt64:
+		return "int64"
+	case KindUint32:
+		return "uint32"
+	case KindUint64:
+		return "uint64"
+	case KindFloat:
+		return "float"
+	case KindDouble:
+		return "double"
+	case KindString:
+		return "string"
+	case KindBytes:
+		return "bytes"
+	case KindJSON:
+		return "json"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Column is a column of an export, holding a field of the output message,
+// possibly nested in singular message fields.
+type Column struct {
+	// Name is the path of the field from the output message, the names of
+	// the fields joined by dots.
+	Name string
+	Kind Kind
+
+	path []*desc.FieldDescriptor
+}
+
+// Columns returns the columns holding the fields of `msgDesc`, in field
+// order. Singular message fields are flattened into the columns of their
+// own fields, except for recursive ones, held as JSON like repeated and map
+// fields.
+func Columns(msgDesc *desc.MessageDescriptor) []*Column {
+	return appendColumns(nil, msgDesc, nil, map[string]bool{msgDesc.GetFullyQualifiedName(): true})
+}
+
+func appendColumns(out []*Column, msgDesc *desc.MessageDescriptor, parent []*desc.FieldDescriptor, seen map[string]bool) []*Column {
+	for _, field := range msgDesc.GetFields() {
+		path := append(append([]*desc.FieldDescriptor{}, parent...), field)
+
+		if msgType := field.GetMessageType(); msgType != nil && !field.IsRepeated() && !seen[msgType.GetFullyQualifiedName()] {
+			seen[msgType.GetFullyQualifiedName()] = true
+			out = appendColumns(out, msgType, path, seen)
+			delete(seen, msgType.GetFullyQualifiedName())
+			continue
+		}
+
+		out = append(out, &Column{
+			Name: columnName(path),
+			Kind: fieldKind(field),
+			path: path,
+		})
+	}
+	return out
+}
+
+func columnName(path []*desc.FieldDescriptor) string {
+	names := make([]string, len(path))
+	for i, field := range path {
+		names[i] = field.GetName()
+	}
+	return strings.Join(names, ".")
+}
+
+func fieldKind(field *desc.FieldDescriptor) Kind {
+	if field.IsRepeated() || field.GetMessageType() != nil {
+		return KindJSON
+	}
+
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_BOOL:
+		return KindBool
+	case descriptorpb.FieldDescriptorProto_TYPE_INT32, descriptorpb.FieldDescriptorProto_TYPE_SINT32, descriptorpb.FieldDescriptorProto_TYPE_SFIXED32:
+		return KindInt32
+	case descriptorpb.FieldDescriptorProto_TYPE_INT64, descriptorpb.FieldDescriptorProto_TYPE_SINT64, descriptorpb.FieldDescriptorProto_TYPE_SFIXED64:
+		return KindInt64
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT32, descriptorpb.FieldDescriptorProto_TYPE_FIXED32:
+		return KindUint32
+	case descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_TYPE_FIXED64:
+		return KindUint64
+	case descriptorpb.FieldDescriptorProto_TYPE_FLOAT:
+		return KindFloat
+	case descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:
+		return KindDouble
+	case descriptorpb.FieldDescriptorProto_TYPE_BYTES:
+		return KindBytes
+	}
+	// strings, and enums by value name
+	return KindString
+}
+
+// Value returns the value of the column in `msg`, nil if the field, or a
+// message field holding it, is not set. Values are of the Go type matching
+// the column's Kind, json.RawMessage for KindJSON.
+func (c *Column) Value(msg *dynamic.Message) (interface{}, error) {
+	for _, field := range c.path[:len(c.path)-1] {
+		if !msg.HasField(field) {
+			return nil, nil
+		}
+		nested, ok := msg.GetField(field).(*dynamic.Message)
+		if !ok {
+			return nil, fmt.Errorf("field %q does not hold a dynamic message", field.GetFullyQualifiedName())
+		}
+		msg = nested
+	}
+
+	field := c.path[len(c.path)-1]
+	if field.HasPresence() && !msg.HasField(field) {
+		return nil, nil
+	}
+	value := msg.GetField(field)
+
+	if c.Kind == KindJSON {
+		if field.IsMap() && len(value.(map[interface{}]interface{})) == 0 || field.IsRepeated() && !field.IsMap() && len(value.([]interface{})) == 0 {
+			return nil, nil
+		}
+		cnt, err := json.Marshal(jsonValue(field, value))
+		if err != nil {
+			return nil, fmt.Errorf("encoding field %q: %w", field.GetFullyQualifiedName(), err)
+		}
+		return json.RawMessage(cnt), nil
+	}
+	if enumType := field.GetEnumType(); enumType != nil {
+		return enumName(enumType, value.(int32)), nil
+	}
+	return value, nil
+}
+
+// jsonValue returns the value of `field`, to be encoded in JSON, messages
+// being encoded like in `substreams run -o json`.
+func jsonValue(field *desc.FieldDescriptor, value interface{}) interface{} {
+	switch {
+	case field.IsMap():
+		out := map[string]interface{}{}
+		for k, v := range value.(map[interface{}]interface{}) {
+			out[fmt.Sprint(k)] = jsonValue(field.GetMapValueType(), v)
+		}
+		return out
+	case field.IsRepeated():
+		values := value.([]interface{})
+		out := make([]interface{}, len(values))
+		for i, v := range values {
+			out[i] = singularJSONValue(field, v)
+		}
+		return out
+	}
+	return singularJSONValue(field, value)
+}
+
+func singularJSONValue(field *desc.FieldDescriptor, value interface{}) interface{} {
+	if enumType := field.GetEnumType(); enumType != nil {
+		return enumName(enumType, value.(int32))
+	}
+	if msg, ok := value.(*dynamic.Message); ok {
+		// a json.Marshaler, encoded as by the message descriptor
+		return msg
+	}
+	return value
+}
+
+func enumName(enumType *desc.EnumDescriptor, number int32) string {
+	if value := enumType.FindValueByNumber(number); value != nil {
+		return value.GetName()
+	}
+	return fmt.Sprint(number)
+}
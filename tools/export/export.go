@@ -0,0 +1,188 @@
+// Package export writes the cached outputs of a map module to files for
+// analytics tools, one row per block, the fields of the output message
+// being flattened into typed columns.
+package export
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/execout"
+	pboutput "github.com/streamingfast/substreams/storage/execout/pb"
+)
+
+// Exporter reads the outputs of a map module from its output cache files.
+type Exporter struct {
+	module  *pbsubstreams.Module
+	config  *execout.Config
+	msgDesc *desc.MessageDescriptor
+	columns []*Column
+
+	logger *zap.Logger
+}
+
+// New returns an Exporter of the outputs of the map module `moduleName` of
+// `pkg`, cached in `cacheStore`, the store of a cache tag.
+func New(pkg *pbsubstreams.Package, moduleName string, cacheStore dstore.Store, logger *zap.Logger) (*Exporter, error) {
+	var module *pbsubstreams.Module
+	for _, mod := range pkg.Modules.Modules {
+		if mod.Name == moduleName {
+			module = mod
+		}
+	}
+	if module == nil {
+		return nil, fmt.Errorf("module %q not found", moduleName)
+	}
+	if module.GetKindMap() == nil {
+		return nil, fmt.Errorf("module %q is not a map, only map outputs are cached", moduleName)
+	}
+
+	descs, err := manifest.BuildMessageDescriptors(pkg)
+	if err != nil {
+		return nil, fmt.Errorf("building message descriptors: %w", err)
+	}
+	msgDesc := descs[moduleName].MessageDescriptor
+	if msgDesc == nil {
+		return nil, fmt.Errorf("output type %q of module %q not found in the package's protobuf definitions", module.Output.Type, moduleName)
+	}
+
+	graph, err := manifest.NewModuleGraph(pkg.Modules.Modules)
+	if err != nil {
+		return nil, fmt.Errorf("processing module graph: %w", err)
+	}
+	hashes := manifest.NewModuleHashes()
+	if _, err := hashes.HashModule(pkg.Modules, module, graph); err != nil {
+		return nil, fmt.Errorf("hashing module %q: %w", moduleName, err)
+	}
+
+	config, err := execout.NewConfig(module.Name, module.InitialBlock, module.ModuleKind(), hashes.Get(module.Name), cacheStore, logger)
+	if err != nil {
+		return nil, fmt.Errorf("configuring output cache: %w", err)
+	}
+	return newExporter(module, config, msgDesc, logger), nil
+}
+
+func newExporter(module *pbsubstreams.Module, config *execout.Config, msgDesc *desc.MessageDescriptor, logger *zap.Logger) *Exporter {
+	return &Exporter{
+		module:  module,
+		config:  config,
+		msgDesc: msgDesc,
+		columns: Columns(msgDesc),
+		logger:  logger,
+	}
+}
+
+// Columns returns the columns of the rows exported.
+func (e *Exporter) Columns() []*Column {
+	return e.columns
+}
+
+// ModuleHash returns the hash of the module exported.
+func (e *Exporter) ModuleHash() string {
+	return e.config.ModuleHash()
+}
+
+// Report is the outcome of an export.
+type Report struct {
+	Segments int
+	Rows     int
+	// Missing are the segments whose output file was not found in the
+	// cache, in block order.
+	Missing []*block.Range
+}
+
+// Export writes to `w` the outputs of the blocks in `[startBlock,
+// exclusiveEndBlock)`, reading them from the output files of the segments
+// of `interval` blocks covering the range. Segments whose file is missing
+// are reported and skipped.
+func (e *Exporter) Export(ctx context.Context, startBlock, exclusiveEndBlock, interval uint64, w Writer) (*Report, error) {
+	report := &Report{}
+	startBlock = max(startBlock, e.module.InitialBlock)
+	if exclusiveEndBlock <= startBlock {
+		return report, nil
+	}
+
+	// Segments are those of the files written, ending at the interval
+	// after the last block exported.
+	segmentsEnd := exclusiveEndBlock - 1 + interval - (exclusiveEndBlock-1)%interval
+	segmenter := block.NewSegmenter(interval, e.module.InitialBlock, segmentsEnd)
+	for idx := max(segmenter.IndexForStartBlock(startBlock), segmenter.FirstIndex()); idx <= segmenter.LastIndex(); idx++ {
+		segment := segmenter.Range(idx)
+		report.Segments++
+
+		rows, err := e.exportSegment(ctx, segment, startBlock, exclusiveEndBlock, w)
+		if err == dstore.ErrNotFound {
+			e.logger.Warn("output file missing", zap.Stringer("segment", segment))
+			report.Missing = append(report.Missing, segment)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("exporting segment %s: %w", segment, err)
+		}
+		report.Rows += rows
+	}
+	return report, nil
+}
+
+func (e *Exporter) exportSegment(ctx context.Context, segment *block.Range, startBlock, exclusiveEndBlock uint64, w Writer) (rows int, err error) {
+	reader, err := e.config.NewFile(segment).OpenItems(ctx, startBlock)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	for {
+		item, err := reader.Next()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		if item.BlockNum >= exclusiveEndBlock {
+			return rows, nil
+		}
+
+		row, err := e.row(item)
+		if err != nil {
+			return rows, err
+		}
+		if err := w.Write(row); err != nil {
+			return rows, fmt.Errorf("writing row of block %d: %w", item.BlockNum, err)
+		}
+		rows++
+	}
+}
+
+func (e *Exporter) row(item *pboutput.Item) (*Row, error) {
+	msg := dynamic.NewMessage(e.msgDesc)
+	if err := msg.Unmarshal(item.Payload); err != nil {
+		return nil, fmt.Errorf("decoding output of block %d: %w", item.BlockNum, err)
+	}
+
+	row := &Row{
+		BlockNum: item.BlockNum,
+		BlockID:  item.BlockId,
+		Values:   make([]interface{}, len(e.columns)),
+	}
+	if item.Timestamp != nil {
+		row.BlockTimestamp = item.Timestamp.AsTime()
+	}
+	for i, column := range e.columns {
+		value, err := column.Value(msg)
+		if err != nil {
+			return nil, fmt.Errorf("column %q of block %d: %w", column.Name, item.BlockNum, err)
+		}
+		row.Values[i] = value
+	}
+	return row, nil
+}
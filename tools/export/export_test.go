@@ -0,0 +1,182 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/parquet-go/parquet-go"
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/streamingfast/substreams/block"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/execout"
+)
+
+const testProto = `
+syntax = "proto3";
+package acme;
+
+message Transfer {
+  string from = 1;
+  uint64 amount = 2;
+  Token token = 3;
+  repeated string tags = 4;
+  map<string, int64> balances = 5;
+  Kind kind = 6;
+  Transfer parent = 7;
+  optional bytes memo = 8;
+}
+
+message Token {
+  string address = 1;
+  int32 decimals = 2;
+}
+
+enum Kind {
+  UNKNOWN = 0;
+  MINT = 1;
+}
+`
+
+func testDescriptor(t *testing.T) *desc.MessageDescriptor {
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{"acme.proto": testProto})}
+	files, err := parser.ParseFiles("acme.proto")
+	require.NoError(t, err)
+	return files[0].FindMessage("acme.Transfer")
+}
+
+func testPayload(t *testing.T, msgDesc *desc.MessageDescriptor, from string, amount uint64) []byte {
+	msg := dynamic.NewMessage(msgDesc)
+	msg.SetFieldByName("from", from)
+	msg.SetFieldByName("amount", amount)
+	if amount > 10 {
+		token := dynamic.NewMessage(msgDesc.GetFile().FindMessage("acme.Token"))
+		token.SetFieldByName("address", "0xabc")
+		token.SetFieldByName("decimals", int32(18))
+		msg.SetFieldByName("token", token)
+		msg.SetFieldByName("tags", []string{"a", "b"})
+		msg.SetFieldByName("balances", map[string]int64{from: 5})
+		msg.SetFieldByName("kind", int32(1))
+	}
+	cnt, err := msg.Marshal()
+	require.NoError(t, err)
+	return cnt
+}
+
+func TestColumns(t *testing.T) {
+	var names []string
+	var kinds []Kind
+	for _, column := range Columns(testDescriptor(t)) {
+		names = append(names, column.Name)
+		kinds = append(kinds, column.Kind)
+	}
+	assert.Equal(t, []string{"from", "amount", "token.address", "token.decimals", "tags", "balances", "kind", "parent", "memo"}, names)
+	assert.Equal(t, []Kind{KindString, KindUint64, KindString, KindInt32, KindJSON, KindJSON, KindString, KindJSON, KindBytes}, kinds)
+}
+
+// testExporter returns an Exporter of a module starting at block 5 whose
+// outputs are cached for the segments [5, 10) and [20, 30).
+func testExporter(t *testing.T) *Exporter {
+	ctx := context.Background()
+	msgDesc := testDescriptor(t)
+
+	store, err := dstore.NewStore("file://"+t.TempDir(), "zst", "zstd", true)
+	require.NoError(t, err)
+	config, err := execout.NewConfig("map_transfers", 5, pbsubstreams.ModuleKindMap, "", store, zap.NewNop())
+	require.NoError(t, err)
+	config.SetFileFormat(execout.FileFormatFramed)
+
+	for _, segment := range []*block.Range{block.NewRange(5, 10), block.NewRange(20, 30)} {
+		file := config.NewFile(segment)
+		for blockNum := segment.StartBlock; blockNum < segment.ExclusiveEndBlock; blockNum += 3 {
+			file.SetItem(&pbsubstreams.Clock{
+				Number:    blockNum,
+				Id:        "id" + strings.Repeat("x", int(blockNum)),
+				Timestamp: timestamppb.New(time.Unix(int64(blockNum), 0)),
+			}, testPayload(t, msgDesc, "alice", blockNum))
+		}
+		require.NoError(t, file.Save(ctx))
+	}
+
+	return newExporter(&pbsubstreams.Module{Name: "map_transfers", InitialBlock: 5}, config, msgDesc, zap.NewNop())
+}
+
+func TestExporter_ExportJSONL(t *testing.T) {
+	exporter := testExporter(t)
+
+	buf := &bytes.Buffer{}
+	writer, err := NewWriter(FormatJSONL, buf, exporter.Columns())
+	require.NoError(t, err)
+	report, err := exporter.Export(context.Background(), 0, 27, 10, writer)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	assert.Equal(t, 3, report.Segments)
+	assert.Equal(t, []*block.Range{block.NewRange(10, 20)}, report.Missing)
+	assert.Equal(t, 5, report.Rows)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+
+	var first, last map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[4]), &last))
+	assert.Equal(t, map[string]interface{}{
+		"_block_number":    float64(5),
+		"_block_id":        "idxxxxx",
+		"_block_timestamp": "1970-01-01T00:00:05Z",
+		"from":             "alice",
+		"amount":           float64(5),
+		"kind":             "UNKNOWN",
+	}, first)
+	assert.Equal(t, float64(26), last["_block_number"])
+	assert.Equal(t, "0xabc", last["token.address"])
+	assert.Equal(t, float64(18), last["token.decimals"])
+	assert.Equal(t, []interface{}{"a", "b"}, last["tags"])
+	assert.Equal(t, map[string]interface{}{"alice": float64(5)}, last["balances"])
+	assert.Equal(t, "MINT", last["kind"])
+}
+
+func TestExporter_ExportParquet(t *testing.T) {
+	exporter := testExporter(t)
+
+	buf := &bytes.Buffer{}
+	writer, err := NewWriter(FormatParquet, buf, exporter.Columns())
+	require.NoError(t, err)
+	report, err := exporter.Export(context.Background(), 20, 30, 10, writer)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	assert.Empty(t, report.Missing)
+
+	type row struct {
+		BlockNumber   uint64  `parquet:"_block_number"`
+		BlockID       string  `parquet:"_block_id"`
+		From          *string `parquet:"from"`
+		Amount        *uint64 `parquet:"amount"`
+		TokenDecimals *int32  `parquet:"token.decimals"`
+		Tags          *string `parquet:"tags"`
+		Memo          []byte  `parquet:"memo,optional"`
+	}
+	rows, err := parquet.Read[row](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	assert.Equal(t, uint64(20), rows[0].BlockNumber)
+	assert.Equal(t, "alice", *rows[0].From)
+	assert.Equal(t, uint64(20), *rows[0].Amount)
+	assert.Equal(t, int32(18), *rows[0].TokenDecimals)
+	assert.Equal(t, `["a","b"]`, *rows[0].Tags)
+	assert.Nil(t, rows[0].Memo)
+	assert.Equal(t, uint64(29), rows[3].BlockNumber)
+}
@@ -0,0 +1,248 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Format is the format of the files written by an export.
+type Format int
+
+const (
+	FormatJSONL Format = iota
+	FormatParquet
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatJSONL:
+		return "jsonl"
+	case FormatParquet:
+		return "parquet"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// ParseFormat returns the Format named `name`, as returned by
+// Format.String.
+func ParseFormat(name string) (Format, error) {
+	switch name {
+	case "jsonl":
+		return FormatJSONL, nil
+	case "parquet":
+		return FormatParquet, nil
+	}
+	return 0, fmt.Errorf("unknown export format %q, expected %q or %q", name, FormatJSONL, FormatParquet)
+}
+
+// Names of the columns holding the block of each row, before the columns
+// of the output message.
+const (
+	BlockNumberColumn    = "_block_number"
+	BlockIDColumn        = "_block_id"
+	BlockTimestampColumn = "_block_timestamp"
+)
+
+// Row is the output of a module for a block.
+type Row struct {
+	BlockNum       uint64
+	BlockID        string
+	BlockTimestamp time.Time
+	// Values are the values of the columns, nil for the fields not set.
+	Values []interface{}
+}
+
+// Writer writes the rows of an export, in block order.
+type Writer interface {
+	Write(row *Row) error
+	// Close writes the end of the file. It does not close the underlying
+	// writer.
+	Close() error
+}
+
+// NewWriter returns a Writer of the rows of `columns` to `w` in `format`.
+func NewWriter(format Format, w io.Writer, columns []*Column) (Writer, error) {
+	switch format {
+	case FormatJSONL:
+		return newJSONLWriter(w, columns), nil
+	case FormatParquet:
+		return newParquetWriter(w, columns)
+	}
+	return nil, fmt.Errorf("unsupported export format %s", format)
+}
+
+// jsonlWriter writes a JSON object per row, on its own line, holding the
+// columns set.
+type jsonlWriter struct {
+	w       *bufio.Writer
+	columns []*Column
+}
+
+func newJSONLWriter(w io.Writer, columns []*Column) *jsonlWriter {
+	return &jsonlWriter{w: bufio.NewWriter(w), columns: columns}
+}
+
+func (w *jsonlWriter) Write(row *Row) error {
+	object := make(map[string]interface{}, len(w.columns)+3)
+	object[BlockNumberColumn] = row.BlockNum
+	object[BlockIDColumn] = row.BlockID
+	if !row.BlockTimestamp.IsZero() {
+		object[BlockTimestampColumn] = row.BlockTimestamp.UTC().Format(time.RFC3339Nano)
+	}
+	for i, column := range w.columns {
+		if row.Values[i] != nil {
+			object[column.Name] = row.Values[i]
+		}
+	}
+
+	cnt, err := json.Marshal(object)
+	if err != nil {
+		return fmt.Errorf("encoding row of block %d: %w", row.BlockNum, err)
+	}
+	if _, err := w.w.Write(append(cnt, '\n')); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (w *jsonlWriter) Close() error {
+	return w.w.Flush()
+}
+
+// parquetRowGroupSize is the number of rows buffered in memory before
+// being written as a row group.
+const parquetRowGroupSize = 10_000
+
+// parquetWriter writes a Parquet file whose columns are all optional,
+// except the block number and ID ones.
+type parquetWriter struct {
+	w *parquet.Writer
+
+	blockNumberIndex    int
+	blockIDIndex        int
+	blockTimestampIndex int
+	// indexes are the indexes in the Parquet schema of the columns, whose
+	// fields are sorted by name.
+	indexes []int
+	columns []*Column
+}
+
+func newParquetWriter(w io.Writer, columns []*Column) (*parquetWriter, error) {
+	group := parquet.Group{
+		BlockNumberColumn:    parquet.Uint(64),
+		BlockIDColumn:        parquet.String(),
+		BlockTimestampColumn: parquet.Optional(parquet.Timestamp(parquet.Millisecond)),
+	}
+	for _, column := range columns {
+		if _, found := group[column.Name]; found {
+			return nil, fmt.Errorf("column %q defined twice", column.Name)
+		}
+		group[column.Name] = parquet.Optional(parquetNode(column.Kind))
+	}
+	schema := parquet.NewSchema("output", group)
+
+	indexes := map[string]int{}
+	for i, path := range schema.Columns() {
+		indexes[path[0]] = i
+	}
+
+	pw := &parquetWriter{
+		w:                   parquet.NewWriter(w, schema, parquet.Compression(&parquet.Zstd), parquet.MaxRowsPerRowGroup(parquetRowGroupSize)),
+		blockNumberIndex:    indexes[BlockNumberColumn],
+		blockIDIndex:        indexes[BlockIDColumn],
+		blockTimestampIndex: indexes[BlockTimestampColumn],
+		columns:             columns,
+	}
+	for _, column := range columns {
+		pw.indexes = append(pw.indexes, indexes[column.Name])
+	}
+	return pw, nil
+}
+
+func parquetNode(kind Kind) parquet.Node {
+	switch kind {
+	case KindBool:
+		return parquet.Leaf(parquet.BooleanType)
+	case KindInt32:
+		return parquet.Int(32)
+	case KindInt64:
+		return parquet.Int(64)
+	case KindUint32:
+		return parquet.Uint(32)
+	case KindUint64:
+		return parquet.Uint(64)
+	case KindFloat:
+		return parquet.Leaf(parquet.FloatType)
+	case KindDouble:
+		return parquet.Leaf(parquet.DoubleType)
+	case KindString:
+		return parquet.String()
+	case KindBytes:
+		return parquet.Leaf(parquet.ByteArrayType)
+	case KindJSON:
+		return parquet.JSON()
+	}
+	panic(fmt.Sprintf("unsupported column kind %s", kind))
+}
+
+func (w *parquetWriter) Write(row *Row) error {
+	out := make(parquet.Row, len(w.columns)+3)
+	out[w.blockNumberIndex] = parquet.Int64Value(int64(row.BlockNum)).Level(0, 0, w.blockNumberIndex)
+	out[w.blockIDIndex] = parquet.ByteArrayValue([]byte(row.BlockID)).Level(0, 0, w.blockIDIndex)
+	if row.BlockTimestamp.IsZero() {
+		out[w.blockTimestampIndex] = parquet.NullValue().Level(0, 0, w.blockTimestampIndex)
+	} else {
+		out[w.blockTimestampIndex] = parquet.Int64Value(row.BlockTimestamp.UnixMilli()).Level(0, 1, w.blockTimestampIndex)
+	}
+
+	for i, column := range w.columns {
+		index := w.indexes[i]
+		if row.Values[i] == nil {
+			out[index] = parquet.NullValue().Level(0, 0, index)
+			continue
+		}
+		value, err := parquetValue(column.Kind, row.Values[i])
+		if err != nil {
+			return fmt.Errorf("column %q of block %d: %w", column.Name, row.BlockNum, err)
+		}
+		out[index] = value.Level(0, 1, index)
+	}
+
+	_, err := w.w.WriteRows([]parquet.Row{out})
+	return err
+}
+
+func parquetValue(kind Kind, value interface{}) (parquet.Value, error) {
+	switch v := value.(type) {
+	case bool:
+		return parquet.BooleanValue(v), nil
+	case int32:
+		return parquet.Int32Value(v), nil
+	case int64:
+		return parquet.Int64Value(v), nil
+	case uint32:
+		return parquet.Int32Value(int32(v)), nil
+	case uint64:
+		return parquet.Int64Value(int64(v)), nil
+	case float32:
+		return parquet.FloatValue(v), nil
+	case float64:
+		return parquet.DoubleValue(v), nil
+	case string:
+		return parquet.ByteArrayValue([]byte(v)), nil
+	case []byte:
+		return parquet.ByteArrayValue(v), nil
+	case json.RawMessage:
+		return parquet.ByteArrayValue(v), nil
+	}
+	return parquet.Value{}, fmt.Errorf("unexpected %T value for %s column", value, kind)
+}
+
+func (w *parquetWriter) Close() error {
+	return w.w.Close()
+}
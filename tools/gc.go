@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/cli"
+	"github.com/streamingfast/dstore"
+
+	"github.com/streamingfast/substreams/storage/gc"
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc <store_url>",
+	Short: "Deletes the module outputs and store snapshots of the module hashes not accessed recently",
+	Long: cli.Dedent(`
+		Applies retention rules to the cache of a tier1/tier2 deployment, either the cache of a single cache tag
+		('<state-store>/<cache-tag>') or the whole state store, holding all cache tags.
+
+		The last access time of each module hash is recorded by tier1 in a 'last_access.json' file of its directory.
+		Module hashes without one, cached before it was recorded, are only deleted with '--include-untracked'.
+	`),
+	Example: ExamplePrefixed("substreams tools gc", `
+		gs://bucket/substreams-states/v1 --max-age 720h --dry-run
+		gs://bucket/substreams-states --max-total-size 2TiB --min-age 24h
+		./localdata --keep-every-nth-full-kv 10 --state-bundle-size 1000
+	`),
+	Args:         cobra.ExactArgs(1),
+	RunE:         gcE,
+	SilenceUsage: true,
+}
+
+func init() {
+	gcCmd.Flags().Duration("max-age", 0, "Delete the module hashes not accessed for longer than this duration, disabled if 0")
+	gcCmd.Flags().String("max-total-size", "", "Delete the least recently accessed module hashes until the cache is under this size (ex: 500GiB), disabled if empty")
+	gcCmd.Flags().Duration("min-age", time.Hour, "Never delete the module hashes accessed more recently than this duration to honor '--max-total-size'")
+	gcCmd.Flags().Uint64("keep-every-nth-full-kv", 0, "Only keep the full store snapshots ending every N bundles of '--state-bundle-size' blocks, and the latest one, disabled if 0 or 1")
+	gcCmd.Flags().Uint64("state-bundle-size", 1000, "Interval in blocks at which the store snapshots were written")
+	gcCmd.Flags().Bool("include-untracked", false, "Also delete the module hashes without a recorded last access time")
+	gcCmd.Flags().Bool("dry-run", false, "Only report what would be deleted")
+
+	Cmd.AddCommand(gcCmd)
+}
+
+func gcE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	rules := gc.Rules{
+		MaxAge:             mustGetDuration(cmd, "max-age"),
+		MinAge:             mustGetDuration(cmd, "min-age"),
+		KeepEveryNthFullKV: mustGetUint64(cmd, "keep-every-nth-full-kv"),
+		StateBundleSize:    mustGetUint64(cmd, "state-bundle-size"),
+		IncludeUntracked:   mustGetBool(cmd, "include-untracked"),
+	}
+	if maxTotalSize := mustGetString(cmd, "max-total-size"); maxTotalSize != "" {
+		size, err := humanize.ParseBytes(maxTotalSize)
+		if err != nil {
+			return fmt.Errorf("invalid max total size %q: %w", maxTotalSize, err)
+		}
+		rules.MaxTotalSize = size
+	}
+	if rules.MaxAge == 0 && rules.MaxTotalSize == 0 && rules.KeepEveryNthFullKV <= 1 {
+		return fmt.Errorf("no retention rule set, use '--max-age', '--max-total-size' or '--keep-every-nth-full-kv'")
+	}
+
+	store, err := dstore.NewStore(args[0], "zst", "zstd", false)
+	if err != nil {
+		return fmt.Errorf("creating store: %w", err)
+	}
+
+	dryRun := mustGetBool(cmd, "dry-run")
+	report, err := gc.NewCollector(store, rules, zlog).Run(ctx, dryRun)
+	if err != nil {
+		return err
+	}
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	for _, mod := range report.DeletedModules {
+		lastAccess := "never"
+		if mod.Tracked() {
+			lastAccess = mod.LastAccess.Format(time.RFC3339)
+		}
+		fmt.Printf("%s %s (%d files, last access %s): %s\n", verb, mod.Dir, len(mod.Files), lastAccess, mod.Reason)
+	}
+	for _, filename := range report.DeletedFullKVs {
+		fmt.Printf("%s %s: not kept by '--keep-every-nth-full-kv'\n", verb, filename)
+	}
+
+	fmt.Printf("%s %d of %d module hashes and %d full store snapshots, %d files", verb, len(report.DeletedModules), report.Modules, len(report.DeletedFullKVs), report.DeletedFiles)
+	if rules.MaxTotalSize != 0 {
+		fmt.Printf(" (%s)", humanize.IBytes(report.DeletedBytes))
+	}
+	fmt.Println()
+	return nil
+}
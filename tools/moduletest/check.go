@@ -0,0 +1,139 @@
+package moduletest
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/jhump/protoreflect/dynamic"
+
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// check returns the expectations not met by `res`. When the module
+// panicked, only the panic is checked.
+func (h *Harness) check(module *pbsubstreams.Module, expect *Expect, res *Result) (failures []string, err error) {
+	if res.Panic != "" {
+		switch {
+		case expect.Panic == "":
+			return []string{fmt.Sprintf("unexpected panic: %s", res.Panic)}, nil
+		case !strings.Contains(res.Panic, expect.Panic):
+			return []string{fmt.Sprintf("panic: expected %q in %q", expect.Panic, res.Panic)}, nil
+		}
+		return nil, nil
+	}
+	if expect.Panic != "" {
+		failures = append(failures, fmt.Sprintf("panic: expected %q, the module did not panic", expect.Panic))
+	}
+
+	if expect.Output != nil {
+		failure, err := h.checkOutput(module, expect.Output, res.Output)
+		if err != nil {
+			return nil, fmt.Errorf("output: %w", err)
+		}
+		if failure != "" {
+			failures = append(failures, failure)
+		}
+	}
+	if expect.OutputHex != "" {
+		if actual := hex.EncodeToString(res.Output); actual != strings.ToLower(expect.OutputHex) {
+			failures = append(failures, fmt.Sprintf("output: got 0x%s, expected 0x%s", actual, strings.ToLower(expect.OutputHex)))
+		}
+	}
+
+	if expect.Logs != nil && !equalLogs(expect.Logs, res.Logs) {
+		failures = append(failures, fmt.Sprintf("logs: got %q, expected %q", res.Logs, expect.Logs))
+	}
+
+	if expect.Deltas != nil {
+		kind := module.GetKindStore()
+		if kind == nil {
+			return nil, fmt.Errorf("deltas: module %q is not a store", module.Name)
+		}
+		deltaFailures, err := h.checkDeltas(kind.ValueType, expect.Deltas, res.Deltas)
+		if err != nil {
+			return nil, fmt.Errorf("deltas: %w", err)
+		}
+		failures = append(failures, deltaFailures...)
+	}
+	return failures, nil
+}
+
+func (h *Harness) checkOutput(module *pbsubstreams.Module, expected interface{}, output []byte) (string, error) {
+	if module.GetKindStore() != nil {
+		return "", fmt.Errorf("store module %q has no output, expect its deltas instead", module.Name)
+	}
+	outputType := strings.TrimPrefix(module.Output.GetType(), "proto:")
+
+	expectedMsg, err := h.newMessage(outputType, expected)
+	if err != nil {
+		return "", err
+	}
+	actualMsg := dynamic.NewMessage(expectedMsg.GetMessageDescriptor())
+	if err := actualMsg.Unmarshal(output); err != nil {
+		return fmt.Sprintf("output: decoding %s: %s", outputType, err), nil
+	}
+	if !dynamic.Equal(expectedMsg, actualMsg) {
+		return fmt.Sprintf("output: got %s, expected %s", h.decodeMessage(outputType, output), jsonOf(expectedMsg)), nil
+	}
+	return "", nil
+}
+
+func (h *Harness) checkDeltas(valueType string, expected []*Delta, actual []*pbssinternal.StoreDelta) (failures []string, err error) {
+	if len(expected) != len(actual) {
+		failures = append(failures, fmt.Sprintf("deltas: got %d deltas, expected %d", len(actual), len(expected)))
+	}
+	for i := 0; i < len(expected) && i < len(actual); i++ {
+		expectedDelta, err := h.storeDelta(valueType, expected[i])
+		if err != nil {
+			return nil, fmt.Errorf("delta %d: %w", i, err)
+		}
+		if expected[i].Ordinal == nil {
+			expectedDelta.Ordinal = actual[i].Ordinal
+		}
+		if !equalDeltas(expectedDelta, actual[i]) {
+			failures = append(failures, fmt.Sprintf("delta %d: got %s, expected %s", i, h.describeDelta(valueType, actual[i]), h.describeDelta(valueType, expectedDelta)))
+		}
+	}
+	return failures, nil
+}
+
+func equalDeltas(a, b *pbssinternal.StoreDelta) bool {
+	return a.Operation == b.Operation &&
+		a.Ordinal == b.Ordinal &&
+		a.Key == b.Key &&
+		string(a.OldValue) == string(b.OldValue) &&
+		string(a.NewValue) == string(b.NewValue)
+}
+
+func (h *Harness) describeDelta(valueType string, delta *pbssinternal.StoreDelta) string {
+	return fmt.Sprintf("%s %q (ordinal %d): %s -> %s",
+		delta.Operation,
+		delta.Key,
+		delta.Ordinal,
+		h.decodeStoreValue(valueType, delta.OldValue),
+		h.decodeStoreValue(valueType, delta.NewValue),
+	)
+}
+
+// equalLogs compares the logs, no log matching an empty list.
+func equalLogs(expected, actual []string) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		if expected[i] != actual[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func jsonOf(msg *dynamic.Message) string {
+	cnt, err := msg.MarshalJSON()
+	if err != nil {
+		return msg.String()
+	}
+	return string(cnt)
+}
@@ -0,0 +1,111 @@
+package moduletest
+
+import (
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Fixtures is the content of a fixtures file, in YAML or JSON:
+//
+//	tests:
+//	  - name: maps the block
+//	    module: map_pools
+//	    block: 12369910
+//	    inputs:
+//	      sf.ethereum.type.v2.Block: {number: 12369910, logs: [...]}
+//	    stores:
+//	      store_tokens:
+//	        "token:0xabc": {decimals: 18}
+//	    expect:
+//	      output: {pools: [...]}
+//	      logs: ["found 1 pool"]
+type Fixtures struct {
+	Tests []*Case `json:"tests" yaml:"tests"`
+}
+
+// Case is a single execution of a module entrypoint, with its inputs and
+// the assertions made on the result.
+type Case struct {
+	Name   string `json:"name,omitempty" yaml:"name"`
+	Module string `json:"module" yaml:"module"`
+
+	// Block, BlockID and Timestamp (RFC 3339) make the clock of the
+	// execution.
+	Block     uint64 `json:"block,omitempty" yaml:"block"`
+	BlockID   string `json:"block_id,omitempty" yaml:"block_id"`
+	Timestamp string `json:"timestamp,omitempty" yaml:"timestamp"`
+
+	// Params overrides the params of the module, if set.
+	Params *string `json:"params,omitempty" yaml:"params"`
+
+	// Inputs are the source and map inputs of the module, keyed by source
+	// type or module name, in the JSON mapping of their protobuf type. A
+	// missing input is passed empty, except for the clock source which
+	// defaults to the clock of the case.
+	Inputs map[string]interface{} `json:"inputs,omitempty" yaml:"inputs"`
+	// Stores are the keys of the stores read by the module, and of the store
+	// written by it, before the execution, keyed by store module name.
+	Stores map[string]map[string]interface{} `json:"stores,omitempty" yaml:"stores"`
+	// Deltas are the deltas of the stores read in deltas mode, keyed by
+	// store module name.
+	Deltas map[string][]*Delta `json:"deltas,omitempty" yaml:"deltas"`
+
+	Expect *Expect `json:"expect,omitempty" yaml:"expect"`
+}
+
+// Expect holds the assertions of a case, each one checked only if set.
+type Expect struct {
+	// Output is the output of a map module, in the JSON mapping of its
+	// protobuf type.
+	Output interface{} `json:"output,omitempty" yaml:"output"`
+	// OutputHex is the raw output of the module, hex encoded.
+	OutputHex string `json:"output_hex,omitempty" yaml:"output_hex"`
+	// Logs are all the lines logged by the module, in order.
+	Logs []string `json:"logs,omitempty" yaml:"logs"`
+	// Deltas are all the deltas written by a store module, in order.
+	Deltas []*Delta `json:"deltas,omitempty" yaml:"deltas"`
+	// Panic is a substring of the message of the panic expected from the
+	// module.
+	Panic string `json:"panic,omitempty" yaml:"panic"`
+}
+
+// Delta is a store delta, its values being in the representation of the
+// store's value type: a string for numbers, base64 for bytes, and the JSON
+// mapping of the protobuf type for protobuf values.
+type Delta struct {
+	Operation string `json:"operation" yaml:"operation"`
+	// Ordinal is only compared if set.
+	Ordinal  *uint64     `json:"ordinal,omitempty" yaml:"ordinal"`
+	Key      string      `json:"key" yaml:"key"`
+	OldValue interface{} `json:"old_value,omitempty" yaml:"old_value"`
+	NewValue interface{} `json:"new_value,omitempty" yaml:"new_value"`
+}
+
+func (c *Case) name(idx int) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s #%d", c.Module, idx+1)
+}
+
+// LoadFixtures reads the cases of a fixtures file, JSON being read as YAML.
+func LoadFixtures(path string) ([]*Case, error) {
+	cnt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading fixtures file: %w", err)
+	}
+
+	fixtures := &Fixtures{}
+	if err := yaml.Unmarshal(cnt, fixtures); err != nil {
+		return nil, fmt.Errorf("decoding fixtures file %q: %w", path, err)
+	}
+	for idx, c := range fixtures.Tests {
+		if c.Module == "" {
+			return nil, fmt.Errorf("test %d: missing module", idx+1)
+		}
+		c.Name = c.name(idx)
+	}
+	return fixtures.Tests, nil
+}
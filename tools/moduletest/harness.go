@@ -0,0 +1,484 @@
+package moduletest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/streamingfast/dstore"
+	"go.opentelemetry.io/otel"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/streamingfast/substreams/metrics"
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/pipeline/exec"
+	"github.com/streamingfast/substreams/reqctx"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/store"
+	"github.com/streamingfast/substreams/wasm"
+)
+
+// Harness executes single entrypoints of the modules of a package,
+// in-process, on inputs and stores given by the caller in place of a block
+// stream. Stores are kept in memory and nothing is written anywhere.
+type Harness struct {
+	modules  map[string]*pbsubstreams.Module
+	binaries []*pbsubstreams.Binary
+	files    []*desc.FileDescriptor
+	registry *wasm.Registry
+	logger   *zap.Logger
+
+	// loaded are the wasm modules compiled so far, by binary index.
+	loaded map[uint32]wasm.Module
+}
+
+// Result is the result of the execution of a case.
+type Result struct {
+	// Output is the output of a map module.
+	Output []byte
+	Logs   []string
+	// Deltas are the deltas written by a store module.
+	Deltas []*pbssinternal.StoreDelta
+	// Panic is the message of the panic of the module, with its stack trace,
+	// empty if the module did not panic.
+	Panic string
+	// Failures are the assertions of the case that failed.
+	Failures []string
+}
+
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+func New(pkg *pbsubstreams.Package, logger *zap.Logger) (*Harness, error) {
+	files, err := desc.CreateFileDescriptors(pkg.ProtoFiles)
+	if err != nil {
+		return nil, fmt.Errorf("creating file descriptors: %w", err)
+	}
+
+	h := &Harness{
+		modules:  map[string]*pbsubstreams.Module{},
+		binaries: pkg.Modules.Binaries,
+		registry: wasm.NewRegistry(nil, 0),
+		logger:   logger,
+		loaded:   map[uint32]wasm.Module{},
+	}
+	for _, file := range files {
+		h.files = append(h.files, file)
+	}
+	for _, module := range pkg.Modules.Modules {
+		h.modules[module.Name] = module
+	}
+	return h, nil
+}
+
+// Close releases the wasm modules compiled by the harness.
+func (h *Harness) Close(ctx context.Context) error {
+	for idx, mod := range h.loaded {
+		if err := mod.Close(ctx); err != nil {
+			return fmt.Errorf("closing wasm module %d: %w", idx, err)
+		}
+	}
+	return nil
+}
+
+// Run executes the module of `c` on its inputs, and checks the result
+// against its expectations, if any. A panic of the module is not an error,
+// but part of the result.
+func (h *Harness) Run(ctx context.Context, c *Case) (*Result, error) {
+	module, found := h.modules[c.Module]
+	if !found {
+		return nil, fmt.Errorf("module %q not found", c.Module)
+	}
+
+	clock, err := c.clock()
+	if err != nil {
+		return nil, err
+	}
+
+	// the executor keeps the context for the wasm calls
+	ctx = reqctx.WithLogger(ctx, h.logger)
+	ctx = reqctx.WithReqStats(ctx, metrics.NewReqStats(&metrics.Config{}, h.logger))
+
+	executor, err := h.newExecutor(ctx, module, c, clock)
+	if err != nil {
+		return nil, fmt.Errorf("module %q: %w", module.Name, err)
+	}
+	defer executor.Close(ctx)
+
+	res := &Result{}
+	moduleOutput, outputBytes, err := exec.RunModule(ctx, executor, executor.outputs)
+	switch {
+	case errors.Is(err, exec.ErrWasmDeterministicExec):
+		res.Panic = err.Error()
+	case err != nil:
+		return nil, fmt.Errorf("executing module %q: %w", module.Name, err)
+	case module.GetKindStore() != nil:
+		res.Logs = moduleOutput.Logs
+		res.Deltas = moduleOutput.GetStoreDeltas().GetStoreDeltas()
+	default:
+		res.Logs = moduleOutput.Logs
+		res.Output = outputBytes
+	}
+
+	if c.Expect != nil {
+		if res.Failures, err = h.check(module, c.Expect, res); err != nil {
+			return nil, fmt.Errorf("checking expectations: %w", err)
+		}
+	}
+	return res, nil
+}
+
+// caseExecutor is the executor of the module of a case, with the values of
+// its inputs.
+type caseExecutor struct {
+	exec.ModuleExecutor
+	outputs *caseOutputs
+}
+
+// caseOutputs holds the values of the source, map and deltas inputs of a
+// case, by name.
+type caseOutputs struct {
+	clock  *pbsubstreams.Clock
+	module string
+	values map[string][]byte
+}
+
+func (o *caseOutputs) Clock() *pbsubstreams.Clock { return o.clock }
+
+func (o *caseOutputs) Get(name string) ([]byte, bool, error) {
+	if name == o.module {
+		// the executed module has no cached output
+		return nil, false, execout.NotFound
+	}
+	return o.values[name], false, nil
+}
+
+func (h *Harness) newExecutor(ctx context.Context, module *pbsubstreams.Module, c *Case, clock *pbsubstreams.Clock) (*caseExecutor, error) {
+	wasmModule, err := h.wasmModule(ctx, module.BinaryIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := &caseOutputs{clock: clock, module: module.Name, values: map[string][]byte{}}
+	arguments, err := h.arguments(module, c, outputs)
+	if err != nil {
+		return nil, err
+	}
+
+	tracer := otel.GetTracerProvider().Tracer("moduletest")
+	switch kind := module.Kind.(type) {
+	case *pbsubstreams.Module_KindMap_, *pbsubstreams.Module_KindBlockIndex_:
+		baseExecutor := exec.NewBaseExecutor(ctx, module.Name, wasmModule, false, arguments, nil, module.BinaryEntrypoint, tracer)
+		outType := strings.TrimPrefix(module.Output.GetType(), "proto:")
+		return &caseExecutor{exec.NewMapperModuleExecutor(baseExecutor, outType), outputs}, nil
+
+	case *pbsubstreams.Module_KindStore_:
+		outputStore, err := h.newStore(module, c.Stores[module.Name])
+		if err != nil {
+			return nil, fmt.Errorf("store %q: %w", module.Name, err)
+		}
+		arguments = append(arguments, wasm.NewStoreWriterOutput(module.Name, outputStore, kind.KindStore.UpdatePolicy, kind.KindStore.ValueType))
+		baseExecutor := exec.NewBaseExecutor(ctx, module.Name, wasmModule, false, arguments, nil, module.BinaryEntrypoint, tracer)
+		return &caseExecutor{exec.NewStoreModuleExecutor(baseExecutor, outputStore), outputs}, nil
+
+	default:
+		return nil, fmt.Errorf("invalid kind %q", module.Kind)
+	}
+}
+
+func (h *Harness) wasmModule(ctx context.Context, binaryIndex uint32) (wasm.Module, error) {
+	if mod, found := h.loaded[binaryIndex]; found {
+		return mod, nil
+	}
+	if int(binaryIndex) >= len(h.binaries) {
+		return nil, fmt.Errorf("binary %d not found", binaryIndex)
+	}
+	mod, err := h.registry.NewModule(ctx, h.binaries[binaryIndex].Content)
+	if err != nil {
+		return nil, fmt.Errorf("new wasm module: %w", err)
+	}
+	h.loaded[binaryIndex] = mod
+	return mod, nil
+}
+
+// arguments renders the wasm arguments of the inputs of `module`, setting
+// in `outputs` the values of its source, map and deltas inputs. Inputs,
+// stores or deltas of the case not used by the module are an error, most
+// probably a typo.
+func (h *Harness) arguments(module *pbsubstreams.Module, c *Case, outputs *caseOutputs) (out []wasm.Argument, err error) {
+	usedInputs := map[string]bool{}
+	usedStores := map[string]bool{module.Name: module.GetKindStore() != nil}
+	usedDeltas := map[string]bool{}
+
+	for _, input := range module.Inputs {
+		switch in := input.Input.(type) {
+		case *pbsubstreams.Module_Input_Params_:
+			value := in.Params.Value
+			if c.Params != nil {
+				value = *c.Params
+			}
+			out = append(out, wasm.NewParamsInput(value))
+
+		case *pbsubstreams.Module_Input_Source_:
+			usedInputs[in.Source.Type] = true
+			value, found := c.Inputs[in.Source.Type]
+			switch {
+			case found:
+				if outputs.values[in.Source.Type], err = h.encodeMessage(in.Source.Type, value); err != nil {
+					return nil, fmt.Errorf("source %q: %w", in.Source.Type, err)
+				}
+			case in.Source.Type == wasm.ClockType:
+				if outputs.values[in.Source.Type], err = proto.Marshal(outputs.clock); err != nil {
+					return nil, fmt.Errorf("marshalling clock: %w", err)
+				}
+			}
+			out = append(out, wasm.NewSourceInput(in.Source.Type))
+
+		case *pbsubstreams.Module_Input_Map_:
+			name := in.Map.ModuleName
+			usedInputs[name] = true
+			if value, found := c.Inputs[name]; found {
+				inputModule, found := h.modules[name]
+				if !found {
+					return nil, fmt.Errorf("input module %q not found", name)
+				}
+				if outputs.values[name], err = h.encodeMessage(strings.TrimPrefix(inputModule.Output.GetType(), "proto:"), value); err != nil {
+					return nil, fmt.Errorf("input %q: %w", name, err)
+				}
+			}
+			out = append(out, wasm.NewMapInput(name))
+
+		case *pbsubstreams.Module_Input_Store_:
+			name := in.Store.ModuleName
+			storeModule, found := h.modules[name]
+			if !found || storeModule.GetKindStore() == nil {
+				return nil, fmt.Errorf("input store %q not found", name)
+			}
+			if in.Store.Mode == pbsubstreams.Module_Input_Store_DELTAS {
+				usedDeltas[name] = true
+				if outputs.values[name], err = h.encodeDeltas(storeModule.GetKindStore().ValueType, c.Deltas[name]); err != nil {
+					return nil, fmt.Errorf("deltas of store %q: %w", name, err)
+				}
+				out = append(out, wasm.NewMapInput(name))
+				continue
+			}
+			usedStores[name] = true
+			inputStore, err := h.newStore(storeModule, c.Stores[name])
+			if err != nil {
+				return nil, fmt.Errorf("store %q: %w", name, err)
+			}
+			out = append(out, wasm.NewStoreReaderInput(name, inputStore))
+
+		default:
+			return nil, fmt.Errorf("invalid input struct for module %q", module.Name)
+		}
+	}
+
+	if err := checkUsed("input", c.Inputs, usedInputs); err != nil {
+		return nil, err
+	}
+	if err := checkUsed("store", c.Stores, usedStores); err != nil {
+		return nil, err
+	}
+	if err := checkUsed("deltas", c.Deltas, usedDeltas); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func checkUsed[T any](what string, values map[string]T, used map[string]bool) error {
+	for name := range values {
+		if !used[name] {
+			return fmt.Errorf("%s %q is not an input of the module", what, name)
+		}
+	}
+	return nil
+}
+
+// newStore returns an in-memory store of the store module `module` holding
+// `keys`, without deltas.
+func (h *Harness) newStore(module *pbsubstreams.Module, keys map[string]interface{}) (*store.FullKV, error) {
+	kind := module.GetKindStore()
+	config, err := store.NewConfig(module.Name, module.InitialBlock, "", kind.UpdatePolicy, kind.ValueType, dstore.NewMockStore(nil), "")
+	if err != nil {
+		return nil, fmt.Errorf("new store config: %w", err)
+	}
+
+	kv := config.NewFullKV(h.logger)
+	sortedKeys := make([]string, 0, len(keys))
+	for key := range keys {
+		sortedKeys = append(sortedKeys, key)
+	}
+	sort.Strings(sortedKeys)
+	for _, key := range sortedKeys {
+		value, err := h.encodeStoreValue(kind.ValueType, keys[key])
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", key, err)
+		}
+		kv.SetBytes(0, key, value)
+	}
+	kv.Reset()
+	return kv, nil
+}
+
+func (h *Harness) encodeDeltas(valueType string, deltas []*Delta) ([]byte, error) {
+	out := &pbssinternal.StoreDeltas{}
+	for i, delta := range deltas {
+		storeDelta, err := h.storeDelta(valueType, delta)
+		if err != nil {
+			return nil, fmt.Errorf("delta %d: %w", i, err)
+		}
+		out.StoreDeltas = append(out.StoreDeltas, storeDelta)
+	}
+	return proto.Marshal(out)
+}
+
+func (h *Harness) storeDelta(valueType string, delta *Delta) (*pbssinternal.StoreDelta, error) {
+	operation, found := pbssinternal.StoreDelta_Operation_value[strings.ToUpper(delta.Operation)]
+	if !found {
+		return nil, fmt.Errorf("invalid operation %q", delta.Operation)
+	}
+	oldValue, err := h.encodeStoreValue(valueType, delta.OldValue)
+	if err != nil {
+		return nil, fmt.Errorf("old value: %w", err)
+	}
+	newValue, err := h.encodeStoreValue(valueType, delta.NewValue)
+	if err != nil {
+		return nil, fmt.Errorf("new value: %w", err)
+	}
+
+	out := &pbssinternal.StoreDelta{
+		Operation: pbssinternal.StoreDelta_Operation(operation),
+		Key:       delta.Key,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+	}
+	if delta.Ordinal != nil {
+		out.Ordinal = *delta.Ordinal
+	}
+	return out, nil
+}
+
+// encodeStoreValue encodes `value` as stored by a store of `valueType`:
+// protobuf values from their JSON mapping, bytes from base64, and the other
+// types from their string representation.
+func (h *Harness) encodeStoreValue(valueType string, value interface{}) ([]byte, error) {
+	switch {
+	case value == nil:
+		return nil, nil
+	case strings.HasPrefix(valueType, "proto:"):
+		return h.encodeMessage(strings.TrimPrefix(valueType, "proto:"), value)
+	case valueType == "bytes":
+		encoded, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("bytes value must be a base64 string, got %v", value)
+		}
+		return base64.StdEncoding.DecodeString(encoded)
+	}
+
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case int:
+		return []byte(strconv.Itoa(v)), nil
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	case bool:
+		return []byte(strconv.FormatBool(v)), nil
+	}
+	return nil, fmt.Errorf("%s value must be a scalar, got %v", valueType, value)
+}
+
+// decodeStoreValue is the reverse of encodeStoreValue, used to report
+// differences.
+func (h *Harness) decodeStoreValue(valueType string, value []byte) string {
+	switch {
+	case strings.HasPrefix(valueType, "proto:"):
+		return h.decodeMessage(strings.TrimPrefix(valueType, "proto:"), value)
+	case valueType == "bytes":
+		return base64.StdEncoding.EncodeToString(value)
+	}
+	return strconv.Quote(string(value))
+}
+
+func (h *Harness) findMessage(typeName string) (*desc.MessageDescriptor, error) {
+	for _, file := range h.files {
+		if msgDesc := file.FindMessage(typeName); msgDesc != nil {
+			return msgDesc, nil
+		}
+	}
+	// types compiled in the binary, like the clock, are not always part of
+	// the package
+	if msgDesc, err := desc.LoadMessageDescriptor(typeName); err == nil && msgDesc != nil {
+		return msgDesc, nil
+	}
+	return nil, fmt.Errorf("protobuf type %q not found in package", typeName)
+}
+
+// newMessage returns the message of type `typeName` of the JSON mapping
+// `value`.
+func (h *Harness) newMessage(typeName string, value interface{}) (*dynamic.Message, error) {
+	msgDesc, err := h.findMessage(typeName)
+	if err != nil {
+		return nil, err
+	}
+	cnt, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("encoding value to JSON: %w", err)
+	}
+	msg := dynamic.NewMessage(msgDesc)
+	if err := msg.UnmarshalJSON(cnt); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", typeName, err)
+	}
+	return msg, nil
+}
+
+func (h *Harness) encodeMessage(typeName string, value interface{}) ([]byte, error) {
+	msg, err := h.newMessage(typeName, value)
+	if err != nil {
+		return nil, err
+	}
+	return msg.Marshal()
+}
+
+// decodeMessage returns the JSON mapping of `data`, or its hex encoding if
+// it cannot be decoded.
+func (h *Harness) decodeMessage(typeName string, data []byte) string {
+	msgDesc, err := h.findMessage(typeName)
+	if err != nil {
+		return fmt.Sprintf("%x", data)
+	}
+	msg := dynamic.NewMessage(msgDesc)
+	if err := msg.Unmarshal(data); err != nil {
+		return fmt.Sprintf("%x", data)
+	}
+	cnt, err := msg.MarshalJSON()
+	if err != nil {
+		return fmt.Sprintf("%x", data)
+	}
+	return string(cnt)
+}
+
+func (c *Case) clock() (*pbsubstreams.Clock, error) {
+	clock := &pbsubstreams.Clock{Number: c.Block, Id: c.BlockID}
+	if c.Timestamp != "" {
+		t, err := time.Parse(time.RFC3339, c.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q: %w", c.Timestamp, err)
+		}
+		clock.Timestamp = timestamppb.New(t)
+	}
+	return clock, nil
+}
@@ -0,0 +1,134 @@
+package moduletest
+
+import (
+	"context"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/manifest"
+	_ "github.com/streamingfast/substreams/pb/sf/substreams/v1/test"
+)
+
+const fixtures = `
+tests:
+  - name: maps the block
+    module: test_map
+    block: 20
+    params: my test params
+    inputs:
+      sf.substreams.v1.test.Block: {id: abc, number: 20}
+    expect:
+      output: {block_number: 20, block_hash: abc}
+      logs: []
+
+  - name: panics on unexpected params
+    module: test_map
+    inputs:
+      sf.substreams.v1.test.Block: {id: abc, number: 20}
+    expect:
+      panic: my default params value
+
+  - name: stores the map result
+    module: test_store_proto
+    inputs:
+      test_map: {block_number: 20, block_hash: abc}
+    expect:
+      deltas:
+        - {operation: CREATE, ordinal: 1, key: "result:abc", new_value: {block_number: 20, block_hash: abc}}
+
+  - name: adds to the store
+    module: setup_test_store_add_i64
+    block: 5
+    inputs:
+      sf.substreams.v1.test.Block: {number: 5}
+    stores:
+      setup_test_store_add_i64: {a.key: 10}
+    expect:
+      deltas:
+        - {operation: UPDATE, key: a.key, old_value: 10, new_value: "-9223372036854775799"}
+        - {operation: UPDATE, key: a.key, old_value: "-9223372036854775799", new_value: 9}
+        - {operation: UPDATE, key: a.key, old_value: 9, new_value: 10}
+
+  - name: reads the store
+    module: assert_test_store_add_i64
+    inputs:
+      sf.substreams.v1.test.Block: {number: 5}
+    stores:
+      setup_test_store_add_i64: {a.key: 0}
+    expect:
+      output: {result: true}
+
+  - name: wrong expectations
+    module: assert_test_store_add_i64
+    inputs:
+      sf.substreams.v1.test.Block: {number: 5}
+    stores:
+      setup_test_store_add_i64: {a.key: 0}
+    expect:
+      output: {result: false}
+      output_hex: "0801"
+      logs: [hello]
+
+  - name: missing key
+    module: assert_test_store_add_i64
+    inputs:
+      sf.substreams.v1.test.Block: {number: 5}
+    expect:
+      output: {result: true}
+`
+
+func newTestHarness(t *testing.T) *Harness {
+	pkg := manifest.TestReadManifest(t, "../../test/testdata/substreams-test-v0.1.0.spkg")
+	harness, err := New(pkg, zap.NewNop())
+	require.NoError(t, err)
+	t.Cleanup(func() { harness.Close(context.Background()) })
+	return harness
+}
+
+func TestHarness_Run(t *testing.T) {
+	harness := newTestHarness(t)
+
+	path := filepath.Join(t.TempDir(), "fixtures.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(fixtures), 0644))
+	cases, err := LoadFixtures(path)
+	require.NoError(t, err)
+
+	results := map[string]*Result{}
+	for _, c := range cases {
+		res, err := harness.Run(context.Background(), c)
+		require.NoError(t, err, c.Name)
+		results[c.Name] = res
+	}
+
+	for _, name := range []string{"maps the block", "panics on unexpected params", "stores the map result", "adds to the store", "reads the store"} {
+		assert.Empty(t, results[name].Failures, name)
+	}
+	assert.Equal(t, "08141203616263", hex.EncodeToString(results["maps the block"].Output))
+	assert.Contains(t, results["panics on unexpected params"].Panic, "wasm execution failed deterministically")
+
+	assert.Equal(t, []string{
+		`output: got {"result":true}, expected {}`,
+		`logs: got [], expected ["hello"]`,
+	}, results["wrong expectations"].Failures)
+
+	missingKey := results["missing key"]
+	require.Len(t, missingKey.Failures, 1)
+	assert.Contains(t, missingKey.Failures[0], "unexpected panic: ")
+	assert.NotEmpty(t, missingKey.Panic)
+}
+
+func TestHarness_UnusedInput(t *testing.T) {
+	harness := newTestHarness(t)
+
+	_, err := harness.Run(context.Background(), &Case{
+		Module: "assert_test_store_add_i64",
+		Stores: map[string]map[string]interface{}{"setup_test_store_set_i64": {"a.key": 0}},
+	})
+	assert.EqualError(t, err, `module "assert_test_store_add_i64": store "setup_test_store_set_i64" is not an input of the module`)
+}
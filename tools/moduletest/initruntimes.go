@@ -0,0 +1,5 @@
+package moduletest
+
+import (
+	_ "github.com/streamingfast/substreams/wasm/wazero"
+)
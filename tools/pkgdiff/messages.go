@@ -0,0 +1,114 @@
+package pkgdiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// diffMessages compares the messages of the protobuf files of both
+// packages by full name, and their fields by number.
+func diffMessages(old, new *pbsubstreams.Package) (added, removed []string, changed []*MessageChange) {
+	oldMessages, newMessages := packageMessages(old), packageMessages(new)
+
+	for _, name := range sortedNames(newMessages) {
+		oldMessage, found := oldMessages[name]
+		if !found {
+			added = append(added, name)
+			continue
+		}
+		if changes := diffFields(oldMessage, newMessages[name]); len(changes) != 0 {
+			changed = append(changed, &MessageChange{Name: name, Changes: changes})
+		}
+	}
+	for _, name := range sortedNames(oldMessages) {
+		if _, found := newMessages[name]; !found {
+			removed = append(removed, name)
+		}
+	}
+	return
+}
+
+// packageMessages returns the messages of the protobuf files of the
+// package, nested ones included, by full name. The first definition of a
+// message found is kept.
+func packageMessages(pkg *pbsubstreams.Package) map[string]*descriptorpb.DescriptorProto {
+	out := map[string]*descriptorpb.DescriptorProto{}
+	var walk func(prefix string, messages []*descriptorpb.DescriptorProto)
+	walk = func(prefix string, messages []*descriptorpb.DescriptorProto) {
+		for _, message := range messages {
+			name := message.GetName()
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			if _, found := out[name]; !found {
+				out[name] = message
+			}
+			walk(name, message.NestedType)
+		}
+	}
+	for _, file := range pkg.ProtoFiles {
+		walk(file.GetPackage(), file.MessageType)
+	}
+	return out
+}
+
+func diffFields(old, new *descriptorpb.DescriptorProto) (changes []string) {
+	oldFields := map[int32]*descriptorpb.FieldDescriptorProto{}
+	for _, field := range old.Field {
+		oldFields[field.GetNumber()] = field
+	}
+	newFields := map[int32]bool{}
+	for _, field := range new.Field {
+		newFields[field.GetNumber()] = true
+		oldField, found := oldFields[field.GetNumber()]
+		if !found {
+			changes = append(changes, fmt.Sprintf("field %d added: %s %s", field.GetNumber(), field.GetName(), fieldType(field)))
+			continue
+		}
+		if oldField.GetName() != field.GetName() {
+			changes = append(changes, fmt.Sprintf("field %d renamed: %s -> %s", field.GetNumber(), oldField.GetName(), field.GetName()))
+		}
+		if oldType, newType := fieldType(oldField), fieldType(field); oldType != newType {
+			changes = append(changes, fmt.Sprintf("field %d (%s) type: %s -> %s", field.GetNumber(), field.GetName(), oldType, newType))
+		}
+	}
+	for _, field := range old.Field {
+		if !newFields[field.GetNumber()] {
+			changes = append(changes, fmt.Sprintf("field %d removed: %s %s", field.GetNumber(), field.GetName(), fieldType(field)))
+		}
+	}
+	return changes
+}
+
+// fieldType renders the type of a field as in protobuf files, as in
+// `repeated acme.Pool` or `optional uint64`.
+func fieldType(field *descriptorpb.FieldDescriptorProto) string {
+	var typ string
+	switch field.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		typ = strings.TrimPrefix(field.GetTypeName(), ".")
+	default:
+		typ = strings.ToLower(strings.TrimPrefix(field.GetType().String(), "TYPE_"))
+	}
+
+	switch {
+	case field.GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED:
+		return "repeated " + typ
+	case field.GetProto3Optional():
+		return "optional " + typ
+	}
+	return typ
+}
+
+func sortedNames(messages map[string]*descriptorpb.DescriptorProto) []string {
+	out := make([]string, 0, len(messages))
+	for name := range messages {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,225 @@
+package pkgdiff
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/grpc"
+
+	"github.com/streamingfast/substreams/client"
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+)
+
+// maxBlockDiffs is the number of differences reported for a block.
+const maxBlockDiffs = 10
+
+// BlockOutput is the output of a module at a block, decoded from JSON.
+type BlockOutput struct {
+	Block  uint64
+	Output interface{}
+}
+
+// BlockDiff holds the differences between the outputs of the old and new
+// packages at a block.
+type BlockDiff struct {
+	Block   uint64
+	Changes []string
+}
+
+// OutputsFunc sends on `out`, in increasing block order, the outputs of a
+// module for the blocks with one.
+type OutputsFunc func(ctx context.Context, out chan<- *BlockOutput) error
+
+// DiffOutputs runs `oldOutputs` and `newOutputs` concurrently and calls
+// `onDiff` for each block at which their outputs differ. It returns the
+// number of blocks compared, and the first error of either.
+func DiffOutputs(ctx context.Context, oldOutputs, newOutputs OutputsFunc, onDiff func(*BlockDiff)) (compared int, err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make(chan error, 2)
+	run := func(name string, outputs OutputsFunc) <-chan *BlockOutput {
+		out := make(chan *BlockOutput, 100)
+		go func() {
+			defer close(out)
+			if err := outputs(ctx, out); err != nil {
+				errs <- fmt.Errorf("%s package: %w", name, err)
+				cancel()
+			}
+		}()
+		return out
+	}
+	oldCh, newCh := run("old", oldOutputs), run("new", newOutputs)
+
+	compared = CompareOutputs(oldCh, newCh, onDiff)
+	select {
+	case err := <-errs:
+		return compared, err
+	default:
+		return compared, nil
+	}
+}
+
+// CompareOutputs reads the outputs of the old and new packages, both in
+// increasing block order, until both channels are closed, and calls
+// `onDiff` for each block at which they differ, including the blocks with
+// an output for one package only. It returns the number of blocks compared.
+func CompareOutputs(old, new <-chan *BlockOutput, onDiff func(*BlockDiff)) (compared int) {
+	o, oldOK := <-old
+	n, newOK := <-new
+	for oldOK || newOK {
+		compared++
+		switch {
+		case oldOK && (!newOK || o.Block < n.Block):
+			onDiff(&BlockDiff{Block: o.Block, Changes: []string{"output only in old package"}})
+			o, oldOK = <-old
+		case newOK && (!oldOK || n.Block < o.Block):
+			onDiff(&BlockDiff{Block: n.Block, Changes: []string{"output only in new package"}})
+			n, newOK = <-new
+		default:
+			var changes []string
+			diffJSON(".", o.Output, n.Output, &changes)
+			if len(changes) != 0 {
+				onDiff(&BlockDiff{Block: o.Block, Changes: changes})
+			}
+			o, oldOK = <-old
+			n, newOK = <-new
+		}
+	}
+	return compared
+}
+
+// diffJSON appends to `changes` the paths at which `new` differs from
+// `old`, both decoded from JSON.
+func diffJSON(path string, old, new interface{}, changes *[]string) {
+	if len(*changes) >= maxBlockDiffs {
+		return
+	}
+
+	switch o := old.(type) {
+	case map[string]interface{}:
+		n, ok := new.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(o, n) {
+			ov, inOld := o[key]
+			nv, inNew := n[key]
+			switch {
+			case !inNew:
+				*changes = append(*changes, fmt.Sprintf("%s: removed %s", joinPath(path, key), encodeValue(ov)))
+			case !inOld:
+				*changes = append(*changes, fmt.Sprintf("%s: added %s", joinPath(path, key), encodeValue(nv)))
+			default:
+				diffJSON(joinPath(path, key), ov, nv, changes)
+			}
+			if len(*changes) >= maxBlockDiffs {
+				return
+			}
+		}
+		return
+	case []interface{}:
+		n, ok := new.([]interface{})
+		if !ok {
+			break
+		}
+		if len(n) != len(o) {
+			*changes = append(*changes, fmt.Sprintf("%s: %d items -> %d", path, len(o), len(n)))
+			return
+		}
+		for i := range o {
+			diffJSON(fmt.Sprintf("%s[%d]", strings.TrimSuffix(path, "."), i), o[i], n[i], changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, fmt.Sprintf("%s: %s -> %s", path, encodeValue(old), encodeValue(new)))
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "." {
+		return "." + key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(maps ...map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				out = append(out, key)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func encodeValue(value interface{}) string {
+	cnt, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	if len(cnt) > 80 {
+		return string(cnt[:77]) + "..."
+	}
+	return string(cnt)
+}
+
+// StreamOutputs returns an OutputsFunc running `req` through `streamClient`
+// and decoding the map outputs of its output module with `msgDesc`. The
+// request should only stream final blocks, undo signals being ignored.
+func StreamOutputs(streamClient pbsubstreamsrpc.StreamClient, callOpts []grpc.CallOption, req *pbsubstreamsrpc.Request, msgDesc *manifest.ModuleDescriptor) OutputsFunc {
+	return func(ctx context.Context, out chan<- *BlockOutput) error {
+		stream := client.NewStream(streamClient, callOpts, req, client.NewMemoryCursorStore(),
+			client.WithOnData(func(ctx context.Context, data *pbsubstreamsrpc.BlockScopedData) error {
+				if data.Output == nil || data.Output.MapOutput == nil || len(data.Output.MapOutput.Value) == 0 {
+					return nil
+				}
+				output, err := decodeOutput(msgDesc, data.Output.MapOutput.Value)
+				if err != nil {
+					return fmt.Errorf("decoding output of block %d: %w", data.Clock.Number, err)
+				}
+				select {
+				case out <- &BlockOutput{Block: data.Clock.Number, Output: output}:
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}),
+		)
+		return stream.Run(ctx)
+	}
+}
+
+func decodeOutput(msgDesc *manifest.ModuleDescriptor, data []byte) (interface{}, error) {
+	if msgDesc == nil || msgDesc.MessageDescriptor == nil {
+		return hex.EncodeToString(data), nil
+	}
+	msg := dynamic.NewMessage(msgDesc.MessageDescriptor)
+	if err := msg.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("unmarshalling %s: %w", msgDesc.ProtoMessageType, err)
+	}
+	cnt, err := msg.MarshalJSONPB(&jsonpb.Marshaler{})
+	if err != nil {
+		return nil, fmt.Errorf("marshalling to JSON: %w", err)
+	}
+	var out interface{}
+	if err := json.Unmarshal(cnt, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,64 @@
+package pkgdiff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOutputs(t *testing.T, outputs map[uint64]string, blocks ...uint64) OutputsFunc {
+	return func(ctx context.Context, out chan<- *BlockOutput) error {
+		for _, block := range blocks {
+			var output interface{}
+			require.NoError(t, json.Unmarshal([]byte(outputs[block]), &output))
+			out <- &BlockOutput{Block: block, Output: output}
+		}
+		return nil
+	}
+}
+
+func TestDiffOutputs(t *testing.T) {
+	old := testOutputs(t, map[uint64]string{
+		10: `{"pools":[{"fee":3000}]}`,
+		11: `{"pools":[{"fee":500,"address":"0xabc"}]}`,
+		12: `{"pools":[]}`,
+	}, 10, 11, 12)
+	new := testOutputs(t, map[uint64]string{
+		10: `{"pools":[{"fee":3000}]}`,
+		11: `{"pools":[{"fee":100,"token":"0xdef"}]}`,
+		13: `{"pools":[]}`,
+	}, 10, 11, 13)
+
+	var diffs []*BlockDiff
+	compared, err := DiffOutputs(context.Background(), old, new, func(diff *BlockDiff) {
+		diffs = append(diffs, diff)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 4, compared)
+	assert.Equal(t, []*BlockDiff{
+		{Block: 11, Changes: []string{
+			`.pools[0].address: removed "0xabc"`,
+			`.pools[0].fee: 500 -> 100`,
+			`.pools[0].token: added "0xdef"`,
+		}},
+		{Block: 12, Changes: []string{"output only in old package"}},
+		{Block: 13, Changes: []string{"output only in new package"}},
+	}, diffs)
+}
+
+func TestDiffOutputs_Error(t *testing.T) {
+	failing := func(ctx context.Context, out chan<- *BlockOutput) error {
+		return fmt.Errorf("connection refused")
+	}
+	blocking := func(ctx context.Context, out chan<- *BlockOutput) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	_, err := DiffOutputs(context.Background(), blocking, failing, func(*BlockDiff) {})
+	assert.EqualError(t, err, "new package: connection refused")
+}
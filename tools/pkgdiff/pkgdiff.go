@@ -0,0 +1,231 @@
+package pkgdiff
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+// Report holds the structural differences between two packages.
+type Report struct {
+	ModulesAdded   []string
+	ModulesRemoved []string
+	Modules        []*ModuleChange // modules of both packages that differ, in the order of the new package
+
+	MessagesAdded   []string
+	MessagesRemoved []string
+	Messages        []*MessageChange // messages of both packages with different fields, by name
+}
+
+// ModuleChange describes how a module present in both packages changed.
+type ModuleChange struct {
+	Name    string
+	Changes []string // human-readable differences of the module's own definition
+
+	OldHash string
+	NewHash string
+}
+
+// HashChanged returns whether the module hash changed, in which case the
+// outputs and store snapshots cached for the module are not reused.
+func (c *ModuleChange) HashChanged() bool {
+	return c.OldHash != c.NewHash
+}
+
+// MessageChange describes how the fields of a protobuf message changed.
+type MessageChange struct {
+	Name    string
+	Changes []string
+}
+
+// Empty returns whether the packages have no structural differences.
+func (r *Report) Empty() bool {
+	return len(r.ModulesAdded) == 0 && len(r.ModulesRemoved) == 0 && len(r.Modules) == 0 &&
+		len(r.MessagesAdded) == 0 && len(r.MessagesRemoved) == 0 && len(r.Messages) == 0
+}
+
+// Diff returns the structural differences between the `old` and `new`
+// packages: the modules added and removed, the definition and hash of the
+// modules present in both, and the fields of their protobuf messages.
+func Diff(old, new *pbsubstreams.Package) (*Report, error) {
+	oldHashes, err := moduleHashes(old)
+	if err != nil {
+		return nil, fmt.Errorf("old package: %w", err)
+	}
+	newHashes, err := moduleHashes(new)
+	if err != nil {
+		return nil, fmt.Errorf("new package: %w", err)
+	}
+
+	report := &Report{}
+	oldModules := map[string]*pbsubstreams.Module{}
+	for _, module := range old.Modules.Modules {
+		oldModules[module.Name] = module
+	}
+	newModules := map[string]bool{}
+	for _, module := range new.Modules.Modules {
+		newModules[module.Name] = true
+		oldModule, found := oldModules[module.Name]
+		if !found {
+			report.ModulesAdded = append(report.ModulesAdded, module.Name)
+			continue
+		}
+
+		change := &ModuleChange{
+			Name:    module.Name,
+			Changes: diffModule(old.Modules, oldModule, new.Modules, module),
+			OldHash: oldHashes[module.Name],
+			NewHash: newHashes[module.Name],
+		}
+		if len(change.Changes) == 0 && change.HashChanged() {
+			change.Changes = append(change.Changes, "ancestors changed")
+		}
+		if len(change.Changes) != 0 {
+			report.Modules = append(report.Modules, change)
+		}
+	}
+	for _, module := range old.Modules.Modules {
+		if !newModules[module.Name] {
+			report.ModulesRemoved = append(report.ModulesRemoved, module.Name)
+		}
+	}
+
+	report.MessagesAdded, report.MessagesRemoved, report.Messages = diffMessages(old, new)
+	return report, nil
+}
+
+func moduleHashes(pkg *pbsubstreams.Package) (map[string]string, error) {
+	graph, err := manifest.NewModuleGraph(pkg.Modules.Modules)
+	if err != nil {
+		return nil, fmt.Errorf("creating module graph: %w", err)
+	}
+	hashes := manifest.NewModuleHashes()
+	out := map[string]string{}
+	for _, module := range pkg.Modules.Modules {
+		if _, err := hashes.HashModule(pkg.Modules, module, graph); err != nil {
+			return nil, fmt.Errorf("hashing module %q: %w", module.Name, err)
+		}
+		out[module.Name] = hashes.Get(module.Name)
+	}
+	return out, nil
+}
+
+func diffModule(oldModules *pbsubstreams.Modules, old *pbsubstreams.Module, newModules *pbsubstreams.Modules, new *pbsubstreams.Module) (changes []string) {
+	changed := func(what, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, fmt.Sprintf("%s: %s -> %s", what, oldValue, newValue))
+		}
+	}
+
+	oldKind, newKind := moduleKind(old), moduleKind(new)
+	changed("kind", oldKind, newKind)
+	changed("initial block", fmt.Sprint(old.InitialBlock), fmt.Sprint(new.InitialBlock))
+	changed("inputs", moduleInputs(old), moduleInputs(new))
+	if oldKind == newKind {
+		switch oldKind {
+		case "map":
+			changed("output type", old.GetKindMap().OutputType, new.GetKindMap().OutputType)
+		case "store":
+			changed("value type", old.GetKindStore().ValueType, new.GetKindStore().ValueType)
+			changed("update policy", updatePolicy(old), updatePolicy(new))
+		case "blockIndex":
+			changed("output type", old.GetKindBlockIndex().OutputType, new.GetKindBlockIndex().OutputType)
+		}
+	}
+	changed("entrypoint", old.BinaryEntrypoint, new.BinaryEntrypoint)
+
+	oldBinary, newBinary := oldModules.Binaries[old.BinaryIndex], newModules.Binaries[new.BinaryIndex]
+	changed("binary type", oldBinary.Type, newBinary.Type)
+	if !bytes.Equal(oldBinary.Content, newBinary.Content) {
+		changes = append(changes, fmt.Sprintf("binary code: %s -> %s", contentHash(oldBinary.Content), contentHash(newBinary.Content)))
+	}
+	return changes
+}
+
+func moduleKind(module *pbsubstreams.Module) string {
+	switch module.Kind.(type) {
+	case *pbsubstreams.Module_KindMap_:
+		return "map"
+	case *pbsubstreams.Module_KindStore_:
+		return "store"
+	case *pbsubstreams.Module_KindBlockIndex_:
+		return "blockIndex"
+	}
+	return "unknown"
+}
+
+func updatePolicy(module *pbsubstreams.Module) string {
+	return strings.ToLower(strings.TrimPrefix(module.GetKindStore().UpdatePolicy.String(), "UPDATE_POLICY_"))
+}
+
+// moduleInputs renders the inputs of a module, as in `[source:sf.ethereum.type.v2.Block, store:store_pools(get)]`.
+func moduleInputs(module *pbsubstreams.Module) string {
+	var inputs []string
+	for _, input := range module.Inputs {
+		var in string
+		switch i := input.Input.(type) {
+		case *pbsubstreams.Module_Input_Source_:
+			in = "source:" + i.Source.Type
+		case *pbsubstreams.Module_Input_Map_:
+			in = "map:" + i.Map.ModuleName
+		case *pbsubstreams.Module_Input_Store_:
+			in = fmt.Sprintf("store:%s(%s)", i.Store.ModuleName, strings.ToLower(strings.TrimPrefix(i.Store.Mode.String(), "MODE_")))
+		case *pbsubstreams.Module_Input_Params_:
+			in = fmt.Sprintf("params:%q", i.Params.Value)
+		}
+		if filter := input.BlockFilter; filter != nil {
+			in += fmt.Sprintf("{%s:%q}", filter.Module, filter.Query)
+		}
+		inputs = append(inputs, in)
+	}
+	return "[" + strings.Join(inputs, ", ") + "]"
+}
+
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// Print writes the report in a human-readable form.
+func (r *Report) Print(w io.Writer) {
+	if r.Empty() {
+		fmt.Fprintln(w, "No structural differences")
+		return
+	}
+
+	if len(r.ModulesAdded) != 0 {
+		fmt.Fprintf(w, "Modules added: %s\n", strings.Join(r.ModulesAdded, ", "))
+	}
+	if len(r.ModulesRemoved) != 0 {
+		fmt.Fprintf(w, "Modules removed: %s\n", strings.Join(r.ModulesRemoved, ", "))
+	}
+	for _, module := range r.Modules {
+		if module.HashChanged() {
+			fmt.Fprintf(w, "Module %s (hash %s -> %s, cache invalidated):\n", module.Name, module.OldHash, module.NewHash)
+		} else {
+			fmt.Fprintf(w, "Module %s (hash unchanged):\n", module.Name)
+		}
+		for _, change := range module.Changes {
+			fmt.Fprintf(w, "  %s\n", change)
+		}
+	}
+
+	if len(r.MessagesAdded) != 0 {
+		fmt.Fprintf(w, "Messages added: %s\n", strings.Join(r.MessagesAdded, ", "))
+	}
+	if len(r.MessagesRemoved) != 0 {
+		fmt.Fprintf(w, "Messages removed: %s\n", strings.Join(r.MessagesRemoved, ", "))
+	}
+	for _, message := range r.Messages {
+		fmt.Fprintf(w, "Message %s:\n", message.Name)
+		for _, change := range message.Changes {
+			fmt.Fprintf(w, "  %s\n", change)
+		}
+	}
+}
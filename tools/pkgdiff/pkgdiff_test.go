@@ -0,0 +1,137 @@
+package pkgdiff
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+func testPackage() *pbsubstreams.Package {
+	return &pbsubstreams.Package{
+		Modules: &pbsubstreams.Modules{
+			Binaries: []*pbsubstreams.Binary{{Type: "wasm/rust-v1", Content: []byte("code")}},
+			Modules: []*pbsubstreams.Module{
+				{
+					Name:             "map_pools",
+					BinaryEntrypoint: "map_pools",
+					Kind:             &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{OutputType: "proto:acme.Pools"}},
+					Inputs: []*pbsubstreams.Module_Input{
+						{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "sf.ethereum.type.v2.Block"}}},
+					},
+					InitialBlock: 100,
+				},
+				{
+					Name:             "store_pools",
+					BinaryEntrypoint: "store_pools",
+					Kind: &pbsubstreams.Module_KindStore_{KindStore: &pbsubstreams.Module_KindStore{
+						UpdatePolicy: pbsubstreams.Module_KindStore_UPDATE_POLICY_SET,
+						ValueType:    "proto:acme.Pool",
+					}},
+					Inputs: []*pbsubstreams.Module_Input{
+						{Input: &pbsubstreams.Module_Input_Map_{Map: &pbsubstreams.Module_Input_Map{ModuleName: "map_pools"}}},
+					},
+					InitialBlock: 100,
+				},
+				{
+					Name:             "map_old",
+					BinaryEntrypoint: "map_old",
+					Kind:             &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{OutputType: "proto:acme.Pools"}},
+					Inputs: []*pbsubstreams.Module_Input{
+						{Input: &pbsubstreams.Module_Input_Map_{Map: &pbsubstreams.Module_Input_Map{ModuleName: "map_pools"}}},
+					},
+					InitialBlock: 100,
+				},
+			},
+		},
+		ProtoFiles: []*descriptorpb.FileDescriptorProto{{
+			Name:    proto.String("acme.proto"),
+			Package: proto.String("acme"),
+			MessageType: []*descriptorpb.DescriptorProto{
+				{
+					Name: proto.String("Pools"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						{Name: proto.String("pools"), Number: proto.Int32(1), Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(), Type: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(), TypeName: proto.String(".acme.Pool")},
+					},
+				},
+				{
+					Name: proto.String("Pool"),
+					Field: []*descriptorpb.FieldDescriptorProto{
+						{Name: proto.String("address"), Number: proto.Int32(1), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()},
+						{Name: proto.String("fee"), Number: proto.Int32(2), Type: descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum()},
+					},
+				},
+			},
+		}},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := testPackage()
+
+	report, err := Diff(old, testPackage())
+	require.NoError(t, err)
+	assert.True(t, report.Empty())
+
+	new := testPackage()
+	mapPools, storePools := new.Modules.Modules[0], new.Modules.Modules[1]
+	mapPools.InitialBlock = 200
+	storePools.GetKindStore().UpdatePolicy = pbsubstreams.Module_KindStore_UPDATE_POLICY_SET_IF_NOT_EXISTS
+	storePools.Inputs[0].BlockFilter = &pbsubstreams.Module_Input_BlockFilter{Module: "index_pools", Query: "pool"}
+	new.Modules.Modules = append(new.Modules.Modules[:2], &pbsubstreams.Module{
+		Name:   "map_new",
+		Kind:   &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{OutputType: "proto:acme.Pools"}},
+		Inputs: []*pbsubstreams.Module_Input{{Input: &pbsubstreams.Module_Input_Params_{Params: &pbsubstreams.Module_Input_Params{Value: "x"}}}},
+	})
+	pool := new.ProtoFiles[0].MessageType[1]
+	pool.Field[1].Type = descriptorpb.FieldDescriptorProto_TYPE_UINT64.Enum()
+	pool.Field = append(pool.Field, &descriptorpb.FieldDescriptorProto{Name: proto.String("token"), Number: proto.Int32(3), Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum()})
+	pool.NestedType = []*descriptorpb.DescriptorProto{{Name: proto.String("Token")}}
+
+	report, err = Diff(old, new)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"map_new"}, report.ModulesAdded)
+	assert.Equal(t, []string{"map_old"}, report.ModulesRemoved)
+	require.Len(t, report.Modules, 2)
+	assert.Equal(t, "map_pools", report.Modules[0].Name)
+	assert.Equal(t, []string{"initial block: 100 -> 200"}, report.Modules[0].Changes)
+	assert.True(t, report.Modules[0].HashChanged())
+	assert.Equal(t, "store_pools", report.Modules[1].Name)
+	assert.Equal(t, []string{
+		`inputs: [map:map_pools] -> [map:map_pools{index_pools:"pool"}]`,
+		"update policy: set -> set_if_not_exists",
+	}, report.Modules[1].Changes)
+	assert.True(t, report.Modules[1].HashChanged())
+
+	assert.Equal(t, []string{"acme.Pool.Token"}, report.MessagesAdded)
+	assert.Empty(t, report.MessagesRemoved)
+	require.Len(t, report.Messages, 1)
+	assert.Equal(t, "acme.Pool", report.Messages[0].Name)
+	assert.Equal(t, []string{
+		"field 2 (fee) type: int32 -> uint64",
+		"field 3 added: token string",
+	}, report.Messages[0].Changes)
+
+	buf := &bytes.Buffer{}
+	report.Print(buf)
+	assert.Contains(t, buf.String(), "Modules added: map_new\n")
+	assert.Contains(t, buf.String(), "cache invalidated):\n  initial block: 100 -> 200\n")
+}
+
+func TestDiff_AncestorsChanged(t *testing.T) {
+	new := testPackage()
+	new.Modules.Binaries = append(new.Modules.Binaries, &pbsubstreams.Binary{Type: "wasm/rust-v1", Content: []byte("new code")})
+	new.Modules.Modules[0].BinaryIndex = 1
+
+	report, err := Diff(testPackage(), new)
+	require.NoError(t, err)
+	require.Len(t, report.Modules, 3)
+	assert.Equal(t, []string{"binary code: sha256:5694d08a2e53ffca -> sha256:d7bc32e5f6760a99"}, report.Modules[0].Changes)
+	assert.Equal(t, []string{"ancestors changed"}, report.Modules[1].Changes)
+	assert.True(t, report.Modules[1].HashChanged())
+}
@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/streamingfast/cli"
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/store"
+	"github.com/streamingfast/substreams/storage/store/state"
+)
+
+var StoreCmd = &cobra.Command{
+	Use:          "store",
+	Short:        "Inspect the contents of store modules from the state store",
+	SilenceUsage: true,
+}
+
+var storeGetCmd = &cobra.Command{
+	Use:   "get [<manifest_file>] <module_name> <key>",
+	Short: "Print the value of a store key at a given block",
+	Long: cli.Dedent(`
+		Print the value a store module's key had once the block given by '--at-block' was processed, decoded using
+		the store's protobuf value type. The value is read from the nearest full store snapshot below that block, and
+		updated with the store deltas found in the module's output cache up to that block. The manifest is optional
+		as it will try to find a file named 'substreams.yaml' in current working directory if nothing entered. You may
+		enter a directory that contains a 'substreams.yaml' file in place of '<manifest_file>, or a link to a remote
+		.spkg file, using urls gs://, http(s)://, ipfs://, etc.'.
+	`),
+	Example: string(cli.ExamplePrefixed("substreams store get", `
+		store_pools pool:c772a65917d5da983b7fc3c9cfbfb53ef01aef7e --at-block 12487090 --state-store gs://[bucket-url-path]
+		uniswap-v3.spkg store_pools pool:c772a65917d5da983b7fc3c9cfbfb53ef01aef7e --at-block 12487090 --state-store ./localdata
+	`)),
+	RunE:         runStoreGetE,
+	Args:         cobra.RangeArgs(2, 3),
+	SilenceUsage: true,
+}
+
+func init() {
+	storeGetCmd.Flags().Uint64("at-block", 0, "Block at which to read the value, once that block is processed")
+	storeGetCmd.Flags().String("state-store", "./localdata", "URL of the state store holding the store snapshots and output cache")
+
+	StoreCmd.AddCommand(storeGetCmd)
+}
+
+func runStoreGetE(cmd *cobra.Command, args []string) error {
+	ctx := cmd.Context()
+
+	manifestPath := ""
+	if len(args) == 3 {
+		manifestPath = args[0]
+		args = args[1:]
+	}
+
+	moduleName := args[0]
+	key := args[1]
+	blockNum := mustGetUint64(cmd, "at-block")
+	stateStoreURL := mustGetString(cmd, "state-store")
+
+	zlog.Info("reading store key",
+		zap.String("manifest_path", manifestPath),
+		zap.String("module_name", moduleName),
+		zap.String("key", key),
+		zap.Uint64("at_block", blockNum),
+		zap.String("state_store_url", stateStoreURL),
+	)
+
+	stateStore, err := dstore.NewStore(stateStoreURL, "zst", "zstd", false)
+	if err != nil {
+		return fmt.Errorf("initializing dstore for %q: %w", stateStoreURL, err)
+	}
+
+	manifestReader, err := manifest.NewReader(manifestPath)
+	if err != nil {
+		return fmt.Errorf("manifest reader: %w", err)
+	}
+
+	pkg, err := manifestReader.Read()
+	if err != nil {
+		return fmt.Errorf("read manifest %q: %w", manifestPath, err)
+	}
+
+	graph, err := manifest.NewModuleGraph(pkg.Modules.Modules)
+	if err != nil {
+		return fmt.Errorf("processing module graph %w", err)
+	}
+
+	module, err := graph.Module(moduleName)
+	if err != nil {
+		return fmt.Errorf("module %q not found: %w", moduleName, err)
+	}
+	if module.GetKindStore() == nil {
+		return fmt.Errorf("module %q is not a store", moduleName)
+	}
+
+	hash, err := manifest.NewModuleHashes().HashModule(pkg.Modules, module, graph)
+	if err != nil {
+		return err
+	}
+	moduleHash := hex.EncodeToString(hash)
+	zlog.Info("found module hash", zap.String("hash", moduleHash), zap.String("module", module.Name))
+
+	config, err := store.NewConfig(module.Name, module.InitialBlock, moduleHash, module.GetKindStore().GetUpdatePolicy(), module.GetKindStore().GetValueType(), stateStore, "")
+	if err != nil {
+		return fmt.Errorf("initializing store config module %q: %w", module.Name, err)
+	}
+	outputs, err := execout.NewConfig(module.Name, module.InitialBlock, pbsubstreams.ModuleKindStore, moduleHash, stateStore, zlog)
+	if err != nil {
+		return fmt.Errorf("execout new config: %w", err)
+	}
+
+	value, found, err := state.ReadKeyAtBlock(ctx, config, outputs, key, blockNum)
+	if err != nil {
+		return fmt.Errorf("reading key %q at block %d: %w", key, blockNum, err)
+	}
+	if !found {
+		return fmt.Errorf("no data found for %q at block %d", key, blockNum)
+	}
+	return printObject(module, pkg.ProtoFiles, value)
+}
@@ -0,0 +1,158 @@
+package comparator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+var _ Comparable = (*List)(nil)
+var _ Comparable = (*Length)(nil)
+var _ Comparable = (*Regex)(nil)
+var _ Comparable = (*Exists)(nil)
+
+// List expects a list equal to a JSON array, in any order with
+// `unordered=true`.
+type List struct {
+	expect    []interface{}
+	unordered bool
+}
+
+func newList(expect string, args url.Values) (*List, error) {
+	l := &List{}
+	if err := json.Unmarshal([]byte(expect), &l.expect); err != nil {
+		return nil, fmt.Errorf("expected value must be a JSON array: %w", err)
+	}
+	l.unordered = args.Get("unordered") == "true"
+	if l.unordered {
+		sortValues(l.expect)
+	}
+	return l, nil
+}
+
+func (l *List) Cmp(value interface{}) (bool, string, error) {
+	actual, ok := value.([]interface{})
+	if !ok {
+		return false, fmt.Sprintf("[list] expected a list, got %s", describe(value)), nil
+	}
+	if l.unordered {
+		actual = append([]interface{}(nil), actual...)
+		sortValues(actual)
+	}
+	if !reflect.DeepEqual(actual, l.expect) {
+		return false, fmt.Sprintf("[list] expected %s to equal %s", encode(actual), encode(l.expect)), nil
+	}
+	return true, "", nil
+}
+
+// sortValues sorts `values` by their JSON encoding.
+func sortValues(values []interface{}) {
+	sort.SliceStable(values, func(i, j int) bool { return encode(values[i]) < encode(values[j]) })
+}
+
+// Length expects a list, an object or a string of a given length.
+type Length struct {
+	expect int
+}
+
+func newLength(expect string, args url.Values) (*Length, error) {
+	length, err := strconv.Atoi(expect)
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("expected value must be a length, got %q", expect)
+	}
+	return &Length{expect: length}, nil
+}
+
+func (l *Length) Cmp(value interface{}) (bool, string, error) {
+	var length int
+	switch v := value.(type) {
+	case []interface{}:
+		length = len(v)
+	case map[string]interface{}:
+		length = len(v)
+	case string:
+		length = len(v)
+	default:
+		return false, fmt.Sprintf("[length] expected a list, an object or a string, got %s", describe(value)), nil
+	}
+	if length != l.expect {
+		return false, fmt.Sprintf("[length] expected length %d to equal %d", length, l.expect), nil
+	}
+	return true, "", nil
+}
+
+// Regex expects a scalar value matching a regular expression.
+type Regex struct {
+	expect *regexp.Regexp
+}
+
+func newRegex(expect string, args url.Values) (*Regex, error) {
+	re, err := regexp.Compile(expect)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	return &Regex{expect: re}, nil
+}
+
+func (r *Regex) Cmp(value interface{}) (bool, string, error) {
+	actual, ok := scalarString(value)
+	if !ok {
+		return false, fmt.Sprintf("[regex] expected a scalar value, got %s", describe(value)), nil
+	}
+	if !r.expect.MatchString(actual) {
+		return false, fmt.Sprintf("[regex] expected %q to match %q", actual, r.expect.String()), nil
+	}
+	return true, "", nil
+}
+
+// Exists expects the path to yield a non-null value, or, with an expected
+// value of `false`, to yield nothing or null.
+type Exists struct {
+	expect bool
+}
+
+func newExists(expect string, args url.Values) (*Exists, error) {
+	if expect == "" {
+		return &Exists{expect: true}, nil
+	}
+	exists, err := strconv.ParseBool(expect)
+	if err != nil {
+		return nil, fmt.Errorf("expected value must be 'true' or 'false', got %q", expect)
+	}
+	return &Exists{expect: exists}, nil
+}
+
+func (e *Exists) Cmp(value interface{}) (bool, string, error) {
+	exists := value != nil
+	if exists != e.expect {
+		if e.expect {
+			return false, "[exists] expected a value, got none", nil
+		}
+		return false, fmt.Sprintf("[exists] expected no value, got %s", encode(value)), nil
+	}
+	return true, "", nil
+}
+
+func describe(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "no value"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return encode(value)
+}
+
+func encode(value interface{}) string {
+	cnt, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(cnt)
+}
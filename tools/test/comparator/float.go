@@ -29,7 +29,12 @@ func newFloat(expect string, args url.Values) (*Float, error) {
 	return f, nil
 }
 
-func (i *Float) Cmp(actual string) (bool, string, error) {
+func (i *Float) Cmp(value interface{}) (bool, string, error) {
+	actual, ok := scalarString(value)
+	if !ok {
+		return false, fmt.Sprintf("[float] expected a scalar value, got %s", describe(value)), nil
+	}
+
 	a, ok := new(big.Float).SetString(actual)
 	if !ok {
 		return false, "", fmt.Errorf("[float] failed to parse %q as big float", actual)
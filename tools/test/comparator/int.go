@@ -20,7 +20,12 @@ func newInt(expect string, args url.Values) (*Int, error) {
 	return &Int{expect: int}, nil
 }
 
-func (i *Int) Cmp(actual string) (bool, string, error) {
+func (i *Int) Cmp(value interface{}) (bool, string, error) {
+	actual, ok := scalarString(value)
+	if !ok {
+		return false, fmt.Sprintf("[int] expected a scalar value, got %s", describe(value)), nil
+	}
+
 	a, ok := new(big.Int).SetString(actual, 10)
 	if !ok {
 		return false, "", fmt.Errorf("[int] failed to parse %q as big int", actual)
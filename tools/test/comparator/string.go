@@ -15,7 +15,12 @@ func newString(expect string, args url.Values) *String {
 	return &String{expect: expect}
 }
 
-func (s *String) Cmp(actual string) (bool, string, error) {
+func (s *String) Cmp(value interface{}) (bool, string, error) {
+	actual, ok := scalarString(value)
+	if !ok {
+		return false, fmt.Sprintf("[string] expected a scalar value, got %s", describe(value)), nil
+	}
+
 	if actual != s.expect {
 		return false, fmt.Sprintf("[string] expected %q to equal %q", actual, s.expect), nil
 	}
@@ -3,10 +3,14 @@ package comparator
 import (
 	"fmt"
 	"net/url"
+	"strconv"
 )
 
+// Comparable compares the result of a test's path with what it expects.
+// `actual` is a value decoded from JSON (nil, bool, float64, string,
+// []interface{} or map[string]interface{}), nil if the path yielded nothing.
 type Comparable interface {
-	Cmp(actual string) (bool, string, error)
+	Cmp(actual interface{}) (bool, string, error)
 }
 
 func NewComparable(expect string, op string, args string) (cmp Comparable, err error) {
@@ -27,13 +31,37 @@ func NewComparable(expect string, op string, args string) (cmp Comparable, err e
 		cmp, err = newInt(expect, params)
 	case "float":
 		cmp, err = newFloat(expect, params)
+	case "list":
+		cmp, err = newList(expect, params)
+	case "length":
+		cmp, err = newLength(expect, params)
+	case "regex":
+		cmp, err = newRegex(expect, params)
+	case "exists":
+		cmp, err = newExists(expect, params)
+	default:
+		return nil, fmt.Errorf("unknown op %q", op)
 	}
 	if err != nil {
-		return nil, fmt.Errorf("unknown op %q", op)
+		return nil, fmt.Errorf("op %q: %w", op, err)
 	}
 	return cmp, nil
 }
 
+// scalarString returns the string form of a string, number or boolean
+// `actual`.
+func scalarString(actual interface{}) (string, bool) {
+	switch v := actual.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
 type Def struct {
 	Op     string `json:"op" yaml:"op"`
 	Expect string `json:"expect" yaml:"expect"`
@@ -20,6 +20,9 @@ func TestNewComparable(t *testing.T) {
 		{"use the op", "helloworld", "string", "", reflect.TypeOf(&String{}), false},
 		{"fails if expect not float", "adsa", "float", "", nil, true},
 		{"fails if expect not int", "adsa", "int", "", nil, true},
+		{"fails if op unknown", "adsa", "foo", "", nil, true},
+		{"fails if expect not list", "adsa", "list", "", nil, true},
+		{"fails if regex invalid", "(", "regex", "", nil, true},
 	}
 
 	for _, test := range tests {
@@ -34,3 +37,39 @@ func TestNewComparable(t *testing.T) {
 		})
 	}
 }
+
+func TestComparable_Cmp(t *testing.T) {
+	tests := []struct {
+		name   string
+		op     string
+		expect string
+		args   string
+		actual interface{}
+		valid  bool
+	}{
+		{"string", "string", "abc", "", "abc", true},
+		{"int from number", "int", "12", "", float64(12), true},
+		{"int on list", "int", "12", "", []interface{}{"12"}, false},
+		{"list", "list", `["a", 1]`, "", []interface{}{"a", float64(1)}, true},
+		{"list order", "list", `["a", "b"]`, "", []interface{}{"b", "a"}, false},
+		{"list unordered", "list", `["a", "b"]`, "unordered=true", []interface{}{"b", "a"}, true},
+		{"length of list", "length", "2", "", []interface{}{"b", "a"}, true},
+		{"length of object", "length", "2", "", map[string]interface{}{"a": "b"}, false},
+		{"length of string", "length", "3", "", "abc", true},
+		{"regex", "regex", "^0x[0-9a-f]{4}$", "", "0xab12", true},
+		{"regex mismatch", "regex", "^0x[0-9a-f]{4}$", "", "0xab1", false},
+		{"exists", "exists", "", "", "abc", true},
+		{"exists none", "exists", "true", "", nil, false},
+		{"not exists", "exists", "false", "", nil, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmp, err := NewComparable(test.expect, test.op, test.args)
+			require.NoError(t, err)
+			valid, msg, err := cmp.Cmp(test.actual)
+			require.NoError(t, err)
+			assert.Equal(t, test.valid, valid, msg)
+		})
+	}
+}
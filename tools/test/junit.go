@@ -0,0 +1,93 @@
+package test
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name      `xml:"testsuites"`
+	Name     string        `xml:"name,attr"`
+	Tests    int           `xml:"tests,attr"`
+	Failures int           `xml:"failures,attr"`
+	Skipped  int           `xml:"skipped,attr"`
+	Suites   []*junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Skipped   int              `xml:"skipped,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Content string `xml:",chardata"`
+}
+
+// WriteJUnitReport writes the results as a JUnit XML report, with a test
+// suite per module and a test case per test of the spec file. A test fails
+// if any of its results failed, and is skipped if it never matched an
+// output.
+func (r *Runner) WriteJUnitReport(w io.Writer) error {
+	failuresByTest := map[*Test][]string{}
+	for _, result := range r.results {
+		if !result.Valid {
+			failuresByTest[result.test] = append(failuresByTest[result.test], fmt.Sprintf("block %d: %s", result.Block, result.Msg))
+		}
+	}
+
+	report := &junitTestSuites{Name: "substreams"}
+	suites := map[string]*junitSuite{}
+	for _, test := range r.tests {
+		suite, found := suites[test.moduleName]
+		if !found {
+			suite = &junitSuite{Name: test.moduleName}
+			suites[test.moduleName] = suite
+			report.Suites = append(report.Suites, suite)
+		}
+
+		testCase := &junitTestCase{Name: test.name() + " " + test.path, ClassName: test.moduleName}
+		if test.snapshot != nil {
+			testCase.Name = test.name() + " snapshot " + test.snapshot.path
+		}
+		switch failures := failuresByTest[test]; {
+		case len(failures) != 0:
+			testCase.Failure = &junitMessage{
+				Message: fmt.Sprintf("%d of %d results failed", len(failures), test.results),
+				Content: strings.Join(failures, "\n"),
+			}
+			suite.Failures++
+			report.Failures++
+		case test.results == 0:
+			testCase.Skipped = &junitMessage{Message: "no output matched"}
+			suite.Skipped++
+			report.Skipped++
+		}
+		suite.Tests++
+		report.Tests++
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("encoding junit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
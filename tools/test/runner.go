@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/jhump/protoreflect/dynamic"
@@ -12,58 +14,77 @@ import (
 	"github.com/streamingfast/substreams/manifest"
 	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
 	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/tools/test/comparator"
 )
 
 type Runner struct {
-	tests          map[uint64]map[string][]*Test
+	tests          []*Test
 	descs          map[string]*manifest.ModuleDescriptor
 	messageFactory *dynamic.MessageFactory
 
 	logger *zap.Logger
 
-	configured uint64
-	passed     uint64
-	failed     uint64
-	notfound   uint64
-	verbose    bool
-	results    []*Result
+	passed          uint64
+	failed          uint64
+	verbose         bool
+	updateSnapshots bool
+	results         []*Result
+}
+
+type RunnerOption func(*Runner)
+
+// WithUpdateSnapshots makes the runner write the golden files of the
+// snapshot tests from the outputs of the run, instead of comparing them.
+func WithUpdateSnapshots() RunnerOption {
+	return func(r *Runner) {
+		r.updateSnapshots = true
+	}
 }
 
 var successStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true)
 var failedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)
 
-func NewRunner(path string, descs map[string]*manifest.ModuleDescriptor, verbose bool, logger *zap.Logger) (*Runner, error) {
+func NewRunner(path string, descs map[string]*manifest.ModuleDescriptor, verbose bool, logger *zap.Logger, opts ...RunnerOption) (*Runner, error) {
 	spec, err := readSpecFromFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("reading spec: %w", err)
 	}
 
 	r := &Runner{
-		tests:          map[uint64]map[string][]*Test{},
 		descs:          descs,
 		messageFactory: dynamic.NewMessageFactoryWithDefaults(),
 		logger:         logger.Named("substreams_test"),
 		verbose:        verbose,
-		configured:     uint64(len(spec.Tests)),
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
 
 	for idx, testConfig := range spec.Tests {
-		if _, found := r.tests[testConfig.Block]; !found {
-			r.tests[testConfig.Block] = map[string][]*Test{}
-		}
-
-		blockTests := r.tests[testConfig.Block]
-
 		test, err := testConfig.Test(idx)
 		if err != nil {
 			return nil, fmt.Errorf("failed to setup test number %d: %w", idx, err)
 		}
-		blockTests[testConfig.Module] = append(blockTests[testConfig.Module], test)
+		// snapshots are relative to the spec file
+		if test.snapshot != nil && !filepath.IsAbs(test.snapshot.path) {
+			test.snapshot.path = filepath.Join(filepath.Dir(path), test.snapshot.path)
+		}
+		r.tests = append(r.tests, test)
 	}
 
 	return r, nil
 }
 
+// testsOf returns the tests of `moduleName` covering `blockNum`.
+func (r *Runner) testsOf(moduleName string, blockNum uint64) (out []*Test) {
+	for _, test := range r.tests {
+		if test.moduleName == moduleName && test.blocks.Contains(blockNum) {
+			out = append(out, test)
+		}
+	}
+	return out
+}
+
 func (r *Runner) Test(
 	ctx context.Context,
 	output *pbsubstreamsrpc.MapModuleOutput,
@@ -73,33 +94,28 @@ func (r *Runner) Test(
 ) error {
 	logger := r.logger.With(zap.Uint64("block_num", clock.Number))
 
-	blockTests, found := r.tests[clock.Number]
-	if !found {
-		logger.Debug("skip block test not test found")
-		return nil
-	}
-
 	for _, out := range append([]*pbsubstreamsrpc.MapModuleOutput{output}, debugMapOutputs...) {
-		moduleName := out.Name
-		moduleTests, found := blockTests[moduleName]
-		if !found {
+		if out == nil {
+			continue
+		}
+		moduleTests := r.testsOf(out.Name, clock.Number)
+		if len(moduleTests) == 0 {
 			logger.Debug("skipping module test no test found", zap.String("module", out.Name))
 			continue
 		}
 
-		if err := r.testMapModule(ctx, out, moduleTests, logger); err != nil {
+		if err := r.testMapModule(ctx, out, moduleTests, clock.Number, logger); err != nil {
 			return fmt.Errorf("failed to run test on module  %q - %d: %w", out.Name, clock.Number, err)
 		}
 	}
 
 	for _, out := range debugStoreOutputs {
-		moduleName := out.Name
-		moduleTests, found := blockTests[moduleName]
-		if !found {
+		moduleTests := r.testsOf(out.Name, clock.Number)
+		if len(moduleTests) == 0 {
 			logger.Debug("skipping module test no test found", zap.String("module", out.Name))
 			continue
 		}
-		if err := r.testStoreModule(ctx, out, moduleTests, logger); err != nil {
+		if err := r.testStoreModule(ctx, out, moduleTests, clock.Number, logger); err != nil {
 			return fmt.Errorf("failed to run test on module  %q - %d: %w", out.Name, clock.Number, err)
 		}
 	}
@@ -107,7 +123,7 @@ func (r *Runner) Test(
 	return nil
 }
 
-func (r *Runner) testMapModule(ctx context.Context, module *pbsubstreamsrpc.MapModuleOutput, tests []*Test, logger *zap.Logger) error {
+func (r *Runner) testMapModule(ctx context.Context, module *pbsubstreamsrpc.MapModuleOutput, tests []*Test, blockNum uint64, logger *zap.Logger) error {
 	logger = logger.With(zap.String("module", module.Name), zap.String("module_type", "map"))
 	moduleName := module.Name
 
@@ -144,7 +160,7 @@ func (r *Runner) testMapModule(ctx context.Context, module *pbsubstreamsrpc.MapM
 		return nil
 	}
 
-	return r.runTests(ctx, input, tests, logger)
+	return r.runTests(ctx, input, tests, blockNum, logger)
 }
 
 type StorageDelta struct {
@@ -155,7 +171,7 @@ type StorageDelta struct {
 	NewValue  *json.RawMessage `json:"new,omitempty"`
 }
 
-func (r *Runner) testStoreModule(ctx context.Context, module *pbsubstreamsrpc.StoreModuleOutput, tests []*Test, logger *zap.Logger) error {
+func (r *Runner) testStoreModule(ctx context.Context, module *pbsubstreamsrpc.StoreModuleOutput, tests []*Test, blockNum uint64, logger *zap.Logger) error {
 	logger = logger.With(zap.String("module", module.Name), zap.String("module_type", "store"))
 	moduleName := module.Name
 
@@ -174,6 +190,17 @@ func (r *Runner) testStoreModule(ctx context.Context, module *pbsubstreamsrpc.St
 		}
 	}
 
+	// snapshots hold the deltas of each block, other tests run on each delta
+	var snapshotTests, deltaTests []*Test
+	for _, test := range tests {
+		if test.snapshot != nil {
+			snapshotTests = append(snapshotTests, test)
+		} else {
+			deltaTests = append(deltaTests, test)
+		}
+	}
+	deltas := []interface{}{}
+
 	logger.Debug("running test on store deltas", zap.Int("delta_count", len(module.DebugStoreDeltas)))
 	for _, delta := range module.DebugStoreDeltas {
 		temp := &StorageDelta{
@@ -206,70 +233,120 @@ func (r *Runner) testStoreModule(ctx context.Context, module *pbsubstreamsrpc.St
 			return nil
 		}
 
-		if err := r.runTests(ctx, input, tests, logger); err != nil {
+		deltas = append(deltas, input)
+
+		if err := r.runTests(ctx, input, deltaTests, blockNum, logger); err != nil {
 			return fmt.Errorf("failed to run tests: %w", err)
 		}
 
 	}
 
-	return nil
+	return r.runTests(ctx, deltas, snapshotTests, blockNum, logger)
 }
 
-func (r *Runner) runTests(ctx context.Context, input interface{}, tests []*Test, logger *zap.Logger) error {
-
+func (r *Runner) runTests(ctx context.Context, input interface{}, tests []*Test, blockNum uint64, logger *zap.Logger) error {
 	for _, test := range tests {
 		logger.Debug("running test", zap.String("path", test.path))
 		iter := test.code.RunWithContext(ctx, input) // or query.RunWithContext
-		// we will assume there should be only 1 result
-		v, ok := iter.Next()
-		if !ok {
-			break
-		}
+		// we will assume there should be only 1 result, none being a nil value
+		v, _ := iter.Next()
 		if err, ok := v.(error); ok {
 			logger.Debug("failed get path ", zap.Error(err))
 			continue
 		}
 
-		actual, ok := v.(string)
-		if !ok {
-			r.notfound++
+		if test.snapshot != nil {
+			test.snapshot.record(blockNum, v)
+			continue
+		}
+
+		// a path yielding nothing does not match, unless its absence is tested
+		if _, isExists := test.comparable.(*comparator.Exists); v == nil && !isExists {
 			continue
 		}
 
-		valid, msg, err := test.comparable.Cmp(actual)
+		valid, msg, err := test.comparable.Cmp(v)
 		if err != nil {
 			return fmt.Errorf("failed to run test %d - %s: %w", test.fileIndex, test.path, err)
 		}
-
-		result := &Result{
+		r.addResult(&Result{
 			test:  test,
+			Block: blockNum,
 			Valid: valid,
 			Msg:   msg,
+		})
+	}
+	return nil
+}
+
+func (r *Runner) addResult(result *Result) {
+	result.test.results++
+	r.results = append(r.results, result)
+	if result.Valid {
+		r.passed++
+	} else {
+		r.failed++
+	}
+}
+
+// Finish compares the outputs recorded by the snapshot tests with their
+// golden file, or writes it when updating snapshots. It must be called
+// once the stream is done, before reporting the results.
+func (r *Runner) Finish() error {
+	for _, test := range r.tests {
+		if test.snapshot == nil || len(test.snapshot.outputs) == 0 {
+			continue
 		}
-		r.results = append(r.results, result)
-		if result.Valid {
-			r.passed++
+
+		result := &Result{test: test, Block: test.blocks.StartBlock, Valid: true}
+		if r.updateSnapshots {
+			if err := test.snapshot.write(); err != nil {
+				return fmt.Errorf("writing snapshot %q: %w", test.snapshot.path, err)
+			}
+			result.Msg = fmt.Sprintf("snapshot of %d blocks written to %s", len(test.snapshot.outputs), test.snapshot.path)
 		} else {
-			r.failed++
+			diffs, err := test.snapshot.diff()
+			if err != nil {
+				return err
+			}
+			if len(diffs) != 0 {
+				result.Valid = false
+				result.Msg = fmt.Sprintf("[snapshot] outputs differ from %s:\n  %s", test.snapshot.path, strings.Join(diffs, "\n  "))
+			}
 		}
+		r.addResult(result)
 	}
 	return nil
 }
 
+// Failed returns the number of failed results.
+func (r *Runner) Failed() uint64 {
+	return r.failed
+}
+
 func (r *Runner) LogResults() {
+	var notMatched int
+	for _, test := range r.tests {
+		if test.results == 0 {
+			notMatched++
+		}
+	}
+
 	if r.verbose {
 		fmt.Println()
 		for _, result := range r.results {
 			status := successStyle.Render("ok")
 			if !result.Valid {
 				status = fmt.Sprintf("%s > %s", failedStyle.Render("failed"), result.Msg)
+			} else if result.Msg != "" {
+				status = fmt.Sprintf("%s > %s", status, result.Msg)
 			}
-			fmt.Printf("test %s:%d:%d ... %s\n", result.test.moduleName, result.test.block, result.test.fileIndex, status)
+			fmt.Printf("test %s:%d:%d ... %s\n", result.test.moduleName, result.Block, result.test.fileIndex, status)
 		}
 	}
 
 	fmt.Println()
-	fmt.Printf("test result: ok. %d configured; %d passed; %d failed; %d not matched\n", r.configured, r.passed, r.failed, int(r.configured)-len(r.results))
+	fmt.Printf("test result: ok. %d configured; %d passed; %d failed; %d not matched\n", len(r.tests), r.passed, r.failed, notMatched)
 	fmt.Println()
 }
 
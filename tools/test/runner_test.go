@@ -0,0 +1,182 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/streamingfast/substreams/manifest"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+)
+
+const testProto = `
+syntax = "proto3";
+package acme;
+
+message Pools {
+  repeated Pool pools = 1;
+}
+
+message Pool {
+  string address = 1;
+  uint64 fee = 2;
+}
+`
+
+type testModule struct {
+	descs map[string]*manifest.ModuleDescriptor
+}
+
+func newTestModule(t *testing.T) *testModule {
+	parser := protoparse.Parser{Accessor: protoparse.FileContentsFromMap(map[string]string{"acme.proto": testProto})}
+	files, err := parser.ParseFiles("acme.proto")
+	require.NoError(t, err)
+	return &testModule{descs: map[string]*manifest.ModuleDescriptor{
+		"map_pools": {MapOutputType: "proto:acme.Pools", ProtoMessageType: "acme.Pools", MessageDescriptor: files[0].FindMessage("acme.Pools")},
+	}}
+}
+
+// output returns an output holding a pool per fee.
+func (m *testModule) output(t *testing.T, fees ...uint64) *pbsubstreamsrpc.MapModuleOutput {
+	msgDesc := m.descs["map_pools"].MessageDescriptor
+	msg := dynamic.NewMessage(msgDesc)
+	for _, fee := range fees {
+		pool := dynamic.NewMessage(msgDesc.GetFile().FindMessage("acme.Pool"))
+		pool.SetFieldByName("address", "0xabc")
+		pool.SetFieldByName("fee", fee)
+		msg.AddRepeatedFieldByName("pools", pool)
+	}
+	cnt, err := msg.Marshal()
+	require.NoError(t, err)
+	return &pbsubstreamsrpc.MapModuleOutput{Name: "map_pools", MapOutput: &anypb.Any{Value: cnt}}
+}
+
+func (m *testModule) run(t *testing.T, specPath string, blocks map[uint64][]uint64, opts ...RunnerOption) *Runner {
+	runner, err := NewRunner(specPath, m.descs, false, zap.NewNop(), opts...)
+	require.NoError(t, err)
+	for blockNum := uint64(100); blockNum < 110; blockNum++ {
+		fees, found := blocks[blockNum]
+		if !found {
+			continue
+		}
+		require.NoError(t, runner.Test(context.Background(), m.output(t, fees...), nil, nil, &pbsubstreams.Clock{Number: blockNum}))
+	}
+	require.NoError(t, runner.Finish())
+	return runner
+}
+
+func writeSpec(t *testing.T, dir, spec string) string {
+	path := filepath.Join(dir, "tests.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(spec), 0644))
+	return path
+}
+
+func TestRunner_Ranges(t *testing.T) {
+	module := newTestModule(t)
+	specPath := writeSpec(t, t.TempDir(), `
+tests:
+  - module: map_pools
+    blocks: 100-105
+    path: .pools
+    op: length
+    expect: 1
+  - module: map_pools
+    block: 102
+    path: .pools[0].fee
+    op: int
+    expect: 3000
+  - module: map_pools
+    blocks: 100-110
+    path: .pools | map(.fee)
+    op: list
+    expect: "[\"3000\", \"500\"]"
+    args: unordered=true
+  - module: map_pools
+    blocks: 100-110
+    path: .pools[0].address
+    op: regex
+    expect: ^0x[0-9a-f]+$
+  - module: map_pools
+    block: 101
+    path: .pools[1]
+    op: exists
+    expect: "false"
+  - module: map_pools
+    block: 200
+    path: .pools
+    op: length
+    expect: 1
+`)
+
+	runner := module.run(t, specPath, map[uint64][]uint64{
+		101: {3000},
+		102: {3000},
+		104: {3000, 500},
+		108: {500, 3000},
+	})
+
+	var failed []uint64
+	for _, result := range runner.results {
+		if !result.Valid {
+			failed = append(failed, result.Block)
+		}
+	}
+	// the length test fails at 104, the list one at 101 and 102
+	assert.ElementsMatch(t, []uint64{101, 102, 104}, failed)
+	assert.Equal(t, uint64(10), runner.passed)
+	assert.Equal(t, 0, runner.tests[5].results)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, runner.WriteJUnitReport(buf))
+	report := buf.String()
+	assert.Contains(t, report, `<testsuites name="substreams" tests="6" failures="2" skipped="1">`)
+	assert.Contains(t, report, `<failure message="1 of 3 results failed">block 104: [length] expected length 2 to equal 1</failure>`)
+	assert.Contains(t, report, `<skipped message="no output matched"></skipped>`)
+}
+
+func TestRunner_Snapshot(t *testing.T) {
+	module := newTestModule(t)
+	dir := t.TempDir()
+	specPath := writeSpec(t, dir, `
+tests:
+  - module: map_pools
+    blocks: 100-110
+    snapshot: snapshots/map_pools.jsonl
+`)
+	blocks := map[uint64][]uint64{101: {3000}, 104: {3000, 500}}
+
+	// missing snapshot
+	runner := module.run(t, specPath, blocks)
+	require.Len(t, runner.results, 1)
+	assert.False(t, runner.results[0].Valid)
+	assert.Contains(t, runner.results[0].Msg, "record it with --test-update-snapshots")
+
+	runner = module.run(t, specPath, blocks, WithUpdateSnapshots())
+	require.Len(t, runner.results, 1)
+	assert.True(t, runner.results[0].Valid)
+	cnt, err := os.ReadFile(filepath.Join(dir, "snapshots", "map_pools.jsonl"))
+	require.NoError(t, err)
+	assert.Equal(t, `{"block":101,"output":{"pools":[{"address":"0xabc","fee":"3000"}]}}
+{"block":104,"output":{"pools":[{"address":"0xabc","fee":"3000"},{"address":"0xabc","fee":"500"}]}}
+`, string(cnt))
+
+	runner = module.run(t, specPath, blocks)
+	require.Len(t, runner.results, 1)
+	assert.True(t, runner.results[0].Valid)
+
+	runner = module.run(t, specPath, map[uint64][]uint64{101: {3000}, 104: {3000, 100}, 107: {}})
+	require.Len(t, runner.results, 1)
+	assert.False(t, runner.results[0].Valid)
+	assert.Contains(t, runner.results[0].Msg, `block 104: .pools[1].fee: "100", expected "500"`)
+	assert.Contains(t, runner.results[0].Msg, `block 107: output not in snapshot`)
+}
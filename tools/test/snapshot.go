@@ -0,0 +1,217 @@
+package test
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxSnapshotDiffs is the number of differences reported for a snapshot.
+	maxSnapshotDiffs = 10
+	// maxBlockDiffs is the number of differences reported for a block.
+	maxBlockDiffs = 3
+)
+
+// snapshot is the golden file of a test, holding a JSON line per block:
+//
+//	{"block":12369910,"output":{"pools":[...]}}
+type snapshot struct {
+	path string
+	// outputs are the outputs of the run by block, replaced when a block
+	// is sent again after an undo signal.
+	outputs map[uint64]interface{}
+}
+
+type snapshotEntry struct {
+	Block  uint64      `json:"block"`
+	Output interface{} `json:"output"`
+}
+
+func newSnapshot(path string) *snapshot {
+	return &snapshot{path: path, outputs: map[uint64]interface{}{}}
+}
+
+func (s *snapshot) record(blockNum uint64, output interface{}) {
+	s.outputs[blockNum] = output
+}
+
+func (s *snapshot) entries() []*snapshotEntry {
+	out := make([]*snapshotEntry, 0, len(s.outputs))
+	for blockNum, output := range s.outputs {
+		out = append(out, &snapshotEntry{Block: blockNum, Output: output})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Block < out[j].Block })
+	return out
+}
+
+// write replaces the golden file by the outputs of the run.
+func (s *snapshot) write() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.Create(s.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	encoder := json.NewEncoder(w)
+	for _, entry := range s.entries() {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("encoding output of block %d: %w", entry.Block, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// read returns the entries of the golden file, os.ErrNotExist if it is
+// missing.
+func (s *snapshot) read() ([]*snapshotEntry, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var out []*snapshotEntry
+	decoder := json.NewDecoder(file)
+	for decoder.More() {
+		entry := &snapshotEntry{}
+		if err := decoder.Decode(entry); err != nil {
+			return nil, fmt.Errorf("decoding entry %d: %w", len(out), err)
+		}
+		out = append(out, entry)
+	}
+	return out, nil
+}
+
+// diff returns the differences between the golden file and the outputs of
+// the run, nil if they match.
+func (s *snapshot) diff() ([]string, error) {
+	expected, err := s.read()
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{fmt.Sprintf("snapshot %q not found, record it with --test-update-snapshots", s.path)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot %q: %w", s.path, err)
+	}
+
+	var diffs []string
+	expectedBlocks := map[uint64]bool{}
+	for _, entry := range expected {
+		expectedBlocks[entry.Block] = true
+		actual, found := s.outputs[entry.Block]
+		if !found {
+			diffs = append(diffs, fmt.Sprintf("block %d: no output, expected one", entry.Block))
+			continue
+		}
+		var blockDiffs []string
+		diffValues(".", entry.Output, actual, &blockDiffs)
+		for _, diff := range blockDiffs {
+			diffs = append(diffs, fmt.Sprintf("block %d: %s", entry.Block, diff))
+		}
+	}
+	for _, entry := range s.entries() {
+		if !expectedBlocks[entry.Block] {
+			diffs = append(diffs, fmt.Sprintf("block %d: output not in snapshot", entry.Block))
+		}
+	}
+
+	if len(diffs) > maxSnapshotDiffs {
+		diffs = append(diffs[:maxSnapshotDiffs], fmt.Sprintf("... %d more differences", len(diffs)-maxSnapshotDiffs))
+	}
+	return diffs, nil
+}
+
+// diffValues appends to `diffs` the paths at which `actual` differs from
+// `expected`, both decoded from JSON.
+func diffValues(path string, expected, actual interface{}, diffs *[]string) {
+	if len(*diffs) >= maxBlockDiffs {
+		return
+	}
+
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, key := range sortedKeys(e, a) {
+			ev, inExpected := e[key]
+			av, inActual := a[key]
+			switch {
+			case !inActual:
+				*diffs = append(*diffs, fmt.Sprintf("%s: missing, expected %s", joinPath(path, key), encodeValue(ev)))
+			case !inExpected:
+				*diffs = append(*diffs, fmt.Sprintf("%s: unexpected %s", joinPath(path, key), encodeValue(av)))
+			default:
+				diffValues(joinPath(path, key), ev, av, diffs)
+			}
+			if len(*diffs) >= maxBlockDiffs {
+				return
+			}
+		}
+		return
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok {
+			break
+		}
+		if len(a) != len(e) {
+			*diffs = append(*diffs, fmt.Sprintf("%s: %d items, expected %d", path, len(a), len(e)))
+			return
+		}
+		for i := range e {
+			diffValues(fmt.Sprintf("%s[%d]", strings.TrimSuffix(path, "."), i), e[i], a[i], diffs)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		*diffs = append(*diffs, fmt.Sprintf("%s: %s, expected %s", path, encodeValue(actual), encodeValue(expected)))
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "." {
+		return "." + key
+	}
+	return path + "." + key
+}
+
+func sortedKeys(maps ...map[string]interface{}) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				out = append(out, key)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func encodeValue(value interface{}) string {
+	cnt, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	if len(cnt) > 80 {
+		return string(cnt[:77]) + "..."
+	}
+	return string(cnt)
+}
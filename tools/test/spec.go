@@ -5,13 +5,17 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
-	"github.com/itchyny/gojq"
-	"github.com/streamingfast/substreams/tools/test/comparator"
-	"gopkg.in/yaml.v3"
 	"io"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
+
+	"github.com/itchyny/gojq"
+	"gopkg.in/yaml.v3"
+
+	"github.com/streamingfast/substreams/block"
+	"github.com/streamingfast/substreams/tools/test/comparator"
 )
 
 type Spec struct {
@@ -21,14 +25,26 @@ type Spec struct {
 type TestConfig struct {
 	Module string `json:"module" yaml:"module"`
 	Block  uint64 `json:"block" yaml:"block"`
+	// Blocks is a range of blocks, `<start>-<stop>` with the stop block
+	// exclusive, set in place of Block to run the test on each block of the
+	// range having an output.
+	Blocks string `json:"blocks,omitempty" yaml:"blocks"`
 	Path   string `json:"path" yaml:"path"`
 	Expect string `json:"expect" yaml:"expect"`
 	Op     string `json:"op,omitempty" yaml:"op"`
 	Args   string `json:"args,omitempty" yaml:"args"`
+	// Snapshot is the path of a golden file, relative to the spec file,
+	// holding the output of the module (or the result of Path if set) at
+	// each block, compared with the outputs of the run in place of Expect.
+	Snapshot string `json:"snapshot,omitempty" yaml:"snapshot"`
 }
 
 func (t *TestConfig) Test(idx int) (*Test, error) {
-	query, err := gojq.Parse(t.Path)
+	path := t.Path
+	if path == "" && t.Snapshot != "" {
+		path = "."
+	}
+	query, err := gojq.Parse(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse jq path: %w", err)
 	}
@@ -37,19 +53,51 @@ func (t *TestConfig) Test(idx int) (*Test, error) {
 		return nil, fmt.Errorf("failed to compile jq path: %w", err)
 	}
 
-	cmp, err := comparator.NewComparable(t.Expect, t.Op, t.Args)
-	if err != nil {
-		return nil, fmt.Errorf("failed to setup comparator: %w", err)
+	blocks := block.NewRange(t.Block, t.Block+1)
+	if t.Blocks != "" {
+		if t.Block != 0 {
+			return nil, fmt.Errorf("'block' and 'blocks' are mutually exclusive")
+		}
+		if blocks, err = parseBlocks(t.Blocks); err != nil {
+			return nil, err
+		}
 	}
 
-	return &Test{
+	test := &Test{
 		code:       code,
-		path:       t.Path,
-		block:      t.Block,
+		path:       path,
+		blocks:     blocks,
 		moduleName: t.Module,
 		fileIndex:  idx,
-		comparable: cmp,
-	}, nil
+	}
+	if t.Snapshot != "" {
+		test.snapshot = newSnapshot(t.Snapshot)
+		return test, nil
+	}
+
+	if test.comparable, err = comparator.NewComparable(t.Expect, t.Op, t.Args); err != nil {
+		return nil, fmt.Errorf("failed to setup comparator: %w", err)
+	}
+	return test, nil
+}
+
+func parseBlocks(in string) (*block.Range, error) {
+	start, stop, found := strings.Cut(in, "-")
+	if !found {
+		return nil, fmt.Errorf("invalid blocks %q, expected <start_block>-<stop_block>", in)
+	}
+	startBlock, err := strconv.ParseUint(strings.TrimSpace(start), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start block %q: %w", start, err)
+	}
+	stopBlock, err := strconv.ParseUint(strings.TrimSpace(stop), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stop block %q: %w", stop, err)
+	}
+	if stopBlock <= startBlock {
+		return nil, fmt.Errorf("invalid blocks %q, stop block must be above start block", in)
+	}
+	return block.NewRange(startBlock, stopBlock), nil
 }
 
 func readSpecFromFile(path string) (*Spec, error) {
@@ -114,7 +162,7 @@ func parseCSVLine(line []string) (*TestConfig, error) {
 		config.Op = line[4]
 	}
 	if len(line) >= 6 {
-		config.Op = line[5]
+		config.Args = line[5]
 	}
 	return config, nil
 }
@@ -155,13 +203,26 @@ type Test struct {
 	code       *gojq.Code
 	path       string
 	comparable comparator.Comparable
+	snapshot   *snapshot
 	moduleName string
-	block      uint64
+	blocks     *block.Range
 	fileIndex  int
+
+	results int
+}
+
+// name identifies the test in reports, by its module, its block or range of
+// blocks, and its index in the spec file.
+func (t *Test) name() string {
+	if t.blocks.ExclusiveEndBlock == t.blocks.StartBlock+1 {
+		return fmt.Sprintf("%s:%d:%d", t.moduleName, t.blocks.StartBlock, t.fileIndex)
+	}
+	return fmt.Sprintf("%s:%d-%d:%d", t.moduleName, t.blocks.StartBlock, t.blocks.ExclusiveEndBlock, t.fileIndex)
 }
 
 type Result struct {
 	test  *Test
+	Block uint64
 	Valid bool
 	Msg   string
 }
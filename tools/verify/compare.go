@@ -0,0 +1,226 @@
+package verify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/streamingfast/substreams/block"
+	pbssinternal "github.com/streamingfast/substreams/pb/sf/substreams/intern/v2"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+// compareMapOutputs compares the re-executed outputs of a map module with
+// its output cache, block by block. Blocks without output and blocks with an
+// empty output are considered the same.
+func (v *Verifier) compareMapOutputs(ctx context.Context, mod *pbsubstreams.Module, segment *block.Range, outputs map[uint64][]byte) (*Divergence, bool, error) {
+	cached, found, err := v.cachedOutputs(ctx, mod, segment)
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	for _, blockNum := range sortedBlocks(blocksOf(outputs), blocksOf(cached)) {
+		output, cachedOutput := outputs[blockNum], cached[blockNum]
+		if bytes.Equal(output, cachedOutput) {
+			continue
+		}
+		return v.divergence(mod, blockNum, "", fmt.Sprintf("re-executed output (%d bytes) differs from cached output (%d bytes)", len(output), len(cachedOutput))), true, nil
+	}
+	return nil, true, nil
+}
+
+// compareStore compares the re-executed deltas of a store module with the
+// ones of its output cache, if any, and the state they lead to with the
+// store's full snapshot at the end of the segment, if any.
+func (v *Verifier) compareStore(ctx context.Context, mod *pbsubstreams.Module, segment *block.Range, deltas map[uint64][]*pbsubstreamsrpc.StoreDelta) (*Divergence, bool, error) {
+	divergence, deltasCompared, err := v.compareStoreDeltas(ctx, mod, segment, deltas)
+	if err != nil || divergence != nil {
+		return divergence, deltasCompared, err
+	}
+
+	divergence, stateCompared, err := v.compareStoreState(ctx, mod, segment, deltas)
+	return divergence, deltasCompared || stateCompared, err
+}
+
+func (v *Verifier) compareStoreDeltas(ctx context.Context, mod *pbsubstreams.Module, segment *block.Range, deltas map[uint64][]*pbsubstreamsrpc.StoreDelta) (*Divergence, bool, error) {
+	cached, found, err := v.cachedOutputs(ctx, mod, segment)
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	for _, blockNum := range sortedBlocks(blocksOf(deltas), blocksOf(cached)) {
+		cachedDeltas := &pbssinternal.StoreDeltas{}
+		if err := proto.Unmarshal(cached[blockNum], cachedDeltas); err != nil {
+			return nil, false, fmt.Errorf("unmarshalling cached deltas at block %d: %w", blockNum, err)
+		}
+
+		blockDeltas := deltas[blockNum]
+		for i := 0; i < max(len(blockDeltas), len(cachedDeltas.StoreDeltas)); i++ {
+			if i >= len(blockDeltas) {
+				cachedDelta := cachedDeltas.StoreDeltas[i]
+				return v.divergence(mod, blockNum, cachedDelta.Key, fmt.Sprintf("cached %s delta not produced by re-execution", cachedDelta.Operation)), true, nil
+			}
+			delta := blockDeltas[i]
+			if i >= len(cachedDeltas.StoreDeltas) {
+				return v.divergence(mod, blockNum, delta.Key, fmt.Sprintf("re-executed %s delta not found in cache", delta.Operation)), true, nil
+			}
+
+			cachedDelta := cachedDeltas.StoreDeltas[i]
+			if delta.Key != cachedDelta.Key {
+				return v.divergence(mod, blockNum, delta.Key, fmt.Sprintf("re-executed delta #%d is on key %q in cache", i, cachedDelta.Key)), true, nil
+			}
+			if delta.Operation.String() != cachedDelta.Operation.String() || !bytes.Equal(delta.OldValue, cachedDelta.OldValue) || !bytes.Equal(delta.NewValue, cachedDelta.NewValue) {
+				return v.divergence(mod, blockNum, delta.Key, fmt.Sprintf("re-executed %s delta differs from cached %s delta", delta.Operation, cachedDelta.Operation)), true, nil
+			}
+		}
+	}
+	return nil, true, nil
+}
+
+// compareStoreState applies the re-executed deltas to the store's full
+// snapshot at the start of the segment, and compares the result with the
+// full snapshot at the end of the segment. The block reported for a
+// divergent key is the last one at which the re-execution changed it, or
+// the last block of the segment if it did not.
+func (v *Verifier) compareStoreState(ctx context.Context, mod *pbsubstreams.Module, segment *block.Range, deltas map[uint64][]*pbsubstreamsrpc.StoreDelta) (*Divergence, bool, error) {
+	config := v.storeConfigs[mod.Name]
+	endFile := store.NewCompleteFileInfo(mod.Name, mod.InitialBlock, segment.ExclusiveEndBlock)
+	exists, err := config.FileExists(ctx, endFile)
+	if err != nil || !exists {
+		return nil, false, err
+	}
+
+	state := map[string][]byte{}
+	if mod.InitialBlock < segment.StartBlock {
+		startFile := store.NewCompleteFileInfo(mod.Name, mod.InitialBlock, segment.StartBlock)
+		err := config.IterFile(ctx, startFile, func(key string, value []byte) error {
+			state[key] = value
+			return nil
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	changedAt := map[string]uint64{}
+	for _, blockNum := range sortedBlocks(blocksOf(deltas)) {
+		for _, delta := range deltas[blockNum] {
+			changedAt[delta.Key] = blockNum
+			if delta.Operation == pbsubstreamsrpc.StoreDelta_DELETE {
+				delete(state, delta.Key)
+				continue
+			}
+			state[delta.Key] = delta.NewValue
+		}
+	}
+
+	var divergentKey, reason string
+	diverge := func(key, why string) {
+		if divergentKey == "" || key < divergentKey {
+			divergentKey, reason = key, why
+		}
+	}
+	seen := map[string]bool{}
+	err = config.IterFile(ctx, endFile, func(key string, cachedValue []byte) error {
+		seen[key] = true
+		value, found := state[key]
+		switch {
+		case !found:
+			diverge(key, "key found in cached snapshot is absent once re-executed")
+		case !bytes.Equal(value, cachedValue):
+			diverge(key, fmt.Sprintf("re-executed value (%d bytes) differs from cached snapshot value (%d bytes)", len(value), len(cachedValue)))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	for key := range state {
+		if !seen[key] {
+			diverge(key, "re-executed key is absent from cached snapshot")
+		}
+	}
+
+	if divergentKey == "" {
+		return nil, true, nil
+	}
+	blockNum, found := changedAt[divergentKey]
+	if !found {
+		blockNum = segment.ExclusiveEndBlock - 1
+	}
+	return v.divergence(mod, blockNum, divergentKey, reason), true, nil
+}
+
+// cachedOutputs returns the cached outputs of `mod` for the blocks of
+// `segment`, if cached files cover it entirely.
+func (v *Verifier) cachedOutputs(ctx context.Context, mod *pbsubstreams.Module, segment *block.Range) (map[uint64][]byte, bool, error) {
+	config := v.execOutputs.ConfigMap[mod.Name]
+	files, err := config.ListFilesUpTo(ctx, segment.StartBlock, segment.ExclusiveEndBlock-1)
+	if err != nil {
+		return nil, false, fmt.Errorf("listing cached outputs: %w", err)
+	}
+
+	out := map[uint64][]byte{}
+	nextBlock := max(segment.StartBlock, mod.InitialBlock)
+	for _, fileInfo := range files {
+		if nextBlock >= segment.ExclusiveEndBlock {
+			break
+		}
+		if fileInfo.BlockRange.StartBlock > nextBlock || fileInfo.BlockRange.ExclusiveEndBlock <= nextBlock {
+			continue
+		}
+
+		file := config.NewFile(fileInfo.BlockRange)
+		if err := file.Load(ctx); err != nil {
+			return nil, false, fmt.Errorf("loading cached outputs %s: %w", fileInfo.Filename, err)
+		}
+		for _, item := range file.SortedItems() {
+			if segment.Contains(item.BlockNum) {
+				out[item.BlockNum] = item.Payload
+			}
+		}
+		nextBlock = fileInfo.BlockRange.ExclusiveEndBlock
+	}
+
+	if nextBlock < segment.ExclusiveEndBlock {
+		return nil, false, nil
+	}
+	return out, true, nil
+}
+
+func (v *Verifier) divergence(mod *pbsubstreams.Module, blockNum uint64, key, reason string) *Divergence {
+	return &Divergence{
+		Module:     mod.Name,
+		ModuleHash: v.outputGraph.ModuleHashes().Get(mod.Name),
+		BlockNum:   blockNum,
+		Key:        key,
+		Reason:     reason,
+	}
+}
+
+// sortedBlocks returns the distinct block numbers of all the sets, in order.
+func sortedBlocks(blockSets ...[]uint64) (out []uint64) {
+	seen := map[uint64]bool{}
+	for _, blockNums := range blockSets {
+		for _, blockNum := range blockNums {
+			if !seen[blockNum] {
+				seen[blockNum] = true
+				out = append(out, blockNum)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+func blocksOf[T any](m map[uint64]T) (out []uint64) {
+	for blockNum := range m {
+		out = append(out, blockNum)
+	}
+	return out
+}
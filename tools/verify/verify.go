@@ -0,0 +1,247 @@
+// Package verify re-executes segments of blocks whose outputs are already
+// cached and compares the outputs obtained with the cached ones, to detect
+// nondeterministic modules or a poisoned cache.
+package verify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/streamingfast/dstore"
+	"go.uber.org/zap"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/block"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/pipeline/outputmodules"
+	"github.com/streamingfast/substreams/storage/execout"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+// RunFunc runs `request` in-process, against `stateStore`, sending the
+// responses to `respFunc`.
+type RunFunc func(ctx context.Context, stateStore dstore.Store, request *pbsubstreamsrpc.Request, respFunc substreams.ResponseFunc) error
+
+// Verifier re-executes segments of an output module, along with all its
+// ancestors, and compares every map output and store delta obtained with
+// the ones held in the cache.
+type Verifier struct {
+	modules     *pbsubstreams.Modules
+	outputGraph *outputmodules.Graph
+
+	execOutputs  *execout.Configs
+	storeConfigs store.ConfigMap
+
+	run    RunFunc
+	logger *zap.Logger
+}
+
+// New returns a Verifier of the map module `outputModule`, comparing with
+// the outputs and store snapshots found in `cacheStore`. Segments are
+// re-executed by `run`, in development mode so that the outputs of every
+// module are returned.
+func New(modules *pbsubstreams.Modules, outputModule string, cacheStore dstore.Store, run RunFunc, logger *zap.Logger) (*Verifier, error) {
+	outputGraph, err := outputmodules.NewOutputModuleGraph(outputModule, false, modules)
+	if err != nil {
+		return nil, fmt.Errorf("computing modules graph: %w", err)
+	}
+	if outputGraph.OutputModule().GetKindMap() == nil {
+		return nil, fmt.Errorf("module %q is not a map, verify a map module depending on it instead", outputModule)
+	}
+
+	execOutputs, err := execout.NewConfigs(cacheStore, outputGraph.UsedModules(), outputGraph.ModuleHashes(), 0, logger)
+	if err != nil {
+		return nil, fmt.Errorf("configuring output caches: %w", err)
+	}
+	storeConfigs, err := store.NewConfigMap(cacheStore, outputGraph.Stores(), outputGraph.ModuleHashes(), "")
+	if err != nil {
+		return nil, fmt.Errorf("configuring stores: %w", err)
+	}
+
+	return &Verifier{
+		modules:      modules,
+		outputGraph:  outputGraph,
+		execOutputs:  execOutputs,
+		storeConfigs: storeConfigs,
+		run:          run,
+		logger:       logger,
+	}, nil
+}
+
+// Segments returns the segments of `interval` blocks, the size of the
+// cache files, overlapping `[startBlock, exclusiveEndBlock)`. Segments
+// start at the output module's initial block at the earliest.
+func (v *Verifier) Segments(startBlock, exclusiveEndBlock, interval uint64) (out []*block.Range) {
+	segmenter := block.NewSegmenter(interval, v.outputGraph.OutputModule().InitialBlock, exclusiveEndBlock)
+	if exclusiveEndBlock <= segmenter.InitialBlock() {
+		return nil
+	}
+
+	first := max(segmenter.IndexForStartBlock(startBlock), segmenter.FirstIndex())
+	for idx := first; idx <= segmenter.LastIndex(); idx++ {
+		out = append(out, segmenter.Range(idx))
+	}
+	return out
+}
+
+// Result is the outcome of the verification of a segment.
+type Result struct {
+	Segment *block.Range
+	// Compared are the modules whose re-executed outputs were compared
+	// with cached ones.
+	Compared []string
+	// Skipped are the modules for which nothing covering the segment was
+	// found in the cache.
+	Skipped []string
+	// Divergence is the first difference found, nil if there is none.
+	Divergence *Divergence
+}
+
+// Divergence describes a difference between a re-executed output and a
+// cached one.
+type Divergence struct {
+	Module     string
+	ModuleHash string
+	BlockNum   uint64
+	// Key is the store key diverging, empty for map outputs.
+	Key    string
+	Reason string
+}
+
+func (d *Divergence) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "module %q (hash %s) diverges at block %d", d.Module, d.ModuleHash, d.BlockNum)
+	if d.Key != "" {
+		fmt.Fprintf(&sb, " on key %q", d.Key)
+	}
+	fmt.Fprintf(&sb, ": %s", d.Reason)
+	return sb.String()
+}
+
+// Segment re-executes `segment` against `scratchStore`, an empty state
+// store, and compares the outputs obtained with the cached ones. The
+// cached snapshots of the stores at the start of the segment are copied to
+// `scratchStore` beforehand, so that only the segment is executed.
+func (v *Verifier) Segment(ctx context.Context, segment *block.Range, scratchStore dstore.Store) (*Result, error) {
+	if err := v.copyStoreSnapshots(ctx, segment.StartBlock, scratchStore); err != nil {
+		return nil, err
+	}
+
+	outputs := newSegmentOutputs()
+	request := &pbsubstreamsrpc.Request{
+		StartBlockNum: int64(segment.StartBlock),
+		StopBlockNum:  segment.ExclusiveEndBlock,
+		Modules:       v.modules,
+		OutputModule:  v.outputGraph.OutputModule().Name,
+	}
+	err := v.run(ctx, scratchStore, request, func(respAny substreams.ResponseFromAnyTier) error {
+		if data := respAny.(*pbsubstreamsrpc.Response).GetBlockScopedData(); data != nil {
+			outputs.add(data)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("re-executing segment %s: %w", segment, err)
+	}
+
+	result := &Result{Segment: segment}
+	for _, mod := range v.outputGraph.UsedModules() {
+		var divergence *Divergence
+		var compared bool
+		var err error
+		switch {
+		case mod.GetKindMap() != nil:
+			divergence, compared, err = v.compareMapOutputs(ctx, mod, segment, outputs.maps[mod.Name])
+		case mod.GetKindStore() != nil:
+			divergence, compared, err = v.compareStore(ctx, mod, segment, outputs.deltas[mod.Name])
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("comparing outputs of module %q: %w", mod.Name, err)
+		}
+
+		if !compared {
+			result.Skipped = append(result.Skipped, mod.Name)
+			continue
+		}
+		result.Compared = append(result.Compared, mod.Name)
+		if divergence != nil && (result.Divergence == nil || divergence.BlockNum < result.Divergence.BlockNum) {
+			result.Divergence = divergence
+		}
+	}
+
+	v.logger.Info("segment verified",
+		zap.Stringer("segment", segment),
+		zap.Strings("compared", result.Compared),
+		zap.Strings("skipped", result.Skipped),
+		zap.Bool("divergent", result.Divergence != nil),
+	)
+	return result, nil
+}
+
+// copyStoreSnapshots copies, from the cache to `scratchStore`, the full
+// snapshots of the stores at `blockNum`.
+func (v *Verifier) copyStoreSnapshots(ctx context.Context, blockNum uint64, scratchStore dstore.Store) error {
+	scratchConfigs, err := store.NewConfigMap(scratchStore, v.outputGraph.Stores(), v.outputGraph.ModuleHashes(), "")
+	if err != nil {
+		return fmt.Errorf("configuring scratch stores: %w", err)
+	}
+
+	for _, mod := range v.outputGraph.Stores() {
+		if mod.InitialBlock >= blockNum {
+			continue
+		}
+
+		config := v.storeConfigs[mod.Name]
+		file := store.NewCompleteFileInfo(mod.Name, mod.InitialBlock, blockNum)
+		exists, err := config.FileExists(ctx, file)
+		if err != nil {
+			return fmt.Errorf("checking snapshot of store %q: %w", mod.Name, err)
+		}
+		if !exists {
+			return fmt.Errorf("no full snapshot of store %q (hash %s) at block %d found in the cache", mod.Name, config.ModuleHash(), blockNum)
+		}
+
+		if err := config.CopyFile(ctx, file, scratchConfigs[mod.Name]); err != nil {
+			return fmt.Errorf("store %q: %w", mod.Name, err)
+		}
+	}
+	return nil
+}
+
+// segmentOutputs holds, by module and block number, the outputs of a
+// re-executed segment.
+type segmentOutputs struct {
+	maps   map[string]map[uint64][]byte
+	deltas map[string]map[uint64][]*pbsubstreamsrpc.StoreDelta
+}
+
+func newSegmentOutputs() *segmentOutputs {
+	return &segmentOutputs{
+		maps:   map[string]map[uint64][]byte{},
+		deltas: map[string]map[uint64][]*pbsubstreamsrpc.StoreDelta{},
+	}
+}
+
+func (o *segmentOutputs) add(data *pbsubstreamsrpc.BlockScopedData) {
+	blockNum := data.Clock.Number
+	for _, output := range data.AllModuleOutputs() {
+		switch {
+		case output.IsMap():
+			if o.maps[output.Name()] == nil {
+				o.maps[output.Name()] = map[uint64][]byte{}
+			}
+			o.maps[output.Name()][blockNum] = output.MapOutput.GetMapOutput().GetValue()
+		case output.IsStore():
+			if o.deltas[output.Name()] == nil {
+				o.deltas[output.Name()] = map[uint64][]*pbsubstreamsrpc.StoreDelta{}
+			}
+			o.deltas[output.Name()][blockNum] = output.StoreOutput.DebugStoreDeltas
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/streamingfast/dstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/streamingfast/substreams"
+	"github.com/streamingfast/substreams/block"
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	pbsubstreams "github.com/streamingfast/substreams/pb/sf/substreams/v1"
+	"github.com/streamingfast/substreams/storage/store"
+)
+
+func testModules() *pbsubstreams.Modules {
+	return &pbsubstreams.Modules{
+		Binaries: []*pbsubstreams.Binary{{Type: "wasm/rust-v1"}},
+		Modules: []*pbsubstreams.Module{
+			{
+				Name: "store_a",
+				Kind: &pbsubstreams.Module_KindStore_{KindStore: &pbsubstreams.Module_KindStore{
+					UpdatePolicy: pbsubstreams.Module_KindStore_UPDATE_POLICY_SET,
+					ValueType:    "string",
+				}},
+				Inputs: []*pbsubstreams.Module_Input{
+					{Input: &pbsubstreams.Module_Input_Source_{Source: &pbsubstreams.Module_Input_Source{Type: "sf.test.Block"}}},
+				},
+				InitialBlock: 5,
+			},
+			{
+				Name: "map_b",
+				Kind: &pbsubstreams.Module_KindMap_{KindMap: &pbsubstreams.Module_KindMap{}},
+				Inputs: []*pbsubstreams.Module_Input{
+					{Input: &pbsubstreams.Module_Input_Store_{Store: &pbsubstreams.Module_Input_Store{ModuleName: "store_a"}}},
+				},
+				InitialBlock: 5,
+			},
+		},
+	}
+}
+
+// testCache writes, for a segment [10, 20), the snapshots of `store_a` at
+// blocks 10 and 20 and the output cache of `map_b`.
+func testCache(t *testing.T, v *Verifier) {
+	ctx := context.Background()
+
+	storeConfig := v.storeConfigs["store_a"]
+	for endBlock, kv := range map[uint64]map[string]string{
+		10: {"a": "1"},
+		20: {"a": "2", "b": "3"},
+	} {
+		full := storeConfig.NewFullKV(zap.NewNop())
+		for key, value := range kv {
+			full.Set(0, key, value)
+		}
+		_, writer, err := full.Save(endBlock)
+		require.NoError(t, err)
+		require.NoError(t, writer.Write(ctx))
+	}
+
+	file := v.execOutputs.ConfigMap["map_b"].NewFile(block.NewRange(10, 20))
+	for blockNum := uint64(10); blockNum < 20; blockNum++ {
+		file.SetItem(testClock(blockNum), []byte(fmt.Sprintf("out%d", blockNum)))
+	}
+	require.NoError(t, file.Save(ctx))
+}
+
+func testClock(blockNum uint64) *pbsubstreams.Clock {
+	return &pbsubstreams.Clock{Number: blockNum, Id: fmt.Sprintf("%d", blockNum)}
+}
+
+// testRun returns a RunFunc sending, for each block of the request, the
+// outputs of `map_b` and the deltas of `store_a` given.
+func testRun(outputs map[uint64]string, deltas map[uint64][]*pbsubstreamsrpc.StoreDelta) RunFunc {
+	return func(ctx context.Context, stateStore dstore.Store, request *pbsubstreamsrpc.Request, respFunc substreams.ResponseFunc) error {
+		for blockNum := uint64(request.StartBlockNum); blockNum < request.StopBlockNum; blockNum++ {
+			err := respFunc(&pbsubstreamsrpc.Response{Message: &pbsubstreamsrpc.Response_BlockScopedData{BlockScopedData: &pbsubstreamsrpc.BlockScopedData{
+				Clock:  testClock(blockNum),
+				Output: &pbsubstreamsrpc.MapModuleOutput{Name: "map_b", MapOutput: &anypb.Any{Value: []byte(outputs[blockNum])}},
+				DebugStoreOutputs: []*pbsubstreamsrpc.StoreModuleOutput{
+					{Name: "store_a", DebugStoreDeltas: deltas[blockNum]},
+				},
+			}}})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestVerifier_Segment(t *testing.T) {
+	matchingOutputs := func() map[uint64]string {
+		out := map[uint64]string{}
+		for blockNum := uint64(10); blockNum < 20; blockNum++ {
+			out[blockNum] = fmt.Sprintf("out%d", blockNum)
+		}
+		return out
+	}
+	matchingDeltas := func() map[uint64][]*pbsubstreamsrpc.StoreDelta {
+		return map[uint64][]*pbsubstreamsrpc.StoreDelta{
+			12: {{Operation: pbsubstreamsrpc.StoreDelta_UPDATE, Key: "a", OldValue: []byte("1"), NewValue: []byte("2")}},
+			15: {{Operation: pbsubstreamsrpc.StoreDelta_CREATE, Key: "b", NewValue: []byte("3")}},
+		}
+	}
+
+	tests := []struct {
+		name               string
+		outputs            func() map[uint64]string
+		deltas             func() map[uint64][]*pbsubstreamsrpc.StoreDelta
+		expectedDivergence *Divergence
+	}{
+		{
+			name:    "matching",
+			outputs: matchingOutputs,
+			deltas:  matchingDeltas,
+		},
+		{
+			name: "map output divergent",
+			outputs: func() map[uint64]string {
+				out := matchingOutputs()
+				out[14] = "other"
+				out[17] = "other"
+				return out
+			},
+			deltas:             matchingDeltas,
+			expectedDivergence: &Divergence{Module: "map_b", BlockNum: 14, Reason: "re-executed output (5 bytes) differs from cached output (5 bytes)"},
+		},
+		{
+			name:    "store value divergent",
+			outputs: matchingOutputs,
+			deltas: func() map[uint64][]*pbsubstreamsrpc.StoreDelta {
+				deltas := matchingDeltas()
+				deltas[15][0].NewValue = []byte("4")
+				return deltas
+			},
+			expectedDivergence: &Divergence{Module: "store_a", BlockNum: 15, Key: "b", Reason: "re-executed value (1 bytes) differs from cached snapshot value (1 bytes)"},
+		},
+		{
+			name:    "store key missing",
+			outputs: matchingOutputs,
+			deltas: func() map[uint64][]*pbsubstreamsrpc.StoreDelta {
+				deltas := matchingDeltas()
+				delete(deltas, 12)
+				return deltas
+			},
+			expectedDivergence: &Divergence{Module: "store_a", BlockNum: 19, Key: "a", Reason: "re-executed value (1 bytes) differs from cached snapshot value (1 bytes)"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cacheStore, err := dstore.NewStore("file://"+t.TempDir(), "zst", "zstd", true)
+			require.NoError(t, err)
+			scratchStore, err := dstore.NewStore("file://"+t.TempDir(), "zst", "zstd", true)
+			require.NoError(t, err)
+
+			v, err := New(testModules(), "map_b", cacheStore, testRun(test.outputs(), test.deltas()), zap.NewNop())
+			require.NoError(t, err)
+			testCache(t, v)
+
+			result, err := v.Segment(context.Background(), block.NewRange(10, 20), scratchStore)
+			require.NoError(t, err)
+
+			assert.ElementsMatch(t, []string{"store_a", "map_b"}, result.Compared)
+			assert.Empty(t, result.Skipped)
+			if test.expectedDivergence != nil {
+				test.expectedDivergence.ModuleHash = v.outputGraph.ModuleHashes().Get(test.expectedDivergence.Module)
+			}
+			assert.Equal(t, test.expectedDivergence, result.Divergence)
+
+			scratchConfig, err := store.NewConfig("store_a", 5, v.outputGraph.ModuleHashes().Get("store_a"), pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string", scratchStore, "")
+			require.NoError(t, err)
+			copied, err := scratchConfig.FileExists(context.Background(), store.NewCompleteFileInfo("store_a", 5, 10))
+			require.NoError(t, err)
+			assert.True(t, copied)
+		})
+	}
+}
+
+func TestVerifier_SegmentMissingSnapshot(t *testing.T) {
+	cacheStore, err := dstore.NewStore("file://"+t.TempDir(), "zst", "zstd", true)
+	require.NoError(t, err)
+	scratchStore, err := dstore.NewStore("file://"+t.TempDir(), "zst", "zstd", true)
+	require.NoError(t, err)
+
+	v, err := New(testModules(), "map_b", cacheStore, testRun(nil, nil), zap.NewNop())
+	require.NoError(t, err)
+	testCache(t, v)
+
+	_, err = v.Segment(context.Background(), block.NewRange(30, 40), scratchStore)
+	assert.ErrorContains(t, err, `no full snapshot of store "store_a"`)
+}
+
+func TestVerifier_Segments(t *testing.T) {
+	v, err := New(testModules(), "map_b", dstore.NewMockStore(nil), nil, zap.NewNop())
+	require.NoError(t, err)
+
+	assert.Equal(t, []*block.Range{block.NewRange(5, 10), block.NewRange(10, 20), block.NewRange(20, 25)}, v.Segments(0, 25, 10))
+	assert.Equal(t, []*block.Range{block.NewRange(10, 20)}, v.Segments(12, 20, 10))
+	assert.Nil(t, v.Segments(0, 5, 10))
+}
@@ -79,6 +79,8 @@ func (ui *TUI) Init(outputMode string) error {
 					msgType = modKind.KindStore.ValueType
 				case *pbsubstreams.Module_KindMap_:
 					msgType = modKind.KindMap.OutputType
+				case *pbsubstreams.Module_KindBlockIndex_:
+					msgType = modKind.KindBlockIndex.OutputType
 				}
 				msgType = strings.TrimPrefix(msgType, "proto:")
 
@@ -76,7 +76,12 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var newSlowestJobs []string
 
 		jobsPerStage := make([]int, len(msg.Stages))
+		queuedPerStage := make([]int, len(msg.Stages))
 		for _, j := range msg.RunningJobs {
+			if j.Queued {
+				queuedPerStage[j.Stage]++
+				continue
+			}
 			jobsPerStage[j.Stage]++
 			if j.DurationMs > 5000 && len(newSlowestJobs) < 5 {
 				newSlowestJobs = append(newSlowestJobs, fmt.Sprintf("[Stage: %d, Range: %d-%d, Duration: %ds]", j.Stage, j.StartBlock, j.StopBlock, j.DurationMs/1000))
@@ -88,6 +93,9 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			jobsForStage := jobsPerStage[i]
 			displayedName := fmt.Sprintf("stage %d (%d jobs)", i, jobsForStage)
+			if queued := queuedPerStage[i]; queued != 0 {
+				displayedName = fmt.Sprintf("stage %d (%d jobs, %d queued)", i, jobsForStage, queued)
+			}
 
 			ranges := make([]*blockRange, len(stage.CompletedRanges))
 			for j, r := range stage.CompletedRanges {
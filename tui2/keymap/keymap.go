@@ -20,3 +20,4 @@ var Quit = key.NewBinding(key.WithHelp("q", "quit"), k)
 var ToggleProgressDisplayMode = key.NewBinding(key.WithHelp("m", "toggle display mode"), k)
 var GoToBlock = key.NewBinding(key.WithHelp("=", "go to block"), k)
 var ModGraphView = key.NewBinding(key.WithHelp("M", "toggle mod graph view"), k)
+var ToggleReloadDiff = key.NewBinding(key.WithHelp("D", "toggle diff with output before reload"), k)
@@ -0,0 +1,203 @@
+package output
+
+import (
+	"fmt"
+	"strings"
+
+	pbsubstreamsrpc "github.com/streamingfast/substreams/pb/sf/substreams/rpc/v2"
+	"github.com/streamingfast/substreams/tui2/pages/request"
+)
+
+// maxDiffCells bounds the size of the table of the longest common
+// subsequence of two outputs, larger outputs being compared line by line.
+const maxDiffCells = 4_000_000
+
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffRemoved
+	diffAdded
+)
+
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// snapshotViewedOutputs keeps the plain rendering of the outputs viewed
+// before the stream is reloaded, to diff them with the outputs of the
+// reloaded stream. The rendering of an output viewed before a previous
+// reload is kept if the reloaded stream did not reach it yet.
+func (o *Output) snapshotViewedOutputs() {
+	previous := make(map[request.BlockContext]string, len(o.viewed))
+	for blockCtx := range o.viewed {
+		if payload := o.payloads[blockCtx]; payload != nil {
+			previous[blockCtx] = o.renderedOutput(payload, false).plain()
+		} else if before, found := o.previousOutputs[blockCtx]; found {
+			previous[blockCtx] = before
+		}
+	}
+	o.previousOutputs = previous
+}
+
+func (r *renderedOutput) plain() string {
+	var parts []string
+	if r.error != nil {
+		parts = append(parts, r.error.Error())
+	}
+	for _, part := range []string{r.plainLogs, r.plainJSON, strings.TrimRight(r.plainOutput, "\n")} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// renderDiff renders side by side the output before the reload and the
+// current one, the removed lines on the left and the added ones on the
+// right.
+func (o *Output) renderDiff(before string, current *pbsubstreamsrpc.AnyModuleOutput) string {
+	columnWidth := (o.Width - 3) / 2
+	if columnWidth < 10 {
+		columnWidth = 10
+	}
+
+	after := "(not received yet since reload)"
+	if current != nil {
+		after = o.renderedOutput(current, false).plain()
+	}
+	lines := diffLines(strings.Split(before, "\n"), strings.Split(after, "\n"))
+
+	var changes int
+	for _, line := range lines {
+		if line.op != diffEqual {
+			changes++
+		}
+	}
+	summary := "unchanged since reload"
+	if changes != 0 {
+		summary = fmt.Sprintf("%d lines changed since reload", changes)
+	}
+
+	out := &strings.Builder{}
+	out.WriteString(o.Styles.Output.LogLabel.Render(fmt.Sprintf("%s │ %s", fitColumn("before reload", columnWidth), summary)))
+	out.WriteString("\n")
+	for _, row := range sideBySide(lines) {
+		left, right := fitColumn(row[0].text, columnWidth), fitColumn(row[1].text, columnWidth)
+		if row[0].op == diffRemoved {
+			left = o.Styles.Output.DiffRemoved.Render(left)
+		}
+		if row[1].op == diffAdded {
+			right = o.Styles.Output.DiffAdded.Render(right)
+		}
+		out.WriteString(left)
+		out.WriteString(" │ ")
+		out.WriteString(right)
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+// sideBySide pairs the lines of a diff in rows of a left and a right column,
+// each run of removed lines facing the run of added lines following it.
+func sideBySide(lines []diffLine) (rows [][2]diffLine) {
+	for i := 0; i < len(lines); {
+		if lines[i].op == diffEqual {
+			rows = append(rows, [2]diffLine{lines[i], lines[i]})
+			i++
+			continue
+		}
+		var removed, added []diffLine
+		for ; i < len(lines) && lines[i].op == diffRemoved; i++ {
+			removed = append(removed, lines[i])
+		}
+		for ; i < len(lines) && lines[i].op == diffAdded; i++ {
+			added = append(added, lines[i])
+		}
+		for j := 0; j < len(removed) || j < len(added); j++ {
+			var row [2]diffLine
+			if j < len(removed) {
+				row[0] = removed[j]
+			}
+			if j < len(added) {
+				row[1] = added[j]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return
+}
+
+// diffLines returns the lines of `a` and `b` in order, marked as removed
+// from `a`, added in `b` or equal, using their longest common subsequence.
+func diffLines(a, b []string) (out []diffLine) {
+	if len(a)*len(b) > maxDiffCells {
+		return diffLinesByPosition(a, b)
+	}
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, diffLine{diffEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, diffLine{diffRemoved, a[i]})
+			i++
+		default:
+			out = append(out, diffLine{diffAdded, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, diffLine{diffRemoved, a[i]})
+	}
+	for ; j < len(b); j++ {
+		out = append(out, diffLine{diffAdded, b[j]})
+	}
+	return out
+}
+
+func diffLinesByPosition(a, b []string) (out []diffLine) {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		switch {
+		case i < len(a) && i < len(b) && a[i] == b[i]:
+			out = append(out, diffLine{diffEqual, a[i]})
+		default:
+			if i < len(a) {
+				out = append(out, diffLine{diffRemoved, a[i]})
+			}
+			if i < len(b) {
+				out = append(out, diffLine{diffAdded, b[i]})
+			}
+		}
+	}
+	return out
+}
+
+func fitColumn(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	return text + strings.Repeat(" ", width-len(runes))
+}
@@ -0,0 +1,45 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	lines := diffLines(
+		[]string{"{", `  "fee": 3000,`, `  "pool": "0xabc"`, "}"},
+		[]string{"{", `  "fee": 500,`, `  "pool": "0xabc",`, `  "token": "0xdef"`, "}"},
+	)
+	assert.Equal(t, []diffLine{
+		{diffEqual, "{"},
+		{diffRemoved, `  "fee": 3000,`},
+		{diffRemoved, `  "pool": "0xabc"`},
+		{diffAdded, `  "fee": 500,`},
+		{diffAdded, `  "pool": "0xabc",`},
+		{diffAdded, `  "token": "0xdef"`},
+		{diffEqual, "}"},
+	}, lines)
+
+	assert.Equal(t, [][2]diffLine{
+		{{diffEqual, "{"}, {diffEqual, "{"}},
+		{{diffRemoved, `  "fee": 3000,`}, {diffAdded, `  "fee": 500,`}},
+		{{diffRemoved, `  "pool": "0xabc"`}, {diffAdded, `  "pool": "0xabc",`}},
+		{{}, {diffAdded, `  "token": "0xdef"`}},
+		{{diffEqual, "}"}, {diffEqual, "}"}},
+	}, sideBySide(lines))
+}
+
+func TestDiffLinesByPosition(t *testing.T) {
+	assert.Equal(t, []diffLine{
+		{diffEqual, "a"},
+		{diffRemoved, "b"},
+		{diffAdded, "c"},
+		{diffAdded, "d"},
+	}, diffLinesByPosition([]string{"a", "b"}, []string{"a", "c", "d"}))
+}
+
+func TestFitColumn(t *testing.T) {
+	assert.Equal(t, "abc  ", fitColumn("abc", 5))
+	assert.Equal(t, "abcd…", fitColumn("abcdefgh", 5))
+}
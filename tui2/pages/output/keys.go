@@ -38,6 +38,7 @@ func (o *Output) FullHelp() [][]key.Binding {
 		{
 			keymap.ModuleSearch,
 			keymap.RestartStream,
+			keymap.ToggleReloadDiff,
 		},
 		{
 			keymap.Help,
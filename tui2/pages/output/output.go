@@ -19,6 +19,7 @@ import (
 	"github.com/streamingfast/substreams/tui2/components/modselect"
 	"github.com/streamingfast/substreams/tui2/components/search"
 	"github.com/streamingfast/substreams/tui2/pages/request"
+	"github.com/streamingfast/substreams/tui2/watcher"
 )
 
 type Output struct {
@@ -65,6 +66,10 @@ type Output struct {
 
 	moduleNavigatorMode bool
 	moduleNavigator     *explorer.Navigator
+
+	viewed          map[request.BlockContext]bool   // outputs displayed, across reloads of the stream
+	previousOutputs map[request.BlockContext]string // outputs viewed before the last reload
+	diffEnabled     bool
 }
 
 func New(c common.Common, manifestPath string, outputModule string, config *request.RequestConfig) (*Output, error) {
@@ -91,6 +96,8 @@ func New(c common.Common, manifestPath string, outputModule string, config *requ
 		logsEnabled:         true,
 		moduleNavigator:     nav,
 		firstBlockSeen:      true,
+		viewed:              map[request.BlockContext]bool{},
+		diffEnabled:         true,
 	}
 	return output, nil
 }
@@ -134,6 +141,8 @@ func (o *Output) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		o.searchBlockNumsWithMatches = o.orderMatchingBlocks(msg)
 	case search.AddMatchingBlock:
 		o.searchBlockNumsWithMatches = append(o.searchBlockNumsWithMatches, uint64(msg))
+	case watcher.ChangedMsg:
+		o.snapshotViewedOutputs()
 	case request.NewRequestInstance:
 		o.errReceived = nil
 		o.msgDescs = msg.MsgDescs
@@ -189,7 +198,8 @@ func (o *Output) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if o.firstBlockSeen {
 				o.active = blockCtx
 			}
-			o.setOutputViewContent(false)
+			_, hasPrevious := o.previousOutputs[blockCtx]
+			o.setOutputViewContent(blockCtx == o.active && hasPrevious && o.diffEnabled)
 		}
 
 	case search.ApplySearchQueryMsg:
@@ -232,6 +242,9 @@ func (o *Output) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "/":
 			o.searchEnabled = true
 			cmds = append(cmds, o.searchCtx.InitInput())
+		case "D":
+			o.diffEnabled = !o.diffEnabled
+			o.setOutputViewContent(true)
 		case "F":
 			o.bytesRepresentation = (o.bytesRepresentation + 1) % 3
 			o.setOutputViewContent(true)
@@ -304,8 +317,14 @@ func (o *Output) setOutputViewContent(forcedRender bool) {
 	}
 
 	if o.firstBlockSeen || forcedRender {
+		if displayCtx.payload != nil {
+			o.viewed[o.active] = true
+		}
 		vals := o.renderedOutput(displayCtx.payload, true)
 		content := o.renderPayload(vals)
+		if before, found := o.previousOutputs[o.active]; found && o.diffEnabled && !displayCtx.searchViewEnabled {
+			content = o.renderDiff(before, displayCtx.payload)
+		}
 		if displayCtx.searchViewEnabled {
 			var matchCount int
 			var positions []int
@@ -113,8 +113,13 @@ func (p *Progress) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		incompleteRanges := make(map[int][]*ranges.BlockRange)
 		jobsPerStage := make([]int, len(msg.Stages))
+		queuedPerStage := make([]int, len(msg.Stages))
 		slowJobCount := 0
 		for _, j := range msg.RunningJobs {
+			if j.Queued {
+				queuedPerStage[j.Stage]++
+				continue
+			}
 			totalProcessedBlocks += j.ProcessedBlocks
 			jobsPerStage[j.Stage]++
 			if slowJobCount < 4 && j.DurationMs > 10000 { // skip 'young' jobs
@@ -176,6 +181,9 @@ func (p *Progress) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			jobsForStage := jobsPerStage[i]
 			displayedName := fmt.Sprintf("stage %d (%d jobs)", i, jobsForStage)
+			if queued := queuedPerStage[i]; queued != 0 {
+				displayedName = fmt.Sprintf("stage %d (%d jobs, %d queued)", i, jobsForStage, queued)
+			}
 
 			br := make([]*ranges.BlockRange, len(stage.CompletedRanges))
 			for j, r := range stage.CompletedRanges {
@@ -75,6 +75,9 @@ type OutputStyle struct {
 	LogLabel  lipgloss.Style
 	LogLine   lipgloss.Style
 	ErrorLine lipgloss.Style
+
+	DiffRemoved lipgloss.Style
+	DiffAdded   lipgloss.Style
 }
 
 type ModSelectStyle struct {
@@ -249,6 +252,9 @@ func DefaultStyles() *Styles {
 		LogLabel:  lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Dark: "243", Light: "248"}),
 		LogLine:   lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Dark: "252", Light: "242"}),
 		ErrorLine: lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Dark: "1", Light: "9"}),
+
+		DiffRemoved: lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Dark: "1", Light: "9"}),
+		DiffAdded:   lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Dark: "10", Light: "2"}),
 	}
 
 	s.ModSelect = ModSelectStyle{
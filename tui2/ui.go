@@ -1,7 +1,10 @@
 package tui2
 
 import (
+	"fmt"
 	"log"
+	"path/filepath"
+	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -20,6 +23,7 @@ import (
 	streamui "github.com/streamingfast/substreams/tui2/stream"
 	"github.com/streamingfast/substreams/tui2/styles"
 	"github.com/streamingfast/substreams/tui2/tabs"
+	"github.com/streamingfast/substreams/tui2/watcher"
 )
 
 type page int
@@ -47,9 +51,22 @@ type UI struct {
 	showFooter       bool
 	error            error
 	tabs             *tabs.Tabs
+
+	watcher       *watcher.Watcher // non-nil in dev mode, restarting the stream when the package sources change
+	reloadedFiles []string
+}
+
+type Option func(*UI)
+
+// WithWatcher restarts the stream, from the same start block or cursor, each
+// time the watcher reports a change of the sources of the package.
+func WithWatcher(w *watcher.Watcher) Option {
+	return func(ui *UI) {
+		ui.watcher = w
+	}
 }
 
-func New(reqConfig *request.RequestConfig) (*UI, error) {
+func New(reqConfig *request.RequestConfig, opts ...Option) (*UI, error) {
 	c := common.Common{
 		Styles: styles.DefaultStyles(),
 	}
@@ -71,6 +88,9 @@ func New(reqConfig *request.RequestConfig) (*UI, error) {
 		replayLog:     replaylog.New(),
 	}
 	ui.footer = footer.New(c, ui.pages[0])
+	for _, opt := range opts {
+		opt(ui)
+	}
 
 	return ui, nil
 }
@@ -89,6 +109,9 @@ func (ui *UI) Init() tea.Cmd {
 	)
 
 	cmds = append(cmds, tabs.SelectTabCmd(1))
+	if ui.watcher != nil {
+		cmds = append(cmds, ui.watcher.Wait())
+	}
 
 	return tea.Batch(cmds...)
 }
@@ -146,6 +169,12 @@ func (ui *UI) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		_, cmd = ui.pages[ui.activePage].Update(msg)
 		cmds = append(cmds, cmd)
 		return ui, tea.Batch(cmds...)
+	case watcher.ChangedMsg:
+		ui.forceRefresh()
+		ui.reloadedFiles = msg
+		// The start block resolved from the module on the first run is kept, even if the module's initial block changed.
+		ui.requestConfig.ReadFromModule = false
+		cmds = append(cmds, ui.restartStream(), ui.watcher.Wait())
 	case request.NewRequestInstance:
 		ui.stream = msg.Stream
 		ui.msgDescs = msg.MsgDescs
@@ -204,7 +233,18 @@ func (ui *UI) forceRefresh() {
 }
 
 func (ui *UI) View() string {
-	headline := ui.Styles.Header.Render("Substreams GUI")
+	title := "Substreams GUI"
+	if ui.watcher != nil {
+		title = "Substreams Dev"
+		if ui.reloadedFiles != nil {
+			var names []string
+			for _, file := range ui.reloadedFiles {
+				names = append(names, filepath.Base(file))
+			}
+			title += fmt.Sprintf(" (reloaded on changes to %s)", strings.Join(names, ", "))
+		}
+	}
+	headline := ui.Styles.Header.Render(title)
 
 	if ui.stream != nil {
 		var color lipgloss.TerminalColor
@@ -216,7 +256,7 @@ func (ui *UI) View() string {
 		case stream.StatusError:
 			color = ui.Styles.StreamErrorColor
 		}
-		headline = ui.Styles.Header.Copy().Foreground(color).Render("Substreams GUI")
+		headline = ui.Styles.Header.Copy().Foreground(color).Render(title)
 	}
 
 	return lipgloss.JoinVertical(0,
@@ -0,0 +1,107 @@
+package watcher
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ChangedMsg is sent when watched files changed, holding their paths.
+type ChangedMsg []string
+
+// Watcher polls the modification time and size of a list of files, the
+// list being refreshed on each poll as it can change with the files
+// themselves, like the files referenced by a manifest.
+type Watcher struct {
+	files    func() ([]string, error)
+	interval time.Duration
+
+	lastFiles []string
+	state     map[string]fileState
+}
+
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func New(files func() ([]string, error), interval time.Duration) *Watcher {
+	w := &Watcher{files: files, interval: interval}
+	w.poll()
+	return w
+}
+
+// Wait returns a command waiting for the next change of the files. A change
+// is only reported once the files stopped changing for an interval, so that
+// files being written by a build are reported once complete.
+func (w *Watcher) Wait() tea.Cmd {
+	return func() tea.Msg {
+		for {
+			time.Sleep(w.interval)
+			changed := w.poll()
+			if len(changed) == 0 {
+				continue
+			}
+			for {
+				time.Sleep(w.interval)
+				more := w.poll()
+				if len(more) == 0 {
+					break
+				}
+				changed = union(changed, more)
+			}
+			return ChangedMsg(changed)
+		}
+	}
+}
+
+// poll returns the files that changed since the previous poll, in order,
+// including the ones added to or removed from the list. The previous list is
+// kept when the list cannot be resolved.
+func (w *Watcher) poll() (changed []string) {
+	files, err := w.files()
+	if err != nil {
+		files = w.lastFiles
+	}
+	w.lastFiles = files
+
+	next := make(map[string]fileState, len(files))
+	for _, file := range files {
+		state := fileState{}
+		if info, err := os.Stat(file); err == nil {
+			state = fileState{exists: true, modTime: info.ModTime(), size: info.Size()}
+		}
+		next[file] = state
+
+		if prev, found := w.state[file]; w.state != nil && (!found || prev != state) {
+			changed = append(changed, file)
+		}
+	}
+	for file := range w.state {
+		if _, found := next[file]; !found {
+			changed = append(changed, file)
+		}
+	}
+	w.state = next
+
+	sort.Strings(changed)
+	return changed
+}
+
+func union(a, b []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, list := range [][]string{a, b} {
+		for _, file := range list {
+			if !seen[file] {
+				seen[file] = true
+				out = append(out, file)
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
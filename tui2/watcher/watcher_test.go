@@ -0,0 +1,60 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatcher_Poll(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "substreams.yaml")
+	binary := filepath.Join(dir, "acme.wasm")
+	proto := filepath.Join(dir, "acme.proto")
+	require.NoError(t, os.WriteFile(manifest, []byte("a"), 0644))
+	require.NoError(t, os.WriteFile(binary, []byte("a"), 0644))
+
+	files := []string{manifest, binary}
+	w := New(func() ([]string, error) { return files, nil }, time.Millisecond)
+	assert.Empty(t, w.poll())
+
+	require.NoError(t, os.WriteFile(binary, []byte("ab"), 0644))
+	assert.Equal(t, []string{binary}, w.poll())
+	assert.Empty(t, w.poll())
+
+	// a file added to the list, and one removed from it
+	require.NoError(t, os.WriteFile(proto, []byte("a"), 0644))
+	files = []string{manifest, proto}
+	assert.Equal(t, []string{proto, binary}, w.poll())
+
+	require.NoError(t, os.Remove(proto))
+	assert.Equal(t, []string{proto}, w.poll())
+
+	// errors resolving the list keep the previous one
+	files = nil
+	w.files = func() ([]string, error) { return nil, os.ErrNotExist }
+	require.NoError(t, os.WriteFile(proto, []byte("abc"), 0644))
+	assert.Equal(t, []string{proto}, w.poll())
+}
+
+func TestWatcher_Wait(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "acme.wasm")
+	require.NoError(t, os.WriteFile(binary, []byte("a"), 0644))
+
+	w := New(func() ([]string, error) { return []string{binary}, nil }, 10*time.Millisecond)
+	done := make(chan interface{})
+	go func() { done <- w.Wait()() }()
+
+	require.NoError(t, os.WriteFile(binary, []byte("ab"), 0644))
+	select {
+	case msg := <-done:
+		assert.Equal(t, ChangedMsg{binary}, msg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("change not reported")
+	}
+}
@@ -1,6 +1,7 @@
 package wasm
 
 import (
+	"encoding/binary"
 	"fmt"
 	"math/big"
 	"time"
@@ -134,10 +135,18 @@ func (c *Call) DoAppend(ord uint64, key string, value []byte) {
 	}
 }
 func (c *Call) DoDeletePrefix(ord uint64, prefix string) {
-	defer c.stats.RecordModuleWasmStoreDeletePrefix(c.ModuleName, c.outputStore.SizeBytes(), time.Since(time.Now()))
+	defer c.stats.RecordModuleWasmStoreDelete(c.ModuleName, c.outputStore.SizeBytes(), time.Since(time.Now()))
 	c.traceStateWrites("delete_prefix", prefix)
 	c.outputStore.DeletePrefix(ord, prefix)
 }
+func (c *Call) DoDeleteRange(ord uint64, lowKey, highKey string) {
+	start := time.Now()
+	defer func() {
+		c.stats.RecordModuleWasmStoreDelete(c.ModuleName, c.outputStore.SizeBytes(), time.Since(start))
+	}()
+	c.traceStateWrites("delete_range", fmt.Sprintf("[%s, %s)", lowKey, highKey))
+	c.outputStore.DeleteRange(ord, lowKey, highKey)
+}
 func (c *Call) DoAddBigInt(ord uint64, key string, value string) {
 	defer c.stats.RecordModuleWasmStoreWrite(c.ModuleName, c.outputStore.SizeBytes(), time.Since(time.Now()))
 	c.validateWithValueType("add_bigint", pbsubstreams.Module_KindStore_UPDATE_POLICY_ADD, "bigint", key)
@@ -265,6 +274,50 @@ func (c *Call) DoHasLast(storeIndex int, key string) (found bool) {
 	return readStore.HasLast(key)
 }
 
+func (c *Call) DoScanPrefix(storeIndex int, prefix string, limit uint64) (entries []store.KV) {
+	start := time.Now()
+	defer func() {
+		c.stats.RecordModuleWasmStoreRead(c.ModuleName, time.Since(start))
+	}()
+	c.validateStoreIndex(storeIndex, "scan_prefix")
+	readStore := c.inputStores[storeIndex]
+	entries = readStore.ScanPrefix(prefix, limit)
+	c.traceStateReads("scan_prefix", storeIndex, len(entries) > 0, prefix)
+	return entries
+}
+
+func (c *Call) DoScanRange(storeIndex int, lowKey, highKey string, limit uint64) (entries []store.KV) {
+	start := time.Now()
+	defer func() {
+		c.stats.RecordModuleWasmStoreRead(c.ModuleName, time.Since(start))
+	}()
+	c.validateStoreIndex(storeIndex, "scan_range")
+	readStore := c.inputStores[storeIndex]
+	entries = readStore.ScanRange(lowKey, highKey, limit)
+	c.traceStateReads("scan_range", storeIndex, len(entries) > 0, fmt.Sprintf("[%s, %s)", lowKey, highKey))
+	return entries
+}
+
+// EncodeKVs encodes the entries returned by the store scans as they are
+// handed to the wasm module: for each entry, the key length as a
+// little-endian uint32, the key, the value length as a little-endian uint32
+// and the value.
+func EncodeKVs(entries []store.KV) []byte {
+	size := 0
+	for _, entry := range entries {
+		size += 8 + len(entry.Key) + len(entry.Value)
+	}
+
+	out := make([]byte, 0, size)
+	for _, entry := range entries {
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(entry.Key)))
+		out = append(out, entry.Key...)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(entry.Value)))
+		out = append(out, entry.Value...)
+	}
+	return out
+}
+
 func (c *Call) validateStoreIndex(storeIndex int, stateFunc string) {
 	if storeIndex+1 > len(c.inputStores) {
 		c.ReturnError(fmt.Errorf("%q failed: invalid store index %d, %d stores declared", stateFunc, storeIndex, len(c.inputStores)))
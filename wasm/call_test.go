@@ -344,6 +344,30 @@ func Test_CallStoreOps(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"delete_range golden path",
+			newTestCall(pbsubstreams.Module_KindStore_UPDATE_POLICY_ADD, "int64"),
+			func(c *Call) {
+				c.DoDeleteRange(0, "a", "b")
+			},
+			true,
+		},
+		{
+			"scan_prefix invalid store index",
+			newTestCall(pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string"),
+			func(c *Call) {
+				c.DoScanPrefix(0, "key", 10)
+			},
+			false,
+		},
+		{
+			"scan_range invalid store index",
+			newTestCall(pbsubstreams.Module_KindStore_UPDATE_POLICY_SET, "string"),
+			func(c *Call) {
+				c.DoScanRange(1, "a", "b", 10)
+			},
+			false,
+		},
 		{
 			"set_max_int64 golden path",
 			newTestCall(pbsubstreams.Module_KindStore_UPDATE_POLICY_MAX, "int64"),
@@ -480,6 +504,17 @@ func Test_CallStoreOps(t *testing.T) {
 	}
 }
 
+func Test_EncodeKVs(t *testing.T) {
+	assert.Empty(t, EncodeKVs(nil))
+	assert.Equal(t, []byte{
+		1, 0, 0, 0, 'a', 2, 0, 0, 0, 'v', '1',
+		2, 0, 0, 0, 'a', 'b', 0, 0, 0, 0,
+	}, EncodeKVs([]store.KV{
+		{Key: "a", Value: []byte("v1")},
+		{Key: "ab"},
+	}))
+}
+
 func newTestCall(updatePolicy pbsubstreams.Module_KindStore_UpdatePolicy, valueType string) *Call {
 	myStore := dstore.NewMockStore(nil)
 	storeConf, err := store.NewConfig("test", 0, "", updatePolicy, valueType, myStore, "test")
@@ -117,6 +117,7 @@ func (i *instance) registerStateImports(linker *wasmtime.Linker) error {
 	functions["set_if_not_exists"] = i.setIfNotExists
 	functions["append"] = i.append
 	functions["delete_prefix"] = i.deletePrefix
+	functions["delete_range"] = i.deleteRange
 	functions["add_bigint"] = i.addBigInt
 	functions["add_bigdecimal"] = i.addBigDecimal
 	functions["add_bigfloat"] = i.addBigDecimal
@@ -138,6 +139,8 @@ func (i *instance) registerStateImports(linker *wasmtime.Linker) error {
 	functions["has_at"] = i.hasAt
 	functions["has_first"] = i.hasFirst
 	functions["has_last"] = i.hasLast
+	functions["scan_prefix"] = i.scanPrefix
+	functions["scan_range"] = i.scanRange
 
 	for n, f := range functions {
 		if err := linker.FuncWrap("state", n, f); err != nil {
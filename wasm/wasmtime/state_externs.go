@@ -2,6 +2,9 @@ package wasmtime
 
 import (
 	"fmt"
+
+	"github.com/streamingfast/substreams/storage/store"
+	"github.com/streamingfast/substreams/wasm"
 )
 
 func (i *instance) set(ord int64, keyPtr, keyLength, valPtr, valLength int32) {
@@ -27,6 +30,12 @@ func (i *instance) deletePrefix(ord int64, keyPtr, keyLength int32) {
 	i.CurrentCall.DoDeletePrefix(uint64(ord), prefix)
 }
 
+func (i *instance) deleteRange(ord int64, lowPtr, lowLength, highPtr, highLength int32) {
+	lowKey := i.Heap.ReadString(lowPtr, lowLength)
+	highKey := i.Heap.ReadString(highPtr, highLength)
+	i.CurrentCall.DoDeleteRange(uint64(ord), lowKey, highKey)
+}
+
 func (i *instance) addBigInt(ord int64, keyPtr, keyLength, valPtr, valLength int32) {
 	key := i.Heap.ReadString(keyPtr, keyLength)
 	value := i.Heap.ReadString(valPtr, valLength)
@@ -129,6 +138,29 @@ func (i *instance) hasLast(storeIndex int32, keyPtr, keyLength int32) int32 {
 	return returnIfFound(found)
 }
 
+func (i *instance) scanPrefix(storeIndex int32, prefixPtr, prefixLength, limit, outputPtr int32) int32 {
+	prefix := i.Heap.ReadString(prefixPtr, prefixLength)
+	entries := i.CurrentCall.DoScanPrefix(int(storeIndex), prefix, uint64(uint32(limit)))
+	return writeEntriesToHeap(i, outputPtr, entries)
+}
+
+func (i *instance) scanRange(storeIndex int32, lowPtr, lowLength, highPtr, highLength, limit, outputPtr int32) int32 {
+	lowKey := i.Heap.ReadString(lowPtr, lowLength)
+	highKey := i.Heap.ReadString(highPtr, highLength)
+	entries := i.CurrentCall.DoScanRange(int(storeIndex), lowKey, highKey, uint64(uint32(limit)))
+	return writeEntriesToHeap(i, outputPtr, entries)
+}
+
+func writeEntriesToHeap(i *instance, outputPtr int32, entries []store.KV) int32 {
+	if len(entries) == 0 {
+		return 0
+	}
+	if err := writeOutputToHeap(i, outputPtr, wasm.EncodeKVs(entries)); err != nil {
+		i.CurrentCall.ReturnError(fmt.Errorf("writing output to heap: %w", err))
+	}
+	return int32(len(entries))
+}
+
 func writeToHeapIfFound(i *instance, outputPtr int32, value []byte, found bool) int32 {
 	if !found {
 		return 0
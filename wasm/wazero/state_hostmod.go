@@ -6,6 +6,7 @@ import (
 
 	"github.com/tetratelabs/wazero/api"
 
+	"github.com/streamingfast/substreams/storage/store"
 	"github.com/streamingfast/substreams/wasm"
 )
 
@@ -75,6 +76,19 @@ var stateFuncs = []funcs{
 			call.DoDeletePrefix(ord, prefix)
 		}),
 	},
+	{
+		"delete_range",
+		[]parm{i64, i32, i32, i32, i32},
+		[]parm{},
+		api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+			ord := stack[0]
+			lowKey := readStringFromStack(mod, stack[1:])
+			highKey := readStringFromStack(mod, stack[3:])
+			call := wasm.FromContext(ctx)
+
+			call.DoDeleteRange(ord, lowKey, highKey)
+		}),
+	},
 	{
 		"add_bigint",
 		[]parm{i64, i32, i32, i32, i32},
@@ -321,6 +335,50 @@ var stateFuncs = []funcs{
 			setStack0Bool(stack, found)
 		}),
 	},
+	{
+		"scan_prefix",
+		[]parm{i32, i32, i32, i32, i32},
+		[]parm{i32},
+		api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+			storeIndex := uint32(stack[0])
+			prefix := readStringFromStack(mod, stack[1:])
+			limit := uint32(stack[3])
+			outputPtr := uint32(stack[4])
+			call := wasm.FromContext(ctx)
+			inst := instanceFromContext(ctx)
+
+			entries := call.DoScanPrefix(int(storeIndex), prefix, uint64(limit))
+			setStackAndOutputEntries(ctx, stack, call, inst, outputPtr, entries)
+		}),
+	},
+	{
+		"scan_range",
+		[]parm{i32, i32, i32, i32, i32, i32, i32},
+		[]parm{i32},
+		api.GoModuleFunc(func(ctx context.Context, mod api.Module, stack []uint64) {
+			storeIndex := uint32(stack[0])
+			lowKey := readStringFromStack(mod, stack[1:])
+			highKey := readStringFromStack(mod, stack[3:])
+			limit := uint32(stack[5])
+			outputPtr := uint32(stack[6])
+			call := wasm.FromContext(ctx)
+			inst := instanceFromContext(ctx)
+
+			entries := call.DoScanRange(int(storeIndex), lowKey, highKey, uint64(limit))
+			setStackAndOutputEntries(ctx, stack, call, inst, outputPtr, entries)
+		}),
+	},
+}
+
+// setStackAndOutputEntries returns the number of entries found, writing
+// them to the output when there are any.
+func setStackAndOutputEntries(ctx context.Context, stack []uint64, call *wasm.Call, inst *instance, outputPtr uint32, entries []store.KV) {
+	if len(entries) != 0 {
+		if err := writeOutputToHeap(ctx, inst, outputPtr, wasm.EncodeKVs(entries)); err != nil {
+			call.ReturnError(fmt.Errorf("writing output to heap: %w", err))
+		}
+	}
+	stack[0] = uint64(len(entries))
 }
 
 func setStackAndOutput(ctx context.Context, stack []uint64, call *wasm.Call, found bool, inst *instance, outputPtr uint32, value []byte) {